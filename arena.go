@@ -0,0 +1,271 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// arenaChunkSize is the number of objects allocated together in one slab chunk. It is large enough
+// to turn millions of tiny allocations into a few thousand, yet small enough that a short parse does not
+// pin a lot of memory.
+const arenaChunkSize = 1024
+
+// arenaSlab is a bump allocator for a single type. Objects are handed out from the current chunk
+// until it is exhausted, at which point a new chunk is allocated. Chunks are never reused, except by
+// a slab that keeps its chunks for recycle.
+type arenaSlab[T any] struct {
+	chunk []T
+	used  int
+	count int
+
+	// chunks holds every chunk allocated, if keep is true, and next is the index in it of the chunk
+	// after the current one
+	keep   bool
+	chunks [][]T
+	next   int
+}
+
+func (s *arenaSlab[T]) alloc() *T {
+	if s.used == len(s.chunk) {
+		s.nextChunk()
+	}
+	t := &s.chunk[s.used]
+	s.used++
+	s.count++
+	return t
+}
+
+func (s *arenaSlab[T]) nextChunk() {
+	s.used = 0
+	if !s.keep {
+		s.chunk = make([]T, arenaChunkSize)
+		return
+	}
+	if s.next == len(s.chunks) {
+		s.chunks = append(s.chunks, make([]T, arenaChunkSize))
+	}
+	s.chunk = s.chunks[s.next]
+	s.next++
+}
+
+// recycle zeroes the objects handed out by a slab that keeps its chunks, and rewinds it, so that it hands
+// them out again. Nothing may refer to the objects any more.
+func (s *arenaSlab[T]) recycle() {
+	for _, chunk := range s.chunks[:s.next] {
+		clear(chunk)
+	}
+	s.chunk = nil
+	s.used = 0
+	s.count = 0
+	s.next = 0
+}
+
+// Arena is an optional bump allocator scoped to a single parse. When a recognizer is given an
+// Arena, the [ATNConfig] objects created by its ATN simulator, the [PredictionContext] objects that the
+// simulator pushes as it enters rules, and the [CommonToken] objects created by its token factory are carved
+// out of large chunks rather than being allocated one by one. This greatly reduces the number of objects the
+// garbage collector must track, which matters for services where GC pauses caused by millions of tiny parser
+// objects are the bottleneck. The contexts built by merging those contexts, which are fewer, are still
+// allocated on the heap.
+//
+// An Arena is not safe for concurrent use, so use one Arena per parse (or per goroutine). Once the
+// parse tree and tokens are no longer needed, call [Arena.Release] to drop the arena's chunks
+// wholesale.
+//
+// An Arena made by [NewArena] never reuses memory: Release only forgets the chunks. Any object that
+// is still referenced, such as a token held by a parse tree or a configuration cached in a DFA state
+// that is shared between parsers, keeps its chunk alive and remains valid. It is therefore always safe
+// to use an arena, even with the shared DFA cache, though objects retained by the DFA will keep their
+// chunk in memory for as long as the DFA lives.
+//
+// The pool of [ParserATNSimulator.SetConfigPooling] is the one place that reuses arena memory: it
+// zeroes the [ATNConfig] objects of a full context prediction once the prediction succeeds, and hands
+// them out to the next. Its prediction contexts and tokens are never reused. This is safe because a
+// full context prediction stores only its predicted alternative in the DFA, never a configuration or
+// a configuration set.
+//
+// Use:
+//
+//	arena := antlr.NewArena()
+//	lexer := parser.NewMyLexer(input)
+//	lexer.SetArena(arena)
+//	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+//	p := parser.NewMyParser(stream)
+//	p.SetArena(arena)
+//	tree := p.Start()
+//	// ... use tree ...
+//	arena.Release()
+type Arena struct {
+	configs  arenaSlab[ATNConfig]
+	contexts arenaSlab[PredictionContext]
+	tokens   arenaSlab[CommonToken]
+}
+
+// NewArena creates a new, empty [Arena].
+func NewArena() *Arena {
+	return &Arena{}
+}
+
+// Release drops all chunks held by the arena so that the garbage collector can reclaim them once
+// nothing else refers to the objects they contain. The arena may be used again after Release.
+func (a *Arena) Release() {
+	a.configs = arenaSlab[ATNConfig]{}
+	a.contexts = arenaSlab[PredictionContext]{}
+	a.tokens = arenaSlab[CommonToken]{}
+}
+
+// ConfigCount returns the number of [ATNConfig] objects allocated from the arena since it was created
+// or last released.
+func (a *Arena) ConfigCount() int {
+	return a.configs.count
+}
+
+// ContextCount returns the number of [PredictionContext] objects allocated from the arena since it was
+// created or last released.
+func (a *Arena) ContextCount() int {
+	return a.contexts.count
+}
+
+// TokenCount returns the number of [CommonToken] objects allocated from the arena since it was created
+// or last released.
+func (a *Arena) TokenCount() int {
+	return a.tokens.count
+}
+
+// allocConfig returns a zeroed ATNConfig. It is safe to call on a nil arena, in which case the
+// configuration is allocated on the heap as normal.
+func (a *Arena) allocConfig() *ATNConfig {
+	if a == nil {
+		return &ATNConfig{}
+	}
+	return a.configs.alloc()
+}
+
+// allocToken returns a zeroed CommonToken. It is safe to call on a nil arena.
+func (a *Arena) allocToken() *CommonToken {
+	if a == nil {
+		return &CommonToken{}
+	}
+	return a.tokens.alloc()
+}
+
+// newSingletonContext mirrors SingletonBasePredictionContextCreate, but allocates from the arena. It is safe
+// to call on a nil arena.
+func (a *Arena) newSingletonContext(parent *PredictionContext, returnState int) *PredictionContext {
+	if a == nil || returnState == BasePredictionContextEmptyReturnState && parent == nil {
+		return SingletonBasePredictionContextCreate(parent, returnState)
+	}
+	pc := a.contexts.alloc()
+	pc.initSingleton(parent, returnState)
+	return pc
+}
+
+// The following mirror the NewATNConfig and NewLexerATNConfig constructors, but allocate from the arena.
+// They are used by the ATN simulators, which hold a possibly nil arena.
+
+func (a *Arena) newATNConfig(c *ATNConfig, state ATNState, context *PredictionContext, semanticContext SemanticContext) *ATNConfig {
+	b := a.allocConfig()
+	b.InitATNConfig(c, state, c.GetAlt(), context, semanticContext)
+	b.cType = parserConfig
+	return b
+}
+
+func (a *Arena) newATNConfig6(state ATNState, alt int, context *PredictionContext) *ATNConfig {
+	return a.newATNConfig5(state, alt, context, SemanticContextNone)
+}
+
+func (a *Arena) newATNConfig5(state ATNState, alt int, context *PredictionContext, semanticContext SemanticContext) *ATNConfig {
+	if semanticContext == nil {
+		panic("semanticContext cannot be nil")
+	}
+	b := a.allocConfig()
+	b.state = state
+	b.alt = alt
+	b.context = context
+	b.semanticContext = semanticContext
+	b.cType = parserConfig
+	return b
+}
+
+func (a *Arena) newATNConfig4(c *ATNConfig, state ATNState) *ATNConfig {
+	return a.newATNConfig(c, state, c.GetContext(), c.GetSemanticContext())
+}
+
+func (a *Arena) newATNConfig3(c *ATNConfig, state ATNState, semanticContext SemanticContext) *ATNConfig {
+	return a.newATNConfig(c, state, c.GetContext(), semanticContext)
+}
+
+func (a *Arena) newATNConfig2(c *ATNConfig, semanticContext SemanticContext) *ATNConfig {
+	return a.newATNConfig(c, c.GetState(), c.GetContext(), semanticContext)
+}
+
+func (a *Arena) newATNConfig1(c *ATNConfig, state ATNState, context *PredictionContext) *ATNConfig {
+	return a.newATNConfig(c, state, context, c.GetSemanticContext())
+}
+
+func (a *Arena) newLexerATNConfig6(state ATNState, alt int, context *PredictionContext) *ATNConfig {
+	lac := a.allocConfig()
+	lac.state = state
+	lac.alt = alt
+	lac.context = context
+	lac.semanticContext = SemanticContextNone
+	lac.cType = lexerConfig
+	return lac
+}
+
+func (a *Arena) newLexerATNConfig(c *ATNConfig, state ATNState, context *PredictionContext, lexerActionExecutor *LexerActionExecutor) *ATNConfig {
+	lac := a.allocConfig()
+	lac.lexerActionExecutor = lexerActionExecutor
+	lac.passedThroughNonGreedyDecision = checkNonGreedyDecision(c, state)
+	lac.InitATNConfig(c, state, c.GetAlt(), context, c.GetSemanticContext())
+	lac.cType = lexerConfig
+	return lac
+}
+
+func (a *Arena) newLexerATNConfig4(c *ATNConfig, state ATNState) *ATNConfig {
+	return a.newLexerATNConfig(c, state, c.GetContext(), c.lexerActionExecutor)
+}
+
+func (a *Arena) newLexerATNConfig3(c *ATNConfig, state ATNState, lexerActionExecutor *LexerActionExecutor) *ATNConfig {
+	return a.newLexerATNConfig(c, state, c.GetContext(), lexerActionExecutor)
+}
+
+func (a *Arena) newLexerATNConfig2(c *ATNConfig, state ATNState, context *PredictionContext) *ATNConfig {
+	return a.newLexerATNConfig(c, state, context, c.lexerActionExecutor)
+}
+
+// ArenaTokenFactory is a [TokenFactory] that allocates its [CommonToken] objects from an [Arena].
+// It behaves exactly like [CommonTokenFactory] otherwise.
+type ArenaTokenFactory struct {
+	CommonTokenFactory
+	arena *Arena
+}
+
+// NewArenaTokenFactory creates a token factory that allocates tokens from the given arena. See
+// [CommonTokenFactory] for the meaning of copyText.
+func NewArenaTokenFactory(arena *Arena, copyText bool) *ArenaTokenFactory {
+	return &ArenaTokenFactory{
+		CommonTokenFactory: CommonTokenFactory{copyText: copyText},
+		arena:              arena,
+	}
+}
+
+// Create creates a new token from the factory's arena.
+func (f *ArenaTokenFactory) Create(source *TokenSourceCharStreamPair, ttype int, text string, channel, start, stop, line, column int) Token {
+	t := f.arena.allocToken()
+	t.source = source
+	t.tokenType = ttype
+	t.channel = channel
+	t.start = start
+	t.stop = stop
+	t.tokenIndex = -1
+	t.line = line
+	t.column = column
+
+	if text != "" {
+		t.SetText(text)
+	} else if f.copyText && source.charStream != nil {
+		t.SetText(source.charStream.GetTextFromInterval(NewInterval(start, stop)))
+	}
+
+	return t
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestArena(t *testing.T) {
+	const input = "a b+c d e+f"
+	parse := func(arena *Arena) (string, []Token) {
+		lexer := newListLexer(NewInputStream(input))
+		lexer.Interpreter = NewLexerATNSimulator(lexer, lexer.GetATN(), newDFA(lexer.GetATN()), NewPredictionContextCache())
+		if arena != nil {
+			lexer.SetArena(arena)
+		}
+		stream := NewCommonTokenStream(lexer, TokenDefaultChannel)
+		p := newListParser(stream)
+		p.Interpreter = NewParserATNSimulator(p, p.GetATN(), newDFA(p.GetATN()), NewPredictionContextCache())
+		if arena != nil {
+			p.SetArena(arena)
+		}
+		return p.S().ToStringTree(nil, p), stream.GetAllTokens()
+	}
+
+	want, _ := parse(nil)
+	arena := NewArena()
+	got, tokens := parse(arena)
+	if got != want {
+		t.Fatalf("tree with an arena %s, want %s", got, want)
+	}
+	// a b + c d e + f and EOF
+	if arena.TokenCount() != 9 || len(tokens) != 9 {
+		t.Errorf("%d tokens from the arena for %d tokens", arena.TokenCount(), len(tokens))
+	}
+	if arena.ConfigCount() == 0 || arena.ContextCount() == 0 {
+		t.Errorf("%d configurations and %d contexts from the arena", arena.ConfigCount(), arena.ContextCount())
+	}
+
+	// The tokens of a released arena remain valid
+	arena.Release()
+	if arena.ConfigCount() != 0 || arena.ContextCount() != 0 || arena.TokenCount() != 0 {
+		t.Error("Release kept objects")
+	}
+	if tokens[2].GetText() != "+" || tokens[3].GetStart() != 4 {
+		t.Errorf("token %s after Release", tokens[2])
+	}
+	if again, _ := parse(arena); again != want {
+		t.Errorf("tree from a released arena %s, want %s", again, want)
+	}
+
+	arena.Release()
+	lexer := newListLexer(NewInputStream(input))
+	lexer.SetArena(arena)
+	lexer.SetArena(nil)
+	lexer.NextToken()
+	if arena.TokenCount() != 0 || lexer.GetTokenFactory() != CommonTokenFactoryDEFAULT {
+		t.Error("SetArena(nil) kept the arena")
+	}
+}
+
+func TestArenaTokenFactory(t *testing.T) {
+	arena := NewArena()
+	input := NewInputStream("abc")
+	source := &TokenSourceCharStreamPair{charStream: input}
+	token := NewArenaTokenFactory(arena, true).Create(source, listID, "", TokenDefaultChannel, 1, 2, 1, 1)
+	if token.GetText() != "bc" || token.GetTokenIndex() != -1 || token.GetTokenType() != listID {
+		t.Errorf("token %s", token)
+	}
+	if token := NewArenaTokenFactory(arena, false).Create(source, listID, "x", TokenDefaultChannel, 1, 2, 1, 1); token.GetText() != "x" {
+		t.Errorf("token text %q, want x", token.GetText())
+	}
+	if arena.TokenCount() != 2 {
+		t.Errorf("%d tokens from the arena, want 2", arena.TokenCount())
+	}
+}
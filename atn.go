@@ -54,6 +54,31 @@ type ATN struct {
 	//
 	states []ATNState
 
+	// lexerAlphabet is the equivalence class compression of the input alphabet for
+	// lexer ATNs. It is computed lazily by getLexerAlphabet.
+	lexerAlphabet *lexerAlphabet
+
+	// lexerSkipLoops holds the skipped tokens of each mode of a lexer ATN that are simple character
+	// class loops. It is computed lazily by getSkipLoops.
+	lexerSkipLoops []*lexerSkipLoop
+
+	// observer is notified of each state visited, see SetStateObserver
+	observer StateObserverFunc
+
+	// expectedCache caches the results of getExpectedTokens, if enabled with SetExpectedTokensCacheSize
+	expectedCache *expectedTokensCache
+
+	// serialized is the serialized form the ATN was deserialized from, from which getBypassAltsATN
+	// deserializes a copy with bypass alternatives.
+	serialized []int32
+
+	// fingerprint is the fingerprint of serialized, see Fingerprint
+	fingerprint GrammarFingerprint
+
+	// bypassAltsATN is the copy of a parser ATN with bypass alternatives. It is created lazily by
+	// getBypassAltsATN.
+	bypassAltsATN *ATN
+
 	mu      Mutex
 	stateMu RWMutex
 	edgeMu  RWMutex
@@ -69,10 +94,31 @@ func NewATN(grammarType int, maxTokenType int) *ATN {
 	}
 }
 
+// ReleaseCaches discards what the ATN has computed and cached as recognizers used it: the sets of tokens that
+// can follow each state, the cached sets of expected tokens, see [ATN.SetExpectedTokensCacheSize], which stays
+// enabled, and the copy made to match tree patterns. They are computed again as they are needed. The DFA of
+// the recognizers is not held by the ATN; see [ParserATNSimulator.ClearDFA] and [LexerATNSimulator.ClearDFA].
+// Call it between uses, when no recognizer that uses the ATN is running.
+func (a *ATN) ReleaseCaches() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, s := range a.states {
+		if s != nil {
+			s.SetNextTokenWithinRule(nil)
+		}
+	}
+	a.bypassAltsATN = nil
+	if a.expectedCache != nil {
+		a.SetExpectedTokensCacheSize(a.expectedCache.size)
+	}
+}
+
 // NextTokensInContext computes and returns the set of valid tokens that can occur starting
 // in state s. If ctx is nil, the set of tokens will not include what can follow
 // the rule surrounding s. In other words, the set will be restricted to tokens
-// reachable staying within the rule of s.
+// reachable staying within the rule of s. If ctx is the empty context, [TokenEOF] is in the set
+// if the end of the outermost rule can be reached. See [IsEmptyContext].
 func (a *ATN) NextTokensInContext(s ATNState, ctx RuleContext) *IntervalSet {
 	return NewLL1Analyzer(a).Look(s, nil, ctx)
 }
@@ -94,14 +140,29 @@ func (a *ATN) NextTokensNoContext(s ATNState) *IntervalSet {
 
 // NextTokens computes and returns the set of valid tokens starting in state s, by
 // calling either [NextTokensNoContext] (ctx == nil)  or [NextTokensInContext] (ctx != nil).
+// A ctx holding a nil pointer is treated as nil.
 func (a *ATN) NextTokens(s ATNState, ctx RuleContext) *IntervalSet {
-	if ctx == nil {
+	if isNilContext(ctx) {
 		return a.NextTokensNoContext(s)
 	}
 
 	return a.NextTokensInContext(s, ctx)
 }
 
+// getBypassAltsATN returns a copy of this parser [ATN] with a bypass alternative for each rule, which matches
+// the imaginary token type ruleToTokenType[rule] in place of the whole rule, deserializing it the first time
+// it is requested. It returns nil if the ATN was not deserialized, as only the serialized form can be copied.
+func (a *ATN) getBypassAltsATN() *ATN {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.bypassAltsATN == nil && a.serialized != nil {
+		options := DefaultATNDeserializationOptions()
+		options.SetGenerateRuleBypassTransitions(true)
+		a.bypassAltsATN = NewATNDeserializer(options).Deserialize(a.serialized)
+	}
+	return a.bypassAltsATN
+}
+
 func (a *ATN) addState(state ATNState) {
 	if state != nil {
 		state.SetATN(a)
@@ -130,6 +191,26 @@ func (a *ATN) getDecisionState(decision int) DecisionState {
 	return a.DecisionToState[decision]
 }
 
+// getRuleBlock returns the block of the outer alternatives of the rule with the given index, or nil if the
+// rule has only one alternative. A rule with one alternative may begin with a subrule, whose block is
+// reached from the rule's start state too, so the block must also end at the rule's stop state.
+func (a *ATN) getRuleBlock(ruleIndex int) *BasicBlockStartState {
+	start := a.ruleToStartState[ruleIndex]
+	if start == nil || len(start.GetTransitions()) != 1 {
+		return nil
+	}
+	block, ok := start.GetTransitions()[0].getTarget().(*BasicBlockStartState)
+	if !ok || len(block.GetTransitions()) < 2 || block.endState == nil {
+		return nil
+	}
+	for _, t := range block.endState.GetTransitions() {
+		if t.getTarget() == a.ruleToStopState[ruleIndex] {
+			return block
+		}
+	}
+	return nil
+}
+
 // getExpectedTokens computes the set of input symbols which could follow ATN
 // state number stateNumber in the specified full parse context ctx and returns
 // the set of potentially valid input symbols which could follow the specified
@@ -147,6 +228,18 @@ func (a *ATN) getExpectedTokens(stateNumber int, ctx RuleContext) *IntervalSet {
 		panic("Invalid state number.")
 	}
 
+	if isNilContext(ctx) {
+		ctx = nil
+	}
+	if c := a.expectedCache; c != nil {
+		return c.get(a, stateNumber, ctx)
+	}
+	return a.computeExpectedTokens(stateNumber, ctx)
+}
+
+// computeExpectedTokens computes the set returned by getExpectedTokens, for a valid state number and a ctx
+// that is either nil or not a nil pointer.
+func (a *ATN) computeExpectedTokens(stateNumber int, ctx RuleContext) *IntervalSet {
 	s := a.states[stateNumber]
 	following := a.NextTokens(s, nil)
 
@@ -166,7 +259,7 @@ func (a *ATN) getExpectedTokens(stateNumber int, ctx RuleContext) *IntervalSet {
 		following = a.NextTokens(rt.(*RuleTransition).followState, nil)
 		expected.addSet(following)
 		expected.removeOne(TokenEpsilon)
-		ctx = ctx.GetParent().(RuleContext)
+		ctx = parentContext(ctx)
 	}
 
 	if following.contains(TokenEpsilon) {
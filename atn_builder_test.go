@@ -0,0 +1,162 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// The tests that need a grammar other than the list grammar build its parser ATN from its rules, and serialize
+// it as the ANTLR tool would, so that it is deserialized like that of a generated parser.
+
+// atnElement is an element of an alternative of a rule: a token, a rule reference, or a block of
+// alternatives, which may be optional or repeated.
+type atnElement struct {
+	token int
+	rule  int
+	alts  [][]atnElement
+	op    byte // 0 for a token or rule reference, '(' for a block, '?' or '*'
+}
+
+func bTok(t int) atnElement  { return atnElement{token: t, rule: -1} }
+func bRule(r int) atnElement { return atnElement{rule: r} }
+func bBlock(op byte, alts ...[]atnElement) atnElement {
+	return atnElement{rule: -1, alts: alts, op: op}
+}
+func bAlt(elements ...atnElement) []atnElement { return elements }
+
+// atnBuilder serializes the parser ATN of rules, whose tokens are numbered up to maxTokenType.
+type atnBuilder struct {
+	rules        [][][]atnElement
+	maxTokenType int
+
+	states    [][]int32
+	edges     [][6]int32
+	decisions []int32
+	starts    []int
+}
+
+func (b *atnBuilder) state(stateType, rule int) int {
+	b.states = append(b.states, []int32{int32(stateType), int32(rule)})
+	return len(b.states) - 1
+}
+
+func (b *atnBuilder) edge(from, to, transitionType, arg1, arg2, arg3 int) {
+	b.edges = append(b.edges, [6]int32{int32(from), int32(to), int32(transitionType), int32(arg1), int32(arg2), int32(arg3)})
+}
+
+func (b *atnBuilder) epsilon(from, to int) {
+	b.edge(from, to, TransitionEPSILON, 0, 0, 0)
+}
+
+// sequence builds the states of the elements of an alternative, and returns the first and the last.
+func (b *atnBuilder) sequence(rule int, elements []atnElement) (int, int) {
+	if len(elements) == 0 {
+		s := b.state(ATNStateBasic, rule)
+		return s, s
+	}
+	first, last := -1, -1
+	for _, e := range elements {
+		left, right := b.element(rule, e)
+		if first < 0 {
+			first = left
+		} else {
+			b.epsilon(last, left)
+		}
+		last = right
+	}
+	return first, last
+}
+
+// block builds a block of alternatives from the block start state start, which is a decision if it has more
+// than one alternative, and returns its end state.
+func (b *atnBuilder) block(rule, start int, alts [][]atnElement) int {
+	end := b.state(ATNStateBlockEnd, rule)
+	b.states[start] = append(b.states[start], int32(end))
+	for _, alt := range alts {
+		left, right := b.sequence(rule, alt)
+		b.epsilon(start, left)
+		b.epsilon(right, end)
+	}
+	return end
+}
+
+func (b *atnBuilder) element(rule int, e atnElement) (int, int) {
+	switch e.op {
+	case '(':
+		start := b.state(ATNStateBlockStart, rule)
+		b.decisions = append(b.decisions, int32(start))
+		return start, b.block(rule, start, e.alts)
+	case '?':
+		start := b.state(ATNStateBlockStart, rule)
+		b.decisions = append(b.decisions, int32(start))
+		end := b.block(rule, start, e.alts)
+		b.epsilon(start, end)
+		return start, end
+	case '*':
+		entry := b.state(ATNStateStarLoopEntry, rule)
+		b.decisions = append(b.decisions, int32(entry))
+		start := b.state(ATNStateStarBlockStart, rule)
+		if len(e.alts) > 1 {
+			b.decisions = append(b.decisions, int32(start))
+		}
+		end := b.block(rule, start, e.alts)
+		loopBack := b.state(ATNStateStarLoopBack, rule)
+		loopEnd := b.state(ATNStateLoopEnd, rule)
+		b.states[loopEnd] = append(b.states[loopEnd], int32(loopBack))
+		b.epsilon(entry, start)
+		b.epsilon(entry, loopEnd)
+		b.epsilon(end, loopBack)
+		b.epsilon(loopBack, entry)
+		return entry, loopEnd
+	}
+	left, right := b.state(ATNStateBasic, rule), b.state(ATNStateBasic, rule)
+	switch {
+	case e.rule >= 0:
+		b.edge(left, right, TransitionRULE, b.starts[e.rule], e.rule, 0)
+	case e.token == TokenEOF:
+		b.edge(left, right, TransitionATOM, 0, 0, 1)
+	default:
+		b.edge(left, right, TransitionATOM, e.token, 0, 0)
+	}
+	return left, right
+}
+
+func (b *atnBuilder) serialize() []int32 {
+	stops := make([]int, len(b.rules))
+	for r := range b.rules {
+		b.starts = append(b.starts, b.state(ATNStateRuleStart, r))
+		stops[r] = b.state(ATNStateRuleStop, r)
+	}
+	for r, alts := range b.rules {
+		if len(alts) == 1 {
+			left, right := b.sequence(r, alts[0])
+			b.epsilon(b.starts[r], left)
+			b.epsilon(right, stops[r])
+			continue
+		}
+		start := b.state(ATNStateBlockStart, r)
+		b.decisions = append(b.decisions, int32(start))
+		b.epsilon(b.starts[r], start)
+		b.epsilon(b.block(r, start, alts), stops[r])
+	}
+
+	data := []int32{4, ATNTypeParser, int32(b.maxTokenType), int32(len(b.states))}
+	for _, s := range b.states {
+		data = append(data, s...)
+	}
+	data = append(data, 0, 0, int32(len(b.starts)))
+	for _, s := range b.starts {
+		data = append(data, int32(s))
+	}
+	data = append(data, 0, 0, int32(len(b.edges)))
+	for _, e := range b.edges {
+		data = append(data, e[:]...)
+	}
+	data = append(data, int32(len(b.decisions)))
+	return append(data, b.decisions...)
+}
+
+// buildATN returns the deserialized parser ATN of rules, whose tokens are numbered up to maxTokenType.
+func buildATN(maxTokenType int, rules [][][]atnElement) *ATN {
+	b := &atnBuilder{rules: rules, maxTokenType: maxTokenType}
+	return NewATNDeserializer(nil).Deserialize(b.serialize())
+}
@@ -19,7 +19,7 @@ type ATNConfigSet struct {
 	// effectively doubles the number of objects associated with ATNConfigs. All
 	// keys are hashed by (s, i, _, pi), not including the context. Wiped out when
 	// read-only because a set becomes a DFA state.
-	configLookup *JStore[*ATNConfig, Comparator[*ATNConfig]]
+	configLookup *configTable
 
 	// configs is the added elements that did not match an existing key in configLookup
 	configs []*ATNConfig
@@ -68,7 +68,7 @@ func (b *ATNConfigSet) Alts() *BitSet {
 func NewATNConfigSet(fullCtx bool) *ATNConfigSet {
 	return &ATNConfigSet{
 		cachedHash:   -1,
-		configLookup: NewJStore[*ATNConfig, Comparator[*ATNConfig]](aConfCompInst, ATNConfigLookupCollection, "NewATNConfigSet()"),
+		configLookup: newConfigTable(false),
 		fullCtx:      fullCtx,
 	}
 }
@@ -92,7 +92,7 @@ func (b *ATNConfigSet) Add(config *ATNConfig, mergeCache *JPCMap) bool {
 		b.dipsIntoOuterContext = true
 	}
 
-	existing, present := b.configLookup.Put(config)
+	existing, present := b.configLookup.put(config)
 
 	// The config was not already in the set
 	//
@@ -240,7 +240,7 @@ func (b *ATNConfigSet) Contains(item *ATNConfig) bool {
 	if b.configLookup == nil {
 		return false
 	}
-	return b.configLookup.Contains(item)
+	return b.configLookup.contains(item)
 }
 
 func (b *ATNConfigSet) ContainsFast(item *ATNConfig) bool {
@@ -253,7 +253,7 @@ func (b *ATNConfigSet) Clear() {
 	}
 	b.configs = make([]*ATNConfig, 0)
 	b.cachedHash = -1
-	b.configLookup = NewJStore[*ATNConfig, Comparator[*ATNConfig]](aConfCompInst, ATNConfigLookupCollection, "NewATNConfigSet()")
+	b.configLookup = newConfigTable(false)
 }
 
 func (b *ATNConfigSet) String() string {
@@ -295,7 +295,7 @@ func NewOrderedATNConfigSet() *ATNConfigSet {
 	return &ATNConfigSet{
 		cachedHash: -1,
 		// This set uses the standard Hash() and Equals() from ATNConfig
-		configLookup: NewJStore[*ATNConfig, Comparator[*ATNConfig]](aConfEqInst, ATNConfigCollection, "ATNConfigSet.NewOrderedATNConfigSet()"),
+		configLookup: newConfigTable(true),
 		fullCtx:      false,
 	}
 }
@@ -0,0 +1,133 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// configTableMinSize is the number of slots of a configTable when the first configuration is put in it.
+const configTableMinSize = 16
+
+// configTable is the configLookup of an [ATNConfigSet]: a hash set of configurations with open addressing
+// and linear probing, which keeps the hash of each configuration in its slot. A lookup compares the hashes
+// before the configurations, and calls no comparator through an interface, which makes it much cheaper than
+// a [JStore] for the many small sets that adaptive prediction creates.
+//
+// A configuration is hashed as it is put in the table, and is found by that hash afterward, even if its
+// context is replaced by a merge, as it was in a JStore.
+type configTable struct {
+	slots []configSlot
+	len   int
+
+	// ordered selects the equality of ATNConfig.Equals, which includes the context, as for the sets of a
+	// lexer, in place of the equality of the state, alternative and semantic context alone
+	ordered bool
+}
+
+type configSlot struct {
+	hash   int
+	config *ATNConfig
+}
+
+func newConfigTable(ordered bool) *configTable {
+	return &configTable{ordered: ordered}
+}
+
+// hash returns the hash of c, as ATNConfigComparator.Hash1 computes it or, for an ordered table, as
+// ATNConfig.Hash does.
+func (t *configTable) hash(c *ATNConfig) int {
+	if t.ordered {
+		return c.Hash()
+	}
+	h := 7
+	h = 31*h + c.state.GetStateNumber()
+	h = 31*h + c.alt
+	h = 31*h + c.semanticContext.Hash()
+	return h
+}
+
+// equals returns true if a and b are the same configuration for the table, as ATNConfigComparator.Equals2
+// tells or, for an ordered table, as ATNConfig.Equals does.
+func (t *configTable) equals(a, b *ATNConfig) bool {
+	if a == b {
+		return true
+	}
+	if t.ordered {
+		return a.Equals(b)
+	}
+	return a.state.GetStateNumber() == b.state.GetStateNumber() &&
+		a.alt == b.alt &&
+		(a.semanticContext == b.semanticContext || a.semanticContext.Equals(b.semanticContext))
+}
+
+// index returns the slot at which the probe for hash begins. The hash is spread over the bits of the index by
+// Fibonacci hashing, as the hashes of configurations that differ only in their alternative are close together.
+func (t *configTable) index(hash int) int {
+	return int((uint64(hash) * 0x9E3779B97F4A7C15) >> 32 & uint64(len(t.slots)-1))
+}
+
+// put adds c to the table, unless an equal configuration is in it already, and returns the configuration in
+// the table, with true if it was there already.
+func (t *configTable) put(c *ATNConfig) (*ATNConfig, bool) {
+	if (t.len+1)*4 > len(t.slots)*3 {
+		t.grow()
+	}
+	hash := t.hash(c)
+	mask := len(t.slots) - 1
+	for i := t.index(hash); ; i = (i + 1) & mask {
+		slot := &t.slots[i]
+		if slot.config == nil {
+			slot.hash, slot.config = hash, c
+			t.len++
+			return c, false
+		}
+		if slot.hash == hash && t.equals(c, slot.config) {
+			return slot.config, true
+		}
+	}
+}
+
+// contains returns true if a configuration equal to c is in the table.
+func (t *configTable) contains(c *ATNConfig) bool {
+	if t.len == 0 {
+		return false
+	}
+	hash := t.hash(c)
+	mask := len(t.slots) - 1
+	for i := t.index(hash); ; i = (i + 1) & mask {
+		slot := &t.slots[i]
+		if slot.config == nil {
+			return false
+		}
+		if slot.hash == hash && t.equals(c, slot.config) {
+			return true
+		}
+	}
+}
+
+// grow doubles the slots of the table, or allocates them for the first configuration, and puts the
+// configurations in again, by the hashes they were put in with.
+func (t *configTable) grow() {
+	old := t.slots
+	t.slots = make([]configSlot, max(2*len(old), configTableMinSize))
+	mask := len(t.slots) - 1
+	for _, slot := range old {
+		if slot.config == nil {
+			continue
+		}
+		i := t.index(slot.hash)
+		for t.slots[i].config != nil {
+			i = (i + 1) & mask
+		}
+		t.slots[i] = slot
+	}
+}
+
+// clear removes every configuration from the table, keeping the slots it has allocated.
+func (t *configTable) clear() {
+	clear(t.slots)
+	t.len = 0
+}
+
+func (t *configTable) Len() int {
+	return t.len
+}
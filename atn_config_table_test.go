@@ -0,0 +1,225 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The JSON grammar of the grammars-v4 repository, whose parser ATN is built here as it is for the statement
+// language of bench_grammar_test.go:
+//
+//	json  : value EOF ;
+//	obj   : '{' pair (',' pair)* '}' | '{' '}' ;
+//	pair  : STRING ':' value ;
+//	arr   : '[' value (',' value)* ']' | '[' ']' ;
+//	value : STRING | NUMBER | obj | arr | 'true' | 'false' | 'null' ;
+
+const (
+	jsonSTRING = iota + 1
+	jsonNUMBER
+	jsonLBRACE
+	jsonRBRACE
+	jsonLBRACK
+	jsonRBRACK
+	jsonCOMMA
+	jsonCOLON
+	jsonTRUE
+	jsonFALSE
+	jsonNULL
+)
+
+var jsonRuleNames = []string{"json", "obj", "pair", "arr", "value"}
+
+var jsonLiteralNames = []string{"", "", "", "'{'", "'}'", "'['", "']'", "','", "':'", "'true'", "'false'", "'null'"}
+
+var jsonSymbolicNames = []string{"", "STRING", "NUMBER"}
+
+var jsonRules = [][][]atnElement{
+	// json
+	{bAlt(bRule(4), bTok(TokenEOF))},
+	// obj
+	{
+		bAlt(bTok(jsonLBRACE), bRule(2), bBlock('*', bAlt(bTok(jsonCOMMA), bRule(2))), bTok(jsonRBRACE)),
+		bAlt(bTok(jsonLBRACE), bTok(jsonRBRACE)),
+	},
+	// pair
+	{bAlt(bTok(jsonSTRING), bTok(jsonCOLON), bRule(4))},
+	// arr
+	{
+		bAlt(bTok(jsonLBRACK), bRule(4), bBlock('*', bAlt(bTok(jsonCOMMA), bRule(4))), bTok(jsonRBRACK)),
+		bAlt(bTok(jsonLBRACK), bTok(jsonRBRACK)),
+	},
+	// value
+	{
+		bAlt(bTok(jsonSTRING)), bAlt(bTok(jsonNUMBER)), bAlt(bRule(1)), bAlt(bRule(3)), bAlt(bTok(jsonTRUE)),
+		bAlt(bTok(jsonFALSE)), bAlt(bTok(jsonNULL)),
+	},
+}
+
+var jsonATN = sync.OnceValue(func() *ATN { return buildATN(jsonNULL, jsonRules) })
+
+// jsonDocument is a JSON document, with its tokens separated by spaces.
+const jsonDocument = `{ "name" : "antlr" , "version" : 4.13 , "tags" : [ "parser" , "lexer" , [ ] , { } ] ,
+"owner" : { "id" : 17 , "admin" : true , "email" : null , "keys" : [ [ 1 , 2 ] , [ 3 , [ 4 , { "a" : false } ] ] ] } ,
+"files" : [ { "path" : "atn.go" , "size" : 5120 } , { "path" : "dfa.go" , "size" : 2048 , "generated" : false } ] }
+`
+
+var jsonWords = map[string]int{
+	"{": jsonLBRACE, "}": jsonRBRACE, "[": jsonLBRACK, "]": jsonRBRACK, ",": jsonCOMMA, ":": jsonCOLON,
+	"true": jsonTRUE, "false": jsonFALSE, "null": jsonNULL,
+}
+
+// jsonTokens returns the tokens of the JSON document doc, as the elements of an array of n copies of it.
+func jsonTokens(doc string, n int) []Token {
+	tokens := benchLex("[ "+strings.Repeat(doc+" , ", n-1)+doc+" ]", 1, func(word string) int {
+		if ttype, ok := jsonWords[word]; ok {
+			return ttype
+		}
+		if word[0] == '"' {
+			return jsonSTRING
+		}
+		return jsonNUMBER
+	})
+	return tokens
+}
+
+// jsonParser returns a parser of JSON tokens, which predicts with new DFAs.
+func jsonParser(tokens []Token) *ParserInterpreter {
+	p := NewParserInterpreter("JSON.g4", jsonLiteralNames, jsonSymbolicNames, jsonRuleNames, jsonATN(),
+		benchStream(tokens))
+	p.RemoveErrorListeners()
+	return p
+}
+
+// benchInputs are the inputs of the benchmarks of adaptive prediction: the statements of an ordinary
+// program, statements that carry long rule invocation stacks, and a JSON document.
+var benchInputs = []struct {
+	name   string
+	tokens func() []Token
+	parser func([]Token) *ParserInterpreter
+}{
+	{"statements", func() []Token { return benchTokens(benchProgram, 1) }, statementParser},
+	{"nested", func() []Token { return benchTokens(benchNestedProgram, 1) }, statementParser},
+	{"json", func() []Token { return jsonTokens(jsonDocument, 4) }, jsonParser},
+}
+
+func statementParser(tokens []Token) *ParserInterpreter {
+	return benchParser(tokens, nil, nil)
+}
+
+// BenchmarkAdaptivePredict parses with DFAs that start empty, so that every decision is predicted by
+// simulating the ATN, and the cost is that of adding configurations to the sets of ATNConfigSet and looking
+// them up. Each input is parsed in SLL mode, in LL mode, which falls back to full context on the dangling
+// else of the statements, and with exact ambiguity detection, which fills the largest sets.
+func BenchmarkAdaptivePredict(b *testing.B) {
+	modes := []struct {
+		name string
+		mode int
+	}{
+		{"SLL", PredictionModeSLL},
+		{"LL", PredictionModeLL},
+		{"LLExactAmbig", PredictionModeLLExactAmbigDetection},
+	}
+	for _, input := range benchInputs {
+		for _, mode := range modes {
+			b.Run(input.name+"/"+mode.name, func(b *testing.B) {
+				tokens := input.tokens()
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					p := input.parser(tokens)
+					p.Interpreter.SetPredictionMode(mode.mode)
+					benchParse(b, p, tokens)
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkConfigLookup fills the lookup of a set with the configurations of each DFA state that a parse of
+// an input leaves, and looks each of them up again, once with the configTable of ATNConfigSet, and once with
+// the JStore it replaced.
+func BenchmarkConfigLookup(b *testing.B) {
+	for _, input := range benchInputs {
+		tokens := input.tokens()
+		p := input.parser(tokens)
+		p.Interpreter.SetPredictionMode(PredictionModeLLExactAmbigDetection)
+		benchParse(b, p, tokens)
+		var sets [][]*ATNConfig
+		for _, dfa := range p.Interpreter.decisionToDFA {
+			for _, s := range dfa.sortedStates() {
+				sets = append(sets, s.configs.configs)
+			}
+		}
+
+		b.Run(input.name+"/configTable", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, configs := range sets {
+					t := newConfigTable(false)
+					for _, c := range configs {
+						t.put(c)
+					}
+					for _, c := range configs {
+						if !t.contains(c) {
+							b.Fatal("configuration not found")
+						}
+					}
+				}
+			}
+		})
+		b.Run(input.name+"/JStore", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for _, configs := range sets {
+					t := NewJStore[*ATNConfig, Comparator[*ATNConfig]](aConfCompInst, ATNConfigLookupCollection, "BenchmarkConfigLookup")
+					for _, c := range configs {
+						t.Put(c)
+					}
+					for _, c := range configs {
+						if !t.Contains(c) {
+							b.Fatal("configuration not found")
+						}
+					}
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkConfigTable adds the configurations of a prediction to the table of a set, and looks each of
+// them up again, without the rest of adaptive prediction.
+func BenchmarkConfigTable(b *testing.B) {
+	atn := benchATN()
+	var configs []*ATNConfig
+	for _, s := range atn.states {
+		for alt := 1; alt <= 3; alt++ {
+			configs = append(configs, NewATNConfig5(s, alt, BasePredictionContextEMPTY, SemanticContextNone))
+		}
+	}
+	for _, ordered := range []bool{false, true} {
+		name := "unordered"
+		if ordered {
+			name = "ordered"
+		}
+		b.Run(name, func(b *testing.B) {
+			t := newConfigTable(ordered)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				t.clear()
+				for _, c := range configs {
+					t.put(c)
+				}
+				for _, c := range configs {
+					if !t.contains(c) {
+						b.Fatal("configuration not found")
+					}
+				}
+			}
+		})
+	}
+}
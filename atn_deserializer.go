@@ -52,6 +52,8 @@ func (a *ATNDeserializer) Deserialize(data []int32) *ATN {
 	a.checkVersion()
 
 	atn := a.readATN()
+	atn.serialized = data
+	atn.fingerprint = FingerprintSerializedATN(data)
 
 	a.readStates(atn)
 	a.readRules(atn)
@@ -79,7 +81,9 @@ func (a *ATNDeserializer) checkVersion() {
 	version := a.readInt()
 
 	if version != serializedVersion {
-		panic("Could not deserialize ATN with version " + strconv.Itoa(version) + " (expected " + strconv.Itoa(serializedVersion) + ").")
+		panic("Could not deserialize ATN with version " + strconv.Itoa(version) + " (expected " + strconv.Itoa(serializedVersion) + "). " +
+			"The code was generated by a version of ANTLR that runtime " + RuntimeVersion + " does not support; it supports code generated by ANTLR " +
+			supportedVersions() + ".")
 	}
 }
 
@@ -329,6 +333,7 @@ func (a *ATNDeserializer) readLexerActions(atn *ATN) {
 func (a *ATNDeserializer) generateRuleBypassTransitions(atn *ATN) {
 	count := len(atn.ruleToStartState)
 
+	atn.ruleToTokenType = make([]int, count)
 	for i := 0; i < count; i++ {
 		atn.ruleToTokenType[i] = atn.maxTokenType + i + 1
 	}
@@ -365,7 +370,7 @@ func (a *ATNDeserializer) generateRuleBypassTransition(atn *ATN, idx int) {
 		for i := 0; i < len(atn.states); i++ {
 			state := atn.states[i]
 
-			if a.stateIsEndStateFor(state, idx) != nil {
+			if state != nil && a.stateIsEndStateFor(state, idx) != nil {
 				endState = state
 				excludeTransition = state.(*StarLoopEntryState).loopBackState.GetTransitions()[0]
 
@@ -384,6 +389,9 @@ func (a *ATNDeserializer) generateRuleBypassTransition(atn *ATN, idx int) {
 	// blockEnd instead
 	for i := 0; i < len(atn.states); i++ {
 		state := atn.states[i]
+		if state == nil {
+			continue
+		}
 
 		for j := 0; j < len(state.GetTransitions()); j++ {
 			transition := state.GetTransitions()[j]
@@ -400,12 +408,12 @@ func (a *ATNDeserializer) generateRuleBypassTransition(atn *ATN, idx int) {
 
 	// All transitions leaving the rule start state need to leave blockStart instead
 	ruleToStartState := atn.ruleToStartState[idx]
-	count := len(ruleToStartState.GetTransitions())
+	transitions := ruleToStartState.GetTransitions()
 
-	for count > 0 {
-		bypassStart.AddTransition(ruleToStartState.GetTransitions()[count-1], -1)
-		ruleToStartState.SetTransitions([]Transition{ruleToStartState.GetTransitions()[len(ruleToStartState.GetTransitions())-1]})
+	for count := len(transitions); count > 0; count-- {
+		bypassStart.AddTransition(transitions[count-1], -1)
 	}
+	ruleToStartState.SetTransitions(nil)
 
 	// Link the new states
 	atn.ruleToStartState[idx].AddTransition(NewEpsilonTransition(bypassStart, -1), -1)
@@ -474,75 +482,8 @@ func (a *ATNDeserializer) verifyATN(atn *ATN) {
 	}
 
 	// Verify assumptions
-	for _, state := range atn.states {
-		if state == nil {
-			continue
-		}
-
-		a.checkCondition(state.GetEpsilonOnlyTransitions() || len(state.GetTransitions()) <= 1, "")
-
-		switch s2 := state.(type) {
-		case *PlusBlockStartState:
-			a.checkCondition(s2.loopBackState != nil, "")
-
-		case *StarLoopEntryState:
-			a.checkCondition(s2.loopBackState != nil, "")
-			a.checkCondition(len(s2.GetTransitions()) == 2, "")
-
-			switch s2.transitions[0].getTarget().(type) {
-			case *StarBlockStartState:
-				_, ok := s2.transitions[1].getTarget().(*LoopEndState)
-
-				a.checkCondition(ok, "")
-				a.checkCondition(!s2.nonGreedy, "")
-
-			case *LoopEndState:
-				var _, ok = s2.transitions[1].getTarget().(*StarBlockStartState)
-
-				a.checkCondition(ok, "")
-				a.checkCondition(s2.nonGreedy, "")
-
-			default:
-				panic("IllegalState")
-			}
-
-		case *StarLoopbackState:
-			a.checkCondition(len(state.GetTransitions()) == 1, "")
-
-			var _, ok = state.GetTransitions()[0].getTarget().(*StarLoopEntryState)
-
-			a.checkCondition(ok, "")
-
-		case *LoopEndState:
-			a.checkCondition(s2.loopBackState != nil, "")
-
-		case *RuleStartState:
-			a.checkCondition(s2.stopState != nil, "")
-
-		case BlockStartState:
-			a.checkCondition(s2.getEndState() != nil, "")
-
-		case *BlockEndState:
-			a.checkCondition(s2.startState != nil, "")
-
-		case DecisionState:
-			a.checkCondition(len(s2.GetTransitions()) <= 1 || s2.getDecision() >= 0, "")
-
-		default:
-			var _, ok = s2.(*RuleStopState)
-
-			a.checkCondition(len(s2.GetTransitions()) <= 1 || ok, "")
-		}
-	}
-}
-
-func (a *ATNDeserializer) checkCondition(condition bool, message string) {
-	if !condition {
-		if message == "" {
-			message = "IllegalState"
-		}
-
-		panic(message)
+	if err := atn.Validate(nil); err != nil {
+		panic(err.Error())
 	}
 }
 
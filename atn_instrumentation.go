@@ -0,0 +1,95 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "sync/atomic"
+
+// StateObserverFunc is notified each time a recognizer visits a state of an [ATN] that it has been installed
+// on with [ATN.SetStateObserver]. The prediction flag is false when a parser reaches the state as it executes
+// the generated code of a rule, and true when the state is reached while computing an epsilon closure,
+// which happens during adaptive prediction in a parser, and whenever a lexer matches input that its DFA
+// cache does not yet cover.
+//
+// The ATN of a generated recognizer is shared by all its instances, so the func may be called concurrently
+// if recognizers run in several goroutines, and must be safe for that. It is called on the hot path of the
+// recognizer, so should do as little as possible.
+type StateObserverFunc func(state ATNState, prediction bool)
+
+// SetStateObserver installs a func that is notified of each state that the recognizers using this ATN visit,
+// for tools that measure grammar coverage or find the hot paths in a grammar, see [ATNStateCounter]. Install
+// the observer before recognizers start to use the ATN. Pass nil to remove it.
+//
+// Use:
+//
+//	counter := antlr.NewATNStateCounter(p.GetATN())
+//	p.GetATN().SetStateObserver(counter.Observe)
+func (a *ATN) SetStateObserver(observer StateObserverFunc) {
+	a.observer = observer
+}
+
+// GetStateObserver returns the func installed with [ATN.SetStateObserver], or nil if there is none.
+func (a *ATN) GetStateObserver() StateObserverFunc {
+	return a.observer
+}
+
+// NumberOfStates returns the number of state numbers in the ATN. Some numbers may have no state, if the
+// state was removed as the ATN was optimized.
+func (a *ATN) NumberOfStates() int {
+	return len(a.states)
+}
+
+// GetState returns the state with the given number, or nil if there is no such state.
+func (a *ATN) GetState(stateNumber int) ATNState {
+	if stateNumber < 0 || stateNumber >= len(a.states) {
+		return nil
+	}
+	return a.states[stateNumber]
+}
+
+// ATNStateCounter counts the visits to each state of an [ATN], separately for visits made while executing
+// generated code and while predicting, as reported to its Observe method. It is safe for concurrent use.
+type ATNStateCounter struct {
+	executed  []atomic.Int64
+	predicted []atomic.Int64
+}
+
+// NewATNStateCounter creates an [ATNStateCounter] with a zero count for every state of the given ATN. Pass
+// its Observe method to [ATN.SetStateObserver] to start counting.
+func NewATNStateCounter(atn *ATN) *ATNStateCounter {
+	return &ATNStateCounter{
+		executed:  make([]atomic.Int64, len(atn.states)),
+		predicted: make([]atomic.Int64, len(atn.states)),
+	}
+}
+
+// Observe counts a visit to state. It has the signature of a [StateObserverFunc].
+func (c *ATNStateCounter) Observe(state ATNState, prediction bool) {
+	n := state.GetStateNumber()
+	if n < 0 || n >= len(c.executed) {
+		return
+	}
+	if prediction {
+		c.predicted[n].Add(1)
+	} else {
+		c.executed[n].Add(1)
+	}
+}
+
+// Count returns the number of visits to the state with the given number, made while executing generated
+// code and while predicting.
+func (c *ATNStateCounter) Count(stateNumber int) (executed, predicted int64) {
+	if stateNumber < 0 || stateNumber >= len(c.executed) {
+		return 0, 0
+	}
+	return c.executed[stateNumber].Load(), c.predicted[stateNumber].Load()
+}
+
+// Reset sets all the counts back to zero.
+func (c *ATNStateCounter) Reset() {
+	for i := range c.executed {
+		c.executed[i].Store(0)
+		c.predicted[i].Store(0)
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestATNStateCounterParser(t *testing.T) {
+	// the ATN is deserialized afresh, so that the observer is not installed on the ATN the tests share
+	atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+	counter := NewATNStateCounter(atn)
+	atn.SetStateObserver(counter.Observe)
+	if atn.GetStateObserver() == nil {
+		t.Fatal("no state observer")
+	}
+	decisionToDFA := newDFA(atn)
+	parse := func(input string) {
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+		p.Interpreter = NewParserATNSimulator(p, atn, decisionToDFA, NewPredictionContextCache())
+		p.S()
+	}
+
+	parse("a b + c")
+	// the parser reaches the state before item for each item, and the state before EOF once
+	for state, want := range map[int]int64{4: 2, 8: 1, 14: 1, 15: 1, 13: 1} {
+		if executed, _ := counter.Count(state); executed != want {
+			t.Errorf("state %d executed %d times, want %d", state, executed, want)
+		}
+	}
+	predicted := func() (n int64) {
+		for i := 0; i < atn.NumberOfStates(); i++ {
+			_, p := counter.Count(i)
+			n += p
+		}
+		return n
+	}
+	if predicted() == 0 {
+		t.Error("no state was visited during prediction")
+	}
+
+	// once the DFA holds the predictions, the parser visits no more states to predict
+	counter.Reset()
+	parse("a b + c")
+	if executed, _ := counter.Count(4); executed != 2 || predicted() != 0 {
+		t.Errorf("state 4 executed %d times, and %d states were visited during prediction", executed, predicted())
+	}
+
+	atn.SetStateObserver(nil)
+	counter.Reset()
+	parse("a")
+	if executed, _ := counter.Count(4); executed != 0 {
+		t.Errorf("a removed observer counted %d visits", executed)
+	}
+}
+
+func TestATNStateCounterLexer(t *testing.T) {
+	atn := NewATNDeserializer(nil).Deserialize(listLexerSerialized)
+	counter := NewATNStateCounter(atn)
+	atn.SetStateObserver(counter.Observe)
+	decisionToDFA := newDFA(atn)
+	lex := func(input string) int64 {
+		counter.Reset()
+		lexer := newListLexer(NewInputStream(input))
+		lexer.Interpreter = NewLexerATNSimulator(lexer, atn, decisionToDFA, NewPredictionContextCache())
+		NewCommonTokenStream(lexer, TokenDefaultChannel).Fill()
+		var n int64
+		for i := 0; i < atn.NumberOfStates(); i++ {
+			executed, predicted := counter.Count(i)
+			if executed != 0 {
+				t.Errorf("the lexer executed state %d", i)
+			}
+			n += predicted
+		}
+		return n
+	}
+	if lex("ab+c") == 0 {
+		t.Error("the lexer visited no states")
+	}
+	if n := lex("ab+c"); n != 0 {
+		t.Errorf("the lexer visited %d states of input its DFA covers", n)
+	}
+}
+
+func TestATNGetState(t *testing.T) {
+	atn := newListParser(nil).GetATN()
+	if atn.NumberOfStates() != 20 || atn.GetState(8).GetStateNumber() != 8 {
+		t.Errorf("%d states", atn.NumberOfStates())
+	}
+	if atn.GetState(-1) != nil || atn.GetState(20) != nil {
+		t.Error("a state beyond the ATN")
+	}
+	if executed, predicted := NewATNStateCounter(atn).Count(20); executed != 0 || predicted != 0 {
+		t.Error("a count beyond the ATN")
+	}
+}
@@ -16,6 +16,60 @@ type BaseATNSimulator struct {
 	atn                *ATN
 	sharedContextCache *PredictionContextCache
 	decisionToDFA      []*DFA
+	arena              *Arena
+
+	// closurePath holds the configurations on the path of the epsilon closure being computed, from the
+	// configuration the closure started from, and so its depth. closureFloor is the index in it of the first
+	// configuration that enterClosure compares with, see ParserATNSimulator.closureWork.
+	closurePath  []closureStep
+	closureFloor int
+
+	// diagnostics holds the diagnostics collected for each decision, see ConfigureDiagnostics
+	diagnostics []DecisionDiagnostics
+}
+
+// closureStep is the state and context of a configuration on the path of an epsilon closure.
+type closureStep struct {
+	state   int
+	context *PredictionContext
+}
+
+// enterClosure adds config to the path of the epsilon closure being computed, or returns false if the closure
+// is caught in a loop: config is at a state already on the path, with the same context as there or with that
+// context underneath rules it has entered since. Following the same transitions from config would then bring
+// the closure back to the same state again, without consuming any input, forever.
+func (b *BaseATNSimulator) enterClosure(config *ATNConfig) bool {
+	state := config.GetState().GetStateNumber()
+	for _, step := range b.closurePath[b.closureFloor:] {
+		if step.state == state && extendsContext(config.GetContext(), step.context) {
+			return false
+		}
+	}
+	b.closurePath = append(b.closurePath, closureStep{state: state, context: config.GetContext()})
+	return true
+}
+
+// leaveClosure removes the last configuration added by enterClosure from the path of the closure.
+func (b *BaseATNSimulator) leaveClosure() {
+	b.closurePath = b.closurePath[:len(b.closurePath)-1]
+}
+
+// resetClosure empties the path of the closure, as when a closure is abandoned.
+func (b *BaseATNSimulator) resetClosure() {
+	b.closurePath = b.closurePath[:0]
+	b.closureFloor = 0
+}
+
+// extendsContext returns true if context is base, or is made of base by the rules entered, and so the return
+// states pushed, on top of it. A closure only pushes single return states, so only single parents are followed.
+func extendsContext(context, base *PredictionContext) bool {
+	for context != base {
+		if context == nil || context.length() != 1 {
+			return false
+		}
+		context = context.GetParent(0)
+	}
+	return true
 }
 
 func (b *BaseATNSimulator) getCachedContext(context *PredictionContext) *PredictionContext {
@@ -32,6 +86,29 @@ func (b *BaseATNSimulator) SharedContextCache() *PredictionContextCache {
 	return b.sharedContextCache
 }
 
+// SetSharedContextCache replaces the cache of prediction contexts that the simulator shares with the other
+// simulators of its grammar, such as with a cache bounded by [NewBoundedPredictionContextCache]. Set the
+// cache before the simulator is used, and set the same cache on every simulator that is to share it.
+func (b *BaseATNSimulator) SetSharedContextCache(cache *PredictionContextCache) {
+	b.sharedContextCache = cache
+}
+
+// clearDFA replaces each DFA of decisionToDFA with an empty one, and empties the shared context cache, whose
+// contexts are those of the states of the DFA.
+func (b *BaseATNSimulator) clearDFA(decisionToDFA []*DFA) {
+	b.atn.stateMu.Lock()
+	defer b.atn.stateMu.Unlock()
+	b.atn.edgeMu.Lock()
+	defer b.atn.edgeMu.Unlock()
+
+	for i, dfa := range decisionToDFA {
+		decisionToDFA[i] = NewDFA(dfa.atnStartState, dfa.decision)
+	}
+	if b.sharedContextCache != nil {
+		b.sharedContextCache.Clear()
+	}
+}
+
 func (b *BaseATNSimulator) ATN() *ATN {
 	return b.atn
 }
@@ -39,3 +116,15 @@ func (b *BaseATNSimulator) ATN() *ATN {
 func (b *BaseATNSimulator) DecisionToDFA() []*DFA {
 	return b.decisionToDFA
 }
+
+// GetArena returns the [Arena] that this simulator allocates its configurations from, or nil if
+// configurations are allocated on the heap.
+func (b *BaseATNSimulator) GetArena() *Arena {
+	return b.arena
+}
+
+// SetArena sets the [Arena] that this simulator allocates its configurations from. Passing nil
+// reverts to normal heap allocation.
+func (b *BaseATNSimulator) SetArena(arena *Arena) {
+	b.arena = arena
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"testing"
+)
+
+// loopingListATN returns a copy of a list ATN with an epsilon transition from the decision state to itself.
+func loopingListATN(serialized []int32, decision int) *listStaticData {
+	data := new(listStaticData)
+	data.init(serialized)
+	s := data.atn.DecisionToState[decision]
+	s.AddTransition(NewEpsilonTransition(s, -1), -1)
+	return data
+}
+
+func TestParserLoopDetected(t *testing.T) {
+	data := loopingListATN(listParserSerialized, 1)
+	p, _ := listParse("")
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream("a b+c d")), TokenDefaultChannel))
+	p.Interpreter = NewParserATNSimulator(p, data.atn, data.dfa, data.pcc)
+	listener := new(countingErrorListener)
+	p.RemoveErrorListeners()
+	p.AddErrorListener(listener)
+	p.S()
+
+	var loop *LoopDetectedException
+	if err, _ := p.GetError().(error); !errors.As(err, &loop) {
+		t.Fatalf("the error of the parser is %v", p.GetError())
+	}
+	if loop.GetStateNumber() != data.atn.DecisionToState[1].GetStateNumber() || loop.GetInputIndex() != 0 {
+		t.Errorf("loop at state %d, index %d", loop.GetStateNumber(), loop.GetInputIndex())
+	}
+	if listener.errors != 0 {
+		t.Errorf("%d syntax errors were reported for the cancelled parse", listener.errors)
+	}
+}
+
+func TestLexerLoopDetected(t *testing.T) {
+	data := loopingListATN(listLexerSerialized, 0)
+	lexer := newListLexer(NewInputStream("a b"))
+	lexer.Interpreter = NewLexerATNSimulator(lexer, data.atn, data.dfa, data.pcc)
+	listener := new(recordingErrorListener)
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(listener)
+	tokens := 0
+	for token := lexer.NextToken(); token.GetTokenType() != TokenEOF; token = lexer.NextToken() {
+		tokens++
+	}
+
+	// Each character is skipped in turn
+	if len(listener.exceptions) != 3 || tokens != 0 {
+		t.Fatalf("%d errors and %d tokens", len(listener.exceptions), tokens)
+	}
+	if _, ok := listener.exceptions[0].(*LoopDetectedException); !ok {
+		t.Errorf("the error is %T", listener.exceptions[0])
+	}
+}
+
+func TestParserRuleLoopDetected(t *testing.T) {
+	// The decision state of item invokes item again, so the rule invocation stack grows without end
+	data := new(listStaticData)
+	data.init(listParserSerialized)
+	s := data.atn.DecisionToState[1]
+	s.AddTransition(NewRuleTransition(s, listRuleItem, 0, s), 0)
+	p, _ := listParse("")
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream("a b+c d")), TokenDefaultChannel))
+	p.Interpreter = NewParserATNSimulator(p, data.atn, data.dfa, data.pcc)
+	p.RemoveErrorListeners()
+	p.S()
+
+	var loop *LoopDetectedException
+	if err, _ := p.GetError().(error); !errors.As(err, &loop) {
+		t.Fatalf("the error of the parser is %v", p.GetError())
+	}
+}
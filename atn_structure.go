@@ -0,0 +1,303 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// DecisionKind identifies the grammar construct that gives rise to a decision of an [ATN].
+type DecisionKind int
+
+const (
+	// DecisionBlock chooses between the alternatives of a (A|B) or (A)? block
+	DecisionBlock DecisionKind = iota
+
+	// DecisionRuleAlternatives chooses between the alternatives of a rule
+	DecisionRuleAlternatives
+
+	// DecisionStarLoop chooses whether to enter, or go round again, a (...)* loop
+	DecisionStarLoop
+
+	// DecisionStarBlock chooses between the alternatives inside a (...)* loop
+	DecisionStarBlock
+
+	// DecisionPlusLoop chooses whether to go round a (...)+ loop again
+	DecisionPlusLoop
+
+	// DecisionPlusBlock chooses between the alternatives inside a (...)+ loop
+	DecisionPlusBlock
+
+	// DecisionLeftRecursion chooses whether to continue the loop that the tool generates for a left-recursive
+	// rule, that is, whether to match another operator at the current precedence
+	DecisionLeftRecursion
+
+	// DecisionTokens chooses between the token rules of a lexer mode
+	DecisionTokens
+)
+
+var decisionKindNames = [...]string{
+	DecisionBlock:            "block",
+	DecisionRuleAlternatives: "rule alternatives",
+	DecisionStarLoop:         "star loop",
+	DecisionStarBlock:        "star block",
+	DecisionPlusLoop:         "plus loop",
+	DecisionPlusBlock:        "plus block",
+	DecisionLeftRecursion:    "left recursion",
+	DecisionTokens:           "tokens",
+}
+
+// String returns a short description of the kind, such as "star loop".
+func (k DecisionKind) String() string {
+	if k >= 0 && int(k) < len(decisionKindNames) {
+		return decisionKindNames[k]
+	}
+	return "DecisionKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// DecisionStructure describes one decision of an [ATN], as found by [ATN.DescribeDecisions].
+type DecisionStructure struct {
+	// Decision is the decision number, as passed to AdaptivePredict and used to index the DFA of the decision
+	Decision int
+
+	// Kind is the grammar construct that the decision belongs to
+	Kind DecisionKind
+
+	// StateNumber is the number of the decision state
+	StateNumber int
+
+	// RuleIndex is the index of the rule that contains the decision
+	RuleIndex int
+
+	// RuleName is the name of the rule, if rule names were given to DescribeDecisions, and the empty string
+	// otherwise
+	RuleName string
+
+	// Alternatives is the number of alternatives of the decision
+	Alternatives int
+
+	// NonGreedy is true if the decision belongs to a non-greedy construct, such as (...)*?
+	NonGreedy bool
+
+	// LeftRecursive is true if the decision belongs to the loop that the tool generates for a left-recursive
+	// rule, that is, if its Kind is DecisionLeftRecursion, or it is the star block of such a loop
+	LeftRecursive bool
+
+	// Loop is the decision number of the other decision of the same loop, which is the star block of a star
+	// loop, the star loop of a star block, the plus block of a plus loop and the plus loop of a plus block,
+	// or -1 if the decision does not belong to a loop
+	Loop int
+
+	// Executed and Predicted are the visits to the decision state made while executing generated code and
+	// while predicting, as counted by the [ATNStateCounter] given to [DecisionReport.AddCounts]
+	Executed, Predicted int64
+
+	// DFAStates is the number of states in the DFA of the decision, as given to [DecisionReport.AddDFASizes]
+	DFAStates int
+}
+
+// DecisionReport relates the decisions of an [ATN] to the constructs of the grammar that they come from, so
+// that a decision found to be slow, by number, can be traced back to the grammar. It is created by
+// [ATN.DescribeDecisions], and may be annotated with profiling data using [DecisionReport.AddCounts] and
+// [DecisionReport.AddDFASizes].
+type DecisionReport struct {
+	atn       *ATN
+	ruleNames []string
+
+	// Decisions describes each decision, indexed by decision number
+	Decisions []*DecisionStructure
+
+	// LeftRecursiveRules holds the indexes of the rules that are left-recursive, in order
+	LeftRecursiveRules []int
+}
+
+// DescribeDecisions classifies each decision of the ATN by the grammar construct that it belongs to, and
+// finds the left-recursive rules. If ruleNames is not nil, such as the result of GetRuleNames on the
+// recognizer, the decisions are labeled with the names of their rules.
+//
+// Use:
+//
+//	report := p.GetATN().DescribeDecisions(p.GetRuleNames())
+//	report.AddCounts(counter)
+//	report.AddDFASizes(p.GetInterpreter().DecisionToDFA())
+//	for _, d := range report.Hottest(10) {
+//	    fmt.Printf("decision %d: %s in rule %s\n", d.Decision, d.Kind, d.RuleName)
+//	}
+func (a *ATN) DescribeDecisions(ruleNames []string) *DecisionReport {
+	r := &DecisionReport{
+		atn:       a,
+		ruleNames: ruleNames,
+		Decisions: make([]*DecisionStructure, len(a.DecisionToState)),
+	}
+
+	// The star block of a loop is reached from its entry state, so find those first, and the blocks of the
+	// rules' alternatives.
+	starEntry := make(map[ATNState]DecisionState)
+	for _, s := range a.states {
+		if s, ok := s.(*StarLoopEntryState); ok {
+			for _, t := range s.GetTransitions() {
+				if _, ok := t.getTarget().(*StarBlockStartState); ok {
+					starEntry[t.getTarget()] = s
+				}
+			}
+		}
+	}
+	ruleBlock := make(map[ATNState]bool)
+	for i := range a.ruleToStartState {
+		if block := a.getRuleBlock(i); block != nil {
+			ruleBlock[block] = true
+		}
+	}
+
+	for d, s := range a.DecisionToState {
+		ds := &DecisionStructure{
+			Decision:     d,
+			StateNumber:  s.GetStateNumber(),
+			RuleIndex:    s.GetRuleIndex(),
+			Alternatives: len(s.GetTransitions()),
+			NonGreedy:    s.getNonGreedy(),
+			Loop:         -1,
+		}
+		if ds.RuleIndex >= 0 && ds.RuleIndex < len(ruleNames) {
+			ds.RuleName = ruleNames[ds.RuleIndex]
+		}
+
+		switch s := s.(type) {
+		case *StarLoopEntryState:
+			ds.Kind = DecisionStarLoop
+			if s.precedenceRuleDecision {
+				ds.Kind = DecisionLeftRecursion
+				ds.LeftRecursive = true
+			}
+			for _, t := range s.GetTransitions() {
+				if b, ok := t.getTarget().(*StarBlockStartState); ok && a.isDecision(b) {
+					ds.Loop = b.getDecision()
+				}
+			}
+		case *StarBlockStartState:
+			ds.Kind = DecisionStarBlock
+			if entry, ok := starEntry[s]; ok {
+				ds.Loop = entry.getDecision()
+				if e, ok := entry.(*StarLoopEntryState); ok && e.precedenceRuleDecision {
+					ds.LeftRecursive = true
+				}
+			}
+		case *PlusLoopbackState:
+			ds.Kind = DecisionPlusLoop
+			for _, t := range s.GetTransitions() {
+				if b, ok := t.getTarget().(*PlusBlockStartState); ok && a.isDecision(b) {
+					ds.Loop = b.getDecision()
+				}
+			}
+		case *PlusBlockStartState:
+			ds.Kind = DecisionPlusBlock
+			if l, ok := s.loopBackState.(DecisionState); ok {
+				ds.Loop = l.getDecision()
+			}
+		case *TokensStartState:
+			ds.Kind = DecisionTokens
+		default:
+			if ruleBlock[s] {
+				ds.Kind = DecisionRuleAlternatives
+			}
+		}
+		r.Decisions[d] = ds
+	}
+
+	for i, s := range a.ruleToStartState {
+		if s != nil && s.isPrecedenceRule {
+			r.LeftRecursiveRules = append(r.LeftRecursiveRules, i)
+		}
+	}
+	return r
+}
+
+// isDecision returns true if s is a decision of the ATN. The block of a loop with a single alternative is not,
+// though its decision number is not set to -1 either.
+func (a *ATN) isDecision(s DecisionState) bool {
+	d := s.getDecision()
+	return d >= 0 && d < len(a.DecisionToState) && a.DecisionToState[d] == s
+}
+
+// AddCounts records, for each decision, the visits to its decision state counted by counter, which must
+// have been created for the same ATN.
+func (r *DecisionReport) AddCounts(counter *ATNStateCounter) {
+	for _, d := range r.Decisions {
+		d.Executed, d.Predicted = counter.Count(d.StateNumber)
+	}
+}
+
+// AddDFASizes records, for each decision, the number of states in its DFA, as a measure of how much
+// lookahead the decision has needed so far. decisionToDFA is the DFA cache of a parser that uses the same
+// ATN, as returned by DecisionToDFA on its interpreter. It is safe to call while parsers are running.
+func (r *DecisionReport) AddDFASizes(decisionToDFA []*DFA) {
+	r.atn.stateMu.RLock()
+	defer r.atn.stateMu.RUnlock()
+	for _, d := range r.Decisions {
+		if d.Decision < len(decisionToDFA) && decisionToDFA[d.Decision] != nil {
+			d.DFAStates = decisionToDFA[d.Decision].Len()
+		}
+	}
+}
+
+// Hottest returns up to n decisions, ordered by the number of visits made to them while predicting, then by
+// the size of their DFA, most first.
+func (r *DecisionReport) Hottest(n int) []*DecisionStructure {
+	hottest := make([]*DecisionStructure, len(r.Decisions))
+	copy(hottest, r.Decisions)
+	sort.SliceStable(hottest, func(i, j int) bool {
+		if hottest[i].Predicted != hottest[j].Predicted {
+			return hottest[i].Predicted > hottest[j].Predicted
+		}
+		return hottest[i].DFAStates > hottest[j].DFAStates
+	})
+	if n < len(hottest) {
+		hottest = hottest[:n]
+	}
+	return hottest
+}
+
+// String returns the report as a table, one row per decision, followed by the left-recursive rules.
+func (r *DecisionReport) String() string {
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "DECISION\tKIND\tRULE\tSTATE\tALTS\tLOOP\tEXECUTED\tPREDICTED\tDFA")
+	for _, d := range r.Decisions {
+		rule := d.RuleName
+		if rule == "" && d.RuleIndex >= 0 {
+			rule = strconv.Itoa(d.RuleIndex)
+		} else if rule == "" {
+			rule = "-"
+		}
+		kind := d.Kind.String()
+		if d.NonGreedy {
+			kind += " (non-greedy)"
+		}
+		loop := "-"
+		if d.Loop >= 0 {
+			loop = strconv.Itoa(d.Loop)
+		}
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%s\t%d\t%d\t%d\n", d.Decision, kind, rule, d.StateNumber,
+			d.Alternatives, loop, d.Executed, d.Predicted, d.DFAStates)
+	}
+	_ = tw.Flush()
+
+	if len(r.LeftRecursiveRules) > 0 {
+		names := make([]string, len(r.LeftRecursiveRules))
+		for i, ri := range r.LeftRecursiveRules {
+			if ri < len(r.ruleNames) {
+				names[i] = r.ruleNames[ri]
+			} else {
+				names[i] = strconv.Itoa(ri)
+			}
+		}
+		sb.WriteString("left-recursive rules: " + strings.Join(names, ", ") + "\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+// structureRules is a grammar with a decision of each kind that the ATN builder can build:
+//
+//	s    : (item | '+')* EOF | ID? ;
+//	item : '+' (ID | '+') ;
+var structureRules = [][][]atnElement{
+	{bAlt(bBlock('*', bAlt(bRule(1)), bAlt(bTok(listPLUS))), bTok(TokenEOF)), bAlt(bBlock('?', bAlt(bTok(listID))))},
+	{bAlt(bTok(listPLUS), bBlock('(', bAlt(bTok(listID)), bAlt(bTok(listPLUS))))},
+}
+
+func TestDescribeDecisions(t *testing.T) {
+	atn := buildATN(listWS, structureRules)
+	report := atn.DescribeDecisions([]string{"s", "item"})
+	want := []DecisionStructure{
+		{Decision: 0, Kind: DecisionRuleAlternatives, RuleName: "s", Alternatives: 2, Loop: -1},
+		{Decision: 1, Kind: DecisionStarLoop, RuleName: "s", Alternatives: 2, Loop: 2},
+		{Decision: 2, Kind: DecisionStarBlock, RuleName: "s", Alternatives: 2, Loop: 1},
+		{Decision: 3, Kind: DecisionBlock, RuleName: "s", Alternatives: 2, Loop: -1},
+		{Decision: 4, Kind: DecisionBlock, RuleIndex: 1, RuleName: "item", Alternatives: 2, Loop: -1},
+	}
+	if len(report.Decisions) != len(want) {
+		t.Fatalf("%d decisions, want %d", len(report.Decisions), len(want))
+	}
+	for i, d := range report.Decisions {
+		if d.StateNumber != atn.DecisionToState[i].GetStateNumber() {
+			t.Errorf("decision %d has state %d", i, d.StateNumber)
+		}
+		got := *d
+		got.StateNumber = 0
+		if got != want[i] {
+			t.Errorf("decision %d: %+v, want %+v", i, got, want[i])
+		}
+	}
+	if len(report.LeftRecursiveRules) != 0 || strings.Contains(report.String(), "left-recursive") {
+		t.Errorf("left-recursive rules %v", report.LeftRecursiveRules)
+	}
+
+	// The builder cannot build a left-recursive rule, so mark the star loop of s as the tool would mark the
+	// loop it generates for one
+	atn.DecisionToState[1].(*StarLoopEntryState).precedenceRuleDecision = true
+	atn.ruleToStartState[0].isPrecedenceRule = true
+	report = atn.DescribeDecisions(nil)
+	if d := report.Decisions[1]; d.Kind != DecisionLeftRecursion || !d.LeftRecursive || d.RuleName != "" {
+		t.Errorf("decision 1: %+v", d)
+	}
+	if d := report.Decisions[2]; d.Kind != DecisionStarBlock || !d.LeftRecursive {
+		t.Errorf("decision 2: %+v", d)
+	}
+	if len(report.LeftRecursiveRules) != 1 || !strings.HasSuffix(report.String(), "\nleft-recursive rules: 0\n") {
+		t.Errorf("left-recursive rules %v in:\n%s", report.LeftRecursiveRules, report)
+	}
+
+	lexer := newListLexer(nil).GetATN().DescribeDecisions(nil)
+	if d := lexer.Decisions[0]; d.Kind != DecisionTokens || d.Alternatives != 3 {
+		t.Errorf("lexer decision 0: %+v", d)
+	}
+}
+
+func TestDecisionReportProfile(t *testing.T) {
+	atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+	counter := NewATNStateCounter(atn)
+	atn.SetStateObserver(counter.Observe)
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a b + c d")), TokenDefaultChannel))
+	p.Interpreter = NewParserATNSimulator(p, atn, newDFA(atn), NewPredictionContextCache())
+	p.S()
+
+	report := atn.DescribeDecisions(p.GetRuleNames())
+	report.AddCounts(counter)
+	report.AddDFASizes(p.Interpreter.DecisionToDFA())
+	loop, item := report.Decisions[0], report.Decisions[1]
+	if loop.Kind != DecisionStarLoop || item.Kind != DecisionRuleAlternatives {
+		t.Fatalf("decisions %+v and %+v", loop, item)
+	}
+	// the loop is entered once and then decided by the generated code alone, and its block, which has a single
+	// alternative, is not a decision, while the decision of item is reached for each of the three items
+	if loop.Executed != 1 || loop.Loop != -1 || loop.DFAStates != 0 || item.Executed != 3 || item.Predicted == 0 ||
+		item.DFAStates != 5 {
+		t.Errorf("loop %+v, item %+v", loop, item)
+	}
+	if hottest := report.Hottest(1); len(hottest) != 1 || hottest[0] != item {
+		t.Errorf("hottest %+v", hottest)
+	}
+	if hottest := report.Hottest(5); len(hottest) != 2 {
+		t.Errorf("%d hottest decisions of 2", len(hottest))
+	}
+}
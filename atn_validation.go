@@ -0,0 +1,194 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ATNProblem describes a violation of the invariants of an [ATN], as found by [ATN.Validate].
+type ATNProblem struct {
+	// StateNumber is the number of the offending state
+	StateNumber int
+
+	// RuleIndex is the index of the rule that the offending state belongs to, or -1 if it belongs to none
+	RuleIndex int
+
+	// RuleName is the name of the rule, if rule names were given to Validate, and the empty string otherwise
+	RuleName string
+
+	// Message describes the problem
+	Message string
+}
+
+// Error returns a description of the problem, so that an ATNProblem can be used as an error.
+func (p *ATNProblem) Error() string {
+	if p.RuleName != "" {
+		return fmt.Sprintf("state %d in rule %s: %s", p.StateNumber, p.RuleName, p.Message)
+	}
+	return fmt.Sprintf("state %d in rule %d: %s", p.StateNumber, p.RuleIndex, p.Message)
+}
+
+// Validate checks that the states and transitions of the ATN are consistent with each other, as the ATN
+// simulators assume. It is intended for those who construct or transform an ATN by hand, since an ATN
+// deserialized from generated code is checked as it is deserialized, see
+// [ATNDeserializationOptions.SetVerifyATN].
+//
+// Validate reports every problem it finds, rather than only the first, and returns nil if there are none, or
+// an error joining an [*ATNProblem] for each problem, which can be retrieved with errors.As or by
+// unwrapping the joined error. If ruleNames is not nil, such as the result of GetRuleNames on the recognizer,
+// problems are labeled with the names of the rules.
+func (a *ATN) Validate(ruleNames []string) error {
+	v := &atnValidator{atn: a, ruleNames: ruleNames}
+	v.validate()
+	if len(v.problems) == 0 {
+		return nil
+	}
+	errs := make([]error, len(v.problems))
+	for i, p := range v.problems {
+		errs[i] = p
+	}
+	return errors.Join(errs...)
+}
+
+// atnValidator accumulates the problems found in an ATN.
+type atnValidator struct {
+	atn       *ATN
+	ruleNames []string
+	problems  []*ATNProblem
+}
+
+// check records a problem with state if condition is false, and returns the condition, so that checks that
+// depend on it can be skipped.
+func (v *atnValidator) check(condition bool, state ATNState, format string, args ...interface{}) bool {
+	if condition {
+		return true
+	}
+	p := &ATNProblem{
+		StateNumber: state.GetStateNumber(),
+		RuleIndex:   state.GetRuleIndex(),
+		Message:     fmt.Sprintf(format, args...),
+	}
+	if p.RuleIndex >= 0 && p.RuleIndex < len(v.ruleNames) {
+		p.RuleName = v.ruleNames[p.RuleIndex]
+	}
+	v.problems = append(v.problems, p)
+	return false
+}
+
+// belongs returns true if s is a state of the ATN being validated.
+func (v *atnValidator) belongs(s ATNState) bool {
+	if s == nil {
+		return false
+	}
+	n := s.GetStateNumber()
+	return n >= 0 && n < len(v.atn.states) && v.atn.states[n] == s
+}
+
+func (v *atnValidator) validate() {
+	for i, state := range v.atn.states {
+		if state == nil {
+			continue
+		}
+		v.check(state.GetStateNumber() == i, state, "state is at index %d of the ATN", i)
+		v.check(state.GetRuleIndex() < len(v.atn.ruleToStartState), state,
+			"rule index is beyond the %d rules of the ATN", len(v.atn.ruleToStartState))
+		v.validateTransitions(state)
+		v.validateState(state)
+	}
+
+	for r, start := range v.atn.ruleToStartState {
+		if start == nil {
+			continue
+		}
+		v.check(start.GetRuleIndex() == r, start, "start state of rule %d belongs to rule %d", r, start.GetRuleIndex())
+		if r < len(v.atn.ruleToStopState) {
+			v.check(start.stopState == ATNState(v.atn.ruleToStopState[r]), start,
+				"start state is not linked to the stop state of the rule")
+		}
+	}
+
+	for d, state := range v.atn.DecisionToState {
+		v.check(state.getDecision() == d, state, "decision state is at index %d of the decisions, but has decision %d",
+			d, state.getDecision())
+	}
+}
+
+// validateTransitions checks that the transitions of state lead to states of the ATN, and that they are all
+// epsilon transitions if there is more than one.
+func (v *atnValidator) validateTransitions(state ATNState) {
+	transitions := state.GetTransitions()
+	v.check(state.GetEpsilonOnlyTransitions() || len(transitions) <= 1, state,
+		"state has %d transitions, which are not all epsilon transitions", len(transitions))
+	for i, t := range transitions {
+		if t == nil {
+			v.check(false, state, "transition %d is nil", i)
+			continue
+		}
+		v.check(v.belongs(t.getTarget()), state, "transition %d leads to a state that is not in the ATN", i)
+		if rt, ok := t.(*RuleTransition); ok {
+			v.check(rt.ruleIndex >= 0 && rt.ruleIndex < len(v.atn.ruleToStartState) &&
+				rt.getTarget() == ATNState(v.atn.ruleToStartState[rt.ruleIndex]), state,
+				"rule transition %d does not lead to the start state of rule %d", i, rt.ruleIndex)
+			v.check(v.belongs(rt.followState), state, "rule transition %d has a follow state that is not in the ATN", i)
+		}
+	}
+}
+
+// validateState checks the invariants of each kind of state, as the deserializer does.
+func (v *atnValidator) validateState(state ATNState) {
+	switch s := state.(type) {
+	case *PlusBlockStartState:
+		v.check(s.loopBackState != nil, state, "plus block start state has no loop back state")
+
+	case *StarLoopEntryState:
+		v.check(s.loopBackState != nil, state, "star loop entry state has no loop back state")
+		if !v.check(len(s.GetTransitions()) == 2, state, "star loop entry state has %d transitions, not 2",
+			len(s.GetTransitions())) {
+			return
+		}
+		switch s.transitions[0].getTarget().(type) {
+		case *StarBlockStartState:
+			_, ok := s.transitions[1].getTarget().(*LoopEndState)
+			v.check(ok, state, "greedy star loop entry state does not exit to a loop end state")
+			v.check(!s.nonGreedy, state, "star loop entry state that enters its block first is marked non-greedy")
+		case *LoopEndState:
+			_, ok := s.transitions[1].getTarget().(*StarBlockStartState)
+			v.check(ok, state, "non-greedy star loop entry state does not enter a star block start state")
+			v.check(s.nonGreedy, state, "star loop entry state that exits first is not marked non-greedy")
+		default:
+			v.check(false, state, "star loop entry state does not lead to a star block start or loop end state")
+		}
+
+	case *StarLoopbackState:
+		if v.check(len(s.GetTransitions()) == 1, state, "star loop back state has %d transitions, not 1",
+			len(s.GetTransitions())) {
+			_, ok := s.GetTransitions()[0].getTarget().(*StarLoopEntryState)
+			v.check(ok, state, "star loop back state does not lead to a star loop entry state")
+		}
+
+	case *LoopEndState:
+		v.check(s.loopBackState != nil, state, "loop end state has no loop back state")
+
+	case *RuleStartState:
+		v.check(s.stopState != nil, state, "rule start state has no stop state")
+
+	case BlockStartState:
+		v.check(s.getEndState() != nil, state, "block start state has no end state")
+
+	case *BlockEndState:
+		v.check(s.startState != nil, state, "block end state has no start state")
+
+	case DecisionState:
+		v.check(len(s.GetTransitions()) <= 1 || s.getDecision() >= 0, state,
+			"decision state has %d transitions but no decision number", len(s.GetTransitions()))
+
+	default:
+		_, ok := s.(*RuleStopState)
+		v.check(len(s.GetTransitions()) <= 1 || ok, state, "state has %d transitions but is not a decision state",
+			len(s.GetTransitions()))
+	}
+}
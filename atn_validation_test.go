@@ -0,0 +1,72 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestATNValidate(t *testing.T) {
+	for name, atn := range map[string]*ATN{
+		"lexer":  newListLexer(nil).GetATN(),
+		"parser": newListParser(nil).GetATN(),
+		// s : (ID | r)+ | ; r : '+'? ;
+		"built": buildATN(listWS, [][][]atnElement{
+			{bAlt(bBlock('+', bAlt(bTok(listID)), bAlt(bRule(1)))), bAlt()},
+			{bAlt(bBlock('?', bAlt(bTok(listPLUS))))},
+		}),
+	} {
+		if err := atn.Validate(nil); err != nil {
+			t.Errorf("%s: Validate() = %v", name, err)
+		}
+	}
+}
+
+func TestATNValidateProblems(t *testing.T) {
+	// s : item* EOF ; item : ID | ID '+' ID ;
+	atn := buildATN(listWS, [][][]atnElement{
+		{bAlt(bBlock('*', bAlt(bRule(1))), bTok(TokenEOF))},
+		{bAlt(bTok(listID)), bAlt(bTok(listID), bTok(listPLUS), bTok(listID))},
+	})
+	var entry *StarLoopEntryState
+	for _, s := range atn.states {
+		if s, ok := s.(*StarLoopEntryState); ok {
+			entry = s
+		}
+	}
+	entry.transitions = entry.transitions[:1]
+	stray := NewBasicState()
+	stray.SetStateNumber(len(atn.states))
+	atn.ruleToStartState[1].AddTransition(NewEpsilonTransition(stray, -1), -1)
+	atn.DecisionToState[0].setDecision(5)
+
+	err := atn.Validate([]string{"s", "item"})
+	if err == nil {
+		t.Fatal("Validate() found no problems")
+	}
+	var problems []string
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		problems = append(problems, e.Error())
+	}
+	want := []string{
+		"state 2 in rule item: transition 1 leads to a state that is not in the ATN",
+		"state 4 in rule s: star loop entry state has 1 transitions, not 2",
+		"state 4 in rule s: decision state is at index 0 of the decisions, but has decision 5",
+	}
+	if !slices.Equal(problems, want) {
+		t.Errorf("problems %q, want %q", problems, want)
+	}
+	var problem *ATNProblem
+	if !errors.As(err, &problem) || problem.StateNumber != 2 || problem.RuleIndex != 1 || problem.RuleName != "item" {
+		t.Errorf("first problem %+v", problem)
+	}
+
+	// without rule names, rules are shown by index
+	if err := atn.Validate(nil); !errors.As(err, &problem) || problem.Error() != "state 2 in rule 1: "+problem.Message {
+		t.Errorf("Validate(nil) = %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// SetBailRegion sets whether the rule with the given index is a bail region, in which the parser fails fast on
+// a syntax error rather than recovering from it, while the rest of the grammar recovers as usual. This suits
+// sections of a grammar that are not worth recovering within, such as expressions embedded in a template.
+//
+// The first syntax error within a bail region, including within the rules it invokes, is reported as usual,
+// but no tokens are inserted or deleted to recover from it. Instead, every rule invocation within the
+// region returns at once, each with the error recorded as its exception, up to and including the
+// outermost invocation of a bail region rule. The rule that invoked the region then carries on from the
+// offending token, and recovers with the parser's error strategy if the token does not fit there either.
+// If the error strategy of the parser is a [BailErrorStrategy], the whole parse is cancelled as usual.
+//
+// While the parser is within a bail region, [BaseParser.GetErrorHandler] returns a strategy of its own, which
+// hands reporting to the strategy set with [BaseParser.SetErrorHandler]. Bail regions apply to generated
+// parsers, not to a [ParserInterpreter].
+//
+// Use:
+//
+//	p.SetBailRegion(parser.TemplateParserRULE_embeddedExpr, true)
+//	tree := p.Template()
+func (p *BaseParser) SetBailRegion(ruleIndex int, bail bool) {
+	if !bail {
+		delete(p.bailRules, ruleIndex)
+		return
+	}
+	if p.bailRules == nil {
+		p.bailRules = make(map[int]bool)
+		p.bailHandler = &bailRegionErrorStrategy{parser: p, lastErrorIndex: -1}
+	}
+	p.bailRules[ruleIndex] = true
+}
+
+// IsBailRegion returns true if the rule with the given index is a bail region, see [BaseParser.SetBailRegion].
+func (p *BaseParser) IsBailRegion(ruleIndex int) bool {
+	return p.bailRules[ruleIndex]
+}
+
+// enterBailRegion notes that the rule invocation just entered begins a bail region, if it does.
+func (p *BaseParser) enterBailRegion(ruleIndex int) {
+	if p.bailDepth == 0 && p.bailRules[ruleIndex] {
+		p.bailDepth = p.ruleDepth
+	}
+}
+
+// exitBailRegion ends the bail region once the invocation that began it has returned, clearing the error the
+// region was failed for, so that the rule that invoked the region carries on.
+func (p *BaseParser) exitBailRegion() {
+	if p.bailDepth <= p.ruleDepth {
+		return
+	}
+	p.bailDepth = 0
+	if p.unwinding != nil {
+		p.unwinding = nil
+		p.SetError(nil)
+	}
+}
+
+// bailRegionErrorStrategy is the error strategy of a parser within a bail region. It fails the region on the
+// first syntax error, and hands reporting to the error strategy of the parser, so that errors are reported,
+// and later ones suppressed until a token is matched, as usual.
+type bailRegionErrorStrategy struct {
+	parser *BaseParser
+
+	// lastErrorIndex is the index of the token that a region last failed at, or -1
+	lastErrorIndex int
+}
+
+var _ ErrorStrategy = &bailRegionErrorStrategy{}
+
+func (b *bailRegionErrorStrategy) reset(recognizer Parser) {
+	b.parser.errHandler.reset(recognizer)
+}
+
+// RecoverInline does not recover, but sets the error of the parser, so that the rule fails.
+func (b *bailRegionErrorStrategy) RecoverInline(recognizer Parser) Token {
+	recognizer.SetError(NewInputMisMatchException(recognizer))
+	return nil
+}
+
+// Recover does not recover, but fails the bail region, so that the error remains set until the invocation
+// that began the region returns. A region that fails at the same token as the last one did consumes the
+// token, so that a rule that invokes the region in a loop cannot fail at it forever. If the parser's strategy
+// is a [BailErrorStrategy], it cancels the parse instead.
+func (b *bailRegionErrorStrategy) Recover(recognizer Parser, e RecognitionException) {
+	if _, ok := b.parser.errHandler.(*BailErrorStrategy); ok {
+		b.parser.errHandler.Recover(recognizer, e)
+		return
+	}
+	if b.parser.unwinding != nil {
+		return
+	}
+	b.parser.unwinding = e
+	index := recognizer.GetInputStream().Index()
+	if index == b.lastErrorIndex && recognizer.GetTokenStream().LA(1) != TokenEOF {
+		recognizer.Consume()
+	}
+	b.lastErrorIndex = index
+}
+
+// Sync does nothing, so that no tokens are deleted within a bail region.
+func (b *bailRegionErrorStrategy) Sync(_ Parser) {
+}
+
+func (b *bailRegionErrorStrategy) InErrorRecoveryMode(recognizer Parser) bool {
+	return b.parser.errHandler.InErrorRecoveryMode(recognizer)
+}
+
+// ReportError reports e with the parser's strategy, unless it is the error the region is already failing for,
+// which each rule of the region is handed as it returns.
+func (b *bailRegionErrorStrategy) ReportError(recognizer Parser, e RecognitionException) {
+	if e == b.parser.unwinding {
+		return
+	}
+	b.parser.errHandler.ReportError(recognizer, e)
+}
+
+func (b *bailRegionErrorStrategy) ReportMatch(recognizer Parser) {
+	b.parser.errHandler.ReportMatch(recognizer)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestBailRegion(t *testing.T) {
+	tests := []struct {
+		input  string
+		bail   bool
+		tree   string
+		errors []string
+	}{
+		// without a bail region, the error within item is recovered from there, by deleting or inserting
+		{"a + + b", false, "(s (item a + + b) <EOF>)", []string{"extraneous input '+' expecting ID"}},
+		{"a +", false, "(s (item a + <missing >) <EOF>)", []string{"missing ID at '<EOF>'"}},
+
+		// item fails at once, and s recovers from the offending token, which does not fit there either
+		{"a + + b", true, "(s (item a +) + b)", []string{"mismatched input '+' expecting ID"}},
+		{"a +", true, "(s (item a +) <EOF>)", []string{"mismatched input '<EOF>' expecting ID"}},
+
+		// an error outside the region is recovered from as usual
+		{"a + b + c", true, "(s (item a + b) + (item c) <EOF>)", []string{"extraneous input '+' expecting {<EOF>, ID}"}},
+	}
+	for _, test := range tests {
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(test.input)), TokenDefaultChannel))
+		errors := new(messageRecorder)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(errors)
+		p.SetBailRegion(listRuleItem, test.bail)
+		if p.IsBailRegion(listRuleItem) != test.bail || p.IsBailRegion(listRuleS) {
+			t.Errorf("%q: item is a bail region: %v", test.input, p.IsBailRegion(listRuleItem))
+		}
+		tree := p.S()
+		if got := tree.ToStringTree(nil, p); got != test.tree || !slices.Equal(errors.messages, test.errors) {
+			t.Errorf("%q, bail %v: %s with errors %q, want %s with %q", test.input, test.bail, got, errors.messages,
+				test.tree, test.errors)
+		}
+		if p.HasError() {
+			t.Errorf("%q: the parse ended with the error of the region", test.input)
+		}
+	}
+
+	// a bail error strategy cancels the whole parse
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a + + b")), TokenDefaultChannel))
+	p.RemoveErrorListeners()
+	p.SetErrorHandler(NewBailErrorStrategy())
+	p.SetBailRegion(listRuleItem, true)
+	p.S()
+	if _, ok := p.GetError().(*ParseCancellationException); !ok {
+		t.Errorf("the parse ended with %v", p.GetError())
+	}
+
+	p.SetBailRegion(listRuleItem, false)
+	if p.IsBailRegion(listRuleItem) {
+		t.Error("SetBailRegion(false) kept the region")
+	}
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The benchmarks of adaptive prediction parse a small statement language, whose parser ATN is built here
+// as the ANTLR tool would serialize it for this grammar:
+//
+//	prog   : stat* EOF ;
+//	stat   : ID '=' expr ';' | expr ';' | 'if' '(' expr ')' stat ('else' stat)? | '{' stat* '}' ;
+//	expr   : term (('+' | '-') term)* ;
+//	term   : factor (('*' | '/') factor)* ;
+//	factor : ID | INT | '(' expr ')' | ID '(' args? ')' ;
+//	args   : expr (',' expr)* ;
+//
+// It has the decisions that dominate prediction in real grammars: LL(2) decisions between an assignment
+// and an expression, and between a name and a call, loops whose exit depends on what follows the rule, and
+// the ambiguity of the dangling else, which falls back to full LL prediction.
+
+const (
+	benchID = iota + 1
+	benchINT
+	benchASSIGN
+	benchSEMI
+	benchLPAREN
+	benchRPAREN
+	benchPLUS
+	benchMINUS
+	benchSTAR
+	benchSLASH
+	benchCOMMA
+	benchIF
+	benchELSE
+	benchLBRACE
+	benchRBRACE
+)
+
+var benchRuleNames = []string{"prog", "stat", "expr", "term", "factor", "args"}
+
+var benchLiteralNames = []string{
+	"", "", "", "'='", "';'", "'('", "')'", "'+'", "'-'", "'*'", "'/'", "','", "'if'", "'else'", "'{'", "'}'",
+}
+
+var benchSymbolicNames = []string{
+	"", "ID", "INT", "ASSIGN", "SEMI", "LPAREN", "RPAREN", "PLUS", "MINUS", "STAR", "SLASH", "COMMA", "IF",
+	"ELSE", "LBRACE", "RBRACE",
+}
+
+var benchRules = [][][]atnElement{
+	// prog
+	{bAlt(bBlock('*', bAlt(bRule(1))), bTok(TokenEOF))},
+	// stat
+	{
+		bAlt(bTok(benchID), bTok(benchASSIGN), bRule(2), bTok(benchSEMI)),
+		bAlt(bRule(2), bTok(benchSEMI)),
+		bAlt(bTok(benchIF), bTok(benchLPAREN), bRule(2), bTok(benchRPAREN), bRule(1),
+			bBlock('?', bAlt(bTok(benchELSE), bRule(1)))),
+		bAlt(bTok(benchLBRACE), bBlock('*', bAlt(bRule(1))), bTok(benchRBRACE)),
+	},
+	// expr
+	{bAlt(bRule(3), bBlock('*', bAlt(bBlock('(', bAlt(bTok(benchPLUS)), bAlt(bTok(benchMINUS))), bRule(3))))},
+	// term
+	{bAlt(bRule(4), bBlock('*', bAlt(bBlock('(', bAlt(bTok(benchSTAR)), bAlt(bTok(benchSLASH))), bRule(4))))},
+	// factor
+	{
+		bAlt(bTok(benchID)),
+		bAlt(bTok(benchINT)),
+		bAlt(bTok(benchLPAREN), bRule(2), bTok(benchRPAREN)),
+		bAlt(bTok(benchID), bTok(benchLPAREN), bBlock('?', bAlt(bRule(5))), bTok(benchRPAREN)),
+	},
+	// args
+	{bAlt(bRule(2), bBlock('*', bAlt(bTok(benchCOMMA), bRule(2))))},
+}
+
+var benchATN = sync.OnceValue(func() *ATN { return buildATN(benchRBRACE, benchRules) })
+
+// benchProgram is a program of the statement language, with its tokens separated by spaces.
+const benchProgram = `a = f ( x , y + 1 ) * ( b - 2 ) ;
+if ( a ) { b = a * 3 ; g ( ) ; } else c = d / e ;
+h ( a , b , c ) + k ( ( d ) ) ;
+if ( x ) if ( y ) z = 1 ; else z = 2 ;
+{ p = q ; r ( s , t * u , v ( w ) ) ; }
+m * n - o ;
+`
+
+// benchNestedProgram nests calls, parentheses and statements deeply, so that prediction carries long rule
+// invocation stacks in its configurations, and merges many of their contexts.
+const benchNestedProgram = `x = f ( g ( ( a + b ) * h ( c , ( d - ( e / ( i + j ) ) ) ) ) , k ( ( ( m ( n ) ) ) ) ) ;
+if ( f ( ( a ) ) ) if ( g ( b , h ( c ) ) ) { if ( d ) { e ( ( ( 1 ) ) ) ; } else f = ( ( g ) ) ; } else h = 1 ;
+{ { { a = b ( c ( d ( e ( f ( 1 , 2 ) , 3 ) , 4 ) , 5 ) , 6 ) ; } } }
+`
+
+var benchWords = map[string]int{
+	"=": benchASSIGN, ";": benchSEMI, "(": benchLPAREN, ")": benchRPAREN, "+": benchPLUS, "-": benchMINUS,
+	"*": benchSTAR, "/": benchSLASH, ",": benchCOMMA, "if": benchIF, "else": benchELSE, "{": benchLBRACE,
+	"}": benchRBRACE,
+}
+
+// benchTokens returns the tokens of program repeated n times.
+func benchTokens(program string, n int) []Token {
+	return benchLex(program, n, func(word string) int {
+		ttype, ok := benchWords[word]
+		switch {
+		case ok:
+		case word[0] >= '0' && word[0] <= '9':
+			ttype = benchINT
+		default:
+			ttype = benchID
+		}
+		return ttype
+	})
+}
+
+// benchLex returns the tokens of the words of program repeated n times, with the type tokenType gives each.
+func benchLex(program string, n int, tokenType func(word string) int) []Token {
+	var tokens []Token
+	for i := 0; i < n; i++ {
+		for _, word := range strings.Fields(program) {
+			t := NewCommonToken(&TokenSourceCharStreamPair{}, tokenType(word), TokenDefaultChannel, -1, -1)
+			t.SetText(word)
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// benchStream returns a stream of tokens.
+func benchStream(tokens []Token) *CommonTokenStream {
+	stream := NewCommonTokenStream(nil, TokenDefaultChannel)
+	stream.SetTokenSource(newListTokenSource(tokens))
+	return stream
+}
+
+// benchParser returns a parser of tokens, which predicts with the given DFAs and context cache, or with
+// new ones if decisionToDFA is nil.
+func benchParser(tokens []Token, decisionToDFA []*DFA, cache *PredictionContextCache) *ParserInterpreter {
+	p := NewParserInterpreter("Bench.g4", benchLiteralNames, benchSymbolicNames, benchRuleNames, benchATN(),
+		benchStream(tokens))
+	p.RemoveErrorListeners()
+	if decisionToDFA != nil {
+		p.Interpreter = NewParserATNSimulator(p, p.atn, decisionToDFA, cache)
+	}
+	return p
+}
+
+// benchDFA returns new DFAs for the decisions of the ATN.
+func benchDFA() []*DFA {
+	atn := benchATN()
+	decisionToDFA := make([]*DFA, len(atn.DecisionToState))
+	for i, s := range atn.DecisionToState {
+		decisionToDFA[i] = NewDFA(s, i)
+	}
+	return decisionToDFA
+}
+
+// benchParse parses tokens with p, and fails tb if the parse reports a syntax error or is cancelled.
+func benchParse(tb testing.TB, p *ParserInterpreter, tokens []Token) {
+	p.SetInputStream(benchStream(tokens))
+	p.Parse(0)
+	if p._SyntaxErrors > 0 {
+		tb.Fatalf("%d syntax errors", p._SyntaxErrors)
+	}
+	if p.HasError() {
+		tb.Fatal(p.GetError())
+	}
+}
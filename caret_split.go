@@ -0,0 +1,134 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "unicode"
+
+// CaretSplit is the result of [SplitTokensAtCaret]: the tokens that precede a caret, and the partial token,
+// if any, that is being typed at the caret.
+type CaretSplit struct {
+	// Tokens holds the tokens before the caret, followed by an EOF token at Start, ready to be parsed to find
+	// the tokens that could be typed at the caret
+	Tokens *CommonTokenStream
+
+	// TokenIndex is the index of the token that completion candidates are for, which is the index of the EOF
+	// token in Tokens
+	TokenIndex int
+
+	// Partial is the token of the original stream that the caret is within, or at the end of, and which is
+	// being typed, or nil if the caret is between tokens. A completion replaces the text of Partial from its
+	// start to the caret.
+	Partial Token
+
+	// Prefix is the text of Partial before the caret, by which candidates should be filtered, or the empty
+	// string if there is no partial token
+	Prefix string
+
+	// Start is the index of the character at which the text of a completion begins, which is the start of
+	// Partial, or the caret if there is no partial token
+	Start int
+}
+
+// SplitTokensAtCaret finds where a caret, given as the index of the character before which it stands,
+// falls in the tokens of a stream, and returns a copy of the stream truncated at the caret, for parsing
+// with a code completion engine.
+//
+// Truncating the stream at the first token that starts after the caret, as is tempting, leaves in place a
+// token that the user is part way through typing, so that the engine offers what may follow the whole
+// token rather than what may replace it. For example, with the caret after "whi" in "x = 1; whi", the
+// candidates should be keywords and identifiers that start with "whi", not what may follow an identifier.
+// SplitTokensAtCaret treats a token as partial if the caret is within it, or if the caret is at its end and
+// isWord returns true for it, and then removes it from the stream, and returns it with the prefix that has
+// been typed. Tokens off the stream's channel, such as whitespace and comments, are never partial. If
+// isWord is nil, a token is a word if its text up to the caret consists of letters, digits and
+// underscores, which suits identifiers and keywords in most languages.
+//
+// The stream is filled first, and is not otherwise changed. The tokens in the split stream are those of
+// the original stream, not copies.
+//
+// Use:
+//
+//	split := antlr.SplitTokensAtCaret(stream, caret, nil)
+//	p := parser.NewMyParser(split.Tokens)
+//	// Collect the candidates at split.TokenIndex, then keep those that start with split.Prefix
+func SplitTokensAtCaret(stream *CommonTokenStream, caret int, isWord func(t Token) bool) *CaretSplit {
+	if isWord == nil {
+		isWord = caretIsWord(caret)
+	}
+	stream.Fill()
+	tokens := stream.tokens
+
+	split := &CaretSplit{Start: caret}
+	index := len(tokens) - 1
+	for i, t := range tokens {
+		if t.GetTokenType() == TokenEOF || caret <= t.GetStart() {
+			index = i
+			break
+		}
+		onChannel := t.GetChannel() == stream.channel
+		if onChannel && (caret <= t.GetStop() || caret == t.GetStop()+1 && isWord(t)) {
+			index = i
+			split.Partial = t
+			split.Start = t.GetStart()
+			split.Prefix = t.GetInputStream().GetTextFromInterval(NewInterval(t.GetStart(), caret-1))
+			break
+		}
+	}
+	split.TokenIndex = index
+
+	line, column := caretLineColumn(tokens, index, split.Start)
+	eof := stream.tokenSource.GetTokenFactory().Create(tokens[len(tokens)-1].GetSource(), TokenEOF, "<EOF>",
+		TokenDefaultChannel, split.Start, split.Start-1, line, column)
+	eof.SetTokenIndex(index)
+
+	truncated := make([]Token, index, index+1)
+	copy(truncated, tokens[:index])
+	split.Tokens = &CommonTokenStream{
+		channel:     stream.channel,
+		index:       -1,
+		tokenSource: stream.tokenSource,
+		tokens:      append(truncated, eof),
+		fetchedEOF:  true,
+	}
+	return split
+}
+
+// caretIsWord returns the default test of SplitTokensAtCaret for whether a token that ends at caret is a
+// word.
+func caretIsWord(caret int) func(t Token) bool {
+	return func(t Token) bool {
+		prefix := t.GetInputStream().GetTextFromInterval(NewInterval(t.GetStart(), caret-1))
+		for _, r := range prefix {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+				return false
+			}
+		}
+		return prefix != ""
+	}
+}
+
+// caretLineColumn returns the line and column of the character at offset, which lies at or after the start
+// of the token before tokens[index], by counting from the start of that token.
+func caretLineColumn(tokens []Token, index, offset int) (line, column int) {
+	if index < len(tokens) && tokens[index].GetStart() == offset {
+		return tokens[index].GetLine(), tokens[index].GetColumn()
+	}
+	line, column, from := 1, 0, 0
+	if index > 0 {
+		prev := tokens[index-1]
+		line, column, from = prev.GetLine(), prev.GetColumn(), prev.GetStart()
+	}
+	if input := tokens[len(tokens)-1].GetInputStream(); input != nil && offset > from {
+		for _, r := range input.GetTextFromInterval(NewInterval(from, offset-1)) {
+			if r == '\n' {
+				line++
+				column = 0
+			} else {
+				column++
+			}
+		}
+	}
+	return line, column
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+// listStream returns a stream of the tokens of input, lexed silently with the list lexer.
+func listStream(input string) *CommonTokenStream {
+	lexer := newListLexer(NewInputStream(input))
+	lexer.RemoveErrorListeners()
+	return NewCommonTokenStream(lexer, TokenDefaultChannel)
+}
+
+func TestSplitTokensAtCaret(t *testing.T) {
+	tests := []struct {
+		caret   int
+		isWord  func(Token) bool
+		index   int
+		partial string
+		prefix  string
+		start   int
+	}{
+		{0, nil, 0, "", "", 0},
+		// within and at the end of a word
+		{1, nil, 0, "ab", "a", 0},
+		{2, nil, 0, "ab", "ab", 0},
+		// between tokens, and at the end of '+', which is not a word
+		{3, nil, 1, "", "", 3},
+		{4, nil, 2, "", "", 4},
+		{7, nil, 2, "cd", "cd", 5},
+		{7, func(Token) bool { return false }, 3, "", "", 7},
+	}
+	for _, test := range tests {
+		stream := listStream("ab + cd")
+		split := SplitTokensAtCaret(stream, test.caret, test.isWord)
+		partial := ""
+		if split.Partial != nil {
+			partial = split.Partial.GetText()
+		}
+		if split.TokenIndex != test.index || partial != test.partial || split.Prefix != test.prefix ||
+			split.Start != test.start {
+			t.Errorf("caret %d: index %d, partial %q, prefix %q, start %d", test.caret, split.TokenIndex, partial,
+				split.Prefix, split.Start)
+			continue
+		}
+
+		// the split stream holds the tokens before the caret, then EOF at the start of the completion
+		split.Tokens.Fill()
+		if n := len(split.Tokens.GetAllTokens()); n != test.index+1 {
+			t.Errorf("caret %d: %d tokens in the split stream", test.caret, n)
+		}
+		eof := split.Tokens.Get(test.index)
+		if eof.GetTokenType() != TokenEOF || eof.GetStart() != test.start || eof.GetColumn() != test.start ||
+			eof.GetTokenIndex() != test.index {
+			t.Errorf("caret %d: EOF token %s", test.caret, eof)
+		}
+		for i := 0; i < test.index; i++ {
+			if split.Tokens.Get(i) != stream.Get(i) {
+				t.Errorf("caret %d: token %d was not shared with the stream", test.caret, i)
+			}
+		}
+	}
+}
+
+func TestSplitTokensAtCaretParse(t *testing.T) {
+	split := SplitTokensAtCaret(listStream("a b + c"), 5, nil)
+	p := newListParser(split.Tokens)
+	recorder := new(messageRecorder)
+	p.RemoveErrorListeners()
+	p.AddErrorListener(recorder)
+	// the caret is after "b +", where ID is expected
+	p.S()
+	if len(recorder.messages) != 1 || recorder.messages[0] != "missing ID at '<EOF>'" {
+		t.Errorf("errors %q", recorder.messages)
+	}
+}
+
+func TestSplitTokensAtCaretLines(t *testing.T) {
+	// the lexer skips the newline, and the caret at 3 is within bc, so that the completion starts with it
+	for caret, want := range map[int][2]int{1: {1, 1}, 2: {2, 0}, 3: {2, 0}, 4: {2, 2}} {
+		split := SplitTokensAtCaret(listStream("a\nbc"), caret, func(Token) bool { return false })
+		eof := split.Tokens.Get(split.TokenIndex)
+		if eof.GetLine() != want[0] || eof.GetColumn() != want[1] {
+			t.Errorf("caret %d: EOF at %d:%d, want %d:%d", caret, eof.GetLine(), eof.GetColumn(), want[0], want[1])
+		}
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "unicode/utf8"
+
+// sliceOffsetStride is the number of characters between the byte offsets recorded by an [InputStream] for
+// [InputStream.Slice], so that the offset of any character is found by decoding fewer than this many characters.
+const sliceOffsetStride = 64
+
+// SliceCharStream returns the text of input within the interval, where the Stop of the interval is inclusive,
+// as [InputStream.Slice] does if input has a Slice method, and otherwise as GetTextFromInterval does.
+//
+// Use:
+//
+//	text := antlr.SliceCharStream(ctx.GetStart().GetInputStream(), antlr.NewInterval(start, stop))
+func SliceCharStream(input CharStream, i Interval) string {
+	if slicer, ok := input.(interface{ Slice(Interval) string }); ok {
+		return slicer.Slice(i)
+	}
+	return input.GetTextFromInterval(i)
+}
+
+// Slice returns the text of the stream within the interval, where the Stop of the interval is inclusive, as
+// [InputStream.GetTextFromInterval] does, but without copying it: the string returned is a view of the text
+// the stream holds, so that rewriters and extractors that take many pieces of a large input do not duplicate
+// it. A stream created by [NewInputStream] holds the string it was given. A stream read from a reader or a file
+// holds its input as characters, and converts all of it to a string once, the first time Slice is called, as
+// does a stream whose string is not valid UTF-8, as its characters then differ from the bytes of the string.
+//
+// The string returned is immutable, like any other, and may be kept for as long as it is needed, but it keeps
+// all the text of the stream in memory while it is reachable, even after the stream itself is discarded. A
+// piece that is to outlive the stream, and is much smaller than the input, such as a name kept in a symbol
+// table, should be copied with [strings.Clone], or taken with GetTextFromInterval, so that the input can be
+// freed.
+func (is *InputStream) Slice(i Interval) string {
+	start, stop := i.Start, i.Stop
+	if start < 0 || start > stop+1 {
+		return ""
+	}
+	if stop >= is.size {
+		stop = is.size - 1
+	}
+	if start >= is.size || start > stop {
+		return ""
+	}
+	is.indexText()
+	return is.text[is.byteOffset(start):is.byteOffset(stop+1)]
+}
+
+// indexText makes sure that the stream has its text, and the byte offsets of its characters, for Slice.
+func (is *InputStream) indexText() {
+	if is.indexed {
+		return
+	}
+	is.indexed = true
+	if is.text == "" || !utf8.ValidString(is.text) {
+		// the characters of a string that is not valid UTF-8 differ from its bytes
+		is.text = string(is.data)
+	}
+	if len(is.text) == is.size {
+		// an ASCII text has one byte per character
+		return
+	}
+	is.offsets = make([]int, 0, is.size/sliceOffsetStride+1)
+	n := 0
+	for offset := range is.text {
+		if n%sliceOffsetStride == 0 {
+			is.offsets = append(is.offsets, offset)
+		}
+		n++
+	}
+	if n%sliceOffsetStride == 0 {
+		// the offset of the end of the text, for a slice that ends with the last character
+		is.offsets = append(is.offsets, len(is.text))
+	}
+}
+
+// byteOffset returns the offset in the text of the stream of the character with the given index, or the length
+// of the text for the index one past the last character.
+func (is *InputStream) byteOffset(index int) int {
+	if is.offsets == nil {
+		return index
+	}
+	offset := is.offsets[index/sliceOffsetStride]
+	for n := index % sliceOffsetStride; n > 0; n-- {
+		_, width := utf8.DecodeRuneInString(is.text[offset:])
+		offset += width
+	}
+	return offset
+}
+
+// Slice returns the text within the interval, as [UnbufferedCharStream.GetText] does. The text is always a
+// copy, as the stream holds only a window of its input.
+func (u *UnbufferedCharStream) Slice(i Interval) string {
+	return u.GetText(i.Start, i.Stop)
+}
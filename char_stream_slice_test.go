@@ -0,0 +1,62 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInputStreamSlice(t *testing.T) {
+	// the texts are longer than sliceOffsetStride, so that offsets are found past the first recorded one
+	ascii := strings.Repeat("abcdefghij", 20)
+	unicode := strings.Repeat("aé€😀b", 40)
+	invalid := "a\xffb" + strings.Repeat("é", 70)
+	for _, text := range []string{ascii, unicode, invalid, strings.Repeat("é", 2*sliceOffsetStride), ""} {
+		for _, input := range []*InputStream{NewInputStream(text), NewIoStream(strings.NewReader(text))} {
+			size := input.Size()
+			for _, i := range []Interval{
+				{0, size - 1}, {0, 0}, {1, 3}, {63, 64}, {64, 64}, {65, 130}, {size - 1, size - 1}, {size - 2, size + 5},
+				{5, 4}, {size, size + 1}, {-1, 3}, {4, 2},
+			} {
+				if got, want := input.Slice(i), input.GetTextFromInterval(i); got != want {
+					t.Errorf("%q: Slice(%s) = %q, want %q", text, i, got, want)
+				}
+				if got, want := SliceCharStream(input, i), input.GetTextFromInterval(i); got != want {
+					t.Errorf("%q: SliceCharStream(%s) = %q, want %q", text, i, got, want)
+				}
+			}
+		}
+	}
+
+	// once the stream is indexed, a slice does not copy the text
+	input := NewInputStream(unicode)
+	input.Slice(NewInterval(0, 0))
+	if allocs := testing.AllocsPerRun(10, func() { input.Slice(NewInterval(70, 150)) }); allocs != 0 {
+		t.Errorf("Slice() allocated %v times", allocs)
+	}
+}
+
+func TestSliceCharStream(t *testing.T) {
+	text := strings.Repeat("abcé", 10)
+	unbuffered := NewUnbufferedCharStream(strings.NewReader(text))
+	marker := unbuffered.Mark()
+	for unbuffered.LA(1) != TokenEOF {
+		unbuffered.Consume()
+	}
+	// an unbuffered stream has the text of its window, and a stream with no Slice its own text
+	if got := SliceCharStream(unbuffered, NewInterval(38, 39)); got != "cé" {
+		t.Errorf("SliceCharStream() of an unbuffered stream = %q, want cé", got)
+	}
+	unbuffered.Release(marker)
+	if got := SliceCharStream(charStreamWithoutSlice{NewInputStream(text)}, NewInterval(2, 4)); got != "céa" {
+		t.Errorf("SliceCharStream() of a stream without Slice = %q, want céa", got)
+	}
+}
+
+// charStreamWithoutSlice hides the Slice method of the stream it holds.
+type charStreamWithoutSlice struct {
+	CharStream
+}
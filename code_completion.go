@@ -0,0 +1,451 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"slices"
+	"sort"
+)
+
+// CandidateRule is a rule that may begin at the caret, as collected by a [CodeCompletionCore] for one of its
+// preferred rules.
+type CandidateRule struct {
+	// StartTokenIndex is the index in the token stream of the token where the rule begins, which is the
+	// caret token, or an earlier token if the input before the caret is already part of the rule
+	StartTokenIndex int
+
+	// RuleList holds the indexes of the rules being parsed when the rule is invoked, outermost first, from
+	// the rule where collection began
+	RuleList []int
+}
+
+// CompletionCandidates holds the candidates collected by [CodeCompletionCore.CollectCandidates].
+type CompletionCandidates struct {
+	// Tokens maps each token type that may be typed at the caret to the token types that must follow it, if
+	// any, such as the parenthesis that always follows a keyword, which an editor may complete along with it
+	Tokens map[int][]int
+
+	// Rules maps the index of each preferred rule that may begin at the caret to how it is reached
+	Rules map[int]CandidateRule
+}
+
+// CodeCompletionCore collects the tokens and rules that may be typed at a caret in the input of a parser,
+// which is the core of code completion for an editor, in the manner of the antlr4-c3 library. It does not
+// parse the input, but walks the [ATN] of the parser over the tokens of its token stream, from the start of
+// a rule to the caret, following every path that matches them, and collects what may follow the tokens on
+// each.
+//
+// Tokens are collected as candidates unless they are ignored, see [CodeCompletionCore.SetIgnoredTokens],
+// such as punctuation that an editor need not offer. Where a token would be collected within a preferred
+// rule, see [CodeCompletionCore.SetPreferredRules], the rule is collected instead, such as a rule for a
+// variable reference, for which the editor offers the names of the variables in scope rather than an
+// identifier token.
+//
+// Semantic predicates are evaluated by the parser, with a nil context, as if they were not dependent on
+// it; precedence predicates are honoured. Only tokens on the default channel are considered.
+//
+// A core may be reused for many collections for the same parser, and keeps the follow sets it computes for
+// each rule to speed them up. It is not safe for concurrent use.
+//
+// Use:
+//
+//	split := antlr.SplitTokensAtCaret(stream, caret, nil)
+//	p := parser.NewMyParser(split.Tokens)
+//	core := antlr.NewCodeCompletionCore(p)
+//	core.SetIgnoredTokens(parser.MyParserLPAREN, parser.MyParserRPAREN, parser.MyParserSEMI)
+//	core.SetPreferredRules(parser.MyParserRULE_variableRef)
+//	candidates := core.CollectCandidates(split.TokenIndex, nil)
+//	for ttype := range candidates.Tokens {
+//	    fmt.Println(p.GetLiteralNames()[ttype])
+//	}
+type CodeCompletionCore struct {
+	parser         Parser
+	atn            *ATN
+	ignoredTokens  map[int]bool
+	preferredRules map[int]bool
+
+	// followSets holds the follow sets of the rules whose start states have been visited, by state number
+	followSets map[int]*completionFollowSets
+
+	// tokens holds the types of the tokens from where collection began to the caret, which is the last, and
+	// tokenIndexes their indexes in the token stream
+	tokens       []int
+	tokenIndexes []int
+
+	candidates *CompletionCandidates
+
+	// shortcuts holds the positions in tokens at which each rule has been found to end, for each position it
+	// was entered at, so that each rule is walked once for each
+	shortcuts map[completionShortcut][]int
+}
+
+// completionFollowSet is a set of tokens that may be the first of a rule, with the path of the rules invoked
+// to reach them, and the tokens that must follow them.
+type completionFollowSet struct {
+	intervals *IntervalSet
+	path      []int
+	following []int
+}
+
+// completionFollowSets holds the follow sets of a rule, and the combination of them. The combination holds
+// TokenEpsilon if the rule can match no tokens.
+type completionFollowSets struct {
+	sets     []completionFollowSet
+	combined *IntervalSet
+}
+
+// completionRuleEntry is a rule on the call stack of a [CodeCompletionCore], with the position in its tokens
+// at which it was entered.
+type completionRuleEntry struct {
+	startTokenIndex int
+	ruleIndex       int
+}
+
+type completionShortcut struct {
+	ruleIndex, startTokenIndex, precedence int
+}
+
+// NewCodeCompletionCore creates a [CodeCompletionCore] for parser, which collects candidates from its token
+// stream.
+func NewCodeCompletionCore(parser Parser) *CodeCompletionCore {
+	return &CodeCompletionCore{
+		parser:         parser,
+		atn:            parser.GetATN(),
+		ignoredTokens:  make(map[int]bool),
+		preferredRules: make(map[int]bool),
+		followSets:     make(map[int]*completionFollowSets),
+	}
+}
+
+// SetIgnoredTokens sets the token types that are never collected as candidates, in place of any set before.
+func (c *CodeCompletionCore) SetIgnoredTokens(types ...int) {
+	c.ignoredTokens = make(map[int]bool, len(types))
+	for _, t := range types {
+		c.ignoredTokens[t] = true
+	}
+	// the tokens that must follow a candidate leave out ignored tokens
+	c.followSets = make(map[int]*completionFollowSets)
+}
+
+// SetPreferredRules sets the indexes of the rules that are collected as candidates in place of the tokens
+// that may begin them, in place of any set before. Where preferred rules are nested, the outermost is
+// collected.
+func (c *CodeCompletionCore) SetPreferredRules(ruleIndexes ...int) {
+	c.preferredRules = make(map[int]bool, len(ruleIndexes))
+	for _, r := range ruleIndexes {
+		c.preferredRules[r] = true
+	}
+}
+
+// CollectCandidates returns the tokens and preferred rules that may be at the token with index caretTokenIndex
+// in the token stream of the parser, which is usually the token that the caret is within or before, see
+// [SplitTokensAtCaret]. The tokens are matched from the start of context, which is usually nil, to match them
+// from the start of the stream against the first rule of the grammar, or else a context of an earlier parse,
+// such as the statement that the caret is within, to match only its tokens against its rule.
+func (c *CodeCompletionCore) CollectCandidates(caretTokenIndex int, context ParserRuleContext) *CompletionCandidates {
+	c.candidates = &CompletionCandidates{Tokens: make(map[int][]int), Rules: make(map[int]CandidateRule)}
+	c.shortcuts = make(map[completionShortcut][]int)
+	c.tokens = c.tokens[:0]
+	c.tokenIndexes = c.tokenIndexes[:0]
+
+	start, startRule := 0, 0
+	if context != nil {
+		if t := context.GetStart(); t != nil {
+			start = t.GetTokenIndex()
+		}
+		startRule = context.GetRuleIndex()
+	}
+
+	stream := c.parser.GetTokenStream()
+	for i := start; ; i++ {
+		if s, ok := stream.(*CommonTokenStream); ok && i >= len(s.tokens) {
+			if !s.Sync(i) {
+				break
+			}
+		} else if i >= stream.Size() {
+			break
+		}
+		t := stream.Get(i)
+		if t.GetChannel() == TokenDefaultChannel {
+			c.tokens = append(c.tokens, t.GetTokenType())
+			c.tokenIndexes = append(c.tokenIndexes, t.GetTokenIndex())
+			if t.GetTokenIndex() >= caretTokenIndex {
+				break
+			}
+		}
+		if t.GetTokenType() == TokenEOF {
+			break
+		}
+	}
+	if len(c.tokens) == 0 {
+		return c.candidates
+	}
+
+	c.processRule(c.atn.ruleToStartState[startRule], 0, nil, 0)
+	return c.candidates
+}
+
+// processRule walks the rule that begins with startState from the position tokenIndex in the tokens, and
+// returns the positions at which it may end, collecting candidates if it reaches the caret.
+func (c *CodeCompletionCore) processRule(startState *RuleStartState, tokenIndex int, callStack []completionRuleEntry, precedence int) []int {
+	ruleIndex := startState.GetRuleIndex()
+	key := completionShortcut{ruleIndex: ruleIndex, startTokenIndex: tokenIndex, precedence: precedence}
+	if ends, ok := c.shortcuts[key]; ok {
+		return ends
+	}
+
+	callStack = append(callStack[:len(callStack):len(callStack)], completionRuleEntry{startTokenIndex: tokenIndex, ruleIndex: ruleIndex})
+	follow := c.followSetsFor(startState)
+
+	ends := make(map[int]bool)
+	if tokenIndex >= len(c.tokens)-1 {
+		// at the caret, collect what may begin the rule
+		if c.preferredRules[ruleIndex] {
+			c.translateStackToRuleIndex(callStack)
+		} else {
+			for _, set := range follow.sets {
+				path := slices.Clone(callStack)
+				for _, r := range set.path {
+					path = append(path, completionRuleEntry{startTokenIndex: tokenIndex, ruleIndex: r})
+				}
+				if !c.translateStackToRuleIndex(path) {
+					c.addTokens(set.intervals, set.following)
+				}
+			}
+		}
+		// the rule may be passed over without a token, so that what follows it may be typed too
+		if follow.combined.contains(TokenEpsilon) {
+			ends[tokenIndex] = true
+		}
+		return c.shortcut(key, ends)
+	}
+
+	// walk the rule only if it may be passed over without a token, or the current token may be matched
+	// somewhere in it
+	if !follow.combined.contains(TokenEpsilon) && !follow.combined.contains(c.tokens[tokenIndex]) {
+		return c.shortcut(key, ends)
+	}
+
+	type pipelineEntry struct {
+		state      ATNState
+		tokenIndex int
+	}
+	pipeline := []pipelineEntry{{state: startState, tokenIndex: tokenIndex}}
+	seen := make(map[pipelineEntry]bool)
+	for len(pipeline) > 0 {
+		entry := pipeline[len(pipeline)-1]
+		pipeline = pipeline[:len(pipeline)-1]
+		if seen[entry] {
+			continue
+		}
+		seen[entry] = true
+
+		if _, ok := entry.state.(*RuleStopState); ok {
+			ends[entry.tokenIndex] = true
+			continue
+		}
+		atCaret := entry.tokenIndex >= len(c.tokens)-1
+		for _, t := range entry.state.GetTransitions() {
+			switch t := t.(type) {
+			case *RuleTransition:
+				for _, end := range c.processRule(t.getTarget().(*RuleStartState), entry.tokenIndex, callStack, t.precedence) {
+					pipeline = append(pipeline, pipelineEntry{state: t.followState, tokenIndex: end})
+				}
+			case *PredicateTransition:
+				if t.getPredicate().evaluate(c.parser, nil) {
+					pipeline = append(pipeline, pipelineEntry{state: t.getTarget(), tokenIndex: entry.tokenIndex})
+				}
+			case *PrecedencePredicateTransition:
+				if t.precedence >= precedence {
+					pipeline = append(pipeline, pipelineEntry{state: t.getTarget(), tokenIndex: entry.tokenIndex})
+				}
+			default:
+				if t.getIsEpsilon() {
+					pipeline = append(pipeline, pipelineEntry{state: t.getTarget(), tokenIndex: entry.tokenIndex})
+					continue
+				}
+				set := c.transitionTokens(t)
+				if set == nil || set.length() == 0 {
+					continue
+				}
+				if atCaret {
+					if !c.translateStackToRuleIndex(callStack) {
+						c.addTokens(set, c.followingTokens(t))
+					}
+				} else if set.contains(c.tokens[entry.tokenIndex]) {
+					pipeline = append(pipeline, pipelineEntry{state: t.getTarget(), tokenIndex: entry.tokenIndex + 1})
+				}
+			}
+		}
+	}
+	return c.shortcut(key, ends)
+}
+
+// shortcut records the positions at which a rule ends, for the rule and position it was entered at, and
+// returns them in order.
+func (c *CodeCompletionCore) shortcut(key completionShortcut, ends map[int]bool) []int {
+	positions := make([]int, 0, len(ends))
+	for end := range ends {
+		positions = append(positions, end)
+	}
+	sort.Ints(positions)
+	c.shortcuts[key] = positions
+	return positions
+}
+
+// transitionTokens returns the set of token types that transition t matches.
+func (c *CodeCompletionCore) transitionTokens(t Transition) *IntervalSet {
+	switch t.(type) {
+	case *WildcardTransition:
+		set := NewIntervalSet()
+		set.addRange(TokenMinUserTokenType, c.atn.maxTokenType)
+		return set
+	case *NotSetTransition:
+		return t.getLabel().complement(TokenMinUserTokenType, c.atn.maxTokenType)
+	}
+	return t.getLabel()
+}
+
+// addTokens collects the token types of set as candidates, but those that are ignored, with the tokens that
+// must follow them. A token collected again with other tokens following it is kept with none.
+func (c *CodeCompletionCore) addTokens(set *IntervalSet, following []int) {
+	for _, v := range set.GetIntervals() {
+		for ttype := v.Start; ttype < v.Stop; ttype++ {
+			if ttype == TokenEpsilon || c.ignoredTokens[ttype] {
+				continue
+			}
+			if existing, ok := c.candidates.Tokens[ttype]; !ok {
+				c.candidates.Tokens[ttype] = following
+			} else if !slices.Equal(existing, following) {
+				c.candidates.Tokens[ttype] = nil
+			}
+		}
+	}
+}
+
+// translateStackToRuleIndex collects the outermost preferred rule on the call stack as a candidate, and returns
+// true if there is one.
+func (c *CodeCompletionCore) translateStackToRuleIndex(callStack []completionRuleEntry) bool {
+	if len(c.preferredRules) == 0 {
+		return false
+	}
+	for i, entry := range callStack {
+		if !c.preferredRules[entry.ruleIndex] {
+			continue
+		}
+		if _, ok := c.candidates.Rules[entry.ruleIndex]; !ok {
+			path := make([]int, i)
+			for j := range path {
+				path[j] = callStack[j].ruleIndex
+			}
+			c.candidates.Rules[entry.ruleIndex] = CandidateRule{
+				StartTokenIndex: c.tokenIndexes[min(entry.startTokenIndex, len(c.tokenIndexes)-1)],
+				RuleList:        path,
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// followSetsFor returns the follow sets of the rule that begins with startState, which are computed once.
+func (c *CodeCompletionCore) followSetsFor(startState *RuleStartState) *completionFollowSets {
+	if follow, ok := c.followSets[startState.GetStateNumber()]; ok {
+		return follow
+	}
+	follow := &completionFollowSets{combined: NewIntervalSet()}
+	follow.sets = c.collectFollowSets(startState, nil, nil)
+	for _, set := range follow.sets {
+		follow.combined.addSet(set.intervals)
+	}
+	c.followSets[startState.GetStateNumber()] = follow
+	return follow
+}
+
+// collectFollowSets returns the sets of tokens that may be matched first from state s, with the path of
+// the rules invoked, after those on ruleStack, to reach each. A set holding TokenEpsilon marks the end of
+// the rule, reached without a token. The states on stateStack are those being visited, which are skipped.
+func (c *CodeCompletionCore) collectFollowSets(s ATNState, stateStack []ATNState, ruleStack []int) []completionFollowSet {
+	if slices.Contains(stateStack, s) {
+		return nil
+	}
+	stateStack = append(stateStack, s)
+
+	if _, ok := s.(*RuleStopState); ok {
+		set := NewIntervalSet()
+		set.addOne(TokenEpsilon)
+		return []completionFollowSet{{intervals: set, path: slices.Clone(ruleStack)}}
+	}
+
+	var sets []completionFollowSet
+	for _, t := range s.GetTransitions() {
+		switch t := t.(type) {
+		case *RuleTransition:
+			ruleIndex := t.getTarget().GetRuleIndex()
+			if slices.Contains(ruleStack, ruleIndex) {
+				continue
+			}
+			// the end of the invoked rule is not the end of this one, so what follows the invocation is
+			// collected in its place
+			passed := false
+			for _, set := range c.collectFollowSets(t.getTarget(), stateStack, append(ruleStack, ruleIndex)) {
+				if set.intervals.contains(TokenEpsilon) && len(set.path) == len(ruleStack)+1 {
+					passed = true
+					continue
+				}
+				sets = append(sets, set)
+			}
+			if passed {
+				sets = append(sets, c.collectFollowSets(t.followState, stateStack, ruleStack)...)
+			}
+		case *PredicateTransition:
+			if t.getPredicate().evaluate(c.parser, nil) {
+				sets = append(sets, c.collectFollowSets(t.getTarget(), stateStack, ruleStack)...)
+			}
+		default:
+			if t.getIsEpsilon() {
+				sets = append(sets, c.collectFollowSets(t.getTarget(), stateStack, ruleStack)...)
+				continue
+			}
+			if set := c.transitionTokens(t); set != nil && set.length() > 0 {
+				sets = append(sets, completionFollowSet{
+					intervals: set,
+					path:      slices.Clone(ruleStack),
+					following: c.followingTokens(t),
+				})
+			}
+		}
+	}
+	return sets
+}
+
+// followingTokens returns the token types that must follow the token matched by transition t, up to the
+// first choice, or the first token that is ignored.
+func (c *CodeCompletionCore) followingTokens(t Transition) []int {
+	var following []int
+	seen := make(map[ATNState]bool)
+	for s := t.getTarget(); !seen[s]; {
+		seen[s] = true
+		transitions := s.GetTransitions()
+		if len(transitions) != 1 {
+			break
+		}
+		switch next := transitions[0].(type) {
+		case *AtomTransition:
+			if c.ignoredTokens[next.getLabel().first()] {
+				return following
+			}
+			following = append(following, next.getLabel().first())
+		case *RuleTransition, *PredicateTransition, *PrecedencePredicateTransition:
+			return following
+		default:
+			if !next.getIsEpsilon() {
+				return following
+			}
+		}
+		s = transitions[0].getTarget()
+	}
+	return following
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestCodeCompletionCore(t *testing.T) {
+	tests := []struct {
+		input string
+		caret int
+		want  map[int][]int
+	}{
+		// a new item, or the + of b + ..., or the end
+		{"a b", 2, map[int][]int{TokenEOF: nil, listID: nil, listPLUS: {listID}}},
+		{"a", 0, map[int][]int{TokenEOF: nil, listID: nil}},
+		{"a +", 2, map[int][]int{listID: nil}},
+		// the tokens after the caret are not matched
+		{"a + b", 1, map[int][]int{TokenEOF: nil, listID: nil, listPLUS: {listID}}},
+	}
+	for _, test := range tests {
+		lexer := newListLexer(NewInputStream(test.input))
+		p := newListParser(NewCommonTokenStream(lexer, TokenDefaultChannel))
+		candidates := NewCodeCompletionCore(p).CollectCandidates(test.caret, nil)
+		if !maps.EqualFunc(candidates.Tokens, test.want, slices.Equal) || len(candidates.Rules) != 0 {
+			t.Errorf("%q at %d: candidates %v, want %v", test.input, test.caret, candidates.Tokens, test.want)
+		}
+	}
+}
+
+func TestCodeCompletionCoreRules(t *testing.T) {
+	p, tree := listParse("a b + c")
+	core := NewCodeCompletionCore(p)
+
+	// an ignored token is neither a candidate nor a token that must follow one
+	core.SetIgnoredTokens(listID)
+	if got := core.CollectCandidates(2, nil).Tokens; len(got) != 2 || got[listPLUS] != nil || got[TokenEOF] != nil {
+		t.Errorf("candidates %v without ID", got)
+	}
+	core.SetIgnoredTokens()
+
+	// the item b + c is already begun at the caret, and the end of s is still a token
+	core.SetPreferredRules(listRuleItem)
+	want := &CompletionCandidates{
+		Tokens: map[int][]int{TokenEOF: nil},
+		Rules:  map[int]CandidateRule{listRuleItem: {StartTokenIndex: 1, RuleList: []int{listRuleS}}},
+	}
+	if got := core.CollectCandidates(2, nil); !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates %+v, want %+v", got, want)
+	}
+
+	// from the item of an earlier parse, only its tokens are matched against it
+	item := tree.GetChild(1).(ParserRuleContext)
+	want = &CompletionCandidates{
+		Tokens: map[int][]int{},
+		Rules:  map[int]CandidateRule{listRuleItem: {StartTokenIndex: 1, RuleList: []int{}}},
+	}
+	if got := core.CollectCandidates(3, item); !reflect.DeepEqual(got, want) {
+		t.Errorf("candidates within %s: %+v, want %+v", item.GetText(), got, want)
+	}
+	core.SetPreferredRules()
+	if got := core.CollectCandidates(3, item).Tokens; !reflect.DeepEqual(got, map[int][]int{listID: nil}) {
+		t.Errorf("candidates within %s: %v", item.GetText(), got)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "fmt"
+
+// SyntaxErrorInfo is a syntax error collected by a [CollectingErrorListener]: its position as reported to
+// the listener, with lines counted from 1 and columns from 0 as for a [Token], and all that is known about it
+// as a [SyntaxDiagnostic]. It is an error itself, which wraps [ErrSyntax].
+type SyntaxErrorInfo struct {
+	Line, Column int
+	SyntaxDiagnostic
+}
+
+func (e SyntaxErrorInfo) String() string {
+	return fmt.Sprintf("line %d:%d %s", e.Line, e.Column, e.Message)
+}
+
+// Error describes the error as the console error listener does, as in
+//
+//	line 1:4 mismatched input '+' expecting ID
+func (e SyntaxErrorInfo) Error() string {
+	return e.String()
+}
+
+// Unwrap returns [ErrSyntax], so that errors.Is(err, ErrSyntax) is true.
+func (e SyntaxErrorInfo) Unwrap() error {
+	return ErrSyntax
+}
+
+// CollectingErrorListener is an [ErrorListener] that collects the syntax errors reported by a lexer and a
+// parser, in the order they are reported, so that they can be examined once the parse is done, rather than
+// printed as the [ConsoleErrorListener] does. It may be given a limit on the number of errors: once more
+// errors than that are reported, the parse is aborted, as input with that many errors is not worth parsing to
+// the end, and the errors beyond the limit are not collected.
+//
+// A listener collects the errors of one parse at a time; call [CollectingErrorListener.Reset] before reusing
+// it for another.
+//
+// Use:
+//
+//	errs := antlr.NewCollectingErrorListener(100)
+//	lexer.RemoveErrorListeners()
+//	lexer.AddErrorListener(errs)
+//	p.RemoveErrorListeners()
+//	p.AddErrorListener(errs)
+//	tree := p.Document()
+//	for _, e := range errs.Errors() {
+//	    fmt.Println(e)
+//	}
+//	if err := errs.Err(); err != nil {
+//	    // the parse was aborted, and the tree is partial
+//	}
+type CollectingErrorListener struct {
+	*DefaultErrorListener
+	maxErrors int
+	errors    []SyntaxErrorInfo
+
+	// reported is the number of errors reported, including those beyond the limit, and err the error the
+	// parse was aborted with once they exceeded it
+	reported int
+	err      error
+}
+
+// NewCollectingErrorListener creates a [CollectingErrorListener] that aborts the parse once more than
+// maxErrors syntax errors are reported, or never if maxErrors is 0 or less.
+func NewCollectingErrorListener(maxErrors int) *CollectingErrorListener {
+	return &CollectingErrorListener{DefaultErrorListener: NewDefaultErrorListener(), maxErrors: maxErrors}
+}
+
+// SyntaxError collects the error, or if it is one more than the limit, aborts the parse. A parser that
+// reports it is aborted at once, and its error is set to a [ParseCancellationException] whose cause wraps
+// [ErrParseLimitExceeded]. A lexer cannot be aborted, so an error it reports beyond the limit aborts the
+// parser at the next error the parser reports; [CollectingErrorListener.Err] reports the limit as soon as it
+// is exceeded either way.
+func (c *CollectingErrorListener) SyntaxError(recognizer Recognizer, offendingSymbol interface{}, line, column int, msg string, e RecognitionException) {
+	c.reported++
+	if c.maxErrors <= 0 || c.reported <= c.maxErrors {
+		c.errors = append(c.errors, SyntaxErrorInfo{
+			Line:             line,
+			Column:           column,
+			SyntaxDiagnostic: NewSyntaxDiagnostic(recognizer, offendingSymbol, line, column, msg, e),
+		})
+		return
+	}
+	if c.err == nil {
+		c.err = fmt.Errorf("%w: more than %d syntax errors", ErrParseLimitExceeded, c.maxErrors)
+	}
+	if p, ok := recognizer.(interface{ cancel(error) }); ok {
+		p.cancel(c.err)
+	}
+}
+
+// Errors returns the errors collected, in the order they were reported.
+func (c *CollectingErrorListener) Errors() []SyntaxErrorInfo {
+	return c.errors
+}
+
+// SyntaxErrors returns the errors collected as a [SyntaxErrors] error, or nil if there are none.
+func (c *CollectingErrorListener) SyntaxErrors() error {
+	return SyntaxErrors(c.errors).Err()
+}
+
+// Len returns the number of errors reported, including those beyond the limit, which were not collected.
+func (c *CollectingErrorListener) Len() int {
+	return c.reported
+}
+
+// Err returns an error wrapping [ErrParseLimitExceeded] if more errors were reported than the limit, and nil
+// otherwise.
+func (c *CollectingErrorListener) Err() error {
+	return c.err
+}
+
+// Reset discards the errors collected, so that the listener can collect those of another parse.
+func (c *CollectingErrorListener) Reset() {
+	c.errors = nil
+	c.reported = 0
+	c.err = nil
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"testing"
+)
+
+// collectErrors parses input with a CollectingErrorListener listening to both the lexer and the parser.
+func collectErrors(input string, errs *CollectingErrorListener) *listParser {
+	lexer := newListLexer(NewInputStream(input))
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errs)
+	p := newListParser(NewCommonTokenStream(lexer, TokenDefaultChannel))
+	p.RemoveErrorListeners()
+	p.AddErrorListener(errs)
+	p.S()
+	return p
+}
+
+func TestCollectingErrorListener(t *testing.T) {
+	errs := NewCollectingErrorListener(0)
+	p := collectErrors("a + + b $ c + + d", errs)
+	want := []string{
+		"line 1:4 extraneous input '+' expecting ID",
+		"line 1:8 token recognition error at: '$'",
+		"line 1:14 extraneous input '+' expecting ID",
+	}
+	if len(errs.Errors()) != len(want) || errs.Len() != len(want) || errs.Err() != nil || p.GetError() != nil {
+		t.Fatalf("%d errors collected of %d: %v", len(errs.Errors()), errs.Len(), errs.Errors())
+	}
+	for i, e := range errs.Errors() {
+		if e.String() != want[i] {
+			t.Errorf("error %d is %s, want %s", i, e, want[i])
+		}
+	}
+	if e := errs.Errors()[2]; e.Line != 1 || e.Column != 14 || e.Code != DiagnosticExtraneousInput || e.OffendingToken.GetText() != "+" {
+		t.Errorf("the error has the diagnostic %+v", e.SyntaxDiagnostic)
+	}
+
+	errs.Reset()
+	if errs.Errors() != nil || errs.Len() != 0 || errs.Err() != nil {
+		t.Errorf("%d errors after Reset", errs.Len())
+	}
+}
+
+func TestCollectingErrorListenerLimit(t *testing.T) {
+	errs := NewCollectingErrorListener(2)
+	p := collectErrors("a + + b + + c + + d", errs)
+	if len(errs.Errors()) != 2 || errs.Len() != 3 || !errors.Is(errs.Err(), ErrParseLimitExceeded) {
+		t.Errorf("%d errors collected of %d: %v", len(errs.Errors()), errs.Len(), errs.Err())
+	}
+	if cancelled, ok := p.GetError().(*ParseCancellationException); !ok || !errors.Is(cancelled, ErrParseLimitExceeded) {
+		t.Errorf("the parse was not aborted: %v", p.GetError())
+	}
+
+	// the errors of a lexer beyond the limit abort the parser at its next error
+	errs = NewCollectingErrorListener(2)
+	p = collectErrors("$ $ $ a b", errs)
+	if errs.Len() != 3 || !errors.Is(errs.Err(), ErrParseLimitExceeded) || p.GetError() != nil {
+		t.Errorf("%d errors reported, with %v, and the parse ended with %v", errs.Len(), errs.Err(), p.GetError())
+	}
+	errs.Reset()
+	p = collectErrors("$ $ $ a + + b + + c", errs)
+	cancelled, ok := p.GetError().(*ParseCancellationException)
+	if !ok || errs.Len() != 4 || !errors.Is(cancelled, ErrParseLimitExceeded) {
+		t.Errorf("%d errors reported, and the parse ended with %v", errs.Len(), p.GetError())
+	}
+}
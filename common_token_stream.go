@@ -42,6 +42,12 @@ type CommonTokenStream struct {
 	// tokens contains all tokens fetched from the token source. The list is considered a
 	// complete view of the input once fetchedEOF is set to true.
 	tokens []Token
+
+	// counts holds the number of tokens fetched of each type and on each channel, see Summary
+	counts tokenCounts
+
+	// channelIndex is the index of the tokens on channel, or nil if it is off, see SetChannelIndexing
+	channelIndex *tokenChannelIndex
 }
 
 // NewCommonTokenStream creates a new CommonTokenStream instance using the supplied lexer to produce
@@ -66,12 +72,20 @@ func (c *CommonTokenStream) Mark() int {
 
 func (c *CommonTokenStream) Release(_ int) {}
 
+// Reset discards all buffered tokens, so that they are fetched again from the token source, which must
+// itself have been reset to the start of the input first. To reparse the tokens that have already been
+// fetched, use Seek(0), or [ResetStreamAndParser], instead.
 func (c *CommonTokenStream) Reset() {
 	c.fetchedEOF = false
 	c.tokens = make([]Token, 0)
+	c.counts.reset()
+	c.reindexChannel()
 	c.Seek(0)
 }
 
+// Seek moves the stream to the token at index, or the first token on the stream's channel after it.
+// Tokens that have already been fetched are never fetched again, so Seek(0) is a cheap way to rewind the
+// stream and parse the same tokens again. See [ResetStreamAndParser].
 func (c *CommonTokenStream) Seek(index int) {
 	c.lazyInit()
 	c.index = c.adjustSeekIndex(index)
@@ -109,6 +123,13 @@ func (c *CommonTokenStream) Consume() {
 	}
 }
 
+// IsAtEOF returns true if the current token on the channel of the stream is the EOF token, so that the
+// stream cannot be consumed any further. It fetches tokens from the token source as far as the next token on
+// the channel.
+func (c *CommonTokenStream) IsAtEOF() bool {
+	return c.LA(1) == TokenEOF
+}
+
 // Sync makes sure index i in tokens has a token and returns true if a token is
 // located at index i and otherwise false.
 func (c *CommonTokenStream) Sync(i int) bool {
@@ -134,6 +155,10 @@ func (c *CommonTokenStream) fetch(n int) int {
 
 		t.SetTokenIndex(len(c.tokens))
 		c.tokens = append(c.tokens, t)
+		c.counts.add(t, 1)
+		if c.channelIndex != nil {
+			c.channelIndex.add(t, t.GetChannel() == c.channel)
+		}
 
 		if t.GetTokenType() == TokenEOF {
 			c.fetchedEOF = true
@@ -197,6 +222,8 @@ func (c *CommonTokenStream) GetTokenSource() TokenSource {
 func (c *CommonTokenStream) SetTokenSource(tokenSource TokenSource) {
 	c.tokenSource = tokenSource
 	c.tokens = make([]Token, 0)
+	c.counts.reset()
+	c.reindexChannel()
 	c.index = -1
 	c.fetchedEOF = false
 }
@@ -210,6 +237,9 @@ func (c *CommonTokenStream) NextTokenOnChannel(i, _ int) int {
 	if i >= len(c.tokens) {
 		return -1
 	}
+	if c.channelIndex != nil {
+		return c.nextIndexedOnChannel(i)
+	}
 
 	token := c.tokens[i]
 
@@ -230,6 +260,9 @@ func (c *CommonTokenStream) NextTokenOnChannel(i, _ int) int {
 // given a starting index. Returns i if tokens[i] is on channel. Returns -1 if
 // there are no tokens on channel between i and 0.
 func (c *CommonTokenStream) previousTokenOnChannel(i, channel int) int {
+	if c.channelIndex != nil && channel == c.channel && i >= 0 {
+		return c.previousIndexedOnChannel(i)
+	}
 	for i >= 0 && c.tokens[i].GetChannel() != channel {
 		i--
 	}
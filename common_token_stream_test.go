@@ -0,0 +1,35 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestResetStreamAndParser(t *testing.T) {
+	stream := NewCommonTokenStream(newListLexer(NewInputStream("a b + + c")), TokenDefaultChannel)
+	p := newListParser(stream)
+	p.RemoveErrorListeners()
+	first := p.S().ToStringTree(nil, p)
+	tokens := stream.GetAllTokens()
+	if p._SyntaxErrors != 1 || p.GetTokenStream().LA(1) != TokenEOF {
+		t.Fatalf("%d syntax errors", p._SyntaxErrors)
+	}
+
+	ResetStreamAndParser(p, stream)
+	if stream.Index() != 0 || p.GetParserRuleContext() != nil || p._SyntaxErrors != 0 {
+		t.Errorf("the stream is at %d and the parser has %d syntax errors", stream.Index(), p._SyntaxErrors)
+	}
+	// The tokens are parsed again, rather than fetched again from the lexer
+	if again := p.S().ToStringTree(nil, p); again != first || p._SyntaxErrors != 1 {
+		t.Errorf("tree %s with %d errors, want %s", again, p._SyntaxErrors, first)
+	}
+	if all := stream.GetAllTokens(); len(all) != len(tokens) || all[0] != tokens[0] {
+		t.Error("the tokens were fetched again")
+	}
+
+	stream.Seek(2)
+	if stream.LT(1).GetText() != "+" {
+		t.Errorf("Seek(2) moved to %s", stream.LT(1))
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// ErrorStrategyChooser chooses the [ErrorStrategy] that handles an error, or a step that may find one, at the
+// current state of the parse. It is given the parser, whose current context, state and token it may examine.
+type ErrorStrategyChooser func(recognizer Parser) ErrorStrategy
+
+// CompositeErrorStrategy combines error strategies, handing each call to the strategy that a chooser picks
+// for the current state of the parse, such as bailing out within some rules and recovering elsewhere. Once
+// the strategy chosen has reported an error and begun to recover from it, it handles every call until the
+// parser matches a token again and the recovery ends, so that a recovery is never finished by a strategy
+// other than the one that began it.
+//
+// [NewBailForTokensErrorStrategy] and [NewBailInRulesErrorStrategy] build the common combinations.
+//
+// Use:
+//
+//	bail, recover := antlr.NewBailErrorStrategy(), antlr.NewDefaultErrorStrategy()
+//	p.SetErrorHandler(antlr.NewCompositeErrorStrategy(func(recognizer antlr.Parser) antlr.ErrorStrategy {
+//	    if recognizer.GetCurrentToken().GetLine() <= headerLines {
+//	        return bail
+//	    }
+//	    return recover
+//	}))
+type CompositeErrorStrategy struct {
+	choose ErrorStrategyChooser
+
+	// chosen holds each strategy chosen so far, so that they are all reset with the parser
+	chosen []ErrorStrategy
+
+	// recovering is the strategy that began the current recovery, if any
+	recovering ErrorStrategy
+}
+
+var _ ErrorStrategy = &CompositeErrorStrategy{}
+
+// NewCompositeErrorStrategy creates a strategy that hands each call to the strategy choose picks. The
+// chooser should return the same few strategies each time rather than creating new ones, as strategies
+// keep state between calls.
+func NewCompositeErrorStrategy(choose ErrorStrategyChooser) *CompositeErrorStrategy {
+	return &CompositeErrorStrategy{choose: choose}
+}
+
+// NewBailForTokensErrorStrategy creates a strategy that bails out, as a [BailErrorStrategy] does, on an error
+// at any of the first n tokens of the input, and hands errors after them to then, or to a new
+// [DefaultErrorStrategy] if then is nil. It suits input whose first tokens must be well formed for the rest
+// to be worth parsing, such as a header or a version declaration.
+func NewBailForTokensErrorStrategy(n int, then ErrorStrategy) *CompositeErrorStrategy {
+	if then == nil {
+		then = NewDefaultErrorStrategy()
+	}
+	bail := NewBailErrorStrategy()
+	return NewCompositeErrorStrategy(func(recognizer Parser) ErrorStrategy {
+		if recognizer.GetCurrentToken().GetTokenIndex() < n {
+			return bail
+		}
+		return then
+	})
+}
+
+// NewBailInRulesErrorStrategy creates a strategy that bails out, as a [BailErrorStrategy] does, on an error
+// found while parsing any of the rules with the given indexes, including within the rules they invoke, and
+// hands errors elsewhere to otherwise, or to a new [DefaultErrorStrategy] if otherwise is nil.
+//
+// Use:
+//
+//	p.SetErrorHandler(antlr.NewBailInRulesErrorStrategy(nil, parser.MyParserRULE_importDecl))
+func NewBailInRulesErrorStrategy(otherwise ErrorStrategy, ruleIndexes ...int) *CompositeErrorStrategy {
+	if otherwise == nil {
+		otherwise = NewDefaultErrorStrategy()
+	}
+	bail := NewBailErrorStrategy()
+	rules := make(map[int]bool, len(ruleIndexes))
+	for _, r := range ruleIndexes {
+		rules[r] = true
+	}
+	return NewCompositeErrorStrategy(func(recognizer Parser) ErrorStrategy {
+		for ctx := recognizer.GetParserRuleContext(); ctx != nil; {
+			if rules[ctx.GetRuleIndex()] {
+				return bail
+			}
+			parent, ok := ctx.GetParent().(ParserRuleContext)
+			if !ok {
+				break
+			}
+			ctx = parent
+		}
+		return otherwise
+	})
+}
+
+// current returns the strategy that handles a call at the current state of the parse: the strategy that
+// began the current recovery, if any, and otherwise the one chosen.
+func (c *CompositeErrorStrategy) current(recognizer Parser) ErrorStrategy {
+	if c.recovering != nil && c.recovering.InErrorRecoveryMode(recognizer) {
+		return c.recovering
+	}
+	c.recovering = nil
+	s := c.choose(recognizer)
+	for _, o := range c.chosen {
+		if o == s {
+			return s
+		}
+	}
+	c.chosen = append(c.chosen, s)
+	return s
+}
+
+// track notes that s began a recovery, if the call just handed to it did.
+func (c *CompositeErrorStrategy) track(recognizer Parser, s ErrorStrategy) {
+	if s.InErrorRecoveryMode(recognizer) {
+		c.recovering = s
+	}
+}
+
+func (c *CompositeErrorStrategy) reset(recognizer Parser) {
+	for _, s := range c.chosen {
+		s.reset(recognizer)
+	}
+	c.recovering = nil
+}
+
+func (c *CompositeErrorStrategy) RecoverInline(recognizer Parser) Token {
+	s := c.current(recognizer)
+	t := s.RecoverInline(recognizer)
+	c.track(recognizer, s)
+	return t
+}
+
+func (c *CompositeErrorStrategy) Recover(recognizer Parser, e RecognitionException) {
+	s := c.current(recognizer)
+	s.Recover(recognizer, e)
+	c.track(recognizer, s)
+}
+
+func (c *CompositeErrorStrategy) Sync(recognizer Parser) {
+	s := c.current(recognizer)
+	s.Sync(recognizer)
+	c.track(recognizer, s)
+}
+
+func (c *CompositeErrorStrategy) InErrorRecoveryMode(recognizer Parser) bool {
+	return c.recovering != nil && c.recovering.InErrorRecoveryMode(recognizer)
+}
+
+func (c *CompositeErrorStrategy) ReportError(recognizer Parser, e RecognitionException) {
+	s := c.current(recognizer)
+	s.ReportError(recognizer, e)
+	c.track(recognizer, s)
+}
+
+func (c *CompositeErrorStrategy) ReportMatch(recognizer Parser) {
+	s := c.current(recognizer)
+	s.ReportMatch(recognizer)
+	c.recovering = nil
+}
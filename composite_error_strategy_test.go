@@ -0,0 +1,87 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+// indexRecorder is a DefaultErrorStrategy that records the index of the token at each call handed to it, and
+// counts its resets.
+type indexRecorder struct {
+	*DefaultErrorStrategy
+	indexes []int
+	resets  int
+}
+
+func (r *indexRecorder) reset(recognizer Parser) {
+	r.resets++
+	r.DefaultErrorStrategy.reset(recognizer)
+}
+
+func (r *indexRecorder) Sync(recognizer Parser) {
+	r.indexes = append(r.indexes, recognizer.GetCurrentToken().GetTokenIndex())
+	r.DefaultErrorStrategy.Sync(recognizer)
+}
+
+func (r *indexRecorder) Recover(recognizer Parser, e RecognitionException) {
+	r.indexes = append(r.indexes, recognizer.GetCurrentToken().GetTokenIndex())
+	r.DefaultErrorStrategy.Recover(recognizer, e)
+}
+
+func TestCompositeErrorStrategy(t *testing.T) {
+	tests := []struct {
+		input     string
+		strategy  ErrorStrategy
+		cancelled bool
+		errors    int
+	}{
+		{"+ a b", NewBailForTokensErrorStrategy(2, nil), true, 0},
+		{"a + + b", NewBailForTokensErrorStrategy(2, nil), false, 1},
+		{"a + + b", NewBailInRulesErrorStrategy(nil, listRuleItem), true, 0},
+		{"a + + b", NewBailInRulesErrorStrategy(nil, listRuleS), true, 0},
+		{"a + + b", NewBailInRulesErrorStrategy(nil), false, 1},
+	}
+	for i, test := range tests {
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(test.input)), TokenDefaultChannel))
+		recorder := new(messageRecorder)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(recorder)
+		p.SetErrorHandler(test.strategy)
+		p.S()
+		if _, cancelled := p.GetError().(*ParseCancellationException); cancelled != test.cancelled || len(recorder.messages) != test.errors {
+			t.Errorf("%d: %q: cancelled %v with errors %v", i, test.input, cancelled, recorder.messages)
+		}
+	}
+}
+
+func TestCompositeErrorStrategyRecovery(t *testing.T) {
+	before := &indexRecorder{DefaultErrorStrategy: NewDefaultErrorStrategy()}
+	after := &indexRecorder{DefaultErrorStrategy: NewDefaultErrorStrategy()}
+	strategy := NewCompositeErrorStrategy(func(recognizer Parser) ErrorStrategy {
+		if recognizer.GetCurrentToken().GetTokenIndex() < 3 {
+			return before
+		}
+		return after
+	})
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a + + + b c")), TokenDefaultChannel))
+	p.RemoveErrorListeners()
+	p.SetErrorHandler(strategy)
+	p.S()
+
+	// the recovery from the error at the second + is finished by the strategy that began it, at b
+	if n := len(before.indexes); n == 0 || before.indexes[n-1] != 4 {
+		t.Errorf("the strategy for the first tokens handled calls at %v", before.indexes)
+	}
+	if len(after.indexes) == 0 || after.indexes[0] != 5 {
+		t.Errorf("the strategy for the last tokens handled calls at %v", after.indexes)
+	}
+	if strategy.InErrorRecoveryMode(p) {
+		t.Error("the parse ended in recovery")
+	}
+
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream("a")), TokenDefaultChannel))
+	if before.resets != after.resets || after.resets == 0 {
+		t.Errorf("the strategies were reset %d and %d times", before.resets, after.resets)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "sync"
+
+// configPool holds the [ATNConfig] objects, configuration sets and closure sets that a full context
+// prediction creates, so that the next such prediction can reuse them rather than allocate its own.
+type configPool struct {
+	arena Arena
+
+	// sets and busy hold what has been handed out since the pool was last recycled, and freeSets and freeBusy
+	// what is ready to be handed out again
+	sets     []*ATNConfigSet
+	freeSets []*ATNConfigSet
+	busy     []*ClosureBusy
+	freeBusy []*ClosureBusy
+}
+
+// configPools holds the pools not in use by any simulator, so that the simulators of many parsers share them.
+var configPools = sync.Pool{
+	New: func() any {
+		pool := &configPool{}
+		pool.arena.configs.keep = true
+		return pool
+	},
+}
+
+func (c *configPool) newConfigSet(fullCtx bool) *ATNConfigSet {
+	var set *ATNConfigSet
+	if n := len(c.freeSets); n > 0 {
+		set = c.freeSets[n-1]
+		c.freeSets = c.freeSets[:n-1]
+		set.fullCtx = fullCtx
+	} else {
+		set = NewATNConfigSet(fullCtx)
+	}
+	c.sets = append(c.sets, set)
+	return set
+}
+
+func (c *configPool) newClosureBusy(desc string) *ClosureBusy {
+	var busy *ClosureBusy
+	if n := len(c.freeBusy); n > 0 {
+		busy = c.freeBusy[n-1]
+		c.freeBusy = c.freeBusy[:n-1]
+	} else {
+		busy = NewClosureBusy(desc)
+	}
+	c.busy = append(c.busy, busy)
+	return busy
+}
+
+// recycle empties everything handed out since the pool was last recycled, keeping the space allocated for
+// it, so that it can be handed out again. Nothing may refer to any of it any more.
+func (c *configPool) recycle() {
+	c.arena.configs.recycle()
+	for _, set := range c.sets {
+		lookup := set.configLookup
+		if lookup == nil {
+			lookup = newConfigTable(false)
+		} else {
+			lookup.clear()
+		}
+		configs := set.configs
+		clear(configs)
+		*set = ATNConfigSet{
+			cachedHash:   -1,
+			configLookup: lookup,
+			configs:      configs[:0],
+		}
+	}
+	c.freeSets = append(c.freeSets, c.sets...)
+	clear(c.sets)
+	c.sets = c.sets[:0]
+
+	for _, busy := range c.busy {
+		if busy.bMap != nil {
+			busy.bMap.clear()
+		}
+	}
+	c.freeBusy = append(c.freeBusy, c.busy...)
+	clear(c.busy)
+	c.busy = c.busy[:0]
+}
+
+// SetConfigPooling turns the pooling of the configurations of full context predictions on or off. When it is
+// on, the [ATNConfig] objects and configuration sets created by each prediction that falls back to full
+// context are returned to a pool shared by all simulators once the prediction is made, and reused by the next,
+// rather than left for the garbage collector. Full context predictions create most of the short-lived objects
+// of a parse of a grammar with many ambiguous decisions, so pooling them lowers the GC pressure of services
+// that parse at a high rate. SLL predictions are not pooled, as the DFA keeps their configurations.
+//
+// The configuration sets passed to the ReportAttemptingFullContext, ReportContextSensitivity and
+// ReportAmbiguity methods of error listeners for full context predictions are recycled once the report
+// returns, so listeners must not keep them, or any configuration in them, while pooling is on. A prediction
+// that fails keeps its configurations, as the [NoViableAltException] holds them. Pooling is not used while
+// the simulator allocates from an [Arena], or is profiling.
+//
+// Use:
+//
+//	p.GetInterpreter().SetConfigPooling(true)
+func (p *ParserATNSimulator) SetConfigPooling(enabled bool) {
+	p.pooling = enabled
+}
+
+// IsConfigPooling returns true if the configurations of full context predictions are pooled. See
+// [ParserATNSimulator.SetConfigPooling].
+func (p *ParserATNSimulator) IsConfigPooling() bool {
+	return p.pooling
+}
+
+// withConfigPool calls predict with the configurations, sets and closure sets it creates taken from a pool, if
+// pooling is on, and recycles them once it returns, unless it returns an error, which may refer to them, or
+// panics.
+func (p *ParserATNSimulator) withConfigPool(predict func() (int, RecognitionException)) (alt int, err RecognitionException) {
+	if !p.pooling || p.pool != nil || p.arena != nil || p.profile != nil {
+		return predict()
+	}
+	pool := configPools.Get().(*configPool)
+	p.pool = pool
+	p.arena = &pool.arena
+	recycle := false
+	defer func() {
+		p.pool = nil
+		p.arena = nil
+		if recycle {
+			pool.recycle()
+			configPools.Put(pool)
+		}
+	}()
+
+	alt, err = predict()
+	recycle = err == nil
+	return alt, err
+}
+
+// newConfigSet creates a configuration set, from the pool while there is one.
+func (p *ParserATNSimulator) newConfigSet(fullCtx bool) *ATNConfigSet {
+	if p.pool != nil {
+		return p.pool.newConfigSet(fullCtx)
+	}
+	return NewATNConfigSet(fullCtx)
+}
+
+// newClosureBusy creates a closure set, from the pool while there is one.
+func (p *ParserATNSimulator) newClosureBusy(desc string) *ClosureBusy {
+	if p.pool != nil {
+		return p.pool.newClosureBusy(desc)
+	}
+	return NewClosureBusy(desc)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+// dfaConfigs returns the configurations of every state of the DFAs, as text, by state.
+func dfaConfigs(t *testing.T, decisionToDFA []*DFA) map[*DFAState]string {
+	t.Helper()
+	configs := make(map[*DFAState]string)
+	for _, dfa := range decisionToDFA {
+		for _, s := range dfa.sortedStates() {
+			if len(s.configs.configs) == 0 {
+				t.Fatalf("decision %d: state %d holds a recycled configuration set", dfa.decision, s.stateNumber)
+			}
+			for _, c := range s.configs.configs {
+				if c.GetState() == nil {
+					t.Fatalf("decision %d: state %d holds a recycled configuration", dfa.decision, s.stateNumber)
+				}
+			}
+			configs[s] = s.configs.String()
+		}
+	}
+	return configs
+}
+
+func TestConfigPoolingKeepsDFAConfigs(t *testing.T) {
+	decisionToDFA := benchDFA()
+	cache := NewPredictionContextCache()
+	parse := func(tokens []Token) {
+		p := benchParser(tokens, decisionToDFA, cache)
+		p.Interpreter.SetConfigPooling(true)
+		benchParse(t, p, tokens)
+		if p.Interpreter.GetPredictionStats().FullContextPredictions == 0 {
+			t.Fatal("the parse made no full context prediction")
+		}
+	}
+
+	parse(benchTokens(benchProgram, 2))
+	before := dfaConfigs(t, decisionToDFA)
+
+	// The second parse recycles the pooled configurations again, so a configuration of the first that had
+	// found its way into the DFA would now be zeroed or hold another configuration.
+	parse(benchTokens(benchNestedProgram, 2))
+	after := dfaConfigs(t, decisionToDFA)
+	for s, configs := range before {
+		if after[s] != configs {
+			t.Errorf("state %d: configurations changed from %s to %s", s.stateNumber, configs, after[s])
+		}
+	}
+}
+
+func TestConfigPoolRecycle(t *testing.T) {
+	pool := configPools.New().(*configPool)
+	set := pool.newConfigSet(true)
+	c := pool.arena.newATNConfig6(benchATN().states[0], 1, BasePredictionContextEMPTY)
+	set.Add(c, nil)
+	pool.recycle()
+
+	if c.GetState() != nil {
+		t.Error("recycle kept a configuration")
+	}
+	if len(set.configs) != 0 || set.fullCtx {
+		t.Errorf("recycle kept the set %s", set)
+	}
+	if reused := pool.newConfigSet(false); reused != set {
+		t.Error("the recycled set was not handed out again")
+	}
+	if reused := pool.arena.newATNConfig6(benchATN().states[1], 2, BasePredictionContextEMPTY); reused != c {
+		t.Error("the recycled configuration was not handed out again")
+	}
+}
@@ -10,6 +10,8 @@ type runtimeConfiguration struct {
 	parserATNSimulatorRetryDebug  bool
 	lRLoopEntryBranchOpt          bool
 	memoryManager                 bool
+	lexerDFAPrecompute            bool
+	altNumbersInTreeText          bool
 }
 
 // Global runtime configuration
@@ -212,3 +214,44 @@ func WithMemoryManager(use bool) runtimeOption {
 		return nil
 	}
 }
+
+// WithLexerDFAPrecompute sets the global flag indicating whether lexers should exhaustively precompute their [DFA]
+// for all modes when the lexer [ATN] simulator is created. The precomputation is bounded by partitioning the input
+// alphabet into classes of runes that behave identically, so each DFA state is expanded once per class rather than
+// once per rune.
+//
+// This trades startup time for latency: once a DFA is complete, the lexer reads it without taking any locks and
+// does not allocate. Lexers with semantic predicates cannot be fully precomputed,
+// as the predicates must be evaluated against the input. In that case, the DFA is partially populated and the
+// lexer falls back to normal, locked, DFA construction at runtime.
+//
+// Because the DFA is shared between all instances of a lexer, the work is done only once, by the first lexer
+// created after the option is turned on. Turn it on before creating any lexers, for instance in an init function.
+// See also [LexerATNSimulator.PrecomputeDFA].
+//
+// Note that default is not to precompute the lexer DFA.
+//
+// Use:
+//
+//	antlr.ConfigureRuntime(antlr.WithLexerDFAPrecompute(true))
+func WithLexerDFAPrecompute(precompute bool) runtimeOption {
+	return func(config *runtimeConfiguration) error {
+		config.lexerDFAPrecompute = precompute
+		return nil
+	}
+}
+
+// WithAltNumbersInTreeText sets the global flag indicating whether the text of a rule node, as produced by
+// [TreesGetNodeText] and hence by ToStringTree, includes the number of the alternative that matched, as in
+// "expr:2". The parser records the alternative number in every context it builds, so this is off by default
+// to keep the text of parse trees as it has always been.
+//
+// Use:
+//
+//	antlr.ConfigureRuntime(antlr.WithAltNumbersInTreeText(true))
+func WithAltNumbersInTreeText(show bool) runtimeOption {
+	return func(config *runtimeConfiguration) error {
+		config.altNumbersInTreeText = show
+		return nil
+	}
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// DumpContextStack writes the rule invocation stack of the parser to w, as it is when it is called, such as
+// from a predicate or an action, to see which rules a context-sensitive grammar is in when it makes a
+// decision. It writes the current token, and then a table of the rules being parsed, innermost first, one row
+// per rule, showing its depth, its name, the ATN state it is at, which for a rule other than the innermost is
+// the state that invoked the rule below it, and the token where it began, with its index, type name, line
+// and column, and text, quoted and with special characters escaped. Type names are looked up in the
+// vocabulary, typically the parser, which may be nil, in which case numbers are shown.
+//
+// Use:
+//
+//	// in a predicate of the grammar
+//	{ p.DumpContextStack(os.Stderr, p) }?
+func (p *BaseParser) DumpContextStack(w io.Writer, vocabulary Recognizer) error {
+	if t := p.GetCurrentToken(); t != nil {
+		if _, err := fmt.Fprintf(w, "current token %d %s %d:%d %s\n", t.GetTokenIndex(),
+			dumpTypeName(vocabulary, t.GetTokenType()), t.GetLine(), t.GetColumn(), strconv.Quote(t.GetText())); err != nil {
+			return err
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "DEPTH\tRULE\tSTATE\tSTART\tTYPE\tPOSITION\tTEXT")
+	state := p.GetState()
+	depth := 0
+	for c := p.ctx; c != nil; depth++ {
+		rule := "n/a"
+		if ruleIndex := c.GetRuleIndex(); ruleIndex >= 0 && ruleIndex < len(p.GetRuleNames()) {
+			rule = p.GetRuleNames()[ruleIndex]
+		}
+		if start := c.GetStart(); start != nil {
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%s\t%d:%d\t%s\n", depth, rule, state, start.GetTokenIndex(),
+				dumpTypeName(vocabulary, start.GetTokenType()), start.GetLine(), start.GetColumn(),
+				strconv.Quote(start.GetText()))
+		} else {
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%d\t-\t-\t-\t-\n", depth, rule, state)
+		}
+
+		state = c.GetInvokingState()
+		parent, ok := c.GetParent().(ParserRuleContext)
+		if !ok || parent == nil {
+			break
+		}
+		c = parent
+	}
+	return tw.Flush()
+}
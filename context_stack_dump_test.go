@@ -0,0 +1,58 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// failingWriter fails every write.
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
+
+func TestDumpContextStack(t *testing.T) {
+	// the lexer skips the newline as an error, but counts the line
+	lexer := newListLexer(NewInputStream("a b\n+ c"))
+	lexer.RemoveErrorListeners()
+	p := newListParser(NewCommonTokenStream(lexer, TokenDefaultChannel))
+	var dumps []string
+	p.SetHooks(ParserHooks{BeforeConsume: func(recognizer Parser, token Token) {
+		if token.GetText() == "+" {
+			for _, vocabulary := range []Recognizer{p, nil} {
+				var sb strings.Builder
+				if err := p.DumpContextStack(&sb, vocabulary); err != nil {
+					t.Fatal(err)
+				}
+				dumps = append(dumps, sb.String())
+			}
+			if err := p.DumpContextStack(failingWriter{}, p); err == nil {
+				t.Error("the error of the writer was not returned")
+			}
+		}
+	}})
+	p.S()
+
+	// item is at the transition that matches +, and s at the invocation of item
+	want := []string{`current token 2 PLUS 2:0 "+"
+DEPTH  RULE  STATE  START  TYPE  POSITION  TEXT
+0      item  15     1      ID    1:2       "b"
+1      s     4      0      ID    1:0       "a"
+`, `current token 2 2 2:0 "+"
+DEPTH  RULE  STATE  START  TYPE  POSITION  TEXT
+0      item  15     1      1     1:2       "b"
+1      s     4      0      1     1:0       "a"
+`}
+	if len(dumps) != len(want) {
+		t.Fatalf("%d dumps, want %d", len(dumps), len(want))
+	}
+	for i, dump := range dumps {
+		if dump != want[i] {
+			t.Errorf("dump:\n%s\nwant:\n%s", dump, want[i])
+		}
+	}
+}
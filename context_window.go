@@ -0,0 +1,118 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// contextWindowChunk is the number of characters read at a time to find the end of the last line of a window.
+const contextWindowChunk = 256
+
+// ContextWindow returns the source text around the token at tokenIndex of stream, formatted for an error
+// report or a log: the whole lines from the line of the token before tokens before it to the line of the
+// token after tokens after it, each prefixed with its line number, and under the line of the token, a line
+// that marks the token with carets. Tokens on a channel other than that of the token are not counted, and
+// tokens that the stream has not yet fetched are left out. The text is taken from the input stream of the
+// token, so it includes the text that the lexer skipped.
+//
+// It returns an empty string if there is no token at tokenIndex, or if the token has no input stream.
+//
+// Use:
+//
+//	fmt.Fprintf(os.Stderr, "line %d:%d %s\n%s", line, column, msg, antlr.ContextWindow(stream, t.GetTokenIndex(), 3, 3))
+//
+// which shows, for instance:
+//
+//	3 | total := price *
+//	4 |     (quantity + ) / 2
+//	  |                 ^
+func ContextWindow(stream TokenStream, tokenIndex, before, after int) string {
+	if tokenIndex < 0 || tokenIndex >= stream.Size() {
+		return ""
+	}
+	t := stream.Get(tokenIndex)
+	input := t.GetInputStream()
+	if input == nil {
+		return ""
+	}
+	first, last := t, t
+	for i, n := tokenIndex-1, 0; i >= 0 && n < before; i-- {
+		if u := stream.Get(i); u.GetChannel() == t.GetChannel() {
+			first = u
+			n++
+		}
+	}
+	for i, n := tokenIndex+1, 0; i < stream.Size() && n < after; i++ {
+		if u := stream.Get(i); u.GetChannel() == t.GetChannel() {
+			last = u
+			n++
+		}
+	}
+
+	start := max(first.GetStart()-first.GetColumn(), 0)
+	stop := max(last.GetStop(), last.GetStart()-1)
+	text := SliceCharStream(input, NewInterval(start, stop)) + contextWindowLineRest(input, stop+1)
+	lines := strings.Split(text, "\n")
+	lastLine := first.GetLine() + len(lines) - 1
+	width := len(strconv.Itoa(lastLine))
+
+	var sb strings.Builder
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		number := first.GetLine() + i
+		_, _ = fmt.Fprintf(&sb, "%*d | %s\n", width, number, line)
+		if number == t.GetLine() {
+			_, _ = fmt.Fprintf(&sb, "%*s | %s\n", width, "", contextWindowMarker(line, t, input))
+		}
+	}
+	return sb.String()
+}
+
+// contextWindowLineRest returns the text of input from index to the end of its line, not including the line
+// break.
+func contextWindowLineRest(input CharStream, index int) string {
+	var sb strings.Builder
+	for ; index < input.Size(); index += contextWindowChunk {
+		chunk := SliceCharStream(input, NewInterval(index, index+contextWindowChunk-1))
+		if end := strings.IndexByte(chunk, '\n'); end >= 0 {
+			sb.WriteString(chunk[:end])
+			break
+		}
+		sb.WriteString(chunk)
+	}
+	return sb.String()
+}
+
+// contextWindowMarker returns the line that marks token t, which begins on the given line, with carets under
+// its text on that line, or a single caret for a token without text, such as EOF. The characters before the
+// token are replaced by spaces, keeping tabs, so that the carets line up with the token.
+func contextWindowMarker(line string, t Token, input CharStream) string {
+	var sb strings.Builder
+	column := 0
+	for _, r := range line {
+		if column >= t.GetColumn() {
+			break
+		}
+		if r == '\t' {
+			sb.WriteByte('\t')
+		} else {
+			sb.WriteByte(' ')
+		}
+		column++
+	}
+	text := ""
+	if t.GetStop() >= t.GetStart() {
+		text = SliceCharStream(input, NewInterval(t.GetStart(), t.GetStop()))
+	}
+	if end := strings.IndexAny(text, "\r\n"); end >= 0 {
+		text = text[:end]
+	}
+	sb.WriteString(strings.Repeat("^", max(utf8.RuneCountInString(text), 1)))
+	return sb.String()
+}
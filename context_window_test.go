@@ -0,0 +1,57 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContextWindow(t *testing.T) {
+	// the lexer skips tabs and newlines as errors, but counts the lines
+	lex := func(input string) *CommonTokenStream {
+		lexer := newListLexer(NewInputStream(input))
+		lexer.RemoveErrorListeners()
+		stream := NewCommonTokenStream(lexer, TokenDefaultChannel)
+		stream.Fill()
+		return stream
+	}
+	stream := lex("a b\n\tcc + dd\nee\n\n\nff gg")
+	tests := []struct {
+		tokenIndex, before, after int
+		want                      string
+	}{
+		{3, 1, 1, "2 | \tcc + dd\n  | \t   ^\n"},
+		{4, 0, 0, "2 | \tcc + dd\n  | \t     ^^\n"},
+		{2, 2, 3, "1 | a b\n2 | \tcc + dd\n  | \t^^\n3 | ee\n"},
+		{5, 0, 1, "3 | ee\n  | ^^\n4 | \n5 | \n6 | ff gg\n"},
+		// the end of the input is marked with one caret
+		{8, 1, 5, "6 | ff gg\n  |      ^\n"},
+		{9, 1, 1, ""},
+		{-1, 1, 1, ""},
+	}
+	for _, test := range tests {
+		if got := ContextWindow(stream, test.tokenIndex, test.before, test.after); got != test.want {
+			t.Errorf("ContextWindow(%d, %d, %d) =\n%s\nwant:\n%s", test.tokenIndex, test.before, test.after, got, test.want)
+		}
+	}
+
+	// the line numbers are aligned, and the last line is read to its end
+	long := strings.Repeat("x", 3*contextWindowChunk)
+	stream = lex(strings.Repeat("\n", 8) + "a\nb " + long + "\nc")
+	want := " 9 | a\n10 | b " + long + "\n   | ^\n"
+	if got := ContextWindow(stream, 1, 1, 0); got != want {
+		t.Errorf("ContextWindow() =\n%s\nwant:\n%s", got, want)
+	}
+
+	stream = NewCommonTokenStream(nil, TokenDefaultChannel)
+	stream.SetTokenSource(&sliceTokenSource{tokens: []Token{
+		newTestToken(listID, 0, 0, 1, 0), newTestToken(TokenEOF, 1, 0, 1, 1),
+	}})
+	stream.Fill()
+	if got := ContextWindow(stream, 0, 1, 1); got != "" {
+		t.Errorf("ContextWindow() of a token without input = %q", got)
+	}
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "time"
+
+// DecisionEvent describes one prediction made by adaptive prediction, as reported to a [DecisionListener].
+type DecisionEvent struct {
+	// Decision is the number of the decision predicted, and Alt the alternative predicted, which is
+	// ATNInvalidAltNumber if prediction found no viable alternative
+	Decision int
+	Alt      int
+
+	// StartIndex is the index of the token where prediction began, and Lookahead the number of tokens it
+	// examined, including the token that resolved it
+	StartIndex int
+	Lookahead  int
+
+	// FullContext is true if SLL prediction found a conflict and prediction fell back to full LL, and Cached
+	// is true if the prediction was taken from the DFA cache alone, without simulating the ATN
+	FullContext bool
+	Cached      bool
+
+	// Duration is the time the prediction took
+	Duration time.Duration
+}
+
+// DecisionListener is told of the predictions made by a parser, see [BaseParser.SetDecisionListener], such as
+// to gather, from the inputs parsed in production, which decisions of a grammar examine the most lookahead or
+// fall back to full LL prediction, and so which rules are worth rewriting. DecisionPredicted is called from
+// the goroutine of the parser, once each prediction is made.
+type DecisionListener interface {
+	DecisionPredicted(recognizer Parser, event DecisionEvent)
+}
+
+// decisionObserver is the state of the decision listener of a [ParserATNSimulator].
+type decisionObserver struct {
+	listener    DecisionListener
+	sampleEvery int
+	predictions int
+
+	// observing is true while the current prediction is sampled, and stopIndex is then the index of the last
+	// token it has examined, or -1, and fullCtx true once it has fallen back to full LL prediction
+	observing bool
+	stopIndex int
+	fullCtx   bool
+}
+
+// SetDecisionListener installs the listener that the simulator tells of one in every sampleEvery predictions
+// it makes with AdaptivePredict, or of every one if sampleEvery is 1 or less, in place of any installed before.
+// Pass a nil listener to remove it. See [BaseParser.SetDecisionListener].
+func (p *ParserATNSimulator) SetDecisionListener(listener DecisionListener, sampleEvery int) {
+	if listener == nil {
+		p.observer = nil
+		return
+	}
+	p.observer = &decisionObserver{listener: listener, sampleEvery: max(sampleEvery, 1)}
+}
+
+// SetDecisionListener installs the listener that the parser tells of one in every sampleEvery predictions
+// made by adaptive prediction, or of every one if sampleEvery is 1 or less, with the decision, the alternative
+// predicted, the lookahead examined, whether it fell back to full LL prediction, and the time it took. Unlike
+// the profiler, see [BaseParser.SetProfile], it keeps no statistics of its own, and the predictions that are
+// not sampled cost no more than a count, so that it can be left on in production. Pass a nil listener to
+// remove it.
+//
+// Predictions made a second time to compare them, as with [ParserATNSimulator.SetDifferentialPrediction],
+// are not reported.
+//
+// Use:
+//
+//	p.SetDecisionListener(myDecisionListener, 100)
+func (p *BaseParser) SetDecisionListener(listener DecisionListener, sampleEvery int) {
+	p.Interpreter.SetDecisionListener(listener, sampleEvery)
+}
+
+// observeBegin starts observing a prediction of decision from startIndex, if it is sampled, returning the
+// function that ends it and tells the listener, or nil if it is not sampled.
+func (p *ParserATNSimulator) observeBegin(decision, startIndex int) func(predicted int) {
+	o := p.observer
+	if o == nil || p.shadow {
+		return nil
+	}
+	o.predictions++
+	if o.predictions%o.sampleEvery != 0 {
+		return nil
+	}
+	o.observing = true
+	o.stopIndex = -1
+	o.fullCtx = false
+	start := time.Now()
+
+	return func(predicted int) {
+		o.observing = false
+		event := DecisionEvent{
+			Decision:    decision,
+			Alt:         predicted,
+			StartIndex:  startIndex,
+			FullContext: o.fullCtx,
+			Cached:      !p.simulated,
+			Duration:    time.Since(start),
+		}
+		if o.stopIndex >= 0 {
+			event.Lookahead = o.stopIndex - startIndex + 1
+		}
+		o.listener.DecisionPredicted(p.parser, event)
+	}
+}
+
+// observeLookahead records a lookahead step of the prediction being observed, at the current token.
+func (p *ParserATNSimulator) observeLookahead(fullCtx bool) {
+	o := p.observer
+	if o == nil || !o.observing || p.shadow {
+		return
+	}
+	o.stopIndex = max(o.stopIndex, p.input.Index())
+	o.fullCtx = o.fullCtx || fullCtx
+}
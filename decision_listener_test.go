@@ -0,0 +1,87 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"slices"
+	"testing"
+)
+
+// decisionRecorder is a DecisionListener that records the events it is told of, without their durations.
+type decisionRecorder struct {
+	events []DecisionEvent
+}
+
+func (r *decisionRecorder) DecisionPredicted(recognizer Parser, event DecisionEvent) {
+	event.Duration = 0
+	r.events = append(r.events, event)
+}
+
+// decisionParse parses input with the list grammar, using the DFA given, and returns the events the listener
+// was told of.
+func decisionParse(input string, decisionToDFA []*DFA, sampleEvery int) []DecisionEvent {
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+	p.Interpreter = NewParserATNSimulator(p, p.GetATN(), decisionToDFA, NewPredictionContextCache())
+	r := &decisionRecorder{}
+	p.SetDecisionListener(r, sampleEvery)
+	p.S()
+	return r.events
+}
+
+func TestDecisionListener(t *testing.T) {
+	// the items are a, b + c and d, each predicted from its first token and the one after it
+	want := []DecisionEvent{
+		{Decision: listRuleItem, Alt: 1, StartIndex: 0, Lookahead: 2},
+		{Decision: listRuleItem, Alt: 2, StartIndex: 1, Lookahead: 2},
+		{Decision: listRuleItem, Alt: 1, StartIndex: 4, Lookahead: 2},
+	}
+	decisionToDFA := newDFA(newListParser(nil).GetATN())
+	if events := decisionParse("a b + c d", decisionToDFA, 1); !slices.Equal(events, want) {
+		t.Errorf("events %+v, want %+v", events, want)
+	}
+	// the same predictions are then taken from the DFA
+	for i := range want {
+		want[i].Cached = true
+	}
+	if events := decisionParse("a b + c d", decisionToDFA, 0); !slices.Equal(events, want) {
+		t.Errorf("events %+v, want %+v", events, want)
+	}
+	if events := decisionParse("a b + c d", decisionToDFA, 2); !slices.Equal(events, want[1:2]) {
+		t.Errorf("events of one prediction in two %+v, want %+v", events, want[1:2])
+	}
+
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a b")), TokenDefaultChannel))
+	r := &decisionRecorder{}
+	p.SetDecisionListener(r, 1)
+	p.SetDecisionListener(nil, 1)
+	p.S()
+	if r.events != nil {
+		t.Errorf("events %+v after the listener was removed", r.events)
+	}
+}
+
+func TestDecisionListenerFullContext(t *testing.T) {
+	tests := []struct {
+		mode  int
+		first DecisionEvent
+	}{
+		{PredictionModeLL, DecisionEvent{Decision: 1, Alt: 2, StartIndex: 1, Lookahead: 3, FullContext: true}},
+		// the prediction made again in full LL mode to compare with is not reported
+		{PredictionModeSLL, DecisionEvent{Decision: 1, Alt: 1, StartIndex: 1, Lookahead: 3}},
+	}
+	for _, test := range tests {
+		p, ctx := sllParser(test.mode, true)
+		r := &decisionRecorder{}
+		p.SetDecisionListener(r, 1)
+		p.Interpreter.AdaptivePredict(p, p.GetTokenStream(), 1, ctx)
+		// neither alternative of r is viable at b
+		p.GetTokenStream().Seek(2)
+		p.Interpreter.AdaptivePredict(p, p.GetTokenStream(), 1, ctx)
+		want := []DecisionEvent{test.first, {Decision: 1, Alt: ATNInvalidAltNumber, StartIndex: 2, Lookahead: 1}}
+		if !slices.Equal(r.events, want) {
+			t.Errorf("mode %d: events %+v, want %+v", test.mode, r.events, want)
+		}
+	}
+}
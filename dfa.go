@@ -4,6 +4,12 @@
 
 package antlr
 
+import (
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
 // DFA represents the Deterministic Finite Automaton used by the recognizer, including all the states it can
 // reach and the transitions between them.
 type DFA struct {
@@ -22,11 +28,24 @@ type DFA struct {
 
 	numstates int
 
-	s0 *DFAState
+	// s0 is the start state, published atomically so that it is read without locking, see getS0
+	s0 atomic.Pointer[DFAState]
 
 	// precedenceDfa is the backing field for isPrecedenceDfa and setPrecedenceDfa.
 	// True if the DFA is for a precedence decision and false otherwise.
 	precedenceDfa bool
+
+	// precomputed is true when every edge of every state in this lexer DFA has been computed
+	// ahead of time, so the DFA will not be modified again and can be read without locking.
+	precomputed bool
+
+	// expanded is true once [LexerATNSimulator.PrecomputeDFA] has expanded this lexer DFA, whether or not it
+	// was completed, so that it is not expanded again for every new lexer
+	expanded bool
+
+	// frozen is true when this parser DFA is part of a [DFASnapshot], so it will not be modified again and
+	// can be read without locking. Predictions it does not cover are not cached.
+	frozen bool
 }
 
 func NewDFA(atnStartState DecisionState, decision int) *DFA {
@@ -37,9 +56,10 @@ func NewDFA(atnStartState DecisionState, decision int) *DFA {
 	}
 	if s, ok := atnStartState.(*StarLoopEntryState); ok && s.precedenceRuleDecision {
 		dfa.precedenceDfa = true
-		dfa.s0 = NewDFAState(-1, NewATNConfigSet(false))
-		dfa.s0.isAcceptState = false
-		dfa.s0.requiresFullContext = false
+		s0 := NewDFAState(-1, NewATNConfigSet(false))
+		s0.isAcceptState = false
+		s0.requiresFullContext = false
+		dfa.setS0(s0)
 	}
 	return dfa
 }
@@ -54,10 +74,6 @@ func (d *DFA) getPrecedenceStartState(precedence int) *DFAState {
 	}
 
 	// s0.edges is never nil for a precedence DFA
-	if precedence < 0 || precedence >= len(d.getS0().getEdges()) {
-		return nil
-	}
-
 	return d.getS0().getIthEdge(precedence)
 }
 
@@ -74,21 +90,46 @@ func (d *DFA) setPrecedenceStartState(precedence int, startState *DFAState) {
 
 	// Synchronization on s0 here is ok. When the DFA is turned into a
 	// precedence DFA, s0 will be initialized once and not updated again. s0.edges
-	// is never nil for a precedence DFA.
-	s0 := d.getS0()
-	if precedence >= s0.numEdges() {
-		edges := append(s0.getEdges(), make([]*DFAState, precedence+1-s0.numEdges())...)
-		s0.setEdges(edges)
-		d.setS0(s0)
-	}
-
-	s0.setIthEdge(precedence, startState)
+	// is never nil for a precedence DFA. The edges are read without locking, so
+	// they are grown by publishing a copy.
+	d.getS0().setIthEdge(precedence, startState, precedence+1)
 }
 
 func (d *DFA) getPrecedenceDfa() bool {
 	return d.precedenceDfa
 }
 
+// Decision returns the decision number in the [ATN] that this DFA caches predictions for.
+func (d *DFA) Decision() int {
+	return d.decision
+}
+
+// IsPrecedenceDfa returns true if d is a precedence DFA. Precedence DFAs are used for the decisions
+// of left-recursive rules, where the prediction depends upon the precedence level at which the rule was
+// invoked. Instead of a single start state, such a DFA has a separate start state for each precedence
+// level, which can be retrieved with [DFA.PrecedenceStartStates].
+func (d *DFA) IsPrecedenceDfa() bool {
+	return d.precedenceDfa
+}
+
+// PrecedenceStartStates returns the start state of d for each precedence level that has been
+// computed so far, keyed by precedence. It returns nil if d is not a precedence DFA.
+//
+// The DFA is shared between all parsers for the same grammar, so the result is only a
+// consistent snapshot if no parser is using the DFA at the time. See [BaseParser.DumpPrecedenceDFA].
+func (d *DFA) PrecedenceStartStates() map[int]*DFAState {
+	if !d.getPrecedenceDfa() {
+		return nil
+	}
+	starts := make(map[int]*DFAState)
+	for precedence, s := range d.getS0().getEdges() {
+		if s != nil {
+			starts[precedence] = s
+		}
+	}
+	return starts
+}
+
 // setPrecedenceDfa sets whether d is a precedence DFA. If precedenceDfa differs
 // from the current DFA configuration, then d.states is cleared, the initial
 // state s0 is set to a new DFAState with an empty outgoing DFAState.edges to
@@ -138,12 +179,15 @@ func (d *DFA) Put(s *DFAState) (*DFAState, bool) {
 	return d.states.Put(s)
 }
 
+// getS0 returns the start state of d. It is read without locking, as the state is published atomically, fully
+// built, by setS0.
 func (d *DFA) getS0() *DFAState {
-	return d.s0
+	return d.s0.Load()
 }
 
+// setS0 publishes s as the start state of d. Writers must hold ATN.stateMu, unless d is not yet shared.
 func (d *DFA) setS0(s *DFAState) {
-	d.s0 = s
+	d.s0.Store(s)
 }
 
 // sortedStates returns the states in d sorted by their state number, or an empty set if d.states is nil.
@@ -166,6 +210,29 @@ func (d *DFA) String(literalNames []string, symbolicNames []string) string {
 	return NewDFASerializer(d, literalNames, symbolicNames).String()
 }
 
+// PrecedenceString returns a description of a precedence DFA, listing the start state for each
+// precedence level, followed by the states and edges as returned by [DFA.String]. If d is not a
+// precedence DFA, it returns the same as [DFA.String].
+func (d *DFA) PrecedenceString(literalNames []string, symbolicNames []string) string {
+	if !d.getPrecedenceDfa() {
+		return d.String(literalNames, symbolicNames)
+	}
+
+	var sb strings.Builder
+	serializer := NewDFASerializer(d, literalNames, symbolicNames)
+	for precedence, s := range d.getS0().getEdges() {
+		if s != nil {
+			sb.WriteString("precedence ")
+			sb.WriteString(strconv.Itoa(precedence))
+			sb.WriteString("->")
+			sb.WriteString(serializer.GetStateString(s))
+			sb.WriteByte('\n')
+		}
+	}
+	sb.WriteString(serializer.String())
+	return sb.String()
+}
+
 func (d *DFA) ToLexerString() string {
 	if d.getS0() == nil {
 		return ""
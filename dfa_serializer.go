@@ -43,11 +43,11 @@ func (d *DFASerializer) String() string {
 	states := d.dfa.sortedStates()
 
 	for _, s := range states {
-		if s.edges != nil {
-			n := len(s.edges)
+		if edges := s.getEdges(); edges != nil {
+			n := len(edges)
 
 			for j := 0; j < n; j++ {
-				t := s.edges[j]
+				t := edges[j]
 
 				if t != nil && t.stateNumber != 0x7FFFFFFF {
 					buf += d.GetStateString(s)
@@ -112,12 +112,43 @@ func NewLexerDFASerializer(dfa *DFA) *LexerDFASerializer {
 	return &LexerDFASerializer{DFASerializer: NewDFASerializer(dfa, nil, nil)}
 }
 
+// getEdgeLabel returns the runes for the edge at index i. Lexer DFA edges are indexed
+// by equivalence class, so the label is the set of runes in the class.
 func (l *LexerDFASerializer) getEdgeLabel(i int) string {
+	var alphabet *lexerAlphabet
+	if l.dfa.atnStartState != nil && l.dfa.atnStartState.GetATN() != nil {
+		alphabet = l.dfa.atnStartState.GetATN().lexerAlphabet
+	}
+	if alphabet == nil || i >= alphabet.size() {
+		return "'" + string(rune(i)) + "'"
+	}
+
+	return charIntervalsString(alphabet.members[i].intervals)
+}
+
+// charIntervalsString renders intervals of characters as a set, such as {'a'..'z', '_'}, or as the only
+// character or range of characters in it.
+func charIntervalsString(intervals []Interval) string {
 	var sb strings.Builder
-	sb.Grow(6)
-	sb.WriteByte('\'')
-	sb.WriteRune(rune(i))
-	sb.WriteByte('\'')
+	if len(intervals) > 1 {
+		sb.WriteByte('{')
+	}
+	for j, v := range intervals {
+		if j > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteByte('\'')
+		sb.WriteRune(rune(v.Start))
+		sb.WriteByte('\'')
+		if v.Stop > v.Start+1 {
+			sb.WriteString("..'")
+			sb.WriteRune(rune(v.Stop - 1))
+			sb.WriteByte('\'')
+		}
+	}
+	if len(intervals) > 1 {
+		sb.WriteByte('}')
+	}
 	return sb.String()
 }
 
@@ -132,11 +163,11 @@ func (l *LexerDFASerializer) String() string {
 	for i := 0; i < len(states); i++ {
 		s := states[i]
 
-		if s.edges != nil {
-			n := len(s.edges)
+		if edges := s.getEdges(); edges != nil {
+			n := len(edges)
 
 			for j := 0; j < n; j++ {
-				t := s.edges[j]
+				t := edges[j]
 
 				if t != nil && t.stateNumber != 0x7FFFFFFF {
 					buf += l.GetStateString(s)
@@ -0,0 +1,164 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "sync/atomic"
+
+// DFASnapshot is an immutable copy of the DFA cache of a parser, taken once the cache has been warmed up,
+// that any number of read-only parser replicas can share. Replicas read a snapshot without locking, and
+// never add to it: a prediction that the snapshot does not cover is made by simulating the [ATN], as for a
+// cold cache, but its result is not cached. The parser whose cache was copied goes on learning, and new
+// snapshots of it can be taken and handed to the replicas, see [DFAPublisher].
+//
+// The states of a snapshot share their configurations with the states they were copied from, which are
+// not changed once a state is in a DFA, so a snapshot takes far less memory than the cache it copies.
+type DFASnapshot struct {
+	decisionToDFA []*DFA
+	version       uint64
+	states        int
+
+	// fingerprint is the fingerprint of the grammar of the DFA copied
+	fingerprint GrammarFingerprint
+}
+
+// NewDFASnapshot copies decisionToDFA, the DFA cache of parsers for the grammar of atn, into a
+// [DFASnapshot]. Parsers may go on using the cache while the copy is made.
+//
+// Use:
+//
+//	snapshot := antlr.NewDFASnapshot(writer.GetATN(), writer.GetInterpreter().DecisionToDFA())
+//	replica.GetInterpreter().UseDFASnapshot(snapshot)
+func NewDFASnapshot(atn *ATN, decisionToDFA []*DFA) *DFASnapshot {
+	atn.stateMu.RLock()
+	defer atn.stateMu.RUnlock()
+	atn.edgeMu.RLock()
+	defer atn.edgeMu.RUnlock()
+
+	s := &DFASnapshot{decisionToDFA: make([]*DFA, len(decisionToDFA)), fingerprint: atn.Fingerprint()}
+	for i, dfa := range decisionToDFA {
+		s.decisionToDFA[i] = dfa.frozenCopy()
+		s.states += s.decisionToDFA[i].Len()
+	}
+	return s
+}
+
+// DecisionToDFA returns the DFA of each decision in the snapshot, which must not be changed.
+func (s *DFASnapshot) DecisionToDFA() []*DFA {
+	return s.decisionToDFA
+}
+
+// Len returns the number of DFA states in the snapshot, across all decisions.
+func (s *DFASnapshot) Len() int {
+	return s.states
+}
+
+// Version returns the number of the snapshot among those published by a [DFAPublisher], starting from 1,
+// or 0 if it was not published.
+func (s *DFASnapshot) Version() uint64 {
+	return s.version
+}
+
+// Fingerprint returns the fingerprint of the grammar whose DFA the snapshot copied.
+func (s *DFASnapshot) Fingerprint() GrammarFingerprint {
+	return s.fingerprint
+}
+
+// frozenCopy returns a copy of d that is never changed, and so can be read without locking. The caller
+// must hold the state and edge locks of the ATN.
+func (d *DFA) frozenCopy() *DFA {
+	c := &DFA{
+		atnStartState: d.atnStartState,
+		decision:      d.decision,
+		numstates:     d.numstates,
+		precedenceDfa: d.precedenceDfa,
+		precomputed:   d.precomputed,
+		frozen:        true,
+	}
+
+	copies := make(map[*DFAState]*DFAState, d.Len())
+	var copyState func(s *DFAState) *DFAState
+	copyState = func(s *DFAState) *DFAState {
+		if s == nil || s == ATNSimulatorError {
+			return s
+		}
+		if sc, ok := copies[s]; ok {
+			return sc
+		}
+		sc := &DFAState{
+			stateNumber:         s.stateNumber,
+			configs:             s.configs,
+			isAcceptState:       s.isAcceptState,
+			prediction:          s.prediction,
+			lexerActionExecutor: s.lexerActionExecutor,
+			requiresFullContext: s.requiresFullContext,
+			predicates:          s.predicates,
+		}
+		copies[s] = sc
+		if edges := s.getEdges(); edges != nil {
+			copied := make([]*DFAState, len(edges))
+			for i, e := range edges {
+				copied[i] = copyState(e)
+			}
+			sc.setEdges(copied)
+		}
+		return sc
+	}
+
+	for _, s := range d.sortedStates() {
+		c.Put(copyState(s))
+	}
+	c.setS0(copyState(d.getS0()))
+	return c
+}
+
+// DFAPublisher publishes snapshots of the DFA cache of one writer parser to any number of read-only replicas.
+// The writer parses as usual, adding to the cache as it goes, and [DFAPublisher.Publish] is called every so
+// often, after a number of parses or on a timer, to take a new snapshot. Replicas take the current snapshot
+// with [DFAPublisher.Current] before each parse, so a replica never sees a snapshot change part way through
+// a parse, and replicas that are parsing when a snapshot is published go on with the one they have.
+//
+// Replicas read their snapshot without locking, so they do not contend with each other or with the writer,
+// other than while a snapshot is being taken. This suits services that parse the same kinds of input
+// concurrently, where a single warmed cache serves every parser, and its locking would otherwise be a
+// bottleneck.
+//
+// Use:
+//
+//	publisher := antlr.NewDFAPublisher(writer.GetATN(), writer.GetInterpreter().DecisionToDFA())
+//
+//	// in each replica, before each parse
+//	replica.GetInterpreter().UseDFASnapshot(publisher.Current())
+//
+//	// in the writer, every so often
+//	publisher.Publish()
+type DFAPublisher struct {
+	atn           *ATN
+	decisionToDFA []*DFA
+	version       atomic.Uint64
+	current       atomic.Pointer[DFASnapshot]
+}
+
+// NewDFAPublisher creates a [DFAPublisher] for decisionToDFA, the DFA cache of the writer parser for the
+// grammar of atn, and publishes a first snapshot of it.
+func NewDFAPublisher(atn *ATN, decisionToDFA []*DFA) *DFAPublisher {
+	p := &DFAPublisher{atn: atn, decisionToDFA: decisionToDFA}
+	p.Publish()
+	return p
+}
+
+// Publish takes a new snapshot of the writer's DFA cache and makes it the current one, which is returned.
+// It is safe to call while the writer and the replicas are parsing, but calls must not overlap, so call it
+// from one goroutine, such as the writer's.
+func (p *DFAPublisher) Publish() *DFASnapshot {
+	s := NewDFASnapshot(p.atn, p.decisionToDFA)
+	s.version = p.version.Add(1)
+	p.current.Store(s)
+	return s
+}
+
+// Current returns the snapshot most recently published.
+func (p *DFAPublisher) Current() *DFASnapshot {
+	return p.current.Load()
+}
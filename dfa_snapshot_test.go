@@ -0,0 +1,123 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"sync"
+	"testing"
+)
+
+// dfaLen returns the number of states in the DFAs of decisionToDFA.
+func dfaLen(decisionToDFA []*DFA) int {
+	n := 0
+	for _, dfa := range decisionToDFA {
+		n += dfa.Len()
+	}
+	return n
+}
+
+func TestDFASnapshot(t *testing.T) {
+	atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+	counter := NewATNStateCounter(atn)
+	atn.SetStateObserver(counter.Observe)
+	predicted := func() (n int64) {
+		for i := 0; i < atn.NumberOfStates(); i++ {
+			_, p := counter.Count(i)
+			n += p
+		}
+		return n
+	}
+	decisionToDFA := newDFA(atn)
+	parse := func(input string, snapshot *DFASnapshot) string {
+		counter.Reset()
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+		p.Interpreter = NewParserATNSimulator(p, atn, decisionToDFA, NewPredictionContextCache())
+		p.Interpreter.UseDFASnapshot(snapshot)
+		return p.S().ToStringTree(nil, p)
+	}
+
+	cold := NewDFASnapshot(atn, decisionToDFA)
+	const input, tree = "a b + c", "(s (item a) (item b + c) <EOF>)"
+	for i := 0; i < 2; i++ {
+		if got := parse(input, cold); got != tree || predicted() == 0 {
+			t.Fatalf("with a cold snapshot: tree %s, %d states visited", got, predicted())
+		}
+	}
+	if cold.Len() != 0 || dfaLen(decisionToDFA) != 0 {
+		t.Errorf("a replica added %d states to the snapshot and %d to the cache", cold.Len(), dfaLen(decisionToDFA))
+	}
+
+	// the writer warms the cache, and a new snapshot predicts without simulating the ATN
+	parse(input, nil)
+	warm := NewDFASnapshot(atn, decisionToDFA)
+	if warm.Len() == 0 || warm.Len() != dfaLen(decisionToDFA) {
+		t.Fatalf("the snapshot has %d states of %d", warm.Len(), dfaLen(decisionToDFA))
+	}
+	for i, dfa := range warm.DecisionToDFA() {
+		if !dfa.frozen || dfa == decisionToDFA[i] {
+			t.Errorf("decision %d is not a frozen copy", i)
+		}
+	}
+	if got := parse(input, warm); got != tree || predicted() != 0 {
+		t.Errorf("with a warm snapshot: tree %s, %d states visited", got, predicted())
+	}
+
+	// the writer goes on learning, while the snapshot does not change
+	before := warm.Len()
+	parse("a", nil)
+	if warm.Len() != before || dfaLen(decisionToDFA) == before {
+		t.Errorf("the snapshot has %d states of %d, and the writer %d", warm.Len(), before, dfaLen(decisionToDFA))
+	}
+	if got := parse("a", warm); got != "(s (item a) <EOF>)" || predicted() == 0 {
+		t.Errorf("past the snapshot: tree %s, %d states visited", got, predicted())
+	}
+}
+
+func TestDFAPublisher(t *testing.T) {
+	atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+	decisionToDFA := newDFA(atn)
+	newParser := func(input string, dfa []*DFA) *listParser {
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+		p.Interpreter = NewParserATNSimulator(p, atn, dfa, NewPredictionContextCache())
+		return p
+	}
+	publisher := NewDFAPublisher(atn, decisionToDFA)
+	if publisher.Current().Version() != 1 || NewDFASnapshot(atn, decisionToDFA).Version() != 0 {
+		t.Fatalf("version %d", publisher.Current().Version())
+	}
+
+	// replicas parse with the current snapshot while the writer parses and publishes
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				p := newParser("a b + c d", nil)
+				p.Interpreter.UseDFASnapshot(publisher.Current())
+				if got := p.S().ToStringTree(nil, p); got != "(s (item a) (item b + c) (item d) <EOF>)" {
+					t.Errorf("tree %s", got)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		newParser("a b + c d e", decisionToDFA).S()
+		publisher.Publish()
+	}
+	wg.Wait()
+	if got := publisher.Current(); got.Version() != 21 || got.Len() != dfaLen(decisionToDFA) {
+		t.Errorf("version %d with %d states", got.Version(), got.Len())
+	}
+
+	// a replica can go back to its own cache
+	p := newParser("a", decisionToDFA)
+	p.Interpreter.UseDFASnapshot(publisher.Current())
+	p.Interpreter.UseDFASnapshot(nil)
+	if len(p.Interpreter.DecisionToDFA()) != len(decisionToDFA) || p.Interpreter.DecisionToDFA()[0] != decisionToDFA[0] {
+		t.Error("the replica did not go back to its own cache")
+	}
+}
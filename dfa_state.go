@@ -6,6 +6,7 @@ package antlr
 
 import (
 	"fmt"
+	"sync/atomic"
 )
 
 // PredPrediction maps a predicate to a predicted alternative.
@@ -50,7 +51,12 @@ type DFAState struct {
 
 	// edges elements point to the target of the symbol. Shift up by 1 so (-1)
 	// Token.EOF maps to the first element.
-	edges []*DFAState
+	//
+	// The slice is published once, at the size of the alphabet, and its elements
+	// are then set one at a time by writers holding ATN.edgeMu, so that readers on
+	// the hot path load the edges without taking a lock. A nil pointer means no
+	// edges.
+	edges atomic.Pointer[dfaEdges]
 
 	isAcceptState bool
 
@@ -107,24 +113,76 @@ func (d *DFAState) GetAltSet() []int {
 	return alts
 }
 
+// dfaEdges holds the edges of a [DFAState], each of which is set at most once while other goroutines may be
+// reading them.
+type dfaEdges []atomic.Pointer[DFAState]
+
+// loadEdges returns the edges of d, or nil if it has none, for a reader to follow with edge.
+func (d *DFAState) loadEdges() dfaEdges {
+	if edges := d.edges.Load(); edges != nil {
+		return *edges
+	}
+	return nil
+}
+
+// edge returns edge i of edges, or nil if it is not set or i is beyond the edges.
+func (edges dfaEdges) edge(i int) *DFAState {
+	if i < 0 || i >= len(edges) {
+		return nil
+	}
+	return edges[i].Load()
+}
+
+// getEdges returns a copy of the edges of d, or nil if it has none, for walking them all.
 func (d *DFAState) getEdges() []*DFAState {
-	return d.edges
+	edges := d.loadEdges()
+	if edges == nil {
+		return nil
+	}
+	copied := make([]*DFAState, len(edges))
+	for i := range edges {
+		copied[i] = edges[i].Load()
+	}
+	return copied
 }
 
 func (d *DFAState) numEdges() int {
-	return len(d.edges)
+	return len(d.loadEdges())
 }
 
 func (d *DFAState) getIthEdge(i int) *DFAState {
-	return d.edges[i]
+	return d.loadEdges().edge(i)
 }
 
+// setEdges publishes newEdges as the edges of d, or removes the edges of d if newEdges is nil. Writers must
+// hold ATN.edgeMu, unless d is not yet shared.
 func (d *DFAState) setEdges(newEdges []*DFAState) {
-	d.edges = newEdges
+	if newEdges == nil {
+		d.edges.Store(nil)
+		return
+	}
+	edges := make(dfaEdges, len(newEdges))
+	for i, e := range newEdges {
+		edges[i].Store(e)
+	}
+	d.edges.Store(&edges)
 }
 
-func (d *DFAState) setIthEdge(i int, edge *DFAState) {
-	d.edges[i] = edge
+// setIthEdge sets edge i of d. If d has no edges, or too few to hold edge i, it publishes a copy of its edges
+// grown to size edges, or to i+1 if that is more, with edge i among them. Writers must hold ATN.edgeMu,
+// unless d is not yet shared.
+func (d *DFAState) setIthEdge(i int, edge *DFAState, size int) {
+	old := d.loadEdges()
+	if i < len(old) {
+		old[i].Store(edge)
+		return
+	}
+	edges := make(dfaEdges, max(size, i+1))
+	for j := range old {
+		edges[j].Store(old[j].Load())
+	}
+	edges[i].Store(edge)
+	d.edges.Store(&edges)
 }
 
 func (d *DFAState) setPrediction(v int) {
@@ -0,0 +1,78 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestDFAStateSetIthEdge(t *testing.T) {
+	d, a, b, c := NewDFAState(0, nil), NewDFAState(1, nil), NewDFAState(2, nil), NewDFAState(3, nil)
+	if d.getIthEdge(0) != nil || d.numEdges() != 0 {
+		t.Fatal("a new state has edges")
+	}
+
+	d.setIthEdge(1, a, 4)
+	if d.numEdges() != 4 || d.getIthEdge(1) != a || d.getIthEdge(0) != nil {
+		t.Fatalf("first edge: edges %v", d.getEdges())
+	}
+	published := d.loadEdges()
+	d.setIthEdge(2, b, 4)
+	if published.edge(2) != b {
+		t.Error("an edge within the edges was not set in place")
+	}
+
+	// An edge beyond the edges grows them into a copy, leaving those a reader holds as they were
+	d.setIthEdge(6, c, 0)
+	if d.numEdges() != 7 || d.getIthEdge(1) != a || d.getIthEdge(2) != b || d.getIthEdge(6) != c {
+		t.Fatalf("grown edges %v", d.getEdges())
+	}
+	if len(published) != 4 || published.edge(6) != nil {
+		t.Error("growing the edges changed the edges published before")
+	}
+	if d.getIthEdge(-1) != nil || d.getIthEdge(7) != nil {
+		t.Error("an edge outside the edges is not nil")
+	}
+}
+
+func TestDFASetPrecedenceStartState(t *testing.T) {
+	dfa := NewDFA(NewStarLoopEntryState(), 0)
+	dfa.setPrecedenceDfa(true)
+	s1, s3 := NewDFAState(1, nil), NewDFAState(3, nil)
+	dfa.setPrecedenceStartState(3, s3)
+	dfa.setPrecedenceStartState(1, s1)
+	if dfa.getPrecedenceStartState(1) != s1 || dfa.getPrecedenceStartState(3) != s3 {
+		t.Errorf("start states %v", dfa.PrecedenceStartStates())
+	}
+	if dfa.getPrecedenceStartState(0) != nil || dfa.getPrecedenceStartState(4) != nil {
+		t.Error("a precedence level without a start state has one")
+	}
+}
+
+// BenchmarkSharedDFAParallel parses with parsers that share one ATN, DFA and context cache, one per
+// goroutine, as the parsers of a server do. The warm benchmark reads the DFA, which is built before it
+// starts, and measures the cost of following its edges concurrently. The cold one gives each parse DFAs of
+// its own, which start empty, and so measures the cost of adding states and edges under the locks of the
+// shared ATN.
+func BenchmarkSharedDFAParallel(b *testing.B) {
+	b.Run("warm", func(b *testing.B) {
+		decisionToDFA, cache := benchDFA(), NewPredictionContextCache()
+		benchParse(b, benchParser(benchTokens(benchProgram, 1), decisionToDFA, cache), benchTokens(benchProgram, 1))
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			tokens := benchTokens(benchProgram, 4)
+			p := benchParser(tokens, decisionToDFA, cache)
+			for pb.Next() {
+				benchParse(b, p, tokens)
+			}
+		})
+	})
+	b.Run("cold", func(b *testing.B) {
+		b.RunParallel(func(pb *testing.PB) {
+			tokens := benchTokens(benchProgram, 1)
+			for pb.Next() {
+				benchParse(b, benchParser(tokens, nil, nil), tokens)
+			}
+		})
+	})
+}
@@ -0,0 +1,898 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidDFADelta is wrapped by the errors returned by [DFASync.Import] and [ParserATNSimulator.ImportDFA]
+// for a delta that is malformed, or was exported for a different grammar.
+var ErrInvalidDFADelta = errors.New("invalid DFA delta")
+
+// dfaDeltaMagic and dfaDeltaVersion begin every delta exported by a DFASync. Deltas of version 1 do not have
+// the fingerprint of the grammar, and are still imported.
+const (
+	dfaDeltaMagic   = "ADFA"
+	dfaDeltaVersion = 2
+)
+
+// The flags of a DFA state in a delta
+const (
+	dfaDeltaAccept = 1 << iota
+	dfaDeltaRequiresFullContext
+	dfaDeltaFullCtx
+	dfaDeltaHasSemanticContext
+	dfaDeltaDipsIntoOuterContext
+)
+
+// The flags of an ATN configuration in a delta
+const (
+	dfaDeltaPrecedenceFilterSuppressed = 1 << iota
+	dfaDeltaPassedThroughNonGreedyDecision
+)
+
+// The kinds of semantic context in a delta
+const (
+	dfaDeltaPredicate = iota
+	dfaDeltaPrecedencePredicate
+	dfaDeltaAND
+	dfaDeltaOR
+)
+
+// DFADeltaTransport is the hook through which [DFASync.Sync] exchanges deltas with other processes. It is
+// given the delta exported by this process, which is nil if there is nothing new to send, and returns the
+// deltas received from other processes since it was last called. How deltas travel, be it through a
+// message queue, a shared store or an RPC service, is up to the transport.
+type DFADeltaTransport func(outgoing []byte) (incoming [][]byte, err error)
+
+// DFASync shares what parsers learn about a grammar across a fleet of processes. Adaptive prediction caches
+// each prediction that needs the [ATN] to be simulated in the DFA of the decision, and parsers in a freshly
+// started process are slow until the cache is warm again. A DFASync exports the DFA states and edges that a
+// process has added to its cache since its last export as a delta, a compact binary encoding, and imports
+// the deltas exported by other processes into the cache, so that each process benefits from the predictions
+// made by all of them, and a fleet converges on a warm cache quickly after a deploy.
+//
+// A state is identified by its content, not by its number, which differs between processes, so deltas can
+// be imported in any order, more than once, and from any number of processes; a state or edge that the cache
+// already has is skipped. Deltas only apply to the same grammar, built with the same version of ANTLR, which
+// is checked as well as possible. States and edges that are imported are not exported again. When the cache
+// is cleared, with [ParserATNSimulator.ClearDFA], what was sent of the DFA it held is forgotten by the next
+// export or import, and the states that the cache gains after that are exported again.
+//
+// Only the DFA of parsers can be shared, and not that of lexers.
+//
+// Use:
+//
+//	dfaSync := antlr.NewDFASync(p.GetATN(), p.GetInterpreter().DecisionToDFA())
+//	for range time.Tick(time.Minute) {
+//	    err := dfaSync.Sync(func(outgoing []byte) ([][]byte, error) {
+//	        return myFleet.Exchange(outgoing)
+//	    })
+//	    ...
+//	}
+type DFASync struct {
+	mu            Mutex
+	atn           *ATN
+	decisionToDFA []*DFA
+
+	// sent holds what has been exported or imported of the DFA of each decision
+	sent []dfaSent
+
+	// maxPrecedence is the highest precedence that a left-recursive rule of the grammar is invoked with,
+	// which bounds the precedence of the start states in a delta
+	maxPrecedence int
+}
+
+// dfaSent holds what has been exported or imported of the DFA of one decision.
+type dfaSent struct {
+	// dfa is the DFA that the rest describes. When it is cleared, by ClearDFA, the DFA of the decision is
+	// replaced, and what was sent of the old one is dropped.
+	dfa *DFA
+
+	// edges holds, for each state that has been exported or imported, the symbols of the edges it had at
+	// the time, shifted up by 1 as in DFAState.edges, so that new edges can be found; an edge is never
+	// changed once it is set. The start state of a precedence DFA is included, with the start state for
+	// each precedence as its edges.
+	edges map[*DFAState]*BitSet
+
+	// s0 is whether the start state of a regular DFA has been exported or imported
+	s0 bool
+}
+
+// dfaSentEdges holds the edges of a state that an export sends, until the export is committed.
+type dfaSentEdges struct {
+	decision int
+	dfa      *DFA
+	state    *DFAState
+	edges    *BitSet
+}
+
+// NewDFASync creates a [DFASync] for decisionToDFA, the DFA cache of the parsers for the grammar of atn, with
+// nothing exported.
+func NewDFASync(atn *ATN, decisionToDFA []*DFA) *DFASync {
+	return &DFASync{
+		atn:           atn,
+		decisionToDFA: decisionToDFA,
+		sent:          make([]dfaSent, len(decisionToDFA)),
+		maxPrecedence: maxRulePrecedence(atn),
+	}
+}
+
+// maxRulePrecedence returns the highest precedence of the rule transitions of atn, which is the highest
+// precedence a precedence DFA has a start state for.
+func maxRulePrecedence(atn *ATN) int {
+	highest := 0
+	for _, s := range atn.states {
+		if s == nil {
+			continue
+		}
+		for _, t := range s.GetTransitions() {
+			if rt, ok := t.(*RuleTransition); ok {
+				highest = max(highest, rt.precedence)
+			}
+		}
+	}
+	return highest
+}
+
+// Export returns a delta of the states and edges added to the DFA cache since the last export, or nil if
+// there are none. The delta is taken to be sent, so if it cannot be delivered, its states are not exported
+// again. Parsers may go on using the cache while the delta is made.
+func (s *DFASync) Export() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delta, commit := s.export()
+	commit()
+	return delta
+}
+
+// Import adds the states and edges of a delta exported by another process to the DFA cache. The delta is
+// checked in full before anything is added, so an error leaves the cache as it was. Parsers may go on using
+// the cache while the delta is imported.
+func (s *DFASync) Import(delta []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.importDelta(delta)
+}
+
+// Sync exports a delta, passes it to transport, and imports the deltas that transport returns. If transport
+// fails, the delta is not taken to be sent, and is exported again with the next. Errors from importing the
+// deltas received are joined and returned, after the others have been imported.
+func (s *DFASync) Sync(transport DFADeltaTransport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delta, commit := s.export()
+	incoming, err := transport(delta)
+	if err != nil {
+		return err
+	}
+	commit()
+
+	var errs []error
+	for _, d := range incoming {
+		if err := s.importDelta(d); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// dfaDeltaDecision holds the states and edges of one decision in a delta, with the states numbered in the
+// order they are added.
+type dfaDeltaDecision struct {
+	decision   int
+	precedence bool
+	states     []*DFAState
+	index      map[*DFAState]int
+	s0         int
+	starts     [][2]int // precedence, state
+	edges      [][3]int // from state, symbol, to state or -1 for the error state
+}
+
+// include adds state d to the decision if it is not already there, and returns its number.
+func (dd *dfaDeltaDecision) include(d *DFAState) int {
+	if d == ATNSimulatorError {
+		return -1
+	}
+	if i, ok := dd.index[d]; ok {
+		return i
+	}
+	dd.index[d] = len(dd.states)
+	dd.states = append(dd.states, d)
+	return len(dd.states) - 1
+}
+
+// sentOf returns what has been sent of the DFA of the given decision, dropping what was sent of a DFA that
+// has been cleared since.
+func (s *DFASync) sentOf(decision int) *dfaSent {
+	ds := &s.sent[decision]
+	if dfa := s.decisionToDFA[decision]; ds.dfa != dfa {
+		*ds = dfaSent{dfa: dfa, edges: make(map[*DFAState]*BitSet)}
+	}
+	return ds
+}
+
+// newDFAEdges calls f for each edge of d that is not in sent, which is nil if none are, and returns the edges
+// it was called for, or nil if there are none.
+func newDFAEdges(d *DFAState, sent *BitSet, f func(i int, to *DFAState)) *BitSet {
+	var added *BitSet
+	edges := d.loadEdges()
+	for i := range edges {
+		to := edges[i].Load()
+		if to == nil || sent != nil && sent.contains(i) {
+			continue
+		}
+		f(i, to)
+		if added == nil {
+			added = NewBitSet()
+		}
+		added.add(i)
+	}
+	return added
+}
+
+// export encodes the states and edges not yet sent, and returns the delta, or nil if there are none, with a
+// func that marks them as sent. The caller must hold s.mu.
+func (s *DFASync) export() ([]byte, func()) {
+	s.atn.stateMu.RLock()
+	defer s.atn.stateMu.RUnlock()
+	s.atn.edgeMu.RLock()
+	defer s.atn.edgeMu.RUnlock()
+
+	var decisions []*dfaDeltaDecision
+	var sent []dfaSentEdges
+	var sentS0 []int
+	for decision, dfa := range s.decisionToDFA {
+		ds := s.sentOf(decision)
+		dd := &dfaDeltaDecision{decision: decision, precedence: dfa.precedenceDfa, index: make(map[*DFAState]int), s0: -1}
+		for _, d := range dfa.sortedStates() {
+			old, seen := ds.edges[d]
+			added := newDFAEdges(d, old, func(i int, to *DFAState) {
+				dd.edges = append(dd.edges, [3]int{dd.include(d), i - 1, dd.include(to)})
+			})
+			if !seen {
+				dd.include(d)
+				if added == nil {
+					added = NewBitSet()
+				}
+			}
+			if added != nil {
+				sent = append(sent, dfaSentEdges{decision, dfa, d, added})
+			}
+		}
+		if dfa.precedenceDfa {
+			s0 := dfa.getS0()
+			added := newDFAEdges(s0, ds.edges[s0], func(precedence int, start *DFAState) {
+				dd.starts = append(dd.starts, [2]int{precedence, dd.include(start)})
+			})
+			if added != nil {
+				sent = append(sent, dfaSentEdges{decision, dfa, s0, added})
+			}
+		} else if s0 := dfa.getS0(); s0 != nil && !ds.s0 {
+			dd.s0 = dd.include(s0)
+			sentS0 = append(sentS0, decision)
+		}
+		if len(dd.states) > 0 || len(dd.starts) > 0 || dd.s0 >= 0 {
+			decisions = append(decisions, dd)
+		}
+	}
+
+	commit := func() {
+		for _, e := range sent {
+			// Drop the edges of a DFA cleared since the export
+			if ds := &s.sent[e.decision]; ds.dfa == e.dfa {
+				if old, ok := ds.edges[e.state]; ok {
+					old.or(e.edges)
+				} else {
+					ds.edges[e.state] = e.edges
+				}
+			}
+		}
+		for _, decision := range sentS0 {
+			if ds := &s.sent[decision]; ds.dfa == s.decisionToDFA[decision] {
+				ds.s0 = true
+			}
+		}
+	}
+	if len(decisions) == 0 {
+		return nil, commit
+	}
+
+	w := &dfaDeltaWriter{buf: []byte(dfaDeltaMagic)}
+	w.putUint(dfaDeltaVersion)
+	w.buf = binary.LittleEndian.AppendUint64(w.buf, uint64(s.atn.Fingerprint()))
+	w.putUint(len(s.atn.states))
+	w.putUint(len(s.atn.DecisionToState))
+	w.putUint(s.atn.maxTokenType)
+	w.putUint(len(decisions))
+	for _, dd := range decisions {
+		w.putDecision(dd)
+	}
+	return w.buf, commit
+}
+
+// dfaDeltaWriter encodes a delta. While it encodes the states of a decision, it numbers the prediction and
+// semantic contexts they use in the order they are added, the parts of each before it.
+type dfaDeltaWriter struct {
+	buf      []byte
+	contexts map[*PredictionContext]int
+	semantic map[SemanticContext]int
+}
+
+func (w *dfaDeltaWriter) putUint(v int) {
+	w.buf = binary.AppendUvarint(w.buf, uint64(v))
+}
+
+func (w *dfaDeltaWriter) putInt(v int) {
+	w.buf = binary.AppendVarint(w.buf, int64(v))
+}
+
+func (w *dfaDeltaWriter) putBool(v bool) {
+	if v {
+		w.buf = append(w.buf, 1)
+	} else {
+		w.buf = append(w.buf, 0)
+	}
+}
+
+// putDecision encodes the contexts of a decision, then its states, start states and edges.
+func (w *dfaDeltaWriter) putDecision(dd *dfaDeltaDecision) {
+	w.putUint(dd.decision)
+	w.putBool(dd.precedence)
+
+	// The states refer to the contexts, which must come before them, so the states are encoded first, by a
+	// writer of their own that numbers the contexts they use
+	states := &dfaDeltaWriter{contexts: make(map[*PredictionContext]int), semantic: make(map[SemanticContext]int)}
+	states.putUint(len(dd.states))
+	for _, d := range dd.states {
+		states.putState(d)
+	}
+	w.putContexts(states)
+	w.putSemantic(states)
+	w.buf = append(w.buf, states.buf...)
+
+	w.putInt(dd.s0)
+	w.putUint(len(dd.starts))
+	for _, start := range dd.starts {
+		w.putUint(start[0])
+		w.putInt(start[1])
+	}
+	w.putUint(len(dd.edges))
+	for _, e := range dd.edges {
+		w.putInt(e[0])
+		w.putInt(e[1])
+		w.putInt(e[2])
+	}
+}
+
+// putState encodes a DFA state.
+func (w *dfaDeltaWriter) putState(d *DFAState) {
+	flags := 0
+	if d.isAcceptState {
+		flags |= dfaDeltaAccept
+	}
+	if d.requiresFullContext {
+		flags |= dfaDeltaRequiresFullContext
+	}
+	if d.configs.fullCtx {
+		flags |= dfaDeltaFullCtx
+	}
+	if d.configs.hasSemanticContext {
+		flags |= dfaDeltaHasSemanticContext
+	}
+	if d.configs.dipsIntoOuterContext {
+		flags |= dfaDeltaDipsIntoOuterContext
+	}
+	w.putUint(flags)
+	w.putInt(d.prediction)
+	w.putInt(d.configs.uniqueAlt)
+	if d.configs.conflictingAlts == nil {
+		w.putUint(0)
+	} else {
+		w.putUint(len(d.configs.conflictingAlts.data) + 1)
+		for _, word := range d.configs.conflictingAlts.data {
+			w.buf = binary.AppendUvarint(w.buf, word)
+		}
+	}
+
+	w.putUint(len(d.configs.configs))
+	for _, c := range d.configs.configs {
+		w.putUint(c.state.GetStateNumber())
+		w.putInt(c.alt)
+		w.putInt(w.addContext(c.context))
+		w.putInt(w.addSemantic(c.semanticContext))
+		w.putInt(c.reachesIntoOuterContext)
+		cflags := 0
+		if c.precedenceFilterSuppressed {
+			cflags |= dfaDeltaPrecedenceFilterSuppressed
+		}
+		if c.passedThroughNonGreedyDecision {
+			cflags |= dfaDeltaPassedThroughNonGreedyDecision
+		}
+		w.putUint(cflags)
+	}
+
+	if d.predicates == nil {
+		w.putInt(-1)
+	} else {
+		w.putInt(len(d.predicates))
+		for _, p := range d.predicates {
+			w.putInt(w.addSemantic(p.pred))
+			w.putInt(p.alt)
+		}
+	}
+}
+
+// addContext numbers pc and its parents, if they are not numbered already, and returns its number, or -1
+// if it is nil.
+func (w *dfaDeltaWriter) addContext(pc *PredictionContext) int {
+	if pc == nil {
+		return -1
+	}
+	if i, ok := w.contexts[pc]; ok {
+		return i
+	}
+	switch pc.pcType {
+	case PredictionContextSingleton:
+		w.addContext(pc.parentCtx)
+	case PredictionContextArray:
+		for _, parent := range pc.parents {
+			w.addContext(parent)
+		}
+	}
+	w.contexts[pc] = len(w.contexts)
+	return w.contexts[pc]
+}
+
+// putContexts encodes the prediction contexts numbered by states in order.
+func (w *dfaDeltaWriter) putContexts(states *dfaDeltaWriter) {
+	ordered := make([]*PredictionContext, len(states.contexts))
+	for pc, i := range states.contexts {
+		ordered[i] = pc
+	}
+	w.putUint(len(ordered))
+	for _, pc := range ordered {
+		w.putUint(pc.pcType)
+		switch pc.pcType {
+		case PredictionContextSingleton:
+			w.putInt(states.addContext(pc.parentCtx))
+			w.putUint(pc.returnState)
+		case PredictionContextArray:
+			w.putUint(len(pc.parents))
+			for i, parent := range pc.parents {
+				w.putInt(states.addContext(parent))
+				w.putUint(pc.returnStates[i])
+			}
+		}
+	}
+}
+
+// addSemantic numbers sc and its operands, if they are not numbered already, and returns its number, or -1
+// if it is nil.
+func (w *dfaDeltaWriter) addSemantic(sc SemanticContext) int {
+	if sc == nil {
+		return -1
+	}
+	if i, ok := w.semantic[sc]; ok {
+		return i
+	}
+	switch s := sc.(type) {
+	case *AND:
+		for _, o := range s.opnds {
+			w.addSemantic(o)
+		}
+	case *OR:
+		for _, o := range s.opnds {
+			w.addSemantic(o)
+		}
+	}
+	w.semantic[sc] = len(w.semantic)
+	return w.semantic[sc]
+}
+
+// putSemantic encodes the semantic contexts numbered by states in order.
+func (w *dfaDeltaWriter) putSemantic(states *dfaDeltaWriter) {
+	ordered := make([]SemanticContext, len(states.semantic))
+	for sc, i := range states.semantic {
+		ordered[i] = sc
+	}
+	w.putUint(len(ordered))
+	for _, sc := range ordered {
+		switch s := sc.(type) {
+		case *Predicate:
+			w.putUint(dfaDeltaPredicate)
+			w.putInt(s.ruleIndex)
+			w.putInt(s.predIndex)
+			w.putBool(s.isCtxDependent)
+		case *PrecedencePredicate:
+			w.putUint(dfaDeltaPrecedencePredicate)
+			w.putInt(s.precedence)
+		case *AND:
+			w.putUint(dfaDeltaAND)
+			w.putUint(len(s.opnds))
+			for _, o := range s.opnds {
+				w.putInt(states.addSemantic(o))
+			}
+		case *OR:
+			w.putUint(dfaDeltaOR)
+			w.putUint(len(s.opnds))
+			for _, o := range s.opnds {
+				w.putInt(states.addSemantic(o))
+			}
+		}
+	}
+}
+
+// dfaDeltaReader decodes a delta, recording the first error it finds.
+type dfaDeltaReader struct {
+	buf []byte
+	err error
+}
+
+func (r *dfaDeltaReader) fail(format string, args ...interface{}) {
+	if r.err == nil {
+		r.err = fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidDFADelta}, args...)...)
+	}
+}
+
+func (r *dfaDeltaReader) getUint() int {
+	v, n := binary.Uvarint(r.buf)
+	if n <= 0 || v > uint64(^uint(0)>>1) {
+		r.fail("truncated")
+		r.buf = nil
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return int(v)
+}
+
+func (r *dfaDeltaReader) getInt() int {
+	v, n := binary.Varint(r.buf)
+	if n <= 0 {
+		r.fail("truncated")
+		r.buf = nil
+		return 0
+	}
+	r.buf = r.buf[n:]
+	return int(v)
+}
+
+func (r *dfaDeltaReader) getBool() bool {
+	if len(r.buf) == 0 {
+		r.fail("truncated")
+		return false
+	}
+	v := r.buf[0] != 0
+	r.buf = r.buf[1:]
+	return v
+}
+
+// getRef reads the number of an entry in a table of length n, which may be -1 for nil if nilable.
+func (r *dfaDeltaReader) getRef(n int, nilable bool) int {
+	i := r.getInt()
+	if i >= n || i < -1 || i == -1 && !nilable {
+		r.fail("reference %d out of range", i)
+		return -1
+	}
+	return i
+}
+
+// getCount reads a count of entries, each of which takes at least one byte.
+func (r *dfaDeltaReader) getCount() int {
+	n := r.getUint()
+	if n > len(r.buf) {
+		r.fail("count %d out of range", n)
+		return 0
+	}
+	return n
+}
+
+// importDelta decodes delta in full, then adds its states and edges to the cache. The caller must hold s.mu.
+func (s *DFASync) importDelta(delta []byte) error {
+	if len(delta) == 0 {
+		return nil
+	}
+	if len(delta) < len(dfaDeltaMagic) || string(delta[:len(dfaDeltaMagic)]) != dfaDeltaMagic {
+		return fmt.Errorf("%w: not a DFA delta", ErrInvalidDFADelta)
+	}
+	r := &dfaDeltaReader{buf: delta[len(dfaDeltaMagic):]}
+	v := r.getUint()
+	if v != 1 && v != dfaDeltaVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidDFADelta, v)
+	}
+	if v >= 2 {
+		if len(r.buf) < 8 {
+			return fmt.Errorf("%w: truncated", ErrInvalidDFADelta)
+		}
+		fingerprint := GrammarFingerprint(binary.LittleEndian.Uint64(r.buf))
+		r.buf = r.buf[8:]
+		// only deserialized ATNs have fingerprints
+		if fingerprint != 0 && s.atn.Fingerprint() != 0 {
+			if err := s.atn.Fingerprint().Check(fingerprint); err != nil {
+				return fmt.Errorf("%w: %w", ErrInvalidDFADelta, err)
+			}
+		}
+	}
+	if r.getUint() != len(s.atn.states) || r.getUint() != len(s.atn.DecisionToState) ||
+		r.getUint() != s.atn.maxTokenType {
+		return fmt.Errorf("%w: exported for a different grammar", ErrInvalidDFADelta)
+	}
+
+	n := r.getCount()
+	decisions := make([]*dfaDeltaDecision, 0, n)
+	for i := 0; i < n && r.err == nil; i++ {
+		decisions = append(decisions, s.getDecision(r))
+	}
+	if r.err == nil && len(r.buf) > 0 {
+		r.fail("%d bytes left over", len(r.buf))
+	}
+	if r.err != nil {
+		return r.err
+	}
+
+	s.atn.stateMu.Lock()
+	defer s.atn.stateMu.Unlock()
+	s.atn.edgeMu.Lock()
+	defer s.atn.edgeMu.Unlock()
+	for _, dd := range decisions {
+		s.apply(dd)
+	}
+	return nil
+}
+
+// getDecision decodes the states, start states and edges of a decision.
+func (s *DFASync) getDecision(r *dfaDeltaReader) *dfaDeltaDecision {
+	dd := &dfaDeltaDecision{decision: r.getUint(), precedence: r.getBool()}
+	if r.err != nil {
+		return dd
+	}
+	if dd.decision >= len(s.decisionToDFA) {
+		r.fail("decision %d out of range", dd.decision)
+		return dd
+	}
+	if dfa := s.decisionToDFA[dd.decision]; dfa.frozen || dfa.precedenceDfa != dd.precedence {
+		r.fail("decision %d does not match", dd.decision)
+		return dd
+	}
+
+	contexts := make([]*PredictionContext, r.getCount())
+	for i := range contexts {
+		if r.err != nil {
+			return dd
+		}
+		contexts[i] = getDeltaContext(r, contexts[:i])
+	}
+	semantic := make([]SemanticContext, r.getCount())
+	for i := range semantic {
+		if r.err != nil {
+			return dd
+		}
+		semantic[i] = getDeltaSemantic(r, semantic[:i])
+	}
+
+	dd.states = make([]*DFAState, r.getCount())
+	for i := range dd.states {
+		if r.err != nil {
+			return dd
+		}
+		dd.states[i] = s.getState(r, contexts, semantic)
+	}
+
+	dd.s0 = r.getRef(len(dd.states), true)
+	if dd.precedence && dd.s0 >= 0 {
+		r.fail("precedence decision %d has a start state", dd.decision)
+	}
+	dd.starts = make([][2]int, r.getCount())
+	for i := range dd.starts {
+		dd.starts[i] = [2]int{r.getUint(), r.getRef(len(dd.states), false)}
+		if precedence := dd.starts[i][0]; precedence > s.maxPrecedence {
+			r.fail("precedence %d out of range", precedence)
+		}
+	}
+	if !dd.precedence && len(dd.starts) > 0 {
+		r.fail("decision %d has precedence start states", dd.decision)
+	}
+	dd.edges = make([][3]int, r.getCount())
+	for i := range dd.edges {
+		dd.edges[i] = [3]int{r.getRef(len(dd.states), false), r.getInt(), r.getRef(len(dd.states), true)}
+		if t := dd.edges[i][1]; t < TokenEOF || t > s.atn.maxTokenType {
+			r.fail("symbol %d out of range", t)
+		}
+	}
+	return dd
+}
+
+// getDeltaContext decodes a prediction context whose parents are among decoded.
+func getDeltaContext(r *dfaDeltaReader, decoded []*PredictionContext) *PredictionContext {
+	parent := func() *PredictionContext {
+		if i := r.getRef(len(decoded), true); i >= 0 {
+			return decoded[i]
+		}
+		return nil
+	}
+	switch pcType := r.getUint(); pcType {
+	case PredictionContextEmpty:
+		return BasePredictionContextEMPTY
+	case PredictionContextSingleton:
+		p := parent()
+		return SingletonBasePredictionContextCreate(p, r.getUint())
+	case PredictionContextArray:
+		n := r.getCount()
+		parents, returnStates := make([]*PredictionContext, n), make([]int, n)
+		for i := 0; i < n; i++ {
+			parents[i] = parent()
+			returnStates[i] = r.getUint()
+		}
+		if r.err != nil {
+			return nil
+		}
+		return NewArrayPredictionContext(parents, returnStates)
+	default:
+		r.fail("unknown prediction context type %d", pcType)
+		return nil
+	}
+}
+
+// getDeltaSemantic decodes a semantic context whose operands are among decoded.
+func getDeltaSemantic(r *dfaDeltaReader, decoded []SemanticContext) SemanticContext {
+	operands := func() []SemanticContext {
+		opnds := make([]SemanticContext, r.getCount())
+		for i := range opnds {
+			if j := r.getRef(len(decoded), false); j >= 0 {
+				opnds[i] = decoded[j]
+			}
+		}
+		return opnds
+	}
+	switch kind := r.getUint(); kind {
+	case dfaDeltaPredicate:
+		ruleIndex, predIndex, isCtxDependent := r.getInt(), r.getInt(), r.getBool()
+		if ruleIndex == -1 && predIndex == -1 && !isCtxDependent {
+			return SemanticContextNone
+		}
+		return NewPredicate(ruleIndex, predIndex, isCtxDependent)
+	case dfaDeltaPrecedencePredicate:
+		return NewPrecedencePredicate(r.getInt())
+	case dfaDeltaAND:
+		return &AND{opnds: operands()}
+	case dfaDeltaOR:
+		return &OR{opnds: operands()}
+	default:
+		r.fail("unknown semantic context kind %d", kind)
+		return nil
+	}
+}
+
+// getState decodes a DFA state, whose configurations refer to the decoded contexts.
+func (s *DFASync) getState(r *dfaDeltaReader, contexts []*PredictionContext, semantic []SemanticContext) *DFAState {
+	flags := r.getUint()
+	prediction, uniqueAlt := r.getInt(), r.getInt()
+	var conflictingAlts *BitSet
+	if n := r.getCount(); n > 0 {
+		conflictingAlts = NewBitSet()
+		conflictingAlts.data = make([]uint64, n-1)
+		for i := range conflictingAlts.data {
+			v, m := binary.Uvarint(r.buf)
+			if m <= 0 {
+				r.fail("truncated")
+				return nil
+			}
+			r.buf = r.buf[m:]
+			conflictingAlts.data[i] = v
+		}
+	}
+
+	configs := &ATNConfigSet{
+		cachedHash:           -1,
+		configs:              make([]*ATNConfig, r.getCount()),
+		conflictingAlts:      conflictingAlts,
+		dipsIntoOuterContext: flags&dfaDeltaDipsIntoOuterContext != 0,
+		fullCtx:              flags&dfaDeltaFullCtx != 0,
+		hasSemanticContext:   flags&dfaDeltaHasSemanticContext != 0,
+		readOnly:             true,
+		uniqueAlt:            uniqueAlt,
+	}
+	for i := range configs.configs {
+		stateNumber := r.getUint()
+		c := &ATNConfig{alt: r.getInt(), cType: parserConfig}
+		if i := r.getRef(len(contexts), true); i >= 0 {
+			c.context = contexts[i]
+		}
+		if i := r.getRef(len(semantic), false); i >= 0 {
+			c.semanticContext = semantic[i]
+		}
+		c.reachesIntoOuterContext = r.getInt()
+		cflags := r.getUint()
+		c.precedenceFilterSuppressed = cflags&dfaDeltaPrecedenceFilterSuppressed != 0
+		c.passedThroughNonGreedyDecision = cflags&dfaDeltaPassedThroughNonGreedyDecision != 0
+		if stateNumber >= len(s.atn.states) || s.atn.states[stateNumber] == nil {
+			r.fail("ATN state %d out of range", stateNumber)
+			return nil
+		}
+		c.state = s.atn.states[stateNumber]
+		configs.configs[i] = c
+	}
+
+	d := NewDFAState(-1, configs)
+	d.isAcceptState = flags&dfaDeltaAccept != 0
+	d.requiresFullContext = flags&dfaDeltaRequiresFullContext != 0
+	d.prediction = prediction
+	if n := r.getInt(); n >= 0 {
+		if n > len(r.buf) {
+			r.fail("count %d out of range", n)
+			return nil
+		}
+		d.predicates = make([]*PredPrediction, n)
+		for i := range d.predicates {
+			var pred SemanticContext
+			if j := r.getRef(len(semantic), false); j >= 0 {
+				pred = semantic[j]
+			}
+			d.predicates[i] = NewPredPrediction(pred, r.getInt())
+		}
+	}
+	return d
+}
+
+// apply adds the decoded states and edges of a decision to its DFA, and marks them as sent. The caller must
+// hold the state and edge locks of the ATN.
+func (s *DFASync) apply(dd *dfaDeltaDecision) {
+	dfa := s.decisionToDFA[dd.decision]
+	ds := s.sentOf(dd.decision)
+	local := make([]*DFAState, len(dd.states))
+	for i, d := range dd.states {
+		if existing, ok := dfa.Get(d); ok {
+			d = existing
+		} else {
+			d.stateNumber = dfa.Len()
+			dfa.Put(d)
+		}
+		local[i] = d
+		// The state came from another process, so it need not be exported, though any edges it has here
+		// that the other process did not send still are
+		if _, seen := ds.edges[d]; !seen {
+			ds.edges[d] = NewBitSet()
+		}
+	}
+	target := func(i int) *DFAState {
+		if i < 0 {
+			return ATNSimulatorError
+		}
+		return local[i]
+	}
+
+	for _, e := range dd.edges {
+		from, t, to := local[e[0]], e[1], target(e[2])
+		if from.getIthEdge(t+1) == nil {
+			from.setIthEdge(t+1, to, s.atn.maxTokenType+1+1)
+			ds.markSent(from, t+1)
+		}
+	}
+
+	if dd.s0 >= 0 && dfa.getS0() == nil {
+		dfa.setS0(local[dd.s0])
+		ds.s0 = true
+	}
+	for _, start := range dd.starts {
+		precedence, to := start[0], local[start[1]]
+		if dfa.getPrecedenceStartState(precedence) == nil {
+			dfa.setPrecedenceStartState(precedence, to)
+			ds.markSent(dfa.getS0(), precedence)
+		}
+	}
+}
+
+// markSent records that edge i of state d has been sent.
+func (ds *dfaSent) markSent(d *DFAState, i int) {
+	edges, ok := ds.edges[d]
+	if !ok {
+		edges = NewBitSet()
+		ds.edges[d] = edges
+	}
+	edges.add(i)
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"testing"
+)
+
+// syncProcess is a process of a fleet that shares the DFA of the list grammar with a DFASync.
+type syncProcess struct {
+	atn           *ATN
+	counter       *ATNStateCounter
+	decisionToDFA []*DFA
+	sync          *DFASync
+	sim           *ParserATNSimulator
+}
+
+func newSyncProcess() *syncProcess {
+	atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+	counter := NewATNStateCounter(atn)
+	atn.SetStateObserver(counter.Observe)
+	decisionToDFA := newDFA(atn)
+	return &syncProcess{atn: atn, counter: counter, decisionToDFA: decisionToDFA, sync: NewDFASync(atn, decisionToDFA)}
+}
+
+// parse parses input and returns its tree, and the number of ATN states visited to predict.
+func (sp *syncProcess) parse(input string) (string, int64) {
+	sp.counter.Reset()
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+	sp.sim = NewParserATNSimulator(p, sp.atn, sp.decisionToDFA, NewPredictionContextCache())
+	p.Interpreter = sp.sim
+	tree := p.S().ToStringTree(nil, p)
+	var predicted int64
+	for i := 0; i < sp.atn.NumberOfStates(); i++ {
+		_, n := sp.counter.Count(i)
+		predicted += n
+	}
+	return tree, predicted
+}
+
+func TestDFASyncExportImport(t *testing.T) {
+	a, b := newSyncProcess(), newSyncProcess()
+	if delta := a.sync.Export(); delta != nil {
+		t.Fatalf("an empty cache exported %d bytes", len(delta))
+	}
+	a.parse("a b + c")
+	delta := a.sync.Export()
+	if delta == nil {
+		t.Fatal("nothing was exported")
+	}
+	if again := a.sync.Export(); again != nil {
+		t.Errorf("the states were exported again, in %d bytes", len(again))
+	}
+
+	// the states imported are those exported, and predict without simulating the ATN
+	for i := 0; i < 2; i++ {
+		if err := b.sync.Import(delta); err != nil {
+			t.Fatal(err)
+		}
+		if dfaLen(b.decisionToDFA) != dfaLen(a.decisionToDFA) {
+			t.Fatalf("import %d: %d states, want %d", i, dfaLen(b.decisionToDFA), dfaLen(a.decisionToDFA))
+		}
+	}
+	if tree, predicted := b.parse("a b + c"); tree != "(s (item a) (item b + c) <EOF>)" || predicted != 0 {
+		t.Errorf("tree %s, %d states visited", tree, predicted)
+	}
+	if got := b.sync.Export(); got != nil {
+		t.Errorf("the imported states were exported again, in %d bytes", len(got))
+	}
+
+	// only what was added since is exported next, and either process can import the other's states
+	a.parse("a")
+	first := delta
+	delta = a.sync.Export()
+	if delta == nil || len(delta) >= len(first) {
+		t.Errorf("the second delta has %d bytes, and the first %d", len(delta), len(first))
+	}
+	if err := b.sync.Import(delta); err != nil || dfaLen(b.decisionToDFA) != dfaLen(a.decisionToDFA) {
+		t.Errorf("%d states, want %d: %v", dfaLen(b.decisionToDFA), dfaLen(a.decisionToDFA), err)
+	}
+}
+
+func TestDFASyncInvalidDelta(t *testing.T) {
+	a := newSyncProcess()
+	a.parse("a b + c")
+	delta := a.sync.Export()
+
+	// s : ID EOF ;
+	other := buildATN(listWS, [][][]atnElement{{bAlt(bTok(listID), bTok(TokenEOF))}})
+	for name, bad := range map[string][]byte{
+		"magic":     append([]byte("XDFA"), delta[4:]...),
+		"version":   append([]byte("ADFA\x7f"), delta[5:]...),
+		"truncated": delta[:len(delta)-1],
+		"trailing":  append(append([]byte{}, delta...), 0),
+	} {
+		b := newSyncProcess()
+		if err := b.sync.Import(bad); !errors.Is(err, ErrInvalidDFADelta) || dfaLen(b.decisionToDFA) != 0 {
+			t.Errorf("%s: %v, and %d states imported", name, err, dfaLen(b.decisionToDFA))
+		}
+	}
+	if err := NewDFASync(other, newDFA(other)).Import(delta); !errors.Is(err, ErrInvalidDFADelta) {
+		t.Errorf("a delta of another grammar was imported: %v", err)
+	}
+	if err := newSyncProcess().sync.Import(nil); err != nil {
+		t.Errorf("an empty delta was not imported: %v", err)
+	}
+}
+
+func TestDFASync(t *testing.T) {
+	a, b := newSyncProcess(), newSyncProcess()
+	a.parse("a b + c")
+
+	// a delta that cannot be delivered is exported again with the next
+	failed := errors.New("unreachable")
+	var sent []byte
+	transport := func(outgoing []byte) ([][]byte, error) {
+		sent = outgoing
+		return nil, failed
+	}
+	if err := a.sync.Sync(transport); !errors.Is(err, failed) || sent == nil {
+		t.Fatalf("Sync() = %v", err)
+	}
+	// the fleet passes on each delta to the next process that syncs
+	var queue [][]byte
+	fleet := func(outgoing []byte) ([][]byte, error) {
+		incoming := queue
+		queue = nil
+		if outgoing != nil {
+			queue = append(queue, outgoing)
+		}
+		return incoming, nil
+	}
+	if err := a.sync.Sync(fleet); err != nil || len(queue) != 1 || len(queue[0]) != len(sent) {
+		t.Fatalf("Sync() = %v, with %d deltas queued", err, len(queue))
+	}
+	if err := b.sync.Sync(fleet); err != nil || dfaLen(b.decisionToDFA) != dfaLen(a.decisionToDFA) {
+		t.Fatalf("Sync() = %v, with %d states of %d", err, dfaLen(b.decisionToDFA), dfaLen(a.decisionToDFA))
+	}
+
+	// the errors of the deltas received are returned once the others are imported
+	b.parse("a")
+	queue = [][]byte{[]byte("XDFA"), b.sync.Export()}
+	if err := a.sync.Sync(fleet); !errors.Is(err, ErrInvalidDFADelta) || dfaLen(a.decisionToDFA) != dfaLen(b.decisionToDFA) {
+		t.Errorf("Sync() = %v, with %d states of %d", err, dfaLen(a.decisionToDFA), dfaLen(b.decisionToDFA))
+	}
+}
+
+func TestDFASyncClearDFA(t *testing.T) {
+	a := newSyncProcess()
+	a.parse("a b + c")
+	if a.sync.Export() == nil {
+		t.Fatal("nothing was exported")
+	}
+	a.sim.ClearDFA()
+	a.parse("a b + c")
+	delta := a.sync.Export()
+	b := newSyncProcess()
+	if err := b.sync.Import(delta); err != nil || dfaLen(b.decisionToDFA) != dfaLen(a.decisionToDFA) {
+		t.Errorf("after ClearDFA, %d states of %d were exported: %v", dfaLen(b.decisionToDFA), dfaLen(a.decisionToDFA), err)
+	}
+}
+
+func TestExportImportDFA(t *testing.T) {
+	a, b := newSyncProcess(), newSyncProcess()
+	a.parse("a b + c")
+	a.parse("a")
+	data := a.sim.ExportDFA()
+	if data == nil {
+		t.Fatal("nothing was exported")
+	}
+	// unlike a DFASync, the simulator exports the whole cache each time
+	if again := a.sim.ExportDFA(); len(again) != len(data) {
+		t.Errorf("the second export has %d bytes, and the first %d", len(again), len(data))
+	}
+
+	// the states are imported into the cache the simulator was created with, rather than its snapshot
+	b.parse("a")
+	b.sim.UseDFASnapshot(NewDFASnapshot(b.atn, newDFA(b.atn)))
+	if err := b.sim.ImportDFA(data); err != nil || dfaLen(b.decisionToDFA) != dfaLen(a.decisionToDFA) {
+		t.Fatalf("ImportDFA() = %v, with %d states of %d", err, dfaLen(b.decisionToDFA), dfaLen(a.decisionToDFA))
+	}
+	if tree, predicted := b.parse("a b + c"); tree != "(s (item a) (item b + c) <EOF>)" || predicted != 0 {
+		t.Errorf("tree %s, %d states visited", tree, predicted)
+	}
+	if err := b.sim.ImportDFA(nil); err != nil {
+		t.Errorf("ImportDFA(nil) = %v", err)
+	}
+
+	// a parser exports and imports the cache of its simulator
+	p := newListParser(nil)
+	p.Interpreter = NewParserATNSimulator(p, a.atn, newDFA(a.atn), NewPredictionContextCache())
+	if p.ExportDFA() != nil {
+		t.Error("an empty cache was exported")
+	}
+	if err := p.ImportDFA(data); err != nil || len(p.ExportDFA()) != len(data) {
+		t.Errorf("ImportDFA() = %v", err)
+	}
+}
+
+func TestImportDFAOfAnotherVersion(t *testing.T) {
+	a := newSyncProcess()
+	a.parse("a b + c")
+	a.parse("a")
+	data := a.sim.ExportDFA()
+
+	// the fingerprint of the grammar follows the version, and a delta of version 1 has none
+	stale := append([]byte{}, data...)
+	stale[5]++
+	b := newSyncProcess()
+	b.parse("a")
+	states := dfaLen(b.decisionToDFA)
+	err := b.sim.ImportDFA(stale)
+	if !errors.Is(err, ErrInvalidDFADelta) || !errors.Is(err, ErrGrammarMismatch) || dfaLen(b.decisionToDFA) != states {
+		t.Errorf("ImportDFA() of a stale cache = %v, with %d states of %d", err, dfaLen(b.decisionToDFA), states)
+	}
+	version1 := append([]byte("ADFA\x01"), data[13:]...)
+	if err := b.sim.ImportDFA(version1); err != nil || dfaLen(b.decisionToDFA) != dfaLen(a.decisionToDFA) {
+		t.Errorf("ImportDFA() of version 1 = %v, with %d states of %d", err, dfaLen(b.decisionToDFA), dfaLen(a.decisionToDFA))
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func newPrecedenceDFA(decision int) *DFA {
+	entry := NewStarLoopEntryState()
+	entry.precedenceRuleDecision = true
+	return NewDFA(entry, decision)
+}
+
+func TestDFAPrecedenceStartStates(t *testing.T) {
+	dfa := newPrecedenceDFA(2)
+	if !dfa.IsPrecedenceDfa() || dfa.Decision() != 2 {
+		t.Fatalf("IsPrecedenceDfa() = %v, Decision() = %d", dfa.IsPrecedenceDfa(), dfa.Decision())
+	}
+	if starts := dfa.PrecedenceStartStates(); len(starts) != 0 {
+		t.Errorf("a new DFA has start states %v", starts)
+	}
+
+	s0, s2 := NewDFAState(1, NewATNConfigSet(false)), NewDFAState(2, NewATNConfigSet(false))
+	s2.isAcceptState = true
+	s2.prediction = 1
+	dfa.setPrecedenceStartState(0, s0)
+	dfa.setPrecedenceStartState(2, s2)
+	if starts := dfa.PrecedenceStartStates(); len(starts) != 2 || starts[0] != s0 || starts[2] != s2 {
+		t.Errorf("start states %v", starts)
+	}
+	if got, want := dfa.PrecedenceString(nil, nil), "precedence 0->s1\nprecedence 2->:s2=>1\n"; got != want {
+		t.Errorf("PrecedenceString() = %q, want %q", got, want)
+	}
+
+	other := NewDFA(NewBasicBlockStartState(), 0)
+	if other.IsPrecedenceDfa() || other.PrecedenceStartStates() != nil {
+		t.Error("a DFA for another decision is a precedence DFA")
+	}
+	if other.PrecedenceString(nil, nil) != other.String(nil, nil) {
+		t.Error("PrecedenceString of a DFA for another decision is not its String")
+	}
+}
+
+func TestDumpPrecedenceDFA(t *testing.T) {
+	p := newListParser(nil)
+	decisionToDFA := newDFA(p.GetATN())
+	dfa := newPrecedenceDFA(1)
+	dfa.setPrecedenceStartState(1, NewDFAState(4, NewATNConfigSet(false)))
+	decisionToDFA[1] = dfa
+	p.Interpreter = NewParserATNSimulator(p, p.GetATN(), decisionToDFA, NewPredictionContextCache())
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	p.DumpPrecedenceDFA()
+	os.Stdout = stdout
+	w.Close()
+	out, _ := io.ReadAll(r)
+	if got, want := string(out), "Decision 1 (precedence):\nprecedence 1->s4\n"; got != want {
+		t.Errorf("DumpPrecedenceDFA printed %q, want %q", got, want)
+	}
+}
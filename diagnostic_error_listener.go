@@ -6,6 +6,7 @@ package antlr
 
 import (
 	"strconv"
+	"strings"
 )
 
 //
@@ -50,7 +51,7 @@ func (d *DiagnosticErrorListener) ReportAmbiguity(recognizer Parser, dfa *DFA, s
 	msg := "reportAmbiguity d=" +
 		d.getDecisionDescription(recognizer, dfa) +
 		": ambigAlts=" +
-		d.getConflictingAlts(ambigAlts, configs).String() +
+		d.getAltsDescription(recognizer, dfa, d.getConflictingAlts(ambigAlts, configs)) +
 		", input='" +
 		recognizer.GetTokenStream().GetTextFromInterval(NewInterval(startIndex, stopIndex)) + "'"
 	recognizer.NotifyErrorListeners(msg, nil, nil)
@@ -88,6 +89,34 @@ func (d *DiagnosticErrorListener) getDecisionDescription(recognizer Parser, dfa
 	return strconv.Itoa(decision) + " (" + ruleName + ")"
 }
 
+// getAltsDescription returns the set of alternatives of the decision of dfa, as in {1, 2}, with the label of
+// each alternative that has one registered with the recognizer, as in {1 (Add), 2 (Sub)}.
+func (d *DiagnosticErrorListener) getAltsDescription(recognizer Parser, dfa *DFA, alts *BitSet) string {
+	labels, ok := recognizer.(interface {
+		GetAltLabel(decision, alt int) (string, bool)
+	})
+	if !ok {
+		return alts.String()
+	}
+	var names []string
+	labeled := false
+	for alt := 0; alt < len(alts.data)*bitsPerWord; alt++ {
+		if !alts.contains(alt) {
+			continue
+		}
+		name := strconv.Itoa(alt)
+		if label, ok := labels.GetAltLabel(dfa.decision, alt); ok {
+			name += " (" + label + ")"
+			labeled = true
+		}
+		names = append(names, name)
+	}
+	if !labeled {
+		return alts.String()
+	}
+	return "{" + strings.Join(names, ", ") + "}"
+}
+
 // Computes the set of conflicting or ambiguous alternatives from a
 // configuration set, if that information was not already provided by the
 // parser.
@@ -0,0 +1,229 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync/atomic"
+)
+
+type diagnosticsConfiguration struct {
+	dfaStates  bool
+	configSets bool
+	closure    bool
+}
+
+// diagnosticsConfig is the configuration set by ConfigureDiagnostics, or nil if no diagnostics are collected,
+// so that the simulators check a single pointer when none are.
+var diagnosticsConfig atomic.Pointer[diagnosticsConfiguration]
+
+type diagnosticsOption func(*diagnosticsConfiguration) error
+
+// ConfigureDiagnostics turns the collection of diagnostics by all recognizers on or off, at run time, unlike
+// the statistics of collections that need the runtime to be built with the antlr.stats build tag, so that the
+// diagnostics can be had from a prebuilt binary, such as with a flag or an admin endpoint of a service. Each
+// recognizer collects its own diagnostics, for each decision of its grammar, or each mode of a lexer, until
+// they are reset with [BaseATNSimulator.ResetDiagnostics]; see [DecisionDiagnostics]. The options are applied
+// in the order they are passed in, to the configuration set by the calls before, and diagnostics that are not
+// turned on cost a single check of a pointer.
+//
+// Use:
+//
+//	antlr.ConfigureDiagnostics(antlr.WithDFAStateDiagnostics(true), antlr.WithConfigSetDiagnostics(true))
+//	tree := p.Document()
+//	err := antlr.WriteDiagnosticsJSON(os.Stderr, lexer, p)
+func ConfigureDiagnostics(options ...diagnosticsOption) error {
+	var config diagnosticsConfiguration
+	if current := diagnosticsConfig.Load(); current != nil {
+		config = *current
+	}
+	for _, option := range options {
+		if err := option(&config); err != nil {
+			return err
+		}
+	}
+	if config == (diagnosticsConfiguration{}) {
+		diagnosticsConfig.Store(nil)
+	} else {
+		diagnosticsConfig.Store(&config)
+	}
+	return nil
+}
+
+// WithDFAStateDiagnostics turns on or off the counting of the DFA states that each recognizer adds to the DFA
+// cache of its grammar.
+//
+// Use:
+//
+//	antlr.ConfigureDiagnostics(antlr.WithDFAStateDiagnostics(true))
+func WithDFAStateDiagnostics(collect bool) diagnosticsOption {
+	return func(config *diagnosticsConfiguration) error {
+		config.dfaStates = collect
+		return nil
+	}
+}
+
+// WithConfigSetDiagnostics turns on or off the recording of the sizes of the sets of [ATN] configurations that
+// each recognizer computes while it simulates the ATN.
+//
+// Use:
+//
+//	antlr.ConfigureDiagnostics(antlr.WithConfigSetDiagnostics(true))
+func WithConfigSetDiagnostics(collect bool) diagnosticsOption {
+	return func(config *diagnosticsConfiguration) error {
+		config.configSets = collect
+		return nil
+	}
+}
+
+// WithClosureDiagnostics turns on or off the counting of the epsilon closures that each recognizer computes,
+// and the recording of their depth.
+//
+// Use:
+//
+//	antlr.ConfigureDiagnostics(antlr.WithClosureDiagnostics(true))
+func WithClosureDiagnostics(collect bool) diagnosticsOption {
+	return func(config *diagnosticsConfiguration) error {
+		config.closure = collect
+		return nil
+	}
+}
+
+// DecisionDiagnostics holds the diagnostics collected by a recognizer for one decision of its grammar, or
+// one mode of a lexer, see [ConfigureDiagnostics]. The counts of diagnostics that were not turned on are zero.
+type DecisionDiagnostics struct {
+	// Decision is the number of the decision, or of the mode of a lexer
+	Decision int `json:"decision"`
+
+	// DFAStates is the number of states in the DFA of the decision, shared by all the recognizers of the
+	// grammar, when the diagnostics were taken, and DFAStatesAdded the number of them that the recognizer added
+	DFAStates      int `json:"dfaStates"`
+	DFAStatesAdded int `json:"dfaStatesAdded"`
+
+	// ConfigSets is the number of sets of ATN configurations that the recognizer computed as it simulated the
+	// ATN, one for each symbol of lookahead, Configs the number of configurations in them, and MaxConfigSetSize
+	// the number in the largest
+	ConfigSets       int `json:"configSets"`
+	Configs          int `json:"configs"`
+	MaxConfigSetSize int `json:"maxConfigSetSize"`
+
+	// Closures is the number of configurations whose epsilon closure the recognizer computed, including those
+	// reached while computing another, and MaxClosureDepth the deepest that a closure went
+	Closures        int `json:"closures"`
+	MaxClosureDepth int `json:"maxClosureDepth"`
+}
+
+// RecognizerDiagnostics holds the diagnostics collected by a recognizer, as written by [WriteDiagnosticsJSON].
+type RecognizerDiagnostics struct {
+	// Grammar is the name of the grammar file of the recognizer
+	Grammar string `json:"grammar"`
+
+	// Kind is "lexer" or "parser"
+	Kind string `json:"kind"`
+
+	// Decisions holds the diagnostics of each decision for which the recognizer collected any, in order
+	Decisions []DecisionDiagnostics `json:"decisions"`
+}
+
+// diagnosticsFor returns the diagnostics of decision, which are created if need be.
+func (b *BaseATNSimulator) diagnosticsFor(decision int) *DecisionDiagnostics {
+	if decision >= len(b.diagnostics) {
+		b.diagnostics = append(b.diagnostics, make([]DecisionDiagnostics, decision+1-len(b.diagnostics))...)
+	}
+	return &b.diagnostics[decision]
+}
+
+// noteDFAState records that the simulator added a state to the DFA of decision.
+func (b *BaseATNSimulator) noteDFAState(decision int) {
+	if config := diagnosticsConfig.Load(); config != nil && config.dfaStates && decision >= 0 {
+		b.diagnosticsFor(decision).DFAStatesAdded++
+	}
+}
+
+// noteConfigSet records that the simulator computed a set of size configurations for decision.
+func (b *BaseATNSimulator) noteConfigSet(decision, size int) {
+	if config := diagnosticsConfig.Load(); config != nil && config.configSets && decision >= 0 {
+		d := b.diagnosticsFor(decision)
+		d.ConfigSets++
+		d.Configs += size
+		d.MaxConfigSetSize = max(d.MaxConfigSetSize, size)
+	}
+}
+
+// noteClosure records that the simulator computed the closure of a configuration for decision, at the current
+// closure depth.
+func (b *BaseATNSimulator) noteClosure(decision int) {
+	if config := diagnosticsConfig.Load(); config != nil && config.closure && decision >= 0 {
+		d := b.diagnosticsFor(decision)
+		d.Closures++
+		d.MaxClosureDepth = max(d.MaxClosureDepth, len(b.closurePath))
+	}
+}
+
+// GetDiagnostics returns the diagnostics the simulator has collected for each decision of its grammar, or each
+// mode of a lexer, for which it collected any, see [ConfigureDiagnostics]. Call it between parses, or from the
+// goroutine of the recognizer.
+func (b *BaseATNSimulator) GetDiagnostics() []DecisionDiagnostics {
+	b.atn.stateMu.RLock()
+	defer b.atn.stateMu.RUnlock()
+	var decisions []DecisionDiagnostics
+	for i, d := range b.diagnostics {
+		if d == (DecisionDiagnostics{}) {
+			continue
+		}
+		d.Decision = i
+		if i < len(b.decisionToDFA) {
+			d.DFAStates = b.decisionToDFA[i].Len()
+		}
+		decisions = append(decisions, d)
+	}
+	return decisions
+}
+
+// ResetDiagnostics discards the diagnostics the simulator has collected.
+func (b *BaseATNSimulator) ResetDiagnostics() {
+	b.diagnostics = nil
+}
+
+// CollectDiagnostics returns the diagnostics collected by each of the recognizers, which must be parsers or
+// lexers, as generated by ANTLR or interpreted. See [ConfigureDiagnostics].
+func CollectDiagnostics(recognizers ...Recognizer) []RecognizerDiagnostics {
+	collected := make([]RecognizerDiagnostics, 0, len(recognizers))
+	for _, r := range recognizers {
+		var d RecognizerDiagnostics
+		if named, ok := r.(interface{ GetGrammarFileName() string }); ok {
+			d.Grammar = named.GetGrammarFileName()
+		}
+		switch r := r.(type) {
+		case Parser:
+			d.Kind = "parser"
+			d.Decisions = r.GetInterpreter().GetDiagnostics()
+		case Lexer:
+			d.Kind = "lexer"
+			if sim, ok := r.(interface{ GetInterpreter() ILexerATNSimulator }); ok {
+				if sim, ok := sim.GetInterpreter().(ILexerATNSimulatorDFA); ok {
+					d.Decisions = sim.GetDiagnostics()
+				}
+			}
+		default:
+			panic(fmt.Sprintf("cannot collect the diagnostics of a recognizer of type %T", r))
+		}
+		if d.Decisions == nil {
+			d.Decisions = []DecisionDiagnostics{}
+		}
+		collected = append(collected, d)
+	}
+	return collected
+}
+
+// WriteDiagnosticsJSON writes the diagnostics collected by each of the recognizers to w as a JSON array, with
+// an object for each recognizer in the form of [RecognizerDiagnostics].
+func WriteDiagnosticsJSON(w io.Writer, recognizers ...Recognizer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(CollectDiagnostics(recognizers...))
+}
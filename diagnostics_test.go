@@ -0,0 +1,93 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// diagnosticsParse parses input with a lexer and a parser that share the DFAs given.
+func diagnosticsParse(input string, lexerDFA, parserDFA []*DFA) (*listLexer, *listParser) {
+	lexer := newListLexer(NewInputStream(input))
+	lexer.Interpreter = NewLexerATNSimulator(lexer, lexer.GetATN(), lexerDFA, NewPredictionContextCache())
+	p := newListParser(NewCommonTokenStream(lexer, TokenDefaultChannel))
+	p.Interpreter = NewParserATNSimulator(p, p.GetATN(), parserDFA, NewPredictionContextCache())
+	p.S()
+	return lexer, p
+}
+
+func TestConfigureDiagnostics(t *testing.T) {
+	defer ConfigureDiagnostics(WithDFAStateDiagnostics(false), WithConfigSetDiagnostics(false),
+		WithClosureDiagnostics(false))
+	lexerDFA, parserDFA := newDFA(newListLexer(nil).GetATN()), newDFA(newListParser(nil).GetATN())
+
+	// diagnostics that are not turned on are not collected
+	lexer, p := diagnosticsParse("a b + c", lexerDFA, parserDFA)
+	if d := p.GetInterpreter().GetDiagnostics(); d != nil {
+		t.Errorf("diagnostics were collected before they were turned on: %+v", d)
+	}
+
+	if err := ConfigureDiagnostics(WithDFAStateDiagnostics(true), WithConfigSetDiagnostics(true)); err != nil {
+		t.Fatal(err)
+	}
+	_ = ConfigureDiagnostics(WithClosureDiagnostics(true))
+	lexerDFA, parserDFA = newDFA(lexer.GetATN()), newDFA(p.GetATN())
+	lexer, p = diagnosticsParse("a b + c", lexerDFA, parserDFA)
+	// the loop of s is predicted by the generated code, and only the alternatives of item by the simulator
+	decisions := p.GetInterpreter().GetDiagnostics()
+	if len(decisions) != 1 {
+		t.Fatalf("diagnostics of %d decisions, want 1: %+v", len(decisions), decisions)
+	}
+	if d := decisions[0]; d.Decision != 1 || d.DFAStatesAdded != parserDFA[1].Len() || d.DFAStates != d.DFAStatesAdded ||
+		d.ConfigSets == 0 || d.Configs < d.ConfigSets || d.MaxConfigSetSize == 0 || d.Closures == 0 ||
+		d.MaxClosureDepth == 0 {
+		t.Errorf("decision diagnostics %+v, with %d DFA states", d, parserDFA[1].Len())
+	}
+	lexerDecisions := lexer.Interpreter.(*LexerATNSimulator).GetDiagnostics()
+	if len(lexerDecisions) != 1 || lexerDecisions[0].DFAStatesAdded != lexerDFA[0].Len() || lexerDecisions[0].Closures == 0 {
+		t.Errorf("lexer diagnostics %+v, with %d DFA states", lexerDecisions, lexerDFA[0].Len())
+	}
+
+	// a parser that finds the DFA states it needs in the cache adds none, nor simulates the ATN
+	_, again := diagnosticsParse("d e + f", lexerDFA, parserDFA)
+	if d := again.GetInterpreter().GetDiagnostics(); d != nil {
+		t.Errorf("diagnostics of a warm cache: %+v", d)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDiagnosticsJSON(&buf, lexer, p); err != nil {
+		t.Fatal(err)
+	}
+	var written []RecognizerDiagnostics
+	if err := json.Unmarshal(buf.Bytes(), &written); err != nil {
+		t.Fatal(err)
+	}
+	want := []RecognizerDiagnostics{
+		{Grammar: lexer.GetGrammarFileName(), Kind: "lexer", Decisions: lexerDecisions},
+		{Grammar: p.GetGrammarFileName(), Kind: "parser", Decisions: decisions},
+	}
+	if !reflect.DeepEqual(written, want) || !reflect.DeepEqual(CollectDiagnostics(lexer, p), want) {
+		t.Errorf("diagnostics written:\n%s\nwant %+v", buf.String(), want)
+	}
+
+	p.GetInterpreter().ResetDiagnostics()
+	if d := CollectDiagnostics(p)[0].Decisions; d == nil || len(d) != 0 {
+		t.Errorf("diagnostics after ResetDiagnostics: %+v", d)
+	}
+
+	// turned off, they are no longer collected
+	_ = ConfigureDiagnostics(WithDFAStateDiagnostics(false), WithConfigSetDiagnostics(false),
+		WithClosureDiagnostics(false))
+	if diagnosticsConfig.Load() != nil {
+		t.Error("diagnostics that are all turned off are still configured")
+	}
+	_, p = diagnosticsParse("a b + c", newDFA(p.GetATN()), newDFA(p.GetATN()))
+	if d := p.GetInterpreter().GetDiagnostics(); d != nil {
+		t.Errorf("diagnostics were collected after they were turned off: %+v", d)
+	}
+}
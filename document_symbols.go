@@ -0,0 +1,149 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// SymbolPathStep is one step of the path from a definition to its name, as given to
+// [SymbolExtractor.AddDefinition]. A step with a TokenType selects the first child of the current node that
+// is a token of that type, and a step without one, that is, with a TokenType of zero, selects the first
+// child that is a rule context with the given RuleIndex.
+type SymbolPathStep struct {
+	RuleIndex int
+	TokenType int
+}
+
+// DocumentSymbol is a definition found in a parse tree by a [SymbolExtractor], in the form of the
+// DocumentSymbol of the Language Server Protocol.
+type DocumentSymbol struct {
+	// Name is the text of the name of the definition
+	Name string
+
+	// Kind is the kind of the symbol, as given to AddDefinition, typically an LSP SymbolKind
+	Kind int
+
+	// Range is the range of the whole definition
+	Range TextRange
+
+	// SelectionRange is the range of the name of the definition
+	SelectionRange TextRange
+
+	// Context is the rule context of the definition
+	Context ParserRuleContext
+
+	// Children holds the symbols defined within this one, in order
+	Children []*DocumentSymbol
+}
+
+// symbolDefinition is a rule nominated as a definition by SymbolExtractor.AddDefinition.
+type symbolDefinition struct {
+	kind     int
+	namePath []SymbolPathStep
+}
+
+// SymbolExtractor walks a parse tree and collects the definitions that it contains, such as those of
+// functions, types and variables, into a tree of [DocumentSymbol], for an outline view or the
+// documentSymbol request of a language server. Which rules are definitions, and where their names are, is
+// configured with [SymbolExtractor.AddDefinition].
+//
+// Use:
+//
+//	extractor := antlr.NewSymbolExtractor()
+//	// functionDecl : 'func' ID '(' params ')' block ;
+//	extractor.AddDefinition(parser.MyParserRULE_functionDecl, 12, antlr.SymbolPathStep{TokenType: parser.MyParserID})
+//	// classDecl : 'class' qualifiedName '{' member* '}' ;
+//	extractor.AddDefinition(parser.MyParserRULE_classDecl, 5, antlr.SymbolPathStep{RuleIndex: parser.MyParserRULE_qualifiedName})
+//	symbols := extractor.Extract(tree)
+type SymbolExtractor struct {
+	definitions map[int]*symbolDefinition
+}
+
+// NewSymbolExtractor creates a [SymbolExtractor] with no definitions.
+func NewSymbolExtractor() *SymbolExtractor {
+	return &SymbolExtractor{definitions: make(map[int]*symbolDefinition)}
+}
+
+// AddDefinition nominates the rule with the given index as a definition of a symbol of the given kind,
+// whose name is found by following namePath from the rule context. The name may be a token, or a rule
+// context such as a qualified name, in which case the name is its whole text. If namePath is empty, the
+// name is the text of the first token of the definition.
+func (e *SymbolExtractor) AddDefinition(ruleIndex, kind int, namePath ...SymbolPathStep) {
+	e.definitions[ruleIndex] = &symbolDefinition{kind: kind, namePath: namePath}
+}
+
+// Extract returns the symbols defined in tree, in order, each with the symbols defined within it as its
+// children. A definition whose name cannot be found, as happens when the parser recovers from a syntax
+// error, is left out, and the symbols within it become children of the enclosing symbol.
+func (e *SymbolExtractor) Extract(tree ParseTree) []*DocumentSymbol {
+	root := &DocumentSymbol{}
+	e.extract(tree, root)
+	return root.Children
+}
+
+func (e *SymbolExtractor) extract(t Tree, parent *DocumentSymbol) {
+	ctx, ok := t.(ParserRuleContext)
+	if !ok {
+		return
+	}
+	if def, ok := e.definitions[ctx.GetRuleIndex()]; ok {
+		if symbol := e.symbol(ctx, def); symbol != nil {
+			parent.Children = append(parent.Children, symbol)
+			parent = symbol
+		}
+	}
+	for _, child := range ctx.GetChildren() {
+		e.extract(child, parent)
+	}
+}
+
+// symbol returns the symbol defined by ctx, or nil if its name cannot be found.
+func (e *SymbolExtractor) symbol(ctx ParserRuleContext, def *symbolDefinition) *DocumentSymbol {
+	var name Tree = ctx
+	for _, step := range def.namePath {
+		name = symbolPathChild(name, step)
+		if name == nil {
+			return nil
+		}
+	}
+
+	symbol := &DocumentSymbol{Kind: def.kind, Range: ContextTextRange(ctx), Context: ctx}
+	switch n := name.(type) {
+	case ErrorNode:
+		return nil
+	case TerminalNode:
+		symbol.Name = n.GetText()
+		symbol.SelectionRange = TokenTextRange(n.GetSymbol())
+	case ParserRuleContext:
+		if len(def.namePath) == 0 {
+			if ctx.GetStart() == nil || ctx.GetStart().GetTokenType() == TokenEOF {
+				return nil
+			}
+			symbol.Name = ctx.GetStart().GetText()
+			symbol.SelectionRange = TokenTextRange(ctx.GetStart())
+		} else {
+			symbol.Name = n.GetText()
+			symbol.SelectionRange = ContextTextRange(n)
+		}
+	}
+	if symbol.Name == "" || symbol.SelectionRange.Start < 0 {
+		return nil
+	}
+	return symbol
+}
+
+// symbolPathChild returns the first child of t selected by step, or nil if there is none.
+func symbolPathChild(t Tree, step SymbolPathStep) Tree {
+	for _, child := range t.GetChildren() {
+		switch c := child.(type) {
+		case TerminalNode:
+			if step.TokenType != 0 && c.GetSymbol().GetTokenType() == step.TokenType {
+				return c
+			}
+		case ParserRuleContext:
+			if step.TokenType == 0 && c.GetRuleIndex() == step.RuleIndex {
+				return c
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// symbolOutline returns the symbols as text, one per line, indented by their nesting.
+func symbolOutline(symbols []*DocumentSymbol, indent string) string {
+	var sb strings.Builder
+	for _, s := range symbols {
+		_, _ = fmt.Fprintf(&sb, "%s%d %s %d:%d-%d:%d %d:%d-%d:%d\n", indent, s.Kind, s.Name,
+			s.Range.StartLine, s.Range.StartColumn, s.Range.EndLine, s.Range.EndColumn,
+			s.SelectionRange.StartLine, s.SelectionRange.StartColumn, s.SelectionRange.EndLine, s.SelectionRange.EndColumn)
+		sb.WriteString(symbolOutline(s.Children, indent+"  "))
+	}
+	return sb.String()
+}
+
+func TestSymbolExtractor(t *testing.T) {
+	p := newListParser(listStream("a\nbb + c"))
+	tree := p.S()
+
+	// the list is named by its first token, and each item by its ID
+	extractor := NewSymbolExtractor()
+	extractor.AddDefinition(listRuleS, 1)
+	extractor.AddDefinition(listRuleItem, 2, SymbolPathStep{TokenType: listID})
+	want := `1 a 0:0-1:6 0:0-0:1
+  2 a 0:0-0:1 0:0-0:1
+  2 bb 1:0-1:6 1:0-1:2
+`
+	if got := symbolOutline(extractor.Extract(tree), ""); got != want {
+		t.Errorf("symbols:\n%s\nwant:\n%s", got, want)
+	}
+
+	// an item without '+' has no name, and is left out
+	extractor.AddDefinition(listRuleItem, 2, SymbolPathStep{TokenType: listPLUS})
+	want = `1 a 0:0-1:6 0:0-0:1
+  2 + 1:0-1:6 1:3-1:4
+`
+	if got := symbolOutline(extractor.Extract(tree), ""); got != want {
+		t.Errorf("symbols:\n%s\nwant:\n%s", got, want)
+	}
+	symbols := extractor.Extract(tree)
+	if symbols[0].Context != tree || symbols[0].Children[0].Context != tree.GetChild(1) {
+		t.Error("the symbols do not hold their contexts")
+	}
+
+	// a path to a rule context names the symbol with the text of the context
+	extractor = NewSymbolExtractor()
+	extractor.AddDefinition(listRuleS, 1, SymbolPathStep{RuleIndex: listRuleItem})
+	if got := symbolOutline(extractor.Extract(tree), ""); got != "1 a 0:0-1:6 0:0-0:1\n" {
+		t.Errorf("symbols:\n%s", got)
+	}
+}
+
+func TestTextRange(t *testing.T) {
+	token := newTestToken(listID, 4, 8, 2, 3)
+	token.SetText("ab\ncd")
+	token.SetTokenIndex(1)
+	if got := TokenTextRange(token); got != (TextRange{1, 3, 2, 2, 4, 8}) {
+		t.Errorf("TokenTextRange() = %+v", got)
+	}
+
+	// an empty context lies at the start of the token that follows it
+	ctx := NewBaseParserRuleContext(nil, -1)
+	ctx.SetStart(token)
+	before := newTestToken(listID, 0, 2, 1, 0)
+	before.SetTokenIndex(0)
+	ctx.SetStop(before)
+	if got := ContextTextRange(ctx); got != (TextRange{1, 3, 1, 3, 4, 3}) {
+		t.Errorf("ContextTextRange() of an empty context = %+v", got)
+	}
+	if got := ContextTextRange(NewBaseParserRuleContext(nil, -1)); got.Start != -1 {
+		t.Errorf("ContextTextRange() of a context without tokens = %+v", got)
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// dotEscaper escapes the text of a label in the DOT language.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// ToDOT renders the DFA in the DOT language of Graphviz, so that the DFA that the runtime has built for a
+// decision can be viewed as a graph, such as to see why a decision is slow or ambiguous. Accept states are
+// drawn as double circles, labelled with the alternative they predict, and states that need full context
+// prediction are marked with ^ and drawn dashed. Edges are labelled with the names of their tokens, looked up
+// in vocabulary, typically the parser, which may be nil, or with the characters of a lexer DFA. The start
+// state of each precedence of a precedence DFA is shown by an edge from the start point.
+//
+// The DFA may be rendered while parsers are using it.
+//
+// Use:
+//
+//	dot := p.GetInterpreter().DecisionToDFA()[decision].ToDOT(p) // then run: dot -Tsvg dfa.dot > dfa.svg
+func (d *DFA) ToDOT(vocabulary Recognizer) string {
+	var edgeLabel func(i int) string
+	if d.atnStartState != nil && d.atnStartState.GetATN() != nil {
+		atn := d.atnStartState.GetATN()
+		atn.stateMu.RLock()
+		defer atn.stateMu.RUnlock()
+		atn.edgeMu.RLock()
+		defer atn.edgeMu.RUnlock()
+		if atn.grammarType == ATNTypeLexer {
+			edgeLabel = NewLexerDFASerializer(d).getEdgeLabel
+		}
+	}
+	if edgeLabel == nil {
+		var literalNames, symbolicNames []string
+		if vocabulary != nil {
+			literalNames, symbolicNames = vocabulary.GetLiteralNames(), vocabulary.GetSymbolicNames()
+		}
+		edgeLabel = func(i int) string {
+			// the edges of a parser DFA are indexed by token type + 1, so that EOF is at 0
+			return vocabularyName(i-1, literalNames, symbolicNames)
+		}
+	}
+
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "digraph dfa%d {\n\trankdir=LR;\n\tstart [shape=point];\n", d.decision)
+	if s0 := d.getS0(); s0 != nil {
+		if d.getPrecedenceDfa() {
+			for precedence, s := range s0.getEdges() {
+				if s != nil {
+					_, _ = fmt.Fprintf(&sb, "\tstart -> s%d [label=\"precedence %d\"];\n", s.stateNumber, precedence)
+				}
+			}
+		} else {
+			_, _ = fmt.Fprintf(&sb, "\tstart -> s%d;\n", s0.stateNumber)
+		}
+	}
+
+	for _, s := range d.sortedStates() {
+		label := "s" + strconv.Itoa(s.stateNumber)
+		if s.requiresFullContext {
+			label += "^"
+		}
+		shape := "circle"
+		if s.isAcceptState {
+			shape = "doublecircle"
+			if s.predicates != nil {
+				label += "\n=>" + fmt.Sprint(s.predicates)
+			} else {
+				label += "\n=>" + strconv.Itoa(s.prediction)
+			}
+		}
+		style := ""
+		if s.requiresFullContext {
+			style = ", style=dashed"
+		}
+		_, _ = fmt.Fprintf(&sb, "\ts%d [label=\"%s\", shape=%s%s];\n", s.stateNumber, dotEscaper.Replace(label), shape, style)
+
+		for i, t := range s.getEdges() {
+			if t != nil && t != ATNSimulatorError {
+				_, _ = fmt.Fprintf(&sb, "\ts%d -> s%d [label=\"%s\"];\n", s.stateNumber, t.stateNumber, dotEscaper.Replace(edgeLabel(i)))
+			}
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// ToDOT renders the ATN in the DOT language of Graphviz, with the states of each rule grouped in a box labelled
+// with the name of the rule, looked up in ruleNames, which may be nil. See [ATN.RuleToDOT], which renders a
+// single rule, as the ATN of a whole grammar is usually too large to view.
+func (a *ATN) ToDOT(ruleNames []string, vocabulary Recognizer) string {
+	var sb strings.Builder
+	sb.WriteString("digraph atn {\n\trankdir=LR;\n")
+	for rule := range a.ruleToStartState {
+		_, _ = fmt.Fprintf(&sb, "\tsubgraph cluster_%d {\n\t\tlabel=\"%s\";\n", rule, dotEscaper.Replace(atnRuleName(rule, ruleNames)))
+		a.writeRuleDOT(&sb, "\t\t", rule, ruleNames, vocabulary)
+		sb.WriteString("\t}\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// RuleToDOT renders the ATN of the rule with the given index in the DOT language of Graphviz. The start and
+// stop states of the rule are drawn as double circles, and decision states as diamonds. The transitions that
+// return from the stop state to the callers of the rule are not drawn. Each transition is
+// labelled with what it matches: the names of its tokens, looked up in vocabulary, typically the parser,
+// which may be nil, or the characters of a lexer rule; ε for an epsilon transition; the name of the rule
+// invoked, from ruleNames, for a rule invocation, which is drawn as an edge to the state that follows the
+// invocation; or the predicate or action it runs. It returns an error if the ATN has no rule with the index.
+//
+// Use:
+//
+//	dot, err := p.GetATN().RuleToDOT(parser.MyParserRULE_expr, p.GetRuleNames(), p)
+func (a *ATN) RuleToDOT(ruleIndex int, ruleNames []string, vocabulary Recognizer) (string, error) {
+	if ruleIndex < 0 || ruleIndex >= len(a.ruleToStartState) {
+		return "", fmt.Errorf("invalid rule index %d", ruleIndex)
+	}
+	var sb strings.Builder
+	_, _ = fmt.Fprintf(&sb, "digraph %s {\n\trankdir=LR;\n", strconv.Quote(atnRuleName(ruleIndex, ruleNames)))
+	a.writeRuleDOT(&sb, "\t", ruleIndex, ruleNames, vocabulary)
+	sb.WriteString("}\n")
+	return sb.String(), nil
+}
+
+// writeRuleDOT writes the states of the rule with the given index, and their transitions, each line beginning
+// with indent.
+func (a *ATN) writeRuleDOT(sb *strings.Builder, indent string, ruleIndex int, ruleNames []string, vocabulary Recognizer) {
+	for _, s := range a.states {
+		if s == nil || s.GetRuleIndex() != ruleIndex {
+			continue
+		}
+		shape := "circle"
+		switch s.(type) {
+		case *RuleStartState, *RuleStopState:
+			shape = "doublecircle"
+		case DecisionState:
+			shape = "diamond"
+		}
+		_, _ = fmt.Fprintf(sb, "%ss%d [label=\"%d\", shape=%s];\n", indent, s.GetStateNumber(), s.GetStateNumber(), shape)
+		if _, ok := s.(*RuleStopState); ok {
+			// the transitions of a stop state return to the callers of the rule, in other rules
+			continue
+		}
+
+		for _, t := range s.GetTransitions() {
+			target := t.getTarget()
+			style := ""
+			if rt, ok := t.(*RuleTransition); ok {
+				target = rt.followState
+				style = ", style=dashed"
+			}
+			_, _ = fmt.Fprintf(sb, "%ss%d -> s%d [label=\"%s\"%s];\n", indent, s.GetStateNumber(), target.GetStateNumber(),
+				dotEscaper.Replace(a.transitionLabel(t, ruleNames, vocabulary)), style)
+		}
+	}
+}
+
+// transitionLabel returns the label of transition t in the DOT rendering of the ATN.
+func (a *ATN) transitionLabel(t Transition, ruleNames []string, vocabulary Recognizer) string {
+	switch t := t.(type) {
+	case *EpsilonTransition:
+		return "ε"
+	case *RuleTransition:
+		return atnRuleName(t.ruleIndex, ruleNames)
+	case *PredicateTransition, *PrecedencePredicateTransition, *ActionTransition:
+		return fmt.Sprint(t)
+	case *WildcardTransition:
+		return "."
+	}
+
+	label := t.getLabel()
+	if label == nil {
+		return fmt.Sprint(t)
+	}
+	var text string
+	if a.grammarType == ATNTypeLexer {
+		text = charIntervalsString(label.intervals)
+	} else {
+		text = label.StringVerbose(vocabulary, true)
+	}
+	if _, ok := t.(*NotSetTransition); ok {
+		text = "~" + text
+	}
+	return text
+}
+
+// atnRuleName returns the name of the rule with the given index, or its index if ruleNames does not have it.
+func atnRuleName(ruleIndex int, ruleNames []string) string {
+	if ruleIndex >= 0 && ruleIndex < len(ruleNames) {
+		return ruleNames[ruleIndex]
+	}
+	return "rule" + strconv.Itoa(ruleIndex)
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDFAToDOT(t *testing.T) {
+	lexer := newListLexer(NewInputStream("a b+c"))
+	lexerATN := lexer.GetATN()
+	lexer.Interpreter = NewLexerATNSimulator(lexer, lexerATN, []*DFA{NewDFA(lexerATN.modeToStartState[0], 0)},
+		NewPredictionContextCache())
+	p := newListParser(NewCommonTokenStream(lexer, TokenDefaultChannel))
+	atn := p.GetATN()
+	decisionToDFA := make([]*DFA, len(atn.DecisionToState))
+	for i, s := range atn.DecisionToState {
+		decisionToDFA[i] = NewDFA(s, i)
+	}
+	p.Interpreter = NewParserATNSimulator(p, atn, decisionToDFA, NewPredictionContextCache())
+	p.S()
+
+	want := `digraph dfa1 {
+	rankdir=LR;
+	start [shape=point];
+	start -> s0;
+	s0 [label="s0", shape=circle];
+	s0 -> s1 [label="ID"];
+	s1 [label="s1", shape=circle];
+	s1 -> s2 [label="ID"];
+	s1 -> s3 [label="'+'"];
+	s2 [label="s2\n=>1", shape=doublecircle];
+	s3 [label="s3\n=>2", shape=doublecircle];
+}
+`
+	if got := decisionToDFA[listRuleItem].ToDOT(p); got != want {
+		t.Errorf("parser DFA:\n%s\nwant:\n%s", got, want)
+	}
+
+	dot := lexer.GetInterpreter().DecisionToDFA()[0].ToDOT(lexer)
+	for _, line := range []string{`s0 -> s1 [label="'a'..'z'"];`, `s0 -> s2 [label="'+'"];`, `s1 [label="s1\n=>1", shape=doublecircle];`} {
+		if !strings.Contains(dot, "\t"+line+"\n") {
+			t.Errorf("lexer DFA has no line %s:\n%s", line, dot)
+		}
+	}
+}
+
+func TestRuleToDOT(t *testing.T) {
+	p := newListParser(nil)
+	atn := p.GetATN()
+	dot, err := atn.RuleToDOT(listRuleItem, p.GetRuleNames(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		`digraph "item" {`,
+		`	s10 [label="10", shape=doublecircle];`,
+		`	s12 [label="12", shape=diamond];`,
+		`	s12 -> s13 [label="ε"];`,
+		`	s15 -> s16 [label="'+'"];`,
+		`	s16 -> s19 [label="ID"];`,
+	} {
+		if !strings.Contains(dot, line+"\n") {
+			t.Errorf("no line %s in:\n%s", line, dot)
+		}
+	}
+	if strings.Contains(dot, "s0 ") {
+		t.Errorf("the rule has a state of another rule:\n%s", dot)
+	}
+
+	// the invocation of item is drawn to the state that follows it
+	dot, _ = atn.RuleToDOT(listRuleS, p.GetRuleNames(), p)
+	if !strings.Contains(dot, `s4 -> s5 [label="item", style=dashed];`) {
+		t.Errorf("no rule invocation in:\n%s", dot)
+	}
+
+	for _, ruleIndex := range []int{-1, 2} {
+		if dot, err := atn.RuleToDOT(ruleIndex, p.GetRuleNames(), p); err == nil || dot != "" {
+			t.Errorf("RuleToDOT(%d) = %q, %v", ruleIndex, dot, err)
+		}
+	}
+
+	all := atn.ToDOT(p.GetRuleNames(), p)
+	if !strings.Contains(all, "subgraph cluster_0 {\n\t\tlabel=\"s\";") || !strings.Contains(all, "subgraph cluster_1 {\n\t\tlabel=\"item\";") {
+		t.Errorf("the rules are not grouped:\n%s", all)
+	}
+}
@@ -98,3 +98,22 @@ func (p *ProxyErrorListener) ReportContextSensitivity(recognizer Parser, dfa *DF
 		d.ReportContextSensitivity(recognizer, dfa, startIndex, stopIndex, prediction, configs)
 	}
 }
+
+// ReportPredictionDifference passes the difference on to each delegate that implements
+// [PredictionDifferenceListener].
+func (p *ProxyErrorListener) ReportPredictionDifference(recognizer Parser, dfa *DFA, startIndex, stopIndex, sllAlt, llAlt int) {
+	for _, d := range p.delegates {
+		if l, ok := d.(PredictionDifferenceListener); ok {
+			l.ReportPredictionDifference(recognizer, dfa, startIndex, stopIndex, sllAlt, llAlt)
+		}
+	}
+}
+
+// ReportRecovery passes the recovery event on to each delegate that implements [RecoveryListener].
+func (p *ProxyErrorListener) ReportRecovery(recognizer Parser, e RecognitionException, recoverySet *IntervalSet) {
+	for _, d := range p.delegates {
+		if l, ok := d.(RecoveryListener); ok {
+			l.ReportRecovery(recognizer, e, recoverySet)
+		}
+	}
+}
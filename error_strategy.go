@@ -27,6 +27,28 @@ type DefaultErrorStrategy struct {
 	errorRecoveryMode bool
 	lastErrorIndex    int
 	lastErrorStates   *IntervalSet
+	recoverySetFilter RecoverySetFunc
+	expectedFilter    ExpectedTokensFunc
+	recoveryLog       *RecoveryLog
+	suggester         TokenSuggester
+}
+
+// RecoverySetFunc post-processes the resynchronization set computed by [CalculateErrorRecoverySet] before
+// the [DefaultErrorStrategy] consumes tokens until one in the set is found. It may modify and return the set
+// it is given, or return a different set. See [DefaultErrorStrategy.SetRecoverySetFilter].
+type RecoverySetFunc func(recognizer Parser, recoverySet *IntervalSet) *IntervalSet
+
+// ExpectedTokensFunc post-processes a set of expected tokens before the [DefaultErrorStrategy] reports it
+// in an error message. It must not modify the set it is given, but should return a new set if it makes any
+// changes. See [DefaultErrorStrategy.SetExpectedTokensFilter].
+type ExpectedTokensFunc func(recognizer Parser, expected *IntervalSet) *IntervalSet
+
+// RecoveryListener may be implemented by an [ErrorListener] that wants to be told about each panic-mode
+// recovery performed by the [DefaultErrorStrategy], including the resynchronization set that the strategy
+// will consume tokens up to. The exception is nil when the recovery is performed by Sync within a loop
+// rather than in response to a [RecognitionException].
+type RecoveryListener interface {
+	ReportRecovery(recognizer Parser, e RecognitionException, recoverySet *IntervalSet)
 }
 
 var _ ErrorStrategy = &DefaultErrorStrategy{}
@@ -51,6 +73,7 @@ func NewDefaultErrorStrategy() *DefaultErrorStrategy {
 	//
 	d.lastErrorIndex = -1
 	d.lastErrorStates = nil
+	d.suggester = NewEditDistanceSuggester(0)
 	return d
 }
 
@@ -96,7 +119,7 @@ func (d *DefaultErrorStrategy) ReportMatch(recognizer Parser) {
 func (d *DefaultErrorStrategy) ReportError(recognizer Parser, e RecognitionException) {
 	// if we've already Reported an error and have not Matched a token
 	// yet successfully, don't Report any errors.
-	if d.InErrorRecoveryMode(recognizer) {
+	if d.InErrorRecoveryMode(recognizer) || isCancelled(recognizer) {
 		return // don't Report spurious errors
 	}
 	d.beginErrorCondition(recognizer)
@@ -115,11 +138,23 @@ func (d *DefaultErrorStrategy) ReportError(recognizer Parser, e RecognitionExcep
 	}
 }
 
+// isCancelled returns true if the parse has been cancelled, in which case there is no point
+// in reporting or recovering from errors.
+func isCancelled(recognizer Parser) bool {
+	_, ok := recognizer.GetError().(*ParseCancellationException)
+	return ok
+}
+
 // Recover is the default recovery implementation.
 // It reSynchronizes the parser by consuming tokens until we find one in the reSynchronization set -
 // loosely the set of tokens that can follow the current rule.
-func (d *DefaultErrorStrategy) Recover(recognizer Parser, _ RecognitionException) {
+func (d *DefaultErrorStrategy) Recover(recognizer Parser, e RecognitionException) {
+	if isCancelled(recognizer) {
+		return
+	}
 
+	start := recognizer.GetInputStream().Index()
+	replayed, replaying := d.replayRecovery(recognizer, RecoveryResync)
 	if d.lastErrorIndex == recognizer.GetInputStream().Index() &&
 		d.lastErrorStates != nil && d.lastErrorStates.contains(recognizer.GetState()) {
 		// uh oh, another error at same token index and previously-Visited
@@ -134,7 +169,83 @@ func (d *DefaultErrorStrategy) Recover(recognizer Parser, _ RecognitionException
 	}
 	d.lastErrorStates.addOne(recognizer.GetState())
 	followSet := d.GetErrorRecoverySet(recognizer)
+	d.reportRecovery(recognizer, e, followSet)
+	if replaying {
+		d.consumeTo(recognizer, replayed.Stop)
+		return
+	}
 	d.consumeUntil(recognizer, followSet)
+	d.recordRecovery(recognizer, RecoveryResync, start)
+}
+
+// SetRecoverySetFilter installs a func that post-processes every resynchronization set computed by this
+// strategy, for instance to always include statement terminators so that recovery never skips past the end
+// of a statement:
+//
+//	strategy := antlr.NewDefaultErrorStrategy()
+//	strategy.SetRecoverySetFilter(func(recognizer antlr.Parser, set *antlr.IntervalSet) *antlr.IntervalSet {
+//	    set.AddOne(parser.MyParserSEMI)
+//	    return set
+//	})
+//	p.SetErrorHandler(strategy)
+//
+// Pass nil to remove the filter.
+func (d *DefaultErrorStrategy) SetRecoverySetFilter(filter RecoverySetFunc) {
+	d.recoverySetFilter = filter
+}
+
+// SetExpectedTokensFilter installs a func that post-processes the expected tokens listed in the error
+// messages produced by this strategy. The filter affects only what is reported, not how the strategy
+// recovers. It is typically used with a token source that remaps token types, so that messages do not
+// list tokens that the parser can never be given. See [TokenTypeRemapper.FilterExpectedTokens].
+//
+// Pass nil to remove the filter.
+func (d *DefaultErrorStrategy) SetExpectedTokensFilter(filter ExpectedTokensFunc) {
+	d.expectedFilter = filter
+}
+
+// SetRecoveryLog installs a [RecoveryLog] that records the recoveries made by this strategy or, once it has
+// been switched to replaying, makes them again. Pass nil to remove the log.
+func (d *DefaultErrorStrategy) SetRecoveryLog(log *RecoveryLog) {
+	d.recoveryLog = log
+}
+
+// recordRecovery records a recovery that began at the current state and at token index start and has just
+// ended, if a [RecoveryLog] is installed.
+func (d *DefaultErrorStrategy) recordRecovery(recognizer Parser, kind RecoveryKind, start int) {
+	if d.recoveryLog != nil {
+		d.recoveryLog.record(RecoveryDecision{
+			Kind:       kind,
+			State:      recognizer.GetState(),
+			TokenIndex: start,
+			Stop:       recognizer.GetInputStream().Index(),
+		})
+	}
+}
+
+// replayRecovery returns the recorded recovery to make at the current state and token, if a [RecoveryLog]
+// is installed and replaying, and its next recovery matches.
+func (d *DefaultErrorStrategy) replayRecovery(recognizer Parser, kinds ...RecoveryKind) (RecoveryDecision, bool) {
+	if d.recoveryLog == nil {
+		return RecoveryDecision{}, false
+	}
+	return d.recoveryLog.take(recognizer.GetState(), recognizer.GetInputStream().Index(), kinds...)
+}
+
+// expectedTokensDisplay returns the expected tokens as they should appear in an error message, after
+// applying the filter installed with [DefaultErrorStrategy.SetExpectedTokensFilter], if any.
+func (d *DefaultErrorStrategy) expectedTokensDisplay(recognizer Parser, expected *IntervalSet) string {
+	if d.expectedFilter != nil {
+		expected = d.expectedFilter(recognizer, expected)
+	}
+	return expected.StringVerbose(recognizer, false)
+}
+
+// reportRecovery tells any [RecoveryListener] about a recovery event.
+func (d *DefaultErrorStrategy) reportRecovery(recognizer Parser, e RecognitionException, recoverySet *IntervalSet) {
+	if l, ok := recognizer.GetErrorListenerDispatch().(RecoveryListener); ok {
+		l.ReportRecovery(recognizer, e, recoverySet)
+	}
 }
 
 // Sync is the default implementation of error strategy synchronization.
@@ -187,7 +298,7 @@ func (d *DefaultErrorStrategy) Recover(recognizer Parser, _ RecognitionException
 // [Jim Idle]: https://github.com/jimidle
 func (d *DefaultErrorStrategy) Sync(recognizer Parser) {
 	// If already recovering, don't try to Sync
-	if d.InErrorRecoveryMode(recognizer) {
+	if d.InErrorRecoveryMode(recognizer) || isCancelled(recognizer) {
 		return
 	}
 
@@ -200,19 +311,37 @@ func (d *DefaultErrorStrategy) Sync(recognizer Parser) {
 		return
 	}
 
+	start := recognizer.GetInputStream().Index()
 	switch s.GetStateType() {
 	case ATNStateBlockStart, ATNStateStarBlockStart, ATNStatePlusBlockStart, ATNStateStarLoopEntry:
+		if replayed, ok := d.replayRecovery(recognizer, RecoveryDeletion, RecoveryFailed); ok {
+			if replayed.Kind == RecoveryDeletion {
+				d.deleteToken(recognizer)
+				return
+			}
+			recognizer.SetError(NewInputMisMatchException(recognizer))
+			return
+		}
 		// Report error and recover if possible
 		if d.SingleTokenDeletion(recognizer) != nil {
+			d.recordRecovery(recognizer, RecoveryDeletion, start)
 			return
 		}
+		d.recordRecovery(recognizer, RecoveryFailed, start)
 		recognizer.SetError(NewInputMisMatchException(recognizer))
 	case ATNStatePlusLoopBack, ATNStateStarLoopBack:
+		replayed, replaying := d.replayRecovery(recognizer, RecoveryResync)
 		d.ReportUnwantedToken(recognizer)
 		expecting := NewIntervalSet()
 		expecting.addSet(recognizer.GetExpectedTokens())
 		whatFollowsLoopIterationOrRule := expecting.addSet(d.GetErrorRecoverySet(recognizer))
+		d.reportRecovery(recognizer, nil, whatFollowsLoopIterationOrRule)
+		if replaying {
+			d.consumeTo(recognizer, replayed.Stop)
+			return
+		}
 		d.consumeUntil(recognizer, whatFollowsLoopIterationOrRule)
+		d.recordRecovery(recognizer, RecoveryResync, start)
 	default:
 		// do nothing if we can't identify the exact kind of ATN state
 	}
@@ -234,16 +363,19 @@ func (d *DefaultErrorStrategy) ReportNoViableAlternative(recognizer Parser, e *N
 		input = "<unknown input>"
 	}
 	msg := "no viable alternative at input " + d.escapeWSAndQuote(input)
-	recognizer.NotifyErrorListeners(msg, e.offendingToken, e)
+	notifyErrorListeners(recognizer, DiagnosticNoViableAlternative, msg, e.offendingToken, e)
 }
 
 // ReportInputMisMatch is called by [ReportError] when the exception is an [InputMisMatchException]
 //
 // See also: [ReportError]
 func (d *DefaultErrorStrategy) ReportInputMisMatch(recognizer Parser, e *InputMisMatchException) {
+	if d.suggester != nil {
+		e.suggestions = d.suggester.SuggestTokens(recognizer, e.offendingToken, e.expected)
+	}
 	msg := "mismatched input " + d.GetTokenErrorDisplay(e.offendingToken) +
-		" expecting " + e.getExpectedTokens().StringVerbose(recognizer.GetLiteralNames(), recognizer.GetSymbolicNames(), false)
-	recognizer.NotifyErrorListeners(msg, e.offendingToken, e)
+		" expecting " + d.expectedTokensDisplay(recognizer, e.getExpectedTokens())
+	notifyErrorListeners(recognizer, DiagnosticMismatchedInput, msg, e.offendingToken, e)
 }
 
 // ReportFailedPredicate is called by [ReportError] when the exception is a [FailedPredicateException].
@@ -252,7 +384,7 @@ func (d *DefaultErrorStrategy) ReportInputMisMatch(recognizer Parser, e *InputMi
 func (d *DefaultErrorStrategy) ReportFailedPredicate(recognizer Parser, e *FailedPredicateException) {
 	ruleName := recognizer.GetRuleNames()[recognizer.GetParserRuleContext().GetRuleIndex()]
 	msg := "rule " + ruleName + " " + e.message
-	recognizer.NotifyErrorListeners(msg, e.offendingToken, e)
+	notifyErrorListeners(recognizer, DiagnosticFailedPredicate, msg, e.offendingToken, e)
 }
 
 // ReportUnwantedToken is called to report a syntax error that requires the removal
@@ -277,9 +409,8 @@ func (d *DefaultErrorStrategy) ReportUnwantedToken(recognizer Parser) {
 	t := recognizer.GetCurrentToken()
 	tokenName := d.GetTokenErrorDisplay(t)
 	expecting := d.GetExpectedTokens(recognizer)
-	msg := "extraneous input " + tokenName + " expecting " +
-		expecting.StringVerbose(recognizer.GetLiteralNames(), recognizer.GetSymbolicNames(), false)
-	recognizer.NotifyErrorListeners(msg, t, nil)
+	msg := "extraneous input " + tokenName + " expecting " + d.expectedTokensDisplay(recognizer, expecting)
+	notifyErrorListeners(recognizer, DiagnosticExtraneousInput, msg, t, nil)
 }
 
 // ReportMissingToken is called to report a syntax error which requires the
@@ -301,9 +432,9 @@ func (d *DefaultErrorStrategy) ReportMissingToken(recognizer Parser) {
 	d.beginErrorCondition(recognizer)
 	t := recognizer.GetCurrentToken()
 	expecting := d.GetExpectedTokens(recognizer)
-	msg := "missing " + expecting.StringVerbose(recognizer.GetLiteralNames(), recognizer.GetSymbolicNames(), false) +
+	msg := "missing " + d.expectedTokensDisplay(recognizer, expecting) +
 		" at " + d.GetTokenErrorDisplay(t)
-	recognizer.NotifyErrorListeners(msg, t, nil)
+	notifyErrorListeners(recognizer, DiagnosticMissingToken, msg, t, nil)
 }
 
 // The RecoverInline default implementation attempts to recover from the mismatched input
@@ -349,9 +480,27 @@ func (d *DefaultErrorStrategy) ReportMissingToken(recognizer Parser) {
 // is in the set of tokens that can follow the ')' token reference
 // in rule atom. It can assume that you forgot the ')'.
 func (d *DefaultErrorStrategy) RecoverInline(recognizer Parser) Token {
+	if isCancelled(recognizer) {
+		return nil
+	}
+	start := recognizer.GetInputStream().Index()
+	if replayed, ok := d.replayRecovery(recognizer, RecoveryDeletion, RecoveryInsertion, RecoveryFailed); ok {
+		switch replayed.Kind {
+		case RecoveryDeletion:
+			MatchedSymbol := d.deleteToken(recognizer)
+			recognizer.Consume()
+			return MatchedSymbol
+		case RecoveryInsertion:
+			d.ReportMissingToken(recognizer)
+			return d.GetMissingSymbol(recognizer)
+		}
+		recognizer.SetError(NewInputMisMatchException(recognizer))
+		return nil
+	}
 	// SINGLE TOKEN DELETION
 	MatchedSymbol := d.SingleTokenDeletion(recognizer)
 	if MatchedSymbol != nil {
+		d.recordRecovery(recognizer, RecoveryDeletion, start)
 		// we have deleted the extra token.
 		// now, move past ttype token as if all were ok
 		recognizer.Consume()
@@ -359,8 +508,10 @@ func (d *DefaultErrorStrategy) RecoverInline(recognizer Parser) Token {
 	}
 	// SINGLE TOKEN INSERTION
 	if d.SingleTokenInsertion(recognizer) {
+		d.recordRecovery(recognizer, RecoveryInsertion, start)
 		return d.GetMissingSymbol(recognizer)
 	}
+	d.recordRecovery(recognizer, RecoveryFailed, start)
 	// even that didn't work must panic the exception
 	recognizer.SetError(NewInputMisMatchException(recognizer))
 	return nil
@@ -416,21 +567,23 @@ func (d *DefaultErrorStrategy) SingleTokenDeletion(recognizer Parser) Token {
 	NextTokenType := recognizer.GetTokenStream().LA(2)
 	expecting := d.GetExpectedTokens(recognizer)
 	if expecting.contains(NextTokenType) {
-		d.ReportUnwantedToken(recognizer)
-		// print("recoverFromMisMatchedToken deleting " \
-		// + str(recognizer.GetTokenStream().LT(1)) \
-		// + " since " + str(recognizer.GetTokenStream().LT(2)) \
-		// + " is what we want", file=sys.stderr)
-		recognizer.Consume() // simply delete extra token
-		// we want to return the token we're actually Matching
-		MatchedSymbol := recognizer.GetCurrentToken()
-		d.ReportMatch(recognizer) // we know current token is correct
-		return MatchedSymbol
+		return d.deleteToken(recognizer)
 	}
 
 	return nil
 }
 
+// deleteToken reports and consumes the extraneous current token, and returns the token after it, which is
+// the one the parser wants.
+func (d *DefaultErrorStrategy) deleteToken(recognizer Parser) Token {
+	d.ReportUnwantedToken(recognizer)
+	recognizer.Consume() // simply delete extra token
+	// we want to return the token we're actually Matching
+	MatchedSymbol := recognizer.GetCurrentToken()
+	d.ReportMatch(recognizer) // we know current token is correct
+	return MatchedSymbol
+}
+
 // GetMissingSymbol conjures up a missing token during error recovery.
 //
 // The recognizer attempts to recover from single missing
@@ -507,10 +660,7 @@ func (d *DefaultErrorStrategy) GetTokenErrorDisplay(t Token) string {
 }
 
 func (d *DefaultErrorStrategy) escapeWSAndQuote(s string) string {
-	s = strings.Replace(s, "\t", "\\t", -1)
-	s = strings.Replace(s, "\n", "\\n", -1)
-	s = strings.Replace(s, "\r", "\\r", -1)
-	return "'" + s + "'"
+	return EscapeText(s, EscapeErrorDisplay)
 }
 
 // GetErrorRecoverySet computes the error recovery set for the current rule. During
@@ -605,10 +755,24 @@ func (d *DefaultErrorStrategy) escapeWSAndQuote(s string) string {
 // Like Grosch I implement context-sensitive FOLLOW sets that are combined  at run-time upon error to avoid overhead
 // during parsing. Later, the runtime Sync was improved for loops/sub-rules see [Sync] docs
 //
+// This implementation calls [CalculateErrorRecoverySet], and then applies the filter installed with
+// [DefaultErrorStrategy.SetRecoverySetFilter], if any.
+//
 // [A note on error recovery in recursive descent parsers]: http://portal.acm.org/citation.cfm?id=947902.947905
 // [Algorithms + Data Structures = Programs]: https://t.ly/5QzgE
 // [Efficient and Comfortable Error Recovery in Recursive Descent Parsers]: ftp://www.cocolab.com/products/cocktail/doca4.ps/ell.ps.zip
 func (d *DefaultErrorStrategy) GetErrorRecoverySet(recognizer Parser) *IntervalSet {
+	recoverSet := CalculateErrorRecoverySet(recognizer)
+	if d.recoverySetFilter != nil {
+		recoverSet = d.recoverySetFilter(recognizer, recoverSet)
+	}
+	return recoverSet
+}
+
+// CalculateErrorRecoverySet computes the resynchronization set for the parser's current rule invocation
+// stack, which is the union of the sets of tokens that can follow each rule invocation on the stack.
+// See [DefaultErrorStrategy.GetErrorRecoverySet] for a full explanation of how it is used.
+func CalculateErrorRecoverySet(recognizer Parser) *IntervalSet {
 	atn := recognizer.GetInterpreter().atn
 	ctx := recognizer.GetParserRuleContext()
 	recoverSet := NewIntervalSet()
@@ -633,6 +797,13 @@ func (d *DefaultErrorStrategy) consumeUntil(recognizer Parser, set *IntervalSet)
 	}
 }
 
+// consumeTo consumes tokens until the token at index stop, as recorded in a [RecoveryLog], is the current one.
+func (d *DefaultErrorStrategy) consumeTo(recognizer Parser, stop int) {
+	for recognizer.GetInputStream().Index() < stop && recognizer.GetTokenStream().LA(1) != TokenEOF {
+		recognizer.Consume()
+	}
+}
+
 // The BailErrorStrategy implementation of ANTLRErrorStrategy responds to syntax errors
 // by immediately canceling the parse operation with a
 // [ParseCancellationException]. The implementation ensures that the
@@ -672,10 +843,9 @@ func NewBailErrorStrategy() *BailErrorStrategy {
 	return b
 }
 
-// Recover Instead of recovering from exception e, re-panic it wrapped
-// in a [ParseCancellationException] so it is not caught by the
-// rule func catches. Use Exception.GetCause() to get the
-// original [RecognitionException].
+// Recover Instead of recovering from exception e, cancels the parse, setting
+// the parser's error to a [ParseCancellationException] that is not cleared as
+// the rule funcs return. Its cause wraps [ErrSyntax].
 func (b *BailErrorStrategy) Recover(recognizer Parser, e RecognitionException) {
 	context := recognizer.GetParserRuleContext()
 	for context != nil {
@@ -686,9 +856,88 @@ func (b *BailErrorStrategy) Recover(recognizer Parser, e RecognitionException) {
 			context = nil
 		}
 	}
+	// Cancel the parse, if the parser supports it, so that the error is not cleared as each rule returns
+	if bp, ok := recognizer.(interface{ getBaseParser() *BaseParser }); ok {
+		bp.getBaseParser().cancel(newParseCancellationError(recognizer, e))
+		return
+	}
 	recognizer.SetError(NewParseCancellationException()) // TODO: we don't emit e properly
 }
 
+// ParseCancellationError describes the syntax error that a [BailErrorStrategy] bailed out on, so that callers
+// can handle it as an ordinary Go error. It is the cause of the [ParseCancellationException] that the parser's
+// error is set to, and is returned by [BaseParser.GetCancellationError]. It wraps [ErrSyntax].
+//
+// Use:
+//
+//	p.SetErrorHandler(antlr.NewBailErrorStrategy())
+//	tree := p.Start()
+//	if err := p.GetCancellationError(); err != nil {
+//	    return fmt.Errorf("%s: %w", name, err)
+//	}
+type ParseCancellationError struct {
+	// Exception is the error that the strategy bailed out on
+	Exception RecognitionException
+
+	// OffendingToken is the token at which the error was found
+	OffendingToken Token
+
+	// RuleStack holds the names of the rules being parsed when the error was found, innermost first, as
+	// returned by [Parser.GetRuleInvocationStack]
+	RuleStack []string
+
+	// Expected is the set of token types that the parser could have matched instead of OffendingToken
+	Expected *IntervalSet
+
+	// expected is Expected, rendered with the vocabulary of the parser
+	expected string
+}
+
+// newParseCancellationError describes the RecognitionException e, found by recognizer.
+func newParseCancellationError(recognizer Parser, e RecognitionException) *ParseCancellationError {
+	c := &ParseCancellationError{
+		Exception:      e,
+		OffendingToken: e.GetOffendingToken(),
+		RuleStack:      recognizer.GetRuleInvocationStack(nil),
+		Expected:       recognizer.GetExpectedTokens(),
+	}
+	if c.OffendingToken == nil {
+		c.OffendingToken = recognizer.GetCurrentToken()
+	}
+	c.expected = c.Expected.StringVerbose(recognizer, false)
+	return c
+}
+
+// Error describes the error, as in
+//
+//	syntax error at line 1:4 near "+", expecting ID, in rule item < s
+func (c *ParseCancellationError) Error() string {
+	msg := ErrSyntax.Error()
+	if t := c.OffendingToken; t != nil {
+		msg += " at line " + strconv.Itoa(t.GetLine()) + ":" + strconv.Itoa(t.GetColumn())
+		if t.GetTokenType() == TokenEOF {
+			msg += " near <EOF>"
+		} else {
+			msg += " near " + strconv.Quote(t.GetText())
+		}
+	}
+	if m := c.Exception.GetMessage(); m != "" {
+		msg += ": " + m
+	}
+	if c.expected != "" && c.expected != "{}" {
+		msg += ", expecting " + c.expected
+	}
+	if len(c.RuleStack) > 0 {
+		msg += ", in rule " + strings.Join(c.RuleStack, " < ")
+	}
+	return msg
+}
+
+// Unwrap returns [ErrSyntax], so that errors.Is(err, ErrSyntax) is true.
+func (c *ParseCancellationError) Unwrap() error {
+	return ErrSyntax
+}
+
 // RecoverInline makes sure we don't attempt to recover inline if the parser
 // successfully recovers, it won't panic an exception.
 func (b *BailErrorStrategy) RecoverInline(recognizer Parser) Token {
@@ -0,0 +1,82 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+// recoveryRecorder records the recovery sets reported to it, and the token the parser was at.
+type recoveryRecorder struct {
+	DefaultErrorListener
+	sets   []string
+	tokens []int
+}
+
+func (r *recoveryRecorder) ReportRecovery(recognizer Parser, _ RecognitionException, recoverySet *IntervalSet) {
+	r.sets = append(r.sets, recoverySet.String())
+	r.tokens = append(r.tokens, recognizer.GetCurrentToken().GetTokenIndex())
+}
+
+func TestRecoverySetFilter(t *testing.T) {
+	parse := func(filter RecoverySetFunc) (*recoveryRecorder, string) {
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a b + + + c")), TokenDefaultChannel))
+		p.RemoveErrorListeners()
+		recorder := new(recoveryRecorder)
+		p.AddErrorListener(recorder)
+		strategy := NewDefaultErrorStrategy()
+		strategy.SetRecoverySetFilter(filter)
+		p.SetErrorHandler(strategy)
+		return recorder, p.S().ToStringTree(nil, p)
+	}
+
+	// The item b + is followed by '+', and recovery skips to the ID or EOF that may follow the item
+	recorder, tree := parse(nil)
+	if len(recorder.sets) != 1 || recorder.sets[0] != "{<EOF>, 1}" || recorder.tokens[0] != 3 {
+		t.Errorf("recovered with %v at %v", recorder.sets, recorder.tokens)
+	}
+	if want := "(s (item a) (item b + + +) (item c) <EOF>)"; tree != want {
+		t.Errorf("tree %s, want %s", tree, want)
+	}
+
+	plus := func(_ Parser, set *IntervalSet) *IntervalSet {
+		set.AddOne(listPLUS)
+		return set
+	}
+	// Recovery now stops at the first '+', so the item b + does not take in the others
+	recorder, tree = parse(plus)
+	if len(recorder.sets) == 0 || recorder.sets[0] != "{<EOF>, 1..2}" || recorder.tokens[0] != 3 {
+		t.Errorf("recovered with %v at %v", recorder.sets, recorder.tokens)
+	}
+	if !strings.HasPrefix(tree, "(s (item a) (item b +)") {
+		t.Errorf("tree %s", tree)
+	}
+}
+
+// ruleEntryListener calls enter as the parser enters each rule.
+type ruleEntryListener struct {
+	BaseParseTreeListener
+	enter func(ctx ParserRuleContext)
+}
+
+func (r *ruleEntryListener) EnterEveryRule(ctx ParserRuleContext) {
+	r.enter(ctx)
+}
+
+func TestCalculateErrorRecoverySet(t *testing.T) {
+	p, _ := listParse("")
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream("a")), TokenDefaultChannel))
+	var set *IntervalSet
+	p.AddParseListener(&ruleEntryListener{enter: func(ctx ParserRuleContext) {
+		if ctx.GetRuleIndex() == listRuleItem {
+			set = CalculateErrorRecoverySet(p)
+		}
+	}})
+	p.S()
+	if set == nil || set.String() != "{<EOF>, 1}" {
+		t.Errorf("the recovery set within item is %v", set)
+	}
+}
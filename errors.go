@@ -4,6 +4,11 @@
 
 package antlr
 
+import (
+	"sort"
+	"strconv"
+)
+
 // The root of the ANTLR exception hierarchy. In general, ANTLR tracks just
 //  3 kinds of errors: prediction errors, failed predicate errors, and
 //  mismatched input errors. In each case, the parser knows where it is
@@ -131,6 +136,7 @@ type NoViableAltException struct {
 	offendingToken Token
 	ctx            ParserRuleContext
 	deadEndConfigs *ATNConfigSet
+	decision       int
 }
 
 // NewNoViableAltException creates an exception indicating that the parser could not decide which of two or more paths
@@ -172,15 +178,74 @@ func NewNoViableAltException(recognizer Parser, input TokenStream, startToken To
 	n.startToken = startToken
 	n.offendingToken = offendingToken
 
+	// The decision is not known here, the ATN simulator fills it in
+	n.decision = -1
+
 	return n
 }
 
+// GetDecision returns the number of the decision in the [ATN] for which no viable alternative
+// was found, or -1 if it is not known.
+func (n *NoViableAltException) GetDecision() int {
+	return n.decision
+}
+
+// GetStartToken returns the token at which prediction started for the decision.
+func (n *NoViableAltException) GetStartToken() Token {
+	return n.startToken
+}
+
+// GetOffendingToken returns the token at which prediction found that no alternative was viable.
+func (n *NoViableAltException) GetOffendingToken() Token {
+	return n.offendingToken
+}
+
+// GetOffendingInterval returns the token index interval from the start token to the offending token,
+// inclusive, which is all the input that was examined by prediction before it failed.
+func (n *NoViableAltException) GetOffendingInterval() Interval {
+	return NewInterval(n.startToken.GetTokenIndex(), n.offendingToken.GetTokenIndex())
+}
+
+// GetDeadEndConfigs returns the [ATN] configurations that were being considered when prediction
+// could not match the offending token.
+func (n *NoViableAltException) GetDeadEndConfigs() *ATNConfigSet {
+	return n.deadEndConfigs
+}
+
+// GetAlternatives returns the alternatives, in ascending order, that were still being considered
+// when prediction could not match the offending token.
+func (n *NoViableAltException) GetAlternatives() []int {
+	if n.deadEndConfigs == nil {
+		return nil
+	}
+	seen := make(map[int]bool)
+	var alts []int
+	for _, c := range n.deadEndConfigs.configs {
+		if !seen[c.GetAlt()] {
+			seen[c.GetAlt()] = true
+			alts = append(alts, c.GetAlt())
+		}
+	}
+	sort.Ints(alts)
+	return alts
+}
+
 type InputMisMatchException struct {
 	*BaseRecognitionException
+
+	ruleIndex int
+	alt       int
+	expected  *IntervalSet
+
+	// suggestions are the tokens the offending token may have been meant to be, see GetSuggestions
+	suggestions []TokenSuggestion
 }
 
 // NewInputMisMatchException creates an exception that signifies any kind of mismatched input exceptions such as
 // when the current input does not Match the expected token.
+//
+// The rule index, the alternative being matched and the set of expected tokens are captured when the
+// exception is created, so that they are still available after the parser state has moved on.
 func NewInputMisMatchException(recognizer Parser) *InputMisMatchException {
 
 	i := new(InputMisMatchException)
@@ -188,10 +253,42 @@ func NewInputMisMatchException(recognizer Parser) *InputMisMatchException {
 
 	i.offendingToken = recognizer.GetCurrentToken()
 
+	i.ruleIndex = -1
+	i.alt = ATNInvalidAltNumber
+	if ctx := recognizer.GetParserRuleContext(); ctx != nil {
+		i.ruleIndex = ctx.GetRuleIndex()
+		i.alt = ctx.GetAltNumber()
+	}
+	i.expected = i.BaseRecognitionException.getExpectedTokens()
+
 	return i
 
 }
 
+// GetRuleIndex returns the index of the rule that was being matched when the exception was
+// created, or -1 if it is not known.
+func (i *InputMisMatchException) GetRuleIndex() int {
+	return i.ruleIndex
+}
+
+// GetAltNumber returns the outer alternative of the rule that was being matched when the exception
+// was created, or [ATNInvalidAltNumber] if the context does not record alternative numbers.
+func (i *InputMisMatchException) GetAltNumber() int {
+	return i.alt
+}
+
+// GetExpectedTokens returns the set of tokens that the parser expected when the exception was
+// created, or nil if they could not be computed.
+func (i *InputMisMatchException) GetExpectedTokens() *IntervalSet {
+	return i.expected
+}
+
+// getExpectedTokens returns the snapshot of the expected tokens rather than computing them
+// again from the parser state.
+func (i *InputMisMatchException) getExpectedTokens() *IntervalSet {
+	return i.expected
+}
+
 // FailedPredicateException indicates that a semantic predicate failed during validation. Validation of predicates
 // occurs when normally parsing the alternative just like Matching a token.
 // Disambiguating predicate evaluation occurs when we test a predicate during
@@ -204,13 +301,15 @@ type FailedPredicateException struct {
 	predicate      string
 }
 
+// NewFailedPredicateException creates an exception for the predicate that failed at the parser's current
+// state. If the source text of the predicate has been registered with [BaseRecognizer.SetPredicateSource], it
+// is used in place of the predicate string given here.
+//
 //goland:noinspection GoUnusedExportedFunction
 func NewFailedPredicateException(recognizer Parser, predicate string, message string) *FailedPredicateException {
 
 	f := new(FailedPredicateException)
 
-	f.BaseRecognitionException = NewBaseRecognitionException(f.formatMessage(predicate, message), recognizer, recognizer.GetInputStream(), recognizer.GetParserRuleContext())
-
 	s := recognizer.GetInterpreter().atn.states[recognizer.GetState()]
 	trans := s.GetTransitions()[0]
 	if trans2, ok := trans.(*PredicateTransition); ok {
@@ -220,6 +319,17 @@ func NewFailedPredicateException(recognizer Parser, predicate string, message st
 		f.ruleIndex = 0
 		f.predicateIndex = 0
 	}
+
+	if sources, ok := recognizer.(interface {
+		GetPredicateSource(ruleIndex, predIndex int) (string, bool)
+	}); ok {
+		if source, ok := sources.GetPredicateSource(f.ruleIndex, f.predicateIndex); ok {
+			predicate = source
+		}
+	}
+
+	f.BaseRecognitionException = NewBaseRecognitionException(f.formatMessage(predicate, message), recognizer, recognizer.GetInputStream(), recognizer.GetParserRuleContext())
+
 	f.predicate = predicate
 	f.offendingToken = recognizer.GetCurrentToken()
 
@@ -230,26 +340,108 @@ func (f *FailedPredicateException) formatMessage(predicate, message string) stri
 	if message != "" {
 		return message
 	}
+	if predicate == "" {
+		return "predicate failed"
+	}
 
 	return "failed predicate: {" + predicate + "}?"
 }
 
+// GetRuleIndex returns the index of the rule containing the predicate that failed.
+func (f *FailedPredicateException) GetRuleIndex() int {
+	return f.ruleIndex
+}
+
+// GetPredicateIndex returns the index of the predicate that failed within its rule.
+func (f *FailedPredicateException) GetPredicateIndex() int {
+	return f.predicateIndex
+}
+
+// GetPredicate returns the source text of the predicate that failed.
+func (f *FailedPredicateException) GetPredicate() string {
+	return f.predicate
+}
+
+// LoopDetectedException indicates that a lexer or parser ATN simulator, following epsilon transitions without
+// consuming any input, reached an ATN state it had already reached at the same input index, with the same
+// rule invocation stack or with that stack underneath the rules entered since, which means that it was caught
+// in a loop. Well-formed ATNs generated by the ANTLR tool do not loop, so this is caused by a malformed or hand
+// built ATN, or by a bug. The simulator abandons the loop rather than hanging or exhausting the stack.
+//
+// A lexer reports the exception to its error listeners, and skips a character, as for any other token
+// recognition error. A parser cancels the parse, so the parser's error is a [ParseCancellationException]
+// whose cause is the LoopDetectedException.
+type LoopDetectedException struct {
+	*BaseRecognitionException
+
+	stateNumber int
+	inputIndex  int
+}
+
+// NewLoopDetectedException creates a [LoopDetectedException] for a loop at the given ATN state, at the
+// current index of the input.
+func NewLoopDetectedException(recognizer Recognizer, input IntStream, stateNumber int) *LoopDetectedException {
+	l := new(LoopDetectedException)
+	l.BaseRecognitionException = NewBaseRecognitionException("", recognizer, input, nil)
+	l.stateNumber = stateNumber
+	l.inputIndex = -1
+	if input != nil {
+		l.inputIndex = input.Index()
+	}
+	l.message = "no progress at input index " + strconv.Itoa(l.inputIndex) + ": ATN state " +
+		strconv.Itoa(stateNumber) + " is reached again without consuming input"
+	return l
+}
+
+// GetStateNumber returns the ATN state at which the loop was detected.
+func (l *LoopDetectedException) GetStateNumber() int {
+	return l.stateNumber
+}
+
+// GetInputIndex returns the index of the input at which the loop was detected, or -1 if it is not known.
+func (l *LoopDetectedException) GetInputIndex() int {
+	return l.inputIndex
+}
+
+// Error returns the message, so that a LoopDetectedException can be used as an error.
+func (l *LoopDetectedException) Error() string {
+	return l.message
+}
+
+// ParseCancellationException is set as the parser's error when the parse is cancelled rather than
+// recovered from, such as by the [BailErrorStrategy], or when a [ProgressFunc] aborts the parse.
 type ParseCancellationException struct {
+	cause error
 }
 
 func (p ParseCancellationException) GetOffendingToken() Token {
-	//TODO implement me
-	panic("implement me")
+	return nil
 }
 
 func (p ParseCancellationException) GetMessage() string {
-	//TODO implement me
-	panic("implement me")
+	if p.cause != nil {
+		return "parse cancelled: " + p.cause.Error()
+	}
+	return "parse cancelled"
 }
 
 func (p ParseCancellationException) GetInputStream() IntStream {
-	//TODO implement me
-	panic("implement me")
+	return nil
+}
+
+// GetCause returns the reason that the parse was cancelled, which may be nil.
+func (p ParseCancellationException) GetCause() error {
+	return p.cause
+}
+
+// Error returns the message for the exception, so that it can be used as an error.
+func (p ParseCancellationException) Error() string {
+	return p.GetMessage()
+}
+
+// Unwrap returns the cause of the cancellation, for use with errors.Is and errors.As.
+func (p ParseCancellationException) Unwrap() error {
+	return p.cause
 }
 
 func NewParseCancellationException() *ParseCancellationException {
@@ -257,3 +449,9 @@ func NewParseCancellationException() *ParseCancellationException {
 	//	Error.captureStackTrace(this, ParseCancellationException)
 	return new(ParseCancellationException)
 }
+
+// newParseCancellationExceptionWithCause creates a [ParseCancellationException] that records why
+// the parse was cancelled.
+func newParseCancellationExceptionWithCause(cause error) *ParseCancellationException {
+	return &ParseCancellationException{cause: cause}
+}
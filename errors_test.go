@@ -0,0 +1,125 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+// recordingErrorListener records the exceptions of the syntax errors reported to it.
+type recordingErrorListener struct {
+	DefaultErrorListener
+	exceptions []RecognitionException
+}
+
+func (r *recordingErrorListener) SyntaxError(_ Recognizer, _ interface{}, _, _ int, _ string, e RecognitionException) {
+	r.exceptions = append(r.exceptions, e)
+}
+
+// listParseRule parses input with a new parser, with rule, and returns the parser and the exceptions of the
+// syntax errors it reported.
+func listParseRule(input string, rule func(p *listParser)) (*listParser, []RecognitionException) {
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+	listener := new(recordingErrorListener)
+	p.RemoveErrorListeners()
+	p.AddErrorListener(listener)
+	rule(p)
+	return p, listener.exceptions
+}
+
+// noSyncErrorStrategy is the default strategy without the resynchronization before each decision, so that
+// the decision sees the unexpected token.
+type noSyncErrorStrategy struct {
+	*DefaultErrorStrategy
+}
+
+func (noSyncErrorStrategy) Sync(Parser) {}
+
+func TestNoViableAltExceptionDetails(t *testing.T) {
+	// The second item starts with '+', which the strategy does not delete before the item's decision
+	_, exceptions := listParseRule("b+a + c", func(p *listParser) {
+		p.SetErrorHandler(noSyncErrorStrategy{NewDefaultErrorStrategy()})
+		p.Item()
+		p.Item()
+	})
+	if len(exceptions) != 1 {
+		t.Fatalf("%d syntax errors, want 1", len(exceptions))
+	}
+	e, ok := exceptions[0].(*NoViableAltException)
+	if !ok {
+		t.Fatalf("the syntax error is %T", exceptions[0])
+	}
+	if e.GetDecision() != 1 {
+		t.Errorf("decision %d, want 1", e.GetDecision())
+	}
+	if e.GetStartToken().GetText() != "+" || e.GetOffendingToken().GetText() != "+" {
+		t.Errorf("no viable alternative from %s at %s", e.GetStartToken(), e.GetOffendingToken())
+	}
+	if got := e.GetOffendingInterval(); got.Start != 3 || got.Stop != 3 {
+		t.Errorf("offending interval %s, want 3..3", got)
+	}
+	if alts := e.GetAlternatives(); len(alts) != 2 || alts[0] != 1 || alts[1] != 2 {
+		t.Errorf("alternatives %v, want [1 2]", alts)
+	}
+	if e.GetDeadEndConfigs() == nil || len(e.GetDeadEndConfigs().configs) == 0 {
+		t.Error("no dead end configurations")
+	}
+}
+
+func TestInputMisMatchExceptionDetails(t *testing.T) {
+	// The item b + is followed by '+' rather than ID, and the error cannot be repaired by deleting the
+	// first '+' or inserting an ID before it
+	_, exceptions := listParseRule("a b + + +", func(p *listParser) { p.S() })
+	if len(exceptions) != 1 {
+		t.Fatalf("%d syntax errors, want 1", len(exceptions))
+	}
+	e, ok := exceptions[0].(*InputMisMatchException)
+	if !ok {
+		t.Fatalf("the syntax error is %T", exceptions[0])
+	}
+	if e.GetRuleIndex() != listRuleItem || e.GetAltNumber() != 2 {
+		t.Errorf("rule %d, alternative %d", e.GetRuleIndex(), e.GetAltNumber())
+	}
+	if e.GetOffendingToken().GetTokenIndex() != 3 {
+		t.Errorf("offending token %s", e.GetOffendingToken())
+	}
+	// The expected tokens are those of the rule that failed, though the parser has since returned from it
+	if got := e.GetExpectedTokens().String(); got != "1" {
+		t.Errorf("expected tokens %s, want ID", got)
+	}
+}
+
+func TestFailedPredicateExceptionSource(t *testing.T) {
+	atn := NewATN(ATNTypeParser, 2)
+	state, target := NewBasicState(), NewBasicState()
+	atn.addState(state)
+	atn.addState(target)
+	state.AddTransition(NewPredicateTransition(target, 1, 2, false), -1)
+
+	p := NewBaseParser(NewCommonTokenStream(newListLexer(NewInputStream("a")), TokenDefaultChannel))
+	p.Interpreter = NewParserATNSimulator(p, atn, nil, nil)
+	p.SetState(state.GetStateNumber())
+
+	e := NewFailedPredicateException(p, "x", "")
+	if e.GetRuleIndex() != 1 || e.GetPredicateIndex() != 2 || e.GetPredicate() != "x" {
+		t.Errorf("predicate %d of rule %d is %q", e.GetPredicateIndex(), e.GetRuleIndex(), e.GetPredicate())
+	}
+	if got := e.GetMessage(); got != "failed predicate: {x}?" {
+		t.Errorf("message %q", got)
+	}
+
+	p.SetPredicateSource(1, 2, "p.isType(p.GetTokenStream().LT(1))")
+	if source, ok := p.GetPredicateSource(1, 2); !ok || source != "p.isType(p.GetTokenStream().LT(1))" {
+		t.Errorf("GetPredicateSource() = %q, %v", source, ok)
+	}
+	if _, ok := p.GetPredicateSource(1, 0); ok {
+		t.Error("a predicate without source has some")
+	}
+	e = NewFailedPredicateException(p, "x", "")
+	if got := e.GetMessage(); got != "failed predicate: {p.isType(p.GetTokenStream().LT(1))}?" || e.GetPredicate() != "p.isType(p.GetTokenStream().LT(1))" {
+		t.Errorf("message %q", got)
+	}
+	if got := NewFailedPredicateException(p, "x", "not a type").GetMessage(); got != "not a type" {
+		t.Errorf("message %q, want the given message", got)
+	}
+}
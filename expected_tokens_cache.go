@@ -0,0 +1,120 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "slices"
+
+// SetExpectedTokensCacheSize enables a cache of the sets of expected tokens computed for the parsers that use
+// this ATN, holding up to size sets, or disables it if size is zero or less. The cache is disabled by default.
+//
+// The expected tokens at a point of the parse depend on the ATN state and on the chain of rule invocations
+// that leads to it, and computing them means walking that chain and combining the tokens that can follow
+// each invocation. Editors and other interactive tools ask for the expected tokens at the same positions
+// over and over again, such as after every keystroke to offer completions, and with the cache each set is
+// computed once. The cache is keyed by the state number and a hash of the invoking states of the context, so
+// that the rule contexts themselves are not retained, and once it is full, the oldest set is evicted to make
+// room for each new one.
+//
+// Sets returned from the cache are read-only, and are shared by all the parsers that use the ATN. Enable the
+// cache before parsers start to use the ATN, as changing the size discards the cached sets and is not safe
+// while they are running.
+//
+// Use:
+//
+//	p.GetATN().SetExpectedTokensCacheSize(1024)
+func (a *ATN) SetExpectedTokensCacheSize(size int) {
+	if size <= 0 {
+		a.expectedCache = nil
+		return
+	}
+	a.expectedCache = &expectedTokensCache{
+		entries: make(map[expectedTokensKey]*expectedTokensEntry, size),
+		order:   make([]expectedTokensKey, 0, size),
+		size:    size,
+	}
+}
+
+// expectedTokensCacheSize returns the size of the cache of expected tokens, or 0 if there is none.
+func (a *ATN) expectedTokensCacheSize() int {
+	if a.expectedCache == nil {
+		return 0
+	}
+	return a.expectedCache.size
+}
+
+// expectedTokensKey identifies a cached set of expected tokens by state number and a hash of the chain of
+// invoking states.
+type expectedTokensKey struct {
+	stateNumber int
+	hash        int
+}
+
+// expectedTokensEntry is a cached set of expected tokens, with the chain of invoking states it was computed
+// for, so that hash collisions can be detected.
+type expectedTokensEntry struct {
+	invokingStates []int
+	expected       *IntervalSet
+}
+
+// expectedTokensCache is a bounded cache of the results of ATN.getExpectedTokens. It is safe for concurrent
+// use.
+type expectedTokensCache struct {
+	mu      Mutex
+	entries map[expectedTokensKey]*expectedTokensEntry
+
+	// order holds the keys of the entries in the order they were added, as a ring once it reaches size;
+	// next is the index of the oldest entry, which is evicted to make room for the next one
+	order []expectedTokensKey
+	next  int
+	size  int
+}
+
+// get returns the expected tokens at stateNumber in ctx, computing and caching them if they are not cached.
+func (c *expectedTokensCache) get(a *ATN, stateNumber int, ctx RuleContext) *IntervalSet {
+	// If the end of the rule cannot be reached, the context is not used, and the set is already cached with
+	// the state.
+	if !a.NextTokensNoContext(a.states[stateNumber]).contains(TokenEpsilon) {
+		return a.computeExpectedTokens(stateNumber, ctx)
+	}
+
+	invokingStates := make([]int, 0, 16)
+	h := murmurInit(stateNumber)
+	for c := ctx; c != nil && c.GetInvokingState() >= 0; c = parentContext(c) {
+		invokingStates = append(invokingStates, c.GetInvokingState())
+		h = murmurUpdate(h, c.GetInvokingState())
+	}
+	key := expectedTokensKey{stateNumber: stateNumber, hash: murmurFinish(h, len(invokingStates))}
+
+	c.mu.Lock()
+	e, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && slices.Equal(e.invokingStates, invokingStates) {
+		return e.expected
+	}
+
+	expected := a.computeExpectedTokens(stateNumber, ctx)
+	expected.readOnly = true
+	c.put(key, &expectedTokensEntry{invokingStates: invokingStates, expected: expected})
+	return expected
+}
+
+// put adds an entry to the cache, replacing any entry with the same key, and evicting the oldest entry if
+// the cache is full.
+func (c *expectedTokensCache) put(key expectedTokensKey, e *expectedTokensEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		c.entries[key] = e
+		return
+	}
+	if len(c.order) < c.size {
+		c.order = append(c.order, key)
+	} else {
+		delete(c.entries, c.order[c.next])
+		c.order[c.next] = key
+		c.next = (c.next + 1) % c.size
+	}
+	c.entries[key] = e
+}
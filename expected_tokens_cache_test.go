@@ -0,0 +1,53 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestExpectedTokensCache(t *testing.T) {
+	atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+	atn.SetExpectedTokensCacheSize(2)
+	if atn.expectedTokensCacheSize() != 2 {
+		t.Fatalf("cache size %d", atn.expectedTokensCacheSize())
+	}
+
+	// The end of an item, invoked from s, or from an item invoked from s
+	s := NewBaseParserRuleContext(nil, -1)
+	item := NewBaseParserRuleContext(s, 4)
+	nested := NewBaseParserRuleContext(item, 4)
+	const end = 17
+
+	first := atn.getExpectedTokens(end, item)
+	if first.String() != "{<EOF>, 1}" || !first.readOnly {
+		t.Errorf("expected tokens %s, read-only %v", first, first.readOnly)
+	}
+	if again := atn.getExpectedTokens(end, NewBaseParserRuleContext(s, 4)); again != first {
+		t.Error("the expected tokens of the same invoking states were computed again")
+	}
+	if other := atn.getExpectedTokens(end, nil); other == first || other.String() != "<EOF>" {
+		t.Errorf("the expected tokens without a context are %s", other)
+	}
+
+	// a third set evicts the first
+	if got := atn.getExpectedTokens(end, nested); got.String() != "{<EOF>, 1}" {
+		t.Errorf("expected tokens %s in a nested item", got)
+	}
+	if again := atn.getExpectedTokens(end, item); again == first || again.String() != first.String() {
+		t.Error("the oldest set was not evicted")
+	}
+
+	// the expected tokens at a state from which the end of the rule cannot be reached are those cached with
+	// the state, whatever the context
+	plus := atn.getExpectedTokens(15, item)
+	if plus.String() != "2" || plus != atn.getExpectedTokens(15, nested) ||
+		plus != atn.NextTokensNoContext(atn.states[15]) {
+		t.Errorf("expected tokens %s before '+'", plus)
+	}
+
+	atn.SetExpectedTokensCacheSize(0)
+	if atn.expectedTokensCacheSize() != 0 || atn.getExpectedTokens(end, item) == atn.getExpectedTokens(end, item) {
+		t.Error("the cache was not disabled")
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"sort"
+	"strings"
+)
+
+// The kinds of [FoldingRange], as named by the Language Server Protocol.
+const (
+	FoldingRangeComment = "comment"
+	FoldingRangeRegion  = "region"
+)
+
+// FoldingRange is a range of lines that an editor may fold, as found by [FoldingRanges]. Lines count from
+// 0, as in the Language Server Protocol.
+type FoldingRange struct {
+	StartLine, EndLine int
+
+	// Kind is FoldingRangeComment for a block of comments and FoldingRangeRegion for a rule context
+	Kind string
+
+	// Context is the rule context folded, or nil for a block of comments
+	Context ParserRuleContext
+}
+
+// FoldingRangeOptions configures [FoldingRanges]. The zero value folds every rule context and every block of
+// comments that spans two lines or more.
+type FoldingRangeOptions struct {
+	// Rules, if not empty, restricts folding to rule contexts of these rules
+	Rules []int
+
+	// CommentTypes, if not empty, restricts comments to tokens of these types. Otherwise every token off the
+	// default channel whose text is not all whitespace is a comment.
+	CommentTypes []int
+
+	// MinLines is the number of lines that a range must span to be folded. Values below 2 are taken as 2,
+	// since a range within one line cannot be folded.
+	MinLines int
+}
+
+// FoldingRanges computes the ranges of lines that an editor may fold in the text of a parse tree: the rule
+// contexts that span enough lines, and the blocks of comments, for the foldingRange request of a language
+// server. A block of comments is a sequence of comment tokens separated only by whitespace, and so may be a
+// single comment that spans several lines. The comments are found in tokens, which should be the stream
+// that the tree was parsed from, and which is filled first; it may be nil to fold only rule contexts.
+//
+// Editors fold at most one range starting on each line, so where several ranges start on the same line,
+// only the outermost is returned. The ranges are ordered by their start line.
+//
+// Use:
+//
+//	ranges := antlr.FoldingRanges(tree, stream, antlr.FoldingRangeOptions{
+//	    Rules: []int{parser.MyParserRULE_block, parser.MyParserRULE_classBody},
+//	})
+func FoldingRanges(tree ParseTree, tokens *CommonTokenStream, opts FoldingRangeOptions) []FoldingRange {
+	minLines := opts.MinLines
+	if minLines < 2 {
+		minLines = 2
+	}
+	byLine := make(map[int]FoldingRange)
+	add := func(r FoldingRange) {
+		if r.EndLine-r.StartLine+1 < minLines {
+			return
+		}
+		if existing, ok := byLine[r.StartLine]; !ok || r.EndLine > existing.EndLine {
+			byLine[r.StartLine] = r
+		}
+	}
+
+	var walk func(t Tree)
+	walk = func(t Tree) {
+		ctx, ok := t.(ParserRuleContext)
+		if !ok {
+			return
+		}
+		if dumpSelected(opts.Rules, ctx.GetRuleIndex()) && ctx.GetStart() != nil && ctx.GetStart().GetTokenType() != TokenEOF {
+			r := ContextTextRange(ctx)
+			add(FoldingRange{StartLine: r.StartLine, EndLine: r.EndLine, Kind: FoldingRangeRegion, Context: ctx})
+		}
+		for _, child := range ctx.GetChildren() {
+			walk(child)
+		}
+	}
+	if tree != nil {
+		walk(tree)
+	}
+
+	if tokens != nil {
+		tokens.Fill()
+		var block *FoldingRange
+		for _, t := range tokens.GetAllTokens() {
+			switch {
+			case t.GetChannel() != TokenDefaultChannel && foldingIsComment(t, opts.CommentTypes):
+				r := TokenTextRange(t)
+				if block == nil {
+					block = &FoldingRange{StartLine: r.StartLine, Kind: FoldingRangeComment}
+				}
+				block.EndLine = r.EndLine
+			case t.GetChannel() != TokenDefaultChannel && strings.TrimSpace(t.GetText()) == "":
+				// Whitespace between comments continues the block
+			default:
+				if block != nil {
+					add(*block)
+					block = nil
+				}
+			}
+		}
+		if block != nil {
+			add(*block)
+		}
+	}
+
+	ranges := make([]FoldingRange, 0, len(byLine))
+	for _, r := range byLine {
+		ranges = append(ranges, r)
+	}
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].StartLine < ranges[j].StartLine
+	})
+	return ranges
+}
+
+// foldingIsComment returns true if t, which is off the default channel, is a comment.
+func foldingIsComment(t Token, commentTypes []int) bool {
+	if len(commentTypes) > 0 {
+		return dumpSelected(commentTypes, t.GetTokenType())
+	}
+	return strings.TrimSpace(t.GetText()) != ""
+}
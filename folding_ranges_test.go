@@ -0,0 +1,84 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFoldingRanges(t *testing.T) {
+	const blockComment, lineComment = 10, 11
+	token := func(tokenType, channel, line, column int, text string) Token {
+		t := newTestToken(tokenType, 0, len(text)-1, line, column)
+		t.SetText(text)
+		t.channel = channel
+		return t
+	}
+	// /* c
+	//  c */
+	// // d
+	// a
+	// b +
+	// c
+	tokens := []Token{
+		token(blockComment, TokenHiddenChannel, 1, 0, "/* c\n c */"),
+		token(listWS, TokenHiddenChannel, 2, 4, "\n"),
+		token(lineComment, TokenHiddenChannel, 3, 0, "// d"),
+		token(listWS, TokenHiddenChannel, 3, 4, "\n"),
+		token(listID, TokenDefaultChannel, 4, 0, "a"),
+		token(listWS, TokenHiddenChannel, 4, 1, "\n"),
+		token(listID, TokenDefaultChannel, 5, 0, "b"),
+		token(listPLUS, TokenDefaultChannel, 5, 2, "+"),
+		token(listWS, TokenHiddenChannel, 5, 3, "\n"),
+		token(listID, TokenDefaultChannel, 6, 0, "c"),
+		token(TokenEOF, TokenDefaultChannel, 6, 1, "<EOF>"),
+	}
+	newStream := func() *CommonTokenStream {
+		stream := NewCommonTokenStream(nil, TokenDefaultChannel)
+		stream.SetTokenSource(&sliceTokenSource{tokens: tokens})
+		return stream
+	}
+	p := newListParser(newStream())
+	tree := p.S()
+
+	folds := func(ranges []FoldingRange) string {
+		var sb strings.Builder
+		for _, r := range ranges {
+			_, _ = fmt.Fprintf(&sb, "%d-%d %s", r.StartLine, r.EndLine, r.Kind)
+			if r.Context != nil {
+				sb.WriteString(" " + p.GetRuleNames()[r.Context.GetRuleIndex()])
+			}
+			sb.WriteString("; ")
+		}
+		return sb.String()
+	}
+	tests := []struct {
+		stream *CommonTokenStream
+		opts   FoldingRangeOptions
+		want   string
+	}{
+		// the item a is on one line
+		{newStream(), FoldingRangeOptions{}, "0-2 comment; 3-5 region s; 4-5 region item; "},
+		{nil, FoldingRangeOptions{}, "3-5 region s; 4-5 region item; "},
+		{newStream(), FoldingRangeOptions{MinLines: 3}, "0-2 comment; 3-5 region s; "},
+		{newStream(), FoldingRangeOptions{Rules: []int{listRuleItem}}, "0-2 comment; 4-5 region item; "},
+		// the line comment is not a comment, and ends the block
+		{newStream(), FoldingRangeOptions{CommentTypes: []int{blockComment}},
+			"0-1 comment; 3-5 region s; 4-5 region item; "},
+	}
+	for _, test := range tests {
+		if got := folds(FoldingRanges(tree, test.stream, test.opts)); got != test.want {
+			t.Errorf("FoldingRanges(%+v) = %s, want %s", test.opts, got, test.want)
+		}
+	}
+
+	// of the ranges that start on the same line, only the outermost is folded
+	p = newListParser(listStream("a b +\nc"))
+	if got := folds(FoldingRanges(p.S(), nil, FoldingRangeOptions{})); got != "0-1 region s; " {
+		t.Errorf("FoldingRanges() = %s", got)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"unicode/utf8"
+)
+
+// ErrParseNotProgressing is the error, possibly wrapped, returned by [FuzzParse] when the lexer or parser
+// is doing far more work than the input can justify, which indicates that it is looping.
+var ErrParseNotProgressing = errors.New("parse is not making progress")
+
+const (
+	// fuzzConfigsPerToken bounds the number of ATN configurations that FuzzParse allows adaptive
+	// prediction to create for each token of the input.
+	fuzzConfigsPerToken = 100000
+
+	// fuzzErrorsPerToken bounds the number of syntax errors that FuzzParse allows for each token of
+	// the input. Error recovery reports at most one error before it consumes a token, so more errors
+	// than tokens means that recovery is looping.
+	fuzzErrorsPerToken = 2
+)
+
+// FuzzParse lexes and parses data with a lexer and parser created by the given constructors, starting at
+// the rule invoked by start, and guarantees that no panic escapes: a panic anywhere in the lexer, the parser,
+// or the runtime is converted into an error that includes the stack at the point of the panic. It is intended
+// as the body of a fuzz test for a generated grammar, using Go's native fuzzing, go-fuzz or oss-fuzz.
+//
+// Syntax errors are expected for fuzzed input, so they are not reported, and FuzzParse returns nil for input
+// that fails to parse. It returns an error only when something is wrong with the grammar or the runtime:
+//
+//   - a panic, as described above
+//   - a lexer that violates the invariants checked by [ValidateTokenSource], including failing to reach EOF
+//     within one token per character of input, which indicates that it is looping
+//   - a parser that does not make progress, because adaptive prediction creates far more [ATN]
+//     configurations, or error recovery reports far more errors, than the number of tokens can justify
+//
+// In the last case the parse is aborted and the returned error wraps [ErrParseNotProgressing]. These checks
+// count work rather than measure time, so the outcome for a given input does not depend on the machine.
+//
+// Use:
+//
+//	func FuzzMyGrammar(f *testing.F) {
+//	    f.Fuzz(func(t *testing.T, data []byte) {
+//	        err := antlr.FuzzParse(
+//	            func(input antlr.CharStream) antlr.Lexer { return parser.NewMyLexer(input) },
+//	            func(input antlr.TokenStream) antlr.Parser { return parser.NewMyParser(input) },
+//	            func(p antlr.Parser) antlr.ParseTree { return p.(*parser.MyParser).Start() },
+//	            data)
+//	        if err != nil {
+//	            t.Fatal(err)
+//	        }
+//	    })
+//	}
+func FuzzParse(lexerCtor func(CharStream) Lexer, parserCtor func(TokenStream) Parser, start func(Parser) ParseTree, data []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic during parse: %v\n%s", r, debug.Stack())
+		}
+	}()
+
+	text := string(data)
+
+	// Every token but EOF consumes at least one character, so a lexer that returns more tokens than
+	// there are characters is looping.
+	tokens := utf8.RuneCountInString(text) + 1
+	lexer := lexerCtor(NewInputStream(text))
+	lexer.RemoveErrorListeners()
+	if err := ValidateTokenSource(lexer, tokens); err != nil {
+		return fmt.Errorf("lexer is inconsistent: %w", err)
+	}
+
+	lexer = lexerCtor(NewInputStream(text))
+	lexer.RemoveErrorListeners()
+	stream := NewCommonTokenStream(lexer, TokenDefaultChannel)
+	p := parserCtor(stream)
+	p.RemoveErrorListeners()
+
+	guard := &fuzzGuard{maxErrors: fuzzErrorsPerToken * tokens}
+	if bp, ok := p.(interface {
+		SetProgressCallback(int, ProgressFunc)
+		cancel(error)
+	}); ok {
+		guard.cancel = bp.cancel
+		maxConfigs := fuzzConfigsPerToken * tokens
+		bp.SetProgressCallback(1000, func(progress ParseProgress) error {
+			if progress.ConfigsCreated > maxConfigs {
+				return fmt.Errorf("%w: %d ATN configurations created for %d tokens",
+					ErrParseNotProgressing, progress.ConfigsCreated, progress.TokensConsumed)
+			}
+			return nil
+		})
+	}
+	p.AddErrorListener(guard)
+
+	start(p)
+
+	if guard.err != nil {
+		return guard.err
+	}
+	if e, ok := p.GetError().(*ParseCancellationException); ok && e.GetCause() != nil {
+		return e.GetCause()
+	}
+	return nil
+}
+
+// fuzzGuard is the error listener installed by [FuzzParse]. It discards syntax errors, but aborts the
+// parse if there are more of them than the input can justify.
+type fuzzGuard struct {
+	*DefaultErrorListener
+	maxErrors int
+	errors    int
+	cancel    func(error)
+	err       error
+}
+
+func (g *fuzzGuard) SyntaxError(_ Recognizer, _ interface{}, _, _ int, _ string, _ RecognitionException) {
+	g.errors++
+	if g.errors > g.maxErrors && g.err == nil {
+		g.err = fmt.Errorf("%w: %d syntax errors reported", ErrParseNotProgressing, g.errors)
+		if g.cancel != nil {
+			g.cancel(g.err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func fuzzList(data []byte) error {
+	return FuzzParse(
+		func(input CharStream) Lexer { return newListLexer(input) },
+		func(input TokenStream) Parser { return newListParser(input) },
+		func(p Parser) ParseTree { return p.(*listParser).S() },
+		data)
+}
+
+func FuzzParseList(f *testing.F) {
+	for _, seed := range []string{"", "a b + c", "+ + a", "a+", "é!\x00", "a b c d e f + + + g"} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		if err := fuzzList(data); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// typelessLexer is a list lexer that gives every token but EOF the invalid token type 0.
+type typelessLexer struct {
+	*listLexer
+}
+
+func (l typelessLexer) NextToken() Token {
+	t := l.listLexer.NextToken()
+	if t.GetTokenType() != TokenEOF {
+		t.(*CommonToken).tokenType = 0
+	}
+	return t
+}
+
+func TestFuzzParseErrors(t *testing.T) {
+	err := FuzzParse(
+		func(input CharStream) Lexer { return newListLexer(input) },
+		func(input TokenStream) Parser { return newListParser(input) },
+		func(p Parser) ParseTree { panic("boom") },
+		[]byte("a"))
+	if err == nil || !strings.Contains(err.Error(), "panic during parse: boom") {
+		t.Errorf("FuzzParse of a panicking rule = %v", err)
+	}
+
+	err = FuzzParse(
+		func(input CharStream) Lexer { return typelessLexer{newListLexer(input)} },
+		func(input TokenStream) Parser { return newListParser(input) },
+		func(p Parser) ParseTree { return p.(*listParser).S() },
+		[]byte("a b"))
+	var problem *TokenProblem
+	if !errors.As(err, &problem) || problem.Kind != TokenProblemInvalidType {
+		t.Errorf("FuzzParse of an inconsistent lexer = %v", err)
+	}
+
+	// A parser whose recovery is broken reports an error for each call of the rule, without consuming a token
+	err = FuzzParse(
+		func(input CharStream) Lexer { return newListLexer(input) },
+		func(input TokenStream) Parser { return newListParser(input) },
+		func(p Parser) ParseTree {
+			for i := 0; i < 10; i++ {
+				p.GetErrorHandler().reset(p)
+				p.(*listParser).Item()
+			}
+			return nil
+		},
+		[]byte("+"))
+	if !errors.Is(err, ErrParseNotProgressing) {
+		t.Errorf("FuzzParse of a looping parse = %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrGrammarMismatch is the error, possibly wrapped, returned when an artifact derived from a grammar, such
+// as a saved DFA or a cached parse tree, was made with a different version of the grammar than the one in use.
+var ErrGrammarMismatch = errors.New("grammar fingerprint mismatch")
+
+// GrammarFingerprint identifies a version of a grammar. It is a hash of the serialized [ATN] of the grammar,
+// so that it changes whenever the grammar is changed in a way that changes how it parses, and does not change
+// when the code is merely generated again. Artifacts derived from a grammar, such as a saved DFA or a cache of
+// parse trees, should be stored with the fingerprint of the grammar they were made with, so that stale
+// artifacts can be detected once the grammar changes, see [GrammarFingerprint.Check].
+//
+// Use:
+//
+//	entry := cacheEntry{tree: tree, fingerprint: p.GetATN().Fingerprint()}
+//	...
+//	if err := p.GetATN().Fingerprint().Check(entry.fingerprint); err != nil {
+//	    // reparse, the grammar has changed
+//	}
+type GrammarFingerprint uint64
+
+// FingerprintSerializedATN returns the fingerprint of the grammar whose serialized [ATN] is serializedATN.
+// It is the 64-bit FNV-1a hash of the serialized ATN.
+func FingerprintSerializedATN(serializedATN []int32) GrammarFingerprint {
+	const (
+		offset64 = 14695981039346656037
+		prime64  = 1099511628211
+	)
+	h := uint64(offset64)
+	for _, v := range serializedATN {
+		for i := 0; i < 32; i += 8 {
+			h ^= uint64(byte(uint32(v) >> i))
+			h *= prime64
+		}
+	}
+	return GrammarFingerprint(h)
+}
+
+func (f GrammarFingerprint) String() string {
+	return fmt.Sprintf("%016x", uint64(f))
+}
+
+// Check returns nil if the fingerprint of an artifact, as stored with it, is f, and an error wrapping
+// [ErrGrammarMismatch] if it is not, so that the artifact is stale.
+func (f GrammarFingerprint) Check(artifact GrammarFingerprint) error {
+	if artifact != f {
+		return fmt.Errorf("%w: artifact was made with grammar %v, not %v", ErrGrammarMismatch, artifact, f)
+	}
+	return nil
+}
+
+// Fingerprint returns the fingerprint of the grammar of the ATN, or 0 if the ATN was not deserialized, as only
+// the serialized form of an ATN is fingerprinted.
+func (a *ATN) Fingerprint() GrammarFingerprint {
+	return a.fingerprint
+}
+
+// grammarFingerprints holds the fingerprints registered by generated recognizers, by grammar name.
+var grammarFingerprints = struct {
+	mu           RWMutex
+	fingerprints map[string]GrammarFingerprint
+}{
+	fingerprints: make(map[string]GrammarFingerprint),
+}
+
+// RegisterGrammarFingerprint records fingerprint as that of the grammar named grammarName, replacing any
+// fingerprint registered for it before. Generated recognizers register the fingerprints of their grammars
+// when their static data is initialized, so that code that checks artifacts by grammar name, see
+// [CheckGrammarFingerprint], need not have a recognizer at hand.
+//
+// Use, in generated code:
+//
+//	staticData.atn = deserializer.Deserialize(staticData.serializedATN)
+//	antlr.RegisterGrammarFingerprint("Calc", staticData.atn.Fingerprint())
+func RegisterGrammarFingerprint(grammarName string, fingerprint GrammarFingerprint) {
+	grammarFingerprints.mu.Lock()
+	defer grammarFingerprints.mu.Unlock()
+	grammarFingerprints.fingerprints[grammarName] = fingerprint
+}
+
+// GetGrammarFingerprint returns the fingerprint registered for the grammar named grammarName, and false if
+// there is none. See [RegisterGrammarFingerprint].
+func GetGrammarFingerprint(grammarName string) (GrammarFingerprint, bool) {
+	grammarFingerprints.mu.RLock()
+	defer grammarFingerprints.mu.RUnlock()
+	fingerprint, ok := grammarFingerprints.fingerprints[grammarName]
+	return fingerprint, ok
+}
+
+// CheckGrammarFingerprint returns nil if the fingerprint of an artifact, as stored with it, is the fingerprint
+// registered for the grammar named grammarName, and an error wrapping [ErrGrammarMismatch] if it is not, or
+// if no fingerprint is registered for the grammar, as the artifact cannot then be trusted.
+func CheckGrammarFingerprint(grammarName string, artifact GrammarFingerprint) error {
+	fingerprint, ok := GetGrammarFingerprint(grammarName)
+	if !ok {
+		return fmt.Errorf("%w: no fingerprint is registered for grammar %s", ErrGrammarMismatch, grammarName)
+	}
+	if err := fingerprint.Check(artifact); err != nil {
+		return fmt.Errorf("grammar %s: %w", grammarName, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestGrammarFingerprint(t *testing.T) {
+	atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+	fingerprint := atn.Fingerprint()
+	if fingerprint == 0 || fingerprint != FingerprintSerializedATN(listParserSerialized) || len(fingerprint.String()) != 16 {
+		t.Errorf("fingerprint %v, want %v", fingerprint, FingerprintSerializedATN(listParserSerialized))
+	}
+	if NewATNDeserializer(nil).Deserialize(listParserSerialized).Fingerprint() != fingerprint {
+		t.Error("the fingerprint of the same grammar changed")
+	}
+	changed := slices.Clone(listParserSerialized)
+	changed[len(changed)-1]++
+	if FingerprintSerializedATN(changed) == fingerprint || FingerprintSerializedATN(listLexerSerialized) == fingerprint {
+		t.Error("another grammar has the same fingerprint")
+	}
+	if built := buildATN(listWS, sllRules); built.Fingerprint() == fingerprint {
+		t.Errorf("another grammar over the same tokens has the fingerprint %v", built.Fingerprint())
+	}
+	if NewATN(ATNTypeParser, 1).Fingerprint() != 0 {
+		t.Error("an ATN that was not deserialized has a fingerprint")
+	}
+
+	if err := fingerprint.Check(fingerprint); err != nil {
+		t.Error(err)
+	}
+	if err := fingerprint.Check(FingerprintSerializedATN(changed)); !errors.Is(err, ErrGrammarMismatch) {
+		t.Errorf("Check() of a stale artifact = %v", err)
+	}
+
+	if err := CheckGrammarFingerprint("FingerprintTest", fingerprint); !errors.Is(err, ErrGrammarMismatch) {
+		t.Errorf("CheckGrammarFingerprint() of a grammar not registered = %v", err)
+	}
+	RegisterGrammarFingerprint("FingerprintTest", fingerprint)
+	if got, ok := GetGrammarFingerprint("FingerprintTest"); !ok || got != fingerprint {
+		t.Errorf("GetGrammarFingerprint() = %v, %v", got, ok)
+	}
+	if err := CheckGrammarFingerprint("FingerprintTest", fingerprint); err != nil {
+		t.Error(err)
+	}
+	if err := CheckGrammarFingerprint("FingerprintTest", 1); !errors.Is(err, ErrGrammarMismatch) {
+		t.Errorf("CheckGrammarFingerprint() of a stale artifact = %v", err)
+	}
+}
+
+func TestUseDFASnapshotOfAnotherGrammar(t *testing.T) {
+	other := buildATN(listWS, sllRules)
+	snapshot := NewDFASnapshot(other, newDFA(other))
+	p := newListParser(nil)
+	if snapshot.Fingerprint() != other.Fingerprint() {
+		t.Errorf("the snapshot has the fingerprint %v", snapshot.Fingerprint())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("UseDFASnapshot() of another grammar did not panic")
+		}
+	}()
+	p.Interpreter.UseDFASnapshot(snapshot)
+}
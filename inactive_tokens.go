@@ -0,0 +1,105 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "strings"
+
+// InactiveTokens marks ranges of tokens, by token index, as inactive, such as the regions of C-like source
+// that a preprocessor conditional excludes, so that text taken from the token stream can include or exclude
+// them. Grammars for such languages usually route the tokens of an excluded region to a channel of their own,
+// so that the parser does not see them, and [NewInactiveTokensFromChannel] marks the tokens of that channel.
+// Tools that evaluate the conditionals themselves can instead mark the regions with [InactiveTokens.Mark].
+//
+// The parser never sees tokens off its channel, so the text of a parse tree taken from its leaves, as by
+// GetText, never includes them. The text taken from the token stream for the source interval of a tree does,
+// along with all other off-channel tokens such as whitespace and comments; [InactiveTokens.GetTreeText]
+// takes that text with or without the inactive tokens.
+//
+// Use:
+//
+//	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+//	tree := parser.NewMyParser(stream).TranslationUnit()
+//	inactive := antlr.NewInactiveTokensFromChannel(stream, parser.MyLexerINACTIVE)
+//	for _, fn := range functions(tree) {
+//	    fmt.Println(inactive.GetTreeText(stream, fn, false))
+//	}
+type InactiveTokens struct {
+	tokens *IntervalSet
+}
+
+// NewInactiveTokens creates an [InactiveTokens] with no tokens marked.
+func NewInactiveTokens() *InactiveTokens {
+	return &InactiveTokens{tokens: NewIntervalSet()}
+}
+
+// NewInactiveTokensFromChannel creates an [InactiveTokens] that marks each token of stream on the given
+// channel. It fills the stream, fetching all the tokens of its source.
+func NewInactiveTokensFromChannel(stream *CommonTokenStream, channel int) *InactiveTokens {
+	stream.Fill()
+	inactive := NewInactiveTokens()
+	for _, t := range stream.GetAllTokens() {
+		if t.GetChannel() == channel && t.GetTokenType() != TokenEOF {
+			inactive.tokens.addOne(t.GetTokenIndex())
+		}
+	}
+	return inactive
+}
+
+// Mark marks the tokens with the indexes from start to stop inclusive as inactive.
+func (i *InactiveTokens) Mark(start, stop int) {
+	if start <= stop {
+		i.tokens.addRange(start, stop)
+	}
+}
+
+// IsInactive returns true if the token with the given index is marked as inactive.
+func (i *InactiveTokens) IsInactive(tokenIndex int) bool {
+	return i.tokens.contains(tokenIndex)
+}
+
+// IsTokenInactive returns true if t is marked as inactive.
+func (i *InactiveTokens) IsTokenInactive(t Token) bool {
+	return t != nil && i.tokens.contains(t.GetTokenIndex())
+}
+
+// Regions returns the ranges of inactive token indexes, in order, with adjacent ranges merged. As within an
+// [IntervalSet], the Stop of each range is exclusive.
+func (i *InactiveTokens) Regions() []Interval {
+	return append([]Interval(nil), i.tokens.GetIntervals()...)
+}
+
+// GetText returns the text of the tokens of stream with the indexes in interval, whose Stop is inclusive as
+// for [TokenStream.GetTextFromInterval], on all channels, but including the inactive tokens only if
+// includeInactive is true.
+func (i *InactiveTokens) GetText(stream TokenStream, interval Interval, includeInactive bool) string {
+	if includeInactive || interval.Start < 0 || interval.Stop < interval.Start {
+		return stream.GetTextFromInterval(interval)
+	}
+
+	var sb strings.Builder
+	start := interval.Start
+	for _, r := range i.tokens.GetIntervals() {
+		if r.Stop <= start {
+			continue
+		}
+		if r.Start > interval.Stop {
+			break
+		}
+		if r.Start > start {
+			sb.WriteString(stream.GetTextFromInterval(NewInterval(start, r.Start-1)))
+		}
+		start = r.Stop
+	}
+	if start <= interval.Stop {
+		sb.WriteString(stream.GetTextFromInterval(NewInterval(start, interval.Stop)))
+	}
+	return sb.String()
+}
+
+// GetTreeText returns the text of the tokens of stream within the source interval of tree, on all channels,
+// but including the inactive tokens only if includeInactive is true.
+func (i *InactiveTokens) GetTreeText(stream TokenStream, tree SyntaxTree, includeInactive bool) string {
+	return i.GetText(stream, tree.GetSourceInterval(), includeInactive)
+}
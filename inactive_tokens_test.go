@@ -0,0 +1,89 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"testing"
+)
+
+// inactiveChannel is the channel of the tokens of excluded regions in inactiveStream.
+const inactiveChannel = 2
+
+// inactiveStream returns a stream of the tokens of the list grammar "a b" with whitespace on the hidden
+// channel and the inactive region "x + y " on a channel of its own.
+func inactiveStream() *CommonTokenStream {
+	var tokens []Token
+	for _, tok := range []struct {
+		text    string
+		ttype   int
+		channel int
+	}{
+		{"a", listID, TokenDefaultChannel},
+		{" ", listWS, TokenHiddenChannel},
+		{"x", listID, inactiveChannel},
+		{" + ", listPLUS, inactiveChannel},
+		{"y", listID, inactiveChannel},
+		{" ", listWS, TokenHiddenChannel},
+		{"b", listID, TokenDefaultChannel},
+		{"<EOF>", TokenEOF, TokenDefaultChannel},
+	} {
+		t := newTestToken(tok.ttype, 0, 0, 1, 0)
+		t.SetText(tok.text)
+		t.channel = tok.channel
+		tokens = append(tokens, t)
+	}
+	stream := NewCommonTokenStream(nil, TokenDefaultChannel)
+	stream.SetTokenSource(&sliceTokenSource{tokens: tokens})
+	return stream
+}
+
+func TestInactiveTokens(t *testing.T) {
+	stream := inactiveStream()
+	inactive := NewInactiveTokensFromChannel(stream, inactiveChannel)
+	if got := fmt.Sprint(inactive.Regions()); got != "[2..4]" {
+		t.Errorf("regions %s", got)
+	}
+	if inactive.IsInactive(1) || !inactive.IsInactive(3) || !inactive.IsTokenInactive(stream.Get(4)) || inactive.IsTokenInactive(nil) {
+		t.Error("the wrong tokens are inactive")
+	}
+
+	p := newListParser(stream)
+	tree := p.S()
+	if got := tree.ToStringTree(nil, p); got != "(s (item a) (item b) <EOF>)" {
+		t.Fatalf("tree %s", got)
+	}
+	if got := inactive.GetTreeText(stream, tree, true); got != "a x + y b" {
+		t.Errorf("with the inactive tokens: %q", got)
+	}
+	if got := inactive.GetTreeText(stream, tree, false); got != "a  b" {
+		t.Errorf("without the inactive tokens: %q", got)
+	}
+
+	// regions marked by hand may be anywhere, and are merged
+	marked := NewInactiveTokens()
+	marked.Mark(0, 0)
+	marked.Mark(1, 2)
+	marked.Mark(5, 4)
+	marked.Mark(6, 6)
+	tests := []struct {
+		start, stop int
+		want        string
+	}{
+		{0, 6, " + y "},
+		{3, 5, " + y "},
+		{1, 1, ""},
+		{6, 6, ""},
+		{6, 5, ""},
+	}
+	for _, test := range tests {
+		if got := marked.GetText(stream, NewInterval(test.start, test.stop), false); got != test.want {
+			t.Errorf("GetText(%d..%d) = %q, want %q", test.start, test.stop, got, test.want)
+		}
+	}
+	if got := fmt.Sprint(marked.Regions()); got != "[0..2 6]" {
+		t.Errorf("regions %s", got)
+	}
+}
@@ -0,0 +1,330 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// IncrementalParse parses the tokens of an [IncrementalTokenStream] again after it is edited, reusing the
+// subtrees of the last parse tree that lie outside the edit, so that a tool can keep the tree of a large
+// document up to date as it is typed in, at a cost that depends more on the size of the edit than on the
+// size of the document.
+//
+// As a rule is entered, the parse looks for a context of the last tree for the same rule that began at the
+// same token, invoked from the same states of the same rules, at the same precedence, and whose parse read
+// no token that an edit has lexed again, nor past one that an edit has removed, counting the tokens that
+// its predictions looked ahead at. If there is one, the parser takes its subtree in place of parsing the
+// rule, and carries on after it; otherwise, it parses the rule as usual, looking for subtrees to reuse
+// within it. Contexts within which a syntax error was reported, or that were parsed while the parser was
+// recovering from one, are never reused, so that the errors in the text are reported by every parse.
+//
+// A reused subtree is moved into the new tree, so the last tree must not be used once the text has been
+// parsed again. The children, start and stop tokens and alternative of a reused context are moved into the
+// context that the rule being parsed creates, with the methods of [ParserRuleContext]. The fields that
+// generated code sets as it parses a rule, such as those of labels, are not moved, so they are left unset in
+// a reused context, whose children should be read with the accessors of the context instead. A context that
+// the rule replaced as it was parsed, as a rule with labeled alternatives replaces its context with one of
+// the alternative, is parsed again, though the subtrees within it may still be reused. Nor are the contexts
+// of left-recursive rules reused. The actions and predicates of the grammar are not run for the subtrees
+// reused, so a grammar whose actions keep state, or whose predicates depend on state other than the tokens,
+// cannot be parsed incrementally. Subtrees are reused only while the parser builds parse trees, and has no
+// parse listeners, such as the tracer.
+//
+// Use:
+//
+//	stream := antlr.NewIncrementalTokenStream(parser.NewMyLexer(nil), text, antlr.TokenDefaultChannel)
+//	p := parser.NewMyParser(stream)
+//	parse := antlr.NewIncrementalParse(p, stream, func() antlr.ParserRuleContext { return p.Document() })
+//	tree := parse.Parse()
+//	...
+//	// on each change from the editor
+//	stream.Edit(change.Range, change.Text)
+//	tree = parse.Parse()
+type IncrementalParse struct {
+	parser *BaseParser
+	stream *IncrementalTokenStream
+	start  func() ParserRuleContext
+
+	// tree is the tree of the last parse, generation the edits made to the stream before it, and contexts
+	// holds what was recorded of its contexts that may be reused
+	tree       ParserRuleContext
+	generation int
+	contexts   map[ParserRuleContext]reusableContext
+
+	// candidates holds the contexts of the last tree that may be reused, by the token they began at,
+	// outermost first, and frames what is recorded of the rule invocations being parsed
+	candidates map[Token][]ParserRuleContext
+	frames     []reuseFrame
+
+	// depth is the rule depth of the invocation whose subtree is being reused, or 0 for none, reused the
+	// context reused for it, and skipped the error that makes the rule return at once
+	depth   int
+	reused  ParserRuleContext
+	skipped RecognitionException
+
+	// reusedCount is the number of subtrees reused by the last parse
+	reusedCount int
+}
+
+// reusableContext is what an [IncrementalParse] records of a context that may be reused: the furthest token
+// that its parse read, and how many tokens after its start token that was, and the precedence it was
+// parsed at.
+type reusableContext struct {
+	reach      Token
+	reachSpan  int
+	precedence int
+}
+
+// reuseFrame is what an [IncrementalParse] records of a rule invocation as it is entered.
+type reuseFrame struct {
+	// ctx is the context the invocation was entered with
+	ctx ParserRuleContext
+
+	// reach is the furthest token read by the invocations that enclose it, up to when it was entered, and
+	// start the index of its start token
+	reach int
+	start int
+
+	// errors is the number of syntax errors reported before it was entered, recovering whether the parser
+	// was recovering from an error, and precedence the precedence it was parsed at
+	errors     int
+	recovering bool
+	precedence int
+}
+
+// NewIncrementalParse creates a parse of stream with parser p, beginning with the rule invoked by start. It
+// panics if p does not embed [BaseParser].
+func NewIncrementalParse(p Parser, stream *IncrementalTokenStream, start func() ParserRuleContext) *IncrementalParse {
+	bp := runtimeConfigParser(p)
+	return &IncrementalParse{
+		parser:   bp,
+		stream:   stream,
+		start:    start,
+		contexts: make(map[ParserRuleContext]reusableContext),
+		skipped:  NewBaseRecognitionException("subtree reused", bp, stream, nil),
+	}
+}
+
+// Parse parses the text of the stream as it is now, reusing the subtrees of the last tree that the edits
+// since it was parsed did not touch, and returns the new tree. The parser is reset, and its token stream set
+// to the stream, first. The first parse parses the whole text.
+func (ip *IncrementalParse) Parse() ParserRuleContext {
+	p := ip.parser
+	ip.stream.Seek(0)
+	p.SetInputStream(ip.stream)
+	ip.findCandidates()
+	ip.frames = ip.frames[:0]
+	ip.depth = 0
+	ip.reused = nil
+	ip.reusedCount = 0
+	ip.stream.reach = -1
+
+	if p.BuildParseTrees && !p.validateOnly && len(p.parseListeners) == 0 {
+		p.reuse = ip
+		defer func() {
+			p.reuse = nil
+		}()
+	}
+	ip.tree = ip.start()
+	ip.generation = ip.stream.generation
+	ip.candidates = nil
+	return ip.tree
+}
+
+// GetReusedCount returns the number of subtrees that the last parse reused, not counting those within them.
+func (ip *IncrementalParse) GetReusedCount() int {
+	return ip.reusedCount
+}
+
+// findCandidates finds the contexts of the last tree that may be reused, and forgets the others.
+func (ip *IncrementalParse) findCandidates() {
+	ip.candidates = make(map[Token][]ParserRuleContext)
+	contexts := make(map[ParserRuleContext]reusableContext)
+	if ip.tree == nil {
+		ip.contexts = contexts
+		return
+	}
+
+	// relexed[i] is the number of the first i tokens that were lexed again since the last parse
+	relexed := make([]int, len(ip.stream.lexed)+1)
+	for i, rec := range ip.stream.lexed {
+		relexed[i+1] = relexed[i]
+		if rec.generation > ip.generation {
+			relexed[i+1]++
+		}
+	}
+
+	var walk func(t Tree)
+	walk = func(t Tree) {
+		ctx, ok := t.(ParserRuleContext)
+		if !ok {
+			return
+		}
+		if c, ok := ip.contexts[ctx]; ok {
+			start := ctx.GetStart()
+			if start != nil && ip.stream.holds(start) && ip.stream.holds(c.reach) {
+				first, last := start.GetTokenIndex(), c.reach.GetTokenIndex()
+				if last-first == c.reachSpan && relexed[last+1] == relexed[first] {
+					contexts[ctx] = c
+					ip.candidates[start] = append(ip.candidates[start], ctx)
+				}
+			}
+		}
+		for _, child := range ctx.GetChildren() {
+			walk(child)
+		}
+	}
+	walk(ip.tree)
+	ip.contexts = contexts
+}
+
+// reusing returns true if the parser is within the invocation of a rule whose subtree is being reused.
+func (p *BaseParser) reusing() bool {
+	return p.reuse != nil && p.reuse.depth > 0
+}
+
+// enterRule reuses a subtree of the last tree for the rule invocation just entered, if it may, and otherwise
+// begins to record what may make the context of the invocation reusable in turn.
+func (ip *IncrementalParse) enterRule(localctx ParserRuleContext) {
+	p := ip.parser
+	if ip.depth > 0 {
+		return
+	}
+	if !p.HasError() {
+		if old := ip.reusable(localctx); old != nil {
+			// the rule returns at once, and its context is replaced by the one reused as it exits
+			ip.depth = p.ruleDepth
+			ip.reused = old
+			p.BaseRecognizer.SetError(ip.skipped)
+			return
+		}
+	}
+	ip.frames = append(ip.frames, reuseFrame{
+		ctx:        localctx,
+		reach:      ip.stream.reach,
+		start:      localctx.GetStart().GetTokenIndex(),
+		errors:     p._SyntaxErrors,
+		recovering: p.errHandler.InErrorRecoveryMode(p),
+		precedence: p.GetPrecedence(),
+	})
+	ip.stream.reach = -1
+}
+
+// reusable returns the context of the last tree that may be reused for the rule invocation with the given
+// context, or nil if there is none.
+func (ip *IncrementalParse) reusable(ctx ParserRuleContext) ParserRuleContext {
+	for _, old := range ip.candidates[ctx.GetStart()] {
+		if old.GetRuleIndex() == ctx.GetRuleIndex() && ip.contexts[old].precedence == ip.parser.GetPrecedence() && sameInvocation(old, ctx) {
+			return old
+		}
+	}
+	return nil
+}
+
+// sameInvocation returns true if the rules of contexts a and b were invoked from the same states of the same
+// rules, all the way up to the start rule.
+func sameInvocation(a, b ParserRuleContext) bool {
+	for a != nil && b != nil {
+		if a.GetRuleIndex() != b.GetRuleIndex() || a.GetInvokingState() != b.GetInvokingState() {
+			return false
+		}
+		a, _ = a.GetParent().(ParserRuleContext)
+		b, _ = b.GetParent().(ParserRuleContext)
+	}
+	return a == nil && b == nil
+}
+
+// exitRule ends the reuse of a subtree as the invocation it was reused for exits, and otherwise records what
+// makes the context of the invocation reusable, if it is.
+func (ip *IncrementalParse) exitRule() {
+	p := ip.parser
+	switch {
+	case ip.depth == p.ruleDepth:
+		ip.graft(p.ctx)
+		return
+	case ip.depth > 0:
+		return
+	}
+
+	f := ip.frames[len(ip.frames)-1]
+	ip.frames = ip.frames[:len(ip.frames)-1]
+	reach := max(ip.stream.reach, f.start)
+	if p._SyntaxErrors == f.errors && !f.recovering && !p.HasError() && p.ctx == f.ctx && reach < ip.stream.Size() {
+		ip.contexts[p.ctx] = reusableContext{
+			reach:      ip.stream.Get(reach),
+			reachSpan:  reach - f.start,
+			precedence: f.precedence,
+		}
+	}
+	ip.stream.reach = max(f.reach, reach)
+}
+
+// graft moves the subtree of the context reused for the rule invocation whose subtree is reused into the
+// context of the invocation, and moves the parser to the token after it.
+func (ip *IncrementalParse) graft(ctx ParserRuleContext) {
+	p := ip.parser
+	old := ip.reused
+	c := ip.contexts[old]
+	ip.depth = 0
+	ip.reused = nil
+	ip.reusedCount++
+	p.BaseRecognizer.SetError(nil)
+
+	for ctx.GetChildCount() > 0 {
+		ctx.RemoveLastChild()
+	}
+	for _, child := range old.GetChildren() {
+		switch child := child.(type) {
+		case ErrorNode:
+			ctx.AddErrorNode(child.GetSymbol())
+		case TerminalNode:
+			ctx.AddTokenNode(child.GetSymbol())
+		case RuleContext:
+			ctx.AddChild(child)
+			child.SetParent(ctx)
+		}
+	}
+	ctx.SetStart(old.GetStart())
+	ctx.SetStop(old.GetStop())
+	ctx.SetException(nil)
+	ctx.SetAltNumber(old.GetAltNumber())
+	if labeled, ok := old.(interface{ AltLabel() string }); ok {
+		if target, ok := ctx.(interface{ setAltLabel(string) }); ok {
+			target.setAltLabel(labeled.AltLabel())
+		}
+	}
+	ip.contexts[ctx] = c
+
+	next := ctx.GetStart().GetTokenIndex()
+	if stop := ctx.GetStop(); stop != nil && stop.GetTokenIndex() >= next {
+		next = stop.GetTokenIndex()
+		if stop.GetTokenType() != TokenEOF {
+			next++
+		}
+	}
+	p.input.Seek(next)
+	ip.stream.reach = max(ip.stream.reach, c.reach.GetTokenIndex())
+}
+
+// reusedSubtreeErrorStrategy is the error strategy of a parser within the invocation of a rule whose subtree
+// is being reused, which returns at once with an error that is neither reported nor recovered from.
+type reusedSubtreeErrorStrategy struct{}
+
+var _ ErrorStrategy = reusedSubtreeErrorStrategy{}
+
+func (reusedSubtreeErrorStrategy) reset(_ Parser) {}
+
+func (reusedSubtreeErrorStrategy) RecoverInline(_ Parser) Token {
+	return nil
+}
+
+func (reusedSubtreeErrorStrategy) Recover(_ Parser, _ RecognitionException) {}
+
+func (reusedSubtreeErrorStrategy) Sync(_ Parser) {}
+
+func (reusedSubtreeErrorStrategy) InErrorRecoveryMode(_ Parser) bool {
+	return false
+}
+
+func (reusedSubtreeErrorStrategy) ReportError(_ Parser, _ RecognitionException) {}
+
+func (reusedSubtreeErrorStrategy) ReportMatch(_ Parser) {}
@@ -0,0 +1,193 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"math/rand"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// nestedRules is a grammar over the tokens of the list grammar whose items nest, so that a reused item has
+// items within it:
+//
+//	s    : item* EOF ;
+//	item : ID | ID '+' item ;
+var nestedRules = [][][]atnElement{
+	{bAlt(bBlock('*', bAlt(bRule(1))), bTok(TokenEOF))},
+	{bAlt(bTok(listID)), bAlt(bTok(listID), bTok(listPLUS), bRule(1))},
+}
+
+var nestedATN = sync.OnceValue(func() *ATN { return buildATN(listWS, nestedRules) })
+
+// incrementalCase is a parse of a text that is edited and parsed again.
+type incrementalCase struct {
+	t      *testing.T
+	text   string
+	stream *IncrementalTokenStream
+	parser Parser
+	parse  *IncrementalParse
+	fresh  func(text string) string
+}
+
+// newListIncremental parses text incrementally with the generated-style parser of the list grammar.
+func newListIncremental(t *testing.T, text string) *incrementalCase {
+	lexer := newListLexer(nil)
+	lexer.RemoveErrorListeners()
+	stream := NewIncrementalTokenStream(lexer, text, TokenDefaultChannel)
+	p := newListParser(stream)
+	p.RemoveErrorListeners()
+	c := &incrementalCase{t: t, text: text, stream: stream, parser: p}
+	c.parse = NewIncrementalParse(p, stream, func() ParserRuleContext { return p.S() })
+	c.fresh = func(text string) string {
+		lexer := newListLexer(NewInputStream(text))
+		lexer.RemoveErrorListeners()
+		p := newListParser(NewCommonTokenStream(lexer, TokenDefaultChannel))
+		p.RemoveErrorListeners()
+		return p.S().ToStringTree(nil, p)
+	}
+	c.check(c.parse.Parse(), 0)
+	return c
+}
+
+// newNestedIncremental parses text incrementally with an interpreter of the nested grammar.
+func newNestedIncremental(t *testing.T, text string) *incrementalCase {
+	interpreter := func(stream TokenStream) *ParserInterpreter {
+		p := NewParserInterpreter("Nested.g4", []string{"", "", "'+'"}, []string{"", "ID", "PLUS", "WS"},
+			[]string{"s", "item"}, nestedATN(), stream)
+		p.RemoveErrorListeners()
+		return p
+	}
+	lexer := newListLexer(nil)
+	lexer.RemoveErrorListeners()
+	stream := NewIncrementalTokenStream(lexer, text, TokenDefaultChannel)
+	p := interpreter(stream)
+	c := &incrementalCase{t: t, text: text, stream: stream, parser: p}
+	c.parse = NewIncrementalParse(p, stream, func() ParserRuleContext { return p.Parse(0) })
+	c.fresh = func(text string) string {
+		lexer := newListLexer(NewInputStream(text))
+		lexer.RemoveErrorListeners()
+		p := interpreter(NewCommonTokenStream(lexer, TokenDefaultChannel))
+		return p.Parse(0).ToStringTree(nil, p)
+	}
+	c.check(c.parse.Parse(), 0)
+	return c
+}
+
+// edit replaces the text from start to stop inclusive, on the first line, with text, parses it again, and
+// checks that the tree is that of a parse from scratch, and that reused subtrees were reused.
+func (c *incrementalCase) edit(start, stop int, text string, reused int) ParserRuleContext {
+	c.t.Helper()
+	c.stream.Edit(TextRange{EndColumn: stop + 1, StartColumn: start, Start: start, Stop: stop}, text)
+	c.text = c.text[:start] + text + c.text[stop+1:]
+	tree := c.parse.Parse()
+	c.check(tree, reused)
+	return tree
+}
+
+func (c *incrementalCase) check(tree ParserRuleContext, reused int) {
+	c.t.Helper()
+	if got, want := tree.ToStringTree(nil, c.parser), c.fresh(c.text); got != want {
+		c.t.Fatalf("%q: tree %s, want %s", c.text, got, want)
+	}
+	if got := c.parse.GetReusedCount(); got != reused {
+		c.t.Errorf("%q: %d subtrees reused, want %d", c.text, got, reused)
+	}
+	var walk func(ctx ParserRuleContext)
+	walk = func(ctx ParserRuleContext) {
+		for _, child := range ctx.GetChildren() {
+			if child, ok := child.(ParserRuleContext); ok {
+				if child.GetParent() != ctx {
+					c.t.Fatalf("%q: %s is not the parent of %s", c.text, ctx.ToStringTree(nil, c.parser),
+						child.ToStringTree(nil, c.parser))
+				}
+				walk(child)
+			}
+		}
+	}
+	walk(tree)
+}
+
+func TestIncrementalParseEditInSubtree(t *testing.T) {
+	// the items are a, b + c, and d
+	c := newListIncremental(t, "a b + c d")
+	c.edit(6, 6, "x", 2)
+	// a is parsed again too, as its prediction looked ahead at b
+	c.edit(2, 2, "yy", 1)
+
+	// the innermost item is parsed again, and so are the items that hold it, but not d
+	n := newNestedIncremental(t, "a + b + c d")
+	n.edit(8, 8, "x", 1)
+}
+
+func TestIncrementalParseEditAtSubtreeEdge(t *testing.T) {
+	c := newListIncremental(t, "a b + c d")
+	// an insertion before b lexes b again, which a looked ahead at, so only d is reused
+	c.edit(2, 1, "e ", 1)
+	// an edit of the last token of b + c parses only b + c again
+	c.edit(8, 8, "cc", 3)
+	// an edit of the first token of the document parses only the first item again
+	tree := c.edit(0, 0, "x", 3)
+	item := tree.GetChild(2).(ParserRuleContext)
+	if got := item.GetStart().GetText() + item.GetStop().GetText(); got != "bcc" {
+		t.Errorf("the reused subtree spans %s, want bcc", got)
+	}
+	if item.GetAltNumber() != 2 || item.GetChildCount() != 3 || item.GetParent() != tree {
+		t.Errorf("the reused subtree has alternative %d and %d children", item.GetAltNumber(), item.GetChildCount())
+	}
+
+	// the items nested in the first item are reused, and the items within them are moved with them
+	n := newNestedIncremental(t, "a + b + c d")
+	tree = n.edit(0, 0, "x", 2)
+	outer := tree.GetChild(0).(ParserRuleContext)
+	nested := outer.GetChild(2).(ParserRuleContext)
+	if nested.GetParent() != outer || nested.GetChild(2).GetParent() != nested {
+		t.Error("the contexts of a reused subtree were not moved with it")
+	}
+	if got := nested.GetStart().GetText() + nested.GetStop().GetText(); got != "bc" {
+		t.Errorf("the reused subtree spans %s, want bc", got)
+	}
+}
+
+func TestIncrementalParseEditAcrossSubtrees(t *testing.T) {
+	c := newListIncremental(t, "a b + c d e")
+	// removing the end of b + c and all of d leaves b, and lexes e again
+	c.edit(4, 8, "", 1)
+	c.edit(2, 2, "f + g h + i", 1)
+
+	n := newNestedIncremental(t, "a + b + c d + e")
+	n.edit(6, 12, "", 0)
+}
+
+func TestIncrementalParseRandomEdits(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	alphabet := []string{"a", "b", " ", "+", "cd", "Q"}
+	randText := func(n int) string {
+		var sb strings.Builder
+		for i := 0; i < n; i++ {
+			sb.WriteString(alphabet[r.Intn(len(alphabet))])
+		}
+		return sb.String()
+	}
+	reused := 0
+	for round := 0; round < 50; round++ {
+		for _, newCase := range []func(*testing.T, string) *incrementalCase{newListIncremental, newNestedIncremental} {
+			c := newCase(t, randText(r.Intn(30)))
+			for step := 0; step < 10; step++ {
+				start := r.Intn(len(c.text) + 1)
+				stop := start - 1 + r.Intn(min(3, len(c.text)-start)+1)
+				c.stream.Edit(TextRange{EndColumn: stop + 1, StartColumn: start, Start: start, Stop: stop}, randText(r.Intn(3)))
+				c.text = c.stream.GetText()
+				tree := c.parse.Parse()
+				reused += c.parse.GetReusedCount()
+				c.check(tree, c.parse.GetReusedCount())
+			}
+		}
+	}
+	if reused == 0 {
+		t.Error("no subtree was reused")
+	}
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"slices"
+)
+
+// IncrementalTokenStream is a [CommonTokenStream] over text that is edited, such as in an editor, which lexes
+// again only the tokens that an edit may have changed, and keeps the others, moved to their place in the new
+// text. Together with an [IncrementalParse], which reuses the subtrees of the last parse tree that span
+// tokens that were kept, it lets a tool keep the tree of a large document up to date as it is typed in.
+//
+// As it lexes, the stream records, for each token, the mode of the lexer when it began to lex the token and
+// the furthest character that the lexer examined to lex it. An edit is lexed again from the first token whose
+// lexing examined a character the edit replaced, or the place where it inserts text, until the lexer begins
+// a token at the place where one began before, after the edit, in the same mode; from there on, the lexer
+// would lex the same tokens as before, so the old ones are kept.
+//
+// The lexer must embed [BaseLexer], as all generated lexers do. Its modes are the only state of it that is
+// restored to lex again part way through the text, so a lexer with actions or predicates that keep state of
+// their own, or that depend on the line or column, cannot be used. Tokens are lexed with the [InputStream]
+// fast paths of the lexer turned off, so that the characters it examines can be recorded.
+//
+// Use:
+//
+//	stream := antlr.NewIncrementalTokenStream(parser.NewMyLexer(nil), text, antlr.TokenDefaultChannel)
+//	...
+//	// on each change from the editor
+//	stream.Edit(change.Range, change.Text)
+type IncrementalTokenStream struct {
+	*CommonTokenStream
+
+	lexer incrementalLexer
+	text  []rune
+	input *reachCharStream
+
+	// lexed holds what was recorded as each token was lexed, by token index, and generation counts the edits
+	lexed      []lexedToken
+	generation int
+
+	// reach is the index of the furthest token read by LT or LA, see IncrementalParse
+	reach int
+}
+
+// incrementalLexer is the lexer of an [IncrementalTokenStream], which must embed [BaseLexer].
+type incrementalLexer interface {
+	Lexer
+	SetInputStream(CharStream)
+	modeState() (int, []int)
+	setModeState(mode int, stack []int)
+	setPosition(line, column int)
+}
+
+// lexedToken is what an [IncrementalTokenStream] records as it lexes a token.
+type lexedToken struct {
+	// start is the index of the character that the lexer began at, before any text it skipped, at the given
+	// line and column, and in the given state, and reach is the index of the furthest character it examined
+	start, reach int
+	line, column int
+	state        lineLexerState
+
+	// generation is the edit that the token was lexed after, or 0 if it was lexed from the original text
+	generation int
+}
+
+// TokenStreamEdit describes the tokens of an [IncrementalTokenStream] that an edit lexed again: those from
+// Start up to but not including OldEnd were replaced by those from Start up to but not including NewEnd. The
+// tokens from OldEnd on were kept, and are now numbered from NewEnd on.
+type TokenStreamEdit struct {
+	Start, OldEnd, NewEnd int
+}
+
+// NewIncrementalTokenStream creates an [IncrementalTokenStream] of the tokens on channel lexed from text by
+// lexer, whose input stream it replaces. It panics if lexer does not embed [BaseLexer].
+func NewIncrementalTokenStream(lexer Lexer, text string, channel int) *IncrementalTokenStream {
+	l, ok := lexer.(incrementalLexer)
+	if !ok {
+		panic("an IncrementalTokenStream requires a lexer that embeds BaseLexer")
+	}
+	s := &IncrementalTokenStream{lexer: l, text: []rune(text), reach: -1}
+	s.input = newReachCharStream(s.text)
+	l.SetInputStream(s.input)
+	s.CommonTokenStream = NewCommonTokenStream(lexer, channel)
+	s.tokenSource = &incrementalTokenSource{Lexer: lexer, stream: s}
+	return s
+}
+
+// GetText returns the text that the tokens are lexed from, with all the edits made to it.
+func (s *IncrementalTokenStream) GetText() string {
+	return string(s.text)
+}
+
+// LT returns the token k tokens ahead of the current one, as for a [CommonTokenStream], noting how far ahead
+// the parser has read.
+func (s *IncrementalTokenStream) LT(k int) Token {
+	t := s.CommonTokenStream.LT(k)
+	if k > 0 && t != nil && t.GetTokenIndex() > s.reach {
+		s.reach = t.GetTokenIndex()
+	}
+	return t
+}
+
+func (s *IncrementalTokenStream) LA(i int) int {
+	return s.LT(i).GetTokenType()
+}
+
+// Edit replaces the characters of edited by text, lexes again the tokens that may have changed, and moves
+// the tokens after them to their place in the new text, as [ShiftTokens] does. The edited range is given as
+// an LSP editor gives it, see [TextRange], and must lie within the text. The stream is rewound to its first
+// token, and tokens are numbered again from the first that was lexed again.
+//
+// Tokens before those lexed again are kept as they are, and those after them are the same tokens, moved, so
+// a parse tree that holds them stays valid for the parts of the text that the edit did not touch.
+func (s *IncrementalTokenStream) Edit(edited TextRange, text string) TokenStreamEdit {
+	if edited.Start < 0 || edited.Stop < edited.Start-1 || edited.Stop >= len(s.text) {
+		panic(fmt.Sprintf("edit of characters %d..%d outside text of length %d", edited.Start, edited.Stop, len(s.text)))
+	}
+	s.Fill()
+	inserted := []rune(text)
+	s.text = slices.Concat(s.text[:edited.Start], inserted, s.text[edited.Stop+1:])
+	deltaOffset, deltaLines, deltaColumns := editDeltas(edited, text)
+	s.generation++
+
+	// the first token whose lexing examined a character replaced, or that the text may be inserted into
+	first := 0
+	for first < len(s.lexed)-1 && s.lexed[first].reach < edited.Start {
+		first++
+	}
+	from := s.lexed[first]
+	s.input = newReachCharStream(s.text)
+	s.lexer.SetInputStream(s.input)
+	s.input.Seek(from.start)
+	s.lexer.setPosition(from.line, from.column)
+	s.lexer.setModeState(from.state.mode, from.state.stack)
+
+	// lex until the lexer begins a token where one began after the edit, in the same mode, or reaches the end
+	editEnd := edited.Start + len(inserted)
+	var tokens []Token
+	var lexed []lexedToken
+	kept := first
+	for {
+		if start := s.input.Index(); start >= editEnd {
+			for kept < len(s.lexed) && s.lexed[kept].start+deltaOffset < start {
+				kept++
+			}
+			if kept < len(s.lexed) && s.lexed[kept].start+deltaOffset == start {
+				mode, stack := s.lexer.modeState()
+				if s.lexed[kept].state.equals(lineLexerState{mode: mode, stack: stack}) {
+					break
+				}
+			}
+		}
+		t, rec := s.lex()
+		tokens = append(tokens, t)
+		lexed = append(lexed, rec)
+		if t.GetTokenType() == TokenEOF {
+			kept = len(s.lexed)
+			break
+		}
+	}
+
+	ShiftTokens(s.tokens[kept:], edited, text)
+	for i := kept; i < len(s.lexed); i++ {
+		rec := &s.lexed[i]
+		if rec.line-1 == edited.EndLine {
+			rec.column += deltaColumns
+		}
+		rec.start += deltaOffset
+		rec.reach += deltaOffset
+		rec.line += deltaLines
+	}
+	end := first + len(tokens)
+	for _, t := range s.tokens[first:kept] {
+		s.counts.add(t, -1)
+	}
+	for _, t := range tokens {
+		s.counts.add(t, 1)
+	}
+	s.tokens = slices.Concat(s.tokens[:first], tokens, s.tokens[kept:])
+	s.lexed = slices.Concat(s.lexed[:first], lexed, s.lexed[kept:])
+	for i := first; i < len(s.tokens); i++ {
+		s.tokens[i].SetTokenIndex(i)
+	}
+	s.reindexChannel()
+	s.Seek(0)
+	return TokenStreamEdit{Start: first, OldEnd: kept, NewEnd: end}
+}
+
+// lex lexes the next token, and returns it with what was recorded as it was lexed.
+func (s *IncrementalTokenStream) lex() (Token, lexedToken) {
+	mode, stack := s.lexer.modeState()
+	rec := lexedToken{
+		start:      s.input.Index(),
+		line:       s.lexer.GetLine(),
+		column:     s.lexer.GetCharPositionInLine(),
+		state:      lineLexerState{mode: mode, stack: stack},
+		generation: s.generation,
+	}
+	s.input.reach = -1
+	t := s.lexer.NextToken()
+	rec.reach = max(s.input.reach, rec.start)
+	return t, rec
+}
+
+// holds returns true if t is one of the tokens of the stream, at its index.
+func (s *IncrementalTokenStream) holds(t Token) bool {
+	i := t.GetTokenIndex()
+	return i >= 0 && i < len(s.tokens) && s.tokens[i] == t
+}
+
+// incrementalTokenSource is the token source of an [IncrementalTokenStream], which records what it lexes.
+type incrementalTokenSource struct {
+	Lexer
+	stream *IncrementalTokenStream
+}
+
+func (t *incrementalTokenSource) NextToken() Token {
+	token, rec := t.stream.lex()
+	t.stream.lexed = append(t.stream.lexed, rec)
+	return token
+}
+
+// reachCharStream is the input of the lexer of an [IncrementalTokenStream], which records the furthest
+// character that the lexer examines.
+type reachCharStream struct {
+	*InputStream
+	reach int
+}
+
+func newReachCharStream(text []rune) *reachCharStream {
+	return &reachCharStream{InputStream: NewInputStream(string(text)), reach: -1}
+}
+
+func (r *reachCharStream) LA(offset int) int {
+	if offset > 0 {
+		r.reach = max(r.reach, r.Index()+offset-1)
+	}
+	return r.InputStream.LA(offset)
+}
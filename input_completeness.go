@@ -0,0 +1,125 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// InputStatus classifies an input according to whether it is a complete sentence of a grammar, see
+// [InputCompletenessListener].
+type InputStatus int
+
+const (
+	// InputComplete is an input that lexed and parsed without error
+	InputComplete InputStatus = iota
+
+	// InputIncomplete is an input that is valid as far as it goes, but ended where more input was expected,
+	// such as an unclosed bracket or unterminated string, so that it could be completed by further input
+	InputIncomplete
+
+	// InputInvalid is an input with an error before its end, which no further input can correct
+	InputInvalid
+)
+
+// String returns the name of the status, such as "incomplete".
+func (s InputStatus) String() string {
+	switch s {
+	case InputComplete:
+		return "complete"
+	case InputIncomplete:
+		return "incomplete"
+	case InputInvalid:
+		return "invalid"
+	}
+	return "unknown"
+}
+
+// InputCompletenessListener is an [ErrorListener] that distinguishes input that is syntactically
+// incomplete from input that is invalid, so that an interactive interpreter, or REPL, can tell whether to
+// prompt for a continuation line or to report the errors.
+//
+// The input is incomplete if the first error is found at the end of the input, which happens when the
+// parser expects more tokens and finds [TokenEOF] instead, as EOF is not among the tokens that can follow
+// along any viable path, or when the lexer reaches the end of the input part way through a token. The
+// input is invalid if the first error is found at any other point, and complete if there is no error.
+// Later errors are ignored, as they may be the result of recovering from the first.
+//
+// Add the same listener to both the lexer and the parser, so that errors in tokens are seen. The listener
+// reports the status of the last input parsed, and must be reset with [InputCompletenessListener.Reset]
+// before it is used for another.
+//
+// Use:
+//
+//	completeness := antlr.NewInputCompletenessListener()
+//	lexer.AddErrorListener(completeness)
+//	p.AddErrorListener(completeness)
+//	tree := p.Statement()
+//	if completeness.Status() == antlr.InputIncomplete {
+//	    // Read another line, append it to the input and parse again
+//	}
+type InputCompletenessListener struct {
+	*DefaultErrorListener
+	status InputStatus
+}
+
+// NewInputCompletenessListener creates an [InputCompletenessListener] that has seen no errors.
+func NewInputCompletenessListener() *InputCompletenessListener {
+	return &InputCompletenessListener{}
+}
+
+// Status returns the status of the input, as determined by the first error reported to the listener.
+func (l *InputCompletenessListener) Status() InputStatus {
+	return l.status
+}
+
+// Reset forgets any errors reported, so that the listener can be used for another input.
+func (l *InputCompletenessListener) Reset() {
+	l.status = InputComplete
+}
+
+// SyntaxError classifies the input by the first error reported.
+func (l *InputCompletenessListener) SyntaxError(recognizer Recognizer, offendingSymbol interface{}, _, _ int, _ string,
+	_ RecognitionException) {
+
+	if l.status != InputComplete {
+		return
+	}
+	l.status = InputInvalid
+	switch {
+	case offendingSymbol != nil:
+		if t, ok := offendingSymbol.(Token); ok && t.GetTokenType() == TokenEOF {
+			l.status = InputIncomplete
+		}
+	default:
+		// Lexers report errors without an offending symbol, while their input is still positioned at the
+		// character that could not be matched.
+		if lexer, ok := recognizer.(interface{ GetInputStream() CharStream }); ok &&
+			lexer.GetInputStream().LA(1) == TokenEOF {
+			l.status = InputIncomplete
+		}
+	}
+}
+
+// CheckInputCompleteness lexes and parses input with a lexer and parser created by the given constructors,
+// starting at the rule invoked by start, and classifies it as described for [InputCompletenessListener].
+// No errors are reported to the console.
+//
+// Use:
+//
+//	status := antlr.CheckInputCompleteness(
+//	    func(input antlr.CharStream) antlr.Lexer { return parser.NewMyLexer(input) },
+//	    func(input antlr.TokenStream) antlr.Parser { return parser.NewMyParser(input) },
+//	    func(p antlr.Parser) antlr.ParseTree { return p.(*parser.MyParser).Statement() },
+//	    buffered)
+func CheckInputCompleteness(lexerCtor func(CharStream) Lexer, parserCtor func(TokenStream) Parser,
+	start func(Parser) ParseTree, input string) InputStatus {
+
+	completeness := NewInputCompletenessListener()
+	lexer := lexerCtor(NewInputStream(input))
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(completeness)
+	p := parserCtor(NewCommonTokenStream(lexer, TokenDefaultChannel))
+	p.RemoveErrorListeners()
+	p.AddErrorListener(completeness)
+	start(p)
+	return completeness.Status()
+}
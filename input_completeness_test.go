@@ -0,0 +1,51 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestCheckInputCompleteness(t *testing.T) {
+	tests := []struct {
+		input string
+		want  InputStatus
+	}{
+		{"a b + c", InputComplete},
+		{"", InputComplete},
+		{"a b +", InputIncomplete},
+		{"a + + b", InputInvalid},
+		// an error before the end decides, though the input is also incomplete
+		{"a + + b +", InputInvalid},
+		{"a - b", InputInvalid},
+	}
+	for _, test := range tests {
+		got := CheckInputCompleteness(func(input CharStream) Lexer { return newListLexer(input) },
+			func(input TokenStream) Parser { return newListParser(input) },
+			func(p Parser) ParseTree { return p.(*listParser).S() }, test.input)
+		if got != test.want {
+			t.Errorf("%q is %s, want %s", test.input, got, test.want)
+		}
+	}
+}
+
+func TestInputCompletenessListenerLexerErrors(t *testing.T) {
+	// A lexer that reports an error with its input at the end was part way through a token
+	completeness := NewInputCompletenessListener()
+	lexer := newListLexer(NewInputStream("a"))
+	lexer.GetInputStream().Consume()
+	completeness.SyntaxError(lexer, nil, 1, 1, "token recognition error at: 'a'", nil)
+	if completeness.Status() != InputIncomplete {
+		t.Errorf("status %s after a lexer error at the end of the input", completeness.Status())
+	}
+
+	completeness.Reset()
+	if completeness.Status() != InputComplete {
+		t.Errorf("status %s after Reset", completeness.Status())
+	}
+	completeness.SyntaxError(newListLexer(NewInputStream("-")), nil, 1, 0, "token recognition error at: '-'", nil)
+	completeness.SyntaxError(lexer, nil, 1, 1, "token recognition error at: 'a'", nil)
+	if completeness.Status() != InputInvalid || completeness.Status().String() != "invalid" {
+		t.Errorf("status %s after a lexer error before the end of the input", completeness.Status())
+	}
+}
@@ -6,14 +6,26 @@ package antlr
 
 import (
 	"bufio"
+	"errors"
+	"fmt"
 	"io"
 )
 
+// ErrInvalidInterval is the error, possibly wrapped, returned when an interval does not describe a range of
+// the input, because it starts before the input or starts after it stops.
+var ErrInvalidInterval = errors.New("invalid interval")
+
 type InputStream struct {
 	name  string
 	index int
 	data  []rune
 	size  int
+
+	// text is the input as a string, which [InputStream.Slice] returns views of, and offsets the byte offset in
+	// text of every sliceOffsetStride-th character, or nil if text is ASCII, once indexed; see indexText
+	text    string
+	offsets []int
+	indexed bool
 }
 
 // NewIoStream creates a new input stream from the given io.Reader reader.
@@ -51,6 +63,7 @@ func NewInputStream(data string) *InputStream {
 		name:  "<empty>",
 		index: 0,
 		data:  []rune(data), // This is actually the most efficient way
+		text:  data,
 	}
 	is.size = len(is.data) // number of runes, but we could also use len(data), which is efficient too
 	return is
@@ -87,6 +100,11 @@ func (is *InputStream) LA(offset int) int {
 	return int(is.data[pos])
 }
 
+// IsAtEOF returns true if every character of the input stream has been consumed.
+func (is *InputStream) IsAtEOF() bool {
+	return is.index >= is.size
+}
+
 // LT returns the character at the given offset from the start of the input stream
 func (is *InputStream) LT(offset int) int {
 	return is.LA(offset)
@@ -121,16 +139,28 @@ func (is *InputStream) Seek(index int) {
 	is.index = intMin(index, is.size)
 }
 
-// GetText returns the text from the input stream from the start to the stop index
+// GetText returns the text from the input stream from the start to the stop index, inclusive. A stop index
+// beyond the end of the input is treated as the end of the input. If the range is invalid, the empty string
+// is returned; use [InputStream.GetTextChecked] to distinguish this from an empty range.
 func (is *InputStream) GetText(start int, stop int) string {
+	text, _ := is.GetTextChecked(start, stop)
+	return text
+}
+
+// GetTextChecked is like [InputStream.GetText], but returns an error wrapping [ErrInvalidInterval] if start
+// is negative, or if start is more than one past stop. Note that start == stop+1 is valid, and selects no text.
+func (is *InputStream) GetTextChecked(start int, stop int) (string, error) {
+	if start < 0 || start > stop+1 {
+		return "", fmt.Errorf("%w: %d..%d", ErrInvalidInterval, start, stop)
+	}
 	if stop >= is.size {
 		stop = is.size - 1
 	}
-	if start >= is.size {
-		return ""
+	if start >= is.size || start > stop {
+		return "", nil
 	}
 
-	return string(is.data[start : stop+1])
+	return string(is.data[start : stop+1]), nil
 }
 
 // GetTextFromTokens returns the text from the input stream from the first character of the start token to the last
@@ -143,10 +173,18 @@ func (is *InputStream) GetTextFromTokens(start, stop Token) string {
 	return ""
 }
 
+// GetTextFromInterval returns the text from the input stream within the interval, where the Stop of
+// the interval is inclusive. See [InputStream.GetText].
 func (is *InputStream) GetTextFromInterval(i Interval) string {
 	return is.GetText(i.Start, i.Stop)
 }
 
+// GetTextFromIntervalChecked is like [InputStream.GetTextFromInterval], but returns an error for an invalid
+// interval. See [InputStream.GetTextChecked].
+func (is *InputStream) GetTextFromIntervalChecked(i Interval) (string, error) {
+	return is.GetTextChecked(i.Start, i.Stop)
+}
+
 func (*InputStream) GetSourceName() string {
 	return "Obtained from string"
 }
@@ -9,12 +9,18 @@ import (
 	"strings"
 )
 
+// Interval is a range of integers from Start to Stop. Within an [IntervalSet], and for all the methods of
+// Interval, Stop is exclusive: the interval holds Start up to Stop-1, and is empty if Stop <= Start.
+//
+// Note that the GetTextFromInterval methods of the streams, and [SyntaxTree.GetSourceInterval], instead
+// treat Stop as inclusive, so that NewInterval(3, 5) there means the characters or tokens 3, 4 and 5.
 type Interval struct {
 	Start int
 	Stop  int
 }
 
-// NewInterval creates a new interval with the given start and stop values.
+// NewInterval creates a new interval with the given start and stop values. See [Interval] for the meaning
+// of stop.
 func NewInterval(start, stop int) Interval {
 	return Interval{
 		Start: start,
@@ -36,11 +42,39 @@ func (i Interval) String() string {
 	return strconv.Itoa(i.Start) + ".." + strconv.Itoa(i.Stop-1)
 }
 
-// Length returns the length of the interval.
+// Length returns the number of values in the interval, which is zero or less if the interval is empty.
 func (i Interval) Length() int {
 	return i.Stop - i.Start
 }
 
+// Union returns the smallest interval that contains both i and other, including any values between them
+// if they are disjoint.
+func (i Interval) Union(other Interval) Interval {
+	return NewInterval(intMin(i.Start, other.Start), intMax(i.Stop, other.Stop))
+}
+
+// Intersection returns the interval of the values that are in both i and other. If the two are disjoint,
+// the result is an empty interval, with a Length of zero.
+func (i Interval) Intersection(other Interval) Interval {
+	start := intMax(i.Start, other.Start)
+	stop := intMin(i.Stop, other.Stop)
+	if stop < start {
+		stop = start
+	}
+	return NewInterval(start, stop)
+}
+
+// Disjoint returns true if i and other have no values in common.
+func (i Interval) Disjoint(other Interval) bool {
+	return i.Stop <= other.Start || other.Stop <= i.Start
+}
+
+// Adjacent returns true if i and other are disjoint but touch, so that their union contains no values
+// that are in neither of them.
+func (i Interval) Adjacent(other Interval) bool {
+	return i.Stop == other.Start || other.Stop == i.Start
+}
+
 // IntervalSet represents a collection of [Intervals], which may be read-only.
 type IntervalSet struct {
 	intervals []Interval
@@ -80,6 +114,21 @@ func (i *IntervalSet) first() int {
 	return i.intervals[0].Start
 }
 
+// AddOne adds the single value v to the set.
+func (i *IntervalSet) AddOne(v int) {
+	i.addOne(v)
+}
+
+// AddRange adds the values from l to h inclusive to the set.
+func (i *IntervalSet) AddRange(l, h int) {
+	i.addRange(l, h)
+}
+
+// Contains returns true if item is in the set.
+func (i *IntervalSet) Contains(item int) bool {
+	return i.contains(item)
+}
+
 func (i *IntervalSet) addOne(v int) {
 	i.addInterval(NewInterval(v, v+1))
 }
@@ -225,10 +274,15 @@ func (i *IntervalSet) removeOne(v int) {
 }
 
 func (i *IntervalSet) String() string {
-	return i.StringVerbose(nil, nil, false)
+	return i.StringWithNames(nil, nil, false)
 }
 
-func (i *IntervalSet) StringVerbose(literalNames []string, symbolicNames []string, elemsAreChar bool) string {
+// StringWithNames renders the set with the token names given, or as characters if elemsAreChar is true, or
+// else as numbers.
+//
+// Deprecated: StringWithNames is the former StringVerbose, kept for code that passes the names directly. Use
+// [IntervalSet.StringVerbose], which names the tokens of a vocabulary.
+func (i *IntervalSet) StringWithNames(literalNames []string, symbolicNames []string, elemsAreChar bool) string {
 
 	if i.intervals == nil {
 		return "{}"
@@ -241,6 +295,58 @@ func (i *IntervalSet) StringVerbose(literalNames []string, symbolicNames []strin
 	return i.toIndexString()
 }
 
+// StringVerbose renders the set as a set of token types, such as {',', ')', ID..STRING}, for use in
+// diagnostics. Each type is named by its literal name if it has one, as punctuation and keywords have, or
+// else by its symbolic name, or else by its number, with the names looked up in vocabulary, typically the
+// parser or lexer, which may be nil. If elideRanges is true, a range of three or more consecutive types is
+// shown by its first and last types only; otherwise every type in the set is listed.
+func (i *IntervalSet) StringVerbose(vocabulary Recognizer, elideRanges bool) string {
+	if len(i.intervals) == 0 {
+		return "{}"
+	}
+	var literalNames, symbolicNames []string
+	if vocabulary != nil {
+		literalNames, symbolicNames = vocabulary.GetLiteralNames(), vocabulary.GetSymbolicNames()
+	}
+	name := func(a int) string {
+		return vocabularyName(a, literalNames, symbolicNames)
+	}
+
+	names := make([]string, 0, len(i.intervals))
+	elements := 0
+	for _, v := range i.intervals {
+		if elideRanges && v.Length() > 2 {
+			names = append(names, name(v.Start)+".."+name(v.Stop-1))
+			elements += v.Length()
+			continue
+		}
+		for a := v.Start; a < v.Stop; a++ {
+			names = append(names, name(a))
+			elements++
+		}
+	}
+	if elements > 1 {
+		return "{" + strings.Join(names, ", ") + "}"
+	}
+	return names[0]
+}
+
+// vocabularyName returns the name of token type a, which is its literal name if it has one, or else its
+// symbolic name, or else its number.
+func vocabularyName(a int, literalNames, symbolicNames []string) string {
+	switch {
+	case a == TokenEOF:
+		return "<EOF>"
+	case a == TokenEpsilon:
+		return "<EPSILON>"
+	case a >= 0 && a < len(literalNames) && literalNames[a] != "":
+		return literalNames[a]
+	case a >= 0 && a < len(symbolicNames) && symbolicNames[a] != "":
+		return symbolicNames[a]
+	}
+	return strconv.Itoa(a)
+}
+
 func (i *IntervalSet) GetIntervals() []Interval {
 	return i.intervals
 }
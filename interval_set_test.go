@@ -0,0 +1,105 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIntervalSetStringVerbose(t *testing.T) {
+	vocabulary := NewBaseParser(nil)
+	vocabulary.LiteralNames = []string{"", "','", "')'", "", ""}
+	vocabulary.SymbolicNames = []string{"", "COMMA", "RPAREN", "ID", "STRING"}
+
+	set := NewIntervalSet()
+	set.AddRange(1, 4)
+	set.AddOne(TokenEOF)
+	set.AddOne(40)
+
+	tests := []struct {
+		set         *IntervalSet
+		vocabulary  Recognizer
+		elideRanges bool
+		want        string
+	}{
+		{set, vocabulary, true, "{<EOF>, ','..STRING, 40}"},
+		{set, vocabulary, false, "{<EOF>, ',', ')', ID, STRING, 40}"},
+		{set, nil, true, "{<EOF>, 1..4, 40}"},
+		{NewIntervalSet(), vocabulary, true, "{}"},
+	}
+	for _, test := range tests {
+		if got := test.set.StringVerbose(test.vocabulary, test.elideRanges); got != test.want {
+			t.Errorf("StringVerbose(%v) = %q, want %q", test.elideRanges, got, test.want)
+		}
+	}
+
+	one := NewIntervalSet()
+	one.AddOne(3)
+	if got := one.StringVerbose(vocabulary, true); got != "ID" {
+		t.Errorf("StringVerbose of one type = %q, want ID", got)
+	}
+	pair := NewIntervalSet()
+	pair.AddRange(2, 3)
+	if got := pair.StringVerbose(vocabulary, true); got != "{')', ID}" {
+		t.Errorf("StringVerbose of two types = %q, want {')', ID}", got)
+	}
+}
+
+func TestIntervalSetStringWithNames(t *testing.T) {
+	set := NewIntervalSet()
+	set.AddRange(1, 2)
+	if got := set.StringWithNames([]string{"", "'a'", "'b'"}, nil, false); got != "{'a', 'b'}" {
+		t.Errorf("StringWithNames with names = %q, want {'a', 'b'}", got)
+	}
+	if got := set.StringWithNames(nil, nil, false); got != "1..2" || got != set.String() {
+		t.Errorf("StringWithNames without names = %q, want 1..2", got)
+	}
+}
+
+func TestInterval(t *testing.T) {
+	a, b, c := NewInterval(1, 4), NewInterval(4, 6), NewInterval(7, 9)
+	if got := a.Union(c); got != NewInterval(1, 9) {
+		t.Errorf("Union() = %s", got)
+	}
+	if got := a.Intersection(NewInterval(2, 8)); got != NewInterval(2, 4) {
+		t.Errorf("Intersection() = %s", got)
+	}
+	if got := a.Intersection(c); got.Length() != 0 {
+		t.Errorf("Intersection() of disjoint intervals = %s", got)
+	}
+	if !a.Disjoint(b) || !a.Adjacent(b) || b.Adjacent(c) || !b.Disjoint(c) || a.Disjoint(NewInterval(3, 5)) {
+		t.Error("intervals 1..3 and 4..5 are not disjoint and adjacent, or 4..5 and 7..8 are adjacent")
+	}
+}
+
+func TestInputStreamGetTextChecked(t *testing.T) {
+	input := NewInputStream("abc")
+	tests := []struct {
+		start, stop int
+		want        string
+		valid       bool
+	}{
+		{0, 2, "abc", true},
+		{1, 1, "b", true},
+		{1, 0, "", true},
+		{2, 10, "c", true},
+		{3, 5, "", true},
+		{-1, 1, "", false},
+		{2, 0, "", false},
+	}
+	for _, test := range tests {
+		got, err := input.GetTextChecked(test.start, test.stop)
+		if got != test.want || (err == nil) != test.valid || (err != nil && !errors.Is(err, ErrInvalidInterval)) {
+			t.Errorf("GetTextChecked(%d, %d) = %q, %v", test.start, test.stop, got, err)
+		}
+		if text := input.GetText(test.start, test.stop); text != test.want {
+			t.Errorf("GetText(%d, %d) = %q", test.start, test.stop, text)
+		}
+	}
+	if got, err := input.GetTextFromIntervalChecked(NewInterval(0, 1)); got != "ab" || err != nil {
+		t.Errorf("GetTextFromIntervalChecked(0..1) = %q, %v", got, err)
+	}
+}
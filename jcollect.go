@@ -254,6 +254,12 @@ func (s *JStore[T, C]) Each(f func(T) bool) {
 	}
 }
 
+// clear removes every value from the store, keeping the space it has allocated.
+func (s *JStore[T, C]) clear() {
+	clear(s.store)
+	s.len = 0
+}
+
 func (s *JStore[T, C]) Len() int {
 	return s.len
 }
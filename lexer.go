@@ -6,6 +6,7 @@ package antlr
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 )
 
@@ -48,6 +49,19 @@ type BaseLexer struct {
 	modeStack              IntStack
 	mode                   int
 	text                   string
+
+	// startMode is the mode the lexer was in when it began to match the current token
+	startMode int
+
+	// eofToken is the EOF token last emitted, which EmitEOF reuses while cacheEOF is true
+	cacheEOF bool
+	eofToken Token
+
+	// matchState is the mode and the mode stack before the current match, and emptyStates the states the
+	// lexer has been in at emptyIndex, the index of the last match that consumed nothing, or -1
+	matchState  lineLexerState
+	emptyIndex  int
+	emptyStates []lineLexerState
 }
 
 func NewBaseLexer(input CharStream) *BaseLexer {
@@ -64,6 +78,8 @@ func NewBaseLexer(input CharStream) *BaseLexer {
 
 	lexer.Interpreter = nil // child classes must populate it
 
+	lexer.emptyIndex = -1
+
 	// The goal of all lexer rules/methods is to create a token object.
 	// l is an instance variable as multiple rules may collaborate to
 	// create a single token. NextToken will return l object after
@@ -106,17 +122,30 @@ func NewBaseLexer(input CharStream) *BaseLexer {
 }
 
 const (
+	// LexerDefaultMode is the mode the lexer starts in, named DEFAULT_MODE in grammars. Other modes are
+	// numbered from 1 in the order they are declared, see [BaseRecognizer.ModeName].
 	LexerDefaultMode = 0
-	LexerMore        = -2
-	LexerSkip        = -3
+
+	// LexerMore is the token type set by the more command, which makes the lexer continue matching and
+	// include the text matched so far in the next token
+	LexerMore = -2
+
+	// LexerSkip is the token type set by the skip command, which makes the lexer discard the text matched
+	LexerSkip = -3
 )
 
 //goland:noinspection GoUnusedConst
 const (
+	// LexerDefaultTokenChannel is the channel named DEFAULT_TOKEN_CHANNEL in grammars. Channels declared in
+	// a grammar's channels section are numbered from 2 in the order they are declared, see
+	// [BaseRecognizer.ChannelName].
 	LexerDefaultTokenChannel = TokenDefaultChannel
-	LexerHidden              = TokenHiddenChannel
-	LexerMinCharValue        = 0x0000
-	LexerMaxCharValue        = 0x10FFFF
+
+	// LexerHidden is the channel named HIDDEN in grammars
+	LexerHidden = TokenHiddenChannel
+
+	LexerMinCharValue = 0x0000
+	LexerMaxCharValue = 0x10FFFF
 )
 
 func (b *BaseLexer) Reset() {
@@ -135,6 +164,7 @@ func (b *BaseLexer) Reset() {
 	b.hitEOF = false
 	b.mode = LexerDefaultMode
 	b.modeStack = make([]int, 0)
+	b.emptyIndex = -1
 
 	b.Interpreter.reset()
 }
@@ -159,8 +189,31 @@ func (b *BaseLexer) GetTokenFactory() TokenFactory {
 	return b.factory
 }
 
+// SetTokenFactory sets the factory the lexer creates its tokens with, such as a [CommonTokenFactory] that
+// copies the text of each token from the input, as the tokens of an [UnbufferedCharStream] need.
+func (b *BaseLexer) SetTokenFactory(f TokenFactory) {
+	b.setTokenFactory(f)
+}
+
 func (b *BaseLexer) setTokenFactory(f TokenFactory) {
 	b.factory = f
+	b.eofToken = nil
+}
+
+// SetArena causes the lexer to allocate its tokens, and the configurations and prediction contexts
+// created by its ATN simulator, from the given [Arena]. Passing nil reverts to the default token factory and
+// heap allocation. This must be called after the lexer is constructed, but before the
+// first token is requested.
+func (b *BaseLexer) SetArena(arena *Arena) {
+	if arena == nil {
+		b.factory = CommonTokenFactoryDEFAULT
+	} else {
+		b.factory = NewArenaTokenFactory(arena, false)
+	}
+	b.eofToken = nil
+	if sim, ok := b.Interpreter.(*LexerATNSimulator); ok {
+		sim.SetArena(arena)
+	}
 }
 
 func (b *BaseLexer) safeMatch() (ret int) {
@@ -197,17 +250,36 @@ func (b *BaseLexer) NextToken() Token {
 			b.EmitEOF()
 			return b.token
 		}
+		// Step over white space and the like without matching it, when the rules that skip it allow
+		if sim, ok := b.Interpreter.(*LexerATNSimulator); ok && sim.skipRun(b.input, b.mode) {
+			if b.input.LA(1) == TokenEOF {
+				b.hitEOF = true
+			}
+			continue
+		}
 		b.token = nil
 		b.channel = TokenDefaultChannel
 		b.TokenStartCharIndex = b.input.Index()
 		b.TokenStartColumn = b.Interpreter.GetCharPositionInLine()
 		b.TokenStartLine = b.Interpreter.GetLine()
+		b.startMode = b.mode
 		b.text = ""
 		continueOuter := false
 		for {
 			b.thetype = TokenInvalidType
 
+			index := b.input.Index()
+			b.matchState.mode = b.mode
+			b.matchState.stack = append(b.matchState.stack[:0], b.modeStack...)
 			ttype := b.safeMatch() // Defaults to LexerSkip
+			if b.input.Index() != index {
+				b.emptyIndex = -1
+			} else if b.input.LA(1) != TokenEOF && b.emptyMatchLoops(index) {
+				// Nothing was consumed, and the lexer is back in a mode it was in here, so matching again
+				// would loop forever
+				b.recoverFromLoop()
+				ttype = LexerSkip
+			}
 
 			if b.input.LA(1) == TokenEOF {
 				b.hitEOF = true
@@ -316,13 +388,43 @@ func (b *BaseLexer) Emit() Token {
 
 // EmitEOF emits an EOF token. By default, this is the last token emitted
 func (b *BaseLexer) EmitEOF() Token {
+	if b.cacheEOF && b.eofToken != nil && b.eofToken.GetSource() == b.tokenFactorySourcePair &&
+		b.eofToken.GetStart() == b.input.Index() {
+		b.EmitToken(b.eofToken)
+		return b.eofToken
+	}
 	cpos := b.GetCharPositionInLine()
 	lpos := b.GetLine()
 	eof := b.factory.Create(b.tokenFactorySourcePair, TokenEOF, "", TokenDefaultChannel, b.input.Index(), b.input.Index()-1, lpos, cpos)
 	b.EmitToken(eof)
+	if _, arena := b.factory.(*ArenaTokenFactory); b.cacheEOF && !arena {
+		b.eofToken = eof
+	}
 	return eof
 }
 
+// SetEOFTokenCaching turns caching of the EOF token on or off. While it is on, the lexer creates its EOF
+// token once for its input, and returns that same token each time it is asked for another token at the end
+// of the input, as when a token stream is refilled, or the lexer is reset to lex the same input again for
+// another parse, rather than creating an identical one each time. The token is created anew if the lexer is
+// given different input or a different token factory. Tokens allocated from an [Arena] are never cached,
+// as the arena may be reset under them.
+//
+// The cached token is shared by everything that takes tokens from the lexer, so it must be treated as
+// read-only, apart from its token index, which each [CommonTokenStream] sets as it fetches the token.
+func (b *BaseLexer) SetEOFTokenCaching(cache bool) {
+	b.cacheEOF = cache
+	if !cache {
+		b.eofToken = nil
+	}
+}
+
+// IsAtEOF returns true if the lexer has reached the end of its input, so that every token it returns from
+// now on is the EOF token.
+func (b *BaseLexer) IsAtEOF() bool {
+	return b.hitEOF || b.input == nil || b.input.LA(1) == TokenEOF
+}
+
 // GetCharPositionInLine returns the current position in the current line as far as the lexer is concerned.
 func (b *BaseLexer) GetCharPositionInLine() int {
 	return b.Interpreter.GetCharPositionInLine()
@@ -364,6 +466,23 @@ func (b *BaseLexer) GetATN() *ATN {
 	return b.Interpreter.ATN()
 }
 
+// tokenStartMode returns the mode that the lexer was in when it began to match the most recent token.
+func (b *BaseLexer) tokenStartMode() int {
+	return b.startMode
+}
+
+// modeState returns the current mode of the lexer, and the stack of modes saved by [PushMode], outermost
+// first.
+func (b *BaseLexer) modeState() (int, []int) {
+	return b.mode, append([]int(nil), b.modeStack...)
+}
+
+// setModeState restores the mode and the stack of saved modes returned by modeState.
+func (b *BaseLexer) setModeState(mode int, stack []int) {
+	b.mode = mode
+	b.modeStack = append(IntStack(nil), stack...)
+}
+
 // GetAllTokens returns a list of all [Token] objects in input char stream.
 // Forces a load of all tokens that can be made from the input char stream.
 //
@@ -379,11 +498,53 @@ func (b *BaseLexer) GetAllTokens() []Token {
 	return tokens
 }
 
+// lineLexerState is the state of a lexer between tokens: its mode, and the stack of modes it saved.
+type lineLexerState struct {
+	mode  int
+	stack []int
+}
+
+func (s lineLexerState) equals(o lineLexerState) bool {
+	return s.mode == o.mode && slices.Equal(s.stack, o.stack)
+}
+
+// emptyMatchLoops records a match at index that consumed nothing, and returns true if it left the lexer in
+// a mode, with a mode stack, that the lexer was already in at index, so that matching again would loop. An
+// empty match that only changes the mode or the mode stack, as a rule whose action pushes or pops a mode
+// does, is not a loop, as the lexer matches in another mode next.
+func (b *BaseLexer) emptyMatchLoops(index int) bool {
+	if index != b.emptyIndex {
+		b.emptyIndex = index
+		b.emptyStates = append(b.emptyStates[:0], lineLexerState{mode: b.matchState.mode, stack: slices.Clone(b.matchState.stack)})
+	}
+	current := lineLexerState{mode: b.mode, stack: b.modeStack}
+	for _, s := range b.emptyStates {
+		if s.equals(current) {
+			b.emptyIndex = -1
+			return true
+		}
+	}
+	b.emptyStates = append(b.emptyStates, lineLexerState{mode: b.mode, stack: slices.Clone(b.modeStack)})
+	return false
+}
+
+// recoverFromLoop reports that a match consumed no input, which would make the lexer loop, and skips
+// a character.
+func (b *BaseLexer) recoverFromLoop() {
+	e := NewLoopDetectedException(b.Virt, b.input, b.GetATN().modeToStartState[b.mode].GetStateNumber())
+	b.notifyListeners(e)
+	b.Recover(e)
+	b.thetype = LexerSkip
+}
+
 func (b *BaseLexer) notifyListeners(e RecognitionException) {
 	start := b.TokenStartCharIndex
 	stop := b.input.Index()
 	text := b.input.GetTextFromInterval(NewInterval(start, stop))
 	msg := "token recognition error at: '" + text + "'"
+	if _, ok := e.(*LoopDetectedException); ok {
+		msg = e.GetMessage()
+	}
 	listener := b.GetErrorListenerDispatch()
 	listener.SyntaxError(b, nil, b.TokenStartLine, b.TokenStartColumn, msg, e)
 }
@@ -391,15 +552,8 @@ func (b *BaseLexer) notifyListeners(e RecognitionException) {
 func (b *BaseLexer) getErrorDisplayForChar(c rune) string {
 	if c == TokenEOF {
 		return "<EOF>"
-	} else if c == '\n' {
-		return "\\n"
-	} else if c == '\t' {
-		return "\\t"
-	} else if c == '\r' {
-		return "\\r"
-	} else {
-		return string(c)
 	}
+	return EscapeText(string(c), EscapeLineBreaksAndTabs)
 }
 
 func (b *BaseLexer) getCharErrorDisplay(c rune) string {
@@ -415,12 +569,59 @@ func (b *BaseLexer) getCharErrorDisplay(c rune) string {
 // a character that makes no sense to the recognizer.
 func (b *BaseLexer) Recover(re RecognitionException) {
 	if b.input.LA(1) != TokenEOF {
-		if _, ok := re.(*LexerNoViableAltException); ok {
+		switch re.(type) {
+		case *LexerNoViableAltException, *LoopDetectedException:
 			// Skip a char and try again
 			b.Interpreter.Consume(b.input)
-		} else {
+		default:
 			// TODO: Do we lose character or line position information?
 			b.input.Consume()
 		}
 	}
 }
+
+// lexerInput is the input of a lexer and where the lexer is in it between tokens, which replacing the input
+// loses.
+type lexerInput struct {
+	input        CharStream
+	mode         int
+	stack        []int
+	hitEOF       bool
+	line, column int
+}
+
+// saveInput returns the input of the lexer and where it is in it, for restoreInput to give back after the
+// lexer has been given other input.
+func (b *BaseLexer) saveInput() lexerInput {
+	mode, stack := b.modeState()
+	return lexerInput{
+		input:  b.input,
+		mode:   mode,
+		stack:  stack,
+		hitEOF: b.hitEOF,
+		line:   b.GetLine(),
+		column: b.GetCharPositionInLine(),
+	}
+}
+
+// restoreInput gives the lexer back the input saved by saveInput, so that it goes on from where it was. The
+// input itself is not moved.
+func (b *BaseLexer) restoreInput(saved lexerInput) {
+	b.input = nil
+	b.Reset()
+	b.input = saved.input
+	b.tokenFactorySourcePair = &TokenSourceCharStreamPair{b, b.input}
+	b.setModeState(saved.mode, saved.stack)
+	b.hitEOF = saved.hitEOF
+	b.setPosition(saved.line, saved.column)
+}
+
+// setPosition sets the line and column the lexer is at, for lexing that begins part way through the input,
+// as given by GetLine and GetCharPositionInLine. It has no effect unless the interpreter of the lexer is a
+// [LexerATNSimulator].
+func (b *BaseLexer) setPosition(line, column int) {
+	if sim, ok := b.Interpreter.(*LexerATNSimulator); ok {
+		sim.Line = line
+		sim.CharPositionInLine = column
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lexerAlphabet is an equivalence class compression of the input alphabet of a lexer [ATN]. Two runes
+// are in the same class if every transition in the ATN either matches both of them or matches neither,
+// and so they must lead to the same [DFA] state from any DFA state.
+//
+// The lexer DFA edges are indexed by class rather than by rune. Even grammars that use large Unicode
+// sets usually have only a few dozen classes, so the edge tables are small, and every rune, not just
+// ASCII, can be cached in the DFA.
+type lexerAlphabet struct {
+	// ascii holds the class of each rune below 128, which are by far the most common
+	// in practice and so are looked up directly.
+	ascii [128]int
+
+	// starts holds the first rune of each contiguous segment of the alphabet, in
+	// ascending order, and classes holds the class of the corresponding segment.
+	starts  []int
+	classes []int
+
+	// members holds the runes in each class
+	members []*IntervalSet
+}
+
+// newLexerAlphabet computes the equivalence classes of the input alphabet of the given lexer [ATN].
+func newLexerAlphabet(atn *ATN) *lexerAlphabet {
+	var matching []Transition
+	bounds := map[int]bool{0: true}
+	for _, s := range atn.states {
+		if s == nil {
+			continue
+		}
+		for _, trans := range s.GetTransitions() {
+			switch trans.getSerializationType() {
+			case TransitionATOM, TransitionRANGE, TransitionSET, TransitionNOTSET, TransitionWILDCARD:
+				matching = append(matching, trans)
+				if label := trans.getLabel(); label != nil {
+					for _, v := range label.intervals {
+						bounds[v.Start] = true
+						bounds[v.Stop] = true
+					}
+				}
+			}
+		}
+	}
+
+	starts := make([]int, 0, len(bounds))
+	for b := range bounds {
+		if b >= 0 && b <= LexerMaxCharValue {
+			starts = append(starts, b)
+		}
+	}
+	sort.Ints(starts)
+
+	// Every rune within a segment matches the same transitions. Segments that match the
+	// same transitions, contiguous or not, are in the same class.
+	a := &lexerAlphabet{
+		starts:  starts,
+		classes: make([]int, len(starts)),
+	}
+	index := make(map[string]int)
+	var sig strings.Builder
+	for i, start := range starts {
+		stop := LexerMaxCharValue + 1
+		if i+1 < len(starts) {
+			stop = starts[i+1]
+		}
+		sig.Reset()
+		for j, trans := range matching {
+			if trans.Matches(start, 0, LexerMaxCharValue) {
+				sig.WriteString(strconv.Itoa(j))
+				sig.WriteByte(',')
+			}
+		}
+		key := sig.String()
+		c, ok := index[key]
+		if !ok {
+			c = len(a.members)
+			index[key] = c
+			a.members = append(a.members, NewIntervalSet())
+		}
+		a.classes[i] = c
+		a.members[c].addRange(start, stop-1)
+	}
+
+	for t := range a.ascii {
+		a.ascii[t] = a.lookup(t)
+	}
+	return a
+}
+
+// size returns the number of classes in the alphabet
+func (a *lexerAlphabet) size() int {
+	return len(a.members)
+}
+
+// classOf returns the class of the given rune, or -1 if it is outside the alphabet, which
+// is the case for EOF.
+func (a *lexerAlphabet) classOf(t int) int {
+	if t >= 0 && t < len(a.ascii) {
+		return a.ascii[t]
+	}
+	if t < 0 || t > LexerMaxCharValue {
+		return -1
+	}
+	return a.lookup(t)
+}
+
+func (a *lexerAlphabet) lookup(t int) int {
+	i := sort.SearchInts(a.starts, t+1) - 1
+	return a.classes[i]
+}
+
+// representative returns a rune that belongs to the given class
+func (a *lexerAlphabet) representative(c int) int {
+	return a.members[c].first()
+}
+
+// getLexerAlphabet returns the equivalence class compression of the input alphabet for this lexer [ATN],
+// computing it the first time it is requested.
+func (a *ATN) getLexerAlphabet() *lexerAlphabet {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.lexerAlphabet == nil {
+		a.lexerAlphabet = newLexerAlphabet(a)
+	}
+	return a.lexerAlphabet
+}
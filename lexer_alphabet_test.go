@@ -0,0 +1,62 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLexerAlphabet(t *testing.T) {
+	listLexerStatic.init(listLexerSerialized)
+	a := newLexerAlphabet(listLexerStatic.atn)
+
+	// [a-z], '+', ' ' and every other rune
+	if a.size() != 4 {
+		t.Fatalf("%d classes, want 4", a.size())
+	}
+	letter, plus, space, other := a.classOf('a'), a.classOf('+'), a.classOf(' '), a.classOf('A')
+	if len(map[int]bool{letter: true, plus: true, space: true, other: true}) != 4 {
+		t.Errorf("classes %d, %d, %d and %d are not distinct", letter, plus, space, other)
+	}
+	for _, r := range []int{'m', 'z'} {
+		if a.classOf(r) != letter {
+			t.Errorf("%q is not in the class of 'a'", r)
+		}
+	}
+	for _, r := range []int{0, '!', '{', 'é', 0x1F600, LexerMaxCharValue} {
+		if a.classOf(r) != other {
+			t.Errorf("%q is not in the class of 'A'", r)
+		}
+	}
+	if a.classOf(TokenEOF) != -1 || a.classOf(LexerMaxCharValue+1) != -1 {
+		t.Error("EOF is in a class")
+	}
+	if got := a.members[letter].String(); got != "97..122" {
+		t.Errorf("the class of 'a' is %s", got)
+	}
+	for c := 0; c < a.size(); c++ {
+		if a.classOf(a.representative(c)) != c {
+			t.Errorf("the representative of class %d is not in it", c)
+		}
+	}
+}
+
+func TestLexerDFAEdgesByClass(t *testing.T) {
+	lexer := newListLexer(nil)
+	decisionToDFA := newDFA(lexer.GetATN())
+	lexer.Interpreter = NewLexerATNSimulator(lexer, lexer.GetATN(), decisionToDFA, NewPredictionContextCache())
+
+	// Every letter, and every rune that is not matched, shares the edge of its class
+	listTokens(lexer, "abc xyz+é")
+	dfa := decisionToDFA[0].ToLexerString()
+	if !strings.Contains(dfa, "s0-'a'..'z'->:s1=>1") || strings.Contains(dfa, "-'b'") {
+		t.Errorf("the letters have an edge each:\n%s", dfa)
+	}
+	states := decisionToDFA[0].Len()
+	if listTokens(lexer, "éèq+r"); decisionToDFA[0].Len() != states {
+		t.Errorf("the DFA has new states for runes of known classes:\n%s", decisionToDFA[0].ToLexerString())
+	}
+}
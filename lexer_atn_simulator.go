@@ -12,8 +12,11 @@ import (
 
 //goland:noinspection GoUnusedGlobalVariable
 var (
+	// Deprecated: The lexer DFA edges are now indexed by equivalence classes of the input
+	// alphabet, which cover every rune, so these bounds are no longer used.
 	LexerATNSimulatorMinDFAEdge = 0
-	LexerATNSimulatorMaxDFAEdge = 127 // forces unicode to stay in ATN
+	// Deprecated: See LexerATNSimulatorMinDFAEdge.
+	LexerATNSimulatorMaxDFAEdge = 127
 
 	LexerATNSimulatorMatchCalls = 0
 )
@@ -29,6 +32,20 @@ type ILexerATNSimulator interface {
 	Consume(input CharStream)
 }
 
+// ILexerATNSimulatorDFA is implemented by the lexer ATN simulators, such as [LexerATNSimulator], whose DFA
+// can be cleared and whose diagnostics can be collected. It is kept apart from [ILexerATNSimulator], so that
+// other implementations of that interface need not provide it; assert for it on the simulator of a lexer.
+//
+// Use:
+//
+//	if sim, ok := lexer.GetInterpreter().(antlr.ILexerATNSimulatorDFA); ok {
+//	    sim.ClearDFA()
+//	}
+type ILexerATNSimulatorDFA interface {
+	ClearDFA()
+	GetDiagnostics() []DecisionDiagnostics
+}
+
 type LexerATNSimulator struct {
 	BaseATNSimulator
 
@@ -41,6 +58,11 @@ type LexerATNSimulator struct {
 	mode               int
 	prevAccept         *SimState
 	MatchCalls         int
+	precomputing       bool
+	alphabet           *lexerAlphabet
+
+	// skipLoops holds the skip loops of each mode, once the lexer has looked them up, see skipRun
+	skipLoops []*lexerSkipLoop
 }
 
 func NewLexerATNSimulator(recog Lexer, atn *ATN, decisionToDFA []*DFA, sharedContextCache *PredictionContextCache) *LexerATNSimulator {
@@ -73,6 +95,14 @@ func NewLexerATNSimulator(recog Lexer, atn *ATN, decisionToDFA []*DFA, sharedCon
 	// info
 	l.prevAccept = NewSimState()
 
+	// The DFA edges are indexed by the equivalence class of the input rune
+	// rather than by the rune itself.
+	l.alphabet = atn.getLexerAlphabet()
+
+	if runtimeConfig.lexerDFAPrecompute {
+		l.PrecomputeDFA()
+	}
+
 	return l
 }
 
@@ -94,13 +124,12 @@ func (l *LexerATNSimulator) Match(input CharStream, mode int) int {
 
 	l.startIndex = input.Index()
 	l.prevAccept.reset()
+	l.resetClosure()
 
 	dfa := l.decisionToDFA[mode]
 
-	var s0 *DFAState
-	l.atn.stateMu.RLock()
-	s0 = dfa.getS0()
-	l.atn.stateMu.RUnlock()
+	// The start state is published atomically, so it is read without locking
+	s0 := dfa.getS0()
 
 	if s0 == nil {
 		return l.MatchATN(input)
@@ -193,6 +222,11 @@ func (l *LexerATNSimulator) execATN(input CharStream, ds0 *DFAState) int {
 				break
 			}
 		}
+		// If the target loops back to itself, as in identifiers and white space, consume the rest of the
+		// loop in one go rather than one DFA step at a time.
+		if target == s && l.scanLoop(input, s) && s.isAcceptState {
+			l.captureSimState(l.prevAccept, input, s)
+		}
 		t = input.LA(1)
 		s = target // flip current DFA target becomes new src/from state
 	}
@@ -200,6 +234,39 @@ func (l *LexerATNSimulator) execATN(input CharStream, ds0 *DFAState) int {
 	return l.failOrAccept(l.prevAccept, input, s.configs, t)
 }
 
+// scanLoop consumes the characters that follow in input for as long as the DFA state s has an edge for them
+// back to itself, and returns true if it consumed any. This is the bulk path for the loops, such as
+// [a-zA-Z0-9_]* and [ \t\r\n]+, that dominate lexing: it reads the runes of the input directly, and loads the
+// edges of s once for the whole loop, rather than once for each character. It applies only to the input
+// streams of the runtime, whose characters are the runes they hold, and does nothing for other streams.
+func (l *LexerATNSimulator) scanLoop(input CharStream, s *DFAState) bool {
+	is := runeInputStream(input)
+	if is == nil || runtimeConfig.lexerATNSimulatorDebug {
+		return false
+	}
+
+	edges := s.loadEdges()
+	i, line, pos := is.index, l.Line, l.CharPositionInLine
+	for ; i < is.size; i++ {
+		r := is.data[i]
+		c := l.alphabet.classOf(int(r))
+		if edges.edge(c) != s {
+			break
+		}
+		if r == '\n' {
+			line++
+			pos = 0
+		} else {
+			pos++
+		}
+	}
+	if i == is.index {
+		return false
+	}
+	is.index, l.Line, l.CharPositionInLine = i, line, pos
+	return true
+}
+
 // Get an existing target state for an edge in the DFA. If the target state
 // for the edge has not yet been computed or is otherwise not available,
 // l method returns {@code nil}.
@@ -210,16 +277,13 @@ func (l *LexerATNSimulator) execATN(input CharStream, ds0 *DFAState) int {
 // {@code t}, or {@code nil} if the target state for l edge is not
 // already cached
 func (l *LexerATNSimulator) getExistingTargetState(s *DFAState, t int) *DFAState {
-	if t < LexerATNSimulatorMinDFAEdge || t > LexerATNSimulatorMaxDFAEdge {
+	c := l.alphabet.classOf(t)
+	if c < 0 {
 		return nil
 	}
 
-	l.atn.edgeMu.RLock()
-	defer l.atn.edgeMu.RUnlock()
-	if s.getEdges() == nil {
-		return nil
-	}
-	target := s.getIthEdge(t - LexerATNSimulatorMinDFAEdge)
+	// The edges are set atomically, so they are read without locking
+	target := s.getIthEdge(c)
 	if runtimeConfig.lexerATNSimulatorDebug && target != nil {
 		fmt.Println("reuse state " + strconv.Itoa(s.stateNumber) + " edge to " + strconv.Itoa(target.stateNumber))
 	}
@@ -238,6 +302,7 @@ func (l *LexerATNSimulator) computeTargetState(input CharStream, s *DFAState, t
 	// if we don't find an existing DFA state
 	// Fill reach starting from closure, following t transitions
 	l.getReachableConfigSet(input, s.configs, reach, t)
+	l.noteConfigSet(l.mode, len(reach.configs))
 
 	if len(reach.configs) == 0 { // we got nowhere on t from s
 		if !reach.hasSemanticContext {
@@ -272,6 +337,13 @@ func (l *LexerATNSimulator) failOrAccept(prevAccept *SimState, input CharStream,
 //
 // Parameter reach is a return parameter.
 func (l *LexerATNSimulator) getReachableConfigSet(input CharStream, closure *ATNConfigSet, reach *ATNConfigSet, t int) {
+	l.reachableConfigSet(input, closure, reach, t, input.Index()-l.startIndex)
+}
+
+// reachableConfigSet is the implementation of getReachableConfigSet, where offset is the number of
+// characters matched so far in the current token. It is separate so that the DFA can be precomputed
+// without any input.
+func (l *LexerATNSimulator) reachableConfigSet(input CharStream, closure *ATNConfigSet, reach *ATNConfigSet, t int, offset int) {
 	// l is used to Skip processing for configs which have a lower priority
 	// than a runtimeConfig that already reached an accept state for the same rule
 	SkipAlt := ATNInvalidAltNumber
@@ -292,10 +364,10 @@ func (l *LexerATNSimulator) getReachableConfigSet(input CharStream, closure *ATN
 			if target != nil {
 				lexerActionExecutor := cfg.lexerActionExecutor
 				if lexerActionExecutor != nil {
-					lexerActionExecutor = lexerActionExecutor.fixOffsetBeforeMatch(input.Index() - l.startIndex)
+					lexerActionExecutor = lexerActionExecutor.fixOffsetBeforeMatch(offset)
 				}
 				treatEOFAsEpsilon := t == TokenEOF
-				config := NewLexerATNConfig3(cfg, target, lexerActionExecutor)
+				config := l.arena.newLexerATNConfig3(cfg, target, lexerActionExecutor)
 				if l.closure(input, config, reach,
 					currentAltReachedAcceptState, true, treatEOFAsEpsilon) {
 					// any remaining configs for l alt have a lower priority
@@ -332,7 +404,7 @@ func (l *LexerATNSimulator) computeStartState(input CharStream, p ATNState) *ATN
 	configs := NewOrderedATNConfigSet()
 	for i := 0; i < len(p.GetTransitions()); i++ {
 		target := p.GetTransitions()[i].getTarget()
-		cfg := NewLexerATNConfig6(target, i+1, BasePredictionContextEMPTY)
+		cfg := l.arena.newLexerATNConfig6(target, i+1, BasePredictionContextEMPTY)
 		l.closure(input, cfg, configs, false, false, false)
 	}
 
@@ -353,6 +425,15 @@ func (l *LexerATNSimulator) closure(input CharStream, config *ATNConfig, configs
 		fmt.Println("closure(" + config.String() + ")")
 	}
 
+	if !l.enterClosure(config) {
+		panic(NewLoopDetectedException(l.recog, input, config.state.GetStateNumber()))
+	}
+	defer l.leaveClosure()
+	l.noteClosure(l.mode)
+	if l.atn.observer != nil {
+		l.atn.observer(config.state, true)
+	}
+
 	_, ok := config.state.(*RuleStopState)
 	if ok {
 
@@ -370,7 +451,7 @@ func (l *LexerATNSimulator) closure(input CharStream, config *ATNConfig, configs
 				return true
 			}
 
-			configs.Add(NewLexerATNConfig2(config, config.state, BasePredictionContextEMPTY), nil)
+			configs.Add(l.arena.newLexerATNConfig2(config, config.state, BasePredictionContextEMPTY), nil)
 			currentAltReachedAcceptState = true
 		}
 		if config.context != nil && !config.context.isEmpty() {
@@ -378,7 +459,7 @@ func (l *LexerATNSimulator) closure(input CharStream, config *ATNConfig, configs
 				if config.context.getReturnState(i) != BasePredictionContextEmptyReturnState {
 					newContext := config.context.GetParent(i) // "pop" return state
 					returnState := l.atn.states[config.context.getReturnState(i)]
-					cfg := NewLexerATNConfig2(config, returnState, newContext)
+					cfg := l.arena.newLexerATNConfig2(config, returnState, newContext)
 					currentAltReachedAcceptState = l.closure(input, cfg, configs, currentAltReachedAcceptState, speculative, treatEOFAsEpsilon)
 				}
 			}
@@ -411,8 +492,8 @@ func (l *LexerATNSimulator) getEpsilonTarget(input CharStream, config *ATNConfig
 	if trans.getSerializationType() == TransitionRULE {
 
 		rt := trans.(*RuleTransition)
-		newContext := SingletonBasePredictionContextCreate(config.context, rt.followState.GetStateNumber())
-		cfg = NewLexerATNConfig2(config, trans.getTarget(), newContext)
+		newContext := l.arena.newSingletonContext(config.context, rt.followState.GetStateNumber())
+		cfg = l.arena.newLexerATNConfig2(config, trans.getTarget(), newContext)
 
 	} else if trans.getSerializationType() == TransitionPRECEDENCE {
 		panic("Precedence predicates are not supported in lexers.")
@@ -441,8 +522,13 @@ func (l *LexerATNSimulator) getEpsilonTarget(input CharStream, config *ATNConfig
 			fmt.Println("EVAL rule " + strconv.Itoa(trans.(*PredicateTransition).ruleIndex) + ":" + strconv.Itoa(pt.predIndex))
 		}
 		configs.hasSemanticContext = true
+		if l.precomputing {
+			// Predicates cannot be evaluated ahead of time, and the DFA state
+			// reached through them will not be cached anyway
+			return nil
+		}
 		if l.evaluatePredicate(input, pt.ruleIndex, pt.predIndex, speculative) {
-			cfg = NewLexerATNConfig4(config, trans.getTarget())
+			cfg = l.arena.newLexerATNConfig4(config, trans.getTarget())
 		}
 	} else if trans.getSerializationType() == TransitionACTION {
 		if config.context == nil || config.context.hasEmptyPath() {
@@ -459,19 +545,19 @@ func (l *LexerATNSimulator) getEpsilonTarget(input CharStream, config *ATNConfig
 			// additional modifications are needed before we can support
 			// the split operation.
 			lexerActionExecutor := LexerActionExecutorappend(config.lexerActionExecutor, l.atn.lexerActions[trans.(*ActionTransition).actionIndex])
-			cfg = NewLexerATNConfig3(config, trans.getTarget(), lexerActionExecutor)
+			cfg = l.arena.newLexerATNConfig3(config, trans.getTarget(), lexerActionExecutor)
 		} else {
 			// ignore actions in referenced rules
-			cfg = NewLexerATNConfig4(config, trans.getTarget())
+			cfg = l.arena.newLexerATNConfig4(config, trans.getTarget())
 		}
 	} else if trans.getSerializationType() == TransitionEPSILON {
-		cfg = NewLexerATNConfig4(config, trans.getTarget())
+		cfg = l.arena.newLexerATNConfig4(config, trans.getTarget())
 	} else if trans.getSerializationType() == TransitionATOM ||
 		trans.getSerializationType() == TransitionRANGE ||
 		trans.getSerializationType() == TransitionSET {
 		if treatEOFAsEpsilon {
 			if trans.Matches(TokenEOF, 0, LexerMaxCharValue) {
-				cfg = NewLexerATNConfig4(config, trans.getTarget())
+				cfg = l.arena.newLexerATNConfig4(config, trans.getTarget())
 			}
 		}
 	}
@@ -543,8 +629,14 @@ func (l *LexerATNSimulator) addDFAEdge(from *DFAState, tk int, to *DFAState, cfg
 		}
 	}
 	// add the edge
-	if tk < LexerATNSimulatorMinDFAEdge || tk > LexerATNSimulatorMaxDFAEdge {
-		// Only track edges within the DFA bounds
+	c := l.alphabet.classOf(tk)
+	if c < 0 {
+		// Only track edges within the alphabet, which excludes EOF
+		return to
+	}
+	if l.decisionToDFA[l.mode].precomputed {
+		// A precomputed DFA is read without locking, so it must never change. Any
+		// state created at runtime is still cached, but it gains no edges.
 		return to
 	}
 	if runtimeConfig.lexerATNSimulatorDebug {
@@ -552,11 +644,7 @@ func (l *LexerATNSimulator) addDFAEdge(from *DFAState, tk int, to *DFAState, cfg
 	}
 	l.atn.edgeMu.Lock()
 	defer l.atn.edgeMu.Unlock()
-	if from.getEdges() == nil {
-		// make room for tokens 1..n and -1 masquerading as index 0
-		from.setEdges(make([]*DFAState, LexerATNSimulatorMaxDFAEdge-LexerATNSimulatorMinDFAEdge+1))
-	}
-	from.setIthEdge(tk-LexerATNSimulatorMinDFAEdge, to) // connect
+	from.setIthEdge(c, to, l.alphabet.size()) // connect, making room for every class in the alphabet
 
 	return to
 }
@@ -602,6 +690,7 @@ func (l *LexerATNSimulator) addDFAState(configs *ATNConfigSet, suppressEdge bool
 		configs.configLookup = nil // Not needed now
 		proposed.configs = configs
 		dfa.Put(proposed)
+		l.noteDFAState(l.mode)
 	}
 	if !suppressEdge {
 		dfa.setS0(proposed)
@@ -652,6 +741,113 @@ func (l *LexerATNSimulator) GetTokenName(tt int) string {
 	return sb.String()
 }
 
+// PrecomputeDFA exhaustively computes the lexer [DFA] for every mode, so that lexing never needs to fall
+// back to [ATN] simulation. The work is bounded by the equivalence classes of the input alphabet, which
+// group runes that match exactly the same [ATN] transitions: each DFA state is expanded once per class.
+//
+// A mode whose DFA is fully computed is marked as complete and from then on is read without locking.
+// Modes that reach semantic predicates cannot be completed, as predicates must be evaluated against the
+// input; their DFA is populated as far as possible and continues to be extended, under lock, at runtime.
+//
+// The DFA is shared by all lexers for the same grammar, so this need only be called once: a mode that has
+// been expanded, whether or not it was completed, is not expanded again until [LexerATNSimulator.ClearDFA].
+// It must be called before any lexer sharing the DFA starts lexing. See also [WithLexerDFAPrecompute], which
+// calls this automatically.
+//
+// The func returns true if the DFA for every mode was completed.
+func (l *LexerATNSimulator) PrecomputeDFA() bool {
+	l.atn.mu.Lock()
+	defer l.atn.mu.Unlock()
+
+	savedMode := l.mode
+	l.precomputing = true
+	defer func() {
+		l.mode = savedMode
+		l.precomputing = false
+	}()
+
+	complete := true
+	for mode := range l.atn.modeToStartState {
+		dfa := l.decisionToDFA[mode]
+		if !dfa.expanded {
+			l.mode = mode
+			dfa.precomputed = l.precomputeMode(mode)
+			dfa.expanded = true
+		}
+		if !dfa.precomputed {
+			complete = false
+		}
+	}
+	return complete
+}
+
+// ClearDFA discards the DFA of each mode, which is shared by all lexers for the grammar, and the cache of
+// prediction contexts the lexer shares with them, so that the memory they hold can be reclaimed. The DFA is
+// then built up again as the lexer goes on, as it is by a new lexer, so a DFA that was precomputed, see
+// [LexerATNSimulator.PrecomputeDFA], is no longer complete. Call it between uses, when no lexer that shares
+// the DFA is lexing. See [ILexerATNSimulatorDFA] to call it through the simulator of a lexer.
+func (l *LexerATNSimulator) ClearDFA() {
+	l.clearDFA(l.decisionToDFA)
+}
+
+// precomputeMode performs a breadth first expansion of the DFA for the given mode, and returns
+// true if every edge of every reachable state could be computed.
+func (l *LexerATNSimulator) precomputeMode(mode int) (done bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*LoopDetectedException); !ok {
+				panic(r)
+			}
+			// Leave the looping state to be reported when the lexer reaches it
+			l.resetClosure()
+			done = false
+		}
+	}()
+
+	s0Closure := l.computeStartState(nil, l.atn.modeToStartState[mode])
+	if s0Closure.hasSemanticContext {
+		return false
+	}
+	s0 := l.addDFAState(s0Closure, true)
+	l.atn.stateMu.Lock()
+	l.decisionToDFA[mode].setS0(s0)
+	l.atn.stateMu.Unlock()
+
+	type pending struct {
+		state *DFAState
+		depth int // number of characters matched to reach state
+	}
+	work := []pending{{s0, 0}}
+	seen := map[*DFAState]bool{s0: true}
+	complete := true
+
+	for len(work) > 0 {
+		p := work[0]
+		work = work[1:]
+
+		for c := 0; c < l.alphabet.size(); c++ {
+			t := l.alphabet.representative(c)
+			reach := NewOrderedATNConfigSet()
+			l.reachableConfigSet(nil, p.state.configs, reach, t, p.depth)
+			if reach.hasSemanticContext {
+				complete = false
+				continue
+			}
+
+			target := ATNSimulatorError
+			if len(reach.configs) > 0 {
+				target = l.addDFAState(reach, true)
+			}
+			l.addDFAEdge(p.state, t, target, nil)
+			if target != ATNSimulatorError && !seen[target] {
+				seen[target] = true
+				work = append(work, pending{target, p.depth + 1})
+			}
+		}
+	}
+	return complete
+}
+
 func resetSimState(sim *SimState) {
 	sim.index = -1
 	sim.line = 0
@@ -0,0 +1,124 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"math/rand"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// opaqueCharStream hides the InputStream it wraps, so that the lexer cannot scan its runes in bulk.
+type opaqueCharStream struct {
+	CharStream
+}
+
+func TestLexerScanLoop(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	pieces := []string{"a", "xyz", strings.Repeat("q", 200), " ", "   ", "+", "é"}
+	for round := 0; round < 50; round++ {
+		var sb strings.Builder
+		for i := r.Intn(30); i > 0; i-- {
+			sb.WriteString(pieces[r.Intn(len(pieces))])
+		}
+		input := sb.String()
+
+		// the tokens are the same whether the loops are scanned in bulk or one character at a time
+		var want, got []string
+		for _, stream := range []CharStream{opaqueCharStream{NewInputStream(input)}, NewInputStream(input)} {
+			lexer := newListLexer(stream)
+			lexer.RemoveErrorListeners()
+			var tokens []string
+			for tok := lexer.NextToken(); ; tok = lexer.NextToken() {
+				tokens = append(tokens, tok.String())
+				if tok.GetTokenType() == TokenEOF {
+					break
+				}
+			}
+			want, got = got, tokens
+		}
+		if strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Fatalf("%q: tokens\n%s\nwant\n%s", input, strings.Join(got, "\n"), strings.Join(want, "\n"))
+		}
+	}
+
+	// once the DFA has the loop of an identifier, the rest of an identifier is consumed in one go
+	lexer := newListLexer(nil)
+	decisionToDFA := newDFA(lexer.GetATN())
+	sim := NewLexerATNSimulator(lexer, lexer.GetATN(), decisionToDFA, NewPredictionContextCache())
+	lexer.Interpreter = sim
+	listTokens(lexer, "abc")
+	id := decisionToDFA[0].getS0().getIthEdge(sim.alphabet.classOf('a'))
+	input := NewInputStream("bcd+e")
+	sim.CharPositionInLine = 0
+	if !sim.scanLoop(input, id) || input.Index() != 3 || sim.GetCharPositionInLine() != 3 {
+		t.Errorf("the loop stopped at %d, column %d", input.Index(), sim.GetCharPositionInLine())
+	}
+	if sim.scanLoop(input, id) || sim.scanLoop(opaqueCharStream{NewInputStream("bcd")}, id) {
+		t.Error("the loop consumed a character off its edges, or from a stream it cannot scan")
+	}
+}
+
+func TestLexerSkipRun(t *testing.T) {
+	lexer := newListLexer(nil)
+	sim := NewLexerATNSimulator(lexer, lexer.GetATN(), newDFA(lexer.GetATN()), NewPredictionContextCache())
+	lexer.Interpreter = sim
+
+	// WS : ' ' -> skip ; is a skip loop, and no other rule is
+	loops := sim.getSkipLoops()
+	if len(loops) != 1 || loops[0] == nil {
+		t.Fatalf("skip loops %v", loops)
+	}
+	for r, want := range map[rune]bool{' ': true, 'a': false, '+': false, 'é': false} {
+		if got := loops[0].loopOf[sim.alphabet.classOf(int(r))] >= 0; got != want {
+			t.Errorf("%q starts a skip loop: %v", r, got)
+		}
+	}
+
+	input := NewInputStream("   a  ")
+	if !sim.skipRun(input, LexerDefaultMode) || input.Index() != 3 || sim.GetCharPositionInLine() != 3 {
+		t.Errorf("the run stopped at %d, column %d", input.Index(), sim.GetCharPositionInLine())
+	}
+	if sim.skipRun(input, LexerDefaultMode) || sim.skipRun(opaqueCharStream{NewInputStream("  ")}, LexerDefaultMode) {
+		t.Error("the run stepped over a token that is not skipped, or over a stream it cannot scan")
+	}
+
+	// white space is stepped over without being matched, and the tokens are those of a stream that cannot be
+	// scanned
+	matches := func(stream CharStream) (int, string) {
+		lexer.SetInputStream(stream)
+		sim.MatchCalls = 0
+		var tokens []string
+		for tok := lexer.NextToken(); tok.GetTokenType() != TokenEOF; tok = lexer.NextToken() {
+			tokens = append(tokens, tok.String())
+		}
+		return sim.MatchCalls, strings.Join(tokens, " ")
+	}
+	const text = "a   b +  c    "
+	n, tokens := matches(NewInputStream(text))
+	opaqueN, opaqueTokens := matches(opaqueCharStream{NewInputStream(text)})
+	if n != 4 || opaqueN <= n || tokens != opaqueTokens {
+		t.Errorf("%d matches for %s, and %d for %s", n, tokens, opaqueN, opaqueTokens)
+	}
+}
+
+func TestLexerClearDFA(t *testing.T) {
+	lexer := newListLexer(nil)
+	decisionToDFA := newDFA(lexer.GetATN())
+	lexer.Interpreter = NewLexerATNSimulator(lexer, lexer.GetATN(), decisionToDFA, NewPredictionContextCache())
+	want := listTokens(lexer, "ab + c")
+	states := dfaLen(decisionToDFA)
+	if states == 0 {
+		t.Fatal("lexing cached no DFA states")
+	}
+	lexer.Interpreter.(ILexerATNSimulatorDFA).ClearDFA()
+	if dfaLen(decisionToDFA) != 0 {
+		t.Errorf("ClearDFA kept %d states", dfaLen(decisionToDFA))
+	}
+	if got := listTokens(lexer, "ab + c"); !slices.Equal(got, want) || dfaLen(decisionToDFA) != states {
+		t.Errorf("after ClearDFA, tokens %v and %d states, want %v and %d", got, dfaLen(decisionToDFA), want, states)
+	}
+}
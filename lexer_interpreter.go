@@ -0,0 +1,66 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// LexerInterpreter tokenizes input by simulating a lexer [ATN] directly, rather than with a generated lexer,
+// as the LexerInterpreter of the other ANTLR runtimes does. Together with [ParserInterpreter], it lets a
+// grammar be applied to input given only its serialized ATNs and vocabulary.
+//
+// As it has no code of the grammar, it does not run custom actions, and takes every semantic predicate to be
+// true. The lexer commands of the grammar, such as skip, channel and pushMode, are carried out as usual.
+//
+// Use:
+//
+//	atn := antlr.NewATNDeserializer(nil).Deserialize(serializedLexerATN)
+//	lexer := antlr.NewLexerInterpreter("Expr.g4", literalNames, symbolicNames, ruleNames, channelNames,
+//	    modeNames, atn, antlr.NewInputStream(text))
+//	for t := lexer.NextToken(); t.GetTokenType() != antlr.TokenEOF; t = lexer.NextToken() {
+//	    fmt.Println(t)
+//	}
+type LexerInterpreter struct {
+	*BaseLexer
+
+	channelNames []string
+	modeNames    []string
+}
+
+// NewLexerInterpreter creates an interpreter of the lexer ATN atn, with the given vocabulary and the names of
+// its rules, channels and modes, which tokenizes input. It has DFAs of its own, which are not shared with
+// any other lexer. It panics if atn is not a lexer ATN.
+func NewLexerInterpreter(grammarFileName string, literalNames, symbolicNames, ruleNames, channelNames, modeNames []string, atn *ATN, input CharStream) *LexerInterpreter {
+	if atn.grammarType != ATNTypeLexer {
+		panic("NewLexerInterpreter requires a lexer ATN")
+	}
+	l := &LexerInterpreter{
+		BaseLexer:    NewBaseLexer(input),
+		channelNames: channelNames,
+		modeNames:    modeNames,
+	}
+	l.Virt = l
+	l.GrammarFileName = grammarFileName
+	l.LiteralNames = literalNames
+	l.SymbolicNames = symbolicNames
+	l.RuleNames = ruleNames
+
+	decisionToDFA := make([]*DFA, len(atn.DecisionToState))
+	for i, s := range atn.DecisionToState {
+		decisionToDFA[i] = NewDFA(s, i)
+	}
+	l.Interpreter = NewLexerATNSimulator(l, atn, decisionToDFA, NewPredictionContextCache())
+	return l
+}
+
+// Action does nothing, as the interpreter has no code for the custom actions of the grammar.
+func (l *LexerInterpreter) Action(_ RuleContext, _, _ int) {}
+
+// GetChannelNames returns the names of the channels of the grammar.
+func (l *LexerInterpreter) GetChannelNames() []string {
+	return l.channelNames
+}
+
+// GetModeNames returns the names of the modes of the grammar.
+func (l *LexerInterpreter) GetModeNames() []string {
+	return l.modeNames
+}
@@ -0,0 +1,61 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+// listTokens lexes input with lexer, silently, and returns the text of the tokens before EOF.
+func listTokens(lexer *listLexer, input string) []string {
+	lexer.SetInputStream(NewInputStream(input))
+	lexer.RemoveErrorListeners()
+	var texts []string
+	for t := lexer.NextToken(); t.GetTokenType() != TokenEOF; t = lexer.NextToken() {
+		texts = append(texts, t.GetText())
+	}
+	return texts
+}
+
+func TestPrecomputeDFA(t *testing.T) {
+	lexer := newListLexer(nil)
+	decisionToDFA := newDFA(lexer.GetATN())
+	sim := NewLexerATNSimulator(lexer, lexer.GetATN(), decisionToDFA, NewPredictionContextCache())
+	lexer.Interpreter = sim
+	if !sim.PrecomputeDFA() || !decisionToDFA[0].precomputed {
+		t.Fatal("the DFA of the list lexer was not completed")
+	}
+	// The start state, and the states that accept ID, '+' and ' '
+	states := decisionToDFA[0].Len()
+	if states != 4 {
+		t.Errorf("%d states, want 4", states)
+	}
+
+	const input = "ab+c  d!e+"
+	want := listTokens(newListLexer(nil), input)
+	if got := listTokens(lexer, input); len(got) != len(want) || got[0] != "ab" || got[4] != "e" {
+		t.Errorf("tokens %q, want %q", got, want)
+	}
+	if decisionToDFA[0].Len() != states {
+		t.Errorf("lexing added %d states to the precomputed DFA", decisionToDFA[0].Len()-states)
+	}
+
+	// A mode is expanded once
+	if !sim.PrecomputeDFA() || decisionToDFA[0].Len() != states {
+		t.Error("the DFA was expanded again")
+	}
+}
+
+func TestWithLexerDFAPrecompute(t *testing.T) {
+	if err := ConfigureRuntime(WithLexerDFAPrecompute(true)); err != nil {
+		t.Fatal(err)
+	}
+	defer ConfigureRuntime(WithLexerDFAPrecompute(false))
+
+	lexer := newListLexer(nil)
+	decisionToDFA := newDFA(lexer.GetATN())
+	NewLexerATNSimulator(lexer, lexer.GetATN(), decisionToDFA, NewPredictionContextCache())
+	if !decisionToDFA[0].precomputed || decisionToDFA[0].Len() != 4 {
+		t.Error("the DFA was not precomputed when the simulator was created")
+	}
+}
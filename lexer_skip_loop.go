@@ -0,0 +1,196 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// lexerSkipLoop describes the skipped tokens of a lexer mode that are simple character class loops, such as
+// those of the rule WS : [ \t\r\n]+ -> skip ; which the lexer can step over without matching them through the
+// DFA. A rune of a class that starts such a token can begin no other token, and the token runs for as long
+// as the runes that follow are in the classes of its loop.
+type lexerSkipLoop struct {
+	// loopOf holds, for each class of the alphabet, the index in loops of the loop of the skipped token that
+	// a rune of the class starts, or -1 if it starts no such token
+	loopOf []int
+
+	// loops holds, for each loop, whether each class of the alphabet continues it
+	loops [][]bool
+}
+
+// getSkipLoops returns the skip loops of each mode of the lexer [ATN], finding them the first time they are
+// requested. A mode without any has none.
+func (l *LexerATNSimulator) getSkipLoops() []*lexerSkipLoop {
+	l.atn.mu.Lock()
+	defer l.atn.mu.Unlock()
+	if l.atn.lexerSkipLoops == nil {
+		l.precomputing = true
+		defer func() {
+			l.precomputing = false
+		}()
+		loops := make([]*lexerSkipLoop, len(l.atn.modeToStartState))
+		for mode := range loops {
+			loops[mode] = l.findSkipLoops(mode)
+		}
+		l.atn.lexerSkipLoops = loops
+	}
+	return l.atn.lexerSkipLoops
+}
+
+// findSkipLoops finds the skip loops of the given mode by simulating the ATN for each class of the alphabet
+// from the start state of the mode, and then from each state reached, without any input. It returns nil if
+// the mode has none, or if its tokens cannot be found without input, as when it has semantic predicates.
+func (l *LexerATNSimulator) findSkipLoops(mode int) (found *lexerSkipLoop) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*LoopDetectedException); !ok {
+				panic(r)
+			}
+			l.resetClosure()
+			found = nil
+		}
+	}()
+
+	s0 := l.computeStartState(nil, l.atn.modeToStartState[mode])
+	if s0.hasSemanticContext {
+		return nil
+	}
+
+	found = &lexerSkipLoop{loopOf: make([]int, l.alphabet.size())}
+	var states []*ATNConfigSet
+	for c := range found.loopOf {
+		found.loopOf[c] = -1
+		s := l.skipLoopReach(s0, l.alphabet.representative(c), 0)
+		if s == nil || !l.isSkipOnly(s) {
+			continue
+		}
+		id := -1
+		for i, o := range states {
+			if o.Equals(s) {
+				id = i
+				break
+			}
+		}
+		if id < 0 {
+			loop := l.skipLoopClasses(s)
+			if loop == nil {
+				continue
+			}
+			states = append(states, s)
+			found.loops = append(found.loops, loop)
+			id = len(found.loops) - 1
+		}
+		found.loopOf[c] = id
+	}
+	if len(found.loops) == 0 {
+		return nil
+	}
+	return found
+}
+
+// skipLoopClasses returns whether each class of the alphabet continues the loop at s, or nil if s is not a
+// loop: each rune must lead from s back to s, or nowhere.
+func (l *LexerATNSimulator) skipLoopClasses(s *ATNConfigSet) []bool {
+	if reach := l.skipLoopReach(s, TokenEOF, 1); reach == nil || len(reach.configs) > 0 {
+		return nil
+	}
+	loop := make([]bool, l.alphabet.size())
+	for c := range loop {
+		reach := l.skipLoopReach(s, l.alphabet.representative(c), 1)
+		if reach == nil {
+			return nil
+		}
+		if len(reach.configs) == 0 {
+			continue
+		}
+		if !reach.Equals(s) {
+			return nil
+		}
+		loop[c] = true
+	}
+	return loop
+}
+
+// skipLoopReach returns the configurations reached from closure upon t, or nil if they depend on semantic
+// predicates. The set is empty if t leads nowhere.
+func (l *LexerATNSimulator) skipLoopReach(closure *ATNConfigSet, t int, offset int) *ATNConfigSet {
+	reach := NewOrderedATNConfigSet()
+	l.reachableConfigSet(nil, closure, reach, t, offset)
+	if reach.hasSemanticContext {
+		return nil
+	}
+	return reach
+}
+
+// isSkipOnly returns true if the configurations accept a token, and the token accepted is skipped without
+// any other action, as addDFAState would decide for the DFA state with these configurations.
+func (l *LexerATNSimulator) isSkipOnly(configs *ATNConfigSet) bool {
+	if len(configs.configs) == 0 {
+		return false
+	}
+	for _, cfg := range configs.configs {
+		if _, ok := cfg.GetState().(*RuleStopState); ok {
+			e := cfg.lexerActionExecutor
+			return e != nil && len(e.lexerActions) == 1 && e.lexerActions[0].getActionType() == LexerActionTypeSkip
+		}
+	}
+	return false
+}
+
+// skipRun steps over the skipped tokens that follow in input, for as long as they are simple character
+// class loops, and returns true if it stepped over any. The lexer then goes on to match the next token as
+// usual. It produces exactly what matching the skipped tokens through the DFA would, but reads the runes of
+// the input directly, so it applies only to the input streams of the runtime, as for scanLoop.
+func (l *LexerATNSimulator) skipRun(input CharStream, mode int) bool {
+	is := runeInputStream(input)
+	if is == nil || runtimeConfig.lexerATNSimulatorDebug || l.atn.observer != nil {
+		return false
+	}
+	if l.skipLoops == nil {
+		l.skipLoops = l.getSkipLoops()
+	}
+	if mode < 0 || mode >= len(l.skipLoops) || l.skipLoops[mode] == nil {
+		return false
+	}
+	skip := l.skipLoops[mode]
+
+	i, line, pos := is.index, l.Line, l.CharPositionInLine
+	for i < is.size {
+		c := l.alphabet.classOf(int(is.data[i]))
+		if c < 0 || skip.loopOf[c] < 0 {
+			break
+		}
+		loop := skip.loops[skip.loopOf[c]]
+		for {
+			if is.data[i] == '\n' {
+				line++
+				pos = 0
+			} else {
+				pos++
+			}
+			i++
+			if i == is.size {
+				break
+			}
+			if c = l.alphabet.classOf(int(is.data[i])); c < 0 || !loop[c] {
+				break
+			}
+		}
+	}
+	if i == is.index {
+		return false
+	}
+	is.index, l.Line, l.CharPositionInLine = i, line, pos
+	return true
+}
+
+// runeInputStream returns the [InputStream] of input, if it is one of the input streams of the runtime,
+// whose characters are the runes they hold, or nil otherwise.
+func runeInputStream(input CharStream) *InputStream {
+	switch in := input.(type) {
+	case *InputStream:
+		return in
+	case *FileStream:
+		return &in.InputStream
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+// lexEOF lexes the rest of the input of lexer and returns its EOF token.
+func lexEOF(lexer Lexer) Token {
+	for {
+		if t := lexer.NextToken(); t.GetTokenType() == TokenEOF {
+			return t
+		}
+	}
+}
+
+func TestLexerEOFTokenCaching(t *testing.T) {
+	lexer := newListLexer(NewInputStream("a b"))
+	if lexEOF(lexer) == lexEOF(lexer) {
+		t.Error("the EOF token was cached while caching is off")
+	}
+
+	lexer.SetEOFTokenCaching(true)
+	eof := lexEOF(lexer)
+	if lexEOF(lexer) != eof {
+		t.Error("the EOF token was not cached")
+	}
+	// the token is kept when the same input is lexed again, and dropped for other input
+	lexer.Reset()
+	if lexEOF(lexer) != eof || eof.GetStart() != 3 || eof.GetColumn() != 3 {
+		t.Errorf("after Reset, the EOF token %s was not reused", eof)
+	}
+	lexer.SetInputStream(NewInputStream("a b"))
+	if other := lexEOF(lexer); other == eof || other.GetStart() != 3 {
+		t.Errorf("the EOF token %s of other input was reused", other)
+	}
+
+	// the token streams share the token, and each sets its index
+	eof = lexEOF(lexer)
+	for i := 0; i < 2; i++ {
+		lexer.Reset()
+		stream := NewCommonTokenStream(lexer, TokenDefaultChannel)
+		stream.Fill()
+		if last := stream.Get(stream.Size() - 1); last != eof || last.GetTokenIndex() != 2 {
+			t.Errorf("the stream ends with %s", last)
+		}
+	}
+
+	// tokens from an arena are never cached
+	lexer.SetArena(NewArena())
+	if lexEOF(lexer) == lexEOF(lexer) {
+		t.Error("an EOF token from an arena was cached")
+	}
+	lexer.SetEOFTokenCaching(false)
+	lexer.SetArena(nil)
+	if lexEOF(lexer) == lexEOF(lexer) {
+		t.Error("the EOF token was cached once caching was turned off")
+	}
+}
+
+func TestIsAtEOF(t *testing.T) {
+	lexer := newListLexer(NewInputStream("a "))
+	stream := NewCommonTokenStream(lexer, TokenDefaultChannel)
+	if lexer.IsAtEOF() || stream.IsAtEOF() {
+		t.Error("the lexer or the stream is at EOF before the first token")
+	}
+	stream.Consume()
+	// the trailing white space is skipped as the stream fetches the next token
+	if !stream.IsAtEOF() || !lexer.IsAtEOF() || !lexer.GetInputStream().(*InputStream).IsAtEOF() {
+		t.Error("the lexer, the stream or the input is not at EOF after the last token")
+	}
+	if !newListLexer(nil).IsAtEOF() || !NewInputStream("").IsAtEOF() {
+		t.Error("a lexer without input or an empty input is not at EOF")
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+)
+
+// highlightedLine is a line of the text given to a LineHighlighter, with the state of the lexer at its start
+// and end, and its tokens.
+type highlightedLine struct {
+	text       string
+	start, end lineLexerState
+	tokens     []Token
+}
+
+// LineHighlighter lexes text one line at a time for syntax highlighting, and when the text changes, lexes
+// again only the lines that need it. It records the state of the lexer at the start of each line, its mode
+// and the stack of modes it saved, and a line is lexed again only if its text has changed or it starts in a
+// different state than before, as when a change opens a string or comment that continues onto the lines
+// that follow. This keeps the work done for each keystroke proportional to the size of the change, not to
+// the size of the text, as editors need.
+//
+// Each line is lexed on its own, with the newline that ends it, so tokens cannot span lines unless the
+// grammar carries them over from one line to the next with a lexer mode, as grammars meant for highlighting
+// do for block comments and multi-line strings. For the same reason, the tokens of a line have the line
+// number 1, and their start and stop indexes and columns count from the start of the line.
+//
+// Use:
+//
+//	h := antlr.NewLineHighlighter(parser.NewMyLexer(nil))
+//	first, end := h.Update(text)
+//	for line := first; line < end; line++ {
+//	    repaint(line, h.LineTokens(line))
+//	}
+type LineHighlighter struct {
+	lexer Lexer
+	lines []highlightedLine
+}
+
+// lineHighlighterLexer is implemented by lexers that embed BaseLexer, which LineHighlighter requires.
+type lineHighlighterLexer interface {
+	SetInputStream(CharStream)
+	modeState() (int, []int)
+	setModeState(mode int, stack []int)
+}
+
+// NewLineHighlighter creates a [LineHighlighter] that lexes with lexer, which must embed [BaseLexer], as all
+// generated lexers do, and which is given each line as its input in turn. The error listeners of the lexer
+// are removed, as text being edited is often not valid, and an error only means that some characters are
+// not highlighted.
+func NewLineHighlighter(lexer Lexer) *LineHighlighter {
+	if _, ok := lexer.(lineHighlighterLexer); !ok {
+		panic("a LineHighlighter requires a lexer that embeds BaseLexer")
+	}
+	lexer.RemoveErrorListeners()
+	return &LineHighlighter{lexer: lexer}
+}
+
+// Update replaces the text, lexes again the lines that need it, and returns the range of lines, numbered
+// from 0, from first up to but not including end, whose tokens may have changed. Lines outside the range
+// keep their tokens, though they may have moved up or down if lines were added or removed. The range is
+// empty if no tokens changed. The first call lexes every line.
+func (h *LineHighlighter) Update(text string) (first, end int) {
+	texts := strings.SplitAfter(text, "\n")
+	old := h.lines
+
+	// Lines that are the same at the start keep their tokens, as they start in the same state, and so do
+	// lines that are the same at the end, provided that they still start in the same state
+	prefix := 0
+	for prefix < len(texts) && prefix < len(old) && texts[prefix] == old[prefix].text {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(texts)-prefix && suffix < len(old)-prefix &&
+		texts[len(texts)-1-suffix] == old[len(old)-1-suffix].text {
+		suffix++
+	}
+
+	lines := make([]highlightedLine, len(texts))
+	copy(lines, old[:prefix])
+	state := lineLexerState{mode: LexerDefaultMode}
+	if prefix > 0 {
+		state = lines[prefix-1].end
+	}
+	end = prefix
+	for i := prefix; i < len(texts); i++ {
+		if i >= len(texts)-suffix {
+			reused := old[len(old)-len(texts)+i]
+			if reused.start.equals(state) {
+				copy(lines[i:], old[len(old)-len(texts)+i:])
+				break
+			}
+		}
+		lines[i] = h.lex(texts[i], state)
+		state = lines[i].end
+		end = i + 1
+	}
+	h.lines = lines
+	return prefix, end
+}
+
+// lex lexes one line of text, starting in the given state.
+func (h *LineHighlighter) lex(text string, start lineLexerState) highlightedLine {
+	l := h.lexer.(lineHighlighterLexer)
+	l.SetInputStream(NewInputStream(text))
+	l.setModeState(start.mode, start.stack)
+
+	line := highlightedLine{text: text, start: start}
+	for {
+		t := h.lexer.NextToken()
+		if t.GetTokenType() == TokenEOF {
+			break
+		}
+		line.tokens = append(line.tokens, t)
+	}
+	line.end.mode, line.end.stack = l.modeState()
+	return line
+}
+
+// Lines returns the number of lines in the text. Text that ends with a newline has an empty last line.
+func (h *LineHighlighter) Lines() int {
+	return len(h.lines)
+}
+
+// LineTokens returns the tokens of the given line, numbered from 0, on every channel, or nil if there is
+// no such line.
+func (h *LineHighlighter) LineTokens(line int) []Token {
+	if line < 0 || line >= len(h.lines) {
+		return nil
+	}
+	return h.lines[line].tokens
+}
+
+// LineMode returns the mode of the lexer at the start of the given line, numbered from 0, and false if
+// there is no such line.
+func (h *LineHighlighter) LineMode(line int) (int, bool) {
+	if line < 0 || line >= len(h.lines) {
+		return 0, false
+	}
+	return h.lines[line].start.mode, true
+}
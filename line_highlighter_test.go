@@ -0,0 +1,112 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+// commentLexer lexes the tokens of the list grammar, and block comments, which may span lines, in a mode of
+// their own, as grammars meant for highlighting do.
+type commentLexer struct {
+	*listLexer
+}
+
+const (
+	commentTokenType = listWS + 1
+	commentMode      = 1
+)
+
+func (l *commentLexer) NextToken() Token {
+	input := l.GetInputStream()
+	sim := l.Interpreter.(*LexerATNSimulator)
+	for l.mode != commentMode && (input.LA(1) == ' ' || input.LA(1) == '\n') {
+		input.Consume()
+		sim.CharPositionInLine++
+	}
+	if input.LA(1) == TokenEOF {
+		return l.EmitEOF()
+	}
+	if l.mode != commentMode && (input.LA(1) != '/' || input.LA(2) != '*') {
+		return l.listLexer.NextToken()
+	}
+	start, column := input.Index(), sim.CharPositionInLine
+	if l.mode != commentMode {
+		input.Consume()
+		input.Consume()
+		l.PushMode(commentMode)
+	}
+	for input.LA(1) != TokenEOF && l.mode == commentMode {
+		if input.LA(1) == '*' && input.LA(2) == '/' {
+			input.Consume()
+			l.PopMode()
+		}
+		input.Consume()
+	}
+	stop := input.Index() - 1
+	sim.CharPositionInLine = column + stop - start + 1
+	return l.GetTokenFactory().Create(l.GetTokenSourceCharStreamPair(), commentTokenType, input.GetText(start, stop),
+		TokenDefaultChannel, start, stop, 1, column)
+}
+
+// lineTexts returns the text of the tokens of each line, separated by spaces, and the mode of the lexer at
+// its start.
+func lineTexts(h *LineHighlighter) []string {
+	var lines []string
+	for i := 0; i < h.Lines(); i++ {
+		var texts []string
+		for _, t := range h.LineTokens(i) {
+			texts = append(texts, t.GetText())
+		}
+		mode, _ := h.LineMode(i)
+		lines = append(lines, strings.Repeat("*", mode)+strings.Join(texts, " "))
+	}
+	return lines
+}
+
+func TestLineHighlighter(t *testing.T) {
+	h := NewLineHighlighter(&commentLexer{newListLexer(nil)})
+	tests := []struct {
+		text       string
+		first, end int
+		lines      []string
+	}{
+		{"a b\n/* x\ny */ c\nd", 0, 4, []string{"a b", "/* x\n", "*y */ c", "d"}},
+		// only the line edited is lexed again
+		{"a bb\n/* x\ny */ c\nd", 0, 1, []string{"a bb", "/* x\n", "*y */ c", "d"}},
+		{"a bb\n/* x\ny */ c\nd", 4, 4, []string{"a bb", "/* x\n", "*y */ c", "d"}},
+		// closing the comment changes the state at the start of the next line, where */ is no longer a token,
+		// but not of the one after
+		{"a bb\n/* x */\ny */ c\nd", 1, 3, []string{"a bb", "/* x */", "y c", "d"}},
+		// opening it again carries it over to the end
+		{"a bb\n/* x */\ny /* c\nd", 2, 4, []string{"a bb", "/* x */", "y /* c\n", "*d"}},
+		// the lines after an insertion keep their tokens, and move down
+		{"z\na bb\n/* x */\ny /* c\nd", 0, 1, []string{"z", "a bb", "/* x */", "y /* c\n", "*d"}},
+		{"z\n", 1, 2, []string{"z", ""}},
+	}
+	for _, test := range tests {
+		first, end := h.Update(test.text)
+		if got := lineTexts(h); first != test.first || end != test.end || strings.Join(got, "|") != strings.Join(test.lines, "|") {
+			t.Errorf("%q: lines %d to %d: %q, want %d to %d: %q", test.text, first, end, got, test.first, test.end, test.lines)
+		}
+	}
+
+	// the tokens of a line count from its start
+	h.Update("a\nb + cc")
+	if cc := h.LineTokens(1)[2]; cc.GetStart() != 4 || cc.GetColumn() != 4 || cc.GetLine() != 1 {
+		t.Errorf("cc at %d:%d, index %d", cc.GetLine(), cc.GetColumn(), cc.GetStart())
+	}
+	if _, ok := h.LineMode(2); ok || h.LineTokens(-1) != nil {
+		t.Error("a line beyond the text has tokens or a mode")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("a lexer that does not embed BaseLexer was accepted")
+		}
+	}()
+	NewLineHighlighter(struct{ Lexer }{})
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "sort"
+
+// LineIndex maps between character offsets in a [CharStream] and line and column positions. It is built
+// once from the whole stream, after which an offset is converted to a position in O(log n) time, and a
+// position to an offset in O(1) time, where n is the number of lines. This saves tools that report many
+// diagnostics, or convert token ranges to editor ranges, from rescanning the input for each one.
+//
+// Lines and columns follow the same conventions as the lexer and [Token]: lines are numbered from 1,
+// columns from 0, only '\n' ends a line, and every character, including a tab, occupies one column.
+//
+// Use:
+//
+//	index := antlr.NewLineIndex(input)
+//	line, column, _ := index.Position(token.GetStop() + 1) // the position just after the token
+type LineIndex struct {
+	// lineStarts holds the offset of the first character of each line, in ascending order
+	lineStarts []int
+	size       int
+}
+
+// NewLineIndex scans the entire input and builds a [LineIndex] for it. The position of the input stream
+// is not changed.
+func NewLineIndex(input CharStream) *LineIndex {
+	x := &LineIndex{
+		lineStarts: []int{0},
+		size:       input.Size(),
+	}
+	for i, c := range []rune(input.GetText(0, x.size-1)) {
+		if c == '\n' {
+			x.lineStarts = append(x.lineStarts, i+1)
+		}
+	}
+	return x
+}
+
+// LineCount returns the number of lines in the input. Input that ends with '\n' has an empty last line.
+func (x *LineIndex) LineCount() int {
+	return len(x.lineStarts)
+}
+
+// Size returns the number of characters in the input.
+func (x *LineIndex) Size() int {
+	return x.size
+}
+
+// LineStart returns the offset of the first character of the given line, and false if there is no such line.
+func (x *LineIndex) LineStart(line int) (int, bool) {
+	if line < 1 || line > len(x.lineStarts) {
+		return -1, false
+	}
+	return x.lineStarts[line-1], true
+}
+
+// Position returns the line and column of the character at the given offset. The offset may be equal to
+// the size of the input, which is the position of EOF. It returns false if the offset is outside the input.
+func (x *LineIndex) Position(offset int) (line, column int, ok bool) {
+	if offset < 0 || offset > x.size {
+		return 0, -1, false
+	}
+	// Find the last line that starts at or before offset
+	i := sort.SearchInts(x.lineStarts, offset+1) - 1
+	return i + 1, offset - x.lineStarts[i], true
+}
+
+// Offset returns the offset of the character at the given line and column. It returns false if there is no
+// such line, or the column is beyond the end of the line, where the '\n' that ends a line is considered to
+// be part of it.
+func (x *LineIndex) Offset(line, column int) (int, bool) {
+	start, ok := x.LineStart(line)
+	if !ok || column < 0 {
+		return -1, false
+	}
+	end := x.size
+	if line < len(x.lineStarts) {
+		end = x.lineStarts[line] - 1
+	}
+	if start+column > end {
+		return -1, false
+	}
+	return start + column, true
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestLineIndex(t *testing.T) {
+	input := NewInputStream("ab\n\nc\té\n")
+	input.Seek(2)
+	index := NewLineIndex(input)
+	if input.Index() != 2 {
+		t.Errorf("the input moved to %d", input.Index())
+	}
+	if index.LineCount() != 4 || index.Size() != 8 {
+		t.Errorf("%d lines and %d characters", index.LineCount(), index.Size())
+	}
+
+	positions := []struct{ offset, line, column int }{
+		{0, 1, 0}, {2, 1, 2}, {3, 2, 0}, {4, 3, 0}, {6, 3, 2}, {7, 3, 3}, {8, 4, 0},
+	}
+	for _, p := range positions {
+		if line, column, ok := index.Position(p.offset); !ok || line != p.line || column != p.column {
+			t.Errorf("Position(%d) = %d:%d, %v, want %d:%d", p.offset, line, column, ok, p.line, p.column)
+		}
+		if offset, ok := index.Offset(p.line, p.column); !ok || offset != p.offset {
+			t.Errorf("Offset(%d, %d) = %d, %v, want %d", p.line, p.column, offset, ok, p.offset)
+		}
+	}
+	if _, _, ok := index.Position(9); ok {
+		t.Error("Position(9) is in the input")
+	}
+	if _, _, ok := index.Position(-1); ok {
+		t.Error("Position(-1) is in the input")
+	}
+	if _, ok := index.Offset(1, 3); ok {
+		t.Error("Offset(1, 3), after the newline of line 1, is in the input")
+	}
+	if _, ok := index.Offset(5, 0); ok {
+		t.Error("Offset(5, 0) is in the input")
+	}
+	if start, ok := index.LineStart(3); !ok || start != 4 {
+		t.Errorf("LineStart(3) = %d, %v", start, ok)
+	}
+	if _, ok := index.LineStart(0); ok {
+		t.Error("there is a line 0")
+	}
+
+	// The positions agree with those of the tokens, though the lexer does not match the newline
+	lexer := newListLexer(NewInputStream("a b\n+ c"))
+	lexer.RemoveErrorListeners()
+	index = NewLineIndex(NewInputStream("a b\n+ c"))
+	for token := lexer.NextToken(); token.GetTokenType() != TokenEOF; token = lexer.NextToken() {
+		if line, column, _ := index.Position(token.GetStart()); line != token.GetLine() || column != token.GetColumn() {
+			t.Errorf("token %s is at %d:%d", token, line, column)
+		}
+	}
+}
@@ -0,0 +1,324 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "sync"
+
+// The tests parse lists of items with a lexer and a parser written as the ANTLR tool generates them, from
+// their serialized ATNs, for the grammar:
+//
+//	s    : item* EOF ;
+//	item : ID | ID '+' ID ;
+//	ID   : [a-z]+ ;
+//	PLUS : '+' ;
+//	WS   : ' ' -> skip ;
+
+// listLexerSerialized is the serialized ATN of the lexer.
+var listLexerSerialized = []int32{
+	4, 0, 3,
+	17,
+	6, -1,
+	2, 0,
+	7, 0,
+	4, 0, 5,
+	1, 0,
+	8, 0,
+	11, 0,
+	12, 0, 6,
+	2, 1,
+	7, 1,
+	1, 1,
+	1, 1,
+	2, 2,
+	7, 2,
+	1, 2,
+	1, 2,
+	1, 2,
+	0, // nongreedy
+	0, // precedence
+	3, 1, 1, 8, 2, 12, 3,
+	1, 0,
+	0, // sets
+	17,
+	0, 1, 1, 0, 0, 0,
+	0, 8, 1, 0, 0, 0,
+	0, 12, 1, 0, 0, 0,
+	1, 3, 1, 0, 0, 0,
+	3, 4, 1, 0, 0, 0,
+	4, 5, 2, 97, 122, 0,
+	5, 6, 1, 0, 0, 0,
+	6, 3, 1, 0, 0, 0,
+	6, 7, 1, 0, 0, 0,
+	7, 2, 1, 0, 0, 0,
+	8, 10, 1, 0, 0, 0,
+	10, 11, 5, 43, 0, 0,
+	11, 9, 1, 0, 0, 0,
+	12, 14, 1, 0, 0, 0,
+	14, 15, 5, 32, 0, 0,
+	15, 16, 6, 2, 0, 0,
+	16, 13, 1, 0, 0, 0,
+	3, 0, 3, 6,
+	1, 6, 0, 0,
+}
+
+// listParserSerialized is the serialized ATN of the parser.
+var listParserSerialized = []int32{
+	4, 1, 3,
+	20,
+	2, 0,
+	7, 0,
+	10, 0,
+	5, 0, 5,
+	1, 0,
+	8, 0,
+	9, 0,
+	12, 0, 6,
+	1, 0,
+	1, 0,
+	2, 1,
+	7, 1,
+	3, 1, 17,
+	1, 1,
+	1, 1,
+	1, 1,
+	1, 1,
+	8, 1,
+	1, 1,
+	1, 1,
+	0, 0,
+	2, 0, 10,
+	0,
+	0,
+	20,
+	0, 2, 1, 0, 0, 0,
+	2, 3, 1, 0, 0, 0,
+	2, 7, 1, 0, 0, 0,
+	3, 4, 1, 0, 0, 0,
+	4, 5, 3, 10, 1, 0,
+	5, 6, 1, 0, 0, 0,
+	6, 2, 1, 0, 0, 0,
+	7, 8, 1, 0, 0, 0,
+	8, 9, 5, 0, 0, 1,
+	9, 1, 1, 0, 0, 0,
+	10, 12, 1, 0, 0, 0,
+	12, 13, 1, 0, 0, 0,
+	12, 14, 1, 0, 0, 0,
+	13, 18, 5, 1, 0, 0,
+	18, 17, 1, 0, 0, 0,
+	14, 15, 5, 1, 0, 0,
+	15, 16, 5, 2, 0, 0,
+	16, 19, 5, 1, 0, 0,
+	19, 17, 1, 0, 0, 0,
+	17, 11, 1, 0, 0, 0,
+	2, 2, 12,
+}
+
+type listStaticData struct {
+	once sync.Once
+	atn  *ATN
+	dfa  []*DFA
+	pcc  *PredictionContextCache
+}
+
+func (s *listStaticData) init(data []int32) {
+	s.once.Do(func() {
+		s.atn = NewATNDeserializer(nil).Deserialize(data)
+		s.dfa = make([]*DFA, len(s.atn.DecisionToState))
+		for i, ds := range s.atn.DecisionToState {
+			s.dfa[i] = NewDFA(ds, i)
+		}
+		s.pcc = NewPredictionContextCache()
+	})
+}
+
+var listLexerStatic, listParserStatic listStaticData
+
+const (
+	listID   = 1
+	listPLUS = 2
+	listWS   = 3
+)
+
+type listLexer struct {
+	*BaseLexer
+}
+
+func newListLexer(input CharStream) *listLexer {
+	listLexerStatic.init(listLexerSerialized)
+	l := &listLexer{BaseLexer: NewBaseLexer(input)}
+	l.Interpreter = NewLexerATNSimulator(l, listLexerStatic.atn, listLexerStatic.dfa, listLexerStatic.pcc)
+	l.RuleNames = []string{"ID", "PLUS", "WS"}
+	l.LiteralNames = []string{"", "", "'+'"}
+	l.SymbolicNames = []string{"", "ID", "PLUS", "WS"}
+	l.GrammarFileName = "T.g4"
+	return l
+}
+
+type listParser struct {
+	*BaseParser
+}
+
+func newListParser(input TokenStream) *listParser {
+	listParserStatic.init(listParserSerialized)
+	p := &listParser{BaseParser: NewBaseParser(input)}
+	p.Interpreter = NewParserATNSimulator(p, listParserStatic.atn, listParserStatic.dfa, listParserStatic.pcc)
+	p.RuleNames = []string{"s", "item"}
+	p.LiteralNames = []string{"", "", "'+'"}
+	p.SymbolicNames = []string{"", "ID", "PLUS", "WS"}
+	p.GrammarFileName = "T.g4"
+	return p
+}
+
+const (
+	listRuleS    = 0
+	listRuleItem = 1
+)
+
+type listSContext struct {
+	BaseParserRuleContext
+	parser Parser
+}
+
+func newListSContext(parser Parser, parent ParserRuleContext, invokingState int) *listSContext {
+	var p = new(listSContext)
+	InitBaseParserRuleContext(&p.BaseParserRuleContext, parent, invokingState)
+	p.parser = parser
+	p.RuleIndex = listRuleS
+	return p
+}
+
+func (s *listSContext) GetRuleContext() RuleContext { return s }
+
+type listItemContext struct {
+	BaseParserRuleContext
+	parser Parser
+}
+
+func newListItemContext(parser Parser, parent ParserRuleContext, invokingState int) *listItemContext {
+	var p = new(listItemContext)
+	InitBaseParserRuleContext(&p.BaseParserRuleContext, parent, invokingState)
+	p.parser = parser
+	p.RuleIndex = listRuleItem
+	return p
+}
+
+func (s *listItemContext) GetRuleContext() RuleContext { return s }
+
+func (p *listParser) S() (localctx *listSContext) {
+	localctx = newListSContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 0, listRuleS)
+	var _la int
+	p.EnterOuterAlt(localctx, 1)
+	p.SetState(2)
+	p.GetErrorHandler().Sync(p)
+	if p.HasError() {
+		goto errorExit
+	}
+	_la = p.GetTokenStream().LA(1)
+	for _la == listID {
+		{
+			p.SetState(4)
+			p.Item()
+			if p.HasError() {
+				goto errorExit
+			}
+		}
+		p.SetState(6)
+		p.GetErrorHandler().Sync(p)
+		if p.HasError() {
+			goto errorExit
+		}
+		_la = p.GetTokenStream().LA(1)
+	}
+	{
+		p.SetState(8)
+		p.Match(TokenEOF)
+		if p.HasError() {
+			goto errorExit
+		}
+	}
+errorExit:
+	if p.HasError() {
+		v := p.GetError()
+		localctx.SetException(v)
+		p.GetErrorHandler().ReportError(p, v)
+		p.GetErrorHandler().Recover(p, v)
+		p.SetError(nil)
+	}
+	p.ExitRule()
+	return localctx
+}
+
+func (p *listParser) Item() (localctx *listItemContext) {
+	localctx = newListItemContext(p, p.GetParserRuleContext(), p.GetState())
+	p.EnterRule(localctx, 10, listRuleItem)
+	p.SetState(12)
+	p.GetErrorHandler().Sync(p)
+	if p.HasError() {
+		goto errorExit
+	}
+	switch p.GetInterpreter().AdaptivePredict(p.BaseParser, p.GetTokenStream(), 1, p.GetParserRuleContext()) {
+	case 1:
+		p.EnterOuterAlt(localctx, 1)
+		{
+			p.SetState(13)
+			p.Match(listID)
+			if p.HasError() {
+				goto errorExit
+			}
+		}
+	case 2:
+		p.EnterOuterAlt(localctx, 2)
+		{
+			p.SetState(14)
+			p.Match(listID)
+			if p.HasError() {
+				goto errorExit
+			}
+		}
+		{
+			p.SetState(15)
+			p.Match(listPLUS)
+			if p.HasError() {
+				goto errorExit
+			}
+		}
+		{
+			p.SetState(16)
+			p.Match(listID)
+			if p.HasError() {
+				goto errorExit
+			}
+		}
+	case ATNInvalidAltNumber:
+		goto errorExit
+	}
+errorExit:
+	if p.HasError() {
+		v := p.GetError()
+		localctx.SetException(v)
+		p.GetErrorHandler().ReportError(p, v)
+		p.GetErrorHandler().Recover(p, v)
+		p.SetError(nil)
+	}
+	p.ExitRule()
+	return localctx
+}
+
+// listParse parses input with a new parser, and returns the parser and the tree.
+func listParse(input string) (*listParser, *listSContext) {
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+	return p, p.S()
+}
+
+// newDFA returns new, empty DFAs for the decisions of atn, for tests that must not share the DFAs that the
+// recognizers of the grammar cache.
+func newDFA(atn *ATN) []*DFA {
+	decisionToDFA := make([]*DFA, len(atn.DecisionToState))
+	for i, s := range atn.DecisionToState {
+		decisionToDFA[i] = NewDFA(s, i)
+	}
+	return decisionToDFA
+}
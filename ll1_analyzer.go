@@ -73,7 +73,7 @@ func (la *LL1Analyzer) getDecisionLookahead(s ATNState) []*IntervalSet {
 func (la *LL1Analyzer) Look(s, stopState ATNState, ctx RuleContext) *IntervalSet {
 	r := NewIntervalSet()
 	var lookContext *PredictionContext
-	if ctx != nil {
+	if !isNilContext(ctx) {
 		lookContext = predictionContextFromRuleContext(s.GetATN(), ctx)
 	}
 	la.look1(s, stopState, lookContext, r, NewJStore[*ATNConfig, Comparator[*ATNConfig]](aConfEqInst, ClosureBusyCollection, "LL1Analyzer.Look for la.look1()"),
@@ -0,0 +1,99 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "sort"
+
+// MinimizeInput reduces an input for which failing returns true to a smaller input for which failing still
+// returns true, in the manner of delta debugging. It is intended for cutting down a large input that
+// triggers a bug, in the parser or in a tool that consumes the parse tree, to a small test case.
+//
+// Rather than removing arbitrary characters, which mostly yields input that no longer lexes or parses,
+// MinimizeInput parses the input with a lexer and parser created by the given constructors, starting at the
+// rule invoked by start, and tries removing the text of each node of the parse tree, largest first. Each
+// time a removal leaves an input that still fails, the smaller input is kept and parsed afresh. It stops
+// when no single node can be removed, and returns the smallest failing input found. The text between
+// tokens, such as whitespace and comments, is kept unless it lies within a node that is removed.
+//
+// Syntax errors are not reported, and the parse tree of an input with errors is used as it stands. The
+// predicate is called once for each candidate input, so the run time is dominated by the predicate for
+// all but the simplest of them. If failing returns false for the original input, it is returned as is.
+//
+// Use:
+//
+//	small := antlr.MinimizeInput(
+//	    func(input antlr.CharStream) antlr.Lexer { return parser.NewMyLexer(input) },
+//	    func(input antlr.TokenStream) antlr.Parser { return parser.NewMyParser(input) },
+//	    func(p antlr.Parser) antlr.ParseTree { return p.(*parser.MyParser).Start() },
+//	    big, func(input string) bool { return crashesMyTool(input) })
+func MinimizeInput(lexerCtor func(CharStream) Lexer, parserCtor func(TokenStream) Parser, start func(Parser) ParseTree,
+	input string, failing func(input string) bool) string {
+
+	if !failing(input) {
+		return input
+	}
+	for {
+		text := []rune(input)
+		reduced := false
+		for _, span := range minimizeSpans(lexerCtor, parserCtor, start, input) {
+			candidate := string(text[:span.Start]) + string(text[span.Stop+1:])
+			if failing(candidate) {
+				input = candidate
+				reduced = true
+				break
+			}
+		}
+		if !reduced {
+			return input
+		}
+	}
+}
+
+// minimizeSpans parses input and returns the distinct character spans of the nodes of its parse tree,
+// largest first. Each span is inclusive of its Stop.
+func minimizeSpans(lexerCtor func(CharStream) Lexer, parserCtor func(TokenStream) Parser, start func(Parser) ParseTree,
+	input string) []Interval {
+
+	lexer := lexerCtor(NewInputStream(input))
+	lexer.RemoveErrorListeners()
+	p := parserCtor(NewCommonTokenStream(lexer, TokenDefaultChannel))
+	p.RemoveErrorListeners()
+	tree := start(p)
+
+	seen := make(map[Interval]bool)
+	spans := make([]Interval, 0)
+	var collect func(t Tree)
+	collect = func(t Tree) {
+		var first, last Token
+		switch n := t.(type) {
+		case ParserRuleContext:
+			first, last = n.GetStart(), n.GetStop()
+		case TerminalNode:
+			first, last = n.GetSymbol(), n.GetSymbol()
+		}
+		if first != nil && last != nil && first.GetTokenType() != TokenEOF && first.GetStart() >= 0 {
+			stop := last.GetStop()
+			if last.GetTokenType() == TokenEOF {
+				stop = last.GetStart() - 1
+			}
+			span := NewInterval(first.GetStart(), stop)
+			if span.Stop >= span.Start && !seen[span] {
+				seen[span] = true
+				spans = append(spans, span)
+			}
+		}
+		for i := 0; i < t.GetChildCount(); i++ {
+			collect(t.GetChild(i))
+		}
+	}
+	if tree != nil {
+		collect(tree)
+	}
+
+	sort.SliceStable(spans, func(i, j int) bool {
+		return spans[i].Stop-spans[i].Start > spans[j].Stop-spans[j].Start
+	})
+	return spans
+}
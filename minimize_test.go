@@ -0,0 +1,39 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+// minimizeList minimizes input with the list grammar.
+func minimizeList(input string, failing func(string) bool) string {
+	return MinimizeInput(func(input CharStream) Lexer { return newListLexer(input) },
+		func(input TokenStream) Parser { return newListParser(input) },
+		func(p Parser) ParseTree { return p.(*listParser).S() }, input, failing)
+}
+
+func TestMinimizeInput(t *testing.T) {
+	var tried []string
+	got := minimizeList("a b + c dd e + f", func(input string) bool {
+		tried = append(tried, input)
+		return strings.Contains(input, "dd")
+	})
+	if got != "  dd " {
+		t.Errorf("MinimizeInput() = %q, want %q", got, "  dd ")
+	}
+	// the whole list is removed first, then the largest item, and the whitespace between items is kept
+	want := []string{"a b + c dd e + f", "", "a  dd e + f", "", "a  dd ", " ", "a   ", "  dd ", "   "}
+	if !slices.Equal(tried, want) {
+		t.Errorf("tried %q, want %q", tried, want)
+	}
+
+	calls := 0
+	if got := minimizeList("a b", func(string) bool { calls++; return false }); got != "a b" || calls != 1 {
+		t.Errorf("MinimizeInput() of an input that does not fail = %q after %d calls", got, calls)
+	}
+}
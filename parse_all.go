@@ -0,0 +1,211 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// ParseAllOptions configures [ParseAll]. The zero value parses with one worker per CPU and the settings of
+// a newly generated parser.
+type ParseAllOptions struct {
+	// Workers is the number of inputs parsed at once, each by a parser of its own. If it is 0 or less, it
+	// is the number of CPUs, as given by runtime.GOMAXPROCS.
+	Workers int
+
+	// Config, if not nil, is applied to each parser when it is created, see [RuntimeConfig]. Its error
+	// listeners are replaced by one that collects the errors of each input into its result.
+	Config *RuntimeConfig
+}
+
+// ParseResult is the outcome of parsing one of the inputs given to [ParseAll].
+type ParseResult struct {
+	// Tree is the parse tree returned by the start rule, which may be partial if there were errors, or nil
+	// if the parse panicked
+	Tree ParseTree
+
+	// Errors holds the syntax errors reported by the lexer and the parser, in the order they were reported.
+	// Each formats itself as the console error listener formats it, and Errors.Err() returns them as an
+	// error, or nil if there were none.
+	Errors SyntaxErrors
+
+	// Err is the error that ended the parse early, if any: the [ParseCancellationException] of a parse that
+	// was cancelled, as by a [BailErrorStrategy] or a limit set in a [RuntimeConfig], or an error holding
+	// the value and stack of a panic
+	Err error
+
+	// Stats counts the work done by adaptive prediction for the input
+	Stats PredictionStats
+
+	// Elapsed is the time taken to lex and parse the input
+	Elapsed time.Duration
+}
+
+// OK returns true if the input parsed without errors.
+func (r *ParseResult) OK() bool {
+	return r.Err == nil && len(r.Errors) == 0
+}
+
+// ParseAllStats holds the totals for a call to [ParseAll].
+type ParseAllStats struct {
+	// Inputs is the number of inputs parsed, and Failed the number of those that did not parse without
+	// errors
+	Inputs int
+	Failed int
+
+	// Prediction totals the work done by adaptive prediction for all the inputs
+	Prediction PredictionStats
+
+	// Elapsed is the time taken to parse all the inputs
+	Elapsed time.Duration
+}
+
+// ParseAll lexes and parses each of inputs with lexers and parsers created by the given constructors,
+// starting at the rule invoked by start, using a pool of workers, and returns the result for each input, in
+// the order of the inputs, with the totals. It packages the usual pattern for parsing many inputs quickly:
+//
+//   - the inputs are parsed concurrently, by as many workers as opts asks for
+//   - each worker creates one lexer and one parser, which it resets for each input rather than creating new
+//     ones, so each input costs only the tokens and the parse tree
+//   - all the parsers share the DFA cache of the grammar, so what adaptive prediction learns from one input
+//     speeds up the parsing of the others
+//   - errors are collected into the result for each input rather than printed, and a panic during a parse
+//     is recovered and reported as the error of that input, without stopping the others
+//
+// The lexer constructor is called with the first input a worker parses. Lexers that embed [BaseLexer] are
+// then given each further input with SetInputStream, and other lexers are created anew for each input.
+// Parsers must embed [BaseParser], as all generated parsers do.
+//
+// Use:
+//
+//	results, stats := antlr.ParseAll(inputs,
+//	    func(input antlr.CharStream) antlr.Lexer { return parser.NewMyLexer(input) },
+//	    func(input antlr.TokenStream) antlr.Parser { return parser.NewMyParser(input) },
+//	    func(p antlr.Parser) antlr.ParseTree { return p.(*parser.MyParser).Start() },
+//	    nil)
+//	for i, r := range results {
+//	    if !r.OK() {
+//	        log.Printf("%s: %v %v", inputs[i].GetSourceName(), r.Errors.Err(), r.Err)
+//	    }
+//	}
+func ParseAll(inputs []CharStream, lexerCtor func(CharStream) Lexer, parserCtor func(TokenStream) Parser,
+	start func(Parser) ParseTree, opts *ParseAllOptions) ([]ParseResult, ParseAllStats) {
+
+	if opts == nil {
+		opts = &ParseAllOptions{}
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
+	}
+
+	began := time.Now()
+	results := make([]ParseResult, len(inputs))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := &parseAllWorker{lexerCtor: lexerCtor, parserCtor: parserCtor, start: start, config: opts.Config}
+			for i := range next {
+				results[i] = w.parse(inputs[i])
+			}
+		}()
+	}
+	for i := range inputs {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	stats := ParseAllStats{Inputs: len(inputs), Elapsed: time.Since(began)}
+	for i := range results {
+		if !results[i].OK() {
+			stats.Failed++
+		}
+		stats.Prediction.add(results[i].Stats)
+	}
+	return results, stats
+}
+
+// parseAllWorker parses inputs one at a time for [ParseAll], reusing its lexer and parser.
+type parseAllWorker struct {
+	lexerCtor  func(CharStream) Lexer
+	parserCtor func(TokenStream) Parser
+	start      func(Parser) ParseTree
+	config     *RuntimeConfig
+
+	lexer  Lexer
+	parser Parser
+	errors *parseAllErrorListener
+}
+
+// parse lexes and parses input, recovering from any panic.
+func (w *parseAllWorker) parse(input CharStream) (result ParseResult) {
+	began := time.Now()
+	defer func() {
+		if r := recover(); r != nil {
+			result.Err = fmt.Errorf("panic during parse: %v\n%s", r, debug.Stack())
+			// The lexer and parser may have been left in any state, so they are not reused
+			w.lexer, w.parser = nil, nil
+		}
+		result.Elapsed = time.Since(began)
+	}()
+
+	if w.errors == nil {
+		w.errors = &parseAllErrorListener{}
+	}
+	w.errors.result = &result
+
+	if l, ok := w.lexer.(interface{ SetInputStream(CharStream) }); ok {
+		l.SetInputStream(input)
+	} else {
+		w.lexer = w.lexerCtor(input)
+		w.lexer.RemoveErrorListeners()
+		w.lexer.AddErrorListener(w.errors)
+	}
+	stream := NewCommonTokenStream(w.lexer, TokenDefaultChannel)
+
+	if w.parser == nil {
+		w.parser = w.parserCtor(stream)
+		if w.config != nil {
+			w.config.Apply(w.parser)
+		}
+		w.parser.RemoveErrorListeners()
+		w.parser.AddErrorListener(w.errors)
+	} else {
+		runtimeConfigParser(w.parser).SetTokenStream(stream)
+	}
+
+	result.Tree = w.start(w.parser)
+	if e, ok := w.parser.GetError().(*ParseCancellationException); ok {
+		result.Err = e
+	}
+	result.Stats = w.parser.GetInterpreter().GetPredictionStats()
+	return result
+}
+
+// parseAllErrorListener collects the errors reported during [ParseAll] into the result of the input being
+// parsed.
+type parseAllErrorListener struct {
+	*DefaultErrorListener
+	result *ParseResult
+}
+
+func (l *parseAllErrorListener) SyntaxError(recognizer Recognizer, offendingSymbol interface{}, line, column int, msg string, e RecognitionException) {
+	l.result.Errors = append(l.result.Errors, SyntaxError{
+		Line:             line,
+		Column:           column,
+		SyntaxDiagnostic: NewSyntaxDiagnostic(recognizer, offendingSymbol, line, column, msg, e),
+	})
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseAll(t *testing.T) {
+	texts := []string{"a b + c", "a +", "boom", "d", "e + f g", "h h h"}
+	inputs := make([]CharStream, 0, 4*len(texts))
+	for i := 0; i < 4; i++ {
+		for _, text := range texts {
+			inputs = append(inputs, NewInputStream(text))
+		}
+	}
+	var lexers, parsers atomic.Int32
+	results, stats := ParseAll(inputs,
+		func(input CharStream) Lexer { lexers.Add(1); return newListLexer(input) },
+		func(input TokenStream) Parser { parsers.Add(1); return newListParser(input) },
+		func(p Parser) ParseTree {
+			if p.GetTokenStream().LT(1).GetText() == "boom" {
+				panic("boom")
+			}
+			return p.(*listParser).S()
+		},
+		&ParseAllOptions{Workers: 3})
+
+	for i, r := range results {
+		text := texts[i%len(texts)]
+		switch text {
+		case "boom":
+			if r.Tree != nil || r.Err == nil || !strings.Contains(r.Err.Error(), "panic during parse: boom") || r.OK() {
+				t.Errorf("%q: tree %v, error %v", text, r.Tree, r.Err)
+			}
+		case "a +":
+			if len(r.Errors) != 1 || fmt.Sprint(r.Errors[0]) != "line 1:3 missing ID at '<EOF>'" || r.OK() {
+				t.Errorf("%q: errors %v", text, r.Errors)
+			}
+		default:
+			p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(text)), TokenDefaultChannel))
+			if want := p.S().ToStringTree(nil, p); !r.OK() || r.Tree.ToStringTree(nil, p) != want {
+				t.Errorf("%q: tree %s, want %s, errors %v", text, r.Tree.ToStringTree(nil, p), want, r.Errors)
+			}
+		}
+	}
+
+	// the workers reuse their lexer and parser, except after a panic
+	if n := parsers.Load(); n < 3 || n > 3+4 || lexers.Load() != n {
+		t.Errorf("%d lexers and %d parsers were created", lexers.Load(), n)
+	}
+	var prediction PredictionStats
+	for _, r := range results {
+		prediction.add(r.Stats)
+	}
+	if stats.Inputs != len(inputs) || stats.Failed != 8 || stats.Prediction != prediction || prediction.Decisions == 0 {
+		t.Errorf("stats %+v", stats)
+	}
+}
+
+func TestParseAllConfig(t *testing.T) {
+	inputs := []CharStream{NewInputStream("a b"), NewInputStream("c")}
+	config := NewRuntimeConfig(WithMaxRuleDepth(1), WithErrorListeners(NewConsoleErrorListener()))
+	results, stats := ParseAll(inputs,
+		func(input CharStream) Lexer { return newListLexer(input) },
+		func(input TokenStream) Parser { return newListParser(input) },
+		func(p Parser) ParseTree { return p.(*listParser).S() },
+		&ParseAllOptions{Workers: 8, Config: config})
+	for i, r := range results {
+		var cancelled *ParseCancellationException
+		if !errors.As(r.Err, &cancelled) || !errors.Is(r.Err, ErrParseLimitExceeded) {
+			t.Errorf("input %d ended with %v", i, r.Err)
+		}
+	}
+	if stats.Failed != 2 {
+		t.Errorf("%d inputs failed", stats.Failed)
+	}
+	if results, _ := ParseAll(nil, nil, nil, nil, nil); len(results) != 0 {
+		t.Errorf("%d results for no inputs", len(results))
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "context"
+
+// SetContext makes the parser abort its parses once ctx is done, as when its deadline passes or it is
+// cancelled, so that servers can bound the time each request may spend parsing, however pathological its input.
+// The parser checks ctx every few hundred tokens consumed or [ATN] configurations created by adaptive
+// prediction, so that a long prediction is aborted as well as a long parse. An aborted parse sets the
+// parser's error to a [ParseCancellationException] whose cause is the cause of ctx being done, such as
+// [context.DeadlineExceeded], and returns as quickly as possible, see [BaseParser.GetPartialTree].
+//
+// The context applies to every parse until it is replaced; pass nil to remove it. It is checked as well as any
+// [ProgressFunc]. See [BaseParser.ParseWithContext] to set a context for one parse.
+func (p *BaseParser) SetContext(ctx context.Context) {
+	if p.progress != nil && p.progress.callback != nil {
+		// keep the counts and the start time of the progress callback
+		p.progress.ctx = ctx
+		p.progress.ctxCountdown = 0
+		return
+	}
+	p.setProgress(1, nil, ctx)
+}
+
+// GetContext returns the context set with [BaseParser.SetContext], or nil if there is none.
+func (p *BaseParser) GetContext() context.Context {
+	if p.progress == nil {
+		return nil
+	}
+	return p.progress.ctx
+}
+
+// ParseWithContext parses the token stream of the parser from its current position with the rule invoked by
+// start, aborting the parse once ctx is done, see [BaseParser.SetContext]. It returns the tree the start rule
+// returned, which is partial if the parse was aborted, and the [ParseCancellationException] of a parse that
+// was cancelled, whose cause is that of ctx if ctx is why. The context the parser had is restored before it
+// returns.
+//
+// Use:
+//
+//	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+//	defer cancel()
+//	tree, err := p.ParseWithContext(ctx, func() antlr.ParseTree { return p.Query() })
+//	if errors.Is(err, context.DeadlineExceeded) {
+//	    http.Error(w, "query too complex", http.StatusRequestEntityTooLarge)
+//	}
+func (p *BaseParser) ParseWithContext(ctx context.Context, start func() ParseTree) (ParseTree, error) {
+	saved := p.GetContext()
+	defer p.SetContext(saved)
+
+	p.SetContext(ctx)
+	tree := start()
+	if cancelled, ok := p.GetError().(*ParseCancellationException); ok {
+		return tree, cancelled
+	}
+	return tree, nil
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWithContext(t *testing.T) {
+	input := strings.Repeat("a b + c ", 1000)
+	newParser := func() *listParser {
+		p, _ := listParse("")
+		p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+		p.RemoveErrorListeners()
+		return p
+	}
+
+	p := newParser()
+	tree, err := p.ParseWithContext(context.Background(), func() ParseTree { return p.S() })
+	if err != nil || tree.GetChildCount() != 2001 {
+		t.Errorf("the parse ended with %v and %d children", err, tree.GetChildCount())
+	}
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	p = newParser()
+	tree, err = p.ParseWithContext(expired, func() ParseTree { return p.S() })
+	var cancelled *ParseCancellationException
+	if !errors.Is(err, context.DeadlineExceeded) || !errors.As(err, &cancelled) || p.GetPartialTree() != tree {
+		t.Errorf("the parse ended with %v", err)
+	}
+	if p.GetContext() != nil {
+		t.Error("the context of the parse was kept")
+	}
+
+	// the context is checked as well as the progress callback, which is cancelled part way through the parse
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p = newParser()
+	calls := 0
+	p.SetProgressCallback(100, func(progress ParseProgress) error {
+		calls++
+		if progress.TokensConsumed >= 1000 {
+			cancel()
+		}
+		return nil
+	})
+	p.SetContext(ctx)
+	p.S()
+	if err, _ := p.GetError().(error); !errors.Is(err, context.Canceled) || p.GetContext() != ctx {
+		t.Fatalf("the parse ended with %v", p.GetError())
+	}
+	if index := p.GetTokenStream().Index(); index < 1000 || index > 2000 || calls < 10 {
+		t.Errorf("the parse went on to token %d after %d calls", index, calls)
+	}
+	p.SetContext(nil)
+	if p.GetContext() != nil {
+		t.Error("SetContext(nil) kept the context")
+	}
+}
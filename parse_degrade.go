@@ -0,0 +1,181 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// DegradeAction is a way in which a [DegradePolicy] lowers the quality of a parse to bound the time it takes.
+type DegradeAction int
+
+const (
+	// DegradeToSLL switches adaptive prediction to [PredictionModeSLL], which is much faster for pathological
+	// decisions, but may report syntax errors for some valid input.
+	DegradeToSLL DegradeAction = iota + 1
+
+	// DegradeStopBuildingTrees stops building the parse tree. The tree returned holds what was built before,
+	// and the rules entered afterwards are not added to it.
+	DegradeStopBuildingTrees
+
+	// DegradeAbort aborts the parse, as a timeout set with [WithTimeout] does, so that it returns the tree
+	// built so far. The parser's error is set to a [ParseCancellationException] whose cause wraps
+	// [ErrParseLimitExceeded].
+	DegradeAbort
+)
+
+func (a DegradeAction) String() string {
+	switch a {
+	case DegradeToSLL:
+		return "SLL prediction"
+	case DegradeStopBuildingTrees:
+		return "no parse tree"
+	case DegradeAbort:
+		return "abort"
+	}
+	return fmt.Sprintf("DegradeAction(%d)", int(a))
+}
+
+// DegradeStep is a step of a [DegradePolicy]: the action it takes once the parse has taken longer than After.
+type DegradeStep struct {
+	After  time.Duration
+	Action DegradeAction
+}
+
+// Degradation records a step that a [DegradePolicy] took during a parse, and when it took it.
+type Degradation struct {
+	Action DegradeAction
+
+	// Elapsed is the time since the parse started, and TokensConsumed the number of tokens consumed, when the
+	// action was taken
+	Elapsed        time.Duration
+	TokensConsumed int
+}
+
+// DegradePolicy degrades a parse in steps as it exceeds its time budget, rather than aborting it outright as
+// [WithTimeout] does, so that services can still get some result from pathological input. Each step takes an
+// action once the parse has taken longer than the time given for it, such as switching prediction to SLL
+// after 100ms, no longer building the tree after 500ms, and aborting with the partial tree after 2s. The
+// policy records the steps it took, so that callers can tell that a result is degraded, and how.
+//
+// The time is checked every thousand tokens or [ATN] configurations, with the progress callback of the
+// parser, see [BaseParser.SetProgressCallback], so a step may be taken a little late. A policy holds the state
+// of one parse, so each parser needs its own.
+//
+// Use:
+//
+//	policy := antlr.NewDegradePolicy(
+//	    antlr.DegradeStep{After: 100 * time.Millisecond, Action: antlr.DegradeToSLL},
+//	    antlr.DegradeStep{After: 500 * time.Millisecond, Action: antlr.DegradeStopBuildingTrees},
+//	    antlr.DegradeStep{After: 2 * time.Second, Action: antlr.DegradeAbort},
+//	)
+//	policy.Apply(p)
+//	tree := p.Start()
+//	for _, d := range policy.Degradations() {
+//	    log.Printf("parse degraded to %v after %v", d.Action, d.Elapsed)
+//	}
+//	policy.Reset()
+type DegradePolicy struct {
+	steps []DegradeStep
+
+	// next is the index of the next step to take
+	next  int
+	taken []Degradation
+
+	parser *BaseParser
+
+	// predictionMode and buildParseTrees are the settings of the parser when the policy was applied, which
+	// Reset restores
+	predictionMode  int
+	buildParseTrees bool
+
+	// callback is the progress callback the parser had when the policy was applied, which is still called,
+	// and interval the interval at which the policy is called
+	callback ProgressFunc
+	interval int
+}
+
+// NewDegradePolicy creates a policy that takes the given steps, in order of their times.
+func NewDegradePolicy(steps ...DegradeStep) *DegradePolicy {
+	sorted := append([]DegradeStep(nil), steps...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].After < sorted[j].After })
+	return &DegradePolicy{steps: sorted}
+}
+
+// Apply installs the policy on parser p, as its progress callback, and remembers the settings of p that the
+// policy may change, for Reset to restore. Any progress callback that p already has, such as the one that
+// checks the limits of a [RuntimeConfig], is still called, so apply a config before the policy. Applying the
+// policy again to the same parser resets it, and keeps the callback it already wraps rather than wrapping
+// itself. It panics if p does not embed [BaseParser].
+func (d *DegradePolicy) Apply(p Parser) {
+	bp := runtimeConfigParser(p)
+	if d.parser == bp {
+		d.Reset()
+		bp.SetProgressCallback(d.interval, d.check)
+		return
+	}
+	d.parser = bp
+	d.predictionMode = bp.Interpreter.GetPredictionMode()
+	d.buildParseTrees = bp.BuildParseTrees
+	d.next = 0
+	d.taken = nil
+
+	d.interval = runtimeConfigCheckInterval
+	d.callback = nil
+	if bp.progress != nil && bp.progress.callback != nil {
+		d.callback = bp.progress.callback
+		d.interval = min(d.interval, bp.progress.interval)
+	}
+	bp.SetProgressCallback(d.interval, d.check)
+}
+
+// check takes the steps whose time has come, and calls the callback the parser had before.
+func (d *DegradePolicy) check(progress ParseProgress) error {
+	for d.next < len(d.steps) && progress.Elapsed > d.steps[d.next].After {
+		step := d.steps[d.next]
+		d.next++
+		d.taken = append(d.taken, Degradation{
+			Action:         step.Action,
+			Elapsed:        progress.Elapsed,
+			TokensConsumed: progress.TokensConsumed,
+		})
+		switch step.Action {
+		case DegradeToSLL:
+			d.parser.Interpreter.SetPredictionMode(PredictionModeSLL)
+		case DegradeStopBuildingTrees:
+			d.parser.BuildParseTrees = false
+		case DegradeAbort:
+			return fmt.Errorf("%w: parse took longer than %v", ErrParseLimitExceeded, step.After)
+		}
+	}
+	if d.callback != nil {
+		return d.callback(progress)
+	}
+	return nil
+}
+
+// Degradations returns the steps the policy has taken since it was applied or reset, in the order taken.
+func (d *DegradePolicy) Degradations() []Degradation {
+	return d.taken
+}
+
+// Degraded returns true if the policy has taken any step since it was applied or reset.
+func (d *DegradePolicy) Degraded() bool {
+	return len(d.taken) > 0
+}
+
+// Reset restores the settings of the parser that the steps taken changed, and forgets the steps, so that the
+// policy is ready for the next parse. The elapsed time is measured from when the parser was last reset, so
+// reset the parser as well, as setting its input does.
+func (d *DegradePolicy) Reset() {
+	if d.parser != nil {
+		d.parser.Interpreter.SetPredictionMode(d.predictionMode)
+		d.parser.BuildParseTrees = d.buildParseTrees
+	}
+	d.next = 0
+	d.taken = nil
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDegradePolicy(t *testing.T) {
+	// 4000 tokens, so that the policy is checked every thousand
+	input := strings.Repeat("a b + c ", 1000)
+	p, _ := listParse("")
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+	calls := 0
+	p.SetProgressCallback(500, func(ParseProgress) error {
+		calls++
+		return nil
+	})
+	policy := NewDegradePolicy(
+		DegradeStep{After: time.Hour, Action: DegradeAbort},
+		DegradeStep{After: time.Nanosecond, Action: DegradeStopBuildingTrees},
+		DegradeStep{After: 0, Action: DegradeToSLL},
+	)
+	policy.Apply(p)
+	tree := p.S()
+
+	// both steps are taken at the first check, and the parse goes on to the end without building the tree
+	taken := policy.Degradations()
+	if len(taken) != 2 || taken[0].Action != DegradeToSLL || taken[1].Action != DegradeStopBuildingTrees ||
+		taken[1].TokensConsumed != 500 || taken[1].Elapsed <= 0 {
+		t.Errorf("steps taken %+v", taken)
+	}
+	if p.GetError() != nil || p.GetTokenStream().LA(1) != TokenEOF || tree.GetChildCount() >= 2000 {
+		t.Errorf("the parse ended with %v and %d items", p.GetError(), tree.GetChildCount())
+	}
+	if calls != 8 {
+		t.Errorf("the callback of the parser was called %d times, want 8", calls)
+	}
+	if p.Interpreter.GetPredictionMode() != PredictionModeSLL || p.BuildParseTrees || !policy.Degraded() {
+		t.Error("the steps taken did not change the parser")
+	}
+
+	policy.Reset()
+	if p.Interpreter.GetPredictionMode() != PredictionModeLL || !p.BuildParseTrees || policy.Degraded() {
+		t.Error("Reset did not restore the parser")
+	}
+
+	// applying the policy again keeps the callback it wraps, rather than wrapping itself
+	policy.Apply(p)
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+	calls = 0
+	p.S()
+	if calls != 8 || len(policy.Degradations()) != 2 {
+		t.Errorf("the callback was called %d times, and %d steps taken", calls, len(policy.Degradations()))
+	}
+}
+
+func TestDegradePolicyAbort(t *testing.T) {
+	p, _ := listParse("")
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream(strings.Repeat("a ", 3000))), TokenDefaultChannel))
+	policy := NewDegradePolicy(DegradeStep{Action: DegradeAbort})
+	policy.Apply(p)
+	tree := p.S()
+	if err, _ := p.GetError().(error); !errors.Is(err, ErrParseLimitExceeded) {
+		t.Fatalf("the parse ended with %v", p.GetError())
+	}
+	if n := tree.GetChildCount(); n == 0 || n >= 3000 {
+		t.Errorf("the aborted parse has %d items", n)
+	}
+	if taken := policy.Degradations(); len(taken) != 1 || taken[0].Action.String() != "abort" {
+		t.Errorf("steps taken %+v", taken)
+	}
+	if got := DegradeAction(9).String(); got != "DegradeAction(9)" {
+		t.Errorf("String() = %s", got)
+	}
+}
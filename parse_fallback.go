@@ -0,0 +1,81 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"slices"
+)
+
+// ErrSyntax is wrapped by the errors that report syntax errors in the input, such as the cause of the
+// [ParseCancellationException] of a parse cancelled by a [BailErrorStrategy], and the error returned by
+// [BaseParser.ParseWithFallback].
+var ErrSyntax = errors.New("syntax error")
+
+// ParseWithFallback parses the token stream of the parser from its first token with the rule invoked by start,
+// using the two-stage strategy recommended for speed. The first stage parses with [PredictionModeSLL] and a
+// [BailErrorStrategy], which is fast, and succeeds for most input. If the first stage finds a syntax error,
+// which may be real or may only be a limit of SLL prediction, the parser is reset, the stream rewound, and
+// the input parsed again with [PredictionModeLL] and the parser's own error strategy, which reports and
+// recovers from any real errors as usual. Errors are reported to the error listeners only by the second stage.
+//
+// It returns the tree of the stage that completed, and the [SyntaxErrors] that the second stage reported, if
+// any, which wrap [ErrSyntax], or the [ParseCancellationException] of a parse that was cancelled, as by a limit
+// set in a [RuntimeConfig]. The prediction mode and error strategy of the parser are restored before it
+// returns.
+//
+// Use:
+//
+//	p := parser.NewMyParser(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+//	tree, err := p.ParseWithFallback(func() antlr.ParseTree { return p.Start() })
+//	if err != nil {
+//	    return err
+//	}
+func (p *BaseParser) ParseWithFallback(start func() ParseTree) (ParseTree, error) {
+	mode := p.Interpreter.GetPredictionMode()
+	handler := p.errHandler
+	listeners := p.listeners
+	defer func() {
+		p.Interpreter.SetPredictionMode(mode)
+		p.errHandler = handler
+		p.listeners = listeners
+	}()
+
+	// Stage 1: SLL, bailing out at the first error, which is not reported
+	p.Interpreter.SetPredictionMode(PredictionModeSLL)
+	p.errHandler = NewBailErrorStrategy()
+	p.listeners = nil
+	p.rewind()
+	tree := start()
+	cancelled, ok := p.GetError().(*ParseCancellationException)
+	if !ok {
+		return tree, nil
+	}
+	var bail *ParseCancellationError
+	if !errors.As(cancelled, &bail) {
+		return tree, cancelled
+	}
+
+	// Stage 2: full LL, unless an even stronger mode was set, with the parser's own error strategy
+	if mode == PredictionModeSLL {
+		p.Interpreter.SetPredictionMode(PredictionModeLL)
+	} else {
+		p.Interpreter.SetPredictionMode(mode)
+	}
+	p.errHandler = handler
+	collector := NewCollectingErrorListener(0)
+	p.listeners = append(slices.Clip(listeners), collector)
+	p.rewind()
+	tree = start()
+	if cancelled, ok := p.GetError().(*ParseCancellationException); ok {
+		return tree, cancelled
+	}
+	return tree, collector.SyntaxErrors()
+}
+
+// rewind resets the parser and rewinds its token stream to the first token.
+func (p *BaseParser) rewind() {
+	ResetStreamAndParser(p, p.input)
+}
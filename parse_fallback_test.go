@@ -0,0 +1,101 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestParseWithFallback(t *testing.T) {
+	tests := []struct {
+		input, tree string
+		errs        []string
+	}{
+		{"a b + c", "(s (item a) (item b + c) <EOF>)", nil},
+		// the errors are reported once, by the second stage, which recovers from them
+		{"a + + b c +", "(s (item a + + b) (item c + <missing >) <EOF>)",
+			[]string{"extraneous input '+' expecting ID", "missing ID at '<EOF>'"}},
+	}
+	for _, test := range tests {
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(test.input)), TokenDefaultChannel))
+		errs := &messageRecorder{}
+		p.RemoveErrorListeners()
+		p.AddErrorListener(errs)
+		handler := NewDefaultErrorStrategy()
+		p.SetErrorHandler(handler)
+		p.Interpreter.SetPredictionMode(PredictionModeLLExactAmbigDetection)
+		// the stream is rewound to its first token
+		p.GetTokenStream().Consume()
+
+		tree, err := p.ParseWithFallback(func() ParseTree { return p.S() })
+		if got := tree.ToStringTree(nil, p); got != test.tree {
+			t.Errorf("%q: tree %s, want %s", test.input, got, test.tree)
+		}
+		if fmt.Sprint(errs.messages) != fmt.Sprint(test.errs) || (err != nil) != (test.errs != nil) ||
+			(err != nil && !errors.Is(err, ErrSyntax)) {
+			t.Errorf("%q: %v, with errors %q reported", test.input, err, errs.messages)
+		}
+		if p.GetErrorHandler() != handler || p.Interpreter.GetPredictionMode() != PredictionModeLLExactAmbigDetection {
+			t.Errorf("%q: the error strategy or prediction mode was not restored", test.input)
+		}
+	}
+
+	// a parse cancelled other than by a syntax error is not parsed again
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a b")), TokenDefaultChannel))
+	p.SetMaxRuleDepth(1)
+	var cancelled *ParseCancellationException
+	if _, err := p.ParseWithFallback(func() ParseTree { return p.S() }); !errors.As(err, &cancelled) ||
+		!errors.Is(err, ErrParseLimitExceeded) || errors.Is(err, ErrSyntax) {
+		t.Errorf("ParseWithFallback() = %v", err)
+	}
+}
+
+func TestBailErrorStrategyCancels(t *testing.T) {
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a + + b c")), TokenDefaultChannel))
+	p.SetErrorHandler(NewBailErrorStrategy())
+	tree := p.S()
+	err, _ := p.GetError().(*ParseCancellationException)
+	if err == nil || !errors.Is(err, ErrSyntax) {
+		t.Fatalf("the parse ended with %v", p.GetError())
+	}
+	// the parse stops at the error, rather than going on with the rules that invoked the one that failed
+	if index := p.GetTokenStream().Index(); index != 2 || tree.GetChildCount() != 1 {
+		t.Errorf("the parse went on to token %d: %s", index, tree.ToStringTree(nil, p))
+	}
+}
+
+func TestParseCancellationError(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"a + + b", `syntax error at line 1:4 near "+", expecting ID, in rule item < s`},
+		{"a +", `syntax error at line 1:3 near <EOF>, expecting ID, in rule item < s`},
+	}
+	for _, test := range tests {
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(test.input)), TokenDefaultChannel))
+		p.SetErrorHandler(NewBailErrorStrategy())
+		p.S()
+		err := p.GetCancellationError()
+		if err == nil {
+			t.Fatalf("%q: the parse was not cancelled", test.input)
+		}
+		if err.Error() != test.want || !errors.Is(err, ErrSyntax) || !errors.Is(p.GetError().(error), err) {
+			t.Errorf("%q: %v, want %s", test.input, err, test.want)
+		}
+		if _, ok := err.Exception.(*InputMisMatchException); !ok || err.Expected.String() != "1" {
+			t.Errorf("%q: exception %v, expected %s", test.input, err.Exception, err.Expected)
+		}
+	}
+
+	// a parse cancelled for another reason has no cancellation error
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a")), TokenDefaultChannel))
+	p.SetMaxRuleDepth(1)
+	p.S()
+	if p.GetError() == nil || p.GetCancellationError() != nil {
+		t.Errorf("the parse ended with %v and %v", p.GetError(), p.GetCancellationError())
+	}
+}
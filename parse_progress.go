@@ -0,0 +1,128 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"context"
+	"time"
+)
+
+// ParseProgress holds the running counts for a parse, as passed to a [ProgressFunc].
+type ParseProgress struct {
+	// TokensConsumed is the number of tokens the parser has consumed
+	TokensConsumed int
+
+	// ConfigsCreated is the number of [ATN] configurations computed by adaptive prediction
+	ConfigsCreated int
+
+	// Elapsed is the time since the parse started
+	Elapsed time.Duration
+}
+
+// ProgressFunc is called periodically during a parse with the running counts for the parse. It allows the
+// host to implement its own policies for aborting a parse beyond a simple timeout, such as aborting when the
+// ratio of configurations to tokens explodes, which indicates an ambiguous grammar meeting adversarial input.
+//
+// If the func returns a non-nil error, the parse is aborted: the parser's error is set to a
+// [ParseCancellationException] whose cause is the returned error, no further error reporting or recovery
+// is attempted, and each rule returns as quickly as possible. See [BaseParser.SetProgressCallback].
+type ProgressFunc func(progress ParseProgress) error
+
+// contextCheckInterval is the number of tokens or configurations between checks of the context of a parse.
+const contextCheckInterval = 256
+
+// progressMonitor tracks the running counts for a parse, invokes the [ProgressFunc], if any, every interval
+// tokens or configurations, and checks the context of the parse, if any, every contextCheckInterval. It is
+// shared by the parser and its ATN simulator.
+type progressMonitor struct {
+	callback ProgressFunc
+	interval int
+	start    time.Time
+	progress ParseProgress
+
+	// nextTokens and nextConfigs are the counts at which the callback is next invoked
+	nextTokens  int
+	nextConfigs int
+
+	// ctx is the context of the parse, see BaseParser.SetContext, and ctxCountdown the number of tokens or
+	// configurations until it is next checked
+	ctx          context.Context
+	ctxCountdown int
+
+	// err is the error returned by the callback, if it aborted the parse
+	err error
+}
+
+func newProgressMonitor(interval int, callback ProgressFunc, ctx context.Context) *progressMonitor {
+	if interval < 1 {
+		interval = 1
+	}
+	m := &progressMonitor{
+		callback: callback,
+		interval: interval,
+		ctx:      ctx,
+	}
+	m.reset()
+	return m
+}
+
+func (m *progressMonitor) reset() {
+	m.start = time.Now()
+	m.progress = ParseProgress{}
+	m.nextTokens = m.interval
+	m.nextConfigs = m.interval
+	m.ctxCountdown = 0
+	m.err = nil
+}
+
+// tokenConsumed counts a consumed token, and returns the error if the parse has been aborted.
+func (m *progressMonitor) tokenConsumed() error {
+	m.progress.TokensConsumed++
+	m.tick()
+	if m.progress.TokensConsumed >= m.nextTokens {
+		m.nextTokens += m.interval
+		m.check()
+	}
+	return m.err
+}
+
+// configCreated counts a created configuration, and returns the error if the parse has been aborted.
+func (m *progressMonitor) configCreated() error {
+	m.progress.ConfigsCreated++
+	m.tick()
+	if m.progress.ConfigsCreated >= m.nextConfigs {
+		m.nextConfigs += m.interval
+		m.check()
+	}
+	return m.err
+}
+
+func (m *progressMonitor) check() {
+	if m.err != nil || m.callback == nil {
+		return
+	}
+	m.progress.Elapsed = time.Since(m.start)
+	m.err = m.callback(m.progress)
+}
+
+// tick checks the context of the parse, if it is time to, and aborts the parse with its cause if it is done.
+func (m *progressMonitor) tick() {
+	if m.ctx == nil || m.err != nil {
+		return
+	}
+	m.ctxCountdown--
+	if m.ctxCountdown > 0 {
+		return
+	}
+	m.ctxCountdown = contextCheckInterval
+	if m.ctx.Err() != nil {
+		m.err = context.Cause(m.ctx)
+	}
+}
+
+// aborted returns true if the callback has aborted the parse. It is safe to call on a nil monitor.
+func (m *progressMonitor) aborted() bool {
+	return m != nil && m.err != nil
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"testing"
+)
+
+// countingErrorListener counts the syntax errors reported to it.
+type countingErrorListener struct {
+	DefaultErrorListener
+	errors int
+}
+
+func (c *countingErrorListener) SyntaxError(_ Recognizer, _ interface{}, _, _ int, _ string, _ RecognitionException) {
+	c.errors++
+}
+
+func TestProgressCallbackAborts(t *testing.T) {
+	const input = "a b+c d e f g h"
+	listParse(input) // so that the DFA predicts the input without computing configurations
+
+	p, _ := listParse("")
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+	listener := new(countingErrorListener)
+	p.RemoveErrorListeners()
+	p.AddErrorListener(listener)
+	stop := errors.New("stop")
+	var tokens []int
+	p.SetProgressCallback(2, func(progress ParseProgress) error {
+		tokens = append(tokens, progress.TokensConsumed)
+		if progress.TokensConsumed >= 4 {
+			return stop
+		}
+		return nil
+	})
+	tree := p.S()
+
+	if len(tokens) != 2 || tokens[0] != 2 || tokens[1] != 4 {
+		t.Errorf("called back at %v tokens, want [2 4]", tokens)
+	}
+	var cancelled *ParseCancellationException
+	if err, _ := p.GetError().(error); !errors.Is(err, stop) || !errors.As(err, &cancelled) {
+		t.Fatalf("the error of the parser is %v", p.GetError())
+	}
+	if listener.errors != 0 {
+		t.Errorf("%d syntax errors were reported for the cancelled parse", listener.errors)
+	}
+	// a and b + c
+	if got := tree.GetChildCount(); got != 2 {
+		t.Errorf("the tree of the cancelled parse %s has %d items", tree.ToStringTree(nil, p), got)
+	}
+	p.SetError(nil)
+	if p.GetError() != cancelled {
+		t.Error("the cancellation was cleared")
+	}
+}
+
+func TestProgressCallbackCounts(t *testing.T) {
+	lexer := newListLexer(NewInputStream("a b+c d"))
+	p := newListParser(NewCommonTokenStream(lexer, TokenDefaultChannel))
+	p.Interpreter = NewParserATNSimulator(p, p.GetATN(), newDFA(p.GetATN()), NewPredictionContextCache())
+	var last ParseProgress
+	p.SetProgressCallback(1, func(progress ParseProgress) error {
+		if progress.TokensConsumed < last.TokensConsumed || progress.ConfigsCreated < last.ConfigsCreated ||
+			progress.Elapsed < last.Elapsed {
+			t.Errorf("progress went back from %+v to %+v", last, progress)
+		}
+		last = progress
+		return nil
+	})
+	p.S()
+	// a b + c d and EOF
+	if p.GetError() != nil || last.TokensConsumed != 6 || last.ConfigsCreated == 0 {
+		t.Errorf("parse ended with %v and progress %+v", p.GetError(), last)
+	}
+
+	p.SetProgressCallback(1, nil)
+	last = ParseProgress{}
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream("a")), TokenDefaultChannel))
+	if p.S(); last.TokensConsumed != 0 {
+		t.Error("a removed callback was called")
+	}
+}
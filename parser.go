@@ -5,6 +5,8 @@
 package antlr
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strconv"
 )
@@ -46,8 +48,54 @@ type BaseParser struct {
 	tracer         *TraceListener
 	parseListeners []ParseTreeListener
 	_SyntaxErrors  int
+
+	progress  *progressMonitor
+	cancelled *ParseCancellationException
+
+	contextFactory RuleContextFactory
+	softKeywords   *SoftKeywords
+
+	// ruleDepth is the number of rule invocations in progress, and maxRuleDepth the limit on it, or 0 for none
+	ruleDepth    int
+	maxRuleDepth int
+
+	// validateOnly is set by SetValidateOnly
+	validateOnly bool
+
+	// skipSubtree holds the filters set with SetSkipSubtree by rule index, and skipDepth is the rule depth of
+	// the invocation whose subtree is being skipped, or 0 for none
+	skipSubtree map[int]SkipSubtreeFunc
+	skipDepth   int
+
+	// bailRules holds the rules set as bail regions with SetBailRegion, bailHandler is the error strategy
+	// within them, bailDepth is the rule depth of the invocation that began the bail region being parsed, or 0
+	// for none, and unwinding is the error the region is failing for, if any
+	bailRules   map[int]bool
+	bailHandler *bailRegionErrorStrategy
+	bailDepth   int
+	unwinding   RecognitionException
+
+	// partialTree is the root of the tree of a parse that was aborted, and abortMarker the node that marks
+	// where it stopped, see GetPartialTree
+	partialTree ParserRuleContext
+	abortMarker ErrorNode
+
+	// hooks are the hooks installed with SetHooks, and syncHook the strategy that GetErrorHandler returns to
+	// call the Sync hook, if there is one
+	hooks    ParserHooks
+	syncHook *syncHookErrorStrategy
+
+	// errorCode is the code of the syntax error being reported by the error strategy, if it gave one
+	errorCode DiagnosticCode
+
+	// reuse is the incremental parse that the parser is parsing for, if it may reuse subtrees of its last tree
+	reuse *IncrementalParse
 }
 
+// SkipSubtreeFunc decides, as a rule is entered, whether the parser skips building the subtree of the
+// invocation with the given context, whose parent and start token are set. See [BaseParser.SetSkipSubtree].
+type SkipSubtreeFunc func(ctx ParserRuleContext) bool
+
 // NewBaseParser contains all the parsing support code to embed in parsers. Essentially most of it is error
 // recovery stuff.
 //
@@ -94,14 +142,6 @@ func NewBaseParser(input TokenStream) *BaseParser {
 	return p
 }
 
-// This field maps from the serialized ATN string to the deserialized [ATN] with
-// bypass alternatives.
-//
-// [ATNDeserializationOptions.isGenerateRuleBypassTransitions]
-//
-//goland:noinspection GoUnusedGlobalVariable
-var bypassAltsAtnCache = make(map[string]int)
-
 // reset the parser's state//
 func (p *BaseParser) reset() {
 	if p.input != nil {
@@ -113,13 +153,288 @@ func (p *BaseParser) reset() {
 	p.SetTrace(nil)
 	p.precedenceStack = make([]int, 0)
 	p.precedenceStack.Push(0)
+	p.cancelled = nil
+	p.ruleDepth = 0
+	p.skipDepth = 0
+	p.bailDepth = 0
+	p.unwinding = nil
+	p.partialTree = nil
+	p.abortMarker = nil
+	if p.bailHandler != nil {
+		p.bailHandler.lastErrorIndex = -1
+	}
+	p.BaseRecognizer.SetError(nil)
+	if p.progress != nil {
+		p.progress.reset()
+	}
 	if p.Interpreter != nil {
 		p.Interpreter.reset()
 	}
+	if p.softKeywords != nil {
+		p.softKeywords.reset()
+	}
+}
+
+// SetError sets the current error of the parser. Once the parse has been cancelled, by a
+// [ProgressFunc] for instance, the error remains the [ParseCancellationException] and cannot
+// be cleared or replaced until the parser is reset. Within a bail region that is failing, see
+// [BaseParser.SetBailRegion], the error cannot be cleared until the region has returned, nor within a rule
+// whose subtree an [IncrementalParse] reuses until the rule has returned.
+func (p *BaseParser) SetError(err RecognitionException) {
+	if p.cancelled != nil {
+		err = p.cancelled
+	} else if err == nil && p.unwinding != nil {
+		err = p.unwinding
+	} else if err == nil && p.reusing() {
+		err = p.reuse.skipped
+	}
+	p.BaseRecognizer.SetError(err)
+}
+
+// GetCancellationError returns the [ParseCancellationError] describing the syntax error that a
+// [BailErrorStrategy] cancelled the parse for, or nil if the parse was not cancelled by one.
+func (p *BaseParser) GetCancellationError() *ParseCancellationError {
+	var c *ParseCancellationError
+	if p.cancelled != nil && errors.As(p.cancelled, &c) {
+		return c
+	}
+	return nil
+}
+
+// cancel aborts the parse with the given cause, and marks where it stopped in the tree being built.
+func (p *BaseParser) cancel(cause error) {
+	if p.cancelled == nil {
+		p.cancelled = newParseCancellationExceptionWithCause(cause)
+		p.markAbort()
+	}
+	p.SetError(p.cancelled)
+}
+
+// SetProgressCallback installs a [ProgressFunc] that is called with the running counts for the parse
+// each time another interval tokens are consumed, or another interval [ATN] configurations are created
+// by adaptive prediction. The elapsed time is measured from the call to SetProgressCallback, or from the
+// last time the parser was reset. If the callback returns an error, the parse is aborted and the
+// parser's error is set to a [ParseCancellationException] with that error as its cause.
+//
+// For example, to abort a parse when the grammar is behaving pathologically for the input:
+//
+//	p.SetProgressCallback(1000, func(progress antlr.ParseProgress) error {
+//	    if progress.ConfigsCreated > 10000*(progress.TokensConsumed+1) {
+//	        return errors.New("too much work per token")
+//	    }
+//	    return nil
+//	})
+//
+// Pass a nil callback to remove it.
+func (p *BaseParser) SetProgressCallback(interval int, callback ProgressFunc) {
+	p.setProgress(interval, callback, p.GetContext())
+}
+
+// setProgress installs a progress monitor that calls callback and checks ctx, or none if neither is set.
+func (p *BaseParser) setProgress(interval int, callback ProgressFunc, ctx context.Context) {
+	if callback == nil && ctx == nil {
+		p.progress = nil
+	} else {
+		p.progress = newProgressMonitor(interval, callback, ctx)
+	}
+	if p.Interpreter != nil {
+		p.Interpreter.progress = p.progress
+	}
+}
+
+// SetRuleContextFactory installs a [RuleContextFactory] that the parser consults each time it creates the
+// context for a rule invocation, so that the parse tree can be built from the user's own
+// [ParserRuleContext] implementations. Pass nil to remove the factory.
+func (p *BaseParser) SetRuleContextFactory(factory RuleContextFactory) {
+	p.contextFactory = factory
+}
+
+// GetRuleContextFactory returns the [RuleContextFactory] installed with [BaseParser.SetRuleContextFactory],
+// or nil if there is none.
+func (p *BaseParser) GetRuleContextFactory() RuleContextFactory {
+	return p.contextFactory
+}
+
+// NewRuleContext asks the installed [RuleContextFactory] for the context of an invocation of the rule with
+// the given index. It returns nil if there is no factory, or if the factory declines to create a context
+// for the rule, in which case the caller creates the rule's own context type as usual.
+func (p *BaseParser) NewRuleContext(parent ParserRuleContext, invokingState, ruleIndex int) ParserRuleContext {
+	if p.contextFactory == nil {
+		return nil
+	}
+	return p.contextFactory(parent, invokingState, ruleIndex)
+}
+
+// SetSoftKeywords installs a set of [SoftKeywords], which the parser changes to identifiers wherever it
+// does not expect them. Pass nil to remove them.
+func (p *BaseParser) SetSoftKeywords(keywords *SoftKeywords) {
+	p.softKeywords = keywords
+}
+
+// SetMaxRuleDepth limits the nesting of rule invocations during a parse to depth, so that deeply nested input
+// cannot exhaust the stack. A parse that exceeds the limit is aborted, and the parser's error is set to a
+// [ParseCancellationException] whose cause wraps [ErrParseLimitExceeded]. Pass 0 to remove the limit.
+func (p *BaseParser) SetMaxRuleDepth(depth int) {
+	p.maxRuleDepth = depth
+}
+
+// GetMaxRuleDepth returns the limit set with [BaseParser.SetMaxRuleDepth], or 0 if there is none.
+func (p *BaseParser) GetMaxRuleDepth() int {
+	return p.maxRuleDepth
+}
+
+// enterRuleDepth counts a rule invocation, and aborts the parse if it exceeds the maximum depth.
+func (p *BaseParser) enterRuleDepth() {
+	p.ruleDepth++
+	if p.maxRuleDepth > 0 && p.ruleDepth > p.maxRuleDepth {
+		p.cancel(fmt.Errorf("%w: rule invocations nested deeper than %d", ErrParseLimitExceeded, p.maxRuleDepth))
+	}
+}
+
+// SetValidateOnly sets whether the parser only validates its input, for checks of whether input is
+// syntactically valid that need the most throughput. In this mode the parser does the least work beyond
+// recognizing the input and reporting errors: it builds no parse tree, whatever BuildParseTrees says, calls
+// no parse listeners, including the tracer, creates no error nodes for conjured tokens, and does not record
+// the start and stop tokens, alternative numbers or the links of left-recursive rules on the contexts it is
+// given. Generated rules still create their contexts, as the parser needs them to track the rule
+// invocation stack, but nothing holds on to them once their rule returns, so the tree returned by the start
+// rule is empty.
+//
+// Errors are reported to the error listeners as usual, so use it with an error listener that counts errors,
+// or with a [BailErrorStrategy] to stop at the first one:
+//
+//	p.SetValidateOnly(true)
+//	p.SetErrorHandler(antlr.NewBailErrorStrategy())
+//	p.Start()
+//	valid := p.GetError() == nil
+func (p *BaseParser) SetValidateOnly(validateOnly bool) {
+	p.validateOnly = validateOnly
+}
+
+// IsValidateOnly returns true if the parser only validates its input, see [BaseParser.SetValidateOnly].
+func (p *BaseParser) IsValidateOnly() bool {
+	return p.validateOnly
+}
+
+// SetSkipSubtree installs a filter for the rule with the given index, which the parser calls each time it
+// enters the rule while building a parse tree. If the filter returns true, the parser parses the rule as
+// usual, consuming its tokens and reporting its errors, but builds no subtree for it: the context of the
+// invocation is added to the tree as a single opaque node with no children, whose start and stop tokens span
+// the tokens it matched. This lets tools that extract only the top-level structure of huge inputs avoid
+// materializing the deep subtrees of rules such as expressions:
+//
+//	p.SetSkipSubtree(parser.MyParserRULE_expr, func(ctx antlr.ParserRuleContext) bool { return true })
+//	tree := p.CompilationUnit()
+//	for _, expr := range exprs(tree) {
+//	    text := p.GetTokenStream().GetTextFromTokens(expr.GetStart(), expr.GetStop())
+//	}
+//
+// Filters are not called for rules entered within a subtree being skipped. Parse listeners are still told
+// about the rules and tokens within it, but the contexts they are given are not part of the tree. Pass a nil
+// filter to remove the filter for the rule.
+func (p *BaseParser) SetSkipSubtree(ruleIndex int, skip SkipSubtreeFunc) {
+	if skip == nil {
+		delete(p.skipSubtree, ruleIndex)
+		return
+	}
+	if p.skipSubtree == nil {
+		p.skipSubtree = make(map[int]SkipSubtreeFunc)
+	}
+	p.skipSubtree[ruleIndex] = skip
+}
+
+// skipping returns true if the parser is within a subtree that it is not building, see
+// [BaseParser.SetSkipSubtree].
+func (p *BaseParser) skipping() bool {
+	return p.skipDepth > 0 && p.ruleDepth >= p.skipDepth
+}
+
+// startSkipping starts skipping the subtree of the rule invocation just entered, if a filter says so.
+func (p *BaseParser) startSkipping(ruleIndex int) {
+	if p.skipSubtree == nil || p.skipDepth > 0 || !p.BuildParseTrees {
+		return
+	}
+	if skip, ok := p.skipSubtree[ruleIndex]; ok && skip(p.ctx) {
+		p.skipDepth = p.ruleDepth
+	}
+}
+
+// stopSkipping stops skipping once the rule invocation whose subtree was skipped has returned.
+func (p *BaseParser) stopSkipping() {
+	if p.skipDepth > p.ruleDepth {
+		p.skipDepth = 0
+	}
+}
+
+// GetSoftKeywords returns the [SoftKeywords] installed with [BaseParser.SetSoftKeywords], or nil if there
+// are none.
+func (p *BaseParser) GetSoftKeywords() *SoftKeywords {
+	return p.softKeywords
+}
+
+// SetState sets the current [ATN] state of the parser. Generated code calls it before each decision and each
+// token match, so it is also where any [SoftKeywords] are resolved against the tokens the parser expects, and
+// where the state observer of the ATN, if any, is told that the state has been reached.
+func (p *BaseParser) SetState(v int) {
+	p.BaseRecognizer.SetState(v)
+	if p.Interpreter != nil && p.Interpreter.atn.observer != nil && v >= 0 && v < len(p.Interpreter.atn.states) {
+		p.Interpreter.atn.observer(p.Interpreter.atn.states[v], false)
+	}
+	if p.softKeywords != nil {
+		p.softKeywords.resolve(p)
+	}
+}
+
+// GetRuleDecision returns the decision that selects between the outer alternatives of the rule with the given
+// index, or -1 if the rule has only one alternative and so there is no decision.
+func (p *BaseParser) GetRuleDecision(ruleIndex int) int {
+	if block := p.Interpreter.atn.getRuleBlock(ruleIndex); block != nil {
+		return block.getDecision()
+	}
+	return -1
+}
+
+// GetContextAltLabel returns the label, registered with [BaseRecognizer.SetAltLabel], of the outer alternative
+// that matched for the given context, and whether there is one.
+func (p *BaseParser) GetContextAltLabel(ctx ParserRuleContext) (string, bool) {
+	alt := ctx.GetAltNumber()
+	if alt == ATNInvalidAltNumber {
+		return "", false
+	}
+	return p.GetAltLabel(p.GetRuleDecision(ctx.GetRuleIndex()), alt)
+}
+
+// RegisterAltLabels registers the labels given in the grammar to the outer alternatives of the rule with the
+// given index, in order, with an empty string for each alternative that has no label. Generated code, or
+// users, call this once the parser is created. The parser then records the label of the alternative that
+// matched in each context of the rule, which returns it from AltLabel if it embeds [BaseParserRuleContext],
+// and diagnostics name the alternatives of the rule by their labels as well as their numbers.
+//
+// Use:
+//
+//	p.RegisterAltLabels(parser.MyParserRULE_stat, "Assign", "Call", "")
+func (p *BaseParser) RegisterAltLabels(ruleIndex int, labels ...string) {
+	decision := p.GetRuleDecision(ruleIndex)
+	for i, label := range labels {
+		if label != "" {
+			p.SetAltLabel(decision, i+1, label)
+		}
+	}
 }
 
 func (p *BaseParser) GetErrorHandler() ErrorStrategy {
-	return p.errHandler
+	strategy := p.errHandler
+	if p.bailDepth > 0 {
+		strategy = p.bailHandler
+	}
+	if p.reusing() {
+		strategy = reusedSubtreeErrorStrategy{}
+	}
+	if p.syncHook != nil {
+		p.syncHook.ErrorStrategy = strategy
+		return p.syncHook
+	}
+	return strategy
 }
 
 func (p *BaseParser) SetErrorHandler(e ErrorStrategy) {
@@ -144,18 +459,21 @@ func (p *BaseParser) SetErrorHandler(e ErrorStrategy) {
 // mismatched symbol
 
 func (p *BaseParser) Match(ttype int) Token {
+	if p.reusing() {
+		return nil
+	}
 
 	t := p.GetCurrentToken()
 
 	if t.GetTokenType() == ttype {
-		p.errHandler.ReportMatch(p)
+		p.GetErrorHandler().ReportMatch(p)
 		p.Consume()
 	} else {
-		t = p.errHandler.RecoverInline(p)
+		t = p.GetErrorHandler().RecoverInline(p)
 		if p.HasError() {
 			return nil
 		}
-		if p.BuildParseTrees && t.GetTokenIndex() == -1 {
+		if p.BuildParseTrees && !p.validateOnly && !p.skipping() && t.GetTokenIndex() == -1 {
 
 			// we must have conjured up a new token during single token
 			// insertion if it's not the current symbol
@@ -183,13 +501,16 @@ func (p *BaseParser) Match(ttype int) Token {
 // symbol
 
 func (p *BaseParser) MatchWildcard() Token {
+	if p.reusing() {
+		return nil
+	}
 	t := p.GetCurrentToken()
 	if t.GetTokenType() > 0 {
-		p.errHandler.ReportMatch(p)
+		p.GetErrorHandler().ReportMatch(p)
 		p.Consume()
 	} else {
-		t = p.errHandler.RecoverInline(p)
-		if p.BuildParseTrees && t.GetTokenIndex() == -1 {
+		t = p.GetErrorHandler().RecoverInline(p)
+		if p.BuildParseTrees && !p.validateOnly && !p.skipping() && t.GetTokenIndex() == -1 {
 			// we must have conjured up a new token during single token
 			// insertion if it's not the current symbol
 			p.ctx.AddErrorNode(t)
@@ -308,6 +629,25 @@ func (p *BaseParser) GetInterpreter() *ParserATNSimulator {
 	return p.Interpreter
 }
 
+// ExportDFA encodes the DFA cache of the parser's ATN simulator, so that it can be loaded at startup with
+// ImportDFA. See [ParserATNSimulator.ExportDFA].
+func (p *BaseParser) ExportDFA() []byte {
+	return p.Interpreter.ExportDFA()
+}
+
+// ImportDFA loads a DFA cache encoded by ExportDFA into the parser's ATN simulator. See
+// [ParserATNSimulator.ImportDFA].
+func (p *BaseParser) ImportDFA(data []byte) error {
+	return p.Interpreter.ImportDFA(data)
+}
+
+// SetArena causes the parser's ATN simulator to allocate its configurations, and the prediction contexts
+// it pushes as it enters rules, from the given [Arena]. Passing nil reverts to heap allocation. To also
+// allocate tokens from the arena, call SetArena on the lexer that feeds this parser.
+func (p *BaseParser) SetArena(arena *Arena) {
+	p.Interpreter.SetArena(arena)
+}
+
 func (p *BaseParser) GetATN() *ATN {
 	return p.Interpreter.atn
 }
@@ -321,57 +661,50 @@ func (p *BaseParser) setTokenFactory(factory TokenFactory) {
 	p.input.GetTokenSource().setTokenFactory(factory)
 }
 
-// GetATNWithBypassAlts - the ATN with bypass alternatives is expensive to create, so we create it
-// lazily.
-func (p *BaseParser) GetATNWithBypassAlts() {
-
-	// TODO - Implement this?
-	panic("Not implemented!")
-
-	//	serializedAtn := p.getSerializedATN()
-	//	if (serializedAtn == nil) {
-	//		panic("The current parser does not support an ATN with bypass alternatives.")
-	//	}
-	//	result := p.bypassAltsAtnCache[serializedAtn]
-	//	if (result == nil) {
-	//		deserializationOptions := NewATNDeserializationOptions(nil)
-	//		deserializationOptions.generateRuleBypassTransitions = true
-	//		result = NewATNDeserializer(deserializationOptions).deserialize(serializedAtn)
-	//		p.bypassAltsAtnCache[serializedAtn] = result
-	//	}
-	//	return result
+// GetATNWithBypassAlts returns a copy of the parser's [ATN] with a bypass alternative for each rule, which
+// matches a single imaginary token in place of the whole rule, as needed to parse the rule tags of tree
+// patterns. The copy is expensive to create, so it is created the first time it is requested, and shared by
+// all the parsers that share the ATN. It returns [ErrNoBypassAlts] if the ATN was not deserialized, as only
+// the serialized form can be copied.
+func (p *BaseParser) GetATNWithBypassAlts() (*ATN, error) {
+	bypass := p.Interpreter.atn.getBypassAltsATN()
+	if bypass == nil {
+		return nil, ErrNoBypassAlts
+	}
+	return bypass, nil
 }
 
-// The preferred method of getting a tree pattern. For example, here's a
-// sample use:
+// CompileParseTreePattern compiles a tree pattern, which is parsed with the rule patternRuleIndex, into a
+// [ParseTreePattern] that trees parsed by this parser can be matched against. The text of the pattern is
+// tokenized with the lexer of the parser's token stream, which is then given back its input as it was, so
+// that patterns can be compiled during a parse; see [BaseParser.CompileParseTreePatternWithLexer] to use
+// another lexer.
 //
-// <pre>
-// ParseTree t = parser.expr()
-// ParseTreePattern p = parser.compileParseTreePattern("&ltID&gt+0",
-// MyParser.RULE_expr)
-// ParseTreeMatch m = p.Match(t)
-// String id = m.Get("ID")
-// </pre>
+// Use:
+//
+//	tree := p.Expr()
+//	pattern, err := p.CompileParseTreePattern("<ID> + 0", parser.MyParserRULE_expr)
+//	if err != nil {
+//	    return err
+//	}
+//	if m := pattern.Match(tree); m.Succeeded() {
+//	    fmt.Println(m.Get("ID").GetText())
+//	}
+func (p *BaseParser) CompileParseTreePattern(pattern string, patternRuleIndex int) (*ParseTreePattern, error) {
+	var lexer Lexer
+	if p.input != nil {
+		lexer, _ = p.input.GetTokenSource().(Lexer)
+	}
+	if lexer == nil {
+		return nil, fmt.Errorf("cannot compile pattern %q: the parser's token stream has no lexer", pattern)
+	}
+	return p.CompileParseTreePatternWithLexer(pattern, patternRuleIndex, lexer)
+}
 
-//goland:noinspection GoUnusedParameter
-func (p *BaseParser) compileParseTreePattern(pattern, patternRuleIndex, lexer Lexer) {
-
-	panic("NewParseTreePatternMatcher not implemented!")
-	//
-	//	if (lexer == nil) {
-	//		if (p.GetTokenStream() != nil) {
-	//			tokenSource := p.GetTokenStream().GetTokenSource()
-	//			if _, ok := tokenSource.(ILexer); ok {
-	//				lexer = tokenSource
-	//			}
-	//		}
-	//	}
-	//	if (lexer == nil) {
-	//		panic("Parser can't discover a lexer to use")
-	//	}
-
-	//	m := NewParseTreePatternMatcher(lexer, p)
-	//	return m.compile(pattern, patternRuleIndex)
+// CompileParseTreePatternWithLexer compiles a tree pattern as [BaseParser.CompileParseTreePattern] does, but
+// tokenizes the text of the pattern with lexer, which is then given back its input as it was.
+func (p *BaseParser) CompileParseTreePatternWithLexer(pattern string, patternRuleIndex int, lexer Lexer) (*ParseTreePattern, error) {
+	return NewParseTreePatternMatcher(lexer, p).Compile(pattern, patternRuleIndex)
 }
 
 func (p *BaseParser) GetInputStream() IntStream {
@@ -386,13 +719,77 @@ func (p *BaseParser) GetTokenStream() TokenStream {
 	return p.input
 }
 
-// SetTokenStream installs input as the token stream and resets the parser.
+// SetTokenStream installs input as the token stream and resets the parser. Note that the stream itself is
+// not rewound; use [ResetStreamAndParser] to parse the same stream again.
 func (p *BaseParser) SetTokenStream(input TokenStream) {
 	p.input = nil
 	p.reset()
 	p.input = input
 }
 
+// Reset restores the parser to the state it had before its first parse, so that it can be reused, and rewinds
+// its token stream to the first token. It clears the current context and the precedence stack, the error,
+// the count of syntax errors and any cancellation, the state of the error strategy, the tracer, the counts of
+// a progress callback and the state left by adaptive prediction. The parser's configuration, such as its
+// error listeners, parse listeners, prediction mode and error strategy, is retained, as is the shared DFA
+// cache.
+//
+// Use:
+//
+//	for _, input := range inputs {
+//	    p.ResetWithInputStream(antlr.NewCommonTokenStream(parser.NewMyLexer(input), antlr.TokenDefaultChannel))
+//	    tree := p.Start()
+//	}
+func (p *BaseParser) Reset() {
+	if p.input == nil {
+		p.reset()
+		return
+	}
+	ResetStreamAndParser(p, p.input)
+}
+
+// ResetWithInputStream installs input as the token stream of the parser and resets it, as [BaseParser.Reset]
+// does, rewinding input to its first token. See [ResetStreamAndParser].
+func (p *BaseParser) ResetWithInputStream(input TokenStream) {
+	ResetStreamAndParser(p, input)
+}
+
+// ResetStreamAndParser rewinds the token stream ts to its first token and installs it in the parser p,
+// resetting all of the parser's per-parse state: the current context, the error and error count, the
+// error strategy and the precedence stack. The parser's configuration, such as its error listeners, parse
+// listeners, prediction mode and error strategy, is retained, as is the shared DFA cache.
+//
+// The stream is rewound with Seek(0), so the tokens already buffered by a [CommonTokenStream] are reused
+// rather than being fetched again from the lexer, which has in any case already reached EOF. This is the
+// supported way to reparse the same input, as in the common two-stage parsing strategy, where the input is
+// first parsed with the fast SLL prediction mode, and reparsed with full LL only if that fails:
+//
+//	p.GetInterpreter().SetPredictionMode(antlr.PredictionModeSLL)
+//	p.SetErrorHandler(antlr.NewBailErrorStrategy())
+//	tree := p.Start()
+//	if p.HasError() {
+//	    antlr.ResetStreamAndParser(p, stream)
+//	    p.GetInterpreter().SetPredictionMode(antlr.PredictionModeLL)
+//	    p.SetErrorHandler(antlr.NewDefaultErrorStrategy())
+//	    tree = p.Start()
+//	}
+//
+// The parser must embed a [BaseParser], as all generated parsers do.
+func ResetStreamAndParser(p Parser, ts TokenStream) {
+	bp, ok := p.(interface{ SetTokenStream(TokenStream) })
+	if !ok {
+		panic("ResetStreamAndParser requires a parser that embeds BaseParser")
+	}
+	bp.SetTokenStream(ts)
+	ts.Seek(0)
+}
+
+// getBaseParser returns p itself, so that the BaseParser embedded in a generated parser can be found from
+// the [Parser].
+func (p *BaseParser) getBaseParser() *BaseParser {
+	return p
+}
+
 // GetCurrentToken returns the current token at LT(1).
 //
 // [Match] needs to return the current input symbol, which gets put
@@ -414,11 +811,36 @@ func (p *BaseParser) NotifyErrorListeners(msg string, offendingToken Token, err
 
 func (p *BaseParser) Consume() Token {
 	o := p.GetCurrentToken()
+	if p.hooks.BeforeConsume != nil {
+		p.hooks.BeforeConsume(p, o)
+	}
 	if o.GetTokenType() != TokenEOF {
 		p.GetInputStream().Consume()
 	}
+	if !p.validateOnly {
+		p.addConsumedToken(o)
+	}
+	// the progress is checked once the token is in the tree, so that a parse it aborts is marked after it
+	if p.progress != nil {
+		if err := p.progress.tokenConsumed(); err != nil {
+			p.cancel(err)
+		}
+	}
+	if p.hooks.AfterConsume != nil {
+		p.hooks.AfterConsume(p, o)
+	}
+	return o
+}
+
+// addConsumedToken adds the token just consumed to the tree, and tells the parse listeners about it.
+func (p *BaseParser) addConsumedToken(o Token) {
 	hasListener := p.parseListeners != nil && len(p.parseListeners) > 0
-	if p.BuildParseTrees || hasListener {
+	if p.skipping() {
+		// the token is not added to the subtree being skipped, but listeners are still told about it
+		if hasListener {
+			p.visitSkippedToken(o)
+		}
+	} else if p.BuildParseTrees || hasListener {
 		if p.errHandler.InErrorRecoveryMode(p) {
 			node := p.ctx.AddErrorNode(o)
 			if p.parseListeners != nil {
@@ -437,8 +859,24 @@ func (p *BaseParser) Consume() Token {
 		}
 		//        node.invokingState = p.state
 	}
+}
 
-	return o
+// visitSkippedToken tells the parse listeners about a token consumed within a subtree being skipped, with a
+// node that is not added to the tree.
+func (p *BaseParser) visitSkippedToken(o Token) {
+	if p.errHandler.InErrorRecoveryMode(p) {
+		node := NewErrorNodeImpl(o)
+		node.SetParent(p.ctx)
+		for _, l := range p.parseListeners {
+			l.VisitErrorNode(node)
+		}
+		return
+	}
+	node := NewTerminalNodeImpl(o)
+	node.SetParent(p.ctx)
+	for _, l := range p.parseListeners {
+		l.VisitTerminal(node)
+	}
 }
 
 func (p *BaseParser) addContextToParseTree() {
@@ -448,25 +886,42 @@ func (p *BaseParser) addContextToParseTree() {
 	}
 }
 
-func (p *BaseParser) EnterRule(localctx ParserRuleContext, state, _ int) {
-	p.SetState(state)
+func (p *BaseParser) EnterRule(localctx ParserRuleContext, state, ruleIndex int) {
+	p.BaseRecognizer.SetState(state)
+	p.enterRuleDepth()
+	p.enterBailRegion(ruleIndex)
 	p.ctx = localctx
+	if p.validateOnly {
+		return
+	}
 	p.ctx.SetStart(p.input.LT(1))
-	if p.BuildParseTrees {
+	if p.BuildParseTrees && p.skipDepth == 0 {
 		p.addContextToParseTree()
+		p.startSkipping(ruleIndex)
 	}
 	if p.parseListeners != nil {
 		p.TriggerEnterRuleEvent()
 	}
+	if p.reuse != nil {
+		p.reuse.enterRule(localctx)
+	}
 }
 
 func (p *BaseParser) ExitRule() {
-	p.ctx.SetStop(p.input.LT(-1))
+	if p.reuse != nil {
+		p.reuse.exitRule()
+	}
+	p.ruleDepth--
+	p.stopSkipping()
+	p.exitBailRegion()
+	if !p.validateOnly {
+		p.ctx.SetStop(p.input.LT(-1))
+	}
 	// trigger event on ctx, before it reverts to parent
-	if p.parseListeners != nil {
+	if p.parseListeners != nil && !p.validateOnly {
 		p.TriggerExitRuleEvent()
 	}
-	p.SetState(p.ctx.GetInvokingState())
+	p.BaseRecognizer.SetState(p.ctx.GetInvokingState())
 	if p.ctx.GetParent() != nil {
 		p.ctx = p.ctx.GetParent().(ParserRuleContext)
 	} else {
@@ -475,10 +930,20 @@ func (p *BaseParser) ExitRule() {
 }
 
 func (p *BaseParser) EnterOuterAlt(localctx ParserRuleContext, altNum int) {
+	if p.validateOnly {
+		p.ctx = localctx
+		return
+	}
 	localctx.SetAltNumber(altNum)
+	if p.altLabels != nil {
+		if c, ok := localctx.(interface{ setAltLabel(string) }); ok {
+			label, _ := p.GetAltLabel(p.GetRuleDecision(localctx.GetRuleIndex()), altNum)
+			c.setAltLabel(label)
+		}
+	}
 	// if we have a new localctx, make sure we replace existing ctx
 	// that is previous child of parse tree
-	if p.BuildParseTrees && p.ctx != localctx {
+	if p.BuildParseTrees && p.ctx != localctx && !(p.skipDepth > 0 && p.ruleDepth > p.skipDepth) {
 		if p.ctx.GetParent() != nil {
 			p.ctx.GetParent().(ParserRuleContext).RemoveLastChild()
 			p.ctx.GetParent().(ParserRuleContext).AddChild(localctx)
@@ -500,11 +965,17 @@ func (p *BaseParser) GetPrecedence() int {
 	return p.precedenceStack[len(p.precedenceStack)-1]
 }
 
-func (p *BaseParser) EnterRecursionRule(localctx ParserRuleContext, state, _, precedence int) {
-	p.SetState(state)
+func (p *BaseParser) EnterRecursionRule(localctx ParserRuleContext, state, ruleIndex, precedence int) {
+	p.BaseRecognizer.SetState(state)
+	p.enterRuleDepth()
+	p.enterBailRegion(ruleIndex)
 	p.precedenceStack.Push(precedence)
 	p.ctx = localctx
+	if p.validateOnly {
+		return
+	}
 	p.ctx.SetStart(p.input.LT(1))
+	p.startSkipping(ruleIndex)
 	if p.parseListeners != nil {
 		p.TriggerEnterRuleEvent() // simulates rule entry for
 		// left-recursive rules
@@ -515,6 +986,10 @@ func (p *BaseParser) EnterRecursionRule(localctx ParserRuleContext, state, _, pr
 // Like {@link //EnterRule} but for recursive rules.
 
 func (p *BaseParser) PushNewRecursionContext(localctx ParserRuleContext, state, _ int) {
+	if p.validateOnly {
+		p.ctx = localctx
+		return
+	}
 	previous := p.ctx
 	previous.SetParent(localctx)
 	previous.SetInvokingState(state)
@@ -522,7 +997,7 @@ func (p *BaseParser) PushNewRecursionContext(localctx ParserRuleContext, state,
 
 	p.ctx = localctx
 	p.ctx.SetStart(previous.GetStart())
-	if p.BuildParseTrees {
+	if p.BuildParseTrees && !p.skipping() {
 		p.ctx.AddChild(previous)
 	}
 	if p.parseListeners != nil {
@@ -532,7 +1007,14 @@ func (p *BaseParser) PushNewRecursionContext(localctx ParserRuleContext, state,
 }
 
 func (p *BaseParser) UnrollRecursionContexts(parentCtx ParserRuleContext) {
+	p.ruleDepth--
+	p.stopSkipping()
+	p.exitBailRegion()
 	_, _ = p.precedenceStack.Pop()
+	if p.validateOnly {
+		p.ctx = parentCtx
+		return
+	}
 	p.ctx.SetStop(p.input.LT(-1))
 	retCtx := p.ctx // save current ctx (return value)
 	// unroll so ctx is as it was before call to recursive method
@@ -546,7 +1028,7 @@ func (p *BaseParser) UnrollRecursionContexts(parentCtx ParserRuleContext) {
 	}
 	// hook into tree
 	retCtx.SetParent(parentCtx)
-	if p.BuildParseTrees && parentCtx != nil {
+	if p.BuildParseTrees && parentCtx != nil && !p.skipping() {
 		// add return ctx into invoking rule's tree
 		parentCtx.AddChild(retCtx)
 	}
@@ -678,6 +1160,28 @@ func (p *BaseParser) DumpDFA() {
 	}
 }
 
+// DumpPrecedenceDFA prints the DFA of every decision that uses a precedence DFA, which is to say the
+// decisions of left-recursive rules, including the start state cached for each precedence level. This is
+// useful to see what is being cached when debugging the performance of left-recursive expression rules.
+func (p *BaseParser) DumpPrecedenceDFA() {
+	p.Interpreter.atn.stateMu.RLock()
+	defer p.Interpreter.atn.stateMu.RUnlock()
+	p.Interpreter.atn.edgeMu.RLock()
+	defer p.Interpreter.atn.edgeMu.RUnlock()
+
+	seenOne := false
+	for _, dfa := range p.Interpreter.decisionToDFA {
+		if dfa.IsPrecedenceDfa() {
+			if seenOne {
+				fmt.Println()
+			}
+			fmt.Println("Decision " + strconv.Itoa(dfa.decision) + " (precedence):")
+			fmt.Print(dfa.PrecedenceString(p.LiteralNames, p.SymbolicNames))
+			seenOne = true
+		}
+	}
+}
+
 func (p *BaseParser) GetSourceName() string {
 	return p.GrammarFileName
 }
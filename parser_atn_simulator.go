@@ -42,6 +42,32 @@ type ParserATNSimulator struct {
 	dfa            *DFA
 	mergeCache     *JPCMap
 	outerContext   ParserRuleContext
+	progress       *progressMonitor
+
+	// differential is true if every prediction is also made the other way, SLL or full LL, to look for
+	// differences, and shadow is true while that second prediction is being made.
+	differential bool
+	shadow       bool
+
+	// stats counts the work done by prediction since the last reset, and simulated is true once the
+	// current prediction has had to simulate the ATN.
+	stats     PredictionStats
+	simulated bool
+
+	// profile holds the statistics of each decision while the profiler is on, see SetProfile.
+	profile *predictionProfile
+
+	// observer holds the decision listener while one is installed, see SetDecisionListener.
+	observer *decisionObserver
+
+	// ownDecisionToDFA is the DFA cache the simulator was created with, while it predicts with a
+	// DFASnapshot.
+	ownDecisionToDFA []*DFA
+
+	// pooling is true if the configurations of full context predictions are pooled, see SetConfigPooling,
+	// and pool is the pool in use during such a prediction.
+	pooling bool
+	pool    *configPool
 }
 
 //goland:noinspection GoUnusedExportedFunction
@@ -85,10 +111,249 @@ func (p *ParserATNSimulator) SetPredictionMode(v int) {
 }
 
 func (p *ParserATNSimulator) reset() {
+	p.stats = PredictionStats{}
+	p.simulated = false
+	p.shadow = false
+
+	// drop the state of the last prediction, so that it does not hold on to the last input
+	p.input = nil
+	p.startIndex = 0
+	p.outerContext = nil
+	p.dfa = nil
+	p.mergeCache = nil
+}
+
+// SetDifferentialPrediction turns differential prediction on or off. When it is on, every decision is
+// predicted both with SLL and with full LL, and each decision where the two predictions differ is reported
+// to any error listener that implements [PredictionDifferenceListener]. The parse itself proceeds with the
+// prediction made by the current prediction mode, and the usual ambiguity and context sensitivity reports
+// are made only for that prediction.
+//
+// This doubles the work done for each decision, and more for decisions that SLL would resolve without
+// falling back to full LL, so it is intended for validating grammar changes offline against a corpus of
+// inputs, not for production parsing. A decision reported as different is one where the fast two-stage
+// parsing strategy, SLL first and LL only on failure, may produce a different parse than LL alone.
+//
+// Use:
+//
+//	p.GetInterpreter().SetDifferentialPrediction(true)
+//	p.AddErrorListener(myDifferenceListener)
+func (p *ParserATNSimulator) SetDifferentialPrediction(enabled bool) {
+	p.differential = enabled
+}
+
+// GetDifferentialPrediction returns true if differential prediction is on. See
+// [ParserATNSimulator.SetDifferentialPrediction].
+func (p *ParserATNSimulator) GetDifferentialPrediction() bool {
+	return p.differential
+}
+
+// UseDFASnapshot makes the simulator predict with the DFA cache of snapshot, in place of the cache it was
+// created with, which is usually the cache shared by all parsers for the grammar. The snapshot is read
+// without locking and is never added to. Call it between parses, not during one, such as before each parse
+// with the current snapshot of a [DFAPublisher]. Pass nil to go back to the cache the simulator was
+// created with. It panics if the snapshot is of a different grammar, or a different version of the grammar,
+// than that of the simulator.
+func (p *ParserATNSimulator) UseDFASnapshot(snapshot *DFASnapshot) {
+	if snapshot != nil {
+		if err := p.atn.Fingerprint().Check(snapshot.fingerprint); err != nil {
+			panic(fmt.Sprintf("cannot use the DFA snapshot: %v", err))
+		}
+	}
+	if p.ownDecisionToDFA == nil {
+		p.ownDecisionToDFA = p.decisionToDFA
+	}
+	if snapshot == nil {
+		p.decisionToDFA = p.ownDecisionToDFA
+		return
+	}
+	p.decisionToDFA = snapshot.decisionToDFA
+}
+
+// ClearDFA discards the DFA cache the simulator was created with, which is usually the cache shared by all
+// parsers for the grammar, and the cache of prediction contexts it shares with them, so that the memory they
+// hold can be reclaimed, such as between the tenants of a service that have parsed many distinct inputs. The
+// cache is then built up again by the predictions that follow, as it is by a new parser. Call it between
+// parses, when no parser that shares the cache is parsing. A [DFASnapshot] in use is not affected.
+//
+// Use:
+//
+//	p.GetInterpreter().ClearDFA()
+//	p.GetATN().ReleaseCaches()
+func (p *ParserATNSimulator) ClearDFA() {
+	p.clearDFA(p.cacheDecisionToDFA())
+}
+
+// ExportDFA encodes the DFA cache the simulator was created with, which is usually the cache shared by all
+// parsers for the grammar, so that it can be saved, and loaded with [ParserATNSimulator.ImportDFA] by a
+// process that is starting up, which then skips the slow simulation of the [ATN] that the first parses of a
+// cold cache need. The cache is typically warmed offline, by parsing a corpus of representative input, and
+// exported as part of the build. The encoding is that of a delta of [DFASync], holding the whole cache, and
+// the fingerprint of the grammar, see [GrammarFingerprint]. It returns nil if the cache is empty. Parsers
+// may go on using the cache while it is exported.
+//
+// Use:
+//
+//	for _, input := range corpus {
+//	    p.SetInputStream(...)
+//	    p.Document()
+//	}
+//	err := os.WriteFile("grammar.dfa", p.GetInterpreter().ExportDFA(), 0o644)
+func (p *ParserATNSimulator) ExportDFA() []byte {
+	return NewDFASync(p.atn, p.cacheDecisionToDFA()).Export()
+}
+
+// ImportDFA adds the states and edges of a cache exported by [ParserATNSimulator.ExportDFA] to the DFA cache
+// the simulator was created with, which already may have states of its own. It returns an error wrapping
+// [ErrInvalidDFADelta] if the data is malformed, or was exported for a different grammar, or a different
+// version of the grammar, in which case the cache is left as it was. Importing nil does nothing. Parsers may
+// go on using the cache while it is imported.
+//
+// Use, at startup:
+//
+//	if data, err := os.ReadFile("grammar.dfa"); err == nil {
+//	    if err := p.GetInterpreter().ImportDFA(data); err != nil {
+//	        log.Printf("DFA cache not preloaded: %v", err)
+//	    }
+//	}
+func (p *ParserATNSimulator) ImportDFA(data []byte) error {
+	return NewDFASync(p.atn, p.cacheDecisionToDFA()).Import(data)
+}
+
+// cacheDecisionToDFA returns the DFA cache the simulator was created with, rather than a [DFASnapshot] it
+// may be using.
+func (p *ParserATNSimulator) cacheDecisionToDFA() []*DFA {
+	if p.ownDecisionToDFA != nil {
+		return p.ownDecisionToDFA
+	}
+	return p.decisionToDFA
+}
+
+// decision returns the number of the decision being predicted, or -1 if there is none.
+func (p *ParserATNSimulator) decision() int {
+	if p.dfa == nil {
+		return -1
+	}
+	return p.dfa.decision
+}
+
+// PredictionDifferenceListener may be implemented by an [ErrorListener] that wants to be told about the
+// decisions where SLL and full LL prediction differ, when differential prediction is turned on with
+// [ParserATNSimulator.SetDifferentialPrediction]. The input from startIndex to stopIndex is the lookahead
+// examined by the two predictions. Either alternative is [ATNInvalidAltNumber] if that prediction found no
+// viable alternative, which is the usual way in which SLL fails where LL succeeds.
+type PredictionDifferenceListener interface {
+	ReportPredictionDifference(recognizer Parser, dfa *DFA, startIndex, stopIndex, sllAlt, llAlt int)
+}
+
+// comparePredictions makes the prediction that the current prediction mode did not, SLL or full LL, for
+// the decision just predicted, and reports to the listeners if the two differ.
+func (p *ParserATNSimulator) comparePredictions(dfa *DFA, s0 *DFAState, input TokenStream, startIndex int, outerContext ParserRuleContext, predicted int) {
+	stopIndex := input.Index()
+	savedMode := p.predictionMode
+	p.shadow = true
+	defer func() {
+		p.predictionMode = savedMode
+		p.shadow = false
+	}()
+
+	input.Seek(startIndex)
+	sllAlt, llAlt := predicted, predicted
+	if savedMode == PredictionModeSLL {
+		s0Closure := p.computeStartState(dfa.atnStartState, outerContext, true)
+		llAlt, _ = p.execATNWithFullContext(dfa, nil, s0Closure, input, startIndex, outerContext)
+	} else {
+		p.predictionMode = PredictionModeSLL
+		sllAlt, _ = p.execATN(dfa, s0, input, startIndex, outerContext)
+	}
+	if input.Index() > stopIndex {
+		stopIndex = input.Index()
+	}
+
+	if sllAlt != llAlt && p.parser != nil {
+		if l, ok := p.parser.GetErrorListenerDispatch().(PredictionDifferenceListener); ok {
+			l.ReportPredictionDifference(p.parser, dfa, startIndex, stopIndex, sllAlt, llAlt)
+		}
+	}
+}
+
+// RetryWithFullContext predicts decision again with full LL, from the token with index startIndex, whatever
+// the prediction mode, as the simulator itself does when SLL prediction finds a conflict. It returns the
+// alternative predicted, or [ATNInvalidAltNumber] and the error if there is no viable alternative. The
+// context is that of the rule the decision is in, as given to AdaptivePredict; nil stands for the current
+// context of the parser.
+//
+// It lets an error strategy that recovers from a syntax error found by a parse in the SLL prediction mode
+// retry just the decision that failed in LL mode, rather than reparsing the whole input. The retry does not
+// add to the DFA, set the error of the parser or move the input, and is counted in the prediction stats as a
+// full context prediction. If the parse is cancelled during the retry, it returns [ATNInvalidAltNumber] and a
+// nil error.
+//
+// Use:
+//
+//	alt, err := p.GetInterpreter().RetryWithFullContext(decision, startIndex, p.GetParserRuleContext())
+//	if err == nil && alt != antlr.ATNInvalidAltNumber {
+//	    // resume the parse with alt
+//	}
+func (p *ParserATNSimulator) RetryWithFullContext(decision, startIndex int, outerContext ParserRuleContext) (alt int, err RecognitionException) {
+	if decision < 0 || decision >= len(p.decisionToDFA) {
+		panic(fmt.Sprintf("invalid decision %d", decision))
+	}
+	input := p.parser.GetTokenStream()
+	if isNilContext(outerContext) {
+		outerContext = p.parser.GetParserRuleContext()
+	}
+	if isNilContext(outerContext) {
+		outerContext = ParserRuleContextEmpty
+	}
+
+	savedInput, savedStartIndex, savedOuterContext, savedDFA := p.input, p.startIndex, p.outerContext, p.dfa
+	dfa := p.decisionToDFA[decision]
+	p.input = input
+	p.startIndex = startIndex
+	p.outerContext = outerContext
+	p.dfa = dfa
+	p.stats.FullContextPredictions++
+	m := input.Mark()
+	index := input.Index()
+	if p.profiling() {
+		end := p.profileBegin(decision)
+		defer func() { end(startIndex, alt) }()
+	}
+
+	p.resetClosure()
+	defer func() {
+		if r := recover(); r != nil {
+			loop, ok := r.(*LoopDetectedException)
+			if !ok {
+				panic(r)
+			}
+			alt, err = ATNInvalidAltNumber, loop
+		}
+		p.input, p.startIndex, p.outerContext, p.dfa = savedInput, savedStartIndex, savedOuterContext, savedDFA
+		p.mergeCache = nil
+		input.Seek(index)
+		input.Release(m)
+	}()
+
+	alt, err = p.withConfigPool(func() (int, RecognitionException) {
+		s0Closure := p.computeStartState(dfa.atnStartState, outerContext, true)
+		if p.progress.aborted() {
+			return ATNInvalidAltNumber, nil
+		}
+		return p.execATNWithFullContext(dfa, nil, s0Closure, input, startIndex, outerContext)
+	})
+	if p.progress.aborted() {
+		if bp, ok := p.parser.(interface{ getBaseParser() *BaseParser }); ok {
+			bp.getBaseParser().cancel(p.progress.err)
+		}
+		return ATNInvalidAltNumber, nil
+	}
+	return alt, err
 }
 
 //goland:noinspection GoBoolExpressions
-func (p *ParserATNSimulator) AdaptivePredict(parser *BaseParser, input TokenStream, decision int, outerContext ParserRuleContext) int {
+func (p *ParserATNSimulator) AdaptivePredict(parser *BaseParser, input TokenStream, decision int, outerContext ParserRuleContext) (predicted int) {
 	if runtimeConfig.parserATNSimulatorDebug || runtimeConfig.parserATNSimulatorTraceATNSim {
 		fmt.Println("adaptivePredict decision " + strconv.Itoa(decision) +
 			" exec LA(1)==" + p.getLookaheadName(input) +
@@ -101,10 +366,28 @@ func (p *ParserATNSimulator) AdaptivePredict(parser *BaseParser, input TokenStre
 
 	dfa := p.decisionToDFA[decision]
 	p.dfa = dfa
+	p.stats.Decisions++
+	p.simulated = false
 	m := input.Mark()
 	index := input.Index()
+	if p.profiling() {
+		end := p.profileBegin(decision)
+		defer func() { end(index, predicted) }()
+	}
+	if end := p.observeBegin(decision, index); end != nil {
+		defer func() { end(predicted) }()
+	}
 
+	p.resetClosure()
 	defer func() {
+		if r := recover(); r != nil {
+			loop, ok := r.(*LoopDetectedException)
+			if !ok {
+				panic(r)
+			}
+			parser.cancel(loop)
+			predicted = ATNInvalidAltNumber
+		}
 		p.dfa = nil
 		p.mergeCache = nil // whack cache after each prediction
 		// Do not attempt to run a GC now that we're done with the cache as makes the
@@ -121,22 +404,19 @@ func (p *ParserATNSimulator) AdaptivePredict(parser *BaseParser, input TokenStre
 
 	// Now we are certain to have a specific decision's DFA
 	// But, do we still need an initial state?
+	// The start state and the edges are published atomically, so they are read without locking
 	var s0 *DFAState
-	p.atn.stateMu.RLock()
 	if dfa.getPrecedenceDfa() {
-		p.atn.edgeMu.RLock()
 		// the start state for a precedence DFA depends on the current
 		// parser precedence, and is provided by a DFA method.
 		s0 = dfa.getPrecedenceStartState(p.parser.GetPrecedence())
-		p.atn.edgeMu.RUnlock()
 	} else {
 		// the start state for a "regular" DFA is just s0
 		s0 = dfa.getS0()
 	}
-	p.atn.stateMu.RUnlock()
 
 	if s0 == nil {
-		if outerContext == nil {
+		if isNilContext(outerContext) {
 			outerContext = ParserRuleContextEmpty
 		}
 		if runtimeConfig.parserATNSimulatorDebug {
@@ -145,30 +425,56 @@ func (p *ParserATNSimulator) AdaptivePredict(parser *BaseParser, input TokenStre
 				", outerContext=" + outerContext.String(p.parser.GetRuleNames(), nil))
 		}
 		fullCtx := false
+		p.simulated = true
 		s0Closure := p.computeStartState(dfa.atnStartState, ParserRuleContextEmpty, fullCtx)
+		if p.progress.aborted() {
+			// The start state is incomplete, so must not be cached
+			parser.cancel(p.progress.err)
+			return ATNInvalidAltNumber
+		}
 
-		p.atn.stateMu.Lock()
-		if dfa.getPrecedenceDfa() {
-			// If p is a precedence DFA, we use applyPrecedenceFilter
-			// to convert the computed start state to a precedence start
-			// state. We then use DFA.setPrecedenceStartState to set the
-			// appropriate start state for the precedence level rather
-			// than simply setting DFA.s0.
-			//
-			dfa.s0.configs = s0Closure
-			s0Closure = p.applyPrecedenceFilter(s0Closure)
+		if dfa.frozen {
+			// The start state is used for this prediction only, as a snapshot is never modified
+			if dfa.getPrecedenceDfa() {
+				s0Closure = p.applyPrecedenceFilter(s0Closure)
+			}
 			s0 = p.addDFAState(dfa, NewDFAState(-1, s0Closure))
-			p.atn.edgeMu.Lock()
-			dfa.setPrecedenceStartState(p.parser.GetPrecedence(), s0)
-			p.atn.edgeMu.Unlock()
 		} else {
-			s0 = p.addDFAState(dfa, NewDFAState(-1, s0Closure))
-			dfa.setS0(s0)
+			p.atn.stateMu.Lock()
+			if dfa.getPrecedenceDfa() {
+				// If p is a precedence DFA, we use applyPrecedenceFilter
+				// to convert the computed start state to a precedence start
+				// state. We then use DFA.setPrecedenceStartState to set the
+				// appropriate start state for the precedence level rather
+				// than simply setting DFA.s0.
+				//
+				dfa.getS0().configs = s0Closure
+				s0Closure = p.applyPrecedenceFilter(s0Closure)
+				s0 = p.addDFAState(dfa, NewDFAState(-1, s0Closure))
+				p.atn.edgeMu.Lock()
+				dfa.setPrecedenceStartState(p.parser.GetPrecedence(), s0)
+				p.atn.edgeMu.Unlock()
+			} else {
+				s0 = p.addDFAState(dfa, NewDFAState(-1, s0Closure))
+				dfa.setS0(s0)
+			}
+			p.atn.stateMu.Unlock()
 		}
-		p.atn.stateMu.Unlock()
 	}
 
 	alt, re := p.execATN(dfa, s0, input, index, outerContext)
+	if p.simulated {
+		p.stats.ATNSimulations++
+	} else {
+		p.stats.DFAHits++
+	}
+	if p.progress.aborted() {
+		parser.cancel(p.progress.err)
+		return ATNInvalidAltNumber
+	}
+	if p.differential {
+		p.comparePredictions(dfa, s0, input, index, outerContext, alt)
+	}
 	parser.SetError(re)
 	if runtimeConfig.parserATNSimulatorDebug {
 		fmt.Println("DFA after predictATN: " + dfa.String(p.parser.GetLiteralNames(), nil))
@@ -228,9 +534,14 @@ func (p *ParserATNSimulator) execATN(dfa *DFA, s0 *DFAState, input TokenStream,
 	t := input.LA(1)
 	for { // for more work
 		D := p.getExistingTargetState(previousD, t)
+		p.profileExistingTarget(previousD.configs, D)
+		p.observeLookahead(false)
 		if D == nil {
 			D = p.computeTargetState(dfa, previousD, t)
 		}
+		if p.progress.aborted() {
+			return ATNInvalidAltNumber, nil
+		}
 		if D == ATNSimulatorError {
 			// if any configs in previous dipped into outer context, that
 			// means that input up to t actually finished entry rule
@@ -278,10 +589,15 @@ func (p *ParserATNSimulator) execATN(dfa *DFA, s0 *DFAState, input TokenStream,
 				fmt.Println("ctx sensitive state " + outerContext.String(nil, nil) + " in " + D.String())
 			}
 			fullCtx := true
-			s0Closure := p.computeStartState(dfa.atnStartState, outerContext, fullCtx)
-			p.ReportAttemptingFullContext(dfa, conflictingAlts, D.configs, startIndex, input.Index())
-			alt, re := p.execATNWithFullContext(dfa, D, s0Closure, input, startIndex, outerContext)
-			return alt, re
+			p.simulated = true
+			if !p.shadow {
+				p.stats.FullContextPredictions++
+			}
+			return p.withConfigPool(func() (int, RecognitionException) {
+				s0Closure := p.computeStartState(dfa.atnStartState, outerContext, fullCtx)
+				p.ReportAttemptingFullContext(dfa, conflictingAlts, D.configs, startIndex, input.Index())
+				return p.execATNWithFullContext(dfa, D, s0Closure, input, startIndex, outerContext)
+			})
 		}
 		if D.isAcceptState {
 			if D.predicates == nil {
@@ -326,12 +642,7 @@ func (p *ParserATNSimulator) getExistingTargetState(previousD *DFAState, t int)
 		return nil
 	}
 
-	p.atn.edgeMu.RLock()
-	defer p.atn.edgeMu.RUnlock()
-	edges := previousD.getEdges()
-	if edges == nil || t+1 >= len(edges) {
-		return nil
-	}
+	// The edges are set atomically, so they are read without locking
 	return previousD.getIthEdge(t + 1)
 }
 
@@ -348,7 +659,13 @@ func (p *ParserATNSimulator) getExistingTargetState(previousD *DFAState, t int)
 //
 //goland:noinspection GoBoolExpressions
 func (p *ParserATNSimulator) computeTargetState(dfa *DFA, previousD *DFAState, t int) *DFAState {
+	p.simulated = true
 	reach := p.computeReachSet(previousD.configs, t, false)
+	p.profileReach(previousD.configs, reach, false)
+	if p.progress.aborted() {
+		// The reach set is incomplete, so must not be added to the DFA
+		return ATNSimulatorError
+	}
 
 	if reach == nil {
 		p.addDFAEdge(dfa, previousD, t, ATNSimulatorError)
@@ -431,6 +748,11 @@ func (p *ParserATNSimulator) execATNWithFullContext(dfa *DFA, D *DFAState, s0 *A
 
 	for { // for more work
 		reach = p.computeReachSet(previous, t, fullCtx)
+		p.profileReach(previous, reach, fullCtx)
+		p.observeLookahead(fullCtx)
+		if p.progress.aborted() {
+			return ATNInvalidAltNumber, nil
+		}
 		if reach == nil {
 			// if any configs in previous dipped into outer context, that
 			// means that input up to t actually finished entry rule
@@ -527,7 +849,7 @@ func (p *ParserATNSimulator) computeReachSet(closure *ATNConfigSet, t int, fullC
 	if p.mergeCache == nil {
 		p.mergeCache = NewJPCMap(ReachSetCollection, "Merge cache for computeReachSet()")
 	}
-	intermediate := NewATNConfigSet(fullCtx)
+	intermediate := p.newConfigSet(fullCtx)
 
 	// Configurations already in a rule stop state indicate reaching the end
 	// of the decision rule (local context) or end of the start rule (full
@@ -560,7 +882,7 @@ func (p *ParserATNSimulator) computeReachSet(closure *ATNConfigSet, t int, fullC
 		for _, trans := range c.GetState().GetTransitions() {
 			target := p.getReachableTarget(trans, t)
 			if target != nil {
-				cfg := NewATNConfig4(c, target)
+				cfg := p.arena.newATNConfig4(c, target)
 				intermediate.Add(cfg, p.mergeCache)
 				if runtimeConfig.parserATNSimulatorDebug {
 					fmt.Println("added " + cfg.String() + " to intermediate")
@@ -598,8 +920,8 @@ func (p *ParserATNSimulator) computeReachSet(closure *ATNConfigSet, t int, fullC
 	// operation on the intermediate set to compute its initial value.
 	//
 	if reach == nil {
-		reach = NewATNConfigSet(fullCtx)
-		closureBusy := NewClosureBusy("ParserATNSimulator.computeReachSet() make a closureBusy")
+		reach = p.newConfigSet(fullCtx)
+		closureBusy := p.newClosureBusy("ParserATNSimulator.computeReachSet() make a closureBusy")
 		treatEOFAsEpsilon := t == TokenEOF
 		amount := len(intermediate.configs)
 		for k := 0; k < amount; k++ {
@@ -643,6 +965,7 @@ func (p *ParserATNSimulator) computeReachSet(closure *ATNConfigSet, t int, fullC
 	if runtimeConfig.parserATNSimulatorTraceATNSim {
 		fmt.Println("computeReachSet " + closure.String() + " -> " + reach.String())
 	}
+	p.noteConfigSet(p.decision(), len(reach.configs))
 
 	if len(reach.configs) == 0 {
 		return nil
@@ -672,7 +995,7 @@ func (p *ParserATNSimulator) removeAllConfigsNotInRuleStopState(configs *ATNConf
 	if PredictionModeallConfigsInRuleStopStates(configs) {
 		return configs
 	}
-	result := NewATNConfigSet(configs.fullCtx)
+	result := p.newConfigSet(configs.fullCtx)
 	for _, config := range configs.configs {
 		if _, ok := config.GetState().(*RuleStopState); ok {
 			result.Add(config, p.mergeCache)
@@ -682,7 +1005,7 @@ func (p *ParserATNSimulator) removeAllConfigsNotInRuleStopState(configs *ATNConf
 			NextTokens := p.atn.NextTokens(config.GetState(), nil)
 			if NextTokens.contains(TokenEpsilon) {
 				endOfRuleState := p.atn.ruleToStopState[config.GetState().GetRuleIndex()]
-				result.Add(NewATNConfig4(config, endOfRuleState), p.mergeCache)
+				result.Add(p.arena.newATNConfig4(config, endOfRuleState), p.mergeCache)
 			}
 		}
 	}
@@ -693,7 +1016,7 @@ func (p *ParserATNSimulator) removeAllConfigsNotInRuleStopState(configs *ATNConf
 func (p *ParserATNSimulator) computeStartState(a ATNState, ctx RuleContext, fullCtx bool) *ATNConfigSet {
 	// always at least the implicit call to start rule
 	initialContext := predictionContextFromRuleContext(p.atn, ctx)
-	configs := NewATNConfigSet(fullCtx)
+	configs := p.newConfigSet(fullCtx)
 	if runtimeConfig.parserATNSimulatorDebug || runtimeConfig.parserATNSimulatorTraceATNSim {
 		fmt.Println("computeStartState from ATN state " + a.String() +
 			" initialContext=" + initialContext.String())
@@ -701,8 +1024,8 @@ func (p *ParserATNSimulator) computeStartState(a ATNState, ctx RuleContext, full
 
 	for i := 0; i < len(a.GetTransitions()); i++ {
 		target := a.GetTransitions()[i].getTarget()
-		c := NewATNConfig6(target, i+1, initialContext)
-		closureBusy := NewClosureBusy("ParserATNSimulator.computeStartState() make a closureBusy")
+		c := p.arena.newATNConfig6(target, i+1, initialContext)
+		closureBusy := p.newClosureBusy("ParserATNSimulator.computeStartState() make a closureBusy")
 		p.closure(c, configs, closureBusy, true, fullCtx, false)
 	}
 	return configs
@@ -754,7 +1077,7 @@ func (p *ParserATNSimulator) computeStartState(a ATNState, ctx RuleContext, full
 func (p *ParserATNSimulator) applyPrecedenceFilter(configs *ATNConfigSet) *ATNConfigSet {
 
 	statesFromAlt1 := make(map[int]*PredictionContext)
-	configSet := NewATNConfigSet(configs.fullCtx)
+	configSet := p.newConfigSet(configs.fullCtx)
 
 	for _, config := range configs.configs {
 		// handle alt 1 first
@@ -768,7 +1091,7 @@ func (p *ParserATNSimulator) applyPrecedenceFilter(configs *ATNConfigSet) *ATNCo
 		}
 		statesFromAlt1[config.GetState().GetStateNumber()] = config.GetContext()
 		if updatedContext != config.GetSemanticContext() {
-			configSet.Add(NewATNConfig2(config, updatedContext), p.mergeCache)
+			configSet.Add(p.arena.newATNConfig2(config, updatedContext), p.mergeCache)
 		} else {
 			configSet.Add(config, p.mergeCache)
 		}
@@ -933,8 +1256,8 @@ type ATNConfigSetPair struct {
 }
 
 func (p *ParserATNSimulator) splitAccordingToSemanticValidity(configs *ATNConfigSet, outerContext ParserRuleContext) []*ATNConfigSet {
-	succeeded := NewATNConfigSet(configs.fullCtx)
-	failed := NewATNConfigSet(configs.fullCtx)
+	succeeded := p.newConfigSet(configs.fullCtx)
+	failed := p.newConfigSet(configs.fullCtx)
 
 	for _, c := range configs.configs {
 		if c.GetSemanticContext() != SemanticContextNone {
@@ -998,6 +1321,8 @@ func (p *ParserATNSimulator) closureCheckingStopState(config *ATNConfig, configs
 		fmt.Println("closure(" + config.String() + ")")
 	}
 
+	p.noteClosure(p.decision())
+
 	var stack []*ATNConfig
 	visited := make(map[*ATNConfig]bool)
 
@@ -1012,6 +1337,16 @@ func (p *ParserATNSimulator) closureCheckingStopState(config *ATNConfig, configs
 		}
 		visited[currConfig] = true
 
+		if !p.shadow {
+			p.stats.ConfigsCreated++
+		}
+		if p.progress != nil && p.progress.configCreated() != nil {
+			return
+		}
+		if p.atn.observer != nil {
+			p.atn.observer(currConfig.GetState(), true)
+		}
+
 		if _, ok := currConfig.GetState().(*RuleStopState); ok {
 			// We hit rule end. If we have context info, use it
 			// run thru all possible stack tops in ctx
@@ -1019,7 +1354,7 @@ func (p *ParserATNSimulator) closureCheckingStopState(config *ATNConfig, configs
 				for i := 0; i < currConfig.GetContext().length(); i++ {
 					if currConfig.GetContext().getReturnState(i) == BasePredictionContextEmptyReturnState {
 						if fullCtx {
-							nb := NewATNConfig1(currConfig, currConfig.GetState(), BasePredictionContextEMPTY)
+							nb := p.arena.newATNConfig1(currConfig, currConfig.GetState(), BasePredictionContextEMPTY)
 							configs.Add(nb, p.mergeCache)
 							continue
 						} else {
@@ -1034,7 +1369,7 @@ func (p *ParserATNSimulator) closureCheckingStopState(config *ATNConfig, configs
 					returnState := p.atn.states[currConfig.GetContext().getReturnState(i)]
 					newContext := currConfig.GetContext().GetParent(i) // "pop" return state
 
-					c := NewATNConfig5(returnState, currConfig.GetAlt(), newContext, currConfig.GetSemanticContext())
+					c := p.arena.newATNConfig5(returnState, currConfig.GetAlt(), newContext, currConfig.GetSemanticContext())
 					// While we have context to pop back from, we may have
 					// gotten that context AFTER having falling off a rule.
 					// Make sure we track that we are now out of context.
@@ -1064,6 +1399,9 @@ func (p *ParserATNSimulator) closureCheckingStopStateRecursive(config *ATNConfig
 	if runtimeConfig.parserATNSimulatorTraceATNSim {
 		fmt.Println("closure(" + config.String() + ")")
 	}
+	if p.atn.observer != nil {
+		p.atn.observer(config.GetState(), true)
+	}
 
 	if _, ok := config.GetState().(*RuleStopState); ok {
 		// We hit rule end. If we have context info, use it
@@ -1072,7 +1410,7 @@ func (p *ParserATNSimulator) closureCheckingStopStateRecursive(config *ATNConfig
 			for i := 0; i < config.GetContext().length(); i++ {
 				if config.GetContext().getReturnState(i) == BasePredictionContextEmptyReturnState {
 					if fullCtx {
-						nb := NewATNConfig1(config, config.GetState(), BasePredictionContextEMPTY)
+						nb := p.arena.newATNConfig1(config, config.GetState(), BasePredictionContextEMPTY)
 						configs.Add(nb, p.mergeCache)
 						continue
 					} else {
@@ -1087,7 +1425,7 @@ func (p *ParserATNSimulator) closureCheckingStopStateRecursive(config *ATNConfig
 				returnState := p.atn.states[config.GetContext().getReturnState(i)]
 				newContext := config.GetContext().GetParent(i) // "pop" return state
 
-				c := NewATNConfig5(returnState, config.GetAlt(), newContext, config.GetSemanticContext())
+				c := p.arena.newATNConfig5(returnState, config.GetAlt(), newContext, config.GetSemanticContext())
 				// While we have context to pop back from, we may have
 				// gotten that context AFTER having falling off a rule.
 				// Make sure we track that we are now out of context.
@@ -1113,6 +1451,11 @@ func (p *ParserATNSimulator) closureCheckingStopStateRecursive(config *ATNConfig
 //
 //goland:noinspection GoBoolExpressions
 func (p *ParserATNSimulator) closureWork(config *ATNConfig, configs *ATNConfigSet, closureBusy *ClosureBusy, collectPredicates, fullCtx bool, depth int, treatEOFAsEpsilon bool) {
+	if !p.enterClosure(config) {
+		panic(NewLoopDetectedException(p.parser, p.input, config.GetState().GetStateNumber()))
+	}
+	defer p.leaveClosure()
+
 	state := config.GetState()
 	// optimization
 	if !state.GetEpsilonOnlyTransitions() {
@@ -1131,6 +1474,7 @@ func (p *ParserATNSimulator) closureWork(config *ATNConfig, configs *ATNConfigSe
 		c := p.getEpsilonTarget(config, t, continueCollecting, depth == 0, fullCtx, treatEOFAsEpsilon)
 		if c != nil {
 			newDepth := depth
+			guarded := false
 
 			if _, ok := config.GetState().(*RuleStopState); ok {
 				// target fell off end of rule mark resulting c as having dipped into outer context
@@ -1152,6 +1496,7 @@ func (p *ParserATNSimulator) closureWork(config *ATNConfig, configs *ATNConfigSe
 					// avoid infinite recursion for right-recursive rules
 					continue
 				}
+				guarded = true
 
 				configs.dipsIntoOuterContext = true // TODO: can remove? only care when we add to set per middle of this method
 				newDepth--
@@ -1166,6 +1511,7 @@ func (p *ParserATNSimulator) closureWork(config *ATNConfig, configs *ATNConfigSe
 						// avoid infinite recursion for EOF* and EOF+
 						continue
 					}
+					guarded = true
 				}
 				if _, ok := t.(*RuleTransition); ok {
 					// latch when newDepth goes negative - once we step out of the entry context we can't return
@@ -1174,7 +1520,15 @@ func (p *ParserATNSimulator) closureWork(config *ATNConfig, configs *ATNConfigSe
 					}
 				}
 			}
-			p.closureCheckingStopState(c, configs, closureBusy, continueCollecting, fullCtx, newDepth, treatEOFAsEpsilon)
+			if guarded {
+				// closureBusy ends any loop back to c, so only a loop after it is looked for
+				floor := p.closureFloor
+				p.closureFloor = len(p.closurePath)
+				p.closureCheckingStopState(c, configs, closureBusy, continueCollecting, fullCtx, newDepth, treatEOFAsEpsilon)
+				p.closureFloor = floor
+			} else {
+				p.closureCheckingStopState(c, configs, closureBusy, continueCollecting, fullCtx, newDepth, treatEOFAsEpsilon)
+			}
 		}
 	}
 }
@@ -1290,13 +1644,13 @@ func (p *ParserATNSimulator) getEpsilonTarget(config *ATNConfig, t Transition, c
 	case TransitionACTION:
 		return p.actionTransition(config, t.(*ActionTransition))
 	case TransitionEPSILON:
-		return NewATNConfig4(config, t.getTarget())
+		return p.arena.newATNConfig4(config, t.getTarget())
 	case TransitionATOM, TransitionRANGE, TransitionSET:
 		// EOF transitions act like epsilon transitions after the first EOF
 		// transition is traversed
 		if treatEOFAsEpsilon {
 			if t.Matches(TokenEOF, 0, 1) {
-				return NewATNConfig4(config, t.getTarget())
+				return p.arena.newATNConfig4(config, t.getTarget())
 			}
 		}
 		return nil
@@ -1310,7 +1664,7 @@ func (p *ParserATNSimulator) actionTransition(config *ATNConfig, t *ActionTransi
 	if runtimeConfig.parserATNSimulatorDebug {
 		fmt.Println("ACTION edge " + strconv.Itoa(t.ruleIndex) + ":" + strconv.Itoa(t.actionIndex))
 	}
-	return NewATNConfig4(config, t.getTarget())
+	return p.arena.newATNConfig4(config, t.getTarget())
 }
 
 //goland:noinspection GoBoolExpressions
@@ -1336,14 +1690,14 @@ func (p *ParserATNSimulator) precedenceTransition(config *ATNConfig,
 			predSucceeds := pt.getPredicate().evaluate(p.parser, p.outerContext)
 			p.input.Seek(currentPosition)
 			if predSucceeds {
-				c = NewATNConfig4(config, pt.getTarget()) // no pred context
+				c = p.arena.newATNConfig4(config, pt.getTarget()) // no pred context
 			}
 		} else {
 			newSemCtx := SemanticContextandContext(config.GetSemanticContext(), pt.getPredicate())
-			c = NewATNConfig3(config, pt.getTarget(), newSemCtx)
+			c = p.arena.newATNConfig3(config, pt.getTarget(), newSemCtx)
 		}
 	} else {
-		c = NewATNConfig4(config, pt.getTarget())
+		c = p.arena.newATNConfig4(config, pt.getTarget())
 	}
 	if runtimeConfig.parserATNSimulatorDebug {
 		fmt.Println("runtimeConfig from pred transition=" + c.String())
@@ -1373,14 +1727,14 @@ func (p *ParserATNSimulator) predTransition(config *ATNConfig, pt *PredicateTran
 			predSucceeds := pt.getPredicate().evaluate(p.parser, p.outerContext)
 			p.input.Seek(currentPosition)
 			if predSucceeds {
-				c = NewATNConfig4(config, pt.getTarget()) // no pred context
+				c = p.arena.newATNConfig4(config, pt.getTarget()) // no pred context
 			}
 		} else {
 			newSemCtx := SemanticContextandContext(config.GetSemanticContext(), pt.getPredicate())
-			c = NewATNConfig3(config, pt.getTarget(), newSemCtx)
+			c = p.arena.newATNConfig3(config, pt.getTarget(), newSemCtx)
 		}
 	} else {
-		c = NewATNConfig4(config, pt.getTarget())
+		c = p.arena.newATNConfig4(config, pt.getTarget())
 	}
 	if runtimeConfig.parserATNSimulatorDebug {
 		fmt.Println("config from pred transition=" + c.String())
@@ -1394,8 +1748,8 @@ func (p *ParserATNSimulator) ruleTransition(config *ATNConfig, t *RuleTransition
 		fmt.Println("CALL rule " + p.getRuleName(t.getTarget().GetRuleIndex()) + ", ctx=" + config.GetContext().String())
 	}
 	returnState := t.followState
-	newContext := SingletonBasePredictionContextCreate(config.GetContext(), returnState.GetStateNumber())
-	return NewATNConfig1(config, t.getTarget(), newContext)
+	newContext := p.arena.newSingletonContext(config.GetContext(), returnState.GetStateNumber())
+	return p.arena.newATNConfig1(config, t.getTarget(), newContext)
 }
 
 func (p *ParserATNSimulator) getConflictingAlts(configs *ATNConfigSet) *BitSet {
@@ -1517,7 +1871,11 @@ func (p *ParserATNSimulator) dumpDeadEndConfigs(_ *NoViableAltException) {
 }
 
 func (p *ParserATNSimulator) noViableAlt(input TokenStream, outerContext ParserRuleContext, configs *ATNConfigSet, startIndex int) *NoViableAltException {
-	return NewNoViableAltException(p.parser, input, input.Get(startIndex), input.LT(1), configs, outerContext)
+	e := NewNoViableAltException(p.parser, input, input.Get(startIndex), input.LT(1), configs, outerContext)
+	if p.dfa != nil {
+		e.decision = p.dfa.decision
+	}
+	return e
 }
 
 func (p *ParserATNSimulator) getUniqueAlt(configs *ATNConfigSet) int {
@@ -1559,6 +1917,10 @@ func (p *ParserATNSimulator) addDFAEdge(dfa *DFA, from *DFAState, t int, to *DFA
 	if to == nil {
 		return nil
 	}
+	if dfa.frozen {
+		// A snapshot is never modified, so the edge is not added
+		return p.addDFAState(dfa, to)
+	}
 	p.atn.stateMu.Lock()
 	to = p.addDFAState(dfa, to) // used existing if possible not incoming
 	p.atn.stateMu.Unlock()
@@ -1566,10 +1928,7 @@ func (p *ParserATNSimulator) addDFAEdge(dfa *DFA, from *DFAState, t int, to *DFA
 		return to
 	}
 	p.atn.edgeMu.Lock()
-	if from.getEdges() == nil {
-		from.setEdges(make([]*DFAState, p.atn.maxTokenType+1+1))
-	}
-	from.setIthEdge(t+1, to) // connect
+	from.setIthEdge(t+1, to, p.atn.maxTokenType+1+1) // connect
 	p.atn.edgeMu.Unlock()
 
 	if runtimeConfig.parserATNSimulatorDebug {
@@ -1608,13 +1967,20 @@ func (p *ParserATNSimulator) addDFAState(dfa *DFA, d *DFAState) *DFAState {
 	// The state will be added if not already there or we will be given back the existing state struct
 	// if it is present.
 	//
-	d.stateNumber = dfa.Len()
+	if !dfa.frozen {
+		d.stateNumber = dfa.Len()
+	}
 	if !d.configs.readOnly {
 		d.configs.OptimizeConfigs(&p.BaseATNSimulator)
 		d.configs.readOnly = true
 		d.configs.configLookup = nil
 	}
+	if dfa.frozen {
+		// A snapshot is never modified, so the state is used for this prediction only
+		return d
+	}
 	dfa.Put(d)
+	p.noteDFAState(dfa.decision)
 
 	if runtimeConfig.parserATNSimulatorTraceATNSim {
 		fmt.Println("addDFAState new " + d.String())
@@ -1625,11 +1991,17 @@ func (p *ParserATNSimulator) addDFAState(dfa *DFA, d *DFAState) *DFAState {
 
 //goland:noinspection GoBoolExpressions
 func (p *ParserATNSimulator) ReportAttemptingFullContext(dfa *DFA, conflictingAlts *BitSet, configs *ATNConfigSet, startIndex, stopIndex int) {
+	if p.shadow {
+		return
+	}
 	if runtimeConfig.parserATNSimulatorDebug || runtimeConfig.parserATNSimulatorRetryDebug {
 		interval := NewInterval(startIndex, stopIndex+1)
 		fmt.Println("ReportAttemptingFullContext decision=" + strconv.Itoa(dfa.decision) + ":" + configs.String() +
 			", input=" + p.parser.GetTokenStream().GetTextFromInterval(interval))
 	}
+	if p.profile != nil {
+		p.profileDecision().LLFallback++
+	}
 	if p.parser != nil {
 		p.parser.GetErrorListenerDispatch().ReportAttemptingFullContext(p.parser, dfa, startIndex, stopIndex, conflictingAlts, configs)
 	}
@@ -1637,11 +2009,18 @@ func (p *ParserATNSimulator) ReportAttemptingFullContext(dfa *DFA, conflictingAl
 
 //goland:noinspection GoBoolExpressions
 func (p *ParserATNSimulator) ReportContextSensitivity(dfa *DFA, prediction int, configs *ATNConfigSet, startIndex, stopIndex int) {
+	if p.shadow {
+		return
+	}
 	if runtimeConfig.parserATNSimulatorDebug || runtimeConfig.parserATNSimulatorRetryDebug {
 		interval := NewInterval(startIndex, stopIndex+1)
 		fmt.Println("ReportContextSensitivity decision=" + strconv.Itoa(dfa.decision) + ":" + configs.String() +
 			", input=" + p.parser.GetTokenStream().GetTextFromInterval(interval))
 	}
+	if p.profile != nil {
+		d := p.profileDecision()
+		d.ContextSensitivities = append(d.ContextSensitivities, ContextSensitivityInfo{p.profileEvent(configs, stopIndex, true)})
+	}
 	if p.parser != nil {
 		p.parser.GetErrorListenerDispatch().ReportContextSensitivity(p.parser, dfa, startIndex, stopIndex, prediction, configs)
 	}
@@ -1655,11 +2034,18 @@ func (p *ParserATNSimulator) ReportContextSensitivity(dfa *DFA, prediction int,
 //goland:noinspection GoBoolExpressions
 func (p *ParserATNSimulator) ReportAmbiguity(dfa *DFA, _ *DFAState, startIndex, stopIndex int,
 	exact bool, ambigAlts *BitSet, configs *ATNConfigSet) {
+	if p.shadow {
+		return
+	}
 	if runtimeConfig.parserATNSimulatorDebug || runtimeConfig.parserATNSimulatorRetryDebug {
 		interval := NewInterval(startIndex, stopIndex+1)
 		fmt.Println("ReportAmbiguity " + ambigAlts.String() + ":" + configs.String() +
 			", input=" + p.parser.GetTokenStream().GetTextFromInterval(interval))
 	}
+	if p.profile != nil {
+		d := p.profileDecision()
+		d.Ambiguities = append(d.Ambiguities, AmbiguityInfo{p.profileEvent(configs, stopIndex, configs.fullCtx), ambigAlts})
+	}
 	if p.parser != nil {
 		p.parser.GetErrorListenerDispatch().ReportAmbiguity(p.parser, dfa, startIndex, stopIndex, exact, ambigAlts, configs)
 	}
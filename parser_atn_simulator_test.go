@@ -0,0 +1,175 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"sync"
+	"testing"
+)
+
+// sllRules is a grammar over the tokens of the list grammar for which SLL and full LL prediction differ, in
+// the decision of r invoked from the first alternative of s:
+//
+//	s : ID r ID EOF | '+' r EOF ;
+//	r : '+' ID | '+' ;
+//
+// For the input a + b, SLL prediction, which does not know where r was invoked from, sees that r can match
+// + b and predicts the first alternative of r. Full LL prediction knows that r must be followed by an ID,
+// and so predicts the second.
+var sllRules = [][][]atnElement{
+	{bAlt(bTok(listID), bRule(1), bTok(listID), bTok(TokenEOF)), bAlt(bTok(listPLUS), bRule(1), bTok(TokenEOF))},
+	{bAlt(bTok(listPLUS), bTok(listID)), bAlt(bTok(listPLUS))},
+}
+
+var sllATN = sync.OnceValue(func() *ATN { return buildATN(listWS, sllRules) })
+
+// sllParser returns a parser of the input a + b with the grammar of sllRules, in the given prediction mode, at
+// the token +, and the context of r invoked from the first alternative of s.
+func sllParser(mode int, differential bool, listeners ...ErrorListener) (*BaseParser, ParserRuleContext) {
+	atn := sllATN()
+	stream := NewCommonTokenStream(newListLexer(NewInputStream("a + b")), TokenDefaultChannel)
+	p := NewBaseParser(stream)
+	p.Interpreter = NewParserATNSimulator(p, atn, newDFA(atn), NewPredictionContextCache())
+	p.Interpreter.SetPredictionMode(mode)
+	p.Interpreter.SetDifferentialPrediction(differential)
+	p.RemoveErrorListeners()
+	for _, l := range listeners {
+		p.AddErrorListener(l)
+	}
+
+	invokingState := -1
+	for _, s := range atn.states {
+		for _, t := range s.GetTransitions() {
+			if t, ok := t.(*RuleTransition); ok && s.GetRuleIndex() == 0 && atn.NextTokensNoContext(t.followState).contains(listID) {
+				invokingState = s.GetStateNumber()
+			}
+		}
+	}
+	stream.Seek(1)
+	return p, NewBaseParserRuleContext(NewBaseParserRuleContext(nil, -1), invokingState)
+}
+
+// sllPredict predicts the decision of r for the input a + b, invoked from the first alternative of s, with a
+// parser in the given prediction mode, and returns the alternative predicted.
+func sllPredict(mode int, differential bool, listeners ...ErrorListener) int {
+	p, ctx := sllParser(mode, differential, listeners...)
+	return p.Interpreter.AdaptivePredict(p, p.GetTokenStream(), 1, ctx)
+}
+
+// differenceRecorder records the predictions reported to it as different.
+type differenceRecorder struct {
+	DefaultErrorListener
+	differences [][4]int
+}
+
+func (d *differenceRecorder) ReportPredictionDifference(_ Parser, dfa *DFA, startIndex, stopIndex, sllAlt, llAlt int) {
+	d.differences = append(d.differences, [4]int{startIndex, stopIndex, sllAlt, llAlt})
+}
+
+func TestDifferentialPrediction(t *testing.T) {
+	recorder := new(differenceRecorder)
+	if alt := sllPredict(PredictionModeSLL, true, recorder); alt != 1 {
+		t.Errorf("SLL predicted %d, want 1", alt)
+	}
+	if len(recorder.differences) != 1 || recorder.differences[0] != [4]int{1, 3, 1, 2} {
+		t.Errorf("differences %v, want [[1 3 1 2]]", recorder.differences)
+	}
+
+	recorder = new(differenceRecorder)
+	if alt := sllPredict(PredictionModeLLExactAmbigDetection, true, recorder); alt != 2 || len(recorder.differences) != 1 {
+		t.Errorf("full LL predicted %d with differences %v", alt, recorder.differences)
+	}
+
+	recorder = new(differenceRecorder)
+	if sllPredict(PredictionModeSLL, false, recorder); len(recorder.differences) != 0 {
+		t.Errorf("differences %v without differential prediction", recorder.differences)
+	}
+}
+
+func TestRetryWithFullContext(t *testing.T) {
+	p, ctx := sllParser(PredictionModeSLL, false)
+	stream := p.GetTokenStream()
+	if alt := p.Interpreter.AdaptivePredict(p, stream, 1, ctx); alt != 1 {
+		t.Fatalf("SLL predicted %d, want 1", alt)
+	}
+	states := dfaLen(p.Interpreter.decisionToDFA)
+	stream.Seek(1)
+
+	// the retry knows that r must be followed by an ID, whatever the prediction mode
+	if alt, err := p.Interpreter.RetryWithFullContext(1, 1, ctx); alt != 2 || err != nil {
+		t.Errorf("RetryWithFullContext() = %d, %v, want 2", alt, err)
+	}
+	if stream.Index() != 1 || dfaLen(p.Interpreter.decisionToDFA) != states {
+		t.Errorf("the retry moved the input to %d or added to the DFA", stream.Index())
+	}
+	if n := p.Interpreter.GetPredictionStats().FullContextPredictions; n != 1 {
+		t.Errorf("%d full context predictions, want 1", n)
+	}
+
+	// from b, neither alternative of r is viable
+	if alt, err := p.Interpreter.RetryWithFullContext(1, 2, ctx); alt != ATNInvalidAltNumber || err == nil {
+		t.Errorf("RetryWithFullContext() at b = %d, %v", alt, err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("RetryWithFullContext() of an invalid decision did not panic")
+		}
+	}()
+	p.Interpreter.RetryWithFullContext(2, 1, ctx)
+}
+
+func TestClearDFA(t *testing.T) {
+	atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+	decisionToDFA := newDFA(atn)
+	cache := NewPredictionContextCache()
+	parse := func(snapshot *DFASnapshot) *listParser {
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a b + c d")), TokenDefaultChannel))
+		p.Interpreter = NewParserATNSimulator(p, atn, decisionToDFA, cache)
+		p.Interpreter.UseDFASnapshot(snapshot)
+		p.S()
+		return p
+	}
+	p := parse(nil)
+	states := dfaLen(decisionToDFA)
+	snapshot := NewDFASnapshot(atn, decisionToDFA)
+	if states == 0 || cache.Stats().Size == 0 {
+		t.Fatalf("the parse cached %d states and %d contexts", states, cache.Stats().Size)
+	}
+
+	p.Interpreter.ClearDFA()
+	if dfaLen(decisionToDFA) != 0 || cache.Stats().Size != 0 || dfaLen(snapshot.decisionToDFA) != states {
+		t.Errorf("ClearDFA kept %d states and %d contexts, or cleared the snapshot", dfaLen(decisionToDFA),
+			cache.Stats().Size)
+	}
+	// a parser that uses the snapshot adds nothing to the cache, and the cache is built up again as before
+	if parse(snapshot); dfaLen(decisionToDFA) != 0 {
+		t.Errorf("a parse with the snapshot cached %d states", dfaLen(decisionToDFA))
+	}
+	if parse(nil); dfaLen(decisionToDFA) != states {
+		t.Errorf("the parse after ClearDFA cached %d states, want %d", dfaLen(decisionToDFA), states)
+	}
+}
+
+func TestReleaseCaches(t *testing.T) {
+	atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+	p := newListParser(nil)
+	p.Interpreter = NewParserATNSimulator(p, atn, newDFA(atn), NewPredictionContextCache())
+	bypass, _ := p.GetATNWithBypassAlts()
+	follow := atn.NextTokens(atn.ruleToStartState[listRuleItem], nil)
+	if atn.ruleToStartState[listRuleItem].GetNextTokenWithinRule() == nil {
+		t.Fatal("the tokens that can follow the start of item were not cached")
+	}
+
+	atn.ReleaseCaches()
+	if atn.ruleToStartState[listRuleItem].GetNextTokenWithinRule() != nil {
+		t.Error("ReleaseCaches kept the tokens that can follow the start of item")
+	}
+	if again, _ := p.GetATNWithBypassAlts(); again == bypass || again == nil {
+		t.Error("ReleaseCaches kept the ATN with bypass alternatives")
+	}
+	if again := atn.NextTokens(atn.ruleToStartState[listRuleItem], nil); again.String() != follow.String() {
+		t.Errorf("the tokens that can follow the start of item are %s, want %s", again, follow)
+	}
+}
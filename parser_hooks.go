@@ -0,0 +1,62 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// ParserHooks holds funcs that a parser calls around the steps of a parse, so that instrumentation, such as
+// counting tokens or taking checkpoints, and custom behaviour, such as resynchronizing in a way of its own,
+// can be added to a parser without changing [BaseParser]. Each hook that is nil is not called.
+type ParserHooks struct {
+	// BeforeConsume is called with the current token before the parser consumes it, whether it matches it or
+	// consumes it to recover from an error
+	BeforeConsume func(recognizer Parser, token Token)
+
+	// AfterConsume is called with the token the parser has consumed, once it is in the parse tree and the
+	// parse listeners have been told about it
+	AfterConsume func(recognizer Parser, token Token)
+
+	// Sync is called in place of the Sync of the error strategy, which the parser calls before each decision
+	// to check that the current token can follow, and recover if it cannot. Calling sync calls the Sync of the
+	// error strategy, so that the hook may do something before or after it, or instead of it.
+	Sync func(recognizer Parser, sync func())
+}
+
+// SetHooks installs the hooks that the parser calls, in place of any installed before. To add hooks to those
+// installed already, get them with [BaseParser.GetHooks] and call them from the new ones.
+//
+// While a Sync hook is installed, GetErrorHandler returns the error strategy wrapped in a strategy that
+// calls the hook, so that the Sync of the generated code goes through it; use [BaseParser.GetErrorHandler]
+// only to call the strategy then, not to find out its type.
+//
+// Use:
+//
+//	tokens := 0
+//	p.SetHooks(antlr.ParserHooks{
+//	    AfterConsume: func(recognizer antlr.Parser, token antlr.Token) { tokens++ },
+//	})
+func (p *BaseParser) SetHooks(hooks ParserHooks) {
+	p.hooks = hooks
+	if hooks.Sync == nil {
+		p.syncHook = nil
+	} else if p.syncHook == nil {
+		p.syncHook = &syncHookErrorStrategy{parser: p}
+	}
+}
+
+// GetHooks returns the hooks installed with [BaseParser.SetHooks].
+func (p *BaseParser) GetHooks() ParserHooks {
+	return p.hooks
+}
+
+// syncHookErrorStrategy hands every call to the error strategy of the parser, but Sync, which it hands to the
+// Sync hook of the parser.
+type syncHookErrorStrategy struct {
+	ErrorStrategy
+	parser *BaseParser
+}
+
+func (s *syncHookErrorStrategy) Sync(recognizer Parser) {
+	strategy := s.ErrorStrategy
+	s.parser.hooks.Sync(recognizer, func() { strategy.Sync(recognizer) })
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParserHooksConsume(t *testing.T) {
+	lexer := newListLexer(NewInputStream("a b + c"))
+	p := newListParser(NewCommonTokenStream(lexer, TokenDefaultChannel))
+	var before, after []string
+	p.SetHooks(ParserHooks{
+		BeforeConsume: func(recognizer Parser, token Token) {
+			if recognizer.GetCurrentToken() != token {
+				t.Errorf("%s is consumed before the current token %s", token, recognizer.GetCurrentToken())
+			}
+			before = append(before, token.GetText())
+		},
+		AfterConsume: func(recognizer Parser, token Token) {
+			ctx := recognizer.GetParserRuleContext()
+			last, ok := ctx.GetChild(ctx.GetChildCount() - 1).(TerminalNode)
+			if !ok || last.GetSymbol() != token {
+				t.Errorf("%s is not the last node of the tree once consumed", token)
+			}
+			after = append(after, token.GetText())
+		},
+	})
+	p.S()
+	want := []string{"a", "b", "+", "c", "<EOF>"}
+	if !slices.Equal(before, want) || !slices.Equal(after, want) {
+		t.Errorf("tokens consumed: before %v, after %v, want %v", before, after, want)
+	}
+	if p.GetHooks().BeforeConsume == nil || p.GetHooks().Sync != nil {
+		t.Error("GetHooks() did not return the hooks installed")
+	}
+}
+
+func TestParserHooksSync(t *testing.T) {
+	// the indexes of the tokens at which the error strategy syncs without a hook
+	plain := &indexRecorder{DefaultErrorStrategy: NewDefaultErrorStrategy()}
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a b + c")), TokenDefaultChannel))
+	p.SetErrorHandler(plain)
+	p.S()
+	if len(plain.indexes) == 0 {
+		t.Fatal("the parser did not sync")
+	}
+
+	// the hook is called in place of the Sync of the strategy, and may call it
+	for _, interpreted := range []bool{false, true} {
+		hooked := &indexRecorder{DefaultErrorStrategy: NewDefaultErrorStrategy()}
+		var parser *BaseParser
+		var parse func()
+		if interpreted {
+			p := newListInterpreter("a b + c")
+			parser, parse = p.BaseParser, func() { p.Parse(listRuleS) }
+		} else {
+			p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a b + c")), TokenDefaultChannel))
+			parser, parse = p.BaseParser, func() { p.S() }
+		}
+		parser.SetErrorHandler(hooked)
+		var calls []int
+		parser.SetHooks(ParserHooks{Sync: func(recognizer Parser, sync func()) {
+			calls = append(calls, recognizer.GetCurrentToken().GetTokenIndex())
+			sync()
+		}})
+		parse()
+		if !slices.Equal(calls, plain.indexes) || !slices.Equal(hooked.indexes, plain.indexes) {
+			t.Errorf("interpreted %v: the hook was called at %v, and the strategy at %v, want %v", interpreted, calls,
+				hooked.indexes, plain.indexes)
+		}
+	}
+
+	// a hook that does not call sync leaves the error to the match that follows, which recovers inline
+	hooked := &indexRecorder{DefaultErrorStrategy: NewDefaultErrorStrategy()}
+	p = newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a + + b")), TokenDefaultChannel))
+	p.RemoveErrorListeners()
+	errs := &countingErrorListener{}
+	p.AddErrorListener(errs)
+	p.SetErrorHandler(hooked)
+	p.SetHooks(ParserHooks{Sync: func(Parser, func()) {}})
+	p.S()
+	if len(hooked.indexes) != 0 || errs.errors != 1 {
+		t.Errorf("the strategy was called at %v, with %d errors", hooked.indexes, errs.errors)
+	}
+
+	p.SetHooks(ParserHooks{})
+	if p.GetErrorHandler() != hooked {
+		t.Errorf("the error handler is %T once the hooks are removed", p.GetErrorHandler())
+	}
+}
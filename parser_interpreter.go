@@ -0,0 +1,276 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "fmt"
+
+// ParserInterpreter parses by walking the states of a parser [ATN] directly, rather than by running the
+// methods of a generated parser, as the ParserInterpreter of the other ANTLR runtimes does. A grammar can so
+// be applied to input given only its serialized ATN and vocabulary, as grammar development tools and
+// programs that load grammars at run time need to, without generating and compiling Go code for it.
+//
+// As it has no code of the grammar, it does not run actions, and takes every semantic predicate to be true,
+// except precedence predicates, which it evaluates as a generated parser does. The tree it builds is made of
+// [BaseInterpreterRuleContext] nodes, which know the index of their rule but have no type of their own.
+// Errors are reported and recovered from with the error strategy and listeners of the parser, as usual.
+//
+// Use:
+//
+//	atn := antlr.NewATNDeserializer(nil).Deserialize(serializedParserATN)
+//	p := antlr.NewParserInterpreter("Expr.g4", literalNames, symbolicNames, ruleNames, atn,
+//	    antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+//	tree := p.Parse(startRuleIndex)
+//	fmt.Println(tree.ToStringTree(ruleNames, p))
+type ParserInterpreter struct {
+	*BaseParser
+
+	atn *ATN
+
+	// parentContextStack holds, for each left recursive rule being parsed, the context that invoked it
+	// and its invoking state, which the contexts that the rule pushes as it recurses are created with
+	parentContextStack []interpreterParent
+
+	// overrideDecision is the decision whose prediction is overridden, see AddDecisionOverride, or -1
+	overrideDecision           int
+	overrideDecisionInputIndex int
+	overrideDecisionAlt        int
+	overrideDecisionReached    bool
+
+	rootContext ParserRuleContext
+}
+
+// interpreterParent is an entry of the parentContextStack of a [ParserInterpreter].
+type interpreterParent struct {
+	ctx           ParserRuleContext
+	invokingState int
+}
+
+// NewParserInterpreter creates an interpreter of the parser ATN atn, with the given vocabulary and rule
+// names, which parses the tokens of input. It has DFAs of its own, which are not shared with any other
+// parser. It panics if atn is not a parser ATN.
+func NewParserInterpreter(grammarFileName string, literalNames, symbolicNames, ruleNames []string, atn *ATN, input TokenStream) *ParserInterpreter {
+	if atn.grammarType != ATNTypeParser {
+		panic("NewParserInterpreter requires a parser ATN")
+	}
+	p := &ParserInterpreter{
+		BaseParser:       NewBaseParser(input),
+		atn:              atn,
+		overrideDecision: -1,
+	}
+	p.GrammarFileName = grammarFileName
+	p.LiteralNames = literalNames
+	p.SymbolicNames = symbolicNames
+	p.RuleNames = ruleNames
+
+	decisionToDFA := make([]*DFA, len(atn.DecisionToState))
+	for i, s := range atn.DecisionToState {
+		decisionToDFA[i] = NewDFA(s, i)
+	}
+	p.Interpreter = NewParserATNSimulator(p, atn, decisionToDFA, NewPredictionContextCache())
+	return p
+}
+
+// Parse parses the input with the rule startRuleIndex, and returns the context of the rule. A syntax error
+// is reported and recovered from as a generated parser would, unless the error strategy cancels the parse,
+// in which case the context returned holds as much of the tree as was parsed.
+func (p *ParserInterpreter) Parse(startRuleIndex int) ParserRuleContext {
+	start := p.atn.ruleToStartState[startRuleIndex]
+	p.parentContextStack = p.parentContextStack[:0]
+	p.overrideDecisionReached = false
+
+	p.rootContext = NewBaseInterpreterRuleContext(nil, ATNStateInvalidStateNumber, startRuleIndex)
+	if start.isPrecedenceRule {
+		p.enterRecursionRule(p.rootContext, start.GetStateNumber(), startRuleIndex, 0)
+	} else {
+		p.EnterRule(p.rootContext, start.GetStateNumber(), startRuleIndex)
+	}
+
+	for {
+		s := p.atn.states[p.GetState()]
+		if s.GetStateType() == ATNStateRuleStop {
+			if p.ctx.GetInvokingState() == ATNStateInvalidStateNumber {
+				// the end of the start rule
+				if start.isPrecedenceRule {
+					result := p.ctx
+					parent := p.popParent()
+					p.UnrollRecursionContexts(parent.ctx)
+					return result
+				}
+				p.ExitRule()
+				return p.rootContext
+			}
+			p.visitRuleStopState(s)
+			continue
+		}
+
+		p.visitState(s)
+		if p.HasError() {
+			e := p.GetError()
+			p.SetState(p.atn.ruleToStopState[s.GetRuleIndex()].GetStateNumber())
+			p.ctx.SetException(e)
+			p.GetErrorHandler().ReportError(p, e)
+			p.recover(e)
+			if p.cancelled != nil {
+				return p.rootContext
+			}
+			p.SetError(nil)
+		}
+	}
+}
+
+// GetRootContext returns the context of the start rule of the last parse.
+func (p *ParserInterpreter) GetRootContext() ParserRuleContext {
+	return p.rootContext
+}
+
+// AddDecisionOverride makes the next parse predict forcedAlt for decision when it is made at the token with
+// index tokenIndex, in place of the alternative that adaptive prediction would choose, as tools that explore
+// the parses of an ambiguous input do. Only one decision is overridden at a time, and only the first time it
+// is made at the token.
+func (p *ParserInterpreter) AddDecisionOverride(decision, tokenIndex, forcedAlt int) {
+	p.overrideDecision = decision
+	p.overrideDecisionInputIndex = tokenIndex
+	p.overrideDecisionAlt = forcedAlt
+}
+
+// GetOverrideDecision returns the decision overridden with AddDecisionOverride, or -1 if there is none.
+func (p *ParserInterpreter) GetOverrideDecision() int {
+	return p.overrideDecision
+}
+
+// enterRecursionRule enters the left recursive rule ruleIndex, remembering the context that invoked it.
+func (p *ParserInterpreter) enterRecursionRule(localctx ParserRuleContext, state, ruleIndex, precedence int) {
+	p.parentContextStack = append(p.parentContextStack, interpreterParent{p.ctx, localctx.GetInvokingState()})
+	p.EnterRecursionRule(localctx, state, ruleIndex, precedence)
+}
+
+// popParent pops the entry of the innermost left recursive rule from the parentContextStack.
+func (p *ParserInterpreter) popParent() interpreterParent {
+	parent := p.parentContextStack[len(p.parentContextStack)-1]
+	p.parentContextStack = p.parentContextStack[:len(p.parentContextStack)-1]
+	return parent
+}
+
+// visitState takes the transition out of s that the input predicts, matching or entering what it leads
+// to. On a syntax error, it sets the error of the parser, and leaves the state as it was.
+func (p *ParserInterpreter) visitState(s ATNState) {
+	alt := 1
+	if d, ok := s.(DecisionState); ok && len(s.GetTransitions()) > 1 {
+		p.GetErrorHandler().Sync(p)
+		if p.HasError() {
+			return
+		}
+		decision := d.getDecision()
+		if decision == p.overrideDecision && p.input.Index() == p.overrideDecisionInputIndex && !p.overrideDecisionReached {
+			alt = p.overrideDecisionAlt
+			p.overrideDecisionReached = true
+		} else {
+			alt = p.Interpreter.AdaptivePredict(p.BaseParser, p.input, decision, p.ctx)
+			if p.HasError() {
+				return
+			}
+		}
+	}
+
+	t := s.GetTransitions()[alt-1]
+	switch t.getSerializationType() {
+	case TransitionEPSILON:
+		if entry, ok := s.(*StarLoopEntryState); ok && entry.precedenceRuleDecision {
+			if _, ok := t.getTarget().(*LoopEndState); !ok {
+				// another iteration of a left recursive rule, which wraps what has been parsed so far
+				parent := p.parentContextStack[len(p.parentContextStack)-1]
+				ctx := NewBaseInterpreterRuleContext(parent.ctx, parent.invokingState, p.ctx.GetRuleIndex())
+				p.PushNewRecursionContext(ctx, p.atn.ruleToStartState[s.GetRuleIndex()].GetStateNumber(), p.ctx.GetRuleIndex())
+			}
+		}
+
+	case TransitionATOM:
+		p.Match(t.(*AtomTransition).label)
+
+	case TransitionRANGE, TransitionSET, TransitionNOTSET:
+		if !t.Matches(p.input.LA(1), TokenMinUserTokenType, 65535) {
+			p.GetErrorHandler().RecoverInline(p)
+			if p.HasError() {
+				return
+			}
+		}
+		p.MatchWildcard()
+
+	case TransitionWILDCARD:
+		p.MatchWildcard()
+
+	case TransitionRULE:
+		rt := t.(*RuleTransition)
+		ruleStart := rt.getTarget().(*RuleStartState)
+		ruleIndex := ruleStart.GetRuleIndex()
+		ctx := NewBaseInterpreterRuleContext(p.ctx, s.GetStateNumber(), ruleIndex)
+		if ruleStart.isPrecedenceRule {
+			p.enterRecursionRule(ctx, ruleStart.GetStateNumber(), ruleIndex, rt.precedence)
+		} else {
+			p.EnterRule(ctx, ruleStart.GetStateNumber(), ruleIndex)
+		}
+
+	case TransitionPREDICATE:
+		pt := t.(*PredicateTransition)
+		if !p.Sempred(p.ctx, pt.ruleIndex, pt.predIndex) {
+			p.SetError(NewFailedPredicateException(p, "", ""))
+		}
+
+	case TransitionACTION:
+		// the interpreter has no actions to run
+
+	case TransitionPRECEDENCE:
+		pt := t.(*PrecedencePredicateTransition)
+		if !p.Precpred(p.ctx, pt.precedence) {
+			p.SetError(NewFailedPredicateException(p, fmt.Sprintf("precpred(_ctx, %d)", pt.precedence), ""))
+		}
+
+	default:
+		panic(fmt.Sprintf("unrecognized ATN transition type %d", t.getSerializationType()))
+	}
+
+	if p.HasError() {
+		return
+	}
+	p.SetState(t.getTarget().GetStateNumber())
+}
+
+// visitRuleStopState returns from the rule that s ends to the state that follows its invocation.
+func (p *ParserInterpreter) visitRuleStopState(s ATNState) {
+	ruleStart := p.atn.ruleToStartState[s.GetRuleIndex()]
+	if ruleStart.isPrecedenceRule {
+		parent := p.popParent()
+		p.UnrollRecursionContexts(parent.ctx)
+		p.SetState(parent.invokingState)
+	} else {
+		p.ExitRule()
+	}
+
+	rt := p.atn.states[p.GetState()].GetTransitions()[0].(*RuleTransition)
+	p.SetState(rt.followState.GetStateNumber())
+}
+
+// recover recovers from the syntax error e with the error strategy, and adds an error node for the offending
+// token to the tree if the strategy consumed no input in doing so, so that the tree shows where the error was.
+func (p *ParserInterpreter) recover(e RecognitionException) {
+	i := p.input.Index()
+	p.GetErrorHandler().Recover(p, e)
+	if p.input.Index() != i || !p.BuildParseTrees {
+		return
+	}
+
+	tok := e.GetOffendingToken()
+	if tok == nil {
+		return
+	}
+	ttype := TokenInvalidType
+	if ime, ok := e.(*InputMisMatchException); ok {
+		if expected := ime.getExpectedTokens(); expected != nil {
+			ttype = expected.first()
+		}
+	}
+	errToken := p.GetTokenFactory().Create(tok.GetSource(), ttype, tok.GetText(), TokenDefaultChannel, -1, -1, tok.GetLine(), tok.GetColumn())
+	p.ctx.AddErrorNode(errToken)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"slices"
+	"testing"
+)
+
+// newListInterpreter returns an interpreter of the list grammar, deserialized afresh, that parses input.
+func newListInterpreter(input string) *ParserInterpreter {
+	atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+	p := NewParserInterpreter("T.g4", []string{"", "", "'+'"}, []string{"", "ID", "PLUS", "WS"},
+		[]string{"s", "item"}, atn, NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+	p.RemoveErrorListeners()
+	return p
+}
+
+func TestLexerInterpreter(t *testing.T) {
+	atn := NewATNDeserializer(nil).Deserialize(listLexerSerialized)
+	lexer := NewLexerInterpreter("T.g4", []string{"", "", "'+'"}, []string{"", "ID", "PLUS", "WS"},
+		[]string{"ID", "PLUS", "WS"}, []string{"DEFAULT_TOKEN_CHANNEL", "HIDDEN"}, []string{"DEFAULT_MODE"}, atn,
+		NewInputStream("ab +c  d+"))
+	var got []string
+	for tok := lexer.NextToken(); tok.GetTokenType() != TokenEOF; tok = lexer.NextToken() {
+		got = append(got, tok.GetText()+":"+lexer.GetSymbolicNames()[tok.GetTokenType()])
+	}
+	if want := []string{"ab:ID", "+:PLUS", "c:ID", "d:ID", "+:PLUS"}; !slices.Equal(got, want) {
+		t.Errorf("tokens %v, want %v", got, want)
+	}
+	if lexer.GetGrammarFileName() != "T.g4" || len(lexer.GetChannelNames()) != 2 || lexer.GetModeNames()[0] != "DEFAULT_MODE" {
+		t.Errorf("grammar %s, channels %v, modes %v", lexer.GetGrammarFileName(), lexer.GetChannelNames(),
+			lexer.GetModeNames())
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewLexerInterpreter of a parser ATN did not panic")
+		}
+	}()
+	NewLexerInterpreter("T.g4", nil, nil, nil, nil, nil, NewATNDeserializer(nil).Deserialize(listParserSerialized), nil)
+}
+
+func TestParserInterpreter(t *testing.T) {
+	for _, input := range []string{"a b + c d", "", "a + + b", "a +", "+ a"} {
+		p := newListInterpreter(input)
+		errors := new(messageRecorder)
+		p.AddErrorListener(errors)
+		tree := p.Parse(listRuleS)
+
+		generated := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+		generated.RemoveErrorListeners()
+		generatedErrors := new(messageRecorder)
+		generated.AddErrorListener(generatedErrors)
+		want := generated.S().ToStringTree(nil, generated)
+
+		if got := tree.ToStringTree(nil, p); got != want || tree != p.GetRootContext() {
+			t.Errorf("%q: tree %s, want %s", input, got, want)
+		}
+		if !slices.Equal(errors.messages, generatedErrors.messages) {
+			t.Errorf("%q: errors %v, want %v", input, errors.messages, generatedErrors.messages)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("NewParserInterpreter of a lexer ATN did not panic")
+		}
+	}()
+	NewParserInterpreter("T.g4", nil, nil, nil, NewATNDeserializer(nil).Deserialize(listLexerSerialized), nil)
+}
+
+func TestParserInterpreterDecisionOverride(t *testing.T) {
+	p := newListInterpreter("a + b")
+	if p.GetOverrideDecision() != -1 {
+		t.Errorf("decision %d is overridden", p.GetOverrideDecision())
+	}
+	// the item at a is made to end at a, which leaves + b to the loop of s
+	p.AddDecisionOverride(listRuleItem, 0, 1)
+	errors := new(messageRecorder)
+	p.AddErrorListener(errors)
+	for i := 0; i < 2; i++ {
+		errors.messages = nil
+		p.GetTokenStream().Seek(0)
+		tree := p.Parse(listRuleS)
+		if got := tree.ToStringTree(nil, p); got != "(s (item a) + (item b) <EOF>)" || len(errors.messages) != 1 {
+			t.Errorf("parse %d: %s with errors %v", i, got, errors.messages)
+		}
+	}
+	if p.GetOverrideDecision() != listRuleItem {
+		t.Errorf("decision %d is overridden", p.GetOverrideDecision())
+	}
+}
+
+func TestParserInterpreterErrorHandler(t *testing.T) {
+	// the error at the second + is recovered from by consuming up to b, with the calls a generated parser makes
+	p := newListInterpreter("a + + + b")
+	strategy := &indexRecorder{DefaultErrorStrategy: NewDefaultErrorStrategy()}
+	p.SetErrorHandler(strategy)
+	p.Parse(listRuleS)
+	generated := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a + + + b")), TokenDefaultChannel))
+	generated.RemoveErrorListeners()
+	generatedStrategy := &indexRecorder{DefaultErrorStrategy: NewDefaultErrorStrategy()}
+	generated.SetErrorHandler(generatedStrategy)
+	generated.S()
+	if !slices.Contains(strategy.indexes, 2) || !slices.Equal(strategy.indexes, generatedStrategy.indexes) {
+		t.Errorf("the error strategy handled calls at %v, want %v", strategy.indexes, generatedStrategy.indexes)
+	}
+
+	p = newListInterpreter("a + + b")
+	p.SetErrorHandler(NewBailErrorStrategy())
+	tree := p.Parse(listRuleS)
+	if _, ok := p.GetError().(*ParseCancellationException); !ok || tree.GetChildCount() != 1 {
+		t.Errorf("the parse ended with %v: %s", p.GetError(), tree.ToStringTree(nil, p))
+	}
+}
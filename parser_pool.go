@@ -0,0 +1,128 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "sync"
+
+// ParserPool is a pool of parsers, each with its lexer and token stream, for a server or other program that
+// parses many inputs concurrently, and would rather not create a parser, a lexer and a stream for each. A
+// goroutine takes a parser from the pool with [ParserPool.Get], parses one input with it, and gives it back
+// with [ParserPool.Put]. A ParserPool is safe for concurrent use, but each [PooledParser] is used by one
+// goroutine at a time, between Get and Put.
+//
+// What may be shared between parsers is shared, and the rest is not: the parsers and lexers that a generated
+// constructor creates share the [ATN], the DFA cache and the prediction context cache of their grammar,
+// which are safe for concurrent use, so parsers from the pool warm up the caches for one another. The error
+// strategy, which holds the state of error recovery, is created anew for each parser by the [RuntimeConfig]
+// of the pool, and the state of a parse is reset by Get.
+//
+// Every parser has the settings of the config of the pool, as it is applied by [RuntimeConfig.Apply], and
+// its lexer has the error listeners of the config. A borrower may change those settings, such as to add a
+// listener for one parse, as Put applies the config again; Put also removes any context, decision listener
+// and profiler that the borrower set, see [BaseParser.SetContext], [BaseParser.SetDecisionListener] and
+// [BaseParser.SetProfile]. Other settings of a parser that a borrower changes, such as its soft keywords,
+// hooks or rule context factory, are kept for the next borrower, so a borrower that changes them must
+// restore them before Put. Put also drops the input and the tokens of the parse, so that the pool does not
+// keep them alive; the tree returned by the parse, and the tokens it holds, stay valid after Put. The lexer
+// and parser of the grammar themselves must not keep state of their own between parses, such as in fields
+// set by actions, as the pool cannot reset it.
+//
+// Use:
+//
+//	var pool = antlr.NewParserPool(parser.NewMyLexer, parser.NewMyParser,
+//	    antlr.NewRuntimeConfig(antlr.WithErrorListeners(myListener)))
+//	...
+//	pp := pool.Get(antlr.NewInputStream(text))
+//	tree := pp.Parser.Document()
+//	pool.Put(pp)
+type ParserPool[L Lexer, P Parser] struct {
+	newLexer  func(input CharStream) L
+	newParser func(input TokenStream) P
+	config    *RuntimeConfig
+	pool      sync.Pool
+}
+
+// PooledParser is a parser of a [ParserPool], with its lexer and the token stream between them.
+type PooledParser[L Lexer, P Parser] struct {
+	Lexer  L
+	Stream *CommonTokenStream
+	Parser P
+}
+
+// pooledLexer is the lexer of a [PooledParser], which must embed [BaseLexer].
+type pooledLexer interface {
+	SetInputStream(CharStream)
+}
+
+// NewParserPool creates a [ParserPool] of the parsers created by newParser, with lexers created by newLexer,
+// such as the constructors of a generated parser and lexer, and with the settings of config, or if config is
+// nil, with those of NewRuntimeConfig(). The config must not be one captured with [SnapshotRuntimeConfig],
+// as its error strategy would be shared by the parsers. Any [Arena] set by the config with [WithArena] is
+// not used, as an arena is not safe for concurrent use and the parsers of the pool run concurrently; give a
+// borrowed parser an arena of its own with [BaseParser.SetArena] instead. The token stream of each parser is a
+// [CommonTokenStream] of the tokens on the default channel. Parsers are created as they are needed, and the
+// constructors are called with a nil input. It panics if the lexer does not embed [BaseLexer], or the parser
+// does not embed [BaseParser].
+func NewParserPool[L Lexer, P Parser](newLexer func(input CharStream) L, newParser func(input TokenStream) P, config *RuntimeConfig) *ParserPool[L, P] {
+	if config == nil {
+		config = NewRuntimeConfig()
+	} else if config.arena != nil {
+		unshared := *config
+		unshared.arena = nil
+		config = &unshared
+	}
+	pp := &ParserPool[L, P]{newLexer: newLexer, newParser: newParser, config: config}
+	pp.pool.New = func() any {
+		return pp.create()
+	}
+	return pp
+}
+
+// create creates a parser for the pool, with its lexer and stream, and applies the config to them.
+func (pp *ParserPool[L, P]) create() *PooledParser[L, P] {
+	lexer := pp.newLexer(nil)
+	if _, ok := any(lexer).(pooledLexer); !ok {
+		panic("a ParserPool requires a lexer that embeds BaseLexer")
+	}
+	stream := NewCommonTokenStream(lexer, TokenDefaultChannel)
+	entry := &PooledParser[L, P]{Lexer: lexer, Stream: stream, Parser: pp.newParser(nil)}
+	pp.configure(entry)
+	return entry
+}
+
+// configure applies the config of the pool to the parser and lexer of entry.
+func (pp *ParserPool[L, P]) configure(entry *PooledParser[L, P]) {
+	pp.config.Apply(entry.Parser)
+	entry.Lexer.RemoveErrorListeners()
+	for _, listener := range pp.config.errorListeners {
+		entry.Lexer.AddErrorListener(listener)
+	}
+}
+
+// Get takes a parser from the pool, or creates one if the pool is empty, and sets it to parse input: the
+// lexer is given input, and the parser a new stream of its tokens, and both are reset, so that the parser
+// begins as a newly created one would.
+func (pp *ParserPool[L, P]) Get(input CharStream) *PooledParser[L, P] {
+	entry := pp.pool.Get().(*PooledParser[L, P])
+	any(entry.Lexer).(pooledLexer).SetInputStream(input)
+	entry.Stream.SetTokenSource(entry.Lexer)
+	runtimeConfigParser(entry.Parser).SetTokenStream(entry.Stream)
+	return entry
+}
+
+// Put gives a parser taken with [ParserPool.Get] back to the pool, restoring its settings to those of the
+// config of the pool, removing its context, decision listener and profiler, and dropping its input and
+// tokens. The parser, its lexer and its stream must not be used after Put, but the trees it built may be.
+func (pp *ParserPool[L, P]) Put(entry *PooledParser[L, P]) {
+	bp := runtimeConfigParser(entry.Parser)
+	bp.setProgress(0, nil, nil)
+	bp.Interpreter.SetDecisionListener(nil, 0)
+	bp.Interpreter.SetProfile(false)
+	pp.configure(entry)
+	any(entry.Lexer).(pooledLexer).SetInputStream(nil)
+	entry.Stream.SetTokenSource(entry.Lexer)
+	bp.SetTokenStream(nil)
+	pp.pool.Put(entry)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParserPool(t *testing.T) {
+	errs := &countingErrorListener{}
+	pool := NewParserPool(newListLexer, newListParser,
+		NewRuntimeConfig(WithErrorListeners(errs), WithArena(NewArena()), WithPredictionMode(PredictionModeSLL)))
+	if pool.config.arena != nil {
+		t.Error("the parsers of the pool share an arena")
+	}
+
+	pp := pool.Get(NewInputStream("a + + b"))
+	if tree := pp.Parser.S().ToStringTree(nil, pp.Parser); tree != "(s (item a + + b) <EOF>)" || errs.errors != 1 {
+		t.Errorf("tree %s with %d errors", tree, errs.errors)
+	}
+	if pp.Parser.GetInterpreter().GetPredictionMode() != PredictionModeSLL || pp.Parser.GetInterpreter().GetArena() != nil {
+		t.Error("the parser does not have the settings of the config")
+	}
+
+	// the borrower's settings are undone by Put
+	pp.Parser.AddErrorListener(NewDiagnosticErrorListener(false))
+	pp.Parser.SetDecisionListener(&decisionRecorder{}, 1)
+	pp.Parser.SetProfile(true)
+	pp.Parser.GetInterpreter().SetPredictionMode(PredictionModeLL)
+	pool.Put(pp)
+	if listeners := pp.Parser.listeners; len(listeners) != 1 || listeners[0] != errs {
+		t.Errorf("the parser has the error listeners %v after Put", listeners)
+	}
+	if sim := pp.Parser.GetInterpreter(); sim.observer != nil || sim.GetPredictionMode() != PredictionModeSLL ||
+		pp.Parser.GetParseInfo() != nil {
+		t.Error("the borrower's settings were kept by Put")
+	}
+	if pp.Lexer.GetInputStream() != nil || pp.Parser.GetTokenStream() != nil || len(pp.Stream.GetAllTokens()) != 0 {
+		t.Error("the input of the parse was kept by Put")
+	}
+
+	// a parser taken again parses as a new one would
+	pp = pool.Get(NewInputStream("c d"))
+	defer pool.Put(pp)
+	if tree := pp.Parser.S().ToStringTree(nil, pp.Parser); tree != "(s (item c) (item d) <EOF>)" || errs.errors != 1 {
+		t.Errorf("tree %s with %d errors", tree, errs.errors)
+	}
+}
+
+func TestParserPoolConcurrent(t *testing.T) {
+	pool := NewParserPool(newListLexer, newListParser, NewRuntimeConfig(WithErrorListeners()))
+	var wg sync.WaitGroup
+	failures := make(chan string, 8)
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				input := strings.Repeat(fmt.Sprintf("a%c + b ", 'a'+g), i%5) + "c"
+				pp := pool.Get(NewInputStream(input))
+				tree := pp.Parser.S().ToStringTree(nil, pp.Parser)
+				pool.Put(pp)
+				if _, want := listParse(input); tree != want.ToStringTree(nil, newListParser(nil)) {
+					failures <- fmt.Sprintf("%q: tree %s", input, tree)
+					return
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	close(failures)
+	for failure := range failures {
+		t.Error(failure)
+	}
+}
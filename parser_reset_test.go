@@ -0,0 +1,45 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestParserReset(t *testing.T) {
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a + + b")), TokenDefaultChannel))
+	errs := &messageRecorder{}
+	p.RemoveErrorListeners()
+	p.AddErrorListener(errs)
+	want := p.S().ToStringTree(nil, p)
+	if p._SyntaxErrors != 1 {
+		t.Fatalf("%d syntax errors", p._SyntaxErrors)
+	}
+
+	p.Reset()
+	if p._SyntaxErrors != 0 || p.GetTokenStream().Index() != 0 || p.GetParserRuleContext() != nil ||
+		p.Interpreter.input != nil || p.Interpreter.dfa != nil {
+		t.Errorf("Reset kept the state of the parse")
+	}
+	if got := p.S().ToStringTree(nil, p); got != want || len(errs.messages) != 2 {
+		t.Errorf("after Reset: tree %s, want %s, errors %q", got, want, errs.messages)
+	}
+
+	// a cancelled parse is no longer cancelled, and the configuration is kept
+	p.SetErrorHandler(NewBailErrorStrategy())
+	p.ResetWithInputStream(NewCommonTokenStream(newListLexer(NewInputStream("a +")), TokenDefaultChannel))
+	p.S()
+	if p.GetError() == nil {
+		t.Fatal("the parse was not cancelled")
+	}
+	p.ResetWithInputStream(NewCommonTokenStream(newListLexer(NewInputStream("c d")), TokenDefaultChannel))
+	if p.GetError() != nil || p.HasError() {
+		t.Errorf("ResetWithInputStream kept the error %v", p.GetError())
+	}
+	if got := p.S().ToStringTree(nil, p); got != "(s (item c) (item d) <EOF>)" || p.GetError() != nil {
+		t.Errorf("after ResetWithInputStream: tree %s, error %v", got, p.GetError())
+	}
+	if _, ok := p.GetErrorHandler().(*BailErrorStrategy); !ok || len(p.listeners) != 1 {
+		t.Error("the configuration was not kept")
+	}
+}
@@ -9,6 +9,15 @@ import (
 	"strconv"
 )
 
+// ParserRuleContext is the interface the runtime uses for every rule invocation in a parse tree. It is
+// the complete set of methods the runtime calls on a context; nothing in the runtime requires a context
+// to be, or to embed, a [BaseParserRuleContext]. Users who need their own node types, for instance with
+// precomputed semantic fields or a more compact representation, may therefore implement this interface
+// directly and have the parser create their types via a [RuleContextFactory].
+//
+// An implementation must keep the parent, invoking state, start and stop tokens and children that the
+// parser gives it, and return them faithfully. AddTokenNode and AddErrorNode create the leaf nodes with
+// [NewTerminalNodeImpl] and [NewErrorNodeImpl], set their parent and add them as children.
 type ParserRuleContext interface {
 	RuleContext
 
@@ -30,6 +39,13 @@ type ParserRuleContext interface {
 	RemoveLastChild()
 }
 
+// RuleContextFactory creates the context for an invocation of the rule with the given index. It may return
+// nil to let the parser create its usual context type for that rule. A factory that needs the parser can
+// capture it in a closure. See [BaseParser.SetRuleContextFactory].
+type RuleContextFactory func(parent ParserRuleContext, invokingState, ruleIndex int) ParserRuleContext
+
+var _ ParserRuleContext = &BaseParserRuleContext{}
+
 type BaseParserRuleContext struct {
 	parentCtx     RuleContext
 	invokingState int
@@ -38,6 +54,8 @@ type BaseParserRuleContext struct {
 	start, stop Token
 	exception   RecognitionException
 	children    []Tree
+	altNumber   int
+	altLabel    string
 }
 
 func NewBaseParserRuleContext(parent ParserRuleContext, invokingStateNumber int) *BaseParserRuleContext {
@@ -60,6 +78,7 @@ func InitBaseParserRuleContext(prc *BaseParserRuleContext, parent ParserRuleCont
 	}
 
 	prc.RuleIndex = -1
+	prc.altNumber = ATNInvalidAltNumber
 	// * If we are debugging or building a parse tree for a Visitor,
 	// we need to track all of the tokens and rule invocations associated
 	// with prc rule's context. This is empty for parsing w/o tree constr.
@@ -86,6 +105,8 @@ func (prc *BaseParserRuleContext) CopyFrom(ctx *BaseParserRuleContext) {
 	// from RuleContext
 	prc.parentCtx = ctx.parentCtx
 	prc.invokingState = ctx.invokingState
+	prc.altNumber = ctx.altNumber
+	prc.altLabel = ctx.altLabel
 	prc.children = nil
 	prc.start = ctx.start
 	prc.stop = ctx.stop
@@ -374,11 +395,28 @@ func (prc *BaseParserRuleContext) GetRuleIndex() int {
 	return prc.RuleIndex
 }
 
+// GetAltNumber returns the number of the outer alternative of the rule that matched, which the parser
+// records as it enters the alternative, or [ATNInvalidAltNumber] if it has not yet been entered.
 func (prc *BaseParserRuleContext) GetAltNumber() int {
-	return ATNInvalidAltNumber
+	return prc.altNumber
+}
+
+// SetAltNumber records the number of the outer alternative of the rule that matched.
+func (prc *BaseParserRuleContext) SetAltNumber(altNumber int) {
+	prc.altNumber = altNumber
 }
 
-func (prc *BaseParserRuleContext) SetAltNumber(_ int) {}
+// AltLabel returns the label given in the grammar, as in `# Add`, to the outer alternative of the rule that
+// matched, or the empty string if the alternative has no label or its label was not registered with the
+// parser, see [BaseParser.RegisterAltLabels].
+func (prc *BaseParserRuleContext) AltLabel() string {
+	return prc.altLabel
+}
+
+// setAltLabel records the label of the outer alternative of the rule that matched.
+func (prc *BaseParserRuleContext) setAltLabel(label string) {
+	prc.altLabel = label
+}
 
 // IsEmpty returns true if the context of b is empty.
 //
@@ -398,8 +436,17 @@ func (prc *BaseParserRuleContext) GetParent() Tree {
 	return prc.parentCtx
 }
 
+// ParserRuleContextEmpty is the shared empty context, which has no parent and no invoking state. It is used
+// by the runtime wherever a context is needed but nothing called the rule, and must never be modified. Use
+// [NewEmptyParserRuleContext] to obtain an empty context that may be modified. See [IsEmptyContext].
 var ParserRuleContextEmpty = NewBaseParserRuleContext(nil, -1)
 
+// NewEmptyParserRuleContext returns a new empty context, with no parent and no invoking state, which
+// behaves exactly like [ParserRuleContextEmpty] for the purposes of lookahead and prediction.
+func NewEmptyParserRuleContext() ParserRuleContext {
+	return NewBaseParserRuleContext(nil, -1)
+}
+
 type InterpreterRuleContext interface {
 	ParserRuleContext
 }
@@ -408,8 +455,9 @@ type BaseInterpreterRuleContext struct {
 	*BaseParserRuleContext
 }
 
-//goland:noinspection GoUnusedExportedFunction
-func NewBaseInterpreterRuleContext(parent BaseInterpreterRuleContext, invokingStateNumber, ruleIndex int) *BaseInterpreterRuleContext {
+// NewBaseInterpreterRuleContext creates the context of a rule parsed by an interpreter rather than by a generated
+// parser, which knows the index of the rule but has no type of its own for it.
+func NewBaseInterpreterRuleContext(parent ParserRuleContext, invokingStateNumber, ruleIndex int) *BaseInterpreterRuleContext {
 
 	prc := new(BaseInterpreterRuleContext)
 
@@ -0,0 +1,120 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+// userItemContext is a context type of the user's own for the item rule.
+type userItemContext struct {
+	BaseParserRuleContext
+	created int
+}
+
+func (c *userItemContext) GetRuleContext() RuleContext { return c }
+
+func TestRuleContextFactory(t *testing.T) {
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a")), TokenDefaultChannel))
+	if p.NewRuleContext(nil, -1, listRuleItem) != nil {
+		t.Error("a parser without a factory created a context")
+	}
+	created := 0
+	p.SetRuleContextFactory(func(parent ParserRuleContext, invokingState, ruleIndex int) ParserRuleContext {
+		if ruleIndex != listRuleItem {
+			return nil
+		}
+		created++
+		ctx := &userItemContext{created: created}
+		InitBaseParserRuleContext(&ctx.BaseParserRuleContext, parent, invokingState)
+		ctx.RuleIndex = ruleIndex
+		return ctx
+	})
+	if p.GetRuleContextFactory() == nil {
+		t.Fatal("the factory was not installed")
+	}
+
+	// Parse s : item EOF as a generated parser that consults the factory does
+	s := newListSContext(p, nil, -1)
+	if p.NewRuleContext(nil, -1, listRuleS) != nil {
+		t.Error("the factory created a context for a rule it declined")
+	}
+	p.EnterRule(s, 0, listRuleS)
+	p.EnterOuterAlt(s, 1)
+	item := p.NewRuleContext(s, 4, listRuleItem)
+	p.EnterRule(item, 2, listRuleItem)
+	p.Match(listID)
+	p.ExitRule()
+	p.Match(TokenEOF)
+	p.ExitRule()
+
+	if user, ok := item.(*userItemContext); !ok || user.created != 1 {
+		t.Fatalf("the item context is %T", item)
+	}
+	if got := s.ToStringTree(nil, p); got != "(s (item a) <EOF>)" || s.GetChild(0) != item || item.GetParent() != s {
+		t.Errorf("tree %s", got)
+	}
+	if item.GetStart().GetText() != "a" || item.GetStop().GetText() != "a" || item.GetInvokingState() != 4 {
+		t.Errorf("item from %s to %s invoked at %d", item.GetStart(), item.GetStop(), item.GetInvokingState())
+	}
+
+	p.SetRuleContextFactory(nil)
+	if p.NewRuleContext(s, 4, listRuleItem) != nil {
+		t.Error("a removed factory created a context")
+	}
+}
+
+func TestAltNumbersAndLabels(t *testing.T) {
+	p, tree := listParse("a b + c")
+	first, second := tree.GetChild(0).(ParserRuleContext), tree.GetChild(1).(ParserRuleContext)
+	if tree.GetAltNumber() != 1 || first.GetAltNumber() != 1 || second.GetAltNumber() != 2 {
+		t.Errorf("alternatives %d, %d and %d", tree.GetAltNumber(), first.GetAltNumber(), second.GetAltNumber())
+	}
+	if p.GetRuleDecision(listRuleItem) != 1 || p.GetRuleDecision(listRuleS) != -1 {
+		t.Errorf("the rule decisions are %d and %d", p.GetRuleDecision(listRuleS), p.GetRuleDecision(listRuleItem))
+	}
+
+	p.SetAltLabel(1, 2, "Plus")
+	if label, ok := p.GetContextAltLabel(second); !ok || label != "Plus" {
+		t.Errorf("the label of b + c is %q, %v", label, ok)
+	}
+	if label, ok := p.GetContextAltLabel(first); ok {
+		t.Errorf("a has the label %q", label)
+	}
+
+	if got := tree.ToStringTree(nil, p); got != "(s (item a) (item b + c) <EOF>)" {
+		t.Errorf("tree %s", got)
+	}
+	if err := ConfigureRuntime(WithAltNumbersInTreeText(true)); err != nil {
+		t.Fatal(err)
+	}
+	defer ConfigureRuntime(WithAltNumbersInTreeText(false))
+	if got := tree.ToStringTree(nil, p); got != "(s:1 (item:1 a) (item:2 b + c) <EOF>)" {
+		t.Errorf("tree with alternatives %s", got)
+	}
+}
+
+func TestRegisterAltLabels(t *testing.T) {
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a b + c")), TokenDefaultChannel))
+	p.RegisterAltLabels(listRuleItem, "", "Plus")
+	tree := p.S()
+	for i, want := range []string{"", "Plus"} {
+		item := tree.GetChild(i).(interface{ AltLabel() string })
+		if got := item.AltLabel(); got != want {
+			t.Errorf("item %d has the label %q, want %q", i, got, want)
+		}
+	}
+
+	// ambiguity reports name the alternatives by their labels
+	alts := NewBitSet()
+	alts.add(1)
+	alts.add(2)
+	d := NewDiagnosticErrorListener(false)
+	dfa := NewDFA(p.GetATN().DecisionToState[1], 1)
+	if got := d.getAltsDescription(p, dfa, alts); got != "{1, 2 (Plus)}" {
+		t.Errorf("alternatives %s", got)
+	}
+	if got := d.getAltsDescription(newListParser(nil), dfa, alts); got != alts.String() {
+		t.Errorf("alternatives without labels %s", got)
+	}
+}
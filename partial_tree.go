@@ -0,0 +1,64 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// AbortMarkerText is the text of the token of the error node that marks where an aborted parse stopped, see
+// [BaseParser.GetPartialTree].
+const AbortMarkerText = "<aborted>"
+
+// GetPartialTree returns the root of the parse tree that was being built when the parse was aborted, as by a
+// [BailErrorStrategy], a limit or a progress callback, or nil if the parse was not aborted. The tree holds
+// everything recognized before the parse stopped, so that tools can still use the structure of the input
+// before the abort. Each rule that was in progress when it stopped is in the tree, with the error it was
+// aborted for as its exception, and an error node whose token has the text [AbortMarkerText] is added as the
+// last child of the innermost of them, at the point the parse stopped. See [BaseParser.GetAbortMarker].
+//
+// This is the same tree that the start rule returns, but it can be had from the parser even when the start rule
+// was called by code that did not keep its result. No marker is added if the parser was not building a tree.
+//
+// Use:
+//
+//	tree := p.Start()
+//	if partial := p.GetPartialTree(); partial != nil {
+//	    marker := p.GetAbortMarker()
+//	    log.Printf("parse aborted at line %d: %v", marker.GetSymbol().GetLine(), p.GetError())
+//	    index(partial)
+//	}
+func (p *BaseParser) GetPartialTree() ParserRuleContext {
+	return p.partialTree
+}
+
+// GetAbortMarker returns the error node that marks where an aborted parse stopped, in the tree returned by
+// [BaseParser.GetPartialTree]. Its token has the text [AbortMarkerText], and the position of the token that
+// was current when the parse stopped. It returns nil if the parse was not aborted, or if the parser was not
+// building a tree.
+func (p *BaseParser) GetAbortMarker() ErrorNode {
+	return p.abortMarker
+}
+
+// markAbort records the root of the tree being built as the partial tree, and adds the abort marker to the
+// context of the innermost rule in progress.
+func (p *BaseParser) markAbort() {
+	if p.ctx == nil {
+		return
+	}
+	root := p.ctx
+	for {
+		parent, ok := root.GetParent().(ParserRuleContext)
+		if !ok {
+			break
+		}
+		root = parent
+	}
+	p.partialTree = root
+
+	if !p.BuildParseTrees || p.validateOnly || p.skipping() {
+		return
+	}
+	current := p.input.LT(1)
+	marker := p.GetTokenFactory().Create(current.GetSource(), TokenInvalidType, AbortMarkerText, TokenDefaultChannel, -1, -1,
+		current.GetLine(), current.GetColumn())
+	p.abortMarker = p.ctx.AddErrorNode(marker)
+}
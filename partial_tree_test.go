@@ -0,0 +1,68 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPartialTree(t *testing.T) {
+	stop := errors.New("stop")
+	tests := []struct {
+		input  string
+		abort  func(p *listParser)
+		tree   string
+		column int
+	}{
+		{"a + + b", func(p *listParser) { p.SetErrorHandler(NewBailErrorStrategy()) }, "(s (item a + <aborted>))", 4},
+		{"a b + c d e", func(p *listParser) {
+			p.SetProgressCallback(3, func(progress ParseProgress) error {
+				if progress.TokensConsumed >= 3 {
+					return stop
+				}
+				return nil
+			})
+		}, "(s (item a) (item b + <aborted>))", 6},
+	}
+	for _, test := range tests {
+		p, _ := listParse("")
+		p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream(test.input)), TokenDefaultChannel))
+		p.RemoveErrorListeners()
+		test.abort(p)
+		tree := p.S()
+		if got := tree.ToStringTree(nil, p); got != test.tree || p.GetPartialTree() != tree {
+			t.Errorf("%q: partial tree %s, want %s", test.input, got, test.tree)
+		}
+		marker := p.GetAbortMarker()
+		if marker == nil || marker.GetSymbol().GetText() != AbortMarkerText || marker.GetSymbol().GetColumn() != test.column {
+			t.Fatalf("%q: abort marker %v", test.input, marker)
+		}
+		// the marker ends the rule in progress, which was aborted for the error of the parse
+		item := tree.GetChild(tree.GetChildCount() - 1).(*listItemContext)
+		if item.GetChild(item.GetChildCount()-1) != marker || item.exception == nil {
+			t.Errorf("%q: the marker is not last in %s, or it did not fail", test.input, item.ToStringTree(nil, p))
+		}
+
+		p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream("a")), TokenDefaultChannel))
+		if p.GetPartialTree() != nil || p.GetAbortMarker() != nil {
+			t.Errorf("%q: the partial tree was kept for the next parse", test.input)
+		}
+	}
+
+	// no tree is built, so no marker is added
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a + + b")), TokenDefaultChannel))
+	p.RemoveErrorListeners()
+	p.SetErrorHandler(NewBailErrorStrategy())
+	p.BuildParseTrees = false
+	p.S()
+	if p.GetPartialTree() == nil || p.GetAbortMarker() != nil {
+		t.Errorf("partial tree %v with marker %v", p.GetPartialTree(), p.GetAbortMarker())
+	}
+
+	if p, _ := listParse("a b"); p.GetPartialTree() != nil {
+		t.Errorf("a parse that was not aborted has the partial tree %v", p.GetPartialTree())
+	}
+}
@@ -68,6 +68,12 @@ func NewEmptyPredictionContext() *PredictionContext {
 
 func NewBaseSingletonPredictionContext(parent *PredictionContext, returnState int) *PredictionContext {
 	pc := &PredictionContext{}
+	pc.initSingleton(parent, returnState)
+	return pc
+}
+
+// initSingleton makes pc, which is zeroed, the singleton context of returnState on parent.
+func (pc *PredictionContext) initSingleton(parent *PredictionContext, returnState int) {
 	pc.pcType = PredictionContextSingleton
 	pc.returnState = returnState
 	pc.parentCtx = parent
@@ -76,7 +82,6 @@ func NewBaseSingletonPredictionContext(parent *PredictionContext, returnState in
 	} else {
 		pc.cachedHash = calculateEmptyHash()
 	}
-	return pc
 }
 
 func SingletonBasePredictionContextCreate(parent *PredictionContext, returnState int) *PredictionContext {
@@ -297,16 +302,19 @@ func calculateHash(parent *PredictionContext, returnState int) int {
 // Return {@link //EMPTY} if {@code outerContext} is empty or nil.
 // /
 func predictionContextFromRuleContext(a *ATN, outerContext RuleContext) *PredictionContext {
-	if outerContext == nil {
-		outerContext = ParserRuleContextEmpty
-	}
+	// A nil context is treated as the empty context here, as prediction always has a context.
+	//
 	// if we are in RuleContext of start rule, s, then BasePredictionContext
 	// is EMPTY. Nobody called us. (if we are empty, return empty)
-	if outerContext.GetParent() == nil || outerContext == ParserRuleContextEmpty {
+	if isNilContext(outerContext) || IsEmptyContext(outerContext) {
+		return BasePredictionContextEMPTY
+	}
+	parentCtx := parentContext(outerContext)
+	if parentCtx == nil {
 		return BasePredictionContextEMPTY
 	}
 	// If we have a parent, convert it to a BasePredictionContext graph
-	parent := predictionContextFromRuleContext(a, outerContext.GetParent().(RuleContext))
+	parent := predictionContextFromRuleContext(a, parentCtx)
 	state := a.states[outerContext.GetInvokingState()]
 	transition := state.GetTransitions()[0]
 
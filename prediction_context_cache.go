@@ -1,5 +1,7 @@
 package antlr
 
+import "container/list"
+
 var BasePredictionContextEMPTY = &PredictionContext{
 	cachedHash:  calculateEmptyHash(),
 	pcType:      PredictionContextEmpty,
@@ -9,14 +11,66 @@ var BasePredictionContextEMPTY = &PredictionContext{
 // PredictionContextCache is Used to cache [PredictionContext] objects. It is used for the shared
 // context cash associated with contexts in DFA states. This cache
 // can be used for both lexers and parsers.
+//
+// A cache is safe for concurrent use, so one cache can be shared by any number of recognizers, see
+// [BaseATNSimulator.SetSharedContextCache]. The cache created by [NewPredictionContextCache] grows without
+// bound; use [NewBoundedPredictionContextCache] for long-lived recognizers that see diverse inputs.
 type PredictionContextCache struct {
-	cache *JMap[*PredictionContext, *PredictionContext, Comparator[*PredictionContext]]
+	mu    Mutex
+	cache *JMap[*PredictionContext, cachedContext, Comparator[*PredictionContext]]
+
+	// lru holds the cached contexts, the most recently used first, if the cache is bounded to maxEntries
+	lru        *list.List
+	maxEntries int
+
+	stats PredictionContextCacheStats
+}
+
+// cachedContext is a context in a [PredictionContextCache], with its element in the LRU list of a bounded
+// cache.
+type cachedContext struct {
+	ctx *PredictionContext
+	e   *list.Element
+}
+
+// PredictionContextCacheStats holds the counts of a [PredictionContextCache], as returned by
+// [PredictionContextCache.Stats].
+type PredictionContextCacheStats struct {
+	// Hits and Misses are the number of lookups that found a context in the cache, and that did not
+	Hits   int
+	Misses int
+
+	// Size is the number of contexts in the cache, and Evictions the number of contexts evicted to make room
+	// for others
+	Size      int
+	Evictions int
 }
 
 func NewPredictionContextCache() *PredictionContextCache {
 	return &PredictionContextCache{
-		cache: NewJMap[*PredictionContext, *PredictionContext, Comparator[*PredictionContext]](pContextEqInst, PredictionContextCacheCollection, "NewPredictionContextCache()"),
+		cache: NewJMap[*PredictionContext, cachedContext, Comparator[*PredictionContext]](pContextEqInst, PredictionContextCacheCollection, "NewPredictionContextCache()"),
+	}
+}
+
+// NewBoundedPredictionContextCache creates a cache that holds up to maxEntries contexts, evicting the least
+// recently used context to make room for each new one once it is full. A cache with maxEntries of zero or
+// less is not bounded. Evicting a context does not affect the DFA states that refer to it, but the same
+// context may then be cached again as a different object, so a cache that is too small costs memory rather
+// than saving it.
+//
+// Use:
+//
+//	cache := antlr.NewBoundedPredictionContextCache(100_000)
+//	for _, p := range parsers {
+//	    p.GetInterpreter().SetSharedContextCache(cache)
+//	}
+func NewBoundedPredictionContextCache(maxEntries int) *PredictionContextCache {
+	c := NewPredictionContextCache()
+	if maxEntries > 0 {
+		c.lru = list.New()
+		c.maxEntries = maxEntries
 	}
+	return c
 }
 
 // Add a context to the cache and return it. If the context already exists,
@@ -27,22 +81,74 @@ func (p *PredictionContextCache) add(ctx *PredictionContext) *PredictionContext
 		return BasePredictionContextEMPTY
 	}
 
-	// Put will return the existing entry if it is present (note this is done via Equals, not whether it is
-	// the same pointer), otherwise it will add the new entry and return that.
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Get will return the existing entry if it is present (note this is done via Equals, not whether it is
+	// the same pointer), otherwise the new entry is added and returned.
 	//
-	existing, present := p.cache.Get(ctx)
-	if present {
+	if existing, present := p.lookup(ctx); present {
 		return existing
 	}
-	p.cache.Put(ctx, ctx)
+	var e *list.Element
+	if p.lru != nil {
+		if p.lru.Len() >= p.maxEntries {
+			oldest := p.lru.Back()
+			p.cache.Delete(p.lru.Remove(oldest).(*PredictionContext))
+			p.stats.Evictions++
+		}
+		e = p.lru.PushFront(ctx)
+	}
+	p.cache.Put(ctx, cachedContext{ctx: ctx, e: e})
 	return ctx
 }
 
 func (p *PredictionContextCache) Get(ctx *PredictionContext) (*PredictionContext, bool) {
-	pc, exists := p.cache.Get(ctx)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pc, exists := p.lookup(ctx)
+	if exists {
+		p.stats.Hits++
+	} else {
+		p.stats.Misses++
+	}
 	return pc, exists
 }
 
+// lookup returns the cached context equal to ctx, if there is one, and makes it the most recently used.
+// The cache must be locked.
+func (p *PredictionContextCache) lookup(ctx *PredictionContext) (*PredictionContext, bool) {
+	cached, exists := p.cache.Get(ctx)
+	if !exists {
+		return nil, false
+	}
+	if cached.e != nil {
+		p.lru.MoveToFront(cached.e)
+	}
+	return cached.ctx, true
+}
+
+// Clear removes every context from the cache. The counts returned by Stats are kept.
+func (p *PredictionContextCache) Clear() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cache = NewJMap[*PredictionContext, cachedContext, Comparator[*PredictionContext]](pContextEqInst, PredictionContextCacheCollection, "NewPredictionContextCache()")
+	if p.lru != nil {
+		p.lru.Init()
+	}
+}
+
+// Stats returns the counts of lookups in the cache and its size.
+func (p *PredictionContextCache) Stats() PredictionContextCacheStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Size = p.cache.Len()
+	return stats
+}
+
 func (p *PredictionContextCache) length() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	return p.cache.Len()
 }
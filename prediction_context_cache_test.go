@@ -0,0 +1,89 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestBoundedPredictionContextCache(t *testing.T) {
+	ctx := func(returnState int) *PredictionContext {
+		return SingletonBasePredictionContextCreate(BasePredictionContextEMPTY, returnState)
+	}
+	cache := NewBoundedPredictionContextCache(2)
+	a, b := cache.add(ctx(1)), cache.add(ctx(2))
+	if again := cache.add(ctx(1)); again != a {
+		t.Error("an equal context was cached again")
+	}
+	if cache.add(BasePredictionContextEMPTY) != BasePredictionContextEMPTY {
+		t.Error("the empty context was cached")
+	}
+
+	// a was used more recently than b, so b makes room for c
+	if got, ok := cache.Get(ctx(1)); !ok || got != a {
+		t.Error("a is not cached")
+	}
+	cache.add(ctx(3))
+	if _, ok := cache.Get(ctx(2)); ok {
+		t.Errorf("b, %v, was not evicted", b)
+	}
+	if _, ok := cache.Get(ctx(1)); !ok {
+		t.Error("a was evicted")
+	}
+	if got, want := cache.Stats(), (PredictionContextCacheStats{Hits: 2, Misses: 1, Size: 2, Evictions: 1}); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+
+	unbounded := NewBoundedPredictionContextCache(0)
+	for i := 0; i < 100; i++ {
+		unbounded.add(ctx(i))
+	}
+	if got := unbounded.Stats(); got.Size != 100 || got.Evictions != 0 {
+		t.Errorf("Stats() of an unbounded cache = %+v", got)
+	}
+}
+
+func TestSharedPredictionContextCache(t *testing.T) {
+	cache := NewBoundedPredictionContextCache(8)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+			for j := 0; j < 20; j++ {
+				p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a b + c d + e")), TokenDefaultChannel))
+				p.Interpreter = NewParserATNSimulator(p, atn, newDFA(atn), nil)
+				p.Interpreter.SetSharedContextCache(cache)
+				p.S()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := cache.Stats(); got.Size == 0 || got.Size > 8 {
+		t.Errorf("Stats() of the shared cache = %+v", got)
+	}
+}
+
+func TestPredictionContextCacheClear(t *testing.T) {
+	cache := NewBoundedPredictionContextCache(2)
+	a := SingletonBasePredictionContextCreate(BasePredictionContextEMPTY, 1)
+	cache.add(a)
+	cache.Get(a)
+	cache.Clear()
+	if got := cache.Stats(); got.Size != 0 || got.Hits != 1 {
+		t.Errorf("Stats() after Clear = %+v", got)
+	}
+	if _, ok := cache.Get(a); ok {
+		t.Error("Clear kept a")
+	}
+	// the LRU list was emptied too, so that it does not evict contexts that are no longer cached
+	cache.add(a)
+	cache.add(SingletonBasePredictionContextCreate(BasePredictionContextEMPTY, 2))
+	if got := cache.Stats(); got.Size != 2 || got.Evictions != 0 {
+		t.Errorf("Stats() = %+v", got)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// PredictionStats holds counts of the work done by adaptive prediction during a parse. The counts are kept
+// for every parse, as they cost no more than an increment each, and are reset when the parser is given new
+// input. They are much less detailed than the profile collected with the antlr.stats build tag, but give
+// enough of a picture to monitor the parsers of a production service, such as the share of decisions that
+// miss the DFA cache, and so whether the cache is warm.
+//
+// Use:
+//
+//	tree := p.Document()
+//	stats := p.GetPredictionStats()
+//	log.Printf("%d decisions, %d from the DFA cache, %d configurations", stats.Decisions, stats.DFAHits,
+//	    stats.ConfigsCreated)
+type PredictionStats struct {
+	// Decisions is the number of decisions that were predicted with adaptive prediction, which excludes
+	// those the parser decides with a single token of lookahead without calling the simulator
+	Decisions int
+
+	// DFAHits is the number of decisions predicted entirely from the DFA cache
+	DFAHits int
+
+	// ATNSimulations is the number of decisions that needed the ATN to be simulated for at least one token,
+	// because the DFA cache did not cover the input
+	ATNSimulations int
+
+	// FullContextPredictions is the number of decisions that fell back from SLL to full LL prediction
+	FullContextPredictions int
+
+	// ConfigsCreated is the number of [ATN] configurations computed by adaptive prediction
+	ConfigsCreated int
+}
+
+// GetPredictionStats returns the counts of the work done by adaptive prediction since the parser was last
+// given new input. Predictions made a second time to compare them, as with
+// [ParserATNSimulator.SetDifferentialPrediction], are not counted.
+func (p *ParserATNSimulator) GetPredictionStats() PredictionStats {
+	return p.stats
+}
+
+// GetPredictionStats returns the counts of the work done by adaptive prediction during the current parse,
+// see [PredictionStats].
+func (p *BaseParser) GetPredictionStats() PredictionStats {
+	return p.Interpreter.GetPredictionStats()
+}
+
+// add adds the counts of o to s.
+func (s *PredictionStats) add(o PredictionStats) {
+	s.Decisions += o.Decisions
+	s.DFAHits += o.DFAHits
+	s.ATNSimulations += o.ATNSimulations
+	s.FullContextPredictions += o.FullContextPredictions
+	s.ConfigsCreated += o.ConfigsCreated
+}
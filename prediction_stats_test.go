@@ -0,0 +1,50 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestPredictionStats(t *testing.T) {
+	atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+	decisionToDFA := newDFA(atn)
+	newParser := func(input string) *listParser {
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+		p.Interpreter = NewParserATNSimulator(p, atn, decisionToDFA, NewPredictionContextCache())
+		return p
+	}
+
+	tests := []struct {
+		input string
+		want  PredictionStats
+	}{
+		// the decision of item is predicted for a and for b + c, by simulating the ATN
+		{"a b + c", PredictionStats{Decisions: 2, ATNSimulations: 2, ConfigsCreated: 17}},
+		// then from the DFA cache
+		{"a b + c", PredictionStats{Decisions: 2, DFAHits: 2}},
+		// an item before EOF is not in the cache
+		{"a", PredictionStats{Decisions: 1, ATNSimulations: 1, ConfigsCreated: 2}},
+		{"a b + c a", PredictionStats{Decisions: 3, DFAHits: 3}},
+	}
+	for _, test := range tests {
+		p := newParser(test.input)
+		p.S()
+		if got := p.GetPredictionStats(); got != test.want {
+			t.Errorf("%q: %+v, want %+v", test.input, got, test.want)
+		}
+	}
+
+	// the counts are those of the current input, and predictions made twice count once
+	p := newParser("a b + c")
+	p.Interpreter.SetDifferentialPrediction(true)
+	p.S()
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream("d")), TokenDefaultChannel))
+	if got := p.GetPredictionStats(); got != (PredictionStats{}) {
+		t.Errorf("new input: %+v", got)
+	}
+	p.S()
+	if got, want := p.GetPredictionStats(), (PredictionStats{Decisions: 1, DFAHits: 1}); got != want {
+		t.Errorf("with differential prediction: %+v, want %+v", got, want)
+	}
+}
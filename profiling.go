@@ -0,0 +1,376 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DecisionEventInfo describes an event of interest during the prediction of a decision, as recorded by the
+// profiler, see [BaseParser.SetProfile]. The input from StartIndex to StopIndex, inclusive, is the lookahead
+// examined when the event occurred.
+type DecisionEventInfo struct {
+	Decision   int
+	StartIndex int
+	StopIndex  int
+
+	// Configs is the set of ATN configurations reached when the event occurred, which may be nil
+	Configs *ATNConfigSet
+
+	// FullCtx is true if the event occurred during full LL prediction, and false if during SLL prediction
+	FullCtx bool
+}
+
+// LookaheadEventInfo records a prediction that examined the most lookahead for its decision.
+type LookaheadEventInfo struct {
+	DecisionEventInfo
+
+	// PredictedAlt is the alternative predicted
+	PredictedAlt int
+}
+
+// ContextSensitivityInfo records a decision that SLL prediction found to conflict, and that full LL
+// prediction resolved to a single alternative, see [ErrorListener.ReportContextSensitivity].
+type ContextSensitivityInfo struct {
+	DecisionEventInfo
+}
+
+// AmbiguityInfo records a decision that full LL prediction found ambiguous, see
+// [ErrorListener.ReportAmbiguity].
+type AmbiguityInfo struct {
+	DecisionEventInfo
+
+	// AmbigAlts is the set of alternatives that match the input
+	AmbigAlts *BitSet
+}
+
+// ErrorInfo records a prediction that found no viable alternative. The parser reports the syntax error,
+// if any, as usual; a prediction may fail during SLL and then succeed with full LL.
+type ErrorInfo struct {
+	DecisionEventInfo
+}
+
+// DecisionInfo holds the statistics the profiler gathers for one decision of the grammar, over all the
+// predictions made for it since profiling was turned on, see [BaseParser.SetProfile]. Lookahead is counted
+// in tokens, including the token that resolved the prediction.
+type DecisionInfo struct {
+	Decision int
+
+	// Invocations is the number of times the decision was predicted with adaptive prediction
+	Invocations int64
+
+	// TimeInPrediction is the total time spent predicting the decision
+	TimeInPrediction time.Duration
+
+	// SLLTotalLook is the total lookahead examined by SLL prediction, and SLLMinLook and SLLMaxLook the least
+	// and most examined by any one prediction. SLLMaxLookEvent is the prediction that examined the most.
+	SLLTotalLook    int64
+	SLLMinLook      int64
+	SLLMaxLook      int64
+	SLLMaxLookEvent *LookaheadEventInfo
+
+	// LLTotalLook, LLMinLook, LLMaxLook and LLMaxLookEvent are the same for the predictions that fell back
+	// to full LL prediction
+	LLTotalLook    int64
+	LLMinLook      int64
+	LLMaxLook      int64
+	LLMaxLookEvent *LookaheadEventInfo
+
+	// SLLATNTransitions is the number of SLL lookahead steps that had to simulate the ATN because the DFA
+	// cache did not cover them, and SLLDFATransitions the number taken from the DFA cache
+	SLLATNTransitions int64
+	SLLDFATransitions int64
+
+	// LLFallback is the number of predictions that fell back to full LL prediction, and LLATNTransitions the
+	// number of lookahead steps those took. Full LL prediction is never cached in the DFA.
+	LLFallback       int64
+	LLATNTransitions int64
+
+	ContextSensitivities []ContextSensitivityInfo
+	Ambiguities          []AmbiguityInfo
+	Errors               []ErrorInfo
+}
+
+func (d DecisionInfo) String() string {
+	return fmt.Sprintf("{decision=%d, contextSensitivities=%d, errors=%d, ambiguities=%d, SLL_lookahead=%d, "+
+		"SLL_ATNTransitions=%d, SLL_DFATransitions=%d, LL_Fallback=%d, LL_lookahead=%d, LL_ATNTransitions=%d}",
+		d.Decision, len(d.ContextSensitivities), len(d.Errors), len(d.Ambiguities), d.SLLTotalLook,
+		d.SLLATNTransitions, d.SLLDFATransitions, d.LLFallback, d.LLTotalLook, d.LLATNTransitions)
+}
+
+// predictionProfile is the state of the profiler of a [ParserATNSimulator].
+type predictionProfile struct {
+	decisions []DecisionInfo
+
+	// current is the decision being predicted, and sllStopIndex and llStopIndex the index of the last
+	// token examined by its SLL and full LL prediction, or -1
+	current      int
+	sllStopIndex int
+	llStopIndex  int
+}
+
+// ParseInfo gives access to the statistics gathered by the profiler of a parser, see
+// [BaseParser.SetProfile]. It reads them as they are when its methods are called, so a ParseInfo kept
+// across parses sees the statistics grow.
+//
+// Use:
+//
+//	p.SetProfile(true)
+//	p.Query()
+//	info := p.GetParseInfo()
+//	decisions := info.GetDecisionInfo()
+//	slices.SortFunc(decisions, func(a, b antlr.DecisionInfo) int {
+//	    return cmp.Compare(b.TimeInPrediction, a.TimeInPrediction)
+//	})
+//	for _, d := range decisions[:10] {
+//	    fmt.Println(p.GetRuleNames()[p.GetATN().DecisionToState[d.Decision].GetRuleIndex()], d)
+//	}
+type ParseInfo struct {
+	sim *ParserATNSimulator
+}
+
+// GetDecisionInfo returns the statistics of each decision of the grammar, indexed by decision number.
+func (i *ParseInfo) GetDecisionInfo() []DecisionInfo {
+	if i.sim.profile == nil {
+		return nil
+	}
+	return slices.Clone(i.sim.profile.decisions)
+}
+
+// GetLLDecisions returns the numbers of the decisions that fell back to full LL prediction at least once.
+func (i *ParseInfo) GetLLDecisions() []int {
+	var lls []int
+	for _, d := range i.decisions() {
+		if d.LLFallback > 0 {
+			lls = append(lls, d.Decision)
+		}
+	}
+	return lls
+}
+
+// GetTotalTimeInPrediction returns the total time spent in adaptive prediction, over all decisions.
+func (i *ParseInfo) GetTotalTimeInPrediction() time.Duration {
+	var t time.Duration
+	for _, d := range i.decisions() {
+		t += d.TimeInPrediction
+	}
+	return t
+}
+
+// GetTotalSLLLookaheadOps returns the total lookahead examined by SLL prediction, over all decisions.
+func (i *ParseInfo) GetTotalSLLLookaheadOps() int64 {
+	var k int64
+	for _, d := range i.decisions() {
+		k += d.SLLTotalLook
+	}
+	return k
+}
+
+// GetTotalLLLookaheadOps returns the total lookahead examined by full LL prediction, over all decisions.
+func (i *ParseInfo) GetTotalLLLookaheadOps() int64 {
+	var k int64
+	for _, d := range i.decisions() {
+		k += d.LLTotalLook
+	}
+	return k
+}
+
+// GetTotalSLLATNLookaheadOps returns the number of SLL lookahead steps that had to simulate the ATN, over
+// all decisions.
+func (i *ParseInfo) GetTotalSLLATNLookaheadOps() int64 {
+	var k int64
+	for _, d := range i.decisions() {
+		k += d.SLLATNTransitions
+	}
+	return k
+}
+
+// GetTotalLLATNLookaheadOps returns the number of full LL lookahead steps, over all decisions.
+func (i *ParseInfo) GetTotalLLATNLookaheadOps() int64 {
+	var k int64
+	for _, d := range i.decisions() {
+		k += d.LLATNTransitions
+	}
+	return k
+}
+
+// GetTotalATNLookaheadOps returns the number of lookahead steps, SLL and full LL, that had to simulate the
+// ATN, over all decisions.
+func (i *ParseInfo) GetTotalATNLookaheadOps() int64 {
+	return i.GetTotalSLLATNLookaheadOps() + i.GetTotalLLATNLookaheadOps()
+}
+
+// GetDFASize returns the number of states in the DFA cache of all decisions.
+func (i *ParseInfo) GetDFASize() int {
+	n := 0
+	for decision := range i.sim.decisionToDFA {
+		n += i.GetDecisionDFASize(decision)
+	}
+	return n
+}
+
+// GetDecisionDFASize returns the number of states in the DFA cache of the given decision.
+func (i *ParseInfo) GetDecisionDFASize(decision int) int {
+	dfa := i.sim.decisionToDFA[decision]
+	if !dfa.frozen {
+		i.sim.atn.stateMu.RLock()
+		defer i.sim.atn.stateMu.RUnlock()
+	}
+	return dfa.Len()
+}
+
+// decisions returns the statistics of each decision, without copying them.
+func (i *ParseInfo) decisions() []DecisionInfo {
+	if i.sim.profile == nil {
+		return nil
+	}
+	return i.sim.profile.decisions
+}
+
+// SetProfile turns the profiler of the simulator on or off. Turning it on when it is off starts the
+// statistics afresh; turning it off discards them. See [BaseParser.SetProfile].
+func (p *ParserATNSimulator) SetProfile(profile bool) {
+	if !profile {
+		p.profile = nil
+		return
+	}
+	if p.profile != nil {
+		return
+	}
+	decisions := make([]DecisionInfo, len(p.atn.DecisionToState))
+	for i := range decisions {
+		decisions[i].Decision = i
+	}
+	p.profile = &predictionProfile{decisions: decisions}
+}
+
+// GetParseInfo returns the statistics gathered by the profiler of the simulator, or nil if it is off.
+func (p *ParserATNSimulator) GetParseInfo() *ParseInfo {
+	if p.profile == nil {
+		return nil
+	}
+	return &ParseInfo{sim: p}
+}
+
+// SetProfile turns the profiler on or off. While it is on, adaptive prediction gathers statistics for each
+// decision of the grammar, such as the time spent predicting it and the lookahead examined, with SLL and with
+// full LL prediction, and the ambiguities and context sensitivities found, which [BaseParser.GetParseInfo]
+// returns. They are kept across parses until the profiler is turned off. It is meant for finding the
+// decisions that dominate the time spent parsing, and so the rules of the grammar worth rewriting, as the
+// profiler slows prediction down.
+//
+// Predictions made a second time to compare them, as with [ParserATNSimulator.SetDifferentialPrediction],
+// are not profiled.
+func (p *BaseParser) SetProfile(profile bool) {
+	p.Interpreter.SetProfile(profile)
+}
+
+// GetParseInfo returns the statistics gathered by the profiler, or nil if it is off, see
+// [BaseParser.SetProfile].
+func (p *BaseParser) GetParseInfo() *ParseInfo {
+	return p.Interpreter.GetParseInfo()
+}
+
+// profiling returns true if the profiler is on and the current prediction is to be profiled.
+func (p *ParserATNSimulator) profiling() bool {
+	return p.profile != nil && !p.shadow
+}
+
+// profileDecision returns the statistics of the decision being predicted.
+func (p *ParserATNSimulator) profileDecision() *DecisionInfo {
+	return &p.profile.decisions[p.profile.current]
+}
+
+// profileEvent returns the description of an event of the decision being predicted, for the lookahead from
+// the start of the prediction to stopIndex.
+func (p *ParserATNSimulator) profileEvent(configs *ATNConfigSet, stopIndex int, fullCtx bool) DecisionEventInfo {
+	return DecisionEventInfo{
+		Decision:   p.profile.current,
+		StartIndex: p.startIndex,
+		StopIndex:  stopIndex,
+		Configs:    configs,
+		FullCtx:    fullCtx,
+	}
+}
+
+// profileBegin starts profiling a prediction of decision, returning the function that ends it.
+func (p *ParserATNSimulator) profileBegin(decision int) func(startIndex int, predicted int) {
+	p.profile.current = decision
+	p.profile.sllStopIndex = -1
+	p.profile.llStopIndex = -1
+	start := time.Now()
+
+	return func(startIndex int, predicted int) {
+		d := p.profileDecision()
+		d.Invocations++
+		d.TimeInPrediction += time.Since(start)
+
+		if p.profile.sllStopIndex >= 0 {
+			k := int64(p.profile.sllStopIndex - startIndex + 1)
+			d.SLLTotalLook += k
+			if d.SLLMinLook == 0 || k < d.SLLMinLook {
+				d.SLLMinLook = k
+			}
+			if k > d.SLLMaxLook {
+				d.SLLMaxLook = k
+				d.SLLMaxLookEvent = &LookaheadEventInfo{
+					DecisionEventInfo: DecisionEventInfo{Decision: decision, StartIndex: startIndex, StopIndex: p.profile.sllStopIndex},
+					PredictedAlt:      predicted,
+				}
+			}
+		}
+		if p.profile.llStopIndex >= 0 {
+			k := int64(p.profile.llStopIndex - startIndex + 1)
+			d.LLTotalLook += k
+			if d.LLMinLook == 0 || k < d.LLMinLook {
+				d.LLMinLook = k
+			}
+			if k > d.LLMaxLook {
+				d.LLMaxLook = k
+				d.LLMaxLookEvent = &LookaheadEventInfo{
+					DecisionEventInfo: DecisionEventInfo{Decision: decision, StartIndex: startIndex, StopIndex: p.profile.llStopIndex, FullCtx: true},
+					PredictedAlt:      predicted,
+				}
+			}
+		}
+	}
+}
+
+// profileExistingTarget records an SLL lookahead step taken from the DFA cache, if target is not nil.
+func (p *ParserATNSimulator) profileExistingTarget(previous *ATNConfigSet, target *DFAState) {
+	if !p.profiling() {
+		return
+	}
+	p.profile.sllStopIndex = p.input.Index()
+	if target == nil {
+		return
+	}
+	d := p.profileDecision()
+	d.SLLDFATransitions++
+	if target == ATNSimulatorError {
+		d.Errors = append(d.Errors, ErrorInfo{p.profileEvent(previous, p.profile.sllStopIndex, false)})
+	}
+}
+
+// profileReach records a lookahead step that simulated the ATN from closure, reaching reach.
+func (p *ParserATNSimulator) profileReach(closure, reach *ATNConfigSet, fullCtx bool) {
+	if !p.profiling() {
+		return
+	}
+	stopIndex := p.input.Index()
+	d := p.profileDecision()
+	if fullCtx {
+		p.profile.llStopIndex = stopIndex
+		d.LLATNTransitions++
+	} else {
+		p.profile.sllStopIndex = stopIndex
+		d.SLLATNTransitions++
+	}
+	if reach == nil {
+		d.Errors = append(d.Errors, ErrorInfo{p.profileEvent(closure, stopIndex, fullCtx)})
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestProfile(t *testing.T) {
+	atn := NewATNDeserializer(nil).Deserialize(listParserSerialized)
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a b + c d")), TokenDefaultChannel))
+	p.Interpreter = NewParserATNSimulator(p, atn, newDFA(atn), NewPredictionContextCache())
+	if p.GetParseInfo() != nil {
+		t.Fatal("the profiler is on")
+	}
+	p.SetProfile(true)
+	p.S()
+	info := p.GetParseInfo()
+
+	// item is predicted for a, b + c and d, with two tokens of lookahead each; the first prediction simulates
+	// the ATN for both tokens, and the others find their first token in the DFA
+	tests := []struct {
+		input                                 string
+		invocations, look, atnSteps, dfaSteps int64
+	}{
+		{"a b + c d", 3, 6, 4, 2},
+		// the statistics are kept across parses, and the DFA now covers the input
+		{"a b + c d", 6, 12, 4, 8},
+		{"e", 7, 14, 4, 10},
+	}
+	for i, test := range tests {
+		if i > 0 {
+			p.ResetWithInputStream(NewCommonTokenStream(newListLexer(NewInputStream(test.input)), TokenDefaultChannel))
+			p.S()
+		}
+		d := info.GetDecisionInfo()[listRuleItem]
+		if d.Invocations != test.invocations || d.SLLTotalLook != test.look || d.SLLATNTransitions != test.atnSteps ||
+			d.SLLDFATransitions != test.dfaSteps {
+			t.Errorf("parse %d: %s with %d invocations", i, d, d.Invocations)
+		}
+		if d.SLLMinLook != 2 || d.SLLMaxLook != 2 || d.LLFallback != 0 || d.TimeInPrediction <= 0 {
+			t.Errorf("parse %d: lookahead %d to %d, %d full LL predictions, in %s", i, d.SLLMinLook, d.SLLMaxLook,
+				d.LLFallback, d.TimeInPrediction)
+		}
+		if e := d.SLLMaxLookEvent; e == nil || e.StartIndex != 0 || e.StopIndex != 1 || e.PredictedAlt != 1 {
+			t.Errorf("parse %d: the longest lookahead was %+v", i, e)
+		}
+	}
+	if loop := info.GetDecisionInfo()[0]; loop.Invocations != 0 {
+		t.Errorf("the loop of s, decided with one token, was predicted %d times", loop.Invocations)
+	}
+	if info.GetTotalSLLLookaheadOps() != 14 || info.GetTotalATNLookaheadOps() != 4 || info.GetDFASize() != 5 ||
+		len(info.GetLLDecisions()) != 0 || info.GetTotalTimeInPrediction() <= 0 {
+		t.Errorf("totals %d, %d, %d states, full LL decisions %v", info.GetTotalSLLLookaheadOps(),
+			info.GetTotalATNLookaheadOps(), info.GetDFASize(), info.GetLLDecisions())
+	}
+
+	p.SetProfile(false)
+	if p.GetParseInfo() != nil || info.GetDecisionInfo() != nil {
+		t.Error("turning the profiler off kept its statistics")
+	}
+}
+
+func TestProfileFullContext(t *testing.T) {
+	// SLL prediction of r finds both alternatives viable, and full LL resolves them
+	p, ctx := sllParser(PredictionModeLL, false)
+	p.SetProfile(true)
+	if alt := p.Interpreter.AdaptivePredict(p, p.GetTokenStream(), 1, ctx); alt != 2 {
+		t.Fatalf("predicted %d, want 2", alt)
+	}
+	info := p.GetParseInfo()
+	d := info.GetDecisionInfo()[1]
+	if d.LLFallback != 1 || d.LLTotalLook != 3 || d.LLATNTransitions != 3 || info.GetTotalLLLookaheadOps() != 3 {
+		t.Errorf("%s with %d full LL lookahead", d, info.GetTotalLLLookaheadOps())
+	}
+	if len(d.ContextSensitivities) != 1 || len(d.Ambiguities) != 0 || len(info.GetLLDecisions()) != 1 {
+		t.Errorf("%s in full LL decisions %v", d, info.GetLLDecisions())
+	}
+	if e := d.ContextSensitivities[0]; e.Decision != 1 || e.StartIndex != 1 || e.StopIndex != 3 || !e.FullCtx {
+		t.Errorf("context sensitivity %+v", e.DecisionEventInfo)
+	}
+	if e := d.LLMaxLookEvent; e == nil || e.PredictedAlt != 2 {
+		t.Errorf("the longest full LL lookahead was %+v", e)
+	}
+
+	// neither alternative of r is viable at b
+	p.GetTokenStream().Seek(2)
+	p.Interpreter.AdaptivePredict(p, p.GetTokenStream(), 1, ctx)
+	if d := p.GetParseInfo().GetDecisionInfo()[1]; len(d.Errors) != 1 || d.Errors[0].StartIndex != 2 {
+		t.Errorf("%s with errors %+v", d, d.Errors)
+	}
+}
+
+func TestProfileAmbiguity(t *testing.T) {
+	// r : ID | ID ; matches the input a with both alternatives, however much context is known
+	atn := buildATN(listWS, [][][]atnElement{{bAlt(bTok(listID)), bAlt(bTok(listID))}})
+	stream := NewCommonTokenStream(newListLexer(NewInputStream("a")), TokenDefaultChannel)
+	p := NewBaseParser(stream)
+	p.Interpreter = NewParserATNSimulator(p, atn, newDFA(atn), NewPredictionContextCache())
+	p.RemoveErrorListeners()
+	p.SetProfile(true)
+	stream.Seek(0)
+	if alt := p.Interpreter.AdaptivePredict(p, stream, 0, ParserRuleContextEmpty); alt != 1 {
+		t.Fatalf("predicted %d, want 1", alt)
+	}
+	d := p.GetParseInfo().GetDecisionInfo()[0]
+	if len(d.Ambiguities) != 1 || d.LLFallback != 1 {
+		t.Fatalf("%s", d)
+	}
+	if a := d.Ambiguities[0]; a.AmbigAlts.String() != "{1, 2}" || a.StartIndex != 0 || !a.FullCtx {
+		t.Errorf("ambiguity of %s at %+v", a.AmbigAlts, a.DecisionEventInfo)
+	}
+}
@@ -6,7 +6,6 @@ package antlr
 
 import (
 	"fmt"
-	"strings"
 
 	"strconv"
 )
@@ -40,6 +39,23 @@ type BaseRecognizer struct {
 	SymbolicNames   []string
 	GrammarFileName string
 	SynErr          RecognitionException
+
+	predicateSources map[predicateKey]string
+	altLabels        map[altKey]string
+	channelNames     map[int]string
+	modeNames        map[int]string
+}
+
+// altKey identifies an alternative of a decision within a grammar
+type altKey struct {
+	decision int
+	alt      int
+}
+
+// predicateKey identifies a semantic predicate within a grammar
+type predicateKey struct {
+	ruleIndex int
+	predIndex int
 }
 
 func NewBaseRecognizer() *BaseRecognizer {
@@ -55,10 +71,139 @@ var tokenTypeMapCache = make(map[string]int)
 //goland:noinspection GoUnusedGlobalVariable
 var ruleIndexMapCache = make(map[string]int)
 
+// SetPredicateSource registers the source text of the semantic predicate identified by ruleIndex and predIndex,
+// which are the same indexes passed to Sempred. When the predicate fails, the [FailedPredicateException] message
+// then shows the actual predicate expression. Generated code, or users, call this once the recognizer is created.
+//
+// Use:
+//
+//	p.SetPredicateSource(3, 0, "p.isType(p.GetTokenStream().LT(1))")
+func (b *BaseRecognizer) SetPredicateSource(ruleIndex, predIndex int, source string) {
+	if b.predicateSources == nil {
+		b.predicateSources = make(map[predicateKey]string)
+	}
+	b.predicateSources[predicateKey{ruleIndex, predIndex}] = source
+}
+
+// GetPredicateSource returns the source text registered with SetPredicateSource for the semantic predicate
+// identified by ruleIndex and predIndex, and whether there is one.
+func (b *BaseRecognizer) GetPredicateSource(ruleIndex, predIndex int) (string, bool) {
+	source, ok := b.predicateSources[predicateKey{ruleIndex, predIndex}]
+	return source, ok
+}
+
+// SetAltLabel registers the label given in the grammar, as in `# Add`, to the alternative alt of the given
+// decision. The outer alternatives of a rule belong to the decision of the rule's block, or to decision -1 if
+// the rule has only one alternative. Generated code, or users, call this once the recognizer is created, so
+// that tools can report which labeled alternative matched, see [BaseParser.GetContextAltLabel].
+//
+// Use:
+//
+//	p.SetAltLabel(4, 2, "Add")
+func (b *BaseRecognizer) SetAltLabel(decision, alt int, label string) {
+	if b.altLabels == nil {
+		b.altLabels = make(map[altKey]string)
+	}
+	b.altLabels[altKey{decision, alt}] = label
+}
+
+// GetAltLabel returns the label registered with SetAltLabel for the alternative alt of the given decision,
+// and whether there is one.
+func (b *BaseRecognizer) GetAltLabel(decision, alt int) (string, bool) {
+	label, ok := b.altLabels[altKey{decision, alt}]
+	return label, ok
+}
+
+// RegisterChannel registers the name of a token channel, as declared in the grammar's channels section, so that
+// diagnostics and token dumps can show the name rather than the number. Generated code, or users, call this
+// once the recognizer is created, on the parser as well as the lexer if the parser's diagnostics should
+// show channel names. [TokenDefaultChannel] and [TokenHiddenChannel] need not be registered.
+//
+// Use:
+//
+//	l.RegisterChannel("COMMENTS", 2)
+func (b *BaseRecognizer) RegisterChannel(name string, channel int) {
+	if b.channelNames == nil {
+		b.channelNames = make(map[int]string)
+	}
+	b.channelNames[channel] = name
+}
+
+// RegisterMode registers the name of a lexer mode, as declared in the grammar, so that diagnostics and token
+// dumps can show the name rather than the number. [LexerDefaultMode] need not be registered.
+//
+// Use:
+//
+//	l.RegisterMode("STRING_MODE", 1)
+func (b *BaseRecognizer) RegisterMode(name string, mode int) {
+	if b.modeNames == nil {
+		b.modeNames = make(map[int]string)
+	}
+	b.modeNames[mode] = name
+}
+
+// ChannelName returns the name registered for the given channel with RegisterChannel, or the name used in
+// grammars for the predefined channels, DEFAULT_TOKEN_CHANNEL and HIDDEN. Any other channel is returned as
+// its number.
+func (b *BaseRecognizer) ChannelName(channel int) string {
+	if name, ok := b.channelNames[channel]; ok {
+		return name
+	}
+	switch channel {
+	case TokenDefaultChannel:
+		return "DEFAULT_TOKEN_CHANNEL"
+	case TokenHiddenChannel:
+		return "HIDDEN"
+	}
+	return strconv.Itoa(channel)
+}
+
+// ModeName returns the name registered for the given lexer mode with RegisterMode, or DEFAULT_MODE for
+// [LexerDefaultMode]. Any other mode is returned as its number.
+func (b *BaseRecognizer) ModeName(mode int) string {
+	if name, ok := b.modeNames[mode]; ok {
+		return name
+	}
+	if mode == LexerDefaultMode {
+		return "DEFAULT_MODE"
+	}
+	return strconv.Itoa(mode)
+}
+
+// ChannelValue returns the channel registered under the given name, and whether there is one. The names
+// of the predefined channels are also recognized.
+func (b *BaseRecognizer) ChannelValue(name string) (int, bool) {
+	for channel, n := range b.channelNames {
+		if n == name {
+			return channel, true
+		}
+	}
+	switch name {
+	case "DEFAULT_TOKEN_CHANNEL":
+		return TokenDefaultChannel, true
+	case "HIDDEN":
+		return TokenHiddenChannel, true
+	}
+	return 0, false
+}
+
+// ModeValue returns the lexer mode registered under the given name, and whether there is one. DEFAULT_MODE
+// is also recognized.
+func (b *BaseRecognizer) ModeValue(name string) (int, bool) {
+	for mode, n := range b.modeNames {
+		if n == name {
+			return mode, true
+		}
+	}
+	if name == "DEFAULT_MODE" {
+		return LexerDefaultMode, true
+	}
+	return 0, false
+}
+
 func (b *BaseRecognizer) checkVersion(toolVersion string) {
-	runtimeVersion := "4.13.1"
-	if runtimeVersion != toolVersion {
-		fmt.Println("ANTLR runtime and generated code versions disagree: " + runtimeVersion + "!=" + toolVersion)
+	if err := CheckVersion(toolVersion); err != nil {
+		fmt.Println(err)
 	}
 }
 
@@ -102,6 +247,11 @@ func (b *BaseRecognizer) GetLiteralNames() []string {
 	return b.LiteralNames
 }
 
+// GetGrammarFileName returns the name of the grammar file the recognizer was generated or interpreted from.
+func (b *BaseRecognizer) GetGrammarFileName() string {
+	return b.GrammarFileName
+}
+
 func (b *BaseRecognizer) GetState() int {
 	return b.state
 }
@@ -110,79 +260,54 @@ func (b *BaseRecognizer) SetState(v int) {
 	b.state = v
 }
 
-//func (b *Recognizer) GetTokenTypeMap() {
-//    var tokenNames = b.GetTokenNames()
-//    if (tokenNames==nil) {
-//        panic("The current recognizer does not provide a list of token names.")
-//    }
-//    var result = tokenTypeMapCache[tokenNames]
-//    if(result==nil) {
-//        result = tokenNames.reduce(function(o, k, i) { o[k] = i })
-//        result.EOF = TokenEOF
-//        tokenTypeMapCache[tokenNames] = result
-//    }
-//    return result
-//}
-
-// GetRuleIndexMap Get a map from rule names to rule indexes.
+// GetTokenTypeMap returns a map from the names of the token types of the recognizer, both literal and
+// symbolic, to the token types. EOF maps to [TokenEOF].
 //
 // Used for XPath and tree pattern compilation.
+func (b *BaseRecognizer) GetTokenTypeMap() map[string]int {
+	return tokenTypeMap(b.GetLiteralNames(), b.GetSymbolicNames())
+}
+
+// GetRuleIndexMap returns a map from rule names to rule indexes.
 //
-// TODO: JI This is not yet implemented in the Go runtime. Maybe not needed.
+// Used for XPath and tree pattern compilation.
 func (b *BaseRecognizer) GetRuleIndexMap() map[string]int {
+	return ruleIndexMap(b.GetRuleNames())
+}
 
-	panic("Method not defined!")
-	//    var ruleNames = b.GetRuleNames()
-	//    if (ruleNames==nil) {
-	//        panic("The current recognizer does not provide a list of rule names.")
-	//    }
-	//
-	//    var result = ruleIndexMapCache[ruleNames]
-	//    if(result==nil) {
-	//        result = ruleNames.reduce(function(o, k, i) { o[k] = i })
-	//        ruleIndexMapCache[ruleNames] = result
-	//    }
-	//    return result
-}
-
-// GetTokenType get the token type based upon its name
-func (b *BaseRecognizer) GetTokenType(_ string) int {
-	panic("Method not defined!")
-	//    var ttype = b.GetTokenTypeMap()[tokenName]
-	//    if (ttype !=nil) {
-	//        return ttype
-	//    } else {
-	//        return TokenInvalidType
-	//    }
-}
-
-//func (b *Recognizer) GetTokenTypeMap() map[string]int {
-//    Vocabulary vocabulary = getVocabulary()
-//
-//    Synchronized (tokenTypeMapCache) {
-//        Map<String, Integer> result = tokenTypeMapCache.Get(vocabulary)
-//        if (result == null) {
-//            result = new HashMap<String, Integer>()
-//            for (int i = 0; i < GetATN().maxTokenType; i++) {
-//                String literalName = vocabulary.getLiteralName(i)
-//                if (literalName != null) {
-//                    result.put(literalName, i)
-//                }
-//
-//                String symbolicName = vocabulary.GetSymbolicName(i)
-//                if (symbolicName != null) {
-//                    result.put(symbolicName, i)
-//                }
-//            }
-//
-//            result.put("EOF", Token.EOF)
-//            result = Collections.unmodifiableMap(result)
-//            tokenTypeMapCache.put(vocabulary, result)
-//        }
-//
-//        return result
-//    }
-//}
+// GetTokenType returns the token type with the given literal or symbolic name, or [TokenInvalidType] if there
+// is none.
+func (b *BaseRecognizer) GetTokenType(tokenName string) int {
+	if ttype, ok := tokenTypeMap(b.GetLiteralNames(), b.GetSymbolicNames())[tokenName]; ok {
+		return ttype
+	}
+	return TokenInvalidType
+}
+
+// tokenTypeMap returns a map from the given literal and symbolic names of token types to the token types.
+func tokenTypeMap(literalNames, symbolicNames []string) map[string]int {
+	names := map[string]int{"EOF": TokenEOF}
+	for ttype, name := range literalNames {
+		if name != "" {
+			names[name] = ttype
+		}
+	}
+	for ttype, name := range symbolicNames {
+		if name != "" {
+			names[name] = ttype
+		}
+	}
+	return names
+}
+
+// ruleIndexMap returns a map from the given rule names to the rule indexes.
+func ruleIndexMap(ruleNames []string) map[string]int {
+	indexes := make(map[string]int)
+	for i, name := range ruleNames {
+		indexes[name] = i
+	}
+	return indexes
+}
 
 // GetErrorHeader returns the error header, normally line/character position information.
 //
@@ -217,11 +342,7 @@ func (b *BaseRecognizer) GetTokenErrorDisplay(t Token) string {
 			s = "<" + strconv.Itoa(t.GetTokenType()) + ">"
 		}
 	}
-	s = strings.Replace(s, "\t", "\\t", -1)
-	s = strings.Replace(s, "\n", "\\n", -1)
-	s = strings.Replace(s, "\r", "\\r", -1)
-
-	return "'" + s + "'"
+	return EscapeText(s, EscapeErrorDisplay)
 }
 
 func (b *BaseRecognizer) GetErrorListenerDispatch() ErrorListener {
@@ -0,0 +1,44 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestChannelAndModeNames(t *testing.T) {
+	lexer := newListLexer(nil)
+	lexer.RegisterChannel("COMMENTS", 2)
+	lexer.RegisterMode("STRING_MODE", 1)
+
+	for channel, want := range map[int]string{
+		TokenDefaultChannel: "DEFAULT_TOKEN_CHANNEL",
+		TokenHiddenChannel:  "HIDDEN",
+		2:                   "COMMENTS",
+		3:                   "3",
+	} {
+		if got := lexer.ChannelName(channel); got != want {
+			t.Errorf("ChannelName(%d) = %q, want %q", channel, got, want)
+		}
+		if got, ok := lexer.ChannelValue(want); ok != (channel != 3) || (ok && got != channel) {
+			t.Errorf("ChannelValue(%q) = %d, %v", want, got, ok)
+		}
+	}
+	for mode, want := range map[int]string{LexerDefaultMode: "DEFAULT_MODE", 1: "STRING_MODE", 2: "2"} {
+		if got := lexer.ModeName(mode); got != want {
+			t.Errorf("ModeName(%d) = %q, want %q", mode, got, want)
+		}
+		if got, ok := lexer.ModeValue(want); ok != (mode != 2) || (ok && got != mode) {
+			t.Errorf("ModeValue(%q) = %d, %v", want, got, ok)
+		}
+	}
+
+	// A registered name replaces that of a predefined channel, and the names are kept by each recognizer
+	lexer.RegisterChannel("WHITESPACE", TokenHiddenChannel)
+	if got := lexer.ChannelName(TokenHiddenChannel); got != "WHITESPACE" {
+		t.Errorf("ChannelName(HIDDEN) = %q after registering WHITESPACE", got)
+	}
+	if got := newListParser(nil).ChannelName(2); got != "2" {
+		t.Errorf("the parser has the channel name %q of the lexer", got)
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"slices"
+)
+
+// RecoveryKind is the kind of a [RecoveryDecision].
+type RecoveryKind int
+
+const (
+	// RecoveryDeletion is single-token deletion: the token at TokenIndex was skipped as extraneous.
+	RecoveryDeletion RecoveryKind = iota
+
+	// RecoveryInsertion is single-token insertion: a missing token was conjured up before TokenIndex.
+	RecoveryInsertion
+
+	// RecoveryResync is panic-mode recovery: tokens were consumed from TokenIndex up to Stop.
+	RecoveryResync
+
+	// RecoveryFailed is inline recovery that failed, leaving a mismatched input error for the rule to
+	// recover from.
+	RecoveryFailed
+)
+
+func (k RecoveryKind) String() string {
+	switch k {
+	case RecoveryDeletion:
+		return "deletion"
+	case RecoveryInsertion:
+		return "insertion"
+	case RecoveryResync:
+		return "resync"
+	case RecoveryFailed:
+		return "failed"
+	}
+	return fmt.Sprintf("RecoveryKind(%d)", int(k))
+}
+
+// RecoveryDecision is one recovery made by the [DefaultErrorStrategy], as recorded in a [RecoveryLog].
+type RecoveryDecision struct {
+	Kind RecoveryKind
+
+	// State is the ATN state of the parser, and TokenIndex the index of the current token, when the
+	// recovery began
+	State      int
+	TokenIndex int
+
+	// Stop is the index of the current token when the recovery ended
+	Stop int
+}
+
+func (r RecoveryDecision) String() string {
+	return fmt.Sprintf("%s@%d:%d..%d", r.Kind, r.State, r.TokenIndex, r.Stop)
+}
+
+// RecoveryLog records the recoveries made by a [DefaultErrorStrategy] during a parse, and replays them when
+// the same input is parsed again, so that the second parse recovers exactly as the first did, and builds an
+// identical tree, even if it is made with different settings. This suits a fast first pass, such as one in
+// SLL mode without building a tree, followed by a second pass over the same input for a secondary analysis,
+// which must see the same tokens skipped and the same tokens conjured up as the first.
+//
+// While replaying, each recovery the strategy is about to make is matched against the next one recorded,
+// by the state of the parser and the index of the current token. If they match, the strategy skips or
+// conjures up the same tokens as before, rather than working out the recovery anew, and reports the same
+// errors. If they do not, as when the input is not the same, the log has diverged, and the strategy goes on
+// recovering as usual for the rest of the parse.
+//
+// Use:
+//
+//	log := antlr.NewRecoveryLog()
+//	strategy := antlr.NewDefaultErrorStrategy()
+//	strategy.SetRecoveryLog(log)
+//	p.SetErrorHandler(strategy)
+//	p.BuildParseTrees = false
+//	p.Start()
+//
+//	log.Replay()
+//	p.BuildParseTrees = true
+//	p.SetInputStream(antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel))
+//	tree := p.Start()
+type RecoveryLog struct {
+	decisions []RecoveryDecision
+	replaying bool
+	next      int
+	diverged  bool
+}
+
+// NewRecoveryLog creates an empty [RecoveryLog] that records recoveries.
+func NewRecoveryLog() *RecoveryLog {
+	return &RecoveryLog{}
+}
+
+// Decisions returns the recoveries recorded, in the order they were made.
+func (l *RecoveryLog) Decisions() []RecoveryDecision {
+	return slices.Clone(l.decisions)
+}
+
+// Len returns the number of recoveries recorded.
+func (l *RecoveryLog) Len() int {
+	return len(l.decisions)
+}
+
+// Replay rewinds the log to its first recovery and switches it to replaying the recoveries it has recorded.
+func (l *RecoveryLog) Replay() {
+	l.replaying = true
+	l.next = 0
+	l.diverged = false
+}
+
+// Reset discards the recoveries recorded and switches the log back to recording.
+func (l *RecoveryLog) Reset() {
+	l.decisions = nil
+	l.replaying = false
+	l.next = 0
+	l.diverged = false
+}
+
+// Replaying returns true if the log is replaying the recoveries it recorded rather than recording them.
+func (l *RecoveryLog) Replaying() bool {
+	return l.replaying
+}
+
+// Diverged returns true if, while replaying, a recovery did not match the one recorded, or more recoveries
+// were needed than were recorded.
+func (l *RecoveryLog) Diverged() bool {
+	return l.diverged
+}
+
+// Remaining returns the number of recorded recoveries not yet replayed, which is 0 after a replay that
+// matched the recorded parse in full.
+func (l *RecoveryLog) Remaining() int {
+	if !l.replaying || l.diverged {
+		return 0
+	}
+	return len(l.decisions) - l.next
+}
+
+// record appends a recovery to the log, if it is recording.
+func (l *RecoveryLog) record(r RecoveryDecision) {
+	if !l.replaying {
+		l.decisions = append(l.decisions, r)
+	}
+}
+
+// take returns the next recovery to replay, if the log is replaying and it matches the given state and
+// token index and is one of kinds. Otherwise, while replaying, the log diverges.
+func (l *RecoveryLog) take(state, tokenIndex int, kinds ...RecoveryKind) (RecoveryDecision, bool) {
+	if !l.replaying || l.diverged {
+		return RecoveryDecision{}, false
+	}
+	if l.next < len(l.decisions) {
+		r := l.decisions[l.next]
+		if r.State == state && r.TokenIndex == tokenIndex && slices.Contains(kinds, r.Kind) {
+			l.next++
+			return r, true
+		}
+	}
+	l.diverged = true
+	return RecoveryDecision{}, false
+}
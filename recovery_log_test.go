@@ -0,0 +1,89 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"testing"
+)
+
+// parseWithRecoveryLog parses input with a DefaultErrorStrategy that uses log, and returns the tree, if it
+// is built, and the errors reported.
+func parseWithRecoveryLog(input string, log *RecoveryLog, buildParseTrees bool) (string, []string) {
+	strategy := NewDefaultErrorStrategy()
+	strategy.SetRecoveryLog(log)
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+	errs := &messageRecorder{}
+	p.RemoveErrorListeners()
+	p.AddErrorListener(errs)
+	p.SetErrorHandler(strategy)
+	p.BuildParseTrees = buildParseTrees
+	tree := p.S()
+	if !buildParseTrees {
+		return "", errs.messages
+	}
+	return tree.ToStringTree(nil, p), errs.messages
+}
+
+func TestRecoveryLog(t *testing.T) {
+	tests := []struct {
+		input     string
+		decisions string
+		tree      string
+	}{
+		{"a + + b", "[deletion@16:2..3]", "(s (item a + + b) <EOF>)"},
+		{"a +", "[insertion@16:2..2]", "(s (item a + <missing >) <EOF>)"},
+		{"+ a", "[deletion@2:0..1]", "(s + (item a) <EOF>)"},
+		{"a b + + + c", "[failed@16:3..3 resync@16:3..5]", "(s (item a) (item b + + +) (item c) <EOF>)"},
+		{"a b", "[]", "(s (item a) (item b) <EOF>)"},
+	}
+	for _, test := range tests {
+		// a first pass without a tree records the recoveries, which a second pass with a tree replays
+		log := NewRecoveryLog()
+		_, recorded := parseWithRecoveryLog(test.input, log, false)
+		if got := fmt.Sprint(log.Decisions()); got != test.decisions || log.Replaying() {
+			t.Errorf("%q: recorded %s, want %s", test.input, got, test.decisions)
+		}
+		log.Replay()
+		tree, replayed := parseWithRecoveryLog(test.input, log, true)
+		if tree != test.tree || fmt.Sprint(replayed) != fmt.Sprint(recorded) {
+			t.Errorf("%q: replayed tree %s, errors %q, want %s, errors %q", test.input, tree, replayed, test.tree, recorded)
+		}
+		if log.Diverged() || log.Remaining() != 0 {
+			t.Errorf("%q: diverged %v with %d recoveries remaining", test.input, log.Diverged(), log.Remaining())
+		}
+	}
+}
+
+func TestRecoveryLogReplay(t *testing.T) {
+	log := NewRecoveryLog()
+	parseWithRecoveryLog("a b + + + c", log, false)
+
+	// the recovery is made as recorded, not worked out anew, so a resynchronization that is recorded to
+	// stop later skips more
+	log.decisions[1].Stop = 6
+	log.Replay()
+	if tree, _ := parseWithRecoveryLog("a b + + + c", log, true); tree != "(s (item a) (item b + + + c) <EOF>)" {
+		t.Errorf("tree %s", tree)
+	}
+
+	// on other input, the log diverges, and the parse recovers as usual
+	log.Replay()
+	if log.Remaining() != 2 {
+		t.Errorf("%d recoveries to replay", log.Remaining())
+	}
+	want, _ := parseWithRecoveryLog("a + + b", nil, true)
+	if tree, _ := parseWithRecoveryLog("a + + b", log, true); tree != want || !log.Diverged() || log.Remaining() != 0 {
+		t.Errorf("tree %s, want %s, diverged %v", tree, want, log.Diverged())
+	}
+
+	log.Reset()
+	if log.Len() != 0 || log.Replaying() || log.Diverged() {
+		t.Error("Reset kept the log")
+	}
+	if got := RecoveryKind(9).String(); got != "RecoveryKind(9)" {
+		t.Errorf("String() = %s", got)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"time"
+)
+
+// ResumableParse runs a parse in steps of limited time, so that interactive tools can show the user that a
+// parse is taking long, and carry on with it if the user grants more time, rather than abort it and start
+// again from scratch.
+//
+// A parse that runs out of time stops at the point it has reached, with the stack of rules in progress intact,
+// and [ResumableParse.Run] returns. Until the parse is resumed, the parser may be inspected, as with
+// GetCurrentToken, GetRuleInvocationStack and GetParserRuleContext, but not otherwise used. The next call to Run
+// resumes the parse exactly where it stopped. A parse that is not to be resumed must be given up with
+// [ResumableParse.Abort], which aborts it as a [ProgressFunc] does, so that it returns the partial tree; see
+// [BaseParser.GetPartialTree].
+//
+// The parse runs on a goroutine of its own, which only runs while Run is waiting for it. The time is checked
+// every thousand tokens or [ATN] configurations, with the progress callback of the parser, see
+// [BaseParser.SetProgressCallback], so a step may run a little over its time. Any progress callback that the
+// parser already has is still called, and the time the parse spends stopped is not counted in the Elapsed time
+// that it is given.
+//
+// Use:
+//
+//	parse := antlr.NewResumableParse(p, func() antlr.ParseTree { return p.Document() })
+//	tree, done := parse.Run(200 * time.Millisecond)
+//	for !done && askUser("the parse is taking long, continue?") {
+//	    tree, done = parse.Run(time.Second)
+//	}
+//	if !done {
+//	    tree = parse.Abort()
+//	}
+type ResumableParse struct {
+	parser *BaseParser
+	start  func() ParseTree
+
+	// budget is the time the current step may take, and resumed when it started
+	budget  time.Duration
+	resumed time.Time
+
+	started bool
+	done    bool
+	tree    ParseTree
+
+	// panicked is what the parse panicked with, if it did, which Run panics with in turn
+	panicked any
+
+	// stopped is signalled by the parse when it stops or ends, and resume by Run or Abort to carry on, with
+	// true, or abort, with false
+	stopped chan struct{}
+	resume  chan bool
+
+	// callback and interval are the progress callback the parser had when the parse began, which is still
+	// called, and restored when the parse ends
+	callback ProgressFunc
+	interval int
+}
+
+// NewResumableParse creates a parse of the token stream of parser p with the rule invoked by start, which
+// does not begin until [ResumableParse.Run] is called. It panics if p does not embed [BaseParser].
+func NewResumableParse(p Parser, start func() ParseTree) *ResumableParse {
+	bp := runtimeConfigParser(p)
+	return &ResumableParse{
+		parser:  bp,
+		start:   start,
+		stopped: make(chan struct{}),
+		resume:  make(chan bool),
+	}
+}
+
+// Run begins the parse, or resumes it where it stopped, and lets it run for up to budget. It returns the tree
+// that the start rule returned and true if the parse ended, or nil and false if it stopped for lack of time,
+// in which case it can be resumed by calling Run again. If the parse panics, Run panics with the same value.
+func (r *ResumableParse) Run(budget time.Duration) (ParseTree, bool) {
+	if r.done {
+		return r.tree, true
+	}
+	r.budget = budget
+	r.resumed = time.Now()
+	if !r.started {
+		r.started = true
+		r.install()
+		go r.parse()
+	} else {
+		r.resume <- true
+	}
+	return r.wait()
+}
+
+// Abort gives up a parse that has stopped, so that it returns as an aborted parse does, and returns the
+// partial tree that the start rule returned. The parser's error is set to a [ParseCancellationException] whose
+// cause wraps [ErrParseLimitExceeded]. It returns the tree of a parse that has ended, and nil if the parse never
+// began.
+func (r *ResumableParse) Abort() ParseTree {
+	if !r.started {
+		r.done = true
+	}
+	if r.done {
+		return r.tree
+	}
+	r.resume <- false
+	tree, _ := r.wait()
+	return tree
+}
+
+// Done returns true if the parse has ended, or been aborted.
+func (r *ResumableParse) Done() bool {
+	return r.done
+}
+
+// install makes the parser call check as its progress callback.
+func (r *ResumableParse) install() {
+	interval := runtimeConfigCheckInterval
+	r.callback, r.interval = nil, 0
+	if progress := r.parser.progress; progress != nil && progress.callback != nil {
+		r.callback, r.interval = progress.callback, progress.interval
+		interval = min(interval, progress.interval)
+	}
+	r.parser.SetProgressCallback(interval, r.check)
+}
+
+// parse runs the parse on its goroutine, and signals when it ends, once the parser has its own progress callback
+// back.
+func (r *ResumableParse) parse() {
+	defer func() {
+		r.panicked = recover()
+		r.done = true
+		r.parser.SetProgressCallback(r.interval, r.callback)
+		r.stopped <- struct{}{}
+	}()
+	r.tree = r.start()
+}
+
+// wait waits for the parse to stop or end.
+func (r *ResumableParse) wait() (ParseTree, bool) {
+	<-r.stopped
+	if r.panicked != nil {
+		panic(r.panicked)
+	}
+	if !r.done {
+		return nil, false
+	}
+	return r.tree, true
+}
+
+// check stops the parse once the current step has taken its time, until it is resumed or aborted, and calls
+// the callback the parser had before.
+func (r *ResumableParse) check(progress ParseProgress) error {
+	if time.Since(r.resumed) > r.budget {
+		stoppedAt := time.Now()
+		r.stopped <- struct{}{}
+		if !<-r.resume {
+			return fmt.Errorf("%w: parse stopped after running out of time, and was not resumed", ErrParseLimitExceeded)
+		}
+		// the time spent stopped is not part of the parse
+		paused := time.Since(stoppedAt)
+		if monitor := r.parser.progress; monitor != nil {
+			monitor.start = monitor.start.Add(paused)
+		}
+	}
+	if r.callback != nil {
+		return r.callback(progress)
+	}
+	return nil
+}
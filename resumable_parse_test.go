@@ -0,0 +1,79 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResumableParse(t *testing.T) {
+	// 4000 tokens, so that the time is checked every thousand
+	input := strings.Repeat("a b + c ", 1000)
+	p, _ := listParse("")
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+	parse := NewResumableParse(p, func() ParseTree { return p.S() })
+
+	// each step runs out of time at once, and stops at the next check
+	var stops []int
+	tree, done := parse.Run(-1)
+	for ; !done; tree, done = parse.Run(-1) {
+		if tree != nil || parse.Done() {
+			t.Fatal("a stopped parse returned a tree")
+		}
+		stops = append(stops, p.GetCurrentToken().GetTokenIndex())
+	}
+	if len(stops) != 4 || stops[0] != 1000 || stops[3] != 4000 {
+		t.Errorf("the parse stopped at tokens %v", stops)
+	}
+	if _, want := listParse(input); tree.ToStringTree(nil, p) != want.ToStringTree(nil, p) || p.GetError() != nil {
+		t.Errorf("the resumed parse ended with %v and a tree other than that of a parse in one go", p.GetError())
+	}
+	if again, done := parse.Run(-1); again != tree || !done || parse.Abort() != tree {
+		t.Error("a parse that ended did not return its tree again")
+	}
+}
+
+func TestResumableParseAbort(t *testing.T) {
+	p, _ := listParse("")
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream(strings.Repeat("a ", 3000))), TokenDefaultChannel))
+	parse := NewResumableParse(p, func() ParseTree { return p.S() })
+	if _, done := parse.Run(-1); done {
+		t.Fatal("the parse did not stop")
+	}
+	tree := parse.Abort()
+	if err, _ := p.GetError().(error); !errors.Is(err, ErrParseLimitExceeded) || tree != p.GetPartialTree() || !parse.Done() {
+		t.Fatalf("the aborted parse ended with %v", p.GetError())
+	}
+
+	// a step with time enough runs the parse to the end, calling the callback the parser had, which is then
+	// its own again
+	p.SetInputStream(NewCommonTokenStream(newListLexer(NewInputStream(strings.Repeat("a ", 3000))), TokenDefaultChannel))
+	calls := 0
+	p.SetProgressCallback(100, func(ParseProgress) error {
+		calls++
+		return nil
+	})
+	if _, done := NewResumableParse(p, func() ParseTree { return p.S() }).Run(time.Hour); !done {
+		t.Fatal("the parse did not end")
+	}
+	if calls != 30 || p.progress.interval != 100 {
+		t.Errorf("the callback was called %d times, and has the interval %d", calls, p.progress.interval)
+	}
+
+	never := NewResumableParse(p, func() ParseTree { return p.S() })
+	if never.Abort() != nil || !never.Done() {
+		t.Error("a parse that never began was not given up")
+	}
+
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("Run() panicked with %v", r)
+		}
+	}()
+	NewResumableParse(p, func() ParseTree { panic("boom") }).Run(time.Second)
+}
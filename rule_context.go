@@ -4,6 +4,8 @@
 
 package antlr
 
+import "reflect"
+
 // RuleContext is a record of a single rule invocation. It knows
 // which context invoked it, if any. If there is no parent context, then
 // naturally the invoking state is not valid.  The parent link
@@ -38,3 +40,42 @@ type RuleContext interface {
 
 	String([]string, RuleContext) string
 }
+
+// IsEmptyContext reports whether ctx is the empty context.
+//
+// A nil context and the empty context mean different things to the runtime, and they must not be confused:
+//
+//   - A nil context means that there is no context at all. Lookahead computed with a nil context, as by
+//     [ATN.NextTokens] or [LL1Analyzer.Look], stays within the rule containing the state, and contains
+//     [TokenEpsilon] if the end of that rule can be reached.
+//   - The empty context is a context with no invoking state, such as [ParserRuleContextEmpty] or the context
+//     of the start rule. It means that nothing called the rule, so lookahead computed with it that reaches
+//     the end of the rule contains [TokenEOF] instead.
+//
+// A nil pointer stored in a RuleContext, such as a nil *BaseParserRuleContext, is treated as a nil context
+// by the runtime rather than causing a crash.
+//
+// Hence IsEmptyContext returns false for a nil context.
+func IsEmptyContext(ctx RuleContext) bool {
+	if isNilContext(ctx) {
+		return false
+	}
+	return ctx == RuleContext(ParserRuleContextEmpty) || ctx.IsEmpty()
+}
+
+// isNilContext returns true if ctx is nil, or holds a nil pointer.
+func isNilContext(ctx RuleContext) bool {
+	if ctx == nil {
+		return true
+	}
+	v := reflect.ValueOf(ctx)
+	return v.Kind() == reflect.Ptr && v.IsNil()
+}
+
+// parentContext returns the parent of ctx, or nil if it has none.
+func parentContext(ctx RuleContext) RuleContext {
+	if parent, ok := ctx.GetParent().(RuleContext); ok && !isNilContext(parent) {
+		return parent
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestIsEmptyContext(t *testing.T) {
+	var none *BaseParserRuleContext
+	empty := NewEmptyParserRuleContext()
+	tests := []struct {
+		name string
+		ctx  RuleContext
+		want bool
+	}{
+		{"nil", nil, false},
+		{"nil pointer", none, false},
+		{"ParserRuleContextEmpty", ParserRuleContextEmpty, true},
+		{"new empty", empty, true},
+		{"child", NewBaseParserRuleContext(empty, 4), false},
+	}
+	for _, test := range tests {
+		if got := IsEmptyContext(test.ctx); got != test.want {
+			t.Errorf("IsEmptyContext(%s) = %v, want %v", test.name, got, test.want)
+		}
+	}
+	if empty == ParserRuleContextEmpty {
+		t.Error("NewEmptyParserRuleContext returned the shared empty context")
+	}
+}
+
+func TestNextTokensNilAndEmptyContext(t *testing.T) {
+	listParserStatic.init(listParserSerialized)
+	atn := listParserStatic.atn
+	stop := atn.ruleToStopState[listRuleItem]
+
+	// Without a context, lookahead stays within the rule
+	var none *BaseParserRuleContext
+	for _, ctx := range []RuleContext{nil, none} {
+		if got := atn.NextTokens(stop, ctx).String(); got != "-2" {
+			t.Errorf("NextTokens(%v) = %s, want epsilon", ctx, got)
+		}
+		if got := NewLL1Analyzer(atn).Look(stop, nil, ctx).String(); got != "-2" {
+			t.Errorf("Look(%v) = %s, want epsilon", ctx, got)
+		}
+	}
+
+	// With the empty context nothing called the rule, so EOF follows it
+	for _, ctx := range []RuleContext{ParserRuleContextEmpty, NewEmptyParserRuleContext()} {
+		if got := atn.NextTokens(stop, ctx).String(); got != "<EOF>" {
+			t.Errorf("NextTokens(empty) = %s, want <EOF>", got)
+		}
+	}
+
+	// Called from s, item is followed by another item or EOF
+	s := NewBaseParserRuleContext(NewEmptyParserRuleContext(), -1)
+	item := NewBaseParserRuleContext(s, 4)
+	if got := atn.NextTokens(stop, item).String(); got != "{<EOF>, 1}" {
+		t.Errorf("NextTokens(item) = %s, want {<EOF>, 1}", got)
+	}
+	if pc := predictionContextFromRuleContext(atn, none); pc != BasePredictionContextEMPTY {
+		t.Errorf("the prediction context of a nil context is %v", pc)
+	}
+}
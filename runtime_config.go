@@ -0,0 +1,275 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrParseLimitExceeded is wrapped by the cause of the [ParseCancellationException] of a parse that is
+// aborted for exceeding a limit set with [RuntimeConfig] or [BaseParser.SetMaxRuleDepth].
+var ErrParseLimitExceeded = errors.New("parse limit exceeded")
+
+// runtimeConfigCheckInterval is the number of tokens or configurations between checks of the time and
+// configuration limits of a RuntimeConfig.
+const runtimeConfigCheckInterval = 1000
+
+// RuntimeConfig bundles the settings of a parser that are otherwise made with separate setters on the parser,
+// its interpreter and its ATN, so that they can be applied in one call, and captured from a parser and
+// restored later, as when parsers are pooled and a borrower may have changed them.
+//
+// A RuntimeConfig is created with [NewRuntimeConfig] from the defaults of a newly generated parser, as
+// modified by the given options, or captured from a parser with [SnapshotRuntimeConfig]. It is applied with
+// [RuntimeConfig.Apply], which replaces every setting, not only those given as options. A RuntimeConfig is
+// not changed by being applied, so one may be applied to any number of parsers.
+//
+// Use:
+//
+//	config := antlr.NewRuntimeConfig(
+//	    antlr.WithPredictionMode(antlr.PredictionModeSLL),
+//	    antlr.WithErrorStrategy(func() antlr.ErrorStrategy { return antlr.NewBailErrorStrategy() }),
+//	    antlr.WithErrorListeners(myListener),
+//	    antlr.WithMaxRuleDepth(500),
+//	    antlr.WithTimeout(2*time.Second),
+//	)
+//	config.Apply(p)
+type RuntimeConfig struct {
+	predictionMode   int
+	errorStrategy    func() ErrorStrategy
+	errorListeners   []ErrorListener
+	parseListeners   []ParseTreeListener
+	buildParseTrees  bool
+	maxRuleDepth     int
+	timeout          time.Duration
+	maxConfigs       int
+	progressInterval int
+	progress         ProgressFunc
+	arena            *Arena
+	configPooling    bool
+	expectedCache    int
+	validateOnly     bool
+}
+
+// RuntimeConfigOption sets one of the settings of a [RuntimeConfig].
+type RuntimeConfigOption func(*RuntimeConfig)
+
+// NewRuntimeConfig creates a [RuntimeConfig] with the settings of a newly generated parser, modified by the
+// options in the order given: prediction mode [PredictionModeLL], a [DefaultErrorStrategy], the
+// [ConsoleErrorListener], no parse listeners, parse trees built, not validate-only, no limits, no progress
+// callback, no arena, no pooling of configurations and no cache of expected tokens.
+func NewRuntimeConfig(options ...RuntimeConfigOption) *RuntimeConfig {
+	c := &RuntimeConfig{
+		predictionMode:  PredictionModeLL,
+		errorListeners:  []ErrorListener{ConsoleErrorListenerINSTANCE},
+		buildParseTrees: true,
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// WithPredictionMode sets the prediction mode, see [ParserATNSimulator.SetPredictionMode].
+func WithPredictionMode(mode int) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.predictionMode = mode
+	}
+}
+
+// WithErrorStrategy sets the error strategy, see [BaseParser.SetErrorHandler]. As error strategies hold the
+// state of the recovery from errors, each parser that the config is applied to needs its own, so the option
+// takes a func that creates one.
+func WithErrorStrategy(factory func() ErrorStrategy) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.errorStrategy = factory
+	}
+}
+
+// WithErrorListeners sets the error listeners, which replace the [ConsoleErrorListener] and any others.
+// Pass no listeners to report errors to none.
+func WithErrorListeners(listeners ...ErrorListener) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.errorListeners = append([]ErrorListener(nil), listeners...)
+	}
+}
+
+// WithParseListeners sets the parse listeners, see [BaseParser.AddParseListener].
+func WithParseListeners(listeners ...ParseTreeListener) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.parseListeners = append([]ParseTreeListener(nil), listeners...)
+	}
+}
+
+// WithBuildParseTrees sets whether the parser builds a parse tree.
+func WithBuildParseTrees(build bool) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.buildParseTrees = build
+	}
+}
+
+// WithMaxRuleDepth limits the nesting of rule invocations, see [BaseParser.SetMaxRuleDepth].
+func WithMaxRuleDepth(depth int) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.maxRuleDepth = depth
+	}
+}
+
+// WithTimeout limits the time that a parse may take. A parse that takes longer is aborted, and the parser's
+// error is set to a [ParseCancellationException] whose cause wraps [ErrParseLimitExceeded]. The time is
+// checked every thousand tokens or [ATN] configurations, so a parse may overrun the limit a little. Pass 0
+// to remove the limit.
+func WithTimeout(timeout time.Duration) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.timeout = timeout
+	}
+}
+
+// WithMaxConfigs limits the number of [ATN] configurations that adaptive prediction may create during a
+// parse, which bounds the memory and time spent predicting, in the same way as [WithTimeout]. Pass 0 to
+// remove the limit.
+func WithMaxConfigs(configs int) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.maxConfigs = configs
+	}
+}
+
+// WithProgressCallback sets a [ProgressFunc], see [BaseParser.SetProgressCallback]. It is called as well as
+// the checks of any time or configuration limits.
+func WithProgressCallback(interval int, callback ProgressFunc) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.progressInterval = interval
+		c.progress = callback
+	}
+}
+
+// WithArena sets the [Arena] that the parser allocates configurations and prediction contexts from, see
+// [BaseParser.SetArena].
+func WithArena(arena *Arena) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.arena = arena
+	}
+}
+
+// WithConfigPooling sets whether the configurations of full context predictions are pooled, see
+// [ParserATNSimulator.SetConfigPooling].
+func WithConfigPooling(enabled bool) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.configPooling = enabled
+	}
+}
+
+// WithExpectedTokensCacheSize sets the size of the cache of expected tokens of the parser's [ATN], see
+// [ATN.SetExpectedTokensCacheSize]. The ATN is shared by all parsers of the same grammar, so this setting
+// affects them all. The cache is only replaced when a config with a different size is applied, so apply
+// configs with the same size to parsers that run concurrently.
+func WithExpectedTokensCacheSize(size int) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.expectedCache = size
+	}
+}
+
+// WithValidateOnly sets whether the parser only validates its input, see [BaseParser.SetValidateOnly].
+func WithValidateOnly(validateOnly bool) RuntimeConfigOption {
+	return func(c *RuntimeConfig) {
+		c.validateOnly = validateOnly
+	}
+}
+
+// Apply replaces the settings of parser p with those of the config. It panics if p does not embed
+// [BaseParser]. Apply the config between parses, not during one.
+func (c *RuntimeConfig) Apply(p Parser) {
+	bp := runtimeConfigParser(p)
+
+	bp.Interpreter.SetPredictionMode(c.predictionMode)
+	if c.errorStrategy != nil {
+		bp.SetErrorHandler(c.errorStrategy())
+	} else {
+		bp.SetErrorHandler(NewDefaultErrorStrategy())
+	}
+
+	bp.listeners = append([]ErrorListener(nil), c.errorListeners...)
+	bp.tracer = nil
+	bp.parseListeners = nil
+	if len(c.parseListeners) > 0 {
+		bp.parseListeners = append([]ParseTreeListener(nil), c.parseListeners...)
+	}
+	bp.BuildParseTrees = c.buildParseTrees
+	bp.SetValidateOnly(c.validateOnly)
+
+	bp.SetMaxRuleDepth(c.maxRuleDepth)
+	bp.SetProgressCallback(c.progressCallback())
+	bp.SetArena(c.arena)
+	bp.Interpreter.SetConfigPooling(c.configPooling)
+	if size := bp.Interpreter.atn.expectedTokensCacheSize(); size != c.expectedCache {
+		bp.Interpreter.atn.SetExpectedTokensCacheSize(c.expectedCache)
+	}
+}
+
+// progressCallback returns the interval and ProgressFunc that check the limits of the config and call its
+// progress callback, or a nil func if there is nothing to check or call.
+func (c *RuntimeConfig) progressCallback() (int, ProgressFunc) {
+	if c.timeout <= 0 && c.maxConfigs <= 0 {
+		return c.progressInterval, c.progress
+	}
+	interval := runtimeConfigCheckInterval
+	if c.progress != nil && c.progressInterval > 0 && c.progressInterval < interval {
+		interval = c.progressInterval
+	}
+	timeout, maxConfigs, progress := c.timeout, c.maxConfigs, c.progress
+	return interval, func(p ParseProgress) error {
+		if timeout > 0 && p.Elapsed > timeout {
+			return fmt.Errorf("%w: parse took longer than %v", ErrParseLimitExceeded, timeout)
+		}
+		if maxConfigs > 0 && p.ConfigsCreated > maxConfigs {
+			return fmt.Errorf("%w: more than %d ATN configurations created", ErrParseLimitExceeded, maxConfigs)
+		}
+		if progress != nil {
+			return progress(p)
+		}
+		return nil
+	}
+}
+
+// SnapshotRuntimeConfig captures the settings of parser p as a [RuntimeConfig], so that they can be restored
+// by applying it. The error strategy of p is captured as it is, so the snapshot should only be applied back
+// to p itself. It panics if p does not embed [BaseParser].
+//
+// Use:
+//
+//	saved := antlr.SnapshotRuntimeConfig(p)
+//	borrower(p)
+//	saved.Apply(p)
+func SnapshotRuntimeConfig(p Parser) *RuntimeConfig {
+	bp := runtimeConfigParser(p)
+
+	errorStrategy := bp.errHandler
+	c := &RuntimeConfig{
+		predictionMode:  bp.Interpreter.GetPredictionMode(),
+		errorStrategy:   func() ErrorStrategy { return errorStrategy },
+		errorListeners:  append([]ErrorListener(nil), bp.listeners...),
+		parseListeners:  append([]ParseTreeListener(nil), bp.parseListeners...),
+		buildParseTrees: bp.BuildParseTrees,
+		maxRuleDepth:    bp.maxRuleDepth,
+		validateOnly:    bp.validateOnly,
+		arena:           bp.Interpreter.GetArena(),
+		configPooling:   bp.Interpreter.IsConfigPooling(),
+	}
+	if bp.progress != nil && bp.progress.callback != nil {
+		c.progressInterval, c.progress = bp.progress.interval, bp.progress.callback
+	}
+	c.expectedCache = bp.Interpreter.atn.expectedTokensCacheSize()
+	return c
+}
+
+// runtimeConfigParser returns the BaseParser of p, or panics if it does not embed one.
+func runtimeConfigParser(p Parser) *BaseParser {
+	bp, ok := p.(interface{ getBaseParser() *BaseParser })
+	if !ok {
+		panic("a RuntimeConfig requires a parser that embeds BaseParser")
+	}
+	return bp.getBaseParser()
+}
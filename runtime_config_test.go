@@ -0,0 +1,103 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRuntimeConfigApply(t *testing.T) {
+	listener := new(countingErrorListener)
+	arena := NewArena()
+	config := NewRuntimeConfig(
+		WithPredictionMode(PredictionModeSLL),
+		WithErrorStrategy(func() ErrorStrategy { return NewBailErrorStrategy() }),
+		WithErrorListeners(listener),
+		WithBuildParseTrees(false),
+		WithMaxRuleDepth(10),
+		WithArena(arena),
+	)
+	p, q := newListParser(nil), newListParser(nil)
+	config.Apply(p)
+	config.Apply(q)
+	if p.GetInterpreter().GetPredictionMode() != PredictionModeSLL || p.BuildParseTrees || p.GetMaxRuleDepth() != 10 ||
+		p.GetInterpreter().GetArena() != arena {
+		t.Error("the settings were not applied")
+	}
+	if _, ok := p.GetErrorHandler().(*BailErrorStrategy); !ok || p.GetErrorHandler() == q.GetErrorHandler() {
+		t.Error("the parsers do not each have a new bail strategy")
+	}
+	if len(p.listeners) != 1 || p.listeners[0] != listener {
+		t.Errorf("error listeners %v", p.listeners)
+	}
+
+	// the defaults replace every setting
+	NewRuntimeConfig().Apply(p)
+	if p.GetInterpreter().GetPredictionMode() != PredictionModeLL || !p.BuildParseTrees || p.GetMaxRuleDepth() != 0 ||
+		p.GetInterpreter().GetArena() != nil {
+		t.Error("the default settings were not applied")
+	}
+	if _, ok := p.GetErrorHandler().(*DefaultErrorStrategy); !ok {
+		t.Errorf("error strategy %T", p.GetErrorHandler())
+	}
+	if len(p.listeners) != 1 || p.listeners[0] != ConsoleErrorListenerINSTANCE {
+		t.Errorf("error listeners %v", p.listeners)
+	}
+}
+
+func TestSnapshotRuntimeConfig(t *testing.T) {
+	p := newListParser(nil)
+	p.GetInterpreter().SetPredictionMode(PredictionModeLLExactAmbigDetection)
+	p.SetMaxRuleDepth(3)
+	strategy := NewBailErrorStrategy()
+	p.SetErrorHandler(strategy)
+	p.RemoveErrorListeners()
+	saved := SnapshotRuntimeConfig(p)
+
+	NewRuntimeConfig(WithMaxRuleDepth(20)).Apply(p)
+	saved.Apply(p)
+	if p.GetInterpreter().GetPredictionMode() != PredictionModeLLExactAmbigDetection || p.GetMaxRuleDepth() != 3 ||
+		p.GetErrorHandler() != strategy || len(p.listeners) != 0 {
+		t.Error("the snapshot did not restore the settings")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("a parser without a BaseParser was configured")
+		}
+	}()
+	NewRuntimeConfig().Apply(struct{ Parser }{})
+}
+
+func TestRuntimeConfigLimits(t *testing.T) {
+	parse := func(input string, options ...RuntimeConfigOption) error {
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+		p.Interpreter = NewParserATNSimulator(p, p.GetATN(), newDFA(p.GetATN()), NewPredictionContextCache())
+		NewRuntimeConfig(append(options, WithErrorListeners())...).Apply(p)
+		p.S()
+		err, _ := p.GetError().(error)
+		return err
+	}
+
+	// s invokes item, which is nested two deep
+	if err := parse("a b", WithMaxRuleDepth(2)); err != nil {
+		t.Errorf("a parse within the depth failed with %v", err)
+	}
+	if err := parse("a b", WithMaxRuleDepth(1)); !errors.Is(err, ErrParseLimitExceeded) {
+		t.Errorf("a parse beyond the depth ended with %v", err)
+	}
+
+	// the limits are checked as often as the progress callback is called
+	calls := 0
+	progress := WithProgressCallback(1, func(ParseProgress) error { calls++; return nil })
+	if err := parse("a b + c", WithMaxConfigs(1), progress); !errors.Is(err, ErrParseLimitExceeded) {
+		t.Errorf("a parse beyond the configurations ended with %v", err)
+	}
+	if err := parse("a b + c", WithMaxConfigs(1000), WithTimeout(time.Hour), progress); err != nil || calls == 0 {
+		t.Errorf("a parse within the limits ended with %v, after %d calls", err, calls)
+	}
+}
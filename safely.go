@@ -0,0 +1,96 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"sync/atomic"
+)
+
+// ErrPanic is wrapped by the errors returned by [Safely] and [SafelyValue] for a panic.
+var ErrPanic = errors.New("panic in ANTLR runtime")
+
+// PanicError is the error returned by [Safely] and [SafelyValue] for a panic. It wraps [ErrPanic], and the
+// value the code panicked with if that is an error, such as a [runtime.Error], so that errors.Is and errors.As
+// find them.
+type PanicError struct {
+	// Value is the value the code panicked with
+	Value any
+
+	// Stack is the stack of the goroutine where the code panicked, as formatted by [debug.Stack]
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("%v: %v", ErrPanic, e.Value)
+}
+
+func (e *PanicError) Unwrap() []error {
+	if err, ok := e.Value.(error); ok {
+		return []error{ErrPanic, err}
+	}
+	return []error{ErrPanic}
+}
+
+// panicHook is the func installed with SetPanicHook, if any.
+var panicHook atomic.Pointer[func(*PanicError)]
+
+// SetPanicHook installs a func that [Safely] and [SafelyValue] call with each panic they recover, before they
+// return it as an error, such as to log it with its stack, or to count it. The hook is called by the
+// goroutine that panicked, and must be safe to call from several at once. Pass nil to remove it.
+func SetPanicHook(hook func(*PanicError)) {
+	if hook == nil {
+		panicHook.Store(nil)
+		return
+	}
+	panicHook.Store(&hook)
+}
+
+// Safely calls f, and returns a [*PanicError] if it panics, rather than letting the panic unwind the caller.
+// It is the boundary at which services that cannot afford to crash on a bad grammar, a bug in the runtime or
+// in a listener, or input that exhausts a limit, turn such a panic into an error they can log and recover
+// from. Syntax errors are not panics, and are reported to the error listeners as usual.
+//
+// The recognizer that was running may be left in any state, so discard it, or reset it and set its input
+// again, before using it any further.
+//
+// Use:
+//
+//	var tree parser.IStartContext
+//	if err := antlr.Safely(func() { tree = p.Start() }); err != nil {
+//	    var panicErr *antlr.PanicError
+//	    errors.As(err, &panicErr)
+//	    log.Printf("parse failed: %v\n%s", err, panicErr.Stack)
+//	}
+func Safely(f func()) error {
+	_, err := SafelyValue(func() struct{} {
+		f()
+		return struct{}{}
+	})
+	return err
+}
+
+// SafelyValue calls f and returns its result, or the zero value and a [*PanicError] if it panics. See
+// [Safely].
+//
+// Use:
+//
+//	token, err := antlr.SafelyValue(lexer.NextToken)
+//	text, err := antlr.SafelyValue(tree.GetText)
+func SafelyValue[T any](f func() T) (result T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panicErr := &PanicError{Value: r, Stack: debug.Stack()}
+			if hook := panicHook.Load(); hook != nil {
+				(*hook)(panicErr)
+			}
+			var zero T
+			result, err = zero, panicErr
+		}
+	}()
+	return f(), nil
+}
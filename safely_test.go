@@ -0,0 +1,68 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSafely(t *testing.T) {
+	if err := Safely(func() {}); err != nil {
+		t.Errorf("Safely() of a func that returns = %v", err)
+	}
+	if text, err := SafelyValue(func() string { return "a" }); text != "a" || err != nil {
+		t.Errorf("SafelyValue() = %q, %v", text, err)
+	}
+
+	err := Safely(func() { panic("bad grammar") })
+	var panicErr *PanicError
+	if !errors.Is(err, ErrPanic) || !errors.As(err, &panicErr) || panicErr.Value != "bad grammar" {
+		t.Fatalf("Safely() of a panic = %v", err)
+	}
+	if err.Error() != "panic in ANTLR runtime: bad grammar" || !strings.Contains(string(panicErr.Stack), "TestSafely") {
+		t.Errorf("the error %q has the stack:\n%s", err, panicErr.Stack)
+	}
+
+	// a runtime error is found by errors.As, and the zero value is returned
+	n, err := SafelyValue(func() int {
+		var tokens []Token
+		return tokens[1].GetTokenType()
+	})
+	var runtimeErr runtime.Error
+	if n != 0 || !errors.Is(err, ErrPanic) || !errors.As(err, &runtimeErr) {
+		t.Errorf("SafelyValue() of an index out of range = %d, %v", n, err)
+	}
+
+	// a panic of the runtime, recovered around a recognizer
+	lexer := newListLexer(NewInputStream("a"))
+	lexer.Interpreter = nil
+	if token, err := SafelyValue(lexer.NextToken); token != nil || !errors.Is(err, ErrPanic) {
+		t.Errorf("SafelyValue(NextToken) of a lexer without a simulator = %v, %v", token, err)
+	}
+}
+
+func TestSetPanicHook(t *testing.T) {
+	var hooked []*PanicError
+	SetPanicHook(func(err *PanicError) { hooked = append(hooked, err) })
+	defer SetPanicHook(nil)
+
+	err := Safely(func() { panic(ErrInvalidInterval) })
+	if len(hooked) != 1 || hooked[0] != err || !errors.Is(err, ErrInvalidInterval) {
+		t.Errorf("the hook was called with %v for %v", hooked, err)
+	}
+	_ = Safely(func() {})
+	if len(hooked) != 1 {
+		t.Errorf("the hook was called %d times for a func that returns", len(hooked))
+	}
+
+	SetPanicHook(nil)
+	_ = Safely(func() { panic(1) })
+	if len(hooked) != 1 {
+		t.Error("the hook was called after it was removed")
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "strings"
+
+// SemanticTokenStyle is the highlighting of a token, as the index of a token type in the legend of semantic
+// token types that a language server declares to its client, and a bit set of the modifiers in its legend
+// of modifiers.
+type SemanticTokenStyle struct {
+	Type      int
+	Modifiers uint32
+}
+
+// SemanticTokenEncoder produces the data of a semantic tokens response of the Language Server Protocol, the
+// array of integers with five for each token to highlight, from the tokens of a document and, optionally,
+// its parse tree. Which tokens are highlighted, and how, is set by a mapping table built with its Map
+// methods. Where several mappings apply to a token, the most specific applies: a mapping for the token type
+// within a rule, then one for the token type, then one for the lexer mode.
+//
+// Use:
+//
+//	encoder := antlr.NewSemanticTokenEncoder()
+//	encoder.MapTokenType(parser.MyLexerSTRING, antlr.SemanticTokenStyle{Type: legendString})
+//	encoder.MapTokenType(parser.MyLexerID, antlr.SemanticTokenStyle{Type: legendVariable})
+//	encoder.MapRuleToken(parser.MyParserRULE_functionDecl, parser.MyLexerID,
+//	    antlr.SemanticTokenStyle{Type: legendFunction, Modifiers: 1 << legendDeclaration})
+//	data := encoder.Encode(stream, tree)
+type SemanticTokenEncoder struct {
+	types map[int]SemanticTokenStyle
+	modes map[int]SemanticTokenStyle
+	rules map[[2]int]SemanticTokenStyle
+}
+
+// NewSemanticTokenEncoder creates a [SemanticTokenEncoder] with an empty mapping table, which highlights
+// nothing.
+func NewSemanticTokenEncoder() *SemanticTokenEncoder {
+	return &SemanticTokenEncoder{
+		types: make(map[int]SemanticTokenStyle),
+		modes: make(map[int]SemanticTokenStyle),
+		rules: make(map[[2]int]SemanticTokenStyle),
+	}
+}
+
+// MapTokenType highlights tokens of the given type with style.
+func (e *SemanticTokenEncoder) MapTokenType(tokenType int, style SemanticTokenStyle) {
+	e.types[tokenType] = style
+}
+
+// MapMode highlights tokens lexed in the given lexer mode with style, such as the parts of a template
+// string. The mode of a token is known only if the stream given to Encode draws its tokens from a
+// [TokenModeTracker].
+func (e *SemanticTokenEncoder) MapMode(mode int, style SemanticTokenStyle) {
+	e.modes[mode] = style
+}
+
+// MapRuleToken highlights tokens of the given type within a rule context of the given rule with style,
+// such as the name in a function declaration. The innermost rule context with a mapping for the token type
+// applies. Rule mappings apply only when a parse tree is given to Encode.
+func (e *SemanticTokenEncoder) MapRuleToken(ruleIndex, tokenType int, style SemanticTokenStyle) {
+	e.rules[[2]int{ruleIndex, tokenType}] = style
+}
+
+// Encode returns the delta-encoded semantic tokens for the tokens of stream, which is filled first, using
+// tree, which may be nil, for the rule mappings. Tokens on every channel are considered, so comments can be
+// highlighted. As in the Language Server Protocol, each token is encoded as five integers: the line, and the
+// start column, relative to those of the previous token, the length, the token type and the modifiers.
+// Lengths and columns count code points, as for [TextRange]. A token that spans several lines is encoded as
+// one token for each line, since not every client supports tokens that span lines.
+func (e *SemanticTokenEncoder) Encode(stream *CommonTokenStream, tree ParseTree) []uint32 {
+	stream.Fill()
+	modes, _ := stream.GetTokenSource().(interface {
+		TokenMode(tokenIndex int) (int, bool)
+	})
+
+	var byRule map[int]SemanticTokenStyle
+	if tree != nil && len(e.rules) > 0 {
+		byRule = make(map[int]SemanticTokenStyle)
+		e.mapRules(tree, nil, byRule)
+	}
+
+	data := make([]uint32, 0)
+	prevLine, prevColumn := 0, 0
+	for _, t := range stream.GetAllTokens() {
+		if t.GetTokenType() == TokenEOF {
+			break
+		}
+		style, ok := byRule[t.GetTokenIndex()]
+		if !ok {
+			style, ok = e.types[t.GetTokenType()]
+		}
+		if !ok && modes != nil && len(e.modes) > 0 {
+			if mode, known := modes.TokenMode(t.GetTokenIndex()); known {
+				style, ok = e.modes[mode]
+			}
+		}
+		if !ok {
+			continue
+		}
+
+		line, column := t.GetLine()-1, t.GetColumn()
+		for _, text := range strings.Split(t.GetText(), "\n") {
+			length := len([]rune(strings.TrimSuffix(text, "\r")))
+			if length > 0 {
+				deltaColumn := column
+				if line == prevLine {
+					deltaColumn = column - prevColumn
+				}
+				data = append(data, uint32(line-prevLine), uint32(deltaColumn), uint32(length), uint32(style.Type),
+					style.Modifiers)
+				prevLine, prevColumn = line, column
+			}
+			line++
+			column = 0
+		}
+	}
+	return data
+}
+
+// mapRules finds the rule mapping, if any, that applies to each token of the tree, given the rule contexts
+// that enclose t, innermost last.
+func (e *SemanticTokenEncoder) mapRules(t Tree, enclosing []int, byRule map[int]SemanticTokenStyle) {
+	switch n := t.(type) {
+	case TerminalNode:
+		token := n.GetSymbol()
+		for i := len(enclosing) - 1; i >= 0; i-- {
+			if style, ok := e.rules[[2]int{enclosing[i], token.GetTokenType()}]; ok {
+				byRule[token.GetTokenIndex()] = style
+				return
+			}
+		}
+	case ParserRuleContext:
+		enclosing = append(enclosing, n.GetRuleIndex())
+		for _, child := range n.GetChildren() {
+			e.mapRules(child, enclosing, byRule)
+		}
+	}
+}
+
+// TokenModeTracker wraps a [Lexer] and records the mode in which each token it produces was lexed, so that
+// tools such as [SemanticTokenEncoder] can tell the mode of a token once it has been lexed. Create the token
+// stream from the tracker rather than from the lexer itself.
+//
+// Use:
+//
+//	tracker := antlr.NewTokenModeTracker(parser.NewMyLexer(input))
+//	stream := antlr.NewCommonTokenStream(tracker, antlr.TokenDefaultChannel)
+type TokenModeTracker struct {
+	Lexer
+	modes []int
+}
+
+// NewTokenModeTracker creates a [TokenModeTracker] that records the modes of the tokens of lexer.
+func NewTokenModeTracker(lexer Lexer) *TokenModeTracker {
+	return &TokenModeTracker{Lexer: lexer, modes: make([]int, 0)}
+}
+
+// NextToken returns the next token from the lexer, recording the mode in which it was lexed.
+func (m *TokenModeTracker) NextToken() Token {
+	t := m.Lexer.NextToken()
+	mode := LexerDefaultMode
+	if l, ok := m.Lexer.(interface{ tokenStartMode() int }); ok {
+		mode = l.tokenStartMode()
+	}
+	m.modes = append(m.modes, mode)
+	return t
+}
+
+// TokenMode returns the mode of the token with the given index, counting the tokens produced by the
+// tracker from 0 as a [CommonTokenStream] does, and false if there is no such token.
+func (m *TokenModeTracker) TokenMode(tokenIndex int) (int, bool) {
+	if tokenIndex < 0 || tokenIndex >= len(m.modes) {
+		return 0, false
+	}
+	return m.modes[tokenIndex], true
+}
@@ -0,0 +1,73 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSemanticTokenEncoder(t *testing.T) {
+	encoder := NewSemanticTokenEncoder()
+	encoder.MapTokenType(listID, SemanticTokenStyle{Type: 1})
+	encoder.MapMode(LexerDefaultMode, SemanticTokenStyle{Type: 4})
+	newStream := func() *CommonTokenStream {
+		return NewCommonTokenStream(NewTokenModeTracker(newListLexer(NewInputStream("ab c+d"))), TokenDefaultChannel)
+	}
+
+	// '+' has no mapping of its own, and is highlighted by its mode
+	want := []uint32{
+		0, 0, 2, 1, 0,
+		0, 3, 1, 1, 0,
+		0, 1, 1, 4, 0,
+		0, 1, 1, 1, 0,
+	}
+	if got := encoder.Encode(newStream(), nil); !slices.Equal(got, want) {
+		t.Errorf("Encode() = %v, want %v", got, want)
+	}
+
+	// the mapping of the innermost rule applies, and only with the tree
+	encoder.MapRuleToken(listRuleS, listID, SemanticTokenStyle{Type: 3})
+	encoder.MapRuleToken(listRuleItem, listID, SemanticTokenStyle{Type: 2, Modifiers: 1})
+	stream := newStream()
+	tree := newListParser(stream).S()
+	want = []uint32{
+		0, 0, 2, 2, 1,
+		0, 3, 1, 2, 1,
+		0, 1, 1, 4, 0,
+		0, 1, 1, 2, 1,
+	}
+	if got := encoder.Encode(stream, tree); !slices.Equal(got, want) {
+		t.Errorf("Encode() with the tree = %v, want %v", got, want)
+	}
+
+	// without a tracker, the mode of '+' is not known
+	stream = NewCommonTokenStream(newListLexer(NewInputStream("a+")), TokenDefaultChannel)
+	if got := encoder.Encode(stream, nil); !slices.Equal(got, []uint32{0, 0, 1, 1, 0}) {
+		t.Errorf("Encode() without modes = %v", got)
+	}
+}
+
+func TestSemanticTokenEncoderLines(t *testing.T) {
+	first := newTestToken(listID, 0, 0, 1, 4)
+	first.SetText("a")
+	multiline := newTestToken(listID, 2, 6, 2, 2)
+	multiline.SetText("bc\r\n\nd")
+	eof := newTestToken(TokenEOF, 7, 6, 4, 1)
+	stream := NewCommonTokenStream(nil, TokenDefaultChannel)
+	stream.SetTokenSource(&sliceTokenSource{tokens: []Token{first, multiline, eof}})
+
+	encoder := NewSemanticTokenEncoder()
+	encoder.MapTokenType(listID, SemanticTokenStyle{Type: 1})
+	// a token is split at each line, and its empty lines are left out
+	want := []uint32{
+		0, 4, 1, 1, 0,
+		1, 2, 2, 1, 0,
+		2, 0, 1, 1, 0,
+	}
+	if got := encoder.Encode(stream, nil); !slices.Equal(got, want) {
+		t.Errorf("Encode() = %v, want %v", got, want)
+	}
+}
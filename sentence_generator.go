@@ -0,0 +1,250 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// sentenceTokenCost is the cost of matching a token, relative to the cost of 1 for any other transition,
+// when a [SentenceGenerator] looks for the shortest way to complete a sentence
+const sentenceTokenCost = 1 << 16
+
+// SentenceGenerator produces random token sequences that are valid for a parser grammar, by walking the
+// parser's [ATN] from the start state of a rule and choosing transitions at random. The sequences are
+// useful for fuzzing the consumers of a parse tree, and for smoke testing a grammar, since every sentence
+// generated should parse without error.
+//
+// Semantic predicates are assumed to be true and actions are ignored, so a grammar that relies on them may
+// yield sentences that do not parse. Once the nesting of rule invocations reaches the maximum depth, or the
+// sentence reaches the maximum length, the generator always takes the path that completes the sentence with
+// the fewest tokens, so generation always terminates.
+//
+// The generator is deterministic for a given seed, and is not safe for concurrent use.
+//
+// Use:
+//
+//	p := parser.NewMyParser(nil)
+//	g := antlr.NewSentenceGenerator(p.GetATN(), 42)
+//	g.SetMaxDepth(20)
+//	for i := 0; i < 100; i++ {
+//	    fmt.Println(g.GenerateText(parser.MyParserRULE_start, p))
+//	}
+type SentenceGenerator struct {
+	atn       *ATN
+	rand      *rand.Rand
+	maxDepth  int
+	maxTokens int
+
+	// costs holds the cost of reaching the end of the enclosing rule from each state, indexed by state
+	// number. It is computed when the generator is created. See transitionCost.
+	costs []int
+}
+
+// NewSentenceGenerator creates a [SentenceGenerator] for the given parser ATN, seeded with seed. The default
+// maximum depth of rule invocations is 16, and the default maximum sentence length is 256 tokens.
+func NewSentenceGenerator(atn *ATN, seed int64) *SentenceGenerator {
+	if atn.grammarType != ATNTypeParser {
+		panic("a sentence generator requires a parser ATN")
+	}
+	g := &SentenceGenerator{
+		atn:       atn,
+		rand:      rand.New(rand.NewSource(seed)),
+		maxDepth:  16,
+		maxTokens: 256,
+	}
+	g.computeCosts()
+	return g
+}
+
+// SetMaxDepth sets the depth of nested rule invocations beyond which the generator completes the sentence
+// as quickly as possible.
+func (g *SentenceGenerator) SetMaxDepth(depth int) {
+	g.maxDepth = depth
+}
+
+// SetMaxTokens sets the number of tokens beyond which the generator completes the sentence as quickly as
+// possible. Sentences may be a little longer than this, as completing them takes further tokens.
+func (g *SentenceGenerator) SetMaxTokens(n int) {
+	g.maxTokens = n
+}
+
+// Generate returns a random sequence of token types that matches the rule with the given index. [TokenEOF]
+// is not included, even if the rule matches it. It returns nil if the rule cannot match any finite input.
+func (g *SentenceGenerator) Generate(ruleIndex int) []int {
+	s := ATNState(g.atn.ruleToStartState[ruleIndex])
+	if g.costs[s.GetStateNumber()] == math.MaxInt {
+		return nil
+	}
+
+	tokens := make([]int, 0)
+	var follow []ATNState
+	for {
+		if _, ok := s.(*RuleStopState); ok {
+			if len(follow) == 0 {
+				return tokens
+			}
+			s = follow[len(follow)-1]
+			follow = follow[:len(follow)-1]
+			continue
+		}
+
+		var t Transition
+		if len(follow) >= g.maxDepth || len(tokens) >= g.maxTokens {
+			t = g.cheapestTransition(s)
+		} else {
+			t = g.randomTransition(s)
+		}
+
+		switch t := t.(type) {
+		case *RuleTransition:
+			follow = append(follow, t.followState)
+		case *AtomTransition, *RangeTransition, *SetTransition, *NotSetTransition, *WildcardTransition:
+			if ttype := g.randomToken(t); ttype != TokenEOF {
+				tokens = append(tokens, ttype)
+			}
+		}
+		s = t.getTarget()
+	}
+}
+
+// GenerateText returns a random sentence for the rule with the given index, as for
+// [SentenceGenerator.Generate], converted to text using the literal names in the vocabulary, such as the
+// parser. Tokens that have no literal name, such as identifiers, are shown as their symbolic name. The
+// tokens are separated by spaces.
+func (g *SentenceGenerator) GenerateText(ruleIndex int, vocabulary Recognizer) string {
+	literals := vocabulary.GetLiteralNames()
+	symbols := vocabulary.GetSymbolicNames()
+	words := make([]string, 0)
+	for _, ttype := range g.Generate(ruleIndex) {
+		switch {
+		case ttype < len(literals) && literals[ttype] != "":
+			words = append(words, unquoteLiteralName(literals[ttype]))
+		case ttype < len(symbols) && symbols[ttype] != "":
+			words = append(words, symbols[ttype])
+		default:
+			words = append(words, "<"+strconv.Itoa(ttype)+">")
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// randomTransition chooses one of the transitions of s at random, among those that can lead to the end of
+// the rule.
+func (g *SentenceGenerator) randomTransition(s ATNState) Transition {
+	viable := make([]Transition, 0, len(s.GetTransitions()))
+	for _, t := range s.GetTransitions() {
+		if g.transitionCost(t) != math.MaxInt {
+			viable = append(viable, t)
+		}
+	}
+	return viable[g.rand.Intn(len(viable))]
+}
+
+// cheapestTransition chooses the transition of s that leads to the end of the rule with the fewest tokens.
+// A state that can reach the end always has such a transition.
+func (g *SentenceGenerator) cheapestTransition(s ATNState) Transition {
+	var best Transition
+	bestCost := math.MaxInt
+	for _, t := range s.GetTransitions() {
+		if c := g.transitionCost(t); best == nil || c < bestCost {
+			best, bestCost = t, c
+		}
+	}
+	return best
+}
+
+// randomToken chooses a token type at random from those matched by t.
+func (g *SentenceGenerator) randomToken(t Transition) int {
+	var set *IntervalSet
+	switch t := t.(type) {
+	case *NotSetTransition:
+		set = t.getLabel().complement(TokenMinUserTokenType, g.atn.maxTokenType)
+	case *WildcardTransition:
+		set = NewIntervalSet()
+		set.addRange(TokenMinUserTokenType, g.atn.maxTokenType)
+	default:
+		set = t.getLabel()
+	}
+	n := g.rand.Intn(set.length())
+	for _, v := range set.intervals {
+		if n < v.Length() {
+			return v.Start + n
+		}
+		n -= v.Length()
+	}
+	panic("unreachable")
+}
+
+// transitionCost returns the cost of reaching the end of the rule by taking t, or math.MaxInt if the end
+// cannot be reached that way. Each token costs sentenceTokenCost, and every other transition costs 1, so
+// that the cheapest path is the one with the fewest tokens and, among those, the fewest transitions. As
+// every transition has a cost, following the cheapest transition from each state cannot loop.
+func (g *SentenceGenerator) transitionCost(t Transition) int {
+	here := 1
+	rest := g.costs[t.getTarget().GetStateNumber()]
+	switch t := t.(type) {
+	case *RuleTransition:
+		if rest == math.MaxInt {
+			return math.MaxInt
+		}
+		here += rest
+		rest = g.costs[t.followState.GetStateNumber()]
+	case *AtomTransition, *RangeTransition, *SetTransition:
+		if t.getLabel().length() == 0 {
+			return math.MaxInt
+		}
+		here = sentenceTokenCost
+	case *NotSetTransition:
+		if t.getLabel().complement(TokenMinUserTokenType, g.atn.maxTokenType).length() == 0 {
+			return math.MaxInt
+		}
+		here = sentenceTokenCost
+	case *WildcardTransition:
+		here = sentenceTokenCost
+	}
+	if rest == math.MaxInt {
+		return math.MaxInt
+	}
+	return here + rest
+}
+
+// computeCosts computes the cost of reaching the end of the enclosing rule from each state, by relaxing
+// the costs of all states until none changes.
+func (g *SentenceGenerator) computeCosts() {
+	g.costs = make([]int, len(g.atn.states))
+	for i, s := range g.atn.states {
+		if _, ok := s.(*RuleStopState); ok {
+			g.costs[i] = 0
+		} else {
+			g.costs[i] = math.MaxInt
+		}
+	}
+	for changed := true; changed; {
+		changed = false
+		for i, s := range g.atn.states {
+			if s == nil {
+				continue
+			}
+			for _, t := range s.GetTransitions() {
+				if c := g.transitionCost(t); c < g.costs[i] {
+					g.costs[i] = c
+					changed = true
+				}
+			}
+		}
+	}
+}
+
+// unquoteLiteralName converts a literal name from a vocabulary, such as '+', to the text it matches.
+func unquoteLiteralName(name string) string {
+	if len(name) >= 2 && name[0] == '\'' && name[len(name)-1] == '\'' {
+		name = name[1 : len(name)-1]
+	}
+	return strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(name)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSentenceGeneratorSentencesParse(t *testing.T) {
+	vocabulary := newListParser(nil)
+	g := NewSentenceGenerator(vocabulary.GetATN(), 1)
+	g.SetMaxTokens(12)
+	lengths := make(map[int]bool)
+	for i := 0; i < 50; i++ {
+		sentence := g.Generate(listRuleS)
+		lengths[len(sentence)] = true
+		if len(sentence) > 14 {
+			t.Errorf("sentence of %d tokens", len(sentence))
+		}
+		tokens := make([]Token, 0, len(sentence)+1)
+		for j, ttype := range sentence {
+			tokens = append(tokens, newTestToken(ttype, j, j, 1, j))
+		}
+		tokens = append(tokens, newTestToken(TokenEOF, len(sentence), len(sentence)-1, 1, len(sentence)))
+		stream := NewCommonTokenStream(nil, TokenDefaultChannel)
+		stream.SetTokenSource(&sliceTokenSource{tokens: tokens})
+		p := newListParser(stream)
+		errors := new(countingErrorListener)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(errors)
+		if p.S(); errors.errors != 0 {
+			t.Errorf("sentence %v does not parse", sentence)
+		}
+	}
+	if len(lengths) < 3 {
+		t.Errorf("the sentences have only the lengths %v", lengths)
+	}
+
+	text := g.GenerateText(listRuleItem, vocabulary)
+	if text != "ID" && text != "ID + ID" {
+		t.Errorf("GenerateText() = %q", text)
+	}
+}
+
+func TestSentenceGeneratorLimits(t *testing.T) {
+	// e : '+' e | ID ;  r : r ID ;
+	atn := buildATN(listWS, [][][]atnElement{
+		{bAlt(bTok(listPLUS), bRule(0)), bAlt(bTok(listID))},
+		{bAlt(bRule(1), bTok(listID))},
+	})
+	g := NewSentenceGenerator(atn, 3)
+	g.SetMaxDepth(3)
+	deepest := 0
+	for i := 0; i < 50; i++ {
+		sentence := g.Generate(0)
+		// the sentence is some '+' followed by ID, and the nesting of e within e stops at the maximum depth
+		if len(sentence) == 0 || len(sentence) > 4 || sentence[len(sentence)-1] != listID {
+			t.Fatalf("sentence %v", sentence)
+		}
+		deepest = max(deepest, len(sentence)-1)
+	}
+	if deepest != 3 {
+		t.Errorf("the deepest sentence nests %d times, want 3", deepest)
+	}
+
+	if sentence := g.Generate(1); sentence != nil {
+		t.Errorf("a rule that matches no finite input generated %v", sentence)
+	}
+
+	// the same seed generates the same sentences
+	a, b := NewSentenceGenerator(atn, 9), NewSentenceGenerator(atn, 9)
+	for i := 0; i < 10; i++ {
+		if x, y := a.Generate(0), b.Generate(0); !slices.Equal(x, y) {
+			t.Fatalf("the sentences %v and %v differ", x, y)
+		}
+	}
+
+	defer func() {
+		if r := recover(); r == nil || !strings.Contains(r.(string), "parser ATN") {
+			t.Errorf("a lexer ATN was accepted: %v", r)
+		}
+	}()
+	NewSentenceGenerator(newListLexer(nil).GetATN(), 1)
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+// terminalListener records the text of the terminals it visits.
+type terminalListener struct {
+	BaseParseTreeListener
+	texts []string
+}
+
+func (l *terminalListener) VisitTerminal(node TerminalNode) {
+	l.texts = append(l.texts, node.GetText())
+}
+
+func (l *terminalListener) VisitErrorNode(node ErrorNode) {
+	l.texts = append(l.texts, "!"+node.GetText())
+}
+
+func TestSkipSubtree(t *testing.T) {
+	skipB := func(ctx ParserRuleContext) bool { return ctx.GetStart().GetText() == "b" }
+	tests := []struct {
+		input, tree, visited, skipped string
+	}{
+		{"a b + c d", "(s (item a) item (item d) <EOF>)", "a b + c d <EOF>", "b+c"},
+		{"b", "(s item <EOF>)", "b <EOF>", "b"},
+		// errors are recovered from within the skipped subtree, without error nodes
+		{"b + + c a", "(s item (item a) <EOF>)", "b + !+ c a <EOF>", "b++c"},
+		{"a + c", "(s (item a + c) <EOF>)", "a + c <EOF>", ""},
+	}
+	for _, test := range tests {
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(test.input)), TokenDefaultChannel))
+		p.RemoveErrorListeners()
+		listener := &terminalListener{}
+		p.AddParseListener(listener)
+		p.SetSkipSubtree(listRuleItem, skipB)
+		tree := p.S()
+		if got := tree.ToStringTree(nil, p); got != test.tree {
+			t.Errorf("%q: tree %s, want %s", test.input, got, test.tree)
+		}
+		if got := strings.Join(listener.texts, " "); got != test.visited {
+			t.Errorf("%q: visited %s, want %s", test.input, got, test.visited)
+		}
+		skipped := ""
+		for _, child := range tree.GetChildren() {
+			if item, ok := child.(ParserRuleContext); ok && item.GetChildCount() == 0 && item.GetParent() == tree {
+				skipped = p.GetTokenStream().GetTextFromTokens(item.GetStart(), item.GetStop())
+			}
+		}
+		if skipped != test.skipped {
+			t.Errorf("%q: the skipped subtree spans %q, want %q", test.input, skipped, test.skipped)
+		}
+	}
+
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a b")), TokenDefaultChannel))
+	p.SetSkipSubtree(listRuleItem, skipB)
+	p.SetSkipSubtree(listRuleItem, nil)
+	if got := p.S().ToStringTree(nil, p); got != "(s (item a) (item b) <EOF>)" {
+		t.Errorf("without the filter: tree %s", got)
+	}
+}
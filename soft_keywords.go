@@ -0,0 +1,163 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// SoftKeywords declares keyword tokens that are keywords only where the parser expects them, and are
+// identifiers everywhere else. Many languages add keywords over time without reserving them, so that
+// existing programs that use them as names remain valid. Rather than duplicate each keyword in the grammar's
+// identifier rule, lex the keywords as usual, and install a SoftKeywords on the parser with
+// [BaseParser.SetSoftKeywords].
+//
+// Before each decision and each token match, the parser checks whether the current token is a soft keyword.
+// If the keyword is in the set of tokens that the parser expects at that point, it is left as it is. If
+// not, and the identifier token is expected instead, the token's type is changed to the identifier type. The
+// check is repeated at each decision until the token is consumed, so a token can change back again. A
+// keyword may also be restricted to a set of rules, outside of which it is always an identifier.
+//
+// The check looks only at the current token, so a decision that must look past a soft keyword to choose an
+// alternative sees it as whichever type it was last given. Only [CommonToken]s have their type changed, and
+// they are given back their keyword types when the parser is reset. A SoftKeywords keeps state about the
+// tokens it is resolving, so install each one on only one parser.
+//
+// Use:
+//
+//	keywords := antlr.NewSoftKeywords(parser.MyParserIDENTIFIER)
+//	keywords.AddKeyword(parser.MyParserASYNC)
+//	keywords.AddKeyword(parser.MyParserMATCH, parser.MyParserRULE_matchStatement)
+//	p.SetSoftKeywords(keywords)
+type SoftKeywords struct {
+	identifier int
+
+	// keywords maps each soft keyword type to the rules it is restricted to, or nil if it is not restricted
+	keywords map[int][]int
+
+	// original holds the keyword type of each token that has been changed to an identifier
+	original map[*CommonToken]int
+
+	// following caches, by ATN state number, the tokens that can follow each state within its rule, from
+	// which the tokens the parser expects are found without taking the lock of the ATN or allocating a set
+	following map[int]*IntervalSet
+}
+
+// NewSoftKeywords creates an empty set of soft keywords that are changed to the given identifier token type
+// where they are not expected.
+func NewSoftKeywords(identifier int) *SoftKeywords {
+	return &SoftKeywords{
+		identifier: identifier,
+		keywords:   make(map[int][]int),
+		original:   make(map[*CommonToken]int),
+		following:  make(map[int]*IntervalSet),
+	}
+}
+
+// AddKeyword declares the given token type to be a soft keyword. If any rule indexes are given, the keyword
+// is honored only while one of those rules is being parsed, including within any rules that it invokes.
+func (s *SoftKeywords) AddKeyword(keyword int, rules ...int) {
+	s.keywords[keyword] = rules
+}
+
+// IsSoftKeyword returns true if the given token type has been declared a soft keyword.
+func (s *SoftKeywords) IsSoftKeyword(ttype int) bool {
+	_, ok := s.keywords[ttype]
+	return ok
+}
+
+// KeywordType returns the keyword type of the given token if it is a soft keyword, even if the parser has
+// changed it to an identifier, and false otherwise.
+func (s *SoftKeywords) KeywordType(t Token) (int, bool) {
+	if c, ok := t.(*CommonToken); ok {
+		if keyword, ok := s.original[c]; ok {
+			return keyword, true
+		}
+	}
+	if s.IsSoftKeyword(t.GetTokenType()) {
+		return t.GetTokenType(), true
+	}
+	return TokenInvalidType, false
+}
+
+// resolve gives the current token of the parser the type that the parser expects, if it is a soft keyword.
+func (s *SoftKeywords) resolve(p *BaseParser) {
+	if p.input == nil || p.ctx == nil {
+		return
+	}
+	t, ok := p.input.LT(1).(*CommonToken)
+	if !ok {
+		return
+	}
+	keyword, retyped := s.original[t]
+	if !retyped {
+		if !s.IsSoftKeyword(t.tokenType) {
+			return
+		}
+		keyword = t.tokenType
+	}
+
+	ttype := keyword
+	if !s.inRules(p.ctx, s.keywords[keyword]) {
+		ttype = s.identifier
+	} else if keywordExpected, identifierExpected := s.expected(p, keyword); !keywordExpected && identifierExpected {
+		ttype = s.identifier
+	}
+
+	t.tokenType = ttype
+	if ttype == keyword {
+		delete(s.original, t)
+	} else {
+		s.original[t] = keyword
+	}
+}
+
+// expected returns whether the parser expects keyword, and whether it expects the identifier, at its state
+// and context, as ATN.getExpectedTokens would tell.
+func (s *SoftKeywords) expected(p *BaseParser, keyword int) (keywordExpected, identifierExpected bool) {
+	atn := p.Interpreter.atn
+	following := s.followingTokens(atn, p.GetState())
+	var ctx RuleContext = p.ctx
+	for {
+		keywordExpected = keywordExpected || following.contains(keyword)
+		identifierExpected = identifierExpected || following.contains(s.identifier)
+		if !following.contains(TokenEpsilon) || isNilContext(ctx) || ctx.GetInvokingState() < 0 {
+			return
+		}
+		rt := atn.states[ctx.GetInvokingState()].GetTransitions()[0].(*RuleTransition)
+		following = s.followingTokens(atn, rt.followState.GetStateNumber())
+		ctx = parentContext(ctx)
+	}
+}
+
+// followingTokens returns the tokens that can follow the given ATN state within its rule.
+func (s *SoftKeywords) followingTokens(atn *ATN, stateNumber int) *IntervalSet {
+	following, ok := s.following[stateNumber]
+	if !ok {
+		following = atn.NextTokensNoContext(atn.states[stateNumber])
+		s.following[stateNumber] = following
+	}
+	return following
+}
+
+// reset gives the tokens changed to identifiers their keyword types back, as the parser is about to read
+// them again from the start, and forgets them.
+func (s *SoftKeywords) reset() {
+	for t, keyword := range s.original {
+		t.tokenType = keyword
+	}
+	clear(s.original)
+}
+
+// inRules returns true if there are no rules, or if one of the rules is on the invocation stack of ctx.
+func (s *SoftKeywords) inRules(ctx RuleContext, rules []int) bool {
+	if len(rules) == 0 {
+		return true
+	}
+	for ; !isNilContext(ctx); ctx = parentContext(ctx) {
+		for _, rule := range rules {
+			if ctx.GetRuleIndex() == rule {
+				return true
+			}
+		}
+	}
+	return false
+}
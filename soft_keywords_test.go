@@ -0,0 +1,73 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestSoftKeywords(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		// '+' is a keyword where item expects it
+		{"a + b", "(s (item a + b) <EOF>)"},
+		// and an identifier where it is not expected
+		{"+ a", "(s (item +) (item a) <EOF>)"},
+		{"a + + b", "(s (item a + +) (item b) <EOF>)"},
+	}
+	for _, test := range tests {
+		lexer := newListLexer(NewInputStream(test.input))
+		p := newListParser(NewCommonTokenStream(lexer, TokenDefaultChannel))
+		errors := new(countingErrorListener)
+		p.RemoveErrorListeners()
+		p.AddErrorListener(errors)
+		keywords := NewSoftKeywords(listID)
+		keywords.AddKeyword(listPLUS)
+		p.SetSoftKeywords(keywords)
+		if got := p.S().ToStringTree(nil, p); got != test.want || errors.errors != 0 {
+			t.Errorf("%q: tree %s with %d errors, want %s", test.input, got, errors.errors, test.want)
+		}
+	}
+}
+
+func TestSoftKeywordsRestoredOnReset(t *testing.T) {
+	stream := NewCommonTokenStream(newListLexer(NewInputStream("a + + b")), TokenDefaultChannel)
+	p := newListParser(stream)
+	keywords := NewSoftKeywords(listID)
+	keywords.AddKeyword(listPLUS)
+	p.SetSoftKeywords(keywords)
+	p.S()
+
+	plus := stream.Get(2)
+	if plus.GetTokenType() != listID {
+		t.Fatalf("the second '+' has type %d, want ID", plus.GetTokenType())
+	}
+	if keyword, ok := keywords.KeywordType(plus); !ok || keyword != listPLUS {
+		t.Errorf("KeywordType() = %d, %v, want PLUS", keyword, ok)
+	}
+	if _, ok := keywords.KeywordType(stream.Get(0)); ok || keywords.IsSoftKeyword(listID) {
+		t.Error("an identifier is a soft keyword")
+	}
+
+	p.reset()
+	if plus.GetTokenType() != listPLUS {
+		t.Errorf("the second '+' has type %d after a reset, want PLUS", plus.GetTokenType())
+	}
+}
+
+func TestSoftKeywordsInRules(t *testing.T) {
+	// a keyword restricted to the rule s is honored within item, which s invokes, while one restricted to
+	// item is an identifier where s decides whether another item follows
+	for _, rule := range []int{listRuleS, listRuleItem} {
+		p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a + b +")), TokenDefaultChannel))
+		p.RemoveErrorListeners()
+		keywords := NewSoftKeywords(listID)
+		keywords.AddKeyword(listPLUS, rule)
+		p.SetSoftKeywords(keywords)
+		if got := p.S().ToStringTree(nil, p); got != "(s (item a + b) (item +) <EOF>)" {
+			t.Errorf("rule %d: tree %s", rule, got)
+		}
+	}
+}
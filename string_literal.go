@@ -0,0 +1,279 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrInvalidLiteral is the error, possibly wrapped, returned when the text of a string literal is not valid
+// for its [LiteralSyntax], such as for an unknown escape or an unterminated literal.
+var ErrInvalidLiteral = errors.New("invalid string literal")
+
+// LiteralEscapes selects the escapes of a [LiteralSyntax], as a set of flags.
+type LiteralEscapes int
+
+const (
+	// LiteralCEscapes are the backslash escapes of C: \a, \b, \f, \n, \r, \t, \v, \\, \', \" and \?, one to
+	// three octal digits, and \x followed by one or two hexadecimal digits. Octal and hexadecimal escapes
+	// stand for bytes, as in Go, so that a sequence of them may spell out a UTF-8 encoded character.
+	LiteralCEscapes LiteralEscapes = 1 << iota
+
+	// LiteralUnicodeEscapes are \u followed by four hexadecimal digits, as in Java and JSON, where a pair of
+	// them that spell out a UTF-16 surrogate pair stand for one character, \U followed by eight, as in C and
+	// Python, and \u{ followed by one to six and }, as in JavaScript, Rust and Swift. A surrogate that is not
+	// part of a pair stands for the replacement character U+FFFD, as it cannot be held in a Go string.
+	// Without LiteralCEscapes, the backslash and the quote may still be escaped with a backslash.
+	LiteralUnicodeEscapes
+
+	// LiteralDoubledQuotes escapes the quote by doubling it, as in SQL and Pascal
+	LiteralDoubledQuotes
+)
+
+// LiteralSyntax describes how the string literals of a language are quoted and escaped, for unquoting the
+// text of a literal token, as in a lexer action or a visitor, and for quoting a string as a literal, as when
+// generating code. A backslash stands for itself unless the syntax has backslash escapes.
+//
+// Use:
+//
+//	s, err := antlr.CStringLiteral.Unquote(ctx.STRING().GetText())
+type LiteralSyntax struct {
+	// Delimiter is the quote that encloses a literal, or 0 if literals are not enclosed
+	Delimiter rune
+
+	// Escapes are the escapes that may appear in a literal
+	Escapes LiteralEscapes
+}
+
+var (
+	// CStringLiteral is the syntax of the string literals of C and C++, without the concatenation of
+	// adjacent literals, which a parser does. It also suits the string literals of Java, the double quoted
+	// literals of JavaScript, and those of JSON, but for the escape \/ of JSON.
+	CStringLiteral = LiteralSyntax{Delimiter: '"', Escapes: LiteralCEscapes | LiteralUnicodeEscapes}
+
+	// SQLStringLiteral is the syntax of the string literals of standard SQL, in which a backslash stands for
+	// itself
+	SQLStringLiteral = LiteralSyntax{Delimiter: '\'', Escapes: LiteralDoubledQuotes}
+)
+
+// backslashes returns true if the backslash is an escape character of the syntax.
+func (s LiteralSyntax) backslashes() bool {
+	return s.Escapes&(LiteralCEscapes|LiteralUnicodeEscapes) != 0
+}
+
+// Unquote returns the string that literal stands for, removing the quotes around it, if the syntax has a
+// quote, and replacing its escapes by the characters they stand for. It returns an error wrapping
+// [ErrInvalidLiteral] if literal is not enclosed in quotes, or has an escape that is not valid.
+func (s LiteralSyntax) Unquote(literal string) (string, error) {
+	if s.Delimiter == 0 {
+		return s.Unescape(literal)
+	}
+	quote := string(s.Delimiter)
+	if len(literal) < 2*len(quote) || !strings.HasPrefix(literal, quote) || !strings.HasSuffix(literal, quote) {
+		return "", fmt.Errorf("%w: %q is not enclosed in %s", ErrInvalidLiteral, literal, quote)
+	}
+	return s.Unescape(literal[len(quote) : len(literal)-len(quote)])
+}
+
+// Unescape returns the string that the text between the quotes of a literal stands for, replacing its escapes
+// by the characters they stand for. It returns an error wrapping [ErrInvalidLiteral] for an escape that is not
+// valid, or a quote that is not escaped.
+func (s LiteralSyntax) Unescape(text string) (string, error) {
+	if !strings.ContainsRune(text, '\\') && (s.Delimiter == 0 || !strings.ContainsRune(text, s.Delimiter)) {
+		return text, nil
+	}
+	var sb strings.Builder
+	sb.Grow(len(text))
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == s.Delimiter && s.Delimiter != 0:
+			if s.Escapes&LiteralDoubledQuotes == 0 || !strings.HasPrefix(text[i+size:], string(s.Delimiter)) {
+				return "", fmt.Errorf("%w: unescaped %c at offset %d", ErrInvalidLiteral, r, i)
+			}
+			sb.WriteRune(r)
+			i += 2 * size
+		case r == '\\' && s.backslashes():
+			n, err := s.unescape(&sb, text, i)
+			if err != nil {
+				return "", err
+			}
+			i += n
+		default:
+			sb.WriteString(text[i : i+size])
+			i += size
+		}
+	}
+	return sb.String(), nil
+}
+
+// unescape writes the character that the escape at offset i of text stands for to sb, and returns the length
+// of the escape.
+func (s LiteralSyntax) unescape(sb *strings.Builder, text string, i int) (int, error) {
+	invalid := func(reason string) (int, error) {
+		return 0, fmt.Errorf("%w: %s at offset %d", ErrInvalidLiteral, reason, i)
+	}
+	if i+1 >= len(text) {
+		return invalid("unterminated escape")
+	}
+	if r, size := utf8.DecodeRuneInString(text[i+1:]); r == '\\' || r == s.Delimiter {
+		sb.WriteRune(r)
+		return 1 + size, nil
+	}
+	c := text[i+1]
+	switch {
+	case (c == 'u' || c == 'U') && s.Escapes&LiteralUnicodeEscapes != 0:
+		r, n, ok := unescapeUnicode(text, i)
+		if !ok {
+			return invalid("invalid Unicode escape")
+		}
+		if utf16.IsSurrogate(r) {
+			// a surrogate pair is written as two escapes
+			if r < 0xDC00 {
+				if low, m, ok := unescapeUnicode(text, i+n); ok && n == 6 && m == 6 && c == 'u' && text[i+n+1] == 'u' {
+					if pair := utf16.DecodeRune(r, low); pair != utf8.RuneError {
+						sb.WriteRune(pair)
+						return n + m, nil
+					}
+				}
+			}
+			r = utf8.RuneError
+		}
+		sb.WriteRune(r)
+		return n, nil
+	case s.Escapes&LiteralCEscapes == 0:
+	case c >= '0' && c <= '7':
+		n, v := 1, 0
+		for ; n <= 3 && i+n < len(text) && text[i+n] >= '0' && text[i+n] <= '7'; n++ {
+			v = v*8 + int(text[i+n]-'0')
+		}
+		if v > 0xFF {
+			return invalid("octal escape out of range")
+		}
+		sb.WriteByte(byte(v))
+		return n, nil
+	case c == 'x':
+		n := 2
+		for ; n < 4 && i+n < len(text) && isHexDigit(text[i+n]); n++ {
+		}
+		if n == 2 {
+			return invalid("invalid hexadecimal escape")
+		}
+		v, _ := strconv.ParseUint(text[i+2:i+n], 16, 8)
+		sb.WriteByte(byte(v))
+		return n, nil
+	default:
+		if r, ok := cEscapes[c]; ok {
+			sb.WriteByte(r)
+			return 2, nil
+		}
+	}
+	return invalid(fmt.Sprintf("unknown escape \\%c", c))
+}
+
+// cEscapes maps the character after the backslash of each simple escape of C to the character it stands for.
+var cEscapes = map[byte]byte{
+	'a': '\a', 'b': '\b', 'f': '\f', 'n': '\n', 'r': '\r', 't': '\t', 'v': '\v', '\'': '\'', '"': '"', '?': '?',
+}
+
+// unescapeUnicode returns the code point of the Unicode escape at offset i of text, and the length of the
+// escape, or false if there is no valid escape there.
+func unescapeUnicode(text string, i int) (rune, int, bool) {
+	if i+1 >= len(text) || text[i] != '\\' {
+		return 0, 0, false
+	}
+	var digits string
+	n := 0
+	switch {
+	case text[i+1] == 'u' && strings.HasPrefix(text[i+2:], "{"):
+		end := strings.IndexByte(text[i+3:], '}')
+		if end < 1 || end > 6 {
+			return 0, 0, false
+		}
+		digits, n = text[i+3:i+3+end], end+4
+	case text[i+1] == 'u' && i+6 <= len(text):
+		digits, n = text[i+2:i+6], 6
+	case text[i+1] == 'U' && i+10 <= len(text):
+		digits, n = text[i+2:i+10], 10
+	default:
+		return 0, 0, false
+	}
+	for j := 0; j < len(digits); j++ {
+		if !isHexDigit(digits[j]) {
+			return 0, 0, false
+		}
+	}
+	v, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || v > unicode.MaxRune {
+		return 0, 0, false
+	}
+	return rune(v), n, true
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// Quote returns text as a literal of the syntax: enclosed in its quotes, if it has one, and escaped, see
+// [LiteralSyntax.Escape].
+func (s LiteralSyntax) Quote(text string) string {
+	if s.Delimiter == 0 {
+		return s.Escape(text)
+	}
+	return string(s.Delimiter) + s.Escape(text) + string(s.Delimiter)
+}
+
+// Escape returns text with the characters that cannot appear as they are in a literal of the syntax escaped:
+// the quote, doubled if the syntax doubles it, and otherwise escaped with a backslash, and if the syntax has
+// backslash escapes, the backslash, and the control characters, as \n, \r and \t if it has the escapes of C,
+// and the others as \u followed by four hexadecimal digits, or if it has only the escapes of C, as three octal
+// digits, which unlike a hexadecimal escape cannot run into a digit that follows it. Other characters are kept
+// as they are. Escaping and then unescaping text gives back the text.
+func (s LiteralSyntax) Escape(text string) string {
+	backslashes := s.backslashes()
+	var sb strings.Builder
+	sb.Grow(len(text) + 2)
+	for _, r := range text {
+		switch {
+		case r == s.Delimiter && s.Delimiter != 0 && s.Escapes&LiteralDoubledQuotes != 0:
+			sb.WriteRune(r)
+			sb.WriteRune(r)
+		case r == s.Delimiter && s.Delimiter != 0 && backslashes, r == '\\' && backslashes:
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case !backslashes || !unicode.IsControl(r):
+			sb.WriteRune(r)
+		case r == '\n' && s.Escapes&LiteralCEscapes != 0:
+			sb.WriteString(`\n`)
+		case r == '\r' && s.Escapes&LiteralCEscapes != 0:
+			sb.WriteString(`\r`)
+		case r == '\t' && s.Escapes&LiteralCEscapes != 0:
+			sb.WriteString(`\t`)
+		case s.Escapes&LiteralUnicodeEscapes != 0:
+			sb.WriteString(escapeRune(r))
+		case r <= 0xFF:
+			// a C1 control character is escaped as its UTF-8 encoding
+			for _, b := range []byte(string(r)) {
+				o := strconv.FormatInt(int64(b), 8)
+				sb.WriteString(`\` + strings.Repeat("0", 3-len(o)) + o)
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// TokenValue decodes the text of a string literal token with [LiteralSyntax.Unquote], as a
+// [TokenValueFunc], such as for a [TokenValueFactory].
+func (s LiteralSyntax) TokenValue(t Token) (any, error) {
+	return s.Unquote(t.GetText())
+}
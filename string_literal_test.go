@@ -0,0 +1,95 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLiteralSyntaxUnquote(t *testing.T) {
+	cOnly := LiteralSyntax{Delimiter: '"', Escapes: LiteralCEscapes}
+	unicodeOnly := LiteralSyntax{Delimiter: '\'', Escapes: LiteralUnicodeEscapes}
+	tests := []struct {
+		syntax  LiteralSyntax
+		literal string
+		want    string
+	}{
+		{CStringLiteral, `"plain"`, "plain"},
+		{CStringLiteral, `""`, ""},
+		{CStringLiteral, `"a\tb\n\\\"\'\?\a"`, "a\tb\n\\\"'?\a"},
+		{CStringLiteral, `"\101\0\12x"`, "A\x00\nx"},
+		{CStringLiteral, `"\xe2\x82\xac\x4"`, "€\x04"},
+		{CStringLiteral, `"é\U0001F600\u{1F600}"`, "é😀😀"},
+		{CStringLiteral, `"😀"`, "😀"},
+		// surrogates that are not a pair stand for the replacement character
+		{CStringLiteral, `"\ud83d!\ude00"`, "\uFFFD!\uFFFD"},
+		{CStringLiteral, `"\ud83d\ude00"`, "😀"},
+		{SQLStringLiteral, `'it''s \n'`, `it's \n`},
+		{SQLStringLiteral, `''''`, "'"},
+		{unicodeOnly, `'A\'\\'`, `A'\`},
+		{cOnly, `"\101"`, "A"},
+		{LiteralSyntax{Escapes: LiteralCEscapes}, `a\tb"`, "a\tb\""},
+	}
+	for _, test := range tests {
+		if got, err := test.syntax.Unquote(test.literal); got != test.want || err != nil {
+			t.Errorf("Unquote(%s) = %q, %v, want %q", test.literal, got, err, test.want)
+		}
+	}
+
+	invalid := []struct {
+		syntax  LiteralSyntax
+		literal string
+	}{
+		{CStringLiteral, `"`}, {CStringLiteral, `plain`}, {CStringLiteral, `"a`}, {CStringLiteral, `"a"b"`},
+		{CStringLiteral, `"\"`}, {CStringLiteral, `"\q"`}, {CStringLiteral, `"\x"`}, {CStringLiteral, `"\400"`},
+		{CStringLiteral, `"\u12"`}, {CStringLiteral, `"\u{}"`}, {CStringLiteral, `"\u{110000}"`},
+		{CStringLiteral, `"\U0011000G"`}, {SQLStringLiteral, `'it's'`}, {cOnly, `"\u0041"`}, {unicodeOnly, `'\n'`},
+	}
+	for _, test := range invalid {
+		if got, err := test.syntax.Unquote(test.literal); !errors.Is(err, ErrInvalidLiteral) {
+			t.Errorf("Unquote(%s) = %q, %v, want an error", test.literal, got, err)
+		}
+	}
+}
+
+func TestLiteralSyntaxQuote(t *testing.T) {
+	tests := []struct {
+		syntax LiteralSyntax
+		text   string
+		want   string
+	}{
+		{CStringLiteral, "a\"b\\c\n\t\x01é", `"a\"b\\c\n\t\u0001é"`},
+		{SQLStringLiteral, "it's\\\n", "'it''s\\\n'"},
+		{LiteralSyntax{Delimiter: '"', Escapes: LiteralCEscapes}, "\x01\u0085" + "1", `"\001\302\2051"`},
+		{LiteralSyntax{Escapes: LiteralCEscapes}, "a\"\r", `a"\r`},
+	}
+	for _, test := range tests {
+		if got := test.syntax.Quote(test.text); got != test.want {
+			t.Errorf("Quote(%q) = %s, want %s", test.text, got, test.want)
+		}
+	}
+
+	// quoting and unquoting gives back the text
+	syntaxes := []LiteralSyntax{
+		CStringLiteral, SQLStringLiteral, {Delimiter: '"', Escapes: LiteralCEscapes},
+		{Delimiter: '\'', Escapes: LiteralUnicodeEscapes}, {Escapes: LiteralCEscapes | LiteralDoubledQuotes},
+	}
+	for _, syntax := range syntaxes {
+		for _, text := range []string{"", "plain", "\"'\\", "a\x00b\x7f\u0085 ", "é😀\t\r\n", "\\u0041", "''"} {
+			if got, err := syntax.Unquote(syntax.Quote(text)); got != text || err != nil {
+				t.Errorf("%+v: Unquote(%s) = %q, %v, want %q", syntax, syntax.Quote(text), got, err, text)
+			}
+		}
+	}
+}
+
+func TestLiteralSyntaxTokenValue(t *testing.T) {
+	token := newTestToken(listID, 0, 0, 1, 0)
+	token.SetText(`'a''b'`)
+	if value, err := SQLStringLiteral.TokenValue(token); value != "a'b" || err != nil {
+		t.Errorf("TokenValue() = %v, %v", value, err)
+	}
+}
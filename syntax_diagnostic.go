@@ -0,0 +1,224 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// DiagnosticSeverity is the severity of a [SyntaxDiagnostic], with the values of the DiagnosticSeverity of the
+// Language Server Protocol.
+type DiagnosticSeverity int
+
+const (
+	DiagnosticSeverityError       DiagnosticSeverity = 1
+	DiagnosticSeverityWarning     DiagnosticSeverity = 2
+	DiagnosticSeverityInformation DiagnosticSeverity = 3
+	DiagnosticSeverityHint        DiagnosticSeverity = 4
+)
+
+// DiagnosticCode identifies the kind of a [SyntaxDiagnostic], so that tools can tell errors apart, such as
+// to offer a quick fix for a missing token, without looking at the message, which is meant for people.
+type DiagnosticCode string
+
+const (
+	// DiagnosticNoViableAlternative is reported when prediction finds no alternative of a decision that
+	// matches the input
+	DiagnosticNoViableAlternative DiagnosticCode = "no-viable-alternative"
+
+	// DiagnosticMismatchedInput is reported when the current token is not the one the grammar requires
+	DiagnosticMismatchedInput DiagnosticCode = "mismatched-input"
+
+	// DiagnosticExtraneousInput is reported when the current token is skipped, as the token after it is
+	// the one the grammar requires
+	DiagnosticExtraneousInput DiagnosticCode = "extraneous-input"
+
+	// DiagnosticMissingToken is reported when the token the grammar requires is missing before the current
+	// token, and is conjured up to recover
+	DiagnosticMissingToken DiagnosticCode = "missing-token"
+
+	// DiagnosticFailedPredicate is reported when a semantic predicate that must hold fails
+	DiagnosticFailedPredicate DiagnosticCode = "failed-predicate"
+
+	// DiagnosticTokenRecognition is reported by a lexer for input that no token matches
+	DiagnosticTokenRecognition DiagnosticCode = "token-recognition"
+
+	// DiagnosticLoopDetected is reported when the recognizer finds it would loop without consuming input
+	DiagnosticLoopDetected DiagnosticCode = "loop-detected"
+
+	// DiagnosticSyntaxError is reported for any other syntax error, such as those reported by an error
+	// strategy of the application's own
+	DiagnosticSyntaxError DiagnosticCode = "syntax-error"
+)
+
+// SyntaxDiagnostic is a syntax error reported by a recognizer, with what is known about it in a structured
+// form, suited to the diagnostics of a language server, rather than only as a message and a position.
+type SyntaxDiagnostic struct {
+	// Range is the span of the text in error: the offending token, or for a lexer, the characters that no
+	// token matches
+	Range TextRange
+
+	// Severity is DiagnosticSeverityError for a syntax error
+	Severity DiagnosticSeverity
+
+	// Code identifies the kind of error, and Message describes it, as the message given to SyntaxError
+	Code    DiagnosticCode
+	Message string
+
+	// Source is the name of the grammar file of the recognizer that reported the error
+	Source string
+
+	// OffendingToken is the token in error, which is nil for an error reported by a lexer, and Tokens is
+	// the interval of token indexes of the input involved, which for a failed prediction runs from the token
+	// where prediction began to the offending token. For an error reported by a lexer, Tokens is the
+	// interval of characters in error.
+	OffendingToken Token
+	Tokens         Interval
+
+	// Expected is the set of token types that could have followed, or nil if it is not known
+	Expected *IntervalSet
+
+	// RuleStack holds the names of the rules being parsed when the error was reported, innermost first, as
+	// given by GetRuleInvocationStack
+	RuleStack []string
+
+	// Suggestions are the tokens that the offending token may have been meant to be, closest first, for
+	// mismatched input, see [InputMisMatchException.GetSuggestions]
+	Suggestions []TokenSuggestion
+
+	// Exception is the exception reported, which is nil for an error that the parser recovered from
+	// inline, by skipping or conjuring up a token
+	Exception RecognitionException
+}
+
+// SyntaxDiagnosticListener is an [ErrorListener] that hands each syntax error reported to it to a func as a
+// [SyntaxDiagnostic]. Other reports, such as of ambiguities, are ignored.
+//
+// Use:
+//
+//	var diagnostics []antlr.SyntaxDiagnostic
+//	listener := antlr.NewSyntaxDiagnosticListener(func(d antlr.SyntaxDiagnostic) {
+//	    diagnostics = append(diagnostics, d)
+//	})
+//	lexer.RemoveErrorListeners()
+//	lexer.AddErrorListener(listener)
+//	p.RemoveErrorListeners()
+//	p.AddErrorListener(listener)
+type SyntaxDiagnosticListener struct {
+	*DefaultErrorListener
+	report func(d SyntaxDiagnostic)
+}
+
+// NewSyntaxDiagnosticListener creates a [SyntaxDiagnosticListener] that hands each syntax error to report.
+func NewSyntaxDiagnosticListener(report func(d SyntaxDiagnostic)) *SyntaxDiagnosticListener {
+	return &SyntaxDiagnosticListener{DefaultErrorListener: NewDefaultErrorListener(), report: report}
+}
+
+func (l *SyntaxDiagnosticListener) SyntaxError(recognizer Recognizer, offendingSymbol interface{}, line, column int, msg string, e RecognitionException) {
+	l.report(NewSyntaxDiagnostic(recognizer, offendingSymbol, line, column, msg, e))
+}
+
+// NewSyntaxDiagnostic makes a [SyntaxDiagnostic] of the arguments of a call to [ErrorListener.SyntaxError], for
+// listeners that do not embed [SyntaxDiagnosticListener]. It must be called from within SyntaxError, while the
+// recognizer is where the error was found.
+func NewSyntaxDiagnostic(recognizer Recognizer, offendingSymbol interface{}, line, column int, msg string, e RecognitionException) SyntaxDiagnostic {
+	d := SyntaxDiagnostic{
+		Range:     TextRange{StartLine: line - 1, StartColumn: column, EndLine: line - 1, EndColumn: column, Start: -1, Stop: -2},
+		Severity:  DiagnosticSeverityError,
+		Message:   msg,
+		Exception: e,
+		Tokens:    NewInterval(-1, -2),
+	}
+	if named, ok := recognizer.(interface{ GetGrammarFileName() string }); ok {
+		d.Source = named.GetGrammarFileName()
+	}
+
+	if coded, ok := recognizer.(interface{ syntaxErrorCode() DiagnosticCode }); ok {
+		d.Code = coded.syntaxErrorCode()
+	}
+	if d.Code == "" {
+		switch e.(type) {
+		case *NoViableAltException:
+			d.Code = DiagnosticNoViableAlternative
+		case *InputMisMatchException:
+			d.Code = DiagnosticMismatchedInput
+		case *FailedPredicateException:
+			d.Code = DiagnosticFailedPredicate
+		case *LexerNoViableAltException:
+			d.Code = DiagnosticTokenRecognition
+		case *LoopDetectedException:
+			d.Code = DiagnosticLoopDetected
+		default:
+			d.Code = DiagnosticSyntaxError
+		}
+	}
+
+	if t, ok := offendingSymbol.(Token); ok && t != nil {
+		d.OffendingToken = t
+		d.Range = TokenTextRange(t)
+		d.Tokens = NewInterval(t.GetTokenIndex(), t.GetTokenIndex())
+		if nva, ok := e.(*NoViableAltException); ok && nva.startToken != nil && nva.offendingToken != nil {
+			d.Tokens = nva.GetOffendingInterval()
+		}
+	} else if lexer, ok := recognizer.(*BaseLexer); ok && lexer.TokenStartCharIndex >= 0 {
+		d.Range, d.Tokens = lexerErrorRange(lexer)
+	}
+
+	if p, ok := recognizer.(Parser); ok && p.GetParserRuleContext() != nil {
+		if expecting, ok := e.(interface{ getExpectedTokens() *IntervalSet }); ok {
+			d.Expected = expecting.getExpectedTokens()
+		} else if e == nil {
+			d.Expected = p.GetExpectedTokens()
+		}
+		d.RuleStack = p.GetRuleInvocationStack(nil)
+	}
+	if mismatch, ok := e.(*InputMisMatchException); ok {
+		d.Suggestions = mismatch.GetSuggestions()
+	}
+	return d
+}
+
+// lexerErrorRange returns the range and interval of the characters in error that the lexer reports, from the
+// start of the token it was matching to the current character, as in its message.
+func lexerErrorRange(lexer *BaseLexer) (TextRange, Interval) {
+	input := lexer.GetInputStream()
+	start, stop := lexer.TokenStartCharIndex, min(input.Index(), input.Size()-1)
+	r := TextRange{
+		StartLine:   lexer.TokenStartLine - 1,
+		StartColumn: lexer.TokenStartColumn,
+		Start:       start,
+		Stop:        stop,
+	}
+	r.EndLine, r.EndColumn = r.StartLine, r.StartColumn
+	for _, c := range input.GetText(start, stop) {
+		if c == '\n' {
+			r.EndLine++
+			r.EndColumn = 0
+		} else {
+			r.EndColumn++
+		}
+	}
+	return r, NewInterval(start, stop)
+}
+
+// notifyErrorListeners reports a syntax error to the error listeners of the parser, marked with code for
+// listeners that make a [SyntaxDiagnostic] of it.
+func notifyErrorListeners(recognizer Parser, code DiagnosticCode, msg string, offendingToken Token, e RecognitionException) {
+	if coded, ok := recognizer.(interface {
+		setSyntaxErrorCode(code DiagnosticCode) DiagnosticCode
+	}); ok {
+		previous := coded.setSyntaxErrorCode(code)
+		defer coded.setSyntaxErrorCode(previous)
+	}
+	recognizer.NotifyErrorListeners(msg, offendingToken, e)
+}
+
+// syntaxErrorCode returns the code of the syntax error being reported, if the error strategy gave one.
+func (p *BaseParser) syntaxErrorCode() DiagnosticCode {
+	return p.errorCode
+}
+
+// setSyntaxErrorCode sets the code of the syntax error about to be reported, and returns the code it replaces.
+func (p *BaseParser) setSyntaxErrorCode(code DiagnosticCode) DiagnosticCode {
+	previous := p.errorCode
+	p.errorCode = code
+	return previous
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"slices"
+	"testing"
+)
+
+// diagnosticListener returns a SyntaxDiagnosticListener that appends the diagnostics to those given.
+func diagnosticListener(diagnostics *[]SyntaxDiagnostic) *SyntaxDiagnosticListener {
+	return NewSyntaxDiagnosticListener(func(d SyntaxDiagnostic) { *diagnostics = append(*diagnostics, d) })
+}
+
+func TestSyntaxDiagnosticListener(t *testing.T) {
+	tests := []struct {
+		input     string
+		code      DiagnosticCode
+		message   string
+		r         TextRange
+		tokens    Interval
+		expected  string
+		ruleStack []string
+	}{
+		{"a + + b", DiagnosticExtraneousInput, "extraneous input '+' expecting ID",
+			TextRange{0, 4, 0, 5, 4, 4}, NewInterval(2, 2), "1", []string{"item", "s"}},
+		{"a +", DiagnosticMissingToken, "missing ID at '<EOF>'",
+			TextRange{0, 3, 0, 3, 3, 2}, NewInterval(2, 2), "1", []string{"item", "s"}},
+		{"+ a", DiagnosticExtraneousInput, "extraneous input '+' expecting {<EOF>, ID}",
+			TextRange{0, 0, 0, 1, 0, 0}, NewInterval(0, 0), "{<EOF>, 1}", []string{"s"}},
+		{"a $ b", DiagnosticTokenRecognition, "token recognition error at: '$'",
+			TextRange{0, 2, 0, 3, 2, 2}, NewInterval(2, 2), "", nil},
+		{"a b\n+ + c", DiagnosticTokenRecognition, "token recognition error at: '\n'",
+			TextRange{0, 3, 1, 0, 3, 3}, NewInterval(3, 3), "", nil},
+	}
+	for _, test := range tests {
+		var diagnostics []SyntaxDiagnostic
+		listener := diagnosticListener(&diagnostics)
+		lexer := newListLexer(NewInputStream(test.input))
+		lexer.RemoveErrorListeners()
+		lexer.AddErrorListener(listener)
+		p := newListParser(NewCommonTokenStream(lexer, TokenDefaultChannel))
+		p.RemoveErrorListeners()
+		p.AddErrorListener(listener)
+		p.S()
+		if len(diagnostics) == 0 {
+			t.Errorf("%q: no diagnostic", test.input)
+			continue
+		}
+		d := diagnostics[0]
+		if d.Code != test.code || d.Message != test.message || d.Range != test.r || d.Tokens != test.tokens ||
+			d.Severity != DiagnosticSeverityError || d.Source != "T.g4" || !slices.Equal(d.RuleStack, test.ruleStack) {
+			t.Errorf("%q: diagnostic %+v", test.input, d)
+		}
+		if expected := d.Expected; (expected == nil) != (test.expected == "") || expected != nil && expected.String() != test.expected {
+			t.Errorf("%q: expected %v, want %s", test.input, expected, test.expected)
+		}
+		// only the lexer reports an exception, as the parser recovers inline
+		if (d.OffendingToken == nil) != (d.Exception != nil) {
+			t.Errorf("%q: offending token %v, exception %v", test.input, d.OffendingToken, d.Exception)
+		}
+	}
+}
+
+func TestSyntaxDiagnosticNoViableAlternative(t *testing.T) {
+	// r : ID ID '+' | ID ID ID ;
+	atn := buildATN(listWS, [][][]atnElement{
+		{bAlt(bTok(listID), bTok(listID), bTok(listPLUS)), bAlt(bTok(listID), bTok(listID), bTok(listID))},
+	})
+	p := NewParserInterpreter("R.g4", []string{"", "", "'+'"}, []string{"", "ID", "PLUS", "WS"}, []string{"r"}, atn,
+		NewCommonTokenStream(newListLexer(NewInputStream("a b")), TokenDefaultChannel))
+	var diagnostics []SyntaxDiagnostic
+	p.RemoveErrorListeners()
+	p.AddErrorListener(diagnosticListener(&diagnostics))
+	p.Parse(0)
+	if len(diagnostics) != 1 {
+		t.Fatalf("%d diagnostics, want 1", len(diagnostics))
+	}
+	// the tokens run from the start of the prediction to the token where it failed
+	d := diagnostics[0]
+	if _, ok := d.Exception.(*NoViableAltException); !ok || d.Code != DiagnosticNoViableAlternative ||
+		d.Message != "no viable alternative at input 'ab'" || d.Tokens != NewInterval(0, 2) ||
+		d.OffendingToken.GetTokenType() != TokenEOF || d.Source != "R.g4" || !slices.Equal(d.RuleStack, []string{"r"}) {
+		t.Errorf("diagnostic %+v", d)
+	}
+
+	// an error reported by the application, rather than by the error strategy, has no code of its own
+	diagnostics = nil
+	p.NotifyErrorListeners("custom", p.GetCurrentToken(), nil)
+	if d := diagnostics[0]; d.Code != DiagnosticSyntaxError || d.Message != "custom" || d.Exception != nil ||
+		d.OffendingToken != p.GetCurrentToken() {
+		t.Errorf("diagnostic %+v", d)
+	}
+	if d := NewSyntaxDiagnostic(p, nil, 1, 5, "custom", nil); d.Code != DiagnosticSyntaxError ||
+		d.Range != (TextRange{0, 5, 0, 5, -1, -2}) || d.Tokens != NewInterval(-1, -2) || d.OffendingToken != nil {
+		t.Errorf("diagnostic %+v", d)
+	}
+}
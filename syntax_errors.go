@@ -0,0 +1,57 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "strconv"
+
+// SyntaxError is one of the [SyntaxErrors] of a parse, as collected by a [CollectingErrorListener].
+type SyntaxError = SyntaxErrorInfo
+
+// SyntaxErrors is the error returned for a parse that reported syntax errors, such as by
+// [BaseParser.ParseWithFallback], which holds each of the errors, in the order they were reported, so that
+// callers can handle a failed parse as an ordinary Go error and still report every error in detail. Its
+// message sums up the errors, and it wraps each of them, and so [ErrSyntax], for errors.Is and errors.As.
+//
+// Use:
+//
+//	tree, err := p.ParseWithFallback(func() antlr.ParseTree { return p.Start() })
+//	var errs antlr.SyntaxErrors
+//	if errors.As(err, &errs) {
+//	    for _, e := range errs {
+//	        fmt.Printf("%s:%d:%d: %s\n", name, e.Line, e.Column, e.Message)
+//	    }
+//	}
+type SyntaxErrors []SyntaxError
+
+// Error sums up the errors with the first of them, as in
+//
+//	3 syntax errors: line 1:4 mismatched input '+' expecting ID (and 2 more)
+func (s SyntaxErrors) Error() string {
+	switch len(s) {
+	case 0:
+		return "no syntax errors"
+	case 1:
+		return ErrSyntax.Error() + ": " + s[0].Error()
+	}
+	return strconv.Itoa(len(s)) + " syntax errors: " + s[0].Error() + " (and " + strconv.Itoa(len(s)-1) + " more)"
+}
+
+// Unwrap returns each of the errors, so that errors.Is and errors.As look at them all.
+func (s SyntaxErrors) Unwrap() []error {
+	errs := make([]error, len(s))
+	for i, e := range s {
+		errs[i] = e
+	}
+	return errs
+}
+
+// Err returns s as an error, or nil if it holds no errors, so that an empty SyntaxErrors is not returned as a
+// non-nil error.
+func (s SyntaxErrors) Err() error {
+	if len(s) == 0 {
+		return nil
+	}
+	return s
+}
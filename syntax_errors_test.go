@@ -0,0 +1,57 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		input, message string
+		errors         int
+	}{
+		{"a b + c", "", 0},
+		{"a + + b", "syntax error: line 1:4 extraneous input '+' expecting ID", 1},
+		{"a + + b $ c + + d", "3 syntax errors: line 1:4 extraneous input '+' expecting ID (and 2 more)", 3},
+	}
+	for _, test := range tests {
+		collector := NewCollectingErrorListener(0)
+		collectErrors(test.input, collector)
+		err := collector.SyntaxErrors()
+		if test.errors == 0 {
+			if err != nil {
+				t.Errorf("%q: SyntaxErrors() = %v", test.input, err)
+			}
+			continue
+		}
+		var errs SyntaxErrors
+		if err == nil || err.Error() != test.message || !errors.Is(err, ErrSyntax) || !errors.As(err, &errs) {
+			t.Errorf("%q: SyntaxErrors() = %v, want %s", test.input, err, test.message)
+			continue
+		}
+		if len(errs) != test.errors || len(errs.Unwrap()) != test.errors {
+			t.Errorf("%q: %d errors, want %d", test.input, len(errs), test.errors)
+		}
+		for i, e := range collector.Errors() {
+			if errs[i].String() != e.String() || errs[i].OffendingToken != e.OffendingToken {
+				t.Errorf("%q: error %d is %s, want %s", test.input, i, errs[i], e)
+			}
+		}
+		// each error is found on its own
+		var first SyntaxError
+		if !errors.As(err, &first) || first.String() != errs[0].String() {
+			t.Errorf("%q: errors.As() found %v, want %v", test.input, first, errs[0])
+		}
+	}
+
+	if err := SyntaxErrors(nil).Err(); err != nil {
+		t.Errorf("Err() of no errors = %v", err)
+	}
+	if got := SyntaxErrors(nil).Error(); got != "no syntax errors" {
+		t.Errorf("Error() of no errors = %q", got)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strconv"
+	"strings"
+)
+
+// TestRigOptions selects the output produced by [RunTestRig]. Each option corresponds to the option of the
+// same name of the Java TestRig, or grun.
+type TestRigOptions struct {
+	// Tokens dumps the tokens produced by the lexer, see [DumpTokens]
+	Tokens bool
+
+	// Tree renders the parse tree in LISP form, see [TreesStringTree]
+	Tree bool
+
+	// Dot renders the parse tree in the DOT language of Graphviz, in place of the TestRig's -gui option,
+	// see [TreesDotTree]
+	Dot bool
+
+	// Trace records the rule entry and exit events and the tokens consumed during the parse
+	Trace bool
+
+	// Diagnostics reports ambiguities and context sensitivities, using a [DiagnosticErrorListener] and
+	// [PredictionModeLLExactAmbigDetection]
+	Diagnostics bool
+
+	// SLL parses using [PredictionModeSLL] only
+	SLL bool
+}
+
+// TestRigResult holds the output of [RunTestRig]. Fields for output that was not selected are empty.
+type TestRigResult struct {
+	// Tree is the parse tree, or nil if no start rule was given
+	Tree ParseTree
+
+	// Tokens is the token dump
+	Tokens string
+
+	// TreeText is the parse tree in LISP form
+	TreeText string
+
+	// Dot is the parse tree in the DOT language
+	Dot string
+
+	// Trace is the trace of the parse, one event per line
+	Trace string
+
+	// Errors holds the syntax errors reported by the lexer and the parser, and any diagnostics, formatted as
+	// the console error listener formats them
+	Errors []string
+}
+
+// RunTestRig lexes and parses input with a lexer and parser created by the given constructors, starting at
+// the rule invoked by start, and returns the output selected by opts. It is the library equivalent of the
+// Java TestRig, so that the developer of a grammar with a Go target can check it without a JVM. If start is
+// nil, the input is only lexed, as when the TestRig is given the rule name tokens.
+//
+// Errors are collected in the result rather than printed, and nothing is written to standard output.
+//
+// Use:
+//
+//	result := antlr.RunTestRig(
+//	    func(input antlr.CharStream) antlr.Lexer { return parser.NewMyLexer(input) },
+//	    func(input antlr.TokenStream) antlr.Parser { return parser.NewMyParser(input) },
+//	    func(p antlr.Parser) antlr.ParseTree { return p.(*parser.MyParser).Start() },
+//	    "x = 1;", antlr.TestRigOptions{Tree: true, Diagnostics: true})
+//	fmt.Println(result.TreeText)
+//	for _, e := range result.Errors {
+//	    fmt.Println(e)
+//	}
+func RunTestRig(lexerCtor func(CharStream) Lexer, parserCtor func(TokenStream) Parser, start func(Parser) ParseTree,
+	input string, opts TestRigOptions) *TestRigResult {
+
+	result := &TestRigResult{}
+	errors := &testRigErrorListener{result: result}
+
+	lexer := lexerCtor(NewInputStream(input))
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(errors)
+	stream := NewCommonTokenStream(lexer, TokenDefaultChannel)
+
+	if opts.Tokens {
+		var sb strings.Builder
+		_ = DumpTokens(&sb, stream, lexer, nil)
+		result.Tokens = sb.String()
+	}
+	if start == nil {
+		stream.Fill()
+		return result
+	}
+
+	p := parserCtor(stream)
+	p.RemoveErrorListeners()
+	p.AddErrorListener(errors)
+	if opts.Diagnostics {
+		p.AddErrorListener(NewDiagnosticErrorListener(false))
+		p.GetInterpreter().SetPredictionMode(PredictionModeLLExactAmbigDetection)
+	}
+	if opts.SLL {
+		p.GetInterpreter().SetPredictionMode(PredictionModeSLL)
+	}
+	var trace strings.Builder
+	if l, ok := p.(interface{ AddParseListener(ParseTreeListener) }); ok && opts.Trace {
+		l.AddParseListener(&TraceListener{parser: p, out: &trace})
+	}
+
+	result.Tree = start(p)
+
+	result.Trace = trace.String()
+	if opts.Tree && result.Tree != nil {
+		result.TreeText = TreesStringTree(result.Tree, nil, p)
+	}
+	if opts.Dot && result.Tree != nil {
+		result.Dot = TreesDotTree(result.Tree, nil, p)
+	}
+	return result
+}
+
+// testRigErrorListener collects the errors reported during [RunTestRig].
+type testRigErrorListener struct {
+	*DefaultErrorListener
+	result *TestRigResult
+}
+
+func (l *testRigErrorListener) SyntaxError(_ Recognizer, _ interface{}, line, column int, msg string, _ RecognitionException) {
+	l.result.Errors = append(l.result.Errors, "line "+strconv.Itoa(line)+":"+strconv.Itoa(column)+" "+msg)
+}
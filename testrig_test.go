@@ -0,0 +1,71 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+// runListTestRig runs the TestRig over input with the list grammar, starting at the rule s.
+func runListTestRig(input string, start bool, opts TestRigOptions) *TestRigResult {
+	var s func(Parser) ParseTree
+	if start {
+		s = func(p Parser) ParseTree { return p.(*listParser).S() }
+	}
+	return RunTestRig(func(input CharStream) Lexer { return newListLexer(input) },
+		func(input TokenStream) Parser { return newListParser(input) }, s, input, opts)
+}
+
+func TestRunTestRig(t *testing.T) {
+	r := runListTestRig("a b+c", true, TestRigOptions{Tree: true, Dot: true, Trace: true})
+	if r.Tokens != "" || len(r.Errors) != 0 {
+		t.Errorf("tokens %q and errors %q", r.Tokens, r.Errors)
+	}
+	if r.TreeText != "(s (item a) (item b + c) <EOF>)" || r.TreeText != TreesStringTree(r.Tree, nil, newListParser(nil)) {
+		t.Errorf("tree %s", r.TreeText)
+	}
+	for _, line := range []string{"\tn0 [label=\"s\", shape=ellipse];\n", "\tn5 [label=\"+\", shape=box];\n", "\tn3 -> n5;\n"} {
+		if !strings.Contains(r.Dot, line) {
+			t.Errorf("no line %q in the DOT tree:\n%s", line, r.Dot)
+		}
+	}
+	trace := `enter   s, LT(1)=a
+enter   item, LT(1)=a
+consume [@0,0:0='a',<1>,1:0] rule item
+exit    item, LT(1)=b
+enter   item, LT(1)=b
+consume [@1,2:2='b',<1>,1:2] rule item
+consume [@2,3:3='+',<2>,1:3] rule item
+consume [@3,4:4='c',<1>,1:4] rule item
+exit    item, LT(1)=<EOF>
+consume [@4,5:4='<EOF>',<-1>,1:5] rule s
+exit    s, LT(1)=<EOF>
+`
+	if r.Trace != trace {
+		t.Errorf("trace:\n%s\nwant:\n%s", r.Trace, trace)
+	}
+
+	r = runListTestRig("a +", true, TestRigOptions{Diagnostics: true, SLL: true})
+	if r.Tree == nil || r.TreeText != "" || r.Dot != "" || r.Trace != "" {
+		t.Errorf("unselected output: %q %q %q", r.TreeText, r.Dot, r.Trace)
+	}
+	if len(r.Errors) != 1 || r.Errors[0] != "line 1:3 missing ID at '<EOF>'" {
+		t.Errorf("errors %q", r.Errors)
+	}
+}
+
+func TestRunTestRigTokensOnly(t *testing.T) {
+	r := runListTestRig("a -", false, TestRigOptions{Tokens: true, Tree: true})
+	if r.Tree != nil || r.TreeText != "" {
+		t.Errorf("the input was parsed into %s", r.TreeText)
+	}
+	if !strings.HasPrefix(r.Tokens, "INDEX  TYPE") || !strings.Contains(r.Tokens, "\n1      EOF ") {
+		t.Errorf("tokens:\n%s", r.Tokens)
+	}
+	if len(r.Errors) != 1 || r.Errors[0] != "line 1:2 token recognition error at: '-'" {
+		t.Errorf("errors %q", r.Errors)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// TextEscapePolicy selects the characters that [EscapeText] escapes, as a set of flags.
+type TextEscapePolicy int
+
+const (
+	// EscapeLineBreaksAndTabs escapes newline, carriage return and tab as \n, \r and \t
+	EscapeLineBreaksAndTabs TextEscapePolicy = 1 << iota
+
+	// EscapeSpaces shows spaces as the middle dot ·
+	EscapeSpaces
+
+	// EscapeControl escapes the other control characters as \u followed by four hexadecimal digits
+	EscapeControl
+
+	// EscapeNonASCII escapes characters outside ASCII as \u followed by four hexadecimal digits, or as \U
+	// followed by eight for those outside the Basic Multilingual Plane
+	EscapeNonASCII
+
+	// EscapeBackslashes escapes the backslash as \\, so that the escaped text can be told apart from text
+	// that contains escapes to begin with
+	EscapeBackslashes
+
+	// EscapeQuoted encloses the escaped text in single quotes
+	EscapeQuoted
+)
+
+const (
+	// EscapeTokenString is the policy of the String method of tokens and of [TreesStringTree]
+	EscapeTokenString = EscapeLineBreaksAndTabs
+
+	// EscapeErrorDisplay is the policy used to show token text in the messages of syntax errors
+	EscapeErrorDisplay = EscapeLineBreaksAndTabs | EscapeQuoted
+)
+
+// EscapeText escapes the characters of s selected by policy. With [EscapeTokenString] or
+// [EscapeErrorDisplay], the result is exactly the text that the runtime shows for a token, so tools that
+// write their own dumps can match the runtime, and golden files written with the runtime's output.
+//
+// Use:
+//
+//	fmt.Println(antlr.EscapeText(t.GetText(), antlr.EscapeErrorDisplay|antlr.EscapeControl))
+func EscapeText(s string, policy TextEscapePolicy) string {
+	var sb strings.Builder
+	sb.Grow(len(s) + 2)
+	if policy&EscapeQuoted != 0 {
+		sb.WriteByte('\'')
+	}
+	for _, r := range s {
+		switch {
+		case r == '\n' && policy&EscapeLineBreaksAndTabs != 0:
+			sb.WriteString(`\n`)
+		case r == '\r' && policy&EscapeLineBreaksAndTabs != 0:
+			sb.WriteString(`\r`)
+		case r == '\t' && policy&EscapeLineBreaksAndTabs != 0:
+			sb.WriteString(`\t`)
+		case r == ' ' && policy&EscapeSpaces != 0:
+			sb.WriteRune('·')
+		case r == '\\' && policy&EscapeBackslashes != 0:
+			sb.WriteString(`\\`)
+		case r != '\n' && r != '\r' && r != '\t' && unicode.IsControl(r) && policy&EscapeControl != 0,
+			r > unicode.MaxASCII && policy&EscapeNonASCII != 0:
+			sb.WriteString(escapeRune(r))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if policy&EscapeQuoted != 0 {
+		sb.WriteByte('\'')
+	}
+	return sb.String()
+}
+
+// escapeRune returns r as \u followed by four hexadecimal digits, or \U followed by eight if it does not fit
+// in four.
+func escapeRune(r rune) string {
+	if r > 0xFFFF {
+		h := strconv.FormatInt(int64(r), 16)
+		return `\U` + strings.Repeat("0", 8-len(h)) + h
+	}
+	h := strconv.FormatInt(int64(r), 16)
+	return `\u` + strings.Repeat("0", 4-len(h)) + h
+}
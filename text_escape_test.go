@@ -0,0 +1,51 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestEscapeText(t *testing.T) {
+	const text = "a b\t\\\n\r\x01é😀"
+	tests := []struct {
+		policy TextEscapePolicy
+		want   string
+	}{
+		{0, text},
+		{EscapeTokenString, "a b\\t\\\\n\\r\x01é😀"},
+		{EscapeErrorDisplay, "'a b\\t\\\\n\\r\x01é😀'"},
+		{EscapeSpaces, "a·b\t\\\n\r\x01é😀"},
+		{EscapeControl, "a b\t\\\n\r\\u0001é😀"},
+		{EscapeNonASCII, "a b\t\\\n\r\x01\\u00e9\\U0001f600"},
+		{EscapeBackslashes | EscapeLineBreaksAndTabs, "a b\\t\\\\\\n\\r\x01é😀"},
+	}
+	for _, test := range tests {
+		if got := EscapeText(text, test.policy); got != test.want {
+			t.Errorf("EscapeText(%d) = %q, want %q", test.policy, got, test.want)
+		}
+	}
+	if got := EscapeText("", EscapeQuoted); got != "''" {
+		t.Errorf("EscapeText() of no text = %q", got)
+	}
+	if got := EscapeWhitespace("a b\n", true); got != "a·b\\n" {
+		t.Errorf("EscapeWhitespace() = %q", got)
+	}
+}
+
+func TestEscapeTextMatchesRuntime(t *testing.T) {
+	token := newTestToken(listID, 0, 3, 1, 0)
+	token.SetText("a\tb\n")
+	if got, want := token.String(), "[@-1,0:3='"+EscapeText("a\tb\n", EscapeTokenString)+"',<1>,1:0]"; got != want {
+		t.Errorf("String() = %s, want %s", got, want)
+	}
+
+	want := EscapeText("a\tb\n", EscapeErrorDisplay)
+	p := newListParser(nil)
+	if got := p.GetErrorHandler().(*DefaultErrorStrategy).GetTokenErrorDisplay(token); got != want {
+		t.Errorf("GetTokenErrorDisplay() = %s, want %s", got, want)
+	}
+	if got := p.GetTokenErrorDisplay(token); got != want {
+		t.Errorf("the parser's GetTokenErrorDisplay() = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// TextRange is a span of source text, given as positions in the form used by the Language Server Protocol,
+// so that it can be passed to an editor as it stands. Unlike the lines of a [Token], which count from 1,
+// lines count from 0, and the end position is that of the character just after the span. Columns count
+// code points, which is what the LSP calls the utf-32 position encoding; a client that uses the default
+// utf-16 encoding must convert columns on lines that contain characters outside the Basic Multilingual
+// Plane.
+//
+// Start and Stop hold the character indexes of the first and last character of the span, as for a token.
+type TextRange struct {
+	StartLine, StartColumn int
+	EndLine, EndColumn     int
+	Start, Stop            int
+}
+
+// TokenTextRange returns the range of the text of a token.
+func TokenTextRange(t Token) TextRange {
+	line, column := tokenEnd(t)
+	return TextRange{
+		StartLine:   t.GetLine() - 1,
+		StartColumn: t.GetColumn(),
+		EndLine:     line - 1,
+		EndColumn:   column,
+		Start:       t.GetStart(),
+		Stop:        t.GetStop(),
+	}
+}
+
+// ContextTextRange returns the range of the text of a rule context, from the start of its first token to the
+// end of its last. If the context matched no tokens, the range is empty and lies at the start of the token
+// that follows it.
+func ContextTextRange(ctx ParserRuleContext) TextRange {
+	start, stop := ctx.GetStart(), ctx.GetStop()
+	if start == nil {
+		return TextRange{Start: -1, Stop: -2}
+	}
+	if stop == nil || stop.GetTokenIndex() < start.GetTokenIndex() {
+		r := TokenTextRange(start)
+		r.EndLine, r.EndColumn, r.Stop = r.StartLine, r.StartColumn, r.Start-1
+		return r
+	}
+	r := TokenTextRange(start)
+	end := TokenTextRange(stop)
+	r.EndLine, r.EndColumn, r.Stop = end.EndLine, end.EndColumn, end.Stop
+	return r
+}
+
+// tokenEnd returns the line and column of the character just after the text of t, following any line breaks
+// within it.
+func tokenEnd(t Token) (line, column int) {
+	line, column = t.GetLine(), t.GetColumn()
+	if t.GetTokenType() == TokenEOF {
+		return line, column
+	}
+	for _, r := range t.GetText() {
+		if r == '\n' {
+			line++
+			column = 0
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
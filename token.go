@@ -6,7 +6,6 @@ package antlr
 
 import (
 	"strconv"
-	"strings"
 )
 
 type TokenSourceCharStreamPair struct {
@@ -50,6 +49,7 @@ type BaseToken struct {
 	column     int    // beginning of the line at which it occurs, 0..n-1
 	text       string // text of the token.
 	readOnly   bool
+	value      *tokenValue // value decoded from the text, see TokenValueFactory
 }
 
 const (
@@ -121,6 +121,7 @@ func (b *BaseToken) GetText() string {
 
 func (b *BaseToken) SetText(text string) {
 	b.text = text
+	b.value = nil
 }
 
 func (b *BaseToken) GetTokenIndex() int {
@@ -142,9 +143,7 @@ func (b *BaseToken) GetInputStream() CharStream {
 func (b *BaseToken) String() string {
 	txt := b.GetText()
 	if txt != "" {
-		txt = strings.Replace(txt, "\n", "\\n", -1)
-		txt = strings.Replace(txt, "\r", "\\r", -1)
-		txt = strings.Replace(txt, "\t", "\\t", -1)
+		txt = EscapeText(txt, EscapeTokenString)
 	} else {
 		txt = "<no text>"
 	}
@@ -209,5 +208,6 @@ func (c *CommonToken) clone() *CommonToken {
 	t.line = c.GetLine()
 	t.column = c.GetColumn()
 	t.text = c.GetText()
+	t.value = c.value
 	return t
 }
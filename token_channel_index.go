@@ -0,0 +1,91 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// tokenChannelIndex is the index of the tokens on the channel of a [CommonTokenStream], see
+// [CommonTokenStream.SetChannelIndexing].
+type tokenChannelIndex struct {
+	// onChannel holds the indexes of the tokens on the channel, in order, and ranks, for each token, the
+	// number of tokens on the channel before it
+	onChannel []int
+	ranks     []int
+}
+
+// add indexes t, the token after those already indexed, which is on the channel if onChannel is true.
+func (x *tokenChannelIndex) add(t Token, onChannel bool) {
+	x.ranks = append(x.ranks, len(x.onChannel))
+	if onChannel {
+		x.onChannel = append(x.onChannel, t.GetTokenIndex())
+	}
+}
+
+// SetChannelIndexing turns the index of the tokens on the channel of the stream on or off. While it is on,
+// the stream keeps, as it fetches tokens, the positions of those on its channel, so that LT and LB, and
+// consuming a token, find the next or previous token on the channel at once, rather than by skipping the
+// tokens on other channels one at a time. This speeds up the parsing of input with many tokens that the
+// parser does not see, such as comments and whitespace sent to the hidden channel, at the cost of two ints
+// per token. The tokens already fetched are indexed when it is turned on.
+//
+// Use:
+//
+//	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+//	stream.SetChannelIndexing(true)
+func (c *CommonTokenStream) SetChannelIndexing(enabled bool) {
+	if !enabled {
+		c.channelIndex = nil
+		return
+	}
+	if c.channelIndex == nil {
+		c.channelIndex = &tokenChannelIndex{}
+		c.reindexChannel()
+	}
+}
+
+// IsChannelIndexing returns true if the tokens on the channel of the stream are indexed. See
+// [CommonTokenStream.SetChannelIndexing].
+func (c *CommonTokenStream) IsChannelIndexing() bool {
+	return c.channelIndex != nil
+}
+
+// reindexChannel indexes the tokens fetched again, if the index is on, as when they are replaced.
+func (c *CommonTokenStream) reindexChannel() {
+	x := c.channelIndex
+	if x == nil {
+		return
+	}
+	x.onChannel = x.onChannel[:0]
+	x.ranks = x.ranks[:0]
+	for _, t := range c.tokens {
+		x.add(t, t.GetChannel() == c.channel)
+	}
+}
+
+// nextIndexedOnChannel returns the index of the first token on the channel of the stream at or after i, which
+// must have been fetched, or -1 if there is none before EOF, using the index.
+func (c *CommonTokenStream) nextIndexedOnChannel(i int) int {
+	x := c.channelIndex
+	if c.tokens[i].GetChannel() == c.channel {
+		return i
+	}
+	p := x.ranks[i]
+	for p >= len(x.onChannel) {
+		if c.fetch(1) == 0 {
+			return -1
+		}
+	}
+	return x.onChannel[p]
+}
+
+// previousIndexedOnChannel returns the index of the last token on the channel of the stream at or before i,
+// which must have been fetched, or -1 if there is none, using the index.
+func (c *CommonTokenStream) previousIndexedOnChannel(i int) int {
+	if c.tokens[i].GetChannel() == c.channel {
+		return i
+	}
+	if p := c.channelIndex.ranks[i] - 1; p >= 0 {
+		return c.channelIndex.onChannel[p]
+	}
+	return -1
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"testing"
+)
+
+// channelTokens returns tokens whose channels are given by channels, 0 for the default channel and 1 for the
+// hidden one, followed by EOF.
+func channelTokens(channels string) []Token {
+	tokens := make([]Token, 0, len(channels)+1)
+	for i, c := range channels {
+		t := newTestToken(listID, i, i, 1, i)
+		t.channel = int(c - '0')
+		tokens = append(tokens, t)
+	}
+	return append(tokens, newTestToken(TokenEOF, len(channels), len(channels)-1, 1, len(channels)))
+}
+
+// lookaround returns the tokens that LT and LB find from each token of the stream, as it is consumed.
+func lookaround(stream *CommonTokenStream) string {
+	var s []string
+	for {
+		for k := 1; k <= 3; k++ {
+			s = append(s, fmt.Sprint(stream.LT(k).GetTokenIndex()))
+			if t := stream.LB(k); t != nil {
+				s = append(s, fmt.Sprint(t.GetTokenIndex()))
+			} else {
+				s = append(s, "nil")
+			}
+		}
+		if stream.LA(1) == TokenEOF {
+			return fmt.Sprint(stream.Index(), s)
+		}
+		stream.Consume()
+	}
+}
+
+func TestChannelIndexing(t *testing.T) {
+	for _, channels := range []string{"", "0", "1", "0101", "110011", "1001110", "000", "111"} {
+		plain := NewCommonTokenStream(nil, TokenDefaultChannel)
+		plain.SetTokenSource(&sliceTokenSource{tokens: channelTokens(channels)})
+		want := lookaround(plain)
+
+		// the index is built as the tokens are fetched, or from those already fetched when it is turned on
+		for _, fetched := range []int{0, 2, len(channels) + 1} {
+			indexed := NewCommonTokenStream(nil, TokenDefaultChannel)
+			indexed.SetTokenSource(&sliceTokenSource{tokens: channelTokens(channels)})
+			if fetched > 0 {
+				indexed.LT(1)
+				indexed.fetch(fetched)
+			}
+			indexed.SetChannelIndexing(true)
+			if !indexed.IsChannelIndexing() {
+				t.Fatal("the channel index is off once turned on")
+			}
+			if got := lookaround(indexed); got != want {
+				t.Errorf("%q, %d tokens fetched: indexed %s, want %s", channels, fetched, got, want)
+			}
+		}
+	}
+
+	// the index follows the tokens when they are replaced
+	stream := NewCommonTokenStream(nil, TokenDefaultChannel)
+	stream.SetChannelIndexing(true)
+	stream.SetTokenSource(&sliceTokenSource{tokens: channelTokens("0110")})
+	stream.Fill()
+	stream.SetTokenSource(&sliceTokenSource{tokens: channelTokens("1010")})
+	plain := NewCommonTokenStream(nil, TokenDefaultChannel)
+	plain.SetTokenSource(&sliceTokenSource{tokens: channelTokens("1010")})
+	if got, want := lookaround(stream), lookaround(plain); got != want {
+		t.Errorf("once the token source is replaced: indexed %s, want %s", got, want)
+	}
+	stream.SetChannelIndexing(false)
+	if stream.IsChannelIndexing() {
+		t.Error("the channel index is on once turned off")
+	}
+}
+
+func TestIncrementalTokenStreamChannelIndexing(t *testing.T) {
+	text := "a b + c d"
+	stream := NewIncrementalTokenStream(newListLexer(nil), text, TokenDefaultChannel)
+	stream.SetChannelIndexing(true)
+	stream.Fill()
+	stream.Edit(editRange(text, 2, 2), "e + f")
+	want := NewCommonTokenStream(newListLexer(NewInputStream("a e + f + c d")), TokenDefaultChannel)
+	if got, want := lookaround(stream.CommonTokenStream), lookaround(want); got != want {
+		t.Errorf("once edited: indexed %s, want %s", got, want)
+	}
+}
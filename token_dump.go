@@ -0,0 +1,110 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+)
+
+// DumpTokensOptions selects the tokens written by [DumpTokens]. The zero value selects every token.
+type DumpTokensOptions struct {
+	// Channels, if not empty, restricts the dump to tokens on these channels
+	Channels []int
+
+	// Types, if not empty, restricts the dump to tokens of these types
+	Types []int
+}
+
+// DumpTokens writes a table of the tokens in a stream to w, one row per token, showing the token index, the
+// type name, the channel, the lexer mode, the line and column, and the text, quoted and with special
+// characters escaped. It is the library equivalent of the -tokens option of the Java TestRig.
+//
+// If the stream is a [CommonTokenStream], DumpTokens fills it, and otherwise dumps the tokens that the
+// stream has buffered. Type names are looked up in the vocabulary, typically the parser, and channel names
+// are those registered with [BaseRecognizer.RegisterChannel]; the vocabulary may be nil, in which case
+// numbers are shown. The mode is known, and is named as registered with [BaseRecognizer.RegisterMode],
+// only for tokens that DumpTokens itself fetches from a lexer, so pass a stream that has not yet been read.
+// opts may be nil to dump every token.
+//
+// Use:
+//
+//	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+//	err := antlr.DumpTokens(os.Stdout, stream, lexer, &antlr.DumpTokensOptions{Channels: []int{antlr.TokenDefaultChannel}})
+func DumpTokens(w io.Writer, stream TokenStream, vocabulary Recognizer, opts *DumpTokensOptions) error {
+	if opts == nil {
+		opts = &DumpTokensOptions{}
+	}
+	lexer, _ := stream.GetTokenSource().(interface {
+		ModeName(int) string
+		tokenStartMode() int
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "INDEX\tTYPE\tCHANNEL\tMODE\tPOSITION\tTEXT")
+	for i := 0; ; i++ {
+		mode := "-"
+		if c, ok := stream.(*CommonTokenStream); ok && i >= len(c.tokens) {
+			if !c.Sync(i) {
+				break
+			}
+			if lexer != nil {
+				mode = lexer.ModeName(lexer.tokenStartMode())
+			}
+		} else if i >= stream.Size() {
+			break
+		}
+
+		t := stream.Get(i)
+		if dumpSelected(opts.Channels, t.GetChannel()) && dumpSelected(opts.Types, t.GetTokenType()) {
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d:%d\t%s\n", t.GetTokenIndex(),
+				dumpTypeName(vocabulary, t.GetTokenType()), dumpChannelName(vocabulary, t.GetChannel()), mode,
+				t.GetLine(), t.GetColumn(), strconv.Quote(t.GetText()))
+		}
+		if t.GetTokenType() == TokenEOF {
+			break
+		}
+	}
+	return tw.Flush()
+}
+
+// dumpSelected returns true if the filter is empty or contains v.
+func dumpSelected(filter []int, v int) bool {
+	if len(filter) == 0 {
+		return true
+	}
+	for _, f := range filter {
+		if f == v {
+			return true
+		}
+	}
+	return false
+}
+
+// dumpTypeName returns the symbolic name of a token type, or else its literal name, or else its number.
+func dumpTypeName(vocabulary Recognizer, ttype int) string {
+	if ttype == TokenEOF {
+		return "EOF"
+	}
+	if vocabulary != nil {
+		if names := vocabulary.GetSymbolicNames(); ttype >= 0 && ttype < len(names) && names[ttype] != "" {
+			return names[ttype]
+		}
+		if names := vocabulary.GetLiteralNames(); ttype >= 0 && ttype < len(names) && names[ttype] != "" {
+			return names[ttype]
+		}
+	}
+	return strconv.Itoa(ttype)
+}
+
+// dumpChannelName returns the name of a channel if the vocabulary can provide one, or else its number.
+func dumpChannelName(vocabulary Recognizer, channel int) string {
+	if v, ok := vocabulary.(interface{ ChannelName(int) string }); ok {
+		return v.ChannelName(channel)
+	}
+	return strconv.Itoa(channel)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDumpTokens(t *testing.T) {
+	lexer := newListLexer(NewInputStream("a bc+d"))
+	var sb strings.Builder
+	if err := DumpTokens(&sb, NewCommonTokenStream(lexer, TokenDefaultChannel), lexer, nil); err != nil {
+		t.Fatal(err)
+	}
+	want := `INDEX  TYPE  CHANNEL                MODE          POSITION  TEXT
+0      ID    DEFAULT_TOKEN_CHANNEL  DEFAULT_MODE  1:0       "a"
+1      ID    DEFAULT_TOKEN_CHANNEL  DEFAULT_MODE  1:2       "bc"
+2      PLUS  DEFAULT_TOKEN_CHANNEL  DEFAULT_MODE  1:4       "+"
+3      ID    DEFAULT_TOKEN_CHANNEL  DEFAULT_MODE  1:5       "d"
+4      EOF   DEFAULT_TOKEN_CHANNEL  DEFAULT_MODE  1:6       "<EOF>"
+`
+	if got := sb.String(); got != want {
+		t.Errorf("DumpTokens():\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestDumpTokensFilters(t *testing.T) {
+	comment := newTestToken(listID, 2, 8, 1, 2)
+	comment.SetText("// x\ty")
+	comment.channel = 2
+	hidden := newTestToken(listWS, 1, 1, 1, 1)
+	hidden.SetText(" ")
+	hidden.channel = TokenHiddenChannel
+	plus := newTestToken(listPLUS, 0, 0, 1, 0)
+	plus.SetText("+")
+	eof := newTestToken(TokenEOF, 9, 8, 1, 9)
+	eof.SetText("<EOF>")
+	dump := func(vocabulary Recognizer, opts *DumpTokensOptions) string {
+		stream := NewCommonTokenStream(nil, TokenDefaultChannel)
+		stream.SetTokenSource(&sliceTokenSource{tokens: []Token{plus, hidden, comment, eof}})
+		var sb strings.Builder
+		if err := DumpTokens(&sb, stream, vocabulary, opts); err != nil {
+			t.Fatal(err)
+		}
+		return sb.String()
+	}
+
+	lexer := newListLexer(nil)
+	lexer.RegisterChannel("COMMENTS", 2)
+	want := `INDEX  TYPE  CHANNEL   MODE  POSITION  TEXT
+1      WS    HIDDEN    -     1:1       " "
+2      ID    COMMENTS  -     1:2       "// x\ty"
+`
+	if got := dump(lexer, &DumpTokensOptions{Channels: []int{TokenHiddenChannel, 2}}); got != want {
+		t.Errorf("DumpTokens() of the hidden channels:\n%s\nwant:\n%s", got, want)
+	}
+
+	// without a vocabulary, types and channels are numbers
+	want = `INDEX  TYPE  CHANNEL  MODE  POSITION  TEXT
+0      2     0        -     1:0       "+"
+3      EOF   0        -     1:9       "<EOF>"
+`
+	if got := dump(nil, &DumpTokensOptions{Types: []int{listPLUS, TokenEOF}}); got != want {
+		t.Errorf("DumpTokens() of PLUS and EOF:\n%s\nwant:\n%s", got, want)
+	}
+}
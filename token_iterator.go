@@ -0,0 +1,90 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+	"runtime/debug"
+)
+
+// TokenIterator steps through the tokens of a lexer one at a time, without buffering them in a token
+// stream. It is intended for simple token level tools, such as counters, highlighters and grep like
+// utilities, that have no need for a [CommonTokenStream]. Create one with [BaseLexer.Iterate].
+//
+// Use:
+//
+//	it := lexer.Iterate()
+//	for it.Next() {
+//	    fmt.Println(it.Token())
+//	}
+//	if err := it.Err(); err != nil {
+//	    ...
+//	}
+type TokenIterator struct {
+	lexer *BaseLexer
+	token Token
+	done  bool
+	errs  []error
+}
+
+// Iterate returns a [TokenIterator] over the remaining tokens of the lexer, up to but not including EOF.
+//
+// Token recognition errors reported while iterating are collected by the iterator, as well as being passed
+// to the lexer's error listeners as usual, and the lexer recovers from them and carries on. If the lexer
+// panics, the iterator stops and the panic is returned as an error.
+func (b *BaseLexer) Iterate() *TokenIterator {
+	return &TokenIterator{lexer: b}
+}
+
+// Next advances the iterator to the next token, which is then available from [TokenIterator.Token]. It
+// returns false once the lexer reaches EOF, or if the lexer panics.
+func (it *TokenIterator) Next() bool {
+	if it.done {
+		return false
+	}
+	it.token = it.nextToken()
+	if it.token == nil || it.token.GetTokenType() == TokenEOF {
+		it.token = nil
+		it.done = true
+		return false
+	}
+	return true
+}
+
+// Token returns the current token, or nil if [TokenIterator.Next] has not been called or has returned false.
+func (it *TokenIterator) Token() Token {
+	return it.token
+}
+
+// Err returns the errors reported by the lexer so far, joined into a single error, or nil if there were none.
+func (it *TokenIterator) Err() error {
+	return errors.Join(it.errs...)
+}
+
+// nextToken fetches the next token from the lexer, while listening for the errors that it reports.
+func (it *TokenIterator) nextToken() (t Token) {
+	b := it.lexer
+	listeners := b.listeners
+	b.listeners = append(listeners[:len(listeners):len(listeners)], &tokenIteratorListener{it: it})
+	defer func() {
+		b.listeners = listeners
+		if r := recover(); r != nil {
+			it.errs = append(it.errs, fmt.Errorf("panic in lexer: %v\n%s", r, debug.Stack()))
+			t = nil
+		}
+	}()
+	return b.Virt.NextToken()
+}
+
+// tokenIteratorListener records the syntax errors reported by the lexer of a [TokenIterator].
+type tokenIteratorListener struct {
+	*DefaultErrorListener
+	it *TokenIterator
+}
+
+func (l *tokenIteratorListener) SyntaxError(_ Recognizer, _ interface{}, line, column int, msg string, _ RecognitionException) {
+	l.it.errs = append(l.it.errs, fmt.Errorf("line %d:%d %s", line, column, msg))
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTokenIterator(t *testing.T) {
+	lexer := newListLexer(NewInputStream("a!b + c"))
+	listener := new(countingErrorListener)
+	lexer.RemoveErrorListeners()
+	lexer.AddErrorListener(listener)
+
+	var texts []string
+	it := lexer.Iterate()
+	if it.Token() != nil {
+		t.Error("an iterator has a token before Next")
+	}
+	for it.Next() {
+		texts = append(texts, it.Token().GetText())
+	}
+	if strings.Join(texts, " ") != "a b + c" || it.Token() != nil || it.Next() {
+		t.Errorf("tokens %q", texts)
+	}
+	if err := it.Err(); err == nil || err.Error() != "line 1:1 token recognition error at: '!'" {
+		t.Errorf("Err() = %v", err)
+	}
+	if listener.errors != 1 || len(lexer.listeners) != 1 {
+		t.Errorf("the listener of the lexer had %d errors", listener.errors)
+	}
+}
+
+// panickingLexer is a list lexer that panics after its first token.
+type panickingLexer struct {
+	*listLexer
+	tokens int
+}
+
+func (l *panickingLexer) NextToken() Token {
+	if l.tokens++; l.tokens > 1 {
+		panic("boom")
+	}
+	return l.listLexer.NextToken()
+}
+
+func TestTokenIteratorPanic(t *testing.T) {
+	lexer := &panickingLexer{listLexer: newListLexer(NewInputStream("a b"))}
+	lexer.Virt = lexer
+	it := lexer.Iterate()
+	if !it.Next() || it.Token().GetText() != "a" {
+		t.Fatal("no first token")
+	}
+	if it.Next() || it.Token() != nil {
+		t.Error("the iterator went on after a panic")
+	}
+	if err := it.Err(); err == nil || !strings.HasPrefix(err.Error(), "panic in lexer: boom") {
+		t.Errorf("Err() = %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "fmt"
+
+// ShiftTokenPosition moves token t by deltaOffset characters, deltaLines lines and deltaColumns columns, such
+// as to keep the positions of the tokens that follow an edit of the text they were lexed from in step with the
+// text, or to map the tokens of a fragment that a preprocessor has spliced into a larger text to their place
+// in it. Before the token is moved, its text is kept, see [SliceCharStream], as it would otherwise be taken
+// from its input stream at the new position. It panics if t does not embed [BaseToken], which [CommonToken]
+// does.
+func ShiftTokenPosition(t Token, deltaOffset, deltaLines, deltaColumns int) {
+	b, ok := t.(interface {
+		shiftPosition(deltaOffset, deltaLines, deltaColumns int)
+	})
+	if !ok {
+		panic(fmt.Sprintf("cannot shift the position of a token of type %T", t))
+	}
+	b.shiftPosition(deltaOffset, deltaLines, deltaColumns)
+}
+
+func (b *BaseToken) shiftPosition(deltaOffset, deltaLines, deltaColumns int) {
+	if b.text == "" {
+		if input := b.GetInputStream(); input != nil && b.start < input.Size() && b.stop < input.Size() {
+			b.text = SliceCharStream(input, NewInterval(b.start, b.stop))
+		} else {
+			b.text = b.GetText()
+		}
+	}
+	b.start += deltaOffset
+	b.stop += deltaOffset
+	b.line += deltaLines
+	b.column += deltaColumns
+}
+
+// ShiftTokens recomputes the positions of tokens, which must be in the order they were lexed in, such as the
+// tokens of a [CommonTokenStream], after an edit of the text they were lexed from, in which the characters of
+// edited were replaced by text. The edited range is given as an LSP editor gives it, with the Start and Stop
+// of the characters replaced, which for an insertion are the index of the character it is inserted before
+// and one less; see [TextRange].
+//
+// Tokens before the edit are left as they are. Tokens after it are moved, see [ShiftTokenPosition], by the
+// difference in the number of characters and lines, and those on the line where the edit ends also by the
+// difference in columns, so that their positions are those they would have if the new text were lexed again.
+// Tokens that overlap the edit cannot be moved, as their text has changed, and must be lexed again; ShiftTokens
+// returns the indexes in tokens of the first of them and one past the last, which are equal if there are
+// none, in which case they are the index of the first token moved.
+//
+// Use:
+//
+//	first, last := antlr.ShiftTokens(stream.GetAllTokens(), edited, change.Text)
+//	// lex the new text again in place of tokens[first:last]
+func ShiftTokens(tokens []Token, edited TextRange, text string) (first, last int) {
+	deltaOffset, deltaLines, deltaColumns := editDeltas(edited, text)
+
+	first = len(tokens)
+	last = len(tokens)
+	for i, t := range tokens {
+		switch {
+		case t.GetStop() < edited.Start && t.GetStart() <= edited.Stop:
+			// before the edit, as is an empty token, such as EOF, at the start of characters replaced
+			continue
+		case t.GetStart() <= edited.Stop:
+			// overlapping the edit
+			first = min(first, i)
+			continue
+		}
+		if first == len(tokens) {
+			first = i
+		}
+		if last == len(tokens) {
+			last = i
+		}
+		columns := 0
+		if t.GetLine()-1 == edited.EndLine {
+			columns = deltaColumns
+		}
+		ShiftTokenPosition(t, deltaOffset, deltaLines, columns)
+	}
+	return first, last
+}
+
+// editDeltas returns how far the characters after an edit, in which the characters of edited were replaced by
+// text, move: by deltaOffset characters and deltaLines lines, and those on the line where the edit ends also
+// by deltaColumns columns.
+func editDeltas(edited TextRange, text string) (deltaOffset, deltaLines, deltaColumns int) {
+	// the line and column of the end of the new text
+	endLine, endColumn := edited.StartLine, edited.StartColumn
+	inserted := 0
+	for _, r := range text {
+		inserted++
+		if r == '\n' {
+			endLine++
+			endColumn = 0
+		} else {
+			endColumn++
+		}
+	}
+	return inserted - (edited.Stop - edited.Start + 1), endLine - edited.EndLine, endColumn - edited.EndColumn
+}
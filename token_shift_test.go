@@ -0,0 +1,88 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+// lexAll returns all the tokens of text in the list grammar, where a newline is not a token but starts a line.
+func lexAll(text string) []Token {
+	lexer := newListLexer(NewInputStream(text))
+	lexer.RemoveErrorListeners()
+	stream := NewCommonTokenStream(lexer, TokenDefaultChannel)
+	stream.Fill()
+	return stream.GetAllTokens()
+}
+
+// editRange returns the range of the characters of text from start to stop inclusive.
+func editRange(text string, start, stop int) TextRange {
+	position := func(offset int) (line, column int) {
+		before := text[:offset]
+		return strings.Count(before, "\n"), offset - strings.LastIndex(before, "\n") - 1
+	}
+	r := TextRange{Start: start, Stop: stop}
+	r.StartLine, r.StartColumn = position(start)
+	r.EndLine, r.EndColumn = position(stop + 1)
+	return r
+}
+
+func TestShiftTokens(t *testing.T) {
+	tests := []struct {
+		text        string
+		start, stop int
+		replacement string
+		first, last int
+	}{
+		{"ab c d", 3, 3, "xyz", 1, 2},
+		{"ab c d", 2, 1, " e", 1, 1},
+		{"ab c d", 2, 3, "", 1, 2},
+		{"ab c", 4, 3, " f", 2, 2},
+		{"ab c", 0, 0, "b", 0, 1},
+		{"ab\nc d\ne", 3, 3, "x\ny", 1, 2},
+		{"ab\nc\nd e", 2, 3, "", 1, 2},
+		{"ab c\nd", 3, 4, "c\n\n", 1, 2},
+	}
+	for _, test := range tests {
+		tokens := lexAll(test.text)
+		first, last := ShiftTokens(tokens, editRange(test.text, test.start, test.stop), test.replacement)
+		if first != test.first || last != test.last {
+			t.Errorf("%q: ShiftTokens() = %d, %d, want %d, %d", test.text, first, last, test.first, test.last)
+			continue
+		}
+		// the tokens moved are those the new text lexes to
+		text := test.text[:test.start] + test.replacement + test.text[test.stop+1:]
+		relexed := lexAll(text)
+		for i, moved := range tokens[last:] {
+			want := relexed[len(relexed)-len(tokens)+last+i]
+			if moved.GetText() != want.GetText() || moved.GetStart() != want.GetStart() || moved.GetStop() != want.GetStop() ||
+				moved.GetLine() != want.GetLine() || moved.GetColumn() != want.GetColumn() {
+				t.Errorf("%q: token %d is %s, want %s", text, last+i, moved, want)
+			}
+		}
+		for i, kept := range tokens[:first] {
+			if kept.String() != relexed[i].String() {
+				t.Errorf("%q: token %d is %s, want %s", text, i, kept, relexed[i])
+			}
+		}
+	}
+}
+
+func TestShiftTokenPosition(t *testing.T) {
+	token := lexAll("ab cd")[1]
+	ShiftTokenPosition(token, 10, 2, 3)
+	if token.GetText() != "cd" || token.GetStart() != 13 || token.GetStop() != 14 || token.GetLine() != 3 ||
+		token.GetColumn() != 6 {
+		t.Errorf("the token was moved to %s", token)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("a token that does not embed BaseToken was moved")
+		}
+	}()
+	ShiftTokenPosition(struct{ Token }{token}, 1, 0, 1)
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrTokenPipeClosed is returned by [TokenPipe.PutToken] once the pipe has been closed, as when its consumer
+// has stopped reading.
+var ErrTokenPipeClosed = errors.New("token pipe closed")
+
+// TokenSink receives the tokens pushed to it by [PushTokens]. PutToken may block until the sink is ready
+// for the token, which holds the lexer back until the slowest sink catches up, and may return an error to
+// stop the lexer altogether.
+type TokenSink interface {
+	PutToken(t Token) error
+}
+
+// TokenSinkFunc adapts a func to a [TokenSink].
+type TokenSinkFunc func(t Token) error
+
+// PutToken calls f(t).
+func (f TokenSinkFunc) PutToken(t Token) error {
+	return f(t)
+}
+
+// PushTokens drives source, usually a lexer, to the end of its input, pushing each token it produces, EOF
+// included, to each of sinks in turn. It is the push counterpart of a token stream, which pulls tokens from
+// the lexer as the parser needs them, and lets a single lexer feed a pipeline, such as a parser and a search
+// indexer, without lexing the input twice. Give the parser a [TokenPipe] as one of the sinks.
+//
+// It returns the first error returned by a sink, and pushes no further tokens. Sinks that have a Close
+// method, as a TokenPipe has, are then closed, so that their consumers are not left waiting for tokens
+// that will never come.
+//
+// Use:
+//
+//	lexer := parser.NewMyLexer(input)
+//	pipe := antlr.NewTokenPipe(lexer, 256)
+//	go func() {
+//	    _ = antlr.PushTokens(lexer, pipe, antlr.TokenSinkFunc(index.AddToken))
+//	}()
+//	p := parser.NewMyParser(antlr.NewCommonTokenStream(pipe, antlr.TokenDefaultChannel))
+//	tree := p.Start()
+//	pipe.Close()
+func PushTokens(source TokenSource, sinks ...TokenSink) error {
+	for {
+		t := source.NextToken()
+		for _, sink := range sinks {
+			if err := sink.PutToken(t); err != nil {
+				for _, s := range sinks {
+					if c, ok := s.(interface{ Close() }); ok {
+						c.Close()
+					}
+				}
+				return err
+			}
+		}
+		if t.GetTokenType() == TokenEOF {
+			return nil
+		}
+	}
+}
+
+// TokenPipe is a bounded queue of tokens that is a [TokenSink] on one side and a [TokenSource] on the
+// other, so that a parser in one goroutine can consume the tokens pushed by [PushTokens] in another. Once
+// the queue is full, PutToken blocks until the consumer takes a token, so the lexer runs at most the size
+// of the queue ahead of the parser.
+//
+// The pipe embeds the lexer that the tokens are pushed from, so it can be passed to [NewCommonTokenStream]
+// in place of the lexer, and reports its input stream, source name and token factory, but the line and
+// position it reports are those of the end of the last token taken from it, as the lexer itself runs ahead.
+//
+// Close the pipe if the consumer stops before EOF, to release the goroutine pushing to it. Once the pipe is
+// closed, its consumer is given the tokens already queued, then EOF.
+type TokenPipe struct {
+	Lexer
+
+	tokens    chan Token
+	done      chan struct{}
+	closeOnce sync.Once
+
+	// last is the last token taken from the pipe, and eof the EOF token once it has been taken
+	last Token
+	eof  Token
+}
+
+var _ Lexer = &TokenPipe{}
+
+// NewTokenPipe creates a [TokenPipe] for tokens pushed from lexer that holds up to size tokens. A size of
+// 0 or less makes each PutToken wait for the consumer to take the token.
+func NewTokenPipe(lexer Lexer, size int) *TokenPipe {
+	if size < 0 {
+		size = 0
+	}
+	return &TokenPipe{
+		Lexer:  lexer,
+		tokens: make(chan Token, size),
+		done:   make(chan struct{}),
+	}
+}
+
+// PutToken queues t for the consumer, waiting for room in the queue if it is full. It returns
+// [ErrTokenPipeClosed] if the pipe is closed.
+func (p *TokenPipe) PutToken(t Token) error {
+	select {
+	case <-p.done:
+		return ErrTokenPipeClosed
+	default:
+	}
+	select {
+	case p.tokens <- t:
+		return nil
+	case <-p.done:
+		return ErrTokenPipeClosed
+	}
+}
+
+// Close closes the pipe. It may be called more than once, from either side.
+func (p *TokenPipe) Close() {
+	p.closeOnce.Do(func() {
+		close(p.done)
+	})
+}
+
+// NextToken returns the next token from the queue, waiting for one to be pushed if it is empty. After EOF,
+// it returns EOF again, as a lexer does.
+func (p *TokenPipe) NextToken() Token {
+	if p.eof != nil {
+		return p.eof
+	}
+	var t Token
+	select {
+	case t = <-p.tokens:
+	default:
+		select {
+		case t = <-p.tokens:
+		case <-p.done:
+			select {
+			case t = <-p.tokens:
+			default:
+				t = p.closedEOF()
+			}
+		}
+	}
+	p.last = t
+	if t.GetTokenType() == TokenEOF {
+		p.eof = t
+	}
+	return t
+}
+
+// closedEOF returns an EOF token at the end of the last token taken, for a pipe closed before EOF was
+// pushed.
+func (p *TokenPipe) closedEOF() Token {
+	source := &TokenSourceCharStreamPair{tokenSource: p, charStream: p.GetInputStream()}
+	start, line, column := 0, 1, 0
+	if p.last != nil {
+		source = p.last.GetSource()
+		start = p.last.GetStop() + 1
+		line, column = p.GetLine(), p.GetCharPositionInLine()
+	}
+	return p.GetTokenFactory().Create(source, TokenEOF, "<EOF>", TokenDefaultChannel, start, start-1, line, column)
+}
+
+// GetLine returns the line of the end of the last token taken from the pipe.
+func (p *TokenPipe) GetLine() int {
+	line, _ := p.endOfLast()
+	return line
+}
+
+// GetCharPositionInLine returns the position in its line of the end of the last token taken from the pipe.
+func (p *TokenPipe) GetCharPositionInLine() int {
+	_, column := p.endOfLast()
+	return column
+}
+
+// endOfLast returns the line and column just after the last token taken from the pipe.
+func (p *TokenPipe) endOfLast() (int, int) {
+	if p.last == nil {
+		return 1, 0
+	}
+	line, column := p.last.GetLine(), p.last.GetColumn()
+	if p.last.GetTokenType() == TokenEOF {
+		return line, column
+	}
+	for _, r := range p.last.GetText() {
+		if r == '\n' {
+			line++
+			column = 0
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
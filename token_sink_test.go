@@ -0,0 +1,109 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// tokenTexts returns the text of each token, with that of EOF.
+func tokenTexts(tokens []Token) []string {
+	texts := make([]string, len(tokens))
+	for i, t := range tokens {
+		texts[i] = t.GetText()
+	}
+	return texts
+}
+
+func TestPushTokens(t *testing.T) {
+	const input = "a b + c"
+	lexer := newListLexer(NewInputStream(input))
+	pipe := NewTokenPipe(lexer, 1)
+	var indexed []Token
+	pushed := make(chan error)
+	go func() {
+		pushed <- PushTokens(lexer, pipe, TokenSinkFunc(func(t Token) error {
+			indexed = append(indexed, t)
+			return nil
+		}))
+	}()
+	p := newListParser(NewCommonTokenStream(pipe, TokenDefaultChannel))
+	if got := p.S().ToStringTree(nil, p); got != "(s (item a) (item b + c) <EOF>)" {
+		t.Errorf("tree %s", got)
+	}
+	if err := <-pushed; err != nil {
+		t.Fatal(err)
+	}
+	pipe.Close()
+
+	if got := tokenTexts(indexed); strings.Join(got, " ") != "a b + c <EOF>" {
+		t.Errorf("the sink was given %q", got)
+	}
+	// the pipe reports the end of the last token taken, and EOF again after EOF
+	if line, column := pipe.GetLine(), pipe.GetCharPositionInLine(); line != 1 || column != 7 {
+		t.Errorf("the pipe is at %d:%d", line, column)
+	}
+	if eof := pipe.NextToken(); eof.GetTokenType() != TokenEOF {
+		t.Errorf("after EOF: %s", eof)
+	}
+}
+
+func TestPushTokensSinkError(t *testing.T) {
+	lexer := newListLexer(NewInputStream("a bb c"))
+	pipe := NewTokenPipe(lexer, 10)
+	stop := errors.New("stop")
+	n := 0
+	err := PushTokens(lexer, TokenSinkFunc(func(Token) error {
+		if n++; n == 3 {
+			return stop
+		}
+		return nil
+	}), pipe)
+	if !errors.Is(err, stop) {
+		t.Fatalf("PushTokens() = %v", err)
+	}
+	if err := pipe.PutToken(lexer.NextToken()); !errors.Is(err, ErrTokenPipeClosed) {
+		t.Errorf("PutToken() on a closed pipe = %v", err)
+	}
+	pipe.Close()
+
+	// the consumer is given the tokens queued before the error, then EOF at the end of the last of them
+	var texts []string
+	for tok := pipe.NextToken(); ; tok = pipe.NextToken() {
+		texts = append(texts, tok.GetText())
+		if tok.GetTokenType() == TokenEOF {
+			if tok.GetLine() != 1 || tok.GetColumn() != 4 || tok.GetStart() != 4 {
+				t.Errorf("EOF at %d:%d, index %d", tok.GetLine(), tok.GetColumn(), tok.GetStart())
+			}
+			break
+		}
+	}
+	if strings.Join(texts, " ") != "a bb <EOF>" {
+		t.Errorf("the consumer was given %q", texts)
+	}
+}
+
+func TestTokenPipeClosedByConsumer(t *testing.T) {
+	lexer := newListLexer(NewInputStream("a b c d e f"))
+	pipe := NewTokenPipe(lexer, 0)
+	pushed := make(chan error)
+	go func() { pushed <- PushTokens(lexer, pipe) }()
+	if first := pipe.NextToken(); first.GetText() != "a" {
+		t.Errorf("first token %s", first)
+	}
+	pipe.Close()
+	if err := <-pushed; !errors.Is(err, ErrTokenPipeClosed) {
+		t.Errorf("PushTokens() = %v", err)
+	}
+
+	// a pipe closed before any token is taken gives EOF at the start of its input
+	empty := NewTokenPipe(newListLexer(NewInputStream("a")), 4)
+	empty.Close()
+	if eof := empty.NextToken(); eof.GetTokenType() != TokenEOF || eof.GetLine() != 1 || eof.GetColumn() != 0 {
+		t.Errorf("EOF of an empty pipe %s", eof)
+	}
+}
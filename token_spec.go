@@ -0,0 +1,213 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// tokenSpecItem is one token of a spec given to CompareTokens: its type, and its text if the spec gives one.
+type tokenSpecItem struct {
+	ttype   int
+	text    string
+	hasText bool
+}
+
+// CompareTokens compares the tokens of stream with spec, a compact description of the tokens expected, and
+// returns a diff of the two, or the empty string if they match. It is meant for the unit tests of lexers,
+// which it makes readable, as expectations and diffs are written with the names of the token types rather
+// than their numbers:
+//
+//	ID:"x" '=' INT:"42" ';'
+//
+// The spec lists the tokens in order, separated by white space. Each token is given by the name of its type,
+// which is its symbolic name, its literal name, quotes included, or its number, followed optionally by a
+// colon and its text as a quoted Go string, in which case the text must match as well as the type. Names are
+// looked up in the vocabulary, typically the lexer. The EOF token is compared only if the spec ends with EOF.
+//
+// If channels are given, only the tokens on those channels are compared. If the stream is a
+// [CommonTokenStream], CompareTokens fills it. An error is returned only if the spec cannot be parsed.
+//
+// The diff lists the tokens of both, one per line, marking with - the tokens expected but not produced, and
+// with + the tokens produced but not expected:
+//
+//	  ID:"x"
+//	- '='
+//	+ ':'
+//	  INT:"42"
+func CompareTokens(stream TokenStream, vocabulary Recognizer, spec string, channels ...int) (string, error) {
+	expected, err := parseTokenSpec(spec, vocabulary)
+	if err != nil {
+		return "", err
+	}
+	withEOF := len(expected) > 0 && expected[len(expected)-1].ttype == TokenEOF
+
+	if c, ok := stream.(*CommonTokenStream); ok {
+		c.Fill()
+	}
+	var actual []Token
+	for i := 0; i < stream.Size(); i++ {
+		t := stream.Get(i)
+		if t.GetTokenType() == TokenEOF && !withEOF {
+			break
+		}
+		if dumpSelected(channels, t.GetChannel()) {
+			actual = append(actual, t)
+		}
+		if t.GetTokenType() == TokenEOF {
+			break
+		}
+	}
+
+	return diffTokenSpec(expected, actual, vocabulary), nil
+}
+
+// TokensTestingT is the part of [testing.T] used by [AssertTokens].
+type TokensTestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// AssertTokens compares the tokens of stream with spec, as [CompareTokens] does, and reports the diff as an
+// error of the test t if they do not match, or the error if the spec cannot be parsed. It returns true if
+// they match.
+//
+// Use:
+//
+//	func TestLexer(t *testing.T) {
+//	    lexer := parser.NewMyLexer(antlr.NewInputStream("x = 42;"))
+//	    stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+//	    antlr.AssertTokens(t, stream, lexer, `ID:"x" '=' INT:"42" ';' EOF`, antlr.TokenDefaultChannel)
+//	}
+func AssertTokens(t TokensTestingT, stream TokenStream, vocabulary Recognizer, spec string, channels ...int) bool {
+	t.Helper()
+	diff, err := CompareTokens(stream, vocabulary, spec, channels...)
+	if err != nil {
+		t.Errorf("invalid token spec: %v", err)
+		return false
+	}
+	if diff != "" {
+		t.Errorf("tokens do not match (-expected +actual):\n%s", diff)
+		return false
+	}
+	return true
+}
+
+// parseTokenSpec parses a spec given to CompareTokens.
+func parseTokenSpec(spec string, vocabulary Recognizer) ([]tokenSpecItem, error) {
+	names := map[string]int{"EOF": TokenEOF}
+	if vocabulary != nil {
+		names = tokenTypeMap(vocabulary.GetLiteralNames(), vocabulary.GetSymbolicNames())
+	}
+
+	var items []tokenSpecItem
+	rest := strings.TrimSpace(spec)
+	for rest != "" {
+		var name string
+		if rest[0] == '\'' {
+			// a literal name, which may hold white space, colons and escaped quotes
+			end := 1
+			for end < len(rest) && rest[end] != '\'' {
+				if rest[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(rest) {
+				return nil, fmt.Errorf("unterminated literal name at %q", rest)
+			}
+			name, rest = rest[:end+1], rest[end+1:]
+		} else {
+			end := strings.IndexAny(rest, ": \t\r\n")
+			if end < 0 {
+				end = len(rest)
+			}
+			name, rest = rest[:end], rest[end:]
+		}
+
+		item := tokenSpecItem{}
+		if ttype, ok := names[name]; ok {
+			item.ttype = ttype
+		} else if ttype, err := strconv.Atoi(name); err == nil {
+			item.ttype = ttype
+		} else {
+			return nil, fmt.Errorf("unknown token type %q", name)
+		}
+
+		if strings.HasPrefix(rest, ":") {
+			quoted, err := strconv.QuotedPrefix(rest[1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid text for token %s at %q", name, rest)
+			}
+			item.text, _ = strconv.Unquote(quoted)
+			item.hasText = true
+			rest = rest[1+len(quoted):]
+		}
+		if rest != "" && !strings.ContainsAny(rest[:1], " \t\r\n") {
+			return nil, fmt.Errorf("expected white space after token %s at %q", name, rest)
+		}
+		items = append(items, item)
+		rest = strings.TrimSpace(rest)
+	}
+	return items, nil
+}
+
+// matches returns true if t is the token described by the item.
+func (s tokenSpecItem) matches(t Token) bool {
+	return t.GetTokenType() == s.ttype && (!s.hasText || t.GetText() == s.text)
+}
+
+// diffTokenSpec returns a diff of the expected and actual tokens, based on their longest common subsequence,
+// or the empty string if they match.
+func diffTokenSpec(expected []tokenSpecItem, actual []Token, vocabulary Recognizer) string {
+	n, m := len(expected), len(actual)
+	// lcs[i][j] is the length of the longest common subsequence of expected[i:] and actual[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if expected[i].matches(actual[j]) {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = intMax(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	if lcs[0][0] == n && n == m {
+		return ""
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && expected[i].matches(actual[j]):
+			sb.WriteString("  " + tokenSpecString(vocabulary, actual[j].GetTokenType(), actual[j].GetText(), expected[i].hasText) + "\n")
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			// An expected token that is missing is listed before the actual token in its place
+			sb.WriteString("- " + tokenSpecString(vocabulary, expected[i].ttype, expected[i].text, expected[i].hasText) + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + tokenSpecString(vocabulary, actual[j].GetTokenType(), actual[j].GetText(), true) + "\n")
+			j++
+		}
+	}
+	return sb.String()
+}
+
+// tokenSpecString renders a token as it is written in a spec.
+func tokenSpecString(vocabulary Recognizer, ttype int, text string, withText bool) string {
+	name := dumpTypeName(vocabulary, ttype)
+	if !withText || ttype == TokenEOF {
+		return name
+	}
+	return name + ":" + strconv.Quote(text)
+}
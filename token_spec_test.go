@@ -0,0 +1,65 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"testing"
+)
+
+// recordingT records the errors reported by AssertTokens.
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestCompareTokens(t *testing.T) {
+	tests := []struct {
+		spec, diff string
+	}{
+		{`ID:"a" '+' ID:"bb" ID`, ""},
+		{`ID '+':"+" 1:"bb" ID:"c" EOF`, ""},
+		{`ID:"a" PLUS ID ID`, ""},
+		{`ID:"a" ID:"bb" ID`, "  ID:\"a\"\n+ PLUS:\"+\"\n  ID:\"bb\"\n  ID\n"},
+		{`ID:"a" '+' ID:"b" ID:"c"`, "  ID:\"a\"\n  PLUS\n- ID:\"b\"\n+ ID:\"bb\"\n  ID:\"c\"\n"},
+		{`ID '+' ID ID '+'`, "  ID\n  PLUS\n  ID\n  ID\n- PLUS\n"},
+		{`ID '+' ID ID EOF EOF`, "  ID\n  PLUS\n  ID\n  ID\n  EOF\n- EOF\n"},
+	}
+	for _, test := range tests {
+		lexer := newListLexer(NewInputStream("a+bb c"))
+		diff, err := CompareTokens(NewCommonTokenStream(lexer, TokenDefaultChannel), lexer, test.spec)
+		if err != nil || diff != test.diff {
+			t.Errorf("%s: diff\n%s%v\nwant\n%s", test.spec, diff, err, test.diff)
+		}
+	}
+
+	for _, spec := range []string{`ID:"a`, `'+`, `NUMBER`, `ID:a`, `ID:"a"'+'`} {
+		lexer := newListLexer(NewInputStream("a"))
+		if _, err := CompareTokens(NewCommonTokenStream(lexer, TokenDefaultChannel), lexer, spec); err == nil {
+			t.Errorf("%s: no error", spec)
+		}
+	}
+}
+
+func TestAssertTokens(t *testing.T) {
+	lexer := newListLexer(NewInputStream("a+b"))
+	stream := NewCommonTokenStream(lexer, TokenDefaultChannel)
+	rt := &recordingT{}
+	if !AssertTokens(rt, stream, lexer, `ID '+' ID:"b" EOF`, TokenDefaultChannel) || len(rt.errors) != 0 {
+		t.Errorf("the tokens did not match: %q", rt.errors)
+	}
+	if AssertTokens(rt, stream, lexer, `ID ID`) || len(rt.errors) != 1 ||
+		rt.errors[0] != "tokens do not match (-expected +actual):\n  ID\n+ PLUS:\"+\"\n  ID\n" {
+		t.Errorf("errors %q", rt.errors)
+	}
+	if AssertTokens(rt, stream, lexer, `ID:`) || len(rt.errors) != 2 {
+		t.Errorf("errors %q", rt.errors)
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// TokenStreamSummary sums up the tokens that a [CommonTokenStream] has fetched, for the metrics of a service
+// that parses many inputs, such as to chart the size of the inputs, or to notice one with an unusual share
+// of comments or of tokens that the lexer could not match.
+type TokenStreamSummary struct {
+	// Size is the number of tokens fetched, on every channel, including EOF once it has been fetched
+	Size int
+
+	// ByType counts the tokens fetched of each token type, and ByChannel those on each channel. Types and
+	// channels without tokens are left out.
+	ByType    map[int]int
+	ByChannel map[int]int
+
+	// Complete is true if EOF has been fetched, so that the counts are those of the whole input
+	Complete bool
+}
+
+// Summary returns the counts of the tokens fetched so far, by token type and by channel, as they were when
+// each token was fetched. The counts are kept as the tokens are fetched, so Summary costs only the maps it
+// returns; call [CommonTokenStream.Fill] first for the counts of the whole input, rather than of the tokens
+// that the parser has read.
+//
+// Use:
+//
+//	stream.Fill()
+//	summary := stream.Summary()
+//	metrics.Observe("tokens", summary.Size)
+//	metrics.Observe("comments", summary.ByChannel[antlr.TokenHiddenChannel])
+func (c *CommonTokenStream) Summary() TokenStreamSummary {
+	s := TokenStreamSummary{
+		Size:      len(c.tokens),
+		ByType:    make(map[int]int),
+		ByChannel: make(map[int]int),
+		Complete:  c.fetchedEOF,
+	}
+	for i, n := range c.counts.types {
+		if n > 0 {
+			s.ByType[i+TokenEOF] = n
+		}
+	}
+	for channel, n := range c.counts.channels {
+		if n > 0 {
+			s.ByChannel[channel] = n
+		}
+	}
+	return s
+}
+
+// tokenCounts holds the number of tokens of each type, indexed from [TokenEOF], and on each channel.
+type tokenCounts struct {
+	types    []int
+	channels []int
+}
+
+// add adds n to the counts of the type and the channel of t. Types below TokenEOF and negative channels,
+// which no lexer produces, are not counted.
+func (c *tokenCounts) add(t Token, n int) {
+	c.types = addTokenCount(c.types, t.GetTokenType()-TokenEOF, n)
+	c.channels = addTokenCount(c.channels, t.GetChannel(), n)
+}
+
+func addTokenCount(counts []int, i, n int) []int {
+	if i < 0 {
+		return counts
+	}
+	if i >= len(counts) {
+		counts = append(counts, make([]int, i+1-len(counts))...)
+	}
+	counts[i] += n
+	return counts
+}
+
+// reset sets the counts to zero, keeping the space allocated for them.
+func (c *tokenCounts) reset() {
+	clear(c.types)
+	clear(c.channels)
+}
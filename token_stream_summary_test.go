@@ -0,0 +1,66 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestTokenStreamSummary(t *testing.T) {
+	hidden := newTestToken(listWS, 1, 1, 1, 1)
+	hidden.channel = TokenHiddenChannel
+	tokens := []Token{
+		newTestToken(listID, 0, 0, 1, 0), hidden, newTestToken(listPLUS, 2, 2, 1, 2), newTestToken(listID, 3, 3, 1, 3),
+		newTestToken(TokenInvalidType, 4, 4, 1, 4), newTestToken(TokenEOF, 5, 4, 1, 5),
+	}
+	stream := NewCommonTokenStream(nil, TokenDefaultChannel)
+	stream.SetTokenSource(&sliceTokenSource{tokens: tokens})
+	if s := stream.Summary(); s.Size != 0 || len(s.ByType) != 0 || len(s.ByChannel) != 0 || s.Complete {
+		t.Errorf("summary of no tokens = %+v", s)
+	}
+
+	// the parser has read the first tokens only
+	stream.LT(2)
+	s := stream.Summary()
+	if s.Size != 3 || !maps.Equal(s.ByType, map[int]int{listID: 1, listWS: 1, listPLUS: 1}) ||
+		!maps.Equal(s.ByChannel, map[int]int{TokenDefaultChannel: 2, TokenHiddenChannel: 1}) || s.Complete {
+		t.Errorf("summary of the tokens read = %+v", s)
+	}
+
+	stream.Fill()
+	s = stream.Summary()
+	if s.Size != 6 || !maps.Equal(s.ByType, map[int]int{TokenEOF: 1, TokenInvalidType: 1, listID: 2, listWS: 1, listPLUS: 1}) ||
+		!maps.Equal(s.ByChannel, map[int]int{TokenDefaultChannel: 5, TokenHiddenChannel: 1}) || !s.Complete {
+		t.Errorf("summary of all the tokens = %+v", s)
+	}
+
+	stream.SetTokenSource(&sliceTokenSource{tokens: tokens[5:]})
+	stream.Fill()
+	if s := stream.Summary(); s.Size != 1 || !maps.Equal(s.ByType, map[int]int{TokenEOF: 1}) || !s.Complete {
+		t.Errorf("summary once the token source is replaced = %+v", s)
+	}
+}
+
+func TestIncrementalTokenStreamSummary(t *testing.T) {
+	text := "a b + c d"
+	stream := NewIncrementalTokenStream(newListLexer(nil), text, TokenDefaultChannel)
+	stream.Fill()
+	// the tokens lexed again replace those they were lexed from in the counts
+	for _, edit := range []struct {
+		start, stop int
+		text        string
+	}{{2, 2, "e + f"}, {4, 7, ""}, {0, -1, "g h "}} {
+		stream.Edit(editRange(text, edit.start, edit.stop), edit.text)
+		stream.Fill()
+		text = text[:edit.start] + edit.text + text[edit.stop+1:]
+		want := NewCommonTokenStream(newListLexer(NewInputStream(text)), TokenDefaultChannel)
+		want.Fill()
+		if got, want := stream.Summary(), want.Summary(); got.Size != want.Size || !maps.Equal(got.ByType, want.ByType) ||
+			!maps.Equal(got.ByChannel, want.ByChannel) {
+			t.Errorf("%q: summary %+v, want %+v", text, got, want)
+		}
+	}
+}
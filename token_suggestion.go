@@ -0,0 +1,141 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"slices"
+	"unicode"
+)
+
+// TokenSuggestion is a token that the text of an offending token may have been meant to be, such as the
+// keyword SELECT for SELCT, as found by a [TokenSuggester].
+type TokenSuggestion struct {
+	// TokenType is the type of the token suggested, and Text the text it matches, its literal name without
+	// the quotes
+	TokenType int
+	Text      string
+
+	// Distance is how far the text of the offending token is from Text, as measured by the suggester, with 0
+	// the closest
+	Distance int
+}
+
+// TokenSuggester suggests the tokens that the text of an offending token may have been meant to be, from the
+// set of tokens the parser expected, closest first, or returns nil if it has none. The [DefaultErrorStrategy]
+// asks it for suggestions when it reports mismatched input, see [DefaultErrorStrategy.SetTokenSuggester].
+type TokenSuggester interface {
+	SuggestTokens(recognizer Parser, offendingToken Token, expected *IntervalSet) []TokenSuggestion
+}
+
+// EditDistanceSuggester is a [TokenSuggester] that suggests the expected tokens whose literal names are within
+// a number of edits of the text of the offending token, an edit being the insertion, deletion or substitution
+// of a character, or the transposition of two adjacent characters. Letters are compared without regard to
+// case, so that a keyword typed in the wrong case is suggested with a distance of 0. Tokens without a literal
+// name, such as identifiers, are never suggested.
+type EditDistanceSuggester struct {
+	maxDistance int
+}
+
+// NewEditDistanceSuggester creates an [EditDistanceSuggester] that suggests the literals within maxDistance
+// edits of the offending text, or if maxDistance is 0 or less, within a third of the length of the literal,
+// so that short literals, such as operators, are suggested only for a difference of case.
+func NewEditDistanceSuggester(maxDistance int) *EditDistanceSuggester {
+	return &EditDistanceSuggester{maxDistance: maxDistance}
+}
+
+// SuggestTokens implements [TokenSuggester].
+func (s *EditDistanceSuggester) SuggestTokens(recognizer Parser, offendingToken Token, expected *IntervalSet) []TokenSuggestion {
+	if offendingToken == nil || offendingToken.GetTokenType() == TokenEOF || expected == nil {
+		return nil
+	}
+	text := foldedRunes(offendingToken.GetText())
+	if len(text) == 0 {
+		return nil
+	}
+	literalNames := recognizer.GetLiteralNames()
+
+	var suggestions []TokenSuggestion
+	for _, v := range expected.GetIntervals() {
+		for ttype := max(v.Start, 0); ttype < v.Stop && ttype < len(literalNames); ttype++ {
+			if literalNames[ttype] == "" {
+				continue
+			}
+			literal := unquoteLiteralName(literalNames[ttype])
+			folded := foldedRunes(literal)
+			limit := s.maxDistance
+			if limit <= 0 {
+				limit = len(folded) / 3
+			}
+			if distance := editDistance(text, folded, limit); distance <= limit {
+				suggestions = append(suggestions, TokenSuggestion{TokenType: ttype, Text: literal, Distance: distance})
+			}
+		}
+	}
+	slices.SortStableFunc(suggestions, func(a, b TokenSuggestion) int {
+		return a.Distance - b.Distance
+	})
+	return suggestions
+}
+
+// foldedRunes returns the runes of s in lower case.
+func foldedRunes(s string) []rune {
+	runes := []rune(s)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r)
+	}
+	return runes
+}
+
+// editDistance returns the number of insertions, deletions, substitutions and transpositions of adjacent
+// runes that turn a into b, or limit+1 if it is more than limit.
+func editDistance(a, b []rune, limit int) int {
+	if d := len(a) - len(b); d > limit || -d > limit {
+		return limit + 1
+	}
+	// rows i-2, i-1 and i of the table of the distances between the prefixes of a and b
+	previous2 := make([]int, len(b)+1)
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(min(previous[j], current[j-1])+1, previous[j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				current[j] = min(current[j], previous2[j-2]+1)
+			}
+		}
+		previous2, previous, current = previous, current, previous2
+	}
+	return min(previous[len(b)], limit+1)
+}
+
+// SetTokenSuggester installs the [TokenSuggester] that the strategy asks for the tokens that the offending
+// token of mismatched input may have been meant to be, which it records on the [InputMisMatchException] it
+// reports, see [InputMisMatchException.GetSuggestions], so that an error listener can offer them, such as in
+// a message or as quick fixes. The suggestions do not change the message reported, nor how the strategy
+// recovers. A new strategy has a suggester made with NewEditDistanceSuggester(0); pass nil to remove it.
+//
+// Use:
+//
+//	strategy := antlr.NewDefaultErrorStrategy()
+//	strategy.SetTokenSuggester(antlr.NewEditDistanceSuggester(2))
+//	p.SetErrorHandler(strategy)
+func (d *DefaultErrorStrategy) SetTokenSuggester(suggester TokenSuggester) {
+	d.suggester = suggester
+}
+
+// GetSuggestions returns the tokens that the offending token may have been meant to be, closest first, as
+// suggested by the [TokenSuggester] of the error strategy that reported the exception, or nil if there are
+// none.
+func (i *InputMisMatchException) GetSuggestions() []TokenSuggestion {
+	return i.suggestions
+}
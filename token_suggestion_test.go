@@ -0,0 +1,120 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"slices"
+	"testing"
+)
+
+// The token types of a grammar with keywords, whose rule is:
+//
+//	r : 'select' '*' EOF ;
+const (
+	suggestID = iota + 1
+	suggestSELECT
+	suggestSELECTS
+	suggestFROM
+	suggestSTAR
+)
+
+var suggestLiteralNames = []string{"", "", "'select'", "'selects'", "'from'", "'*'"}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"select", "select", 2, 0},
+		{"selct", "select", 2, 1},
+		{"selectt", "select", 2, 1},
+		{"slecet", "select", 2, 2},
+		{"sleect", "select", 2, 1},
+		{"from", "select", 2, 3},
+		{"s", "select", 2, 3},
+		{"", "ab", 2, 2},
+	}
+	for _, test := range tests {
+		if got := editDistance([]rune(test.a), []rune(test.b), test.limit); got != test.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", test.a, test.b, test.limit, got, test.want)
+		}
+	}
+}
+
+func TestEditDistanceSuggester(t *testing.T) {
+	recognizer := NewBaseParser(nil)
+	recognizer.LiteralNames = suggestLiteralNames
+	expected := NewIntervalSet()
+	expected.AddRange(suggestID, suggestSTAR)
+	token := func(text string) Token {
+		t := newTestToken(suggestID, 0, len(text)-1, 1, 0)
+		t.SetText(text)
+		return t
+	}
+
+	tests := []struct {
+		suggester *EditDistanceSuggester
+		text      string
+		want      []TokenSuggestion
+	}{
+		{NewEditDistanceSuggester(0), "SELECT", []TokenSuggestion{{suggestSELECT, "select", 0}, {suggestSELECTS, "selects", 1}}},
+		{NewEditDistanceSuggester(0), "selcts", []TokenSuggestion{{suggestSELECTS, "selects", 1}, {suggestSELECT, "select", 2}}},
+		// a third of the length of * is no edits at all
+		{NewEditDistanceSuggester(0), "+", nil},
+		{NewEditDistanceSuggester(1), "+", []TokenSuggestion{{suggestSTAR, "*", 1}}},
+		{NewEditDistanceSuggester(1), "form", []TokenSuggestion{{suggestFROM, "from", 1}}},
+		{NewEditDistanceSuggester(0), "", nil},
+	}
+	for _, test := range tests {
+		if got := test.suggester.SuggestTokens(recognizer, token(test.text), expected); !slices.Equal(got, test.want) {
+			t.Errorf("SuggestTokens(%q) = %v, want %v", test.text, got, test.want)
+		}
+	}
+	eof := newTestToken(TokenEOF, 0, -1, 1, 0)
+	if got := NewEditDistanceSuggester(5).SuggestTokens(recognizer, eof, expected); got != nil {
+		t.Errorf("SuggestTokens(EOF) = %v", got)
+	}
+}
+
+func TestMismatchedInputSuggestions(t *testing.T) {
+	atn := buildATN(suggestSTAR, [][][]atnElement{{bAlt(bTok(suggestSELECT), bTok(suggestSTAR), bTok(TokenEOF))}})
+	parse := func(strategy *DefaultErrorStrategy) SyntaxDiagnostic {
+		selct := newTestToken(suggestID, 0, 4, 1, 0)
+		selct.SetText("selct")
+		source := &sliceTokenSource{tokens: []Token{
+			selct, newTestToken(suggestSTAR, 6, 6, 1, 6), newTestToken(TokenEOF, 7, 6, 1, 7),
+		}}
+		stream := NewCommonTokenStream(nil, TokenDefaultChannel)
+		stream.SetTokenSource(source)
+		p := NewParserInterpreter("Select.g4", suggestLiteralNames, nil, []string{"r"}, atn, stream)
+		p.SetErrorHandler(strategy)
+		var diagnostics []SyntaxDiagnostic
+		p.RemoveErrorListeners()
+		p.AddErrorListener(diagnosticListener(&diagnostics))
+		p.Parse(0)
+		if len(diagnostics) != 1 {
+			t.Fatalf("%d diagnostics, want 1", len(diagnostics))
+		}
+		return diagnostics[0]
+	}
+
+	// the suggestions do not change the message
+	d := parse(NewDefaultErrorStrategy())
+	want := []TokenSuggestion{{suggestSELECT, "select", 1}}
+	if !slices.Equal(d.Suggestions, want) || d.Code != DiagnosticMismatchedInput ||
+		d.Message != "mismatched input 'selct' expecting 'select'" {
+		t.Errorf("diagnostic %+v, want suggestions %v", d, want)
+	}
+	if mismatch := d.Exception.(*InputMisMatchException); !slices.Equal(mismatch.GetSuggestions(), want) {
+		t.Errorf("GetSuggestions() = %v", mismatch.GetSuggestions())
+	}
+
+	strategy := NewDefaultErrorStrategy()
+	strategy.SetTokenSuggester(nil)
+	if d := parse(strategy); d.Suggestions != nil || d.Message != "mismatched input 'selct' expecting 'select'" {
+		t.Errorf("diagnostic %+v without a suggester", d)
+	}
+}
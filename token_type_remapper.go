@@ -0,0 +1,141 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "fmt"
+
+// TokenRemapCondition decides whether a token should be remapped by a [TokenTypeRemapper].
+type TokenRemapCondition func(t Token) bool
+
+// TokenTypeRemapper is a [TokenSource] that wraps a lexer and changes the types of the tokens it produces
+// according to a table, before the parser sees them. A remapping may be unconditional, or may depend on the
+// token itself, for instance on its text or position. This allows a grammar to treat some keywords as
+// identifiers in some dialects, say, without duplicating the grammar.
+//
+// Because the remapper runs ahead of the parser, it cannot see the parser's state. A remapping that depends
+// on what the parser expects needs a parser hook instead.
+//
+// Error messages list the tokens that the parser expected, and may include token types that an
+// unconditional remapping means the parser can never be given. Install
+// [TokenTypeRemapper.FilterExpectedTokens] on the [DefaultErrorStrategy] to report the types they are
+// remapped to instead.
+//
+// The remapper embeds the lexer, so it can be passed to [NewCommonTokenStream] in place of the lexer.
+//
+// Use:
+//
+//	remapper := antlr.NewTokenTypeRemapper(parser.NewMyLexer(input))
+//	if err := remapper.RemapNames("'select'", "IDENTIFIER"); err != nil {
+//	    ...
+//	}
+//	p := parser.NewMyParser(antlr.NewCommonTokenStream(remapper, antlr.TokenDefaultChannel))
+//	strategy := antlr.NewDefaultErrorStrategy()
+//	strategy.SetExpectedTokensFilter(remapper.FilterExpectedTokens)
+//	p.SetErrorHandler(strategy)
+type TokenTypeRemapper struct {
+	Lexer
+	remaps map[int]tokenTypeRemap
+}
+
+type tokenTypeRemap struct {
+	to        int
+	condition TokenRemapCondition
+}
+
+var _ Lexer = &TokenTypeRemapper{}
+
+// NewTokenTypeRemapper creates a [TokenTypeRemapper] that wraps the given lexer, with no remappings.
+func NewTokenTypeRemapper(lexer Lexer) *TokenTypeRemapper {
+	return &TokenTypeRemapper{
+		Lexer:  lexer,
+		remaps: make(map[int]tokenTypeRemap),
+	}
+}
+
+// Remap changes every token of type from to type to, replacing any earlier remapping of from.
+func (r *TokenTypeRemapper) Remap(from, to int) {
+	r.RemapIf(from, to, nil)
+}
+
+// RemapIf changes tokens of type from to type to when condition returns true for them, replacing any earlier
+// remapping of from. A nil condition remaps every token of type from.
+func (r *TokenTypeRemapper) RemapIf(from, to int, condition TokenRemapCondition) {
+	r.remaps[from] = tokenTypeRemap{to: to, condition: condition}
+}
+
+// RemapNames is like [TokenTypeRemapper.Remap], but takes token names rather than types, which are looked
+// up in the vocabulary of the lexer. A name may be a symbolic name, such as IDENTIFIER, or a literal name
+// including its quotes, such as 'select'. It returns an error if either name is unknown.
+func (r *TokenTypeRemapper) RemapNames(from, to string) error {
+	return r.RemapNamesIf(from, to, nil)
+}
+
+// RemapNamesIf is like [TokenTypeRemapper.RemapIf], but takes token names rather than types, which are
+// looked up as described for [TokenTypeRemapper.RemapNames].
+func (r *TokenTypeRemapper) RemapNamesIf(from, to string, condition TokenRemapCondition) error {
+	fromType, err := tokenTypeOf(r.Lexer, from)
+	if err != nil {
+		return err
+	}
+	toType, err := tokenTypeOf(r.Lexer, to)
+	if err != nil {
+		return err
+	}
+	r.RemapIf(fromType, toType, condition)
+	return nil
+}
+
+// FilterExpectedTokens returns a copy of the expected set in which each token type that is unconditionally
+// remapped is replaced by the type it is remapped to. It has the signature of an [ExpectedTokensFunc], so
+// that it can be passed to [DefaultErrorStrategy.SetExpectedTokensFilter].
+func (r *TokenTypeRemapper) FilterExpectedTokens(_ Parser, expected *IntervalSet) *IntervalSet {
+	filtered := NewIntervalSet()
+	filtered.addSet(expected)
+	for from, remap := range r.remaps {
+		if remap.condition == nil && filtered.contains(from) {
+			filtered.removeOne(from)
+			filtered.addOne(remap.to)
+		}
+	}
+	return filtered
+}
+
+// NextToken returns the next token from the lexer, with its type remapped if necessary.
+func (r *TokenTypeRemapper) NextToken() Token {
+	t := r.Lexer.NextToken()
+	if t == nil {
+		return nil
+	}
+	remap, ok := r.remaps[t.GetTokenType()]
+	if !ok || (remap.condition != nil && !remap.condition(t)) {
+		return t
+	}
+	if c, ok := t.(*CommonToken); ok {
+		c.tokenType = remap.to
+		return c
+	}
+	retyped := r.Lexer.GetTokenFactory().Create(t.GetSource(), remap.to, t.GetText(), t.GetChannel(),
+		t.GetStart(), t.GetStop(), t.GetLine(), t.GetColumn())
+	retyped.SetTokenIndex(t.GetTokenIndex())
+	return retyped
+}
+
+// tokenTypeOf looks up a token name in the literal and symbolic names of a recognizer.
+func tokenTypeOf(vocabulary Recognizer, name string) (int, error) {
+	if name == "EOF" {
+		return TokenEOF, nil
+	}
+	for ttype, literal := range vocabulary.GetLiteralNames() {
+		if literal != "" && literal == name {
+			return ttype, nil
+		}
+	}
+	for ttype, symbolic := range vocabulary.GetSymbolicNames() {
+		if symbolic != "" && symbolic == name {
+			return ttype, nil
+		}
+	}
+	return TokenInvalidType, fmt.Errorf("unknown token name %q", name)
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+// messageRecorder records the messages of the syntax errors reported to it.
+type messageRecorder struct {
+	DefaultErrorListener
+	messages []string
+}
+
+func (m *messageRecorder) SyntaxError(_ Recognizer, _ interface{}, _, _ int, msg string, _ RecognitionException) {
+	m.messages = append(m.messages, msg)
+}
+
+func TestTokenTypeRemapper(t *testing.T) {
+	remapper := NewTokenTypeRemapper(newListLexer(NewInputStream("a plus b c")))
+	if err := remapper.RemapNamesIf("ID", "'+'", func(t Token) bool { return t.GetText() == "plus" }); err != nil {
+		t.Fatal(err)
+	}
+	p := newListParser(NewCommonTokenStream(remapper, TokenDefaultChannel))
+	if got := p.S().ToStringTree(nil, p); got != "(s (item a plus b) (item c) <EOF>)" {
+		t.Errorf("tree %s", got)
+	}
+
+	if err := remapper.RemapNames("ID", "KEYWORD"); err == nil {
+		t.Error("an unknown token name was remapped")
+	}
+	if err := remapper.RemapNames("'-'", "ID"); err == nil {
+		t.Error("an unknown literal was remapped")
+	}
+}
+
+func TestTokenTypeRemapperFilterExpectedTokens(t *testing.T) {
+	remapper := NewTokenTypeRemapper(newListLexer(nil))
+	remapper.Remap(listID, listPLUS)
+	remapper.RemapIf(listWS, listID, func(Token) bool { return true })
+	expected := NewIntervalSet()
+	expected.addOne(listID)
+	expected.addOne(listWS)
+	if got := remapper.FilterExpectedTokens(nil, expected).String(); got != "2..3" {
+		t.Errorf("FilterExpectedTokens() = %s, want 2..3", got)
+	}
+	if expected.String() != "{1, 3}" {
+		t.Errorf("the expected tokens changed to %s", expected)
+	}
+
+	// The strategy reports the filtered tokens
+	p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream("a b + + +")), TokenDefaultChannel))
+	recorder := new(messageRecorder)
+	p.RemoveErrorListeners()
+	p.AddErrorListener(recorder)
+	strategy := NewDefaultErrorStrategy()
+	strategy.SetExpectedTokensFilter(remapper.FilterExpectedTokens)
+	p.SetErrorHandler(strategy)
+	p.S()
+	if len(recorder.messages) != 1 || recorder.messages[0] != "mismatched input '+' expecting '+'" {
+		t.Errorf("errors %q", recorder.messages)
+	}
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// TokenProblemKind identifies the kind of inconsistency found in a sequence of tokens. See [TokenProblem].
+type TokenProblemKind int
+
+const (
+	// TokenProblemIndexGap indicates that a token's index is not its position in the stream or,
+	// for a token source, that it does not follow the index of the previous token
+	TokenProblemIndexGap TokenProblemKind = iota
+
+	// TokenProblemOverlap indicates that a token starts at or before the end of the previous token
+	// in the input
+	TokenProblemOverlap
+
+	// TokenProblemOutOfOrder indicates that a token's line and column are before those of the
+	// previous token
+	TokenProblemOutOfOrder
+
+	// TokenProblemNilToken indicates that a token source returned nil rather than a token
+	TokenProblemNilToken
+
+	// TokenProblemMissingEOF indicates that a token source did not emit [TokenEOF] when expected,
+	// or did not keep emitting it once the input was exhausted
+	TokenProblemMissingEOF
+
+	// TokenProblemInvalidChannel indicates that a token is on a negative channel, or that the EOF
+	// token is not on [TokenDefaultChannel], which would let the parser run past the end of the input
+	TokenProblemInvalidChannel
+
+	// TokenProblemInvalidType indicates that a token's type is neither [TokenEOF] nor a user token type
+	TokenProblemInvalidType
+)
+
+func (k TokenProblemKind) String() string {
+	switch k {
+	case TokenProblemIndexGap:
+		return "index gap"
+	case TokenProblemOverlap:
+		return "overlap"
+	case TokenProblemOutOfOrder:
+		return "out of order"
+	case TokenProblemNilToken:
+		return "nil token"
+	case TokenProblemMissingEOF:
+		return "missing EOF"
+	case TokenProblemInvalidChannel:
+		return "invalid channel"
+	case TokenProblemInvalidType:
+		return "invalid type"
+	}
+	return fmt.Sprintf("TokenProblemKind(%d)", int(k))
+}
+
+// TokenProblem describes an inconsistency between a token and its position in a token stream, or between
+// a token and the token before it. Such problems are almost always caused by a hand-written [TokenSource]
+// and lead to confusing error messages and parse trees, so they are worth checking for when developing
+// a custom source. See [CommonTokenStream.Validate].
+type TokenProblem struct {
+	// Kind is the kind of problem
+	Kind TokenProblemKind
+
+	// Index is the position of the offending token in the stream
+	Index int
+
+	// Token is the offending token
+	Token Token
+
+	// Previous is the token before the offending token, if any
+	Previous Token
+
+	// Message describes the problem
+	Message string
+}
+
+// Error returns a description of the problem, so that a TokenProblem can be used as an error.
+func (p *TokenProblem) Error() string {
+	return fmt.Sprintf("token %d %s: %s", p.Index, p.Kind, p.Message)
+}
+
+// Validate fills the stream if necessary and then checks every buffered token for index gaps, overlapping
+// positions, out of order line and column values, and invalid types and channels. It returns the problems
+// in stream order, or nil if there are none.
+//
+// Tokens whose start index is negative, such as imaginary tokens, are not checked for overlap, and tokens
+// whose line is not set are not checked for ordering.
+//
+// Use:
+//
+//	stream := antlr.NewCommonTokenStream(mySource, antlr.TokenDefaultChannel)
+//	for _, problem := range stream.Validate() {
+//	    fmt.Println(problem)
+//	}
+func (c *CommonTokenStream) Validate() []*TokenProblem {
+	c.Fill()
+	return validateTokens(c.tokens)
+}
+
+// ValidateTokenSource exercises a custom [TokenSource] by reading up to n tokens from it, and verifies the
+// invariants that the token streams and the parser rely on:
+//
+//   - the source never returns nil, and returns [TokenEOF] within n tokens
+//   - once it has returned EOF, the source keeps returning EOF
+//   - token types are [TokenEOF] or at least [TokenMinUserTokenType]
+//   - channels are not negative, and EOF is on [TokenDefaultChannel]
+//   - token indexes, where the source sets them, increase monotonically
+//   - tokens do not overlap in the input, and their line and column values are in order
+//
+// It returns nil if all is well, or an error joining a [*TokenProblem] for each violation, which can be
+// retrieved with errors.As or by unwrapping the joined error.
+//
+// Note that the source is consumed, so create a fresh source for the actual parse.
+func ValidateTokenSource(src TokenSource, n int) error {
+	var v tokenValidator
+	var tokens []Token
+	var prev Token
+	sawEOF := false
+	for i := 0; i < n; i++ {
+		t := src.NextToken()
+		if t == nil {
+			v.report(TokenProblemNilToken, i, nil, prev, "token source returned nil")
+			break
+		}
+		tokens = append(tokens, t)
+		if prev != nil && t.GetTokenIndex() >= 0 && prev.GetTokenIndex() >= 0 && t.GetTokenIndex() <= prev.GetTokenIndex() {
+			v.report(TokenProblemIndexGap, i, t, prev, "token index %d does not follow the previous index %d",
+				t.GetTokenIndex(), prev.GetTokenIndex())
+		}
+		v.checkToken(i, t, prev)
+		if t.GetTokenType() == TokenEOF {
+			sawEOF = true
+			break
+		}
+		prev = t
+	}
+
+	if sawEOF {
+		last := tokens[len(tokens)-1]
+		if t := src.NextToken(); t == nil || t.GetTokenType() != TokenEOF {
+			v.report(TokenProblemMissingEOF, len(tokens), t, last, "token source did not return EOF again after EOF")
+		}
+	} else if len(v.problems) == 0 || v.problems[len(v.problems)-1].Kind != TokenProblemNilToken {
+		v.report(TokenProblemMissingEOF, len(tokens), nil, prev, "token source did not return EOF within %d tokens", n)
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	errs := make([]error, len(v.problems))
+	for i, p := range v.problems {
+		errs[i] = p
+	}
+	return errors.Join(errs...)
+}
+
+// tokenValidator accumulates the problems found in a sequence of tokens.
+type tokenValidator struct {
+	problems []*TokenProblem
+}
+
+func (v *tokenValidator) report(kind TokenProblemKind, i int, t, prev Token, format string, args ...interface{}) {
+	v.problems = append(v.problems, &TokenProblem{
+		Kind:     kind,
+		Index:    i,
+		Token:    t,
+		Previous: prev,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// checkToken checks the type and channel of a token, and checks its position against that of the
+// previous token, if there is one.
+func (v *tokenValidator) checkToken(i int, t, prev Token) {
+	if t.GetTokenType() != TokenEOF && t.GetTokenType() < TokenMinUserTokenType {
+		v.report(TokenProblemInvalidType, i, t, prev, "token type %d is not a valid token type", t.GetTokenType())
+	}
+	if t.GetChannel() < 0 {
+		v.report(TokenProblemInvalidChannel, i, t, prev, "token channel %d is negative", t.GetChannel())
+	} else if t.GetTokenType() == TokenEOF && t.GetChannel() != TokenDefaultChannel {
+		v.report(TokenProblemInvalidChannel, i, t, prev, "EOF token is on channel %d", t.GetChannel())
+	}
+	if prev == nil {
+		return
+	}
+	if t.GetStart() >= 0 && prev.GetStart() >= 0 && t.GetStart() <= prev.GetStop() {
+		v.report(TokenProblemOverlap, i, t, prev, "token starts at %d, but the previous token %s ends at %d",
+			t.GetStart(), prev, prev.GetStop())
+	}
+	if t.GetLine() > 0 && prev.GetLine() > 0 && tokenPositionBefore(t, prev) {
+		v.report(TokenProblemOutOfOrder, i, t, prev, "token is at %d:%d, before the previous token %s at %d:%d",
+			t.GetLine(), t.GetColumn(), prev, prev.GetLine(), prev.GetColumn())
+	}
+}
+
+// validateTokens checks each token against its position in the given slice and against the token
+// before it.
+func validateTokens(tokens []Token) []*TokenProblem {
+	var v tokenValidator
+	var prev Token
+	for i, t := range tokens {
+		if t.GetTokenIndex() != i {
+			v.report(TokenProblemIndexGap, i, t, prev, "token index is %d, expected %d", t.GetTokenIndex(), i)
+		}
+		v.checkToken(i, t, prev)
+		prev = t
+	}
+	return v.problems
+}
+
+// tokenPositionBefore returns true if the line and column of t are before those of prev. A token may
+// share its position with the previous token only if the previous token is empty.
+func tokenPositionBefore(t, prev Token) bool {
+	if t.GetLine() != prev.GetLine() {
+		return t.GetLine() < prev.GetLine()
+	}
+	if prev.GetStop() < prev.GetStart() {
+		return t.GetColumn() < prev.GetColumn()
+	}
+	return t.GetColumn() <= prev.GetColumn()
+}
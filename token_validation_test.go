@@ -0,0 +1,119 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"testing"
+)
+
+// newTestToken returns a token of the given type, on the default channel, at the given position.
+func newTestToken(tokenType, start, stop, line, column int) *CommonToken {
+	t := NewCommonToken(&TokenSourceCharStreamPair{}, tokenType, TokenDefaultChannel, start, stop)
+	t.line = line
+	t.column = column
+	return t
+}
+
+// sliceTokenSource is a hand-written [TokenSource] that returns its tokens and then, for ever, its last
+// token.
+type sliceTokenSource struct {
+	tokens  []Token
+	next    int
+	factory TokenFactory
+}
+
+func (s *sliceTokenSource) NextToken() Token {
+	t := s.tokens[min(s.next, len(s.tokens)-1)]
+	s.next++
+	return t
+}
+
+func (s *sliceTokenSource) Skip()                                {}
+func (s *sliceTokenSource) More()                                {}
+func (s *sliceTokenSource) GetLine() int                         { return 0 }
+func (s *sliceTokenSource) GetCharPositionInLine() int           { return 0 }
+func (s *sliceTokenSource) GetInputStream() CharStream           { return nil }
+func (s *sliceTokenSource) GetSourceName() string                { return "slice" }
+func (s *sliceTokenSource) setTokenFactory(factory TokenFactory) { s.factory = factory }
+func (s *sliceTokenSource) GetTokenFactory() TokenFactory        { return s.factory }
+
+func TestCommonTokenStreamValidate(t *testing.T) {
+	stream := NewCommonTokenStream(newListLexer(NewInputStream("a b + c")), TokenDefaultChannel)
+	if problems := stream.Validate(); problems != nil {
+		t.Fatalf("the tokens of the lexer have problems %v", problems)
+	}
+
+	source := &sliceTokenSource{tokens: []Token{
+		newTestToken(listID, 0, 1, 1, 0),
+		newTestToken(listID, 1, 2, 1, 2), // starts within a
+		newTestToken(listID, 4, 4, 1, 1), // before b on the line
+		newTestToken(TokenEOF, 5, 4, 1, 5),
+	}}
+	stream = NewCommonTokenStream(nil, TokenDefaultChannel)
+	stream.SetTokenSource(source)
+	problems := stream.Validate()
+	if len(problems) != 2 {
+		t.Fatalf("problems %v, want 2", problems)
+	}
+	if p := problems[0]; p.Kind != TokenProblemOverlap || p.Index != 1 || p.Previous != source.tokens[0] {
+		t.Errorf("problem %v", p)
+	}
+	if p := problems[1]; p.Kind != TokenProblemOutOfOrder || p.Index != 2 || p.Token != source.tokens[2] {
+		t.Errorf("problem %v", p)
+	}
+	if got := problems[0].Error(); got != "token 1 overlap: token starts at 1, but the previous token [@0,0:1='<no text>',<1>,1:0] ends at 1" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestValidateTokenSource(t *testing.T) {
+	if err := ValidateTokenSource(newListLexer(NewInputStream("a b + c")), 100); err != nil {
+		t.Fatalf("the lexer has problems: %v", err)
+	}
+
+	eof := newTestToken(TokenEOF, 3, 2, 1, 3)
+	first, second := newTestToken(listID, 0, 0, 1, 0), newTestToken(listID, 2, 2, 1, 2)
+	first.SetTokenIndex(1)
+	second.SetTokenIndex(1)
+	tests := []struct {
+		name   string
+		tokens []Token
+		n      int
+		want   []TokenProblemKind
+	}{
+		{"nil", []Token{newTestToken(listID, 0, 0, 1, 0), nil}, 10, []TokenProblemKind{TokenProblemNilToken}},
+		{"no EOF", []Token{newTestToken(listID, 0, 0, 1, 0)}, 1, []TokenProblemKind{TokenProblemMissingEOF}},
+		{"EOF once", []Token{eof, newTestToken(listID, 4, 4, 1, 4)}, 5, []TokenProblemKind{TokenProblemMissingEOF}},
+		{"index", []Token{first, second, eof}, 5, []TokenProblemKind{TokenProblemIndexGap}},
+		{"type", []Token{newTestToken(0, 0, 0, 1, 0), eof}, 5, []TokenProblemKind{TokenProblemInvalidType}},
+		{"channel", []Token{newTestToken(listID, 0, 0, 1, 0), NewCommonToken(&TokenSourceCharStreamPair{}, TokenEOF, 1, 1, 0)},
+			5, []TokenProblemKind{TokenProblemInvalidChannel}},
+	}
+	for _, test := range tests {
+		err := ValidateTokenSource(&sliceTokenSource{tokens: test.tokens}, test.n)
+		var kinds []TokenProblemKind
+		if err != nil {
+			for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+				kinds = append(kinds, e.(*TokenProblem).Kind)
+			}
+		}
+		if len(kinds) != len(test.want) {
+			t.Errorf("%s: problems %v, want %v", test.name, kinds, test.want)
+			continue
+		}
+		for i := range kinds {
+			if kinds[i] != test.want[i] {
+				t.Errorf("%s: problems %v, want %v", test.name, kinds, test.want)
+				break
+			}
+		}
+	}
+
+	var problem *TokenProblem
+	if err := ValidateTokenSource(&sliceTokenSource{tokens: []Token{nil}}, 1); !errors.As(err, &problem) || problem.Kind != TokenProblemNilToken {
+		t.Errorf("ValidateTokenSource() = %v", err)
+	}
+}
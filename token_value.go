@@ -0,0 +1,132 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNoTokenValue is the error, possibly wrapped, returned by [GetTokenValue] for a token that has no decoded
+// value, because it was not created by a [TokenValueFactory] with a decoder for its type.
+var ErrNoTokenValue = errors.New("token has no decoded value")
+
+// TokenValueFunc decodes the value of a token from its text, such as the number that a numeric literal
+// stands for, returning an error if the text cannot be decoded.
+type TokenValueFunc func(t Token) (any, error)
+
+// tokenValue is the value decoded for a token, kept with it, see [TokenValueFactory].
+type tokenValue struct {
+	value any
+	err   error
+}
+
+// TokenValueFactory is a [TokenFactory] that decodes the value of each token of the types it has decoders
+// for as the lexer creates it, and keeps it with the token, for [GetTokenValue] to return, so that the text
+// of a literal is decoded once, rather than by every listener, visitor and semantic check that needs its
+// value. The tokens are created by another factory, which must create tokens that embed [BaseToken], as
+// [CommonTokenFactory] does; the values of other tokens are not kept.
+//
+// An error decoding a value, such as for an integer literal too large for an int64, is kept in place of the
+// value, as the factory cannot report it; it is returned by GetTokenValue.
+//
+// Use:
+//
+//	lexer.SetTokenFactory(antlr.NewTokenValueFactory(nil, map[int]antlr.TokenValueFunc{
+//	    parser.MyLexerINT:    antlr.ParseIntTokenValue,
+//	    parser.MyLexerFLOAT:  antlr.ParseFloatTokenValue,
+//	    parser.MyLexerSTRING: antlr.UnquoteTokenValue,
+//	}))
+//	...
+//	n, err := antlr.GetTokenValueAs[int64](ctx.INT().GetSymbol())
+type TokenValueFactory struct {
+	factory  TokenFactory
+	decoders map[int]TokenValueFunc
+}
+
+// NewTokenValueFactory creates a [TokenValueFactory] that creates tokens with factory, or with
+// CommonTokenFactoryDEFAULT if factory is nil, and decodes the value of those of the types in decoders with
+// the func for their type.
+func NewTokenValueFactory(factory TokenFactory, decoders map[int]TokenValueFunc) *TokenValueFactory {
+	if factory == nil {
+		factory = CommonTokenFactoryDEFAULT
+	}
+	return &TokenValueFactory{factory: factory, decoders: decoders}
+}
+
+// Create creates a token with the factory the TokenValueFactory was created with, and decodes its value if
+// there is a decoder for its type.
+func (f *TokenValueFactory) Create(source *TokenSourceCharStreamPair, ttype int, text string, channel, start, stop, line, column int) Token {
+	t := f.factory.Create(source, ttype, text, channel, start, stop, line, column)
+	if decode := f.decoders[ttype]; decode != nil {
+		if b, ok := t.(interface{ setValue(value *tokenValue) }); ok {
+			value, err := decode(t)
+			b.setValue(&tokenValue{value: value, err: err})
+		}
+	}
+	return t
+}
+
+func (b *BaseToken) setValue(value *tokenValue) {
+	b.value = value
+}
+
+// GetTokenValue returns the value decoded for token t as it was created by a [TokenValueFactory], or the
+// error decoding it. It returns an error wrapping [ErrNoTokenValue] if the token has no value, such as if it
+// is of a type that the factory has no decoder for, or if its text has been set since it was created.
+func GetTokenValue(t Token) (any, error) {
+	if b, ok := t.(interface{ getValue() *tokenValue }); ok {
+		if value := b.getValue(); value != nil {
+			return value.value, value.err
+		}
+	}
+	return nil, fmt.Errorf("%w: %v", ErrNoTokenValue, t)
+}
+
+func (b *BaseToken) getValue() *tokenValue {
+	return b.value
+}
+
+// GetTokenValueAs returns the value decoded for token t, as [GetTokenValue] does, as a value of type T, such
+// as int64 for a token decoded by [ParseIntTokenValue]. It returns an error if the value is of another type.
+func GetTokenValueAs[T any](t Token) (T, error) {
+	var zero T
+	value, err := GetTokenValue(t)
+	if err != nil {
+		return zero, err
+	}
+	typed, ok := value.(T)
+	if !ok {
+		return zero, fmt.Errorf("the value of token %v is of type %T, not %T", t, value, zero)
+	}
+	return typed, nil
+}
+
+// ParseIntTokenValue decodes the text of an integer literal as an int64, with the syntax of Go: in decimal,
+// or in hexadecimal, octal or binary with a prefix of 0x, 0o or 0, or 0b, and with underscores between the
+// digits.
+func ParseIntTokenValue(t Token) (any, error) {
+	return strconv.ParseInt(t.GetText(), 0, 64)
+}
+
+// ParseFloatTokenValue decodes the text of a numeric literal as a float64, with the syntax of Go, which
+// accepts the literals of most languages, with or without a fraction or an exponent.
+func ParseFloatTokenValue(t Token) (any, error) {
+	return strconv.ParseFloat(t.GetText(), 64)
+}
+
+// UnquoteTokenValue decodes the text of a string literal as the string it stands for, with the syntax of Go,
+// which accepts the double-quoted literals of C, Java and JSON, with their escapes.
+func UnquoteTokenValue(t Token) (any, error) {
+	return strconv.Unquote(t.GetText())
+}
+
+// FoldIdentifierTokenValue decodes the text of an identifier as its lower case, by which the identifiers of
+// a case-insensitive language, such as SQL, are compared.
+func FoldIdentifierTokenValue(t Token) (any, error) {
+	return strings.ToLower(t.GetText()), nil
+}
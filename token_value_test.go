@@ -0,0 +1,85 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+func TestTokenValueFuncs(t *testing.T) {
+	tests := []struct {
+		decode TokenValueFunc
+		text   string
+		want   any
+		valid  bool
+	}{
+		{ParseIntTokenValue, "42", int64(42), true},
+		{ParseIntTokenValue, "0x_ff", int64(255), true},
+		{ParseIntTokenValue, "0b101", int64(5), true},
+		{ParseIntTokenValue, "99999999999999999999", int64(9223372036854775807), false},
+		{ParseFloatTokenValue, "1.5e3", 1500.0, true},
+		{ParseFloatTokenValue, ".5", 0.5, true},
+		{ParseFloatTokenValue, "1.2.3", 0.0, false},
+		{UnquoteTokenValue, `"a\tbé"`, "a\tbé", true},
+		{UnquoteTokenValue, `"a`, "", false},
+		{FoldIdentifierTokenValue, "SelECT", "select", true},
+	}
+	for _, test := range tests {
+		token := newTestToken(listID, 0, 0, 1, 0)
+		token.SetText(test.text)
+		if got, err := test.decode(token); got != test.want || (err == nil) != test.valid {
+			t.Errorf("%q decoded to %v, %v, want %v", test.text, got, err, test.want)
+		}
+	}
+}
+
+func TestTokenValueFactory(t *testing.T) {
+	lexer := newListLexer(NewInputStream("select ab + x"))
+	lexer.SetTokenFactory(NewTokenValueFactory(nil, map[int]TokenValueFunc{
+		listID: func(t Token) (any, error) { return len(t.GetText()), nil },
+	}))
+	stream := NewCommonTokenStream(lexer, TokenDefaultChannel)
+	stream.Fill()
+	tokens := stream.GetAllTokens()
+
+	if value, err := GetTokenValueAs[int](tokens[0]); value != 6 || err != nil {
+		t.Errorf("the value of %s is %d, %v", tokens[0], value, err)
+	}
+	if value, err := GetTokenValueAs[string](tokens[1]); value != "" || err == nil {
+		t.Errorf("the value of %s is the string %q, %v", tokens[1], value, err)
+	}
+	for _, token := range []Token{tokens[2], tokens[4]} {
+		if value, err := GetTokenValue(token); value != nil || !errors.Is(err, ErrNoTokenValue) {
+			t.Errorf("%s has no decoder, but the value %v, %v", token, value, err)
+		}
+	}
+
+	// a clone keeps the value, but new text discards it
+	clone := tokens[3].(*CommonToken).clone()
+	if value, err := GetTokenValue(clone); value != 1 || err != nil {
+		t.Errorf("the value of the clone is %v, %v", value, err)
+	}
+	clone.SetText("y")
+	if _, err := GetTokenValue(clone); !errors.Is(err, ErrNoTokenValue) {
+		t.Errorf("the value of the token was kept with its new text: %v", err)
+	}
+}
+
+func TestTokenValueFactoryError(t *testing.T) {
+	lexer := newListLexer(NewInputStream("a"))
+	lexer.SetTokenFactory(NewTokenValueFactory(CommonTokenFactoryDEFAULT, map[int]TokenValueFunc{
+		listID: ParseIntTokenValue,
+	}))
+	token := lexer.NextToken()
+	var numErr *strconv.NumError
+	if value, err := GetTokenValue(token); value != int64(0) || !errors.As(err, &numErr) {
+		t.Errorf("the value of %s is %v, %v", token, value, err)
+	}
+	if value, err := GetTokenValueAs[int64](token); value != 0 || !errors.As(err, &numErr) {
+		t.Errorf("the value of %s is %v, %v", token, value, err)
+	}
+}
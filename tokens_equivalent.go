@@ -0,0 +1,74 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+// TokensEquivalentOptions configures [TokensEquivalent]. The zero value compares the type and text of the
+// tokens on the default channel.
+type TokensEquivalentOptions struct {
+	// Channels, if not empty, compares the tokens on these channels rather than the default channel
+	Channels []int
+
+	// IgnoreTypes holds token types that are left out of the comparison altogether
+	IgnoreTypes []int
+
+	// TypesOnly compares only the types of the tokens, and not their text
+	TypesOnly bool
+}
+
+// TokensEquivalent returns true if two token streams hold the same sequence of tokens, ignoring the tokens
+// on hidden channels, such as whitespace and comments, and the positions of the tokens. It is intended for
+// tools that rewrite source code, such as formatters and refactorings, to verify that a rewrite changed
+// nothing but the layout.
+//
+// Streams that are [CommonTokenStream] are filled first, and otherwise the tokens that each stream has
+// buffered are compared. opts may be nil to compare the type and text of the tokens on the default channel.
+//
+// Use:
+//
+//	before := antlr.NewCommonTokenStream(parser.NewMyLexer(antlr.NewInputStream(original)), antlr.TokenDefaultChannel)
+//	after := antlr.NewCommonTokenStream(parser.NewMyLexer(antlr.NewInputStream(formatted)), antlr.TokenDefaultChannel)
+//	if !antlr.TokensEquivalent(before, after, nil) {
+//	    return errors.New("formatting changed the code")
+//	}
+func TokensEquivalent(a, b TokenStream, opts *TokensEquivalentOptions) bool {
+	if opts == nil {
+		opts = &TokensEquivalentOptions{}
+	}
+	ta, tb := equivalenceTokens(a, opts), equivalenceTokens(b, opts)
+	if len(ta) != len(tb) {
+		return false
+	}
+	for i := range ta {
+		if ta[i].GetTokenType() != tb[i].GetTokenType() {
+			return false
+		}
+		if !opts.TypesOnly && ta[i].GetText() != tb[i].GetText() {
+			return false
+		}
+	}
+	return true
+}
+
+// equivalenceTokens returns the tokens of stream that TokensEquivalent compares, excluding EOF.
+func equivalenceTokens(stream TokenStream, opts *TokensEquivalentOptions) []Token {
+	if c, ok := stream.(*CommonTokenStream); ok {
+		c.Fill()
+	}
+	channels := opts.Channels
+	if len(channels) == 0 {
+		channels = []int{TokenDefaultChannel}
+	}
+	tokens := make([]Token, 0)
+	for i := 0; i < stream.Size(); i++ {
+		t := stream.Get(i)
+		if t.GetTokenType() == TokenEOF {
+			break
+		}
+		if dumpSelected(channels, t.GetChannel()) && !(len(opts.IgnoreTypes) > 0 && dumpSelected(opts.IgnoreTypes, t.GetTokenType())) {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
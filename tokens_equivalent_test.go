@@ -0,0 +1,48 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "testing"
+
+func TestTokensEquivalent(t *testing.T) {
+	tests := []struct {
+		a, b string
+		opts *TokensEquivalentOptions
+		want bool
+	}{
+		{"a b+c", "a   b + c", nil, true},
+		{"a b+c", "a b+d", nil, false},
+		{"a b+c", "a b+", nil, false},
+		{"a b+c", "x y+z", &TokensEquivalentOptions{TypesOnly: true}, true},
+		{"a b+c", "x y z", &TokensEquivalentOptions{TypesOnly: true}, false},
+		{"a b+c", "a b c", &TokensEquivalentOptions{IgnoreTypes: []int{listPLUS}}, true},
+		{"", "", nil, true},
+	}
+	for _, test := range tests {
+		if got := TokensEquivalent(listStream(test.a), listStream(test.b), test.opts); got != test.want {
+			t.Errorf("TokensEquivalent(%q, %q, %+v) = %v", test.a, test.b, test.opts, got)
+		}
+	}
+}
+
+func TestTokensEquivalentChannels(t *testing.T) {
+	stream := func(comment string) *CommonTokenStream {
+		c := newTestToken(listWS, 0, 0, 1, 0)
+		c.SetText(comment)
+		c.channel = TokenHiddenChannel
+		a := newTestToken(listID, 0, 0, 1, 0)
+		a.SetText("a")
+		s := NewCommonTokenStream(nil, TokenDefaultChannel)
+		s.SetTokenSource(&sliceTokenSource{tokens: []Token{c, a, newTestToken(TokenEOF, 0, 0, 1, 0)}})
+		return s
+	}
+	if !TokensEquivalent(stream("// x"), stream("// y"), nil) {
+		t.Error("the streams differ in their hidden tokens")
+	}
+	hidden := &TokensEquivalentOptions{Channels: []int{TokenHiddenChannel}}
+	if TokensEquivalent(stream("// x"), stream("// y"), hidden) || !TokensEquivalent(stream("// x"), stream("// x"), hidden) {
+		t.Error("the hidden tokens were not compared")
+	}
+}
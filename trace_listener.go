@@ -4,15 +4,21 @@
 
 package antlr
 
-import "fmt"
+import (
+	"fmt"
+	"io"
+	"os"
+)
 
 type TraceListener struct {
-	parser *BaseParser
+	parser Parser
+	out    io.Writer
 }
 
 func NewTraceListener(parser *BaseParser) *TraceListener {
 	tl := new(TraceListener)
 	tl.parser = parser
+	tl.out = os.Stdout
 	return tl
 }
 
@@ -20,13 +26,13 @@ func (t *TraceListener) VisitErrorNode(_ ErrorNode) {
 }
 
 func (t *TraceListener) EnterEveryRule(ctx ParserRuleContext) {
-	fmt.Println("enter   " + t.parser.GetRuleNames()[ctx.GetRuleIndex()] + ", LT(1)=" + t.parser.input.LT(1).GetText())
+	_, _ = fmt.Fprintln(t.out, "enter   "+t.parser.GetRuleNames()[ctx.GetRuleIndex()]+", LT(1)="+t.parser.GetTokenStream().LT(1).GetText())
 }
 
 func (t *TraceListener) VisitTerminal(node TerminalNode) {
-	fmt.Println("consume " + fmt.Sprint(node.GetSymbol()) + " rule " + t.parser.GetRuleNames()[t.parser.ctx.GetRuleIndex()])
+	_, _ = fmt.Fprintln(t.out, "consume "+fmt.Sprint(node.GetSymbol())+" rule "+t.parser.GetRuleNames()[t.parser.GetParserRuleContext().GetRuleIndex()])
 }
 
 func (t *TraceListener) ExitEveryRule(ctx ParserRuleContext) {
-	fmt.Println("exit    " + t.parser.GetRuleNames()[ctx.GetRuleIndex()] + ", LT(1)=" + t.parser.input.LT(1).GetText())
+	_, _ = fmt.Fprintln(t.out, "exit    "+t.parser.GetRuleNames()[ctx.GetRuleIndex()]+", LT(1)="+t.parser.GetTokenStream().LT(1).GetText())
 }
@@ -0,0 +1,142 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import "fmt"
+
+// ParseTreePattern is a compiled tree pattern, which trees can be matched against. It is created by
+// [BaseParser.CompileParseTreePattern] or [ParseTreePatternMatcher.Compile], see [ParseTreePatternMatcher]
+// for the syntax of patterns.
+type ParseTreePattern struct {
+	matcher          *ParseTreePatternMatcher
+	pattern          string
+	patternRuleIndex int
+	patternTree      ParseTree
+}
+
+// Match matches tree against the pattern, and returns the result, which records the parts of the tree that
+// the tags of the pattern matched.
+func (p *ParseTreePattern) Match(tree ParseTree) *ParseTreeMatch {
+	return p.matcher.MatchPattern(tree, p)
+}
+
+// Matches returns true if tree matches the pattern.
+func (p *ParseTreePattern) Matches(tree ParseTree) bool {
+	return p.matcher.MatchesPattern(tree, p)
+}
+
+// FindAll returns the successful matches of the pattern against the subtrees of tree that xpath selects, see
+// [XPath], in the order of a depth-first walk of the tree. It returns an error if the path is not valid.
+func (p *ParseTreePattern) FindAll(tree ParseTree, xpath string) ([]*ParseTreeMatch, error) {
+	subtrees, err := XPathFind(tree, xpath, p.matcher.parser)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*ParseTreeMatch
+	for _, t := range subtrees {
+		if m := p.Match(t); m.Succeeded() {
+			matches = append(matches, m)
+		}
+	}
+	return matches, nil
+}
+
+// GetMatcher returns the matcher that compiled the pattern.
+func (p *ParseTreePattern) GetMatcher() *ParseTreePatternMatcher {
+	return p.matcher
+}
+
+// GetPattern returns the text of the pattern.
+func (p *ParseTreePattern) GetPattern() string {
+	return p.pattern
+}
+
+// GetPatternRuleIndex returns the index of the rule the pattern was parsed with.
+func (p *ParseTreePattern) GetPatternRuleIndex() int {
+	return p.patternRuleIndex
+}
+
+// GetPatternTree returns the tree the pattern was parsed into, in which each tag is a [TokenTagToken] leaf, or
+// a rule context whose only child is a [RuleTagToken] leaf.
+func (p *ParseTreePattern) GetPatternTree() ParseTree {
+	return p.patternTree
+}
+
+// String returns the text of the pattern.
+func (p *ParseTreePattern) String() string {
+	return p.pattern
+}
+
+// ParseTreeMatch is the result of matching a tree against a [ParseTreePattern]. It records, under the token
+// or rule name of each tag of the pattern, and under its label if it has one, the nodes of the tree that the
+// tag matched, in the order of the tags in the pattern. If the match failed, it records the node of the tree
+// at which it failed, and the nodes recorded are those matched before it failed.
+//
+// Use:
+//
+//	m := pattern.Match(tree)
+//	if !m.Succeeded() {
+//	    return fmt.Errorf("no match at %s", m.GetMismatchedNode().GetText())
+//	}
+//	lhs, rhs := m.Get("lhs"), m.Get("rhs")
+type ParseTreeMatch struct {
+	tree           ParseTree
+	pattern        *ParseTreePattern
+	labels         map[string][]ParseTree
+	mismatchedNode ParseTree
+}
+
+// Get returns the node the last tag with the given label or name matched, or nil if no tag with it matched.
+// For a pattern with a single tag for a label, such as <ID> = <expr>; and Get("ID"), this is the node the tag
+// matched.
+func (m *ParseTreeMatch) Get(label string) ParseTree {
+	nodes := m.labels[label]
+	if len(nodes) == 0 {
+		return nil
+	}
+	return nodes[len(nodes)-1]
+}
+
+// GetAll returns the nodes the tags with the given label or name matched, in the order of the tags in the
+// pattern, or nil if none did.
+func (m *ParseTreeMatch) GetAll(label string) []ParseTree {
+	return m.labels[label]
+}
+
+// GetLabels returns the nodes matched, under the labels and names of the tags that matched them. The map is
+// the match's own, and must not be modified.
+func (m *ParseTreeMatch) GetLabels() map[string][]ParseTree {
+	return m.labels
+}
+
+// GetMismatchedNode returns the node of the tree at which the match failed, or nil if it succeeded.
+func (m *ParseTreeMatch) GetMismatchedNode() ParseTree {
+	return m.mismatchedNode
+}
+
+// Succeeded returns true if the tree matched the pattern.
+func (m *ParseTreeMatch) Succeeded() bool {
+	return m.mismatchedNode == nil
+}
+
+// GetPattern returns the pattern the tree was matched against.
+func (m *ParseTreeMatch) GetPattern() *ParseTreePattern {
+	return m.pattern
+}
+
+// GetTree returns the tree that was matched against the pattern.
+func (m *ParseTreeMatch) GetTree() ParseTree {
+	return m.tree
+}
+
+// String describes the match, as in
+//
+//	Match succeeded; found 2 labels
+func (m *ParseTreeMatch) String() string {
+	if !m.Succeeded() {
+		return fmt.Sprintf("Match failed; found %d labels", len(m.labels))
+	}
+	return fmt.Sprintf("Match succeeded; found %d labels", len(m.labels))
+}
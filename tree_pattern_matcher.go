@@ -0,0 +1,542 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrNoBypassAlts is returned when a tree pattern is compiled for a parser whose [ATN] cannot be copied with
+// the bypass alternatives that rule tags need, as it was not deserialized.
+var ErrNoBypassAlts = errors.New("the parser does not support an ATN with bypass alternatives")
+
+// ParseTreePatternMatcher compiles tree patterns, and matches parse trees against them, as the tree pattern
+// matcher of the other ANTLR runtimes does. A tree pattern is a fragment of the language of a grammar, parsed
+// with one of its rules, in which tags stand for parts of the tree:
+//
+//	<ID>         any single ID token
+//	<expr>       any subtree of the rule expr
+//	<name:ID>    any single ID token, which the match records under the label name as well as under ID
+//	<lhs:expr>   any subtree of the rule expr, which the match records under the label lhs as well as expr
+//
+// So, for instance, the pattern <ID> = <expr>; parsed with the rule of statements matches any assignment of
+// an expression to a variable. The rest of the pattern is tokenized with the lexer of the grammar, so white
+// space is insignificant where the lexer skips it, and a tree matches only if its tokens are those of the
+// pattern, with the same types and text. The delimiters of tags can be changed with
+// [ParseTreePatternMatcher.SetDelimiters], and a delimiter that is not meant to start or end a tag can be
+// escaped, by default with a backslash.
+//
+// Most uses need only [BaseParser.CompileParseTreePattern], which creates the matcher.
+//
+// Use:
+//
+//	m := antlr.NewParseTreePatternMatcher(parser.NewMyLexer(nil), p)
+//	pattern, err := m.Compile("<ID> = <expr>;", parser.MyParserRULE_statement)
+//	if err != nil {
+//	    return err
+//	}
+//	for _, match := range pattern.FindAll(tree, "//statement") {
+//	    fmt.Println(match.Get("ID").GetText(), match.Get("expr").GetText())
+//	}
+type ParseTreePatternMatcher struct {
+	lexer  Lexer
+	parser Parser
+
+	start  string
+	stop   string
+	escape string
+}
+
+// NewParseTreePatternMatcher creates a matcher of the patterns of the grammar of parser, whose text is
+// tokenized with lexer, which must embed [BaseLexer]. Rather than creating another lexer of the grammar, which
+// it cannot do for a generated lexer, the matcher gives lexer the text of a pattern as its input, and then
+// gives it back its own input, mode and position, so that lexer may be one in use, such as the lexer of the
+// parser's token stream, provided it is not lexing a token at the time.
+func NewParseTreePatternMatcher(lexer Lexer, parser Parser) *ParseTreePatternMatcher {
+	return &ParseTreePatternMatcher{
+		lexer:  lexer,
+		parser: parser,
+		start:  "<",
+		stop:   ">",
+		escape: "\\",
+	}
+}
+
+// SetDelimiters sets the delimiters that start and stop a tag, and the escape that precedes a delimiter which
+// does not, as in SetDelimiters("<<", ">>", "$$"). It returns an error if either delimiter is empty.
+func (m *ParseTreePatternMatcher) SetDelimiters(start, stop, escape string) error {
+	if start == "" {
+		return errors.New("start delimiter cannot be empty")
+	}
+	if stop == "" {
+		return errors.New("stop delimiter cannot be empty")
+	}
+	m.start, m.stop, m.escape = start, stop, escape
+	return nil
+}
+
+// GetLexer returns the lexer that tokenizes the text of patterns.
+func (m *ParseTreePatternMatcher) GetLexer() Lexer {
+	return m.lexer
+}
+
+// GetParser returns the parser whose grammar the patterns are in.
+func (m *ParseTreePatternMatcher) GetParser() Parser {
+	return m.parser
+}
+
+// Matches returns true if tree matches pattern, compiled with the rule patternRuleIndex, or an error if the
+// pattern cannot be compiled.
+func (m *ParseTreePatternMatcher) Matches(tree ParseTree, pattern string, patternRuleIndex int) (bool, error) {
+	p, err := m.Compile(pattern, patternRuleIndex)
+	if err != nil {
+		return false, err
+	}
+	return m.MatchesPattern(tree, p), nil
+}
+
+// MatchesPattern returns true if tree matches the compiled pattern.
+func (m *ParseTreePatternMatcher) MatchesPattern(tree ParseTree, pattern *ParseTreePattern) bool {
+	return m.matchImpl(tree, pattern.patternTree, make(map[string][]ParseTree)) == nil
+}
+
+// Match matches tree against pattern, compiled with the rule patternRuleIndex, and returns the result, which
+// records the parts of the tree that the tags of the pattern matched. It returns an error if the pattern
+// cannot be compiled.
+func (m *ParseTreePatternMatcher) Match(tree ParseTree, pattern string, patternRuleIndex int) (*ParseTreeMatch, error) {
+	p, err := m.Compile(pattern, patternRuleIndex)
+	if err != nil {
+		return nil, err
+	}
+	return m.MatchPattern(tree, p), nil
+}
+
+// MatchPattern matches tree against the compiled pattern, and returns the result, which records the parts of
+// the tree that the tags of the pattern matched.
+func (m *ParseTreePatternMatcher) MatchPattern(tree ParseTree, pattern *ParseTreePattern) *ParseTreeMatch {
+	labels := make(map[string][]ParseTree)
+	mismatched := m.matchImpl(tree, pattern.patternTree, labels)
+	return &ParseTreeMatch{
+		tree:           tree,
+		pattern:        pattern,
+		labels:         labels,
+		mismatchedNode: mismatched,
+	}
+}
+
+// Compile compiles pattern, parsing it with the rule patternRuleIndex. It returns an error if a tag names a
+// token type or rule the parser does not have, or if the pattern is not a complete, valid instance of the
+// rule.
+func (m *ParseTreePatternMatcher) Compile(pattern string, patternRuleIndex int) (*ParseTreePattern, error) {
+	if patternRuleIndex < 0 || patternRuleIndex >= len(m.parser.GetRuleNames()) {
+		return nil, fmt.Errorf("cannot compile pattern %q: invalid rule index %d", pattern, patternRuleIndex)
+	}
+	tokens, err := m.Tokenize(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	bypass := m.parser.GetATN().getBypassAltsATN()
+	if bypass == nil {
+		return nil, fmt.Errorf("cannot compile pattern %q: %w", pattern, ErrNoBypassAlts)
+	}
+	stream := NewCommonTokenStream(nil, TokenDefaultChannel)
+	stream.SetTokenSource(newListTokenSource(tokens))
+	interp := NewParserInterpreter(m.grammarFileName(), m.parser.GetLiteralNames(), m.parser.GetSymbolicNames(),
+		m.parser.GetRuleNames(), bypass, stream)
+	interp.RemoveErrorListeners()
+	interp.SetErrorHandler(NewBailErrorStrategy())
+
+	tree := interp.Parse(patternRuleIndex)
+	if err := interp.GetCancellationError(); err != nil {
+		return nil, fmt.Errorf("cannot compile pattern %q: %w", pattern, err)
+	}
+	if stream.LA(1) != TokenEOF {
+		return nil, fmt.Errorf("cannot compile pattern %q: the rule %s does not consume the whole pattern",
+			pattern, m.parser.GetRuleNames()[patternRuleIndex])
+	}
+	return &ParseTreePattern{
+		matcher:          m,
+		pattern:          pattern,
+		patternRuleIndex: patternRuleIndex,
+		patternTree:      tree,
+	}, nil
+}
+
+// grammarFileName returns the name of the grammar file of the parser, if it has one.
+func (m *ParseTreePatternMatcher) grammarFileName() string {
+	if g, ok := m.parser.(interface{ GetGrammarFileName() string }); ok {
+		return g.GetGrammarFileName()
+	}
+	return ""
+}
+
+// matchImpl matches tree against patternTree, adding the parts of tree that tags match to labels. It returns
+// the first node of tree that does not match, or nil if tree matches.
+func (m *ParseTreePatternMatcher) matchImpl(tree, patternTree ParseTree, labels map[string][]ParseTree) ParseTree {
+	// x and <ID>, x and y, or x and x
+	if t1, ok := tree.(TerminalNode); ok {
+		t2, ok := patternTree.(TerminalNode)
+		if !ok || t1.GetSymbol().GetTokenType() != t2.GetSymbol().GetTokenType() {
+			return tree
+		}
+		if tag, ok := t2.GetSymbol().(*TokenTagToken); ok {
+			labels[tag.tokenName] = append(labels[tag.tokenName], tree)
+			if tag.label != "" {
+				labels[tag.label] = append(labels[tag.label], tree)
+			}
+			return nil
+		}
+		// the text of an EOF token depends on its source, so that of the pattern is not that of the tree
+		if t1.GetSymbol().GetTokenType() != TokenEOF && t1.GetText() != t2.GetText() {
+			return tree
+		}
+		return nil
+	}
+
+	r1, ok1 := tree.(ParserRuleContext)
+	r2, ok2 := patternTree.(ParserRuleContext)
+	if !ok1 || !ok2 {
+		return tree
+	}
+	// (expr ...) and <expr>
+	if tag := ruleTagToken(r2); tag != nil {
+		if r1.GetRuleIndex() != r2.GetRuleIndex() {
+			return tree
+		}
+		labels[tag.ruleName] = append(labels[tag.ruleName], tree)
+		if tag.label != "" {
+			labels[tag.label] = append(labels[tag.label], tree)
+		}
+		return nil
+	}
+	// (expr ...) and (expr ...)
+	if r1.GetChildCount() != r2.GetChildCount() {
+		return tree
+	}
+	for i := 0; i < r1.GetChildCount(); i++ {
+		c1, _ := r1.GetChild(i).(ParseTree)
+		c2, _ := r2.GetChild(i).(ParseTree)
+		if mismatched := m.matchImpl(c1, c2, labels); mismatched != nil {
+			return mismatched
+		}
+	}
+	return nil
+}
+
+// ruleTagToken returns the tag of a rule tag in a pattern tree, whose context has the tag as its only child,
+// or nil if t is not one.
+func ruleTagToken(t ParserRuleContext) *RuleTagToken {
+	if t.GetChildCount() != 1 {
+		return nil
+	}
+	if c, ok := t.GetChild(0).(TerminalNode); ok {
+		if tag, ok := c.GetSymbol().(*RuleTagToken); ok {
+			return tag
+		}
+	}
+	return nil
+}
+
+// patternLexer is implemented by lexers that embed BaseLexer, which a ParseTreePatternMatcher requires to
+// tokenize patterns.
+type patternLexer interface {
+	SetInputStream(CharStream)
+	saveInput() lexerInput
+	restoreInput(saved lexerInput)
+}
+
+// Tokenize splits pattern into tokens, with a [TokenTagToken] or [RuleTagToken] for each tag, and the tokens
+// the lexer finds in the text between them.
+func (m *ParseTreePatternMatcher) Tokenize(pattern string) ([]Token, error) {
+	chunks, err := m.split(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	lexer, ok := m.lexer.(patternLexer)
+	if !ok {
+		return nil, fmt.Errorf("cannot tokenize pattern %q: the lexer does not embed BaseLexer", pattern)
+	}
+	defer lexer.restoreInput(lexer.saveInput())
+
+	var tokens []Token
+	for _, chunk := range chunks {
+		if !chunk.isTag {
+			lexer.SetInputStream(NewInputStream(chunk.text))
+			for t := m.lexer.NextToken(); t.GetTokenType() != TokenEOF; t = m.lexer.NextToken() {
+				tokens = append(tokens, t)
+			}
+			continue
+		}
+
+		first, _ := utf8.DecodeRuneInString(chunk.tag)
+		switch {
+		case unicode.IsUpper(first):
+			ttype, ok := tokenTypeMap(m.parser.GetLiteralNames(), m.parser.GetSymbolicNames())[chunk.tag]
+			if !ok {
+				return nil, fmt.Errorf("unknown token %s in pattern %q", chunk.tag, pattern)
+			}
+			tokens = append(tokens, NewTokenTagToken(chunk.tag, ttype, chunk.label))
+		case unicode.IsLower(first):
+			ruleIndex, ok := ruleIndexMap(m.parser.GetRuleNames())[chunk.tag]
+			if !ok {
+				return nil, fmt.Errorf("unknown rule %s in pattern %q", chunk.tag, pattern)
+			}
+			bypass := m.parser.GetATN().getBypassAltsATN()
+			if bypass == nil {
+				return nil, fmt.Errorf("cannot tokenize pattern %q: %w", pattern, ErrNoBypassAlts)
+			}
+			tokens = append(tokens, NewRuleTagToken(chunk.tag, bypass.ruleToTokenType[ruleIndex], chunk.label))
+		default:
+			return nil, fmt.Errorf("invalid tag %q in pattern %q", chunk.tag, pattern)
+		}
+	}
+	return tokens, nil
+}
+
+// patternChunk is a tag of a pattern, or the text between two tags.
+type patternChunk struct {
+	isTag bool
+
+	// text is the text of a text chunk, with escapes removed
+	text string
+
+	// tag is the token or rule name of a tag, and label its label, if it has one
+	tag   string
+	label string
+}
+
+// split splits pattern into its tags and the text between them.
+func (m *ParseTreePatternMatcher) split(pattern string) ([]patternChunk, error) {
+	// find the positions of all the delimiters first, skipping those that are escaped
+	var starts, stops []int
+	for p := 0; p < len(pattern); {
+		switch {
+		case m.escape != "" && strings.HasPrefix(pattern[p:], m.escape+m.start):
+			p += len(m.escape) + len(m.start)
+		case m.escape != "" && strings.HasPrefix(pattern[p:], m.escape+m.stop):
+			p += len(m.escape) + len(m.stop)
+		case strings.HasPrefix(pattern[p:], m.start):
+			starts = append(starts, p)
+			p += len(m.start)
+		case strings.HasPrefix(pattern[p:], m.stop):
+			stops = append(stops, p)
+			p += len(m.stop)
+		default:
+			p++
+		}
+	}
+	if len(starts) > len(stops) {
+		return nil, fmt.Errorf("unterminated tag in pattern %q", pattern)
+	}
+	if len(starts) < len(stops) {
+		return nil, fmt.Errorf("missing start tag in pattern %q", pattern)
+	}
+	for i := range starts {
+		if starts[i] >= stops[i] {
+			return nil, fmt.Errorf("tag delimiters out of order in pattern %q", pattern)
+		}
+	}
+
+	var chunks []patternChunk
+	text := func(s string) {
+		if m.escape != "" {
+			s = strings.ReplaceAll(s, m.escape, "")
+		}
+		chunks = append(chunks, patternChunk{text: s})
+	}
+	if len(starts) == 0 {
+		text(pattern)
+		return chunks, nil
+	}
+	if starts[0] > 0 {
+		text(pattern[:starts[0]])
+	}
+	for i := range starts {
+		tag := pattern[starts[i]+len(m.start) : stops[i]]
+		chunk := patternChunk{isTag: true, tag: tag}
+		if colon := strings.IndexByte(tag, ':'); colon >= 0 {
+			chunk.label, chunk.tag = tag[:colon], tag[colon+1:]
+		}
+		chunks = append(chunks, chunk)
+		if i+1 < len(starts) {
+			text(pattern[stops[i]+len(m.stop) : starts[i+1]])
+		}
+	}
+	if afterLast := stops[len(stops)-1] + len(m.stop); afterLast < len(pattern) {
+		text(pattern[afterLast:])
+	}
+	return chunks, nil
+}
+
+// TokenTagToken is the token of a token tag such as <ID> or <name:ID> in a tree pattern, which matches any
+// single token of its type.
+type TokenTagToken struct {
+	*CommonToken
+	tokenName string
+	label     string
+}
+
+// NewTokenTagToken creates the token of a tag for the token type ttype, named tokenName, with the given label,
+// which is empty if the tag has none.
+func NewTokenTagToken(tokenName string, ttype int, label string) *TokenTagToken {
+	t := &TokenTagToken{
+		CommonToken: NewCommonToken(&TokenSourceCharStreamPair{}, ttype, TokenDefaultChannel, -1, -1),
+		tokenName:   tokenName,
+		label:       label,
+	}
+	t.text = tagText(tokenName, label)
+	return t
+}
+
+// GetTokenName returns the name of the token type of the tag.
+func (t *TokenTagToken) GetTokenName() string {
+	return t.tokenName
+}
+
+// GetLabel returns the label of the tag, or the empty string if it has none.
+func (t *TokenTagToken) GetLabel() string {
+	return t.label
+}
+
+// RuleTagToken is the token of a rule tag such as <expr> or <lhs:expr> in a tree pattern, which matches any
+// subtree of its rule. Its type is the imaginary token type that the bypass alternative of the rule matches,
+// see [BaseParser.GetATNWithBypassAlts].
+type RuleTagToken struct {
+	*CommonToken
+	ruleName string
+	label    string
+}
+
+// NewRuleTagToken creates the token of a tag for the rule named ruleName, with the bypass token type of the
+// rule and the given label, which is empty if the tag has none.
+func NewRuleTagToken(ruleName string, bypassTokenType int, label string) *RuleTagToken {
+	t := &RuleTagToken{
+		CommonToken: NewCommonToken(&TokenSourceCharStreamPair{}, bypassTokenType, TokenDefaultChannel, -1, -1),
+		ruleName:    ruleName,
+		label:       label,
+	}
+	t.text = tagText(ruleName, label)
+	return t
+}
+
+// GetRuleName returns the name of the rule of the tag.
+func (t *RuleTagToken) GetRuleName() string {
+	return t.ruleName
+}
+
+// GetLabel returns the label of the tag, or the empty string if it has none.
+func (t *RuleTagToken) GetLabel() string {
+	return t.label
+}
+
+// tagText returns the text of a tag, as it is written in the default delimiters.
+func tagText(name, label string) string {
+	if label != "" {
+		return "<" + label + ":" + name + ">"
+	}
+	return "<" + name + ">"
+}
+
+// listTokenSource is a [TokenSource] of a list of tokens, which returns an EOF token once the list is
+// exhausted.
+type listTokenSource struct {
+	tokens  []Token
+	i       int
+	eof     Token
+	factory TokenFactory
+}
+
+func newListTokenSource(tokens []Token) *listTokenSource {
+	return &listTokenSource{tokens: tokens, factory: CommonTokenFactoryDEFAULT}
+}
+
+func (s *listTokenSource) NextToken() Token {
+	if s.i < len(s.tokens) {
+		t := s.tokens[s.i]
+		s.i++
+		return t
+	}
+	if s.eof == nil {
+		start, line, column := -1, s.GetLine(), s.GetCharPositionInLine()
+		if n := len(s.tokens); n > 0 {
+			if stop := s.tokens[n-1].GetStop(); stop >= 0 {
+				start = stop + 1
+			}
+		}
+		s.eof = s.factory.Create(&TokenSourceCharStreamPair{s, s.GetInputStream()}, TokenEOF, "EOF", TokenDefaultChannel,
+			start, start-1, line, column)
+	}
+	return s.eof
+}
+
+func (s *listTokenSource) Skip() {}
+
+func (s *listTokenSource) More() {}
+
+// GetLine returns the line of the next token, or of the end of the last.
+func (s *listTokenSource) GetLine() int {
+	if s.i < len(s.tokens) {
+		return s.tokens[s.i].GetLine()
+	}
+	if s.eof != nil {
+		return s.eof.GetLine()
+	}
+	if n := len(s.tokens); n > 0 {
+		return s.tokens[n-1].GetLine()
+	}
+	return 1
+}
+
+// GetCharPositionInLine returns the column of the next token, or of the end of the last.
+func (s *listTokenSource) GetCharPositionInLine() int {
+	if s.i < len(s.tokens) {
+		return s.tokens[s.i].GetColumn()
+	}
+	if s.eof != nil {
+		return s.eof.GetColumn()
+	}
+	if n := len(s.tokens); n > 0 {
+		last := s.tokens[n-1]
+		if text := last.GetText(); text != "" && last.GetColumn() >= 0 {
+			return last.GetColumn() + len([]rune(text))
+		}
+	}
+	return 0
+}
+
+// GetInputStream returns the input stream of the next token, or of the last.
+func (s *listTokenSource) GetInputStream() CharStream {
+	if s.i < len(s.tokens) {
+		return s.tokens[s.i].GetInputStream()
+	}
+	if s.eof != nil {
+		return s.eof.GetInputStream()
+	}
+	if n := len(s.tokens); n > 0 {
+		return s.tokens[n-1].GetInputStream()
+	}
+	return nil
+}
+
+func (s *listTokenSource) GetSourceName() string {
+	if input := s.GetInputStream(); input != nil {
+		return input.GetSourceName()
+	}
+	return "List"
+}
+
+func (s *listTokenSource) setTokenFactory(factory TokenFactory) {
+	s.factory = factory
+}
+
+func (s *listTokenSource) GetTokenFactory() TokenFactory {
+	return s.factory
+}
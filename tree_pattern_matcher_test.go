@@ -0,0 +1,106 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetATNWithBypassAlts(t *testing.T) {
+	p := newListParser(nil)
+	bypass, err := p.GetATNWithBypassAlts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := newListParser(nil).GetATNWithBypassAlts(); again != bypass {
+		t.Error("the ATN with bypass alternatives is not shared by the parsers of the ATN")
+	}
+	// Each rule is given an imaginary token type beyond those of the grammar
+	if types := bypass.ruleToTokenType; len(types) != 2 || types[listRuleS] != listWS+1 || types[listRuleItem] != listWS+2 {
+		t.Errorf("bypass token types %v", types)
+	}
+
+	built := NewBaseParser(nil)
+	built.Interpreter = NewParserATNSimulator(built, NewATN(ATNTypeParser, 1), nil, nil)
+	if bypass, err := built.GetATNWithBypassAlts(); bypass != nil || !errors.Is(err, ErrNoBypassAlts) {
+		t.Errorf("GetATNWithBypassAlts of an ATN that was not deserialized = %v, %v", bypass, err)
+	}
+}
+
+func TestParseTreePattern(t *testing.T) {
+	p, tree := listParse("a b + c d")
+	input := p.GetTokenStream().GetTokenSource().(Lexer).GetInputStream()
+
+	sum, err := p.CompileParseTreePattern("<x:ID> + <ID>", listRuleItem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.GetTokenStream().GetTokenSource().(Lexer).GetInputStream() != input {
+		t.Error("the lexer was not given back its input")
+	}
+	matches, err := sum.FindAll(tree, "//item")
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("FindAll() = %v, %v", matches, err)
+	}
+	m := matches[0]
+	if m.Get("x").GetText() != "b" || m.Get("ID").GetText() != "c" || len(m.GetAll("ID")) != 2 || m.GetAll("y") != nil {
+		t.Errorf("%s: x = %s, ID = %s", m, m.Get("x").GetText(), m.Get("ID").GetText())
+	}
+	if m.GetTree() != tree.GetChild(1) || m.GetPattern() != sum || m.String() != "Match succeeded; found 2 labels" {
+		t.Errorf("the match is %s of %s", m, m.GetPattern())
+	}
+	if m := sum.Match(tree.GetChild(0).(ParseTree)); m.Succeeded() || m.GetMismatchedNode() != tree.GetChild(0) {
+		t.Errorf("a lone ID matched %s", sum)
+	}
+
+	items, err := p.CompileParseTreePattern("<item> <last:item>", listRuleS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items.Matches(tree) {
+		t.Errorf("the tree of three items matched %s", items)
+	}
+	_, two := listParse("a b + c")
+	if m := items.Match(two); !m.Succeeded() || len(m.GetAll("item")) != 2 || m.Get("last").GetText() != "b+c" {
+		t.Errorf("%s: item = %v, last = %v", m, m.GetAll("item"), m.Get("last"))
+	}
+
+	// the tokens of a pattern must have the text of the tokens they match
+	literal, err := p.CompileParseTreePattern("b + d", listRuleItem)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := literal.Match(tree.GetChild(1).(ParseTree)); m.Succeeded() || m.GetMismatchedNode().GetText() != "c" {
+		t.Errorf("b + c matched %s, or failed at %v", literal, m.GetMismatchedNode())
+	}
+
+	m2 := NewParseTreePatternMatcher(p.GetTokenStream().GetTokenSource().(Lexer), p)
+	if err := m2.SetDelimiters("<<", ">>", ""); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := m2.Matches(tree.GetChild(1).(ParseTree), "<<ID>> + <<ID>>", listRuleItem); !ok || err != nil {
+		t.Errorf("Matches() with << >> = %v, %v", ok, err)
+	}
+	if err := m2.SetDelimiters("", ">>", ""); err == nil {
+		t.Error("SetDelimiters() with no start delimiter did not fail")
+	}
+
+	for pattern, ruleIndex := range map[string]int{
+		"<ID":     listRuleItem,
+		"ID>":     listRuleItem,
+		"<Foo>":   listRuleItem,
+		"<foo>":   listRuleItem,
+		"<1>":     listRuleItem,
+		"+ a":     listRuleItem,
+		"a b":     listRuleItem,
+		"<ID>":    2,
+		"<ID> <<": listRuleS,
+	} {
+		if compiled, err := p.CompileParseTreePattern(pattern, ruleIndex); err == nil {
+			t.Errorf("%q compiled to %s", pattern, compiled.GetPatternTree().ToStringTree(nil, p))
+		}
+	}
+}
@@ -4,7 +4,10 @@
 
 package antlr
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 /** A set of utility routines useful for all kinds of ANTLR trees. */
 
@@ -36,6 +39,49 @@ func TreesStringTree(tree Tree, ruleNames []string, recog Recognizer) string {
 	return res
 }
 
+// TreesDotTree renders a whole tree in the DOT language of Graphviz, so that it can be viewed as a graph,
+// much as the -gui option of the Java TestRig shows it. Rule nodes are drawn as ellipses and token nodes as
+// boxes, with error nodes in red. Node text is produced as for [TreesStringTree].
+//
+// Use:
+//
+//	dot := antlr.TreesDotTree(tree, nil, p) // then run: dot -Tsvg tree.dot > tree.svg
+func TreesDotTree(tree Tree, ruleNames []string, recog Recognizer) string {
+	if recog != nil {
+		ruleNames = recog.GetRuleNames()
+	}
+	var sb strings.Builder
+	sb.WriteString("digraph tree {\n\tordering=out;\n")
+	n := 0
+	treesDotNode(&sb, tree, ruleNames, &n)
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// treesDotNode writes the node for t and its subtree, numbering the nodes in preorder, and returns the
+// number of t.
+func treesDotNode(sb *strings.Builder, t Tree, ruleNames []string, n *int) int {
+	id := *n
+	*n++
+	shape := "ellipse"
+	color := ""
+	switch t.(type) {
+	case ErrorNode:
+		shape = "box"
+		color = ", color=red, fontcolor=red"
+	case TerminalNode:
+		shape = "box"
+	}
+	label := EscapeWhitespace(TreesGetNodeText(t, ruleNames, nil), false)
+	label = dotEscaper.Replace(label)
+	_, _ = fmt.Fprintf(sb, "\tn%d [label=\"%s\", shape=%s%s];\n", id, label, shape, color)
+	for i := 0; i < t.GetChildCount(); i++ {
+		child := treesDotNode(sb, t.GetChild(i), ruleNames, n)
+		_, _ = fmt.Fprintf(sb, "\tn%d -> n%d;\n", id, child)
+	}
+	return id
+}
+
 func TreesGetNodeText(t Tree, ruleNames []string, recog Parser) string {
 	if recog != nil {
 		ruleNames = recog.GetRuleNames()
@@ -47,7 +93,7 @@ func TreesGetNodeText(t Tree, ruleNames []string, recog Parser) string {
 			t3 := t2.GetRuleContext()
 			altNumber := t3.GetAltNumber()
 
-			if altNumber != ATNInvalidAltNumber {
+			if runtimeConfig.altNumbersInTreeText && altNumber != ATNInvalidAltNumber {
 				return fmt.Sprintf("%s:%d", ruleNames[t3.GetRuleIndex()], altNumber)
 			}
 			return ruleNames[t3.GetRuleIndex()]
@@ -0,0 +1,285 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrTextReleased is the error, possibly wrapped, returned when text is requested from an
+// [UnbufferedCharStream] for characters that it no longer holds.
+var ErrTextReleased = errors.New("text no longer buffered")
+
+// UnbufferedCharStream is a [CharStream] that reads its characters from an [io.Reader] as the lexer asks for
+// them, rather than reading all the input into memory first as [NewIoStream] does, so that input much larger
+// than memory, such as a log file of many gigabytes, can be lexed.
+//
+// The stream holds only a window of the characters it has read: from the start of the oldest mark that has not
+// been released, or just the current character if there is none, to the furthest character looked ahead at.
+// The lexer marks the start of each token while it matches it, so the window is about the size of the longest
+// token, and text can only be had for characters within it. It cannot Seek outside the window, and it cannot
+// know its size until all the input has been read, so Size returns the number of characters read so far.
+//
+// As the text of a token cannot be taken from the stream once the lexer has moved past it, the lexer must copy
+// the text into each token as it creates it, and the tokens must be parsed with a token stream that does not
+// rewind, such as a [CommonTokenStream] that is only read forward:
+//
+//	input := antlr.NewUnbufferedCharStream(os.Stdin)
+//	lexer := parser.NewLogLexer(input)
+//	lexer.SetTokenFactory(antlr.NewCommonTokenFactory(true))
+//	for t := lexer.NextToken(); t.GetTokenType() != antlr.TokenEOF; t = lexer.NextToken() {
+//	    handle(t)
+//	}
+//	if err := input.Err(); err != nil {
+//	    return err
+//	}
+type UnbufferedCharStream struct {
+	name   string
+	reader io.RuneReader
+
+	// data holds the window of characters, ending with the eofChar once the end of input has been read
+	data []rune
+
+	// p is the index in data of the current character, which is data[len(data)] if it has not been read yet
+	p int
+
+	// numMarkers is the number of marks that have not been released. The window is only discarded, up to the
+	// current character, when there are none.
+	numMarkers int
+
+	// lastChar is the character before the current one, for LA(-1), and lastCharBufferStart the character
+	// before the start of the window, which becomes lastChar on a Seek to the start of the window
+	lastChar            int
+	lastCharBufferStart int
+
+	// currentCharIndex is the index in the input of the current character
+	currentCharIndex int
+
+	// err is the error the reader failed with, other than io.EOF
+	err error
+}
+
+// eofChar marks the end of the input in the data of an [UnbufferedCharStream].
+const eofChar = rune(TokenEOF)
+
+// NewUnbufferedCharStream creates a stream that reads the characters of reader as UTF-8 as they are needed.
+// The reader is wrapped in a [bufio.Reader] unless it is an [io.RuneReader] already. A read that fails is taken
+// to be the end of the input, and the error is returned by [UnbufferedCharStream.Err].
+func NewUnbufferedCharStream(reader io.Reader) *UnbufferedCharStream {
+	rr, ok := reader.(io.RuneReader)
+	if !ok {
+		rr = bufio.NewReader(reader)
+	}
+	return &UnbufferedCharStream{
+		name:                "<unknown>",
+		reader:              rr,
+		data:                make([]rune, 0, 256),
+		lastChar:            -1,
+		lastCharBufferStart: -1,
+	}
+}
+
+// SetSourceName sets the name returned by GetSourceName, such as the name of the file being read.
+func (u *UnbufferedCharStream) SetSourceName(name string) {
+	u.name = name
+}
+
+// Err returns the error that reading the input failed with, if any. The end of the input is not an error.
+func (u *UnbufferedCharStream) Err() error {
+	return u.err
+}
+
+// Consume moves to the next character of the input. It discards the window up to the new current character
+// if there are no marks.
+func (u *UnbufferedCharStream) Consume() {
+	if u.LA(1) == TokenEOF {
+		panic("cannot consume EOF")
+	}
+
+	u.lastChar = int(u.data[u.p])
+	if u.p == len(u.data)-1 && u.numMarkers == 0 {
+		// the last character of the window has been consumed, and nothing needs it; start a new window
+		u.data = u.data[:0]
+		u.p = -1
+		u.lastCharBufferStart = u.lastChar
+	}
+	u.p++
+	u.currentCharIndex++
+	u.sync(1)
+}
+
+// sync makes sure that the window holds the characters up to want characters ahead of the current one,
+// unless the input ends before them.
+func (u *UnbufferedCharStream) sync(want int) {
+	if need := u.p + want - len(u.data); need > 0 {
+		u.fill(need)
+	}
+}
+
+// fill reads up to n characters into the window, and returns the number read, which is less than n only at
+// the end of the input.
+func (u *UnbufferedCharStream) fill(n int) int {
+	for i := 0; i < n; i++ {
+		if len(u.data) > 0 && u.data[len(u.data)-1] == eofChar {
+			return i
+		}
+		r, _, err := u.reader.ReadRune()
+		if err != nil {
+			if err != io.EOF {
+				u.err = err
+			}
+			r = eofChar
+		}
+		u.data = append(u.data, r)
+	}
+	return n
+}
+
+// LA returns the character offset characters from the current one, reading it if need be, or [TokenEOF]
+// beyond the end of the input. LA(-1) is the character before the current one; looking further back is not
+// supported.
+func (u *UnbufferedCharStream) LA(offset int) int {
+	if offset == -1 {
+		return u.lastChar
+	}
+	if offset == 0 {
+		return 0
+	}
+	if offset < -1 {
+		panic("UnbufferedCharStream cannot look back more than one character")
+	}
+	u.sync(offset)
+	index := u.p + offset - 1
+	if index >= len(u.data) {
+		return TokenEOF
+	}
+	return int(u.data[index])
+}
+
+// Mark keeps the window from the current character onwards until the mark is released, so that the stream can
+// Seek back to it and return its text. Marks must be released in the reverse order they were made.
+func (u *UnbufferedCharStream) Mark() int {
+	if u.numMarkers == 0 {
+		u.lastCharBufferStart = u.lastChar
+	}
+	marker := -u.numMarkers - 1
+	u.numMarkers++
+	return marker
+}
+
+// Release releases the given mark, which must be the last one made that has not been released. Once every
+// mark has been released, the window is discarded up to the current character.
+func (u *UnbufferedCharStream) Release(marker int) {
+	if marker != -u.numMarkers {
+		panic("release() called with an invalid marker.")
+	}
+	u.numMarkers--
+	if u.numMarkers == 0 && u.p > 0 {
+		// shift the window so that it starts with the current character
+		u.data = u.data[:copy(u.data, u.data[u.p:])]
+		u.p = 0
+		u.lastCharBufferStart = u.lastChar
+	}
+}
+
+// Index returns the index in the input of the current character.
+func (u *UnbufferedCharStream) Index() int {
+	return u.currentCharIndex
+}
+
+// bufferStartIndex returns the index in the input of the first character of the window.
+func (u *UnbufferedCharStream) bufferStartIndex() int {
+	return u.currentCharIndex - u.p
+}
+
+// Seek moves to the character with the given index, which must be within the window, or ahead of it, in which
+// case the characters up to it are read, and the stream moves to the end of the input if it ends before them.
+// Seeking forward does not consume the characters passed, so does not discard the window.
+func (u *UnbufferedCharStream) Seek(index int) {
+	if index == u.currentCharIndex {
+		return
+	}
+	if index > u.currentCharIndex {
+		u.sync(index - u.currentCharIndex)
+		index = min(index, u.bufferStartIndex()+len(u.data)-1)
+	}
+
+	i := index - u.bufferStartIndex()
+	if i < 0 {
+		panic(fmt.Sprintf("cannot seek to index %d, before the buffered window starting at %d", index, u.bufferStartIndex()))
+	}
+	if i >= len(u.data) {
+		panic(fmt.Sprintf("cannot seek to index %d, after the buffered window ending at %d", index, u.bufferStartIndex()+len(u.data)-1))
+	}
+
+	u.p = i
+	u.currentCharIndex = index
+	if u.p == 0 {
+		u.lastChar = u.lastCharBufferStart
+	} else {
+		u.lastChar = int(u.data[u.p-1])
+	}
+}
+
+// Size returns the number of characters read so far, which is the size of the input once the end of it has
+// been read. The size of the input cannot be known before then.
+func (u *UnbufferedCharStream) Size() int {
+	n := u.bufferStartIndex() + len(u.data)
+	if len(u.data) > 0 && u.data[len(u.data)-1] == eofChar {
+		n--
+	}
+	return n
+}
+
+func (u *UnbufferedCharStream) GetSourceName() string {
+	return u.name
+}
+
+// GetText returns the text from the start to the stop index, inclusive, which must be within the window. A stop
+// index beyond the end of the input is treated as the end of the input. If the range is invalid, or outside the
+// window, the empty string is returned; use [UnbufferedCharStream.GetTextChecked] to distinguish this from an
+// empty range.
+func (u *UnbufferedCharStream) GetText(start, stop int) string {
+	text, _ := u.GetTextChecked(start, stop)
+	return text
+}
+
+// GetTextChecked is like [UnbufferedCharStream.GetText], but returns an error wrapping [ErrInvalidInterval] if
+// start is negative, or more than one past stop, and an error wrapping [ErrTextReleased] if the range is not
+// within the window.
+func (u *UnbufferedCharStream) GetTextChecked(start, stop int) (string, error) {
+	if start < 0 || start > stop+1 {
+		return "", fmt.Errorf("%w: %d..%d", ErrInvalidInterval, start, stop)
+	}
+	if size := u.Size(); stop >= size {
+		stop = size - 1
+	}
+	if start > stop {
+		return "", nil
+	}
+	bufferStart := u.bufferStartIndex()
+	if start < bufferStart || stop >= bufferStart+len(u.data) {
+		return "", fmt.Errorf("%w: %d..%d is outside %d..%d", ErrTextReleased, start, stop, bufferStart, bufferStart+len(u.data)-1)
+	}
+	return string(u.data[start-bufferStart : stop-bufferStart+1]), nil
+}
+
+// GetTextFromTokens returns the text from the first character of the start token to the last character of the
+// stop token, which must be within the window.
+func (u *UnbufferedCharStream) GetTextFromTokens(start, stop Token) string {
+	if start != nil && stop != nil {
+		return u.GetText(start.GetStart(), stop.GetStop())
+	}
+	return ""
+}
+
+// GetTextFromInterval returns the text within the interval, where the Stop of the interval is inclusive. See
+// [UnbufferedCharStream.GetText].
+func (u *UnbufferedCharStream) GetTextFromInterval(i Interval) string {
+	return u.GetText(i.Start, i.Stop)
+}
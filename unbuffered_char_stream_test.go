@@ -0,0 +1,77 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestUnbufferedCharStream(t *testing.T) {
+	u := NewUnbufferedCharStream(strings.NewReader("añb日c"))
+	if u.LA(1) != 'a' || u.LA(2) != 'ñ' || u.LA(4) != '日' || u.LA(-1) != -1 || u.Index() != 0 {
+		t.Fatalf("LA() = %c %c %c", u.LA(1), u.LA(2), u.LA(4))
+	}
+	u.Consume()
+	m := u.Mark()
+	u.Consume()
+	u.Consume()
+	if u.LA(-1) != 'b' || u.Index() != 3 || u.GetText(1, 2) != "ñb" {
+		t.Errorf("at %d after %c with text %q", u.Index(), u.LA(-1), u.GetText(1, 2))
+	}
+	u.Seek(1)
+	if u.LA(1) != 'ñ' || u.LA(-1) != 'a' {
+		t.Errorf("Seek(1) moved to %c after %c", u.LA(1), u.LA(-1))
+	}
+	u.Seek(3)
+	u.Release(m)
+
+	// the window now starts at the current character
+	if _, err := u.GetTextChecked(1, 2); !errors.Is(err, ErrTextReleased) {
+		t.Errorf("GetTextChecked() of released text = %v", err)
+	}
+	if _, err := u.GetTextChecked(3, 1); !errors.Is(err, ErrInvalidInterval) {
+		t.Errorf("GetTextChecked() of an invalid interval = %v", err)
+	}
+	if u.Size() != 4 {
+		t.Errorf("Size() before the end of the input = %d, want 4", u.Size())
+	}
+	u.Seek(10)
+	if u.Index() != 5 || u.LA(1) != TokenEOF || u.Size() != 5 || u.GetText(3, 10) != "日c" {
+		t.Errorf("Seek(10) moved to %d, of %d, with text %q", u.Index(), u.Size(), u.GetText(3, 10))
+	}
+	if u.Err() != nil {
+		t.Errorf("Err() at the end of the input = %v", u.Err())
+	}
+
+	failing := NewUnbufferedCharStream(iotest.TimeoutReader(strings.NewReader("a")))
+	failing.Consume()
+	if failing.LA(1) != TokenEOF || !errors.Is(failing.Err(), iotest.ErrTimeout) {
+		t.Errorf("a failed read gave %d with error %v", failing.LA(1), failing.Err())
+	}
+}
+
+func TestUnbufferedCharStreamLexer(t *testing.T) {
+	text := strings.Repeat("abc + de fgh ", 5000)
+	u := NewUnbufferedCharStream(iotest.OneByteReader(strings.NewReader(text)))
+	lexer := newListLexer(u)
+	lexer.SetTokenFactory(NewCommonTokenFactory(true))
+	var got []string
+	window := 0
+	for tok := lexer.NextToken(); tok.GetTokenType() != TokenEOF; tok = lexer.NextToken() {
+		got = append(got, tok.GetText())
+		window = max(window, len(u.data))
+	}
+	if want := listTokens(newListLexer(nil), text); !slices.Equal(got, want) {
+		t.Errorf("%d tokens, want %d", len(got), len(want))
+	}
+	// the window holds little more than a token
+	if window > 8 {
+		t.Errorf("the window grew to %d characters", window)
+	}
+}
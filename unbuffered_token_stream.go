@@ -0,0 +1,283 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// UnbufferedTokenStream is a [TokenStream] that fetches tokens from its token source as the parser asks for
+// them, and keeps only a window of them, rather than every token of the input as a [CommonTokenStream] does, so
+// that the memory a forward-only parse of a huge input needs stays bounded.
+//
+// The window runs from the start of the oldest mark that has not been released, or just the current token if
+// there is none, to the furthest token looked ahead at. Adaptive prediction marks the tokens it looks ahead
+// at, so the window is about as long as the longest lookahead of the parse. Tokens can only be had, and the
+// stream can only Seek, within the window, and the stream cannot know its size until it has fetched the EOF
+// token, so Size returns the number of tokens fetched so far.
+//
+// Like a [CommonTokenStream], the stream passes only the tokens on its channel to the parser, and drops the
+// rest as it fetches them, so the text it returns is the text of the tokens on its channel only. The index of
+// each token is set to its index in the stream. Build no parse tree, or one whose tokens are not used to find
+// text in the stream afterwards, and have the lexer copy the text of each token if it lexes an
+// [UnbufferedCharStream]:
+//
+//	lexer := parser.NewLogLexer(antlr.NewUnbufferedCharStream(reader))
+//	lexer.SetTokenFactory(antlr.NewCommonTokenFactory(true))
+//	p := parser.NewLogParser(antlr.NewUnbufferedTokenStream(lexer, antlr.TokenDefaultChannel))
+//	p.BuildParseTrees = false
+//	p.AddParseListener(handler)
+//	p.Log()
+type UnbufferedTokenStream struct {
+	tokenSource TokenSource
+	channel     int
+
+	// tokens holds the window of tokens, ending with the EOF token once it has been fetched
+	tokens []Token
+
+	// p is the index in tokens of the current token, which is tokens[len(tokens)] if it has not been fetched yet
+	p int
+
+	// numMarkers is the number of marks that have not been released. The window is only discarded, up to the
+	// current token, when there are none.
+	numMarkers int
+
+	// lastToken is the token before the current one, for LT(-1), and lastTokenBufferStart the token before the
+	// start of the window, which becomes lastToken on a Seek to the start of the window
+	lastToken            Token
+	lastTokenBufferStart Token
+
+	// currentTokenIndex is the index in the stream of the current token
+	currentTokenIndex int
+}
+
+var _ TokenStream = &UnbufferedTokenStream{}
+
+// NewUnbufferedTokenStream creates a stream that fetches the tokens of the given channel from tokenSource as
+// they are needed.
+func NewUnbufferedTokenStream(tokenSource TokenSource, channel int) *UnbufferedTokenStream {
+	return &UnbufferedTokenStream{
+		tokenSource: tokenSource,
+		channel:     channel,
+		tokens:      make([]Token, 0, 256),
+	}
+}
+
+// GetTokenSource returns the source the stream fetches its tokens from.
+func (u *UnbufferedTokenStream) GetTokenSource() TokenSource {
+	return u.tokenSource
+}
+
+// SetTokenSource makes the stream fetch its tokens from tokenSource, discarding the tokens it holds.
+func (u *UnbufferedTokenStream) SetTokenSource(tokenSource TokenSource) {
+	u.tokenSource = tokenSource
+	u.Reset()
+}
+
+// Reset discards the tokens the stream holds, so that it fetches tokens from the start again. The token source
+// must itself have been reset to the start of the input first.
+func (u *UnbufferedTokenStream) Reset() {
+	u.tokens = u.tokens[:0]
+	u.p = 0
+	u.numMarkers = 0
+	u.lastToken = nil
+	u.lastTokenBufferStart = nil
+	u.currentTokenIndex = 0
+}
+
+// LT returns the token k tokens from the current one, fetching it if need be, or the EOF token beyond the end
+// of the input. LT(-1) is the token before the current one; looking further back is not supported.
+func (u *UnbufferedTokenStream) LT(k int) Token {
+	if k == -1 {
+		return u.lastToken
+	}
+	if k == 0 {
+		return nil
+	}
+	if k < -1 {
+		panic("UnbufferedTokenStream cannot look back more than one token")
+	}
+	u.sync(k)
+	index := u.p + k - 1
+	if index >= len(u.tokens) {
+		// the EOF token is the last one in the window
+		return u.tokens[len(u.tokens)-1]
+	}
+	return u.tokens[index]
+}
+
+func (u *UnbufferedTokenStream) LA(i int) int {
+	t := u.LT(i)
+	if t == nil {
+		return TokenInvalidType
+	}
+	return t.GetTokenType()
+}
+
+// Get returns the token with the given index, which must be within the window.
+func (u *UnbufferedTokenStream) Get(index int) Token {
+	bufferStart := u.bufferStartIndex()
+	if index < bufferStart || index >= bufferStart+len(u.tokens) {
+		panic(fmt.Sprintf("token %d is outside the buffered window %d..%d", index, bufferStart, bufferStart+len(u.tokens)-1))
+	}
+	return u.tokens[index-bufferStart]
+}
+
+// Consume moves to the next token. It discards the window up to the new current token if there are no marks.
+func (u *UnbufferedTokenStream) Consume() {
+	if u.LA(1) == TokenEOF {
+		panic("cannot consume EOF")
+	}
+
+	u.lastToken = u.tokens[u.p]
+	if u.p == len(u.tokens)-1 && u.numMarkers == 0 {
+		// the last token of the window has been consumed, and nothing needs it; start a new window
+		u.tokens = u.tokens[:0]
+		u.p = -1
+		u.lastTokenBufferStart = u.lastToken
+	}
+	u.p++
+	u.currentTokenIndex++
+	u.sync(1)
+}
+
+// sync makes sure that the window holds the tokens up to want tokens ahead of the current one, unless the
+// input ends before them.
+func (u *UnbufferedTokenStream) sync(want int) {
+	if need := u.p + want - len(u.tokens); need > 0 {
+		u.fill(need)
+	}
+}
+
+// fill fetches up to n tokens on the channel of the stream into the window, and returns the number fetched,
+// which is less than n only at the end of the input.
+func (u *UnbufferedTokenStream) fill(n int) int {
+	for i := 0; i < n; i++ {
+		if len(u.tokens) > 0 && u.tokens[len(u.tokens)-1].GetTokenType() == TokenEOF {
+			return i
+		}
+		t := u.tokenSource.NextToken()
+		for t.GetChannel() != u.channel && t.GetTokenType() != TokenEOF {
+			t = u.tokenSource.NextToken()
+		}
+		t.SetTokenIndex(u.bufferStartIndex() + len(u.tokens))
+		u.tokens = append(u.tokens, t)
+	}
+	return n
+}
+
+// Mark keeps the window from the current token onwards until the mark is released, so that the stream can Seek
+// back to it. Marks must be released in the reverse order they were made.
+func (u *UnbufferedTokenStream) Mark() int {
+	if u.numMarkers == 0 {
+		u.lastTokenBufferStart = u.lastToken
+	}
+	marker := -u.numMarkers - 1
+	u.numMarkers++
+	return marker
+}
+
+// Release releases the given mark, which must be the last one made that has not been released. Once every mark
+// has been released, the window is discarded up to the current token.
+func (u *UnbufferedTokenStream) Release(marker int) {
+	if marker != -u.numMarkers {
+		panic("release() called with an invalid marker.")
+	}
+	u.numMarkers--
+	if u.numMarkers == 0 && u.p > 0 {
+		// shift the window so that it starts with the current token
+		n := copy(u.tokens, u.tokens[u.p:])
+		clear(u.tokens[n:])
+		u.tokens = u.tokens[:n]
+		u.p = 0
+		u.lastTokenBufferStart = u.lastToken
+	}
+}
+
+// Index returns the index in the stream of the current token.
+func (u *UnbufferedTokenStream) Index() int {
+	return u.currentTokenIndex
+}
+
+// bufferStartIndex returns the index in the stream of the first token of the window.
+func (u *UnbufferedTokenStream) bufferStartIndex() int {
+	return u.currentTokenIndex - u.p
+}
+
+// Seek moves to the token with the given index, which must be within the window, or ahead of it, in which case
+// the tokens up to it are fetched, and the stream moves to the EOF token if the input ends before them.
+func (u *UnbufferedTokenStream) Seek(index int) {
+	if index == u.currentTokenIndex {
+		return
+	}
+	if index > u.currentTokenIndex {
+		u.sync(index - u.currentTokenIndex)
+		index = min(index, u.bufferStartIndex()+len(u.tokens)-1)
+	}
+
+	i := index - u.bufferStartIndex()
+	if i < 0 {
+		panic(fmt.Sprintf("cannot seek to index %d, before the buffered window starting at %d", index, u.bufferStartIndex()))
+	}
+	if i >= len(u.tokens) {
+		panic(fmt.Sprintf("cannot seek to index %d, after the buffered window ending at %d", index, u.bufferStartIndex()+len(u.tokens)-1))
+	}
+
+	u.p = i
+	u.currentTokenIndex = index
+	if u.p == 0 {
+		u.lastToken = u.lastTokenBufferStart
+	} else {
+		u.lastToken = u.tokens[u.p-1]
+	}
+}
+
+// Size returns the number of tokens fetched so far, including the EOF token once it has been fetched, which is
+// the size of the stream from then on. The size cannot be known before then.
+func (u *UnbufferedTokenStream) Size() int {
+	return u.bufferStartIndex() + len(u.tokens)
+}
+
+func (u *UnbufferedTokenStream) GetSourceName() string {
+	return u.tokenSource.GetSourceName()
+}
+
+// GetAllText returns the text of the tokens in the window.
+func (u *UnbufferedTokenStream) GetAllText() string {
+	bufferStart := u.bufferStartIndex()
+	return u.GetTextFromInterval(NewInterval(bufferStart, bufferStart+len(u.tokens)-1))
+}
+
+// GetTextFromInterval returns the text of the tokens within the interval, where the Stop of the interval is
+// inclusive, not including the EOF token. The interval must be within the window; the empty string is returned
+// if it is not.
+func (u *UnbufferedTokenStream) GetTextFromInterval(interval Interval) string {
+	bufferStart := u.bufferStartIndex()
+	start, stop := interval.Start-bufferStart, interval.Stop-bufferStart
+	if start < 0 || stop < start || stop >= len(u.tokens) {
+		return ""
+	}
+
+	var sb strings.Builder
+	for _, t := range u.tokens[start : stop+1] {
+		if t.GetTokenType() == TokenEOF {
+			break
+		}
+		sb.WriteString(t.GetText())
+	}
+	return sb.String()
+}
+
+func (u *UnbufferedTokenStream) GetTextFromRuleContext(ctx RuleContext) string {
+	return u.GetTextFromInterval(ctx.GetSourceInterval())
+}
+
+func (u *UnbufferedTokenStream) GetTextFromTokens(start, end Token) string {
+	if start == nil || end == nil {
+		return ""
+	}
+	return u.GetTextFromInterval(NewInterval(start.GetTokenIndex(), end.GetTokenIndex()))
+}
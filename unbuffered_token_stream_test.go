@@ -0,0 +1,87 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnbufferedTokenStream(t *testing.T) {
+	var tokens []Token
+	for i, text := range []string{"a", " ", "b", "+", " ", "c"} {
+		tok := newTestToken(listID, i, i, 1, i)
+		tok.SetText(text)
+		if text == " " {
+			tok.channel = TokenHiddenChannel
+		}
+		tokens = append(tokens, tok)
+	}
+	tokens = append(tokens, newTestToken(TokenEOF, 6, 5, 1, 6))
+	u := NewUnbufferedTokenStream(&sliceTokenSource{tokens: tokens}, TokenDefaultChannel)
+
+	// the hidden tokens are dropped, and the others numbered by their place in the stream
+	if u.LT(1).GetText() != "a" || u.LT(3).GetText() != "+" || u.LT(3).GetTokenIndex() != 2 || u.LT(-1) != nil {
+		t.Fatalf("LT() = %s %s", u.LT(1), u.LT(3))
+	}
+	u.Consume()
+	m := u.Mark()
+	u.Consume()
+	u.Consume()
+	if u.LT(-1).GetText() != "+" || u.Index() != 3 || u.GetTextFromInterval(NewInterval(1, 2)) != "b+" {
+		t.Errorf("at %d after %s with text %q", u.Index(), u.LT(-1), u.GetTextFromInterval(NewInterval(1, 2)))
+	}
+	u.Seek(1)
+	if u.LT(1).GetText() != "b" || u.LT(-1).GetText() != "a" {
+		t.Errorf("Seek(1) moved to %s after %s", u.LT(1), u.LT(-1))
+	}
+	u.Seek(3)
+	u.Release(m)
+
+	// the window now starts at the current token
+	if got := u.GetTextFromInterval(NewInterval(1, 2)); got != "" {
+		t.Errorf("the text of released tokens is %q", got)
+	}
+	if u.Size() != 4 || u.Get(3).GetText() != "c" {
+		t.Errorf("Size() before the EOF token = %d", u.Size())
+	}
+	u.Seek(10)
+	if u.Index() != 4 || u.LA(1) != TokenEOF || u.LA(5) != TokenEOF || u.Size() != 5 || u.GetAllText() != "c" {
+		t.Errorf("Seek(10) moved to %d, of %d, with text %q", u.Index(), u.Size(), u.GetAllText())
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Get() of a released token did not panic")
+		}
+	}()
+	u.Get(0)
+}
+
+func TestUnbufferedTokenStreamParse(t *testing.T) {
+	input := strings.Repeat("a b + c ", 2000)
+	u := NewUnbufferedTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel)
+	p := newListParser(u)
+	p.BuildParseTrees = false
+	listener := &countingParseListener{}
+	p.AddParseListener(listener)
+	errors := new(messageRecorder)
+	p.RemoveErrorListeners()
+	p.AddErrorListener(errors)
+	window := 0
+	p.SetProgressCallback(1, func(ParseProgress) error {
+		window = max(window, len(u.tokens))
+		return nil
+	})
+	p.S()
+
+	// s, and the items, IDs and pluses entered and exited, and EOF
+	if len(errors.messages) != 0 || listener.events != 2+2*4000+6000+2000+1 || u.Size() != 8001 {
+		t.Errorf("%d events and %d tokens, with errors %v", listener.events, u.Size(), errors.messages)
+	}
+	// the window holds the lookahead of the item decision
+	if window > 4 {
+		t.Errorf("the window grew to %d tokens", window)
+	}
+}
@@ -230,15 +230,13 @@ func (a *AltDict) values() []interface{} {
 	return vs
 }
 
+// EscapeWhitespace escapes newlines, carriage returns and tabs in s, and shows spaces as the middle dot if
+// escapeSpaces is true. See [EscapeText] for more control over escaping.
 func EscapeWhitespace(s string, escapeSpaces bool) string {
-
-	s = strings.Replace(s, "\t", "\\t", -1)
-	s = strings.Replace(s, "\n", "\\n", -1)
-	s = strings.Replace(s, "\r", "\\r", -1)
 	if escapeSpaces {
-		s = strings.Replace(s, " ", "\u00B7", -1)
+		return EscapeText(s, EscapeLineBreaksAndTabs|EscapeSpaces)
 	}
-	return s
+	return EscapeText(s, EscapeLineBreaksAndTabs)
 }
 
 //goland:noinspection GoUnusedExportedFunction
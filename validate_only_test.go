@@ -0,0 +1,56 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"testing"
+)
+
+// countingParseListener counts the events it is given.
+type countingParseListener struct {
+	events int
+}
+
+func (l *countingParseListener) VisitTerminal(TerminalNode)       { l.events++ }
+func (l *countingParseListener) VisitErrorNode(ErrorNode)         { l.events++ }
+func (l *countingParseListener) EnterEveryRule(ParserRuleContext) { l.events++ }
+func (l *countingParseListener) ExitEveryRule(ParserRuleContext)  { l.events++ }
+
+func TestValidateOnly(t *testing.T) {
+	for _, input := range []string{"a b + c", "a +", "a + + b", "+ a b"} {
+		parse := func(validateOnly bool) (ParserRuleContext, []string, int) {
+			p := newListParser(NewCommonTokenStream(newListLexer(NewInputStream(input)), TokenDefaultChannel))
+			errs := &messageRecorder{}
+			listener := &countingParseListener{}
+			NewRuntimeConfig(WithValidateOnly(validateOnly), WithErrorListeners(errs)).Apply(p)
+			p.AddParseListener(listener)
+			return p.S(), errs.messages, listener.events
+		}
+		tree, errs, events := parse(false)
+		validated, validatedErrs, validatedEvents := parse(true)
+		if fmt.Sprint(validatedErrs) != fmt.Sprint(errs) {
+			t.Errorf("%q: errors %q, want %q", input, validatedErrs, errs)
+		}
+		if events == 0 || tree.GetChildCount() == 0 {
+			t.Fatalf("%q: the full parse built no tree", input)
+		}
+		if validatedEvents != 0 || validated.GetChildCount() != 0 || validated.GetStart() != nil || validated.GetStop() != nil {
+			t.Errorf("%q: validating called listeners %d times, and built %d children", input, validatedEvents,
+				validated.GetChildCount())
+		}
+	}
+
+	p := newListParser(nil)
+	NewRuntimeConfig(WithValidateOnly(true)).Apply(p)
+	if !p.IsValidateOnly() {
+		t.Error("the config did not set validate-only")
+	}
+	q := newListParser(nil)
+	SnapshotRuntimeConfig(p).Apply(q)
+	if !q.IsValidateOnly() {
+		t.Error("the snapshot did not keep validate-only")
+	}
+}
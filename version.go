@@ -0,0 +1,81 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// RuntimeVersion is the version of ANTLR that this runtime implements.
+const RuntimeVersion = "4.13.1"
+
+// oldestSupportedVersion is the oldest version of the tool whose generated code this runtime supports. Code
+// generated by earlier versions uses a different module path and throws its errors as panics.
+const oldestSupportedVersion = "4.13.0"
+
+// ErrIncompatibleVersion is the error, possibly wrapped, returned by [CheckVersion] for generated code that this
+// runtime does not support.
+var ErrIncompatibleVersion = errors.New("incompatible ANTLR version")
+
+// CheckVersion checks that the code generated by the given version of the ANTLR tool, such as "4.13.1", can be
+// used with this runtime. It returns nil if it can, and otherwise an error wrapping [ErrIncompatibleVersion]
+// that names the versions this runtime supports, so that a mismatch is reported clearly rather than as a
+// failure to deserialize the ATN of the grammar, or as a missing method.
+//
+// The runtime supports code generated by the tool of its own major and minor version, from the first release
+// that generated code for this module, whatever the patch release; code generated by a later minor version may
+// use parts of the runtime that this version does not have. A suffix of the version, such as "-SNAPSHOT", is
+// ignored.
+//
+// Use, in generated code:
+//
+//	func init() {
+//	    if err := antlr.CheckVersion("4.13.1"); err != nil {
+//	        panic(err)
+//	    }
+//	}
+func CheckVersion(generatedWith string) error {
+	generated, ok := parseVersion(generatedWith)
+	if !ok {
+		return fmt.Errorf("%w: %q is not an ANTLR version; runtime %s supports code generated by ANTLR %s", ErrIncompatibleVersion,
+			generatedWith, RuntimeVersion, supportedVersions())
+	}
+	runtime, _ := parseVersion(RuntimeVersion)
+	oldest, _ := parseVersion(oldestSupportedVersion)
+	if slices.Compare(generated[:], oldest[:]) < 0 || generated[0] != runtime[0] || generated[1] != runtime[1] {
+		return fmt.Errorf("%w: code generated by ANTLR %s cannot be used with runtime %s, which supports code generated by ANTLR %s",
+			ErrIncompatibleVersion, generatedWith, RuntimeVersion, supportedVersions())
+	}
+	return nil
+}
+
+// supportedVersions describes the range of versions of the tool whose generated code the runtime supports.
+func supportedVersions() string {
+	runtime, _ := parseVersion(RuntimeVersion)
+	return fmt.Sprintf("%s to %d.%d.x", oldestSupportedVersion, runtime[0], runtime[1])
+}
+
+// parseVersion parses a version of the form major.minor or major.minor.patch, with an optional suffix
+// beginning with '-', and returns false if it is not of that form.
+func parseVersion(version string) ([3]int, bool) {
+	var v [3]int
+	version, _, _ = strings.Cut(version, "-")
+	parts := strings.Split(version, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return v, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		ok      bool
+	}{
+		{RuntimeVersion, true},
+		{"4.13.0", true},
+		{"4.13", true},
+		{"4.13.9", true},
+		{"4.13.2-SNAPSHOT", true},
+		{"4.12.0", false},
+		{"4.14.0", false},
+		{"5.13.1", false},
+		{"4", false},
+		{"4.13.1.1", false},
+		{"4.x.1", false},
+		{"4.-13.1", false},
+		{"", false},
+	}
+	for _, test := range tests {
+		err := CheckVersion(test.version)
+		if (err == nil) != test.ok || err != nil && !errors.Is(err, ErrIncompatibleVersion) {
+			t.Errorf("CheckVersion(%q) = %v", test.version, err)
+		}
+		if err != nil && !strings.Contains(err.Error(), "4.13.0 to 4.13.x") {
+			t.Errorf("CheckVersion(%q) does not name the versions supported: %v", test.version, err)
+		}
+	}
+}
+
+func TestDeserializeUnsupportedVersion(t *testing.T) {
+	serialized := slices.Clone(listParserSerialized)
+	serialized[0] = 3
+	defer func() {
+		if r, _ := recover().(string); !strings.Contains(r, "version 3 (expected 4)") || !strings.Contains(r, "4.13.0 to 4.13.x") {
+			t.Errorf("Deserialize() of an old ATN panicked with %q", r)
+		}
+	}()
+	NewATNDeserializer(nil).Deserialize(serialized)
+}
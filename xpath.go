@@ -0,0 +1,286 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// XPath selects nodes of a parse tree by their path from the root, as the XPath of the other ANTLR runtimes
+// does. A path is a sequence of elements, each preceded by a separator:
+//
+//	/       the element must be a child of a node selected by the path so far, or the root
+//	//      the element may be any descendant of a node selected by the path so far, including the node
+//	        itself
+//
+// and each element names the nodes it selects:
+//
+//	expr    rule contexts of the rule with the given name, which starts with a lower case letter
+//	ID      terminal nodes of the token type with the given symbolic name, which starts with an upper
+//	        case letter
+//	'+'     terminal nodes of the token type with the given literal name
+//	*       any node
+//	!expr   rule contexts other than those of the rule, after either separator, and likewise !ID terminal
+//	        nodes other than those of the token type; !* selects nothing
+//
+// The separator before the first element may be left out, in which case it is /. So, for instance:
+//
+//	//ID               all ID tokens in the tree
+//	/prog/func         all func rule contexts that are children of the prog root
+//	//func/body//ID    all ID tokens anywhere within the bodies of functions
+//	/prog/*            all children of the prog root
+//	//stat/!ID         all children of any stat rule context other than ID tokens
+//
+// An XPath is compiled once with [NewXPath], and may then be evaluated against any number of trees.
+//
+// Use:
+//
+//	path, err := antlr.NewXPath(p, "//selectStatement/columnList/*")
+//	if err != nil {
+//	    return err
+//	}
+//	for _, column := range path.Evaluate(tree) {
+//	    fmt.Println(column.GetText())
+//	}
+type XPath struct {
+	path     string
+	elements []xpathElement
+}
+
+// xpathElementKind is the kind of nodes an element of an XPath selects.
+type xpathElementKind int
+
+const (
+	xpathRuleElement xpathElementKind = iota
+	xpathTokenElement
+	xpathWildcardElement
+)
+
+// xpathElement is one element of an [XPath], with the separator before it.
+type xpathElement struct {
+	kind     xpathElementKind
+	index    int // rule index or token type
+	anywhere bool
+	invert   bool
+}
+
+// xpathTokenKind is the kind of a token of the text of an XPath.
+type xpathTokenKind int
+
+const (
+	xpathTokenRoot     xpathTokenKind = iota // /
+	xpathTokenAnywhere                       // //
+	xpathTokenBang                           // !
+	xpathTokenWildcard                       // *
+	xpathTokenRuleRef                        // a name starting with a lower case letter
+	xpathTokenTokenRef                       // a name starting with an upper case letter
+	xpathTokenString                         // a quoted literal name
+	xpathTokenEOF
+)
+
+// xpathToken is a token of the text of an XPath.
+type xpathToken struct {
+	kind  xpathTokenKind
+	text  string
+	start int
+}
+
+// NewXPath compiles the path, whose rule and token names are looked up in the vocabulary of parser. It
+// returns an error if the path is not well formed, or names a rule or token type the parser does not have.
+func NewXPath(parser Recognizer, path string) (*XPath, error) {
+	tokens, err := xpathTokenize(path)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenTypes := tokenTypeMap(parser.GetLiteralNames(), parser.GetSymbolicNames())
+	ruleIndexes := ruleIndexMap(parser.GetRuleNames())
+
+	x := &XPath{path: path}
+	for i := 0; tokens[i].kind != xpathTokenEOF; i++ {
+		t := tokens[i]
+		anywhere, invert := false, false
+		switch t.kind {
+		case xpathTokenRoot, xpathTokenAnywhere:
+			anywhere = t.kind == xpathTokenAnywhere
+			i++
+			if tokens[i].kind == xpathTokenBang {
+				invert = true
+				i++
+			}
+		case xpathTokenRuleRef, xpathTokenTokenRef, xpathTokenWildcard:
+		default:
+			return nil, fmt.Errorf("unknown path element %q at index %d in path %q", t.text, t.start, path)
+		}
+
+		element, err := xpathNewElement(tokens[i], anywhere, invert, tokenTypes, ruleIndexes)
+		if err != nil {
+			return nil, fmt.Errorf("%v in path %q", err, path)
+		}
+		x.elements = append(x.elements, element)
+	}
+	return x, nil
+}
+
+// XPathFind returns the nodes of tree selected by path, whose rule and token names are looked up in the
+// vocabulary of parser, in the order of a depth-first walk of the tree. It compiles the path each time it is
+// called, see [NewXPath] for a path used more than once.
+//
+// Use:
+//
+//	columns, err := antlr.XPathFind(tree, "//selectStatement/columnList/*", p)
+func XPathFind(tree ParseTree, path string, parser Recognizer) ([]ParseTree, error) {
+	x, err := NewXPath(parser, path)
+	if err != nil {
+		return nil, err
+	}
+	return x.Evaluate(tree), nil
+}
+
+// Evaluate returns the nodes of tree selected by the path, each once, in the order of a depth-first walk of
+// the tree.
+func (x *XPath) Evaluate(tree ParseTree) []ParseTree {
+	// The first element selects among the children of a root above the tree, whose only child is the tree,
+	// and which is not itself a child of it.
+	root := NewBaseParserRuleContext(nil, -1)
+	root.children = []Tree{tree}
+
+	work := []ParseTree{root}
+	for _, element := range x.elements {
+		var next []ParseTree
+		seen := make(map[ParseTree]bool)
+		for _, node := range work {
+			if node.GetChildCount() == 0 {
+				continue
+			}
+			for _, match := range element.evaluate(node) {
+				if match != ParseTree(root) && !seen[match] {
+					seen[match] = true
+					next = append(next, match)
+				}
+			}
+		}
+		work = next
+	}
+	return work
+}
+
+// String returns the text of the path.
+func (x *XPath) String() string {
+	return x.path
+}
+
+// evaluate returns the nodes the element selects from t: its children or, for an element anywhere, its
+// descendants, that the element matches.
+func (e xpathElement) evaluate(t ParseTree) []ParseTree {
+	var nodes []ParseTree
+	if e.anywhere {
+		for _, d := range TreesDescendants(t) {
+			if e.matches(d) {
+				nodes = append(nodes, d)
+			}
+		}
+		return nodes
+	}
+
+	for i := 0; i < t.GetChildCount(); i++ {
+		if c, ok := t.GetChild(i).(ParseTree); ok && e.matches(c) {
+			nodes = append(nodes, c)
+		}
+	}
+	return nodes
+}
+
+// matches returns true if the element matches node n, or for an inverted element, if it does not.
+func (e xpathElement) matches(n ParseTree) bool {
+	switch e.kind {
+	case xpathRuleElement:
+		r, ok := n.(ParserRuleContext)
+		return ok && (r.GetRuleIndex() == e.index) != e.invert
+	case xpathTokenElement:
+		t, ok := n.(TerminalNode)
+		return ok && (t.GetSymbol().GetTokenType() == e.index) != e.invert
+	default:
+		return !e.invert
+	}
+}
+
+// xpathNewElement returns the element for the word token t.
+func xpathNewElement(t xpathToken, anywhere, invert bool, tokenTypes, ruleIndexes map[string]int) (xpathElement, error) {
+	e := xpathElement{anywhere: anywhere, invert: invert}
+	switch t.kind {
+	case xpathTokenWildcard:
+		e.kind = xpathWildcardElement
+	case xpathTokenTokenRef, xpathTokenString:
+		ttype, ok := tokenTypes[t.text]
+		if !ok {
+			return e, fmt.Errorf("%s at index %d isn't a valid token name", t.text, t.start)
+		}
+		e.kind, e.index = xpathTokenElement, ttype
+	case xpathTokenRuleRef:
+		ruleIndex, ok := ruleIndexes[t.text]
+		if !ok {
+			return e, fmt.Errorf("%s at index %d isn't a valid rule name", t.text, t.start)
+		}
+		e.kind, e.index = xpathRuleElement, ruleIndex
+	case xpathTokenEOF:
+		return e, fmt.Errorf("missing path element at end")
+	default:
+		return e, fmt.Errorf("expected a path element but found %q at index %d", t.text, t.start)
+	}
+	return e, nil
+}
+
+// xpathTokenize splits the text of a path into tokens, ending with an EOF token.
+func xpathTokenize(path string) ([]xpathToken, error) {
+	var tokens []xpathToken
+	runes := []rune(path)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case r == '/':
+			i++
+			kind := xpathTokenRoot
+			if i < len(runes) && runes[i] == '/' {
+				i++
+				kind = xpathTokenAnywhere
+			}
+			tokens = append(tokens, xpathToken{kind, string(runes[start:i]), start})
+		case r == '!':
+			i++
+			tokens = append(tokens, xpathToken{xpathTokenBang, "!", start})
+		case r == '*':
+			i++
+			tokens = append(tokens, xpathToken{xpathTokenWildcard, "*", start})
+		case r == '\'':
+			i++
+			for i < len(runes) && runes[i] != '\'' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated literal name at index %d in path %q", start, path)
+			}
+			i++
+			tokens = append(tokens, xpathToken{xpathTokenString, string(runes[start:i]), start})
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			kind := xpathTokenRuleRef
+			if unicode.IsUpper(r) {
+				kind = xpathTokenTokenRef
+			}
+			tokens = append(tokens, xpathToken{kind, string(runes[start:i]), start})
+		default:
+			return nil, fmt.Errorf("invalid character %q at index %d in path %q", r, start, path)
+		}
+	}
+	return append(tokens, xpathToken{kind: xpathTokenEOF, text: "<EOF>", start: len(runes)}), nil
+}
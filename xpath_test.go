@@ -0,0 +1,75 @@
+// Copyright (c) 2012-2022 The ANTLR Project. All rights reserved.
+// Use of this file is governed by the BSD 3-clause license that
+// can be found in the LICENSE.txt file in the project root.
+
+package antlr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestXPath(t *testing.T) {
+	p, tree := listParse("a b + c d")
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"//ID", "a b c d"},
+		{"/s/item", "a b+c d"},
+		{"s/item", "a b+c d"},
+		{"/s/item/'+'", "+"},
+		{"/s/*", "a b+c d <EOF>"},
+		// an inverted rule name selects only rule nodes, and an inverted token name only tokens
+		{"/s/!item", ""},
+		{"//item/!ID", "+"},
+		{"//item//!ID", "+"},
+		{"//!item", "ab+cd<EOF>"},
+		{"//!ID", "+ <EOF>"},
+		{"//*", "ab+cd<EOF> a a b+c b + c d d <EOF>"},
+		{"//!*", ""},
+		{"/item", ""},
+		// the descendants of a node include the node
+		{"//item//item", "a b+c d"},
+		// a node selected through several others is selected once
+		{"//*//ID", "a b c d"},
+	}
+	for _, test := range tests {
+		path, err := NewXPath(p, test.path)
+		if err != nil {
+			t.Errorf("%s: %v", test.path, err)
+			continue
+		}
+		var texts []string
+		for _, node := range path.Evaluate(tree) {
+			texts = append(texts, node.GetText())
+		}
+		if got := strings.Join(texts, " "); got != test.want || path.String() != test.path {
+			t.Errorf("%s: %q, want %q", test.path, got, test.want)
+		}
+	}
+
+	for path, want := range map[string]string{
+		"":         "",
+		"//":       `missing path element at end in path "//"`,
+		"/s/":      `missing path element at end in path "/s/"`,
+		"//Foo":    `Foo at index 2 isn't a valid token name in path "//Foo"`,
+		"//foo":    `foo at index 2 isn't a valid rule name in path "//foo"`,
+		"//'-'":    `'-' at index 2 isn't a valid token name in path "//'-'"`,
+		"/s/'+":    `unterminated literal name at index 3 in path "/s/'+"`,
+		"/s@":      `invalid character '@' at index 2 in path "/s@"`,
+		"!ID":      `unknown path element "!" at index 0 in path "!ID"`,
+		"/s//!/ID": `expected a path element but found "/" at index 5 in path "/s//!/ID"`,
+	} {
+		if _, err := NewXPath(p, path); (err == nil) != (want == "") || err != nil && err.Error() != want {
+			t.Errorf("%q: %v, want %s", path, err, want)
+		}
+	}
+
+	if nodes, err := XPathFind(tree, "//'+'", p); err != nil || len(nodes) != 1 {
+		t.Errorf("XPathFind() = %v, %v", nodes, err)
+	}
+	if _, err := XPathFind(tree, "//+", p); err == nil {
+		t.Error("XPathFind() of an invalid path did not fail")
+	}
+}